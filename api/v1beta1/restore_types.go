@@ -39,6 +39,22 @@ const (
 	RestorePhaseUnknown = "Unknown"
 	// RestorePhaseEnabled means the restore is enabled and will continue syncing with new backups
 	RestorePhaseEnabled = "Enabled"
+	// RestorePhaseWaiting means the restore is waiting for the restores it depends on to finish
+	RestorePhaseWaiting = "Waiting"
+	// RestorePhaseHeld means the restore is on hold, no new velero restores are created
+	// and the managed clusters are not activated until the hold is released
+	RestorePhaseHeld = "Held"
+)
+
+// ActivationPhase contains the phase of the managed clusters activation, run after
+// the managed clusters restore completes
+type ActivationPhase string
+
+const (
+	// ActivationPhaseInProgress means the managed clusters activation started and is not yet completed
+	ActivationPhaseInProgress = "InProgress"
+	// ActivationPhaseCompleted means the managed clusters activation completed
+	ActivationPhaseCompleted = "Completed"
 )
 
 type CleanupType string
@@ -95,6 +111,68 @@ type RestoreSpec struct {
 	// When SyncRestoreWithNewBackups is set to true, defines the duration for checking on new backups
 	// If not defined and SyncRestoreWithNewBackups is set to true, it defaults to 30minutes
 	RestoreSyncInterval metav1.Duration `json:"restoreSyncInterval,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// CleanupConcurrency defines how many resources are deleted in parallel
+	// when CleanupBeforeRestore removes resources from the hub.
+	// If not defined, or set to a value lower than 1, resources are deleted one at a time.
+	CleanupConcurrency int `json:"cleanupConcurrency,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// CleanupCreatedBefore is a cutoff time for the resources cleaned up by the CleanupBeforeRestore option.
+	// When set, resources created after this time are not deleted, for example to keep
+	// the changes made on the hub after the last good backup was created.
+	// If not defined, resources are cleaned up regardless of their creation time.
+	CleanupCreatedBefore *metav1.Time `json:"cleanupCreatedBefore,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the CleanupBeforeRestore option to delete only the resources from
+	// the namespaces stored by the restored backup. Resources from the namespaces the backup doesn't know
+	// about are not deleted, for example the workloads created on this hub which were never backed up.
+	// If not defined, the value is set to false.
+	CleanupScopeToBackup bool `json:"cleanupScopeToBackup,omitempty"`
+	// +kubebuilder:validation:Optional
+	// CleanupResourceOrder sets the order in which the CleanupBeforeRestore option deletes the resources
+	// of each backup, as a list of resource kinds, for example placementbinding or
+	// placementbinding.policy.open-cluster-management.io. The kinds listed before the "*" entry are
+	// deleted first and the kinds listed after it are deleted last, in the listed order; if there is no
+	// "*" entry, all listed kinds are deleted first. The other kinds are deleted children before parents:
+	// namespaced resources first, then the known parent kinds, such as channels and placements,
+	// cluster scoped resources and namespaces last.
+	CleanupResourceOrder []string `json:"cleanupResourceOrder,omitempty"`
+	// +kubebuilder:validation:Optional
+	// CleanupForceDeleteKinds lists the resource kinds deleted by the CleanupAll cleanup even if they
+	// have the velero.io/exclude-from-backup=true label, for example placement or
+	// placement.cluster.open-cluster-management.io. Use this option with caution: the resources of these
+	// kinds are deleted even if they were excluded from the backups on purpose, and they are not
+	// restored if the restored backup doesn't have them. The option is not used by the other cleanup types.
+	CleanupForceDeleteKinds []string `json:"cleanupForceDeleteKinds,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true to pause the BackupSchedule active in the restore namespace, so the restore
+	// can run on this hub. The BackupSchedule is paused by setting its spec.paused property to true,
+	// and the restore starts once the BackupSchedule is paused; it is not resumed after the restore.
+	// If not set, the restore is not executed while a BackupSchedule is active and the
+	// ActiveBackupScheduleDuringRestore condition is reported.
+	// If not defined, the value is set to false.
+	PauseActiveBackupSchedule bool `json:"pauseActiveBackupSchedule,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the GitOps controllers running on this hub, such as the ArgoCD
+	// application controller, to be scaled down while the restore runs, so they don't recreate
+	// the resources deleted by the CleanupBeforeRestore option. The controllers are scaled back
+	// to their previous number of replicas once the restore is completed, with or without errors.
+	// This option cannot be used together with the SyncRestoreWithNewBackups option.
+	// If not defined, the value is set to false.
+	PauseGitOpsDuringRestore bool `json:"pauseGitOpsDuringRestore,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true to restore a backup created on this hub, for a point-in-time recovery of the hub.
+	// The backups are restored first, then, once all velero restores completed successfully, the resources
+	// not restored from the backups are cleaned up as with the CleanupBeforeRestore option set to
+	// CleanupAll and the CleanupScopeToBackup option set to true, resources from the default, openshift,
+	// openshift-* and kube-* namespaces are never cleaned up, and the BackupSchedule active in the restore
+	// namespace is paused as with the PauseActiveBackupSchedule option. The restore fails if one of the
+	// restored backups is not completed. Can't be used with the SyncRestoreWithNewBackups or
+	// SandboxNamespacePrefix options, or with the CleanupBeforeRestore option set to CleanupRestored.
+	// If not defined, the value is set to false.
+	PointInTimeRecovery bool `json:"pointInTimeRecovery,omitempty"`
 
 	// velero option -  RestorePVs specifies whether to restore all included
 	// PVs from snapshot (via the cloudprovider).
@@ -113,6 +191,26 @@ type RestoreSpec struct {
 	// +nullable
 	PreserveNodePorts *bool `json:"preserveNodePorts,omitempty"`
 
+	// velero option - IncludeClusterResources specifies whether cluster-scoped resources
+	// are restored. If not defined, cluster-scoped resources are restored by all restores
+	// except the credentials restores. When set, the value is used for all restores.
+	// +optional
+	// +nullable
+	IncludeClusterResources *bool `json:"includeClusterResources,omitempty"`
+
+	// velero option - UploaderConfig specifies the configuration used by the uploader
+	// when restoring volume data, for example to write files sparsely.
+	// Set only on the resources restore.
+	// +optional
+	// +nullable
+	UploaderConfig *veleroapi.UploaderConfigForRestore `json:"uploaderConfig,omitempty"`
+
+	// velero option - ItemOperationTimeout specifies the time velero waits for the asynchronous
+	// RestoreItemAction operations of the resources restore to complete.
+	// Set only on the resources restore. If not defined, the velero default of 1 hour is used.
+	// +optional
+	ItemOperationTimeout metav1.Duration `json:"itemOperationTimeout,omitempty"`
+
 	// velero option -  Hooks represent custom behaviors that should be executed during or post restore.
 	// +optional
 	Hooks veleroapi.RestoreHooks `json:"hooks,omitempty"`
@@ -124,7 +222,8 @@ type RestoreSpec struct {
 	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
 
 	// velero option - ExcludedNamespaces contains a list of namespaces that are not
-	// included in the restore.
+	// included in the restore. A namespace cannot be set on both the IncludedNamespaces
+	// and ExcludedNamespaces lists.
 	// +optional
 	// +nullable
 	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
@@ -157,12 +256,300 @@ type RestoreSpec struct {
 	// +nullable
 	OrLabelSelectors []*metav1.LabelSelector `json:"orLabelSelectors,omitempty"`
 
+	// BackupLabelSelector is a metav1.LabelSelector used to select the backups restored when
+	// a backup name is set to latest. When set, only backups with labels matching the selector
+	// are used to find the latest backup, for example backups approved for a production restore.
+	// +optional
+	// +nullable
+	BackupLabelSelector *metav1.LabelSelector `json:"backupLabelSelector,omitempty"`
+
+	// ResourcesRestoreOrLabelSelectors is a list of metav1.LabelSelector used to filter
+	// the resources restored from the resources backup. A resource is restored if it
+	// matches any of the selectors. Set only on the velero resources restore, and
+	// cannot be used together with the LabelSelector or OrLabelSelectors options.
+	// +optional
+	// +nullable
+	ResourcesRestoreOrLabelSelectors []*metav1.LabelSelector `json:"resourcesRestoreOrLabelSelectors,omitempty"`
+
 	// velero option - NamespaceMapping is a map of source namespace names
 	// to target namespace names to restore into. Any source
 	// namespaces not included in the map will be restored into
 	// namespaces of the same name.
 	// +optional
 	NamespaceMapping map[string]string `json:"namespaceMapping,omitempty"`
+
+	// ClusterSetMapping maps a ManagedClusterSet name to a namespace prefix. The namespace of each
+	// managed cluster in this set, as found on this hub, is restored into <prefix>-<cluster namespace>.
+	// The expanded namespaces are added to the NamespaceMapping; NamespaceMapping entries take precedence.
+	// Cannot be used together with the SandboxNamespacePrefix option.
+	// +optional
+	ClusterSetMapping map[string]string `json:"clusterSetMapping,omitempty"`
+
+	// Set this to true if you want the resources restored by the velero restores to be labeled,
+	// when the restore completes, with cluster.open-cluster-management.io/restore=<restore name>,
+	// so they can be identified later, for example to roll back the restore.
+	// If not defined, the value is set to false.
+	// +optional
+	TagRestoredResources bool `json:"tagRestoredResources,omitempty"`
+
+	// ManagedClusterNamespaceLabels are labels applied, once the managed clusters restore completes,
+	// to the namespace of each managed cluster restored by this restore, for example to let GitOps tooling
+	// detect the restored clusters. The local cluster namespace is not labeled.
+	// +optional
+	ManagedClusterNamespaceLabels map[string]string `json:"managedClusterNamespaceLabels,omitempty"`
+
+	// StorageLocationPrefix is used when multiple hubs store their backups in the same bucket,
+	// using different path prefixes. When set, only the backups stored by the BackupStorageLocations
+	// with this object storage prefix are restored.
+	// +optional
+	StorageLocationPrefix string `json:"storageLocationPrefix,omitempty"`
+
+	// StorageLocation is the name of the BackupStorageLocation storing the backups to restore,
+	// for example a file system storage location used for an air-gapped restore.
+	// When set, only the backups stored by this storage location are restored and
+	// the restore waits for this storage location to be available.
+	// +optional
+	StorageLocation string `json:"storageLocation,omitempty"`
+
+	// Set this to true to restore the credentials first and create the other velero restores
+	// only after the credentials restore completes. If the credentials restore fails or partially fails,
+	// the restore is stopped and set to FinishedWithErrors.
+	// If not defined, the value is set to false.
+	// +optional
+	FailFast bool `json:"failFast,omitempty"`
+
+	// Set this to true to restore the resources backup in two steps: a first velero restore restores only
+	// the cluster scoped resources, and the namespaced resources are restored by a second velero restore,
+	// created after the first one completes. Use this option when namespaced resources reference cluster scoped
+	// resources which must exist before they are restored. If the cluster scoped restore fails,
+	// the restore is stopped and set to FinishedWithErrors. The option is not used when syncing with new backups.
+	// If not defined, the value is set to false.
+	// +optional
+	RestoreClusterScopedFirst bool `json:"restoreClusterScopedFirst,omitempty"`
+
+	// Set this to true to verify, after the managed clusters activation, that the activated managed clusters
+	// joined the hub and are available. The result is reported for each cluster under status.clusterJoinStatus;
+	// the restore is set to FinishedWithErrors if some clusters did not join within the verification grace period.
+	// If not defined, the value is set to false.
+	// +optional
+	VerifyClusterJoin bool `json:"verifyClusterJoin,omitempty"`
+
+	// AutoImportSecretName is the name of the secret created in a managed cluster namespace to import
+	// the managed cluster during the managed clusters activation, for customized import flows.
+	// If not defined, the value is set to auto-import-secret.
+	// +optional
+	AutoImportSecretName string `json:"autoImportSecretName,omitempty"`
+
+	// ActivationLabel is the label set to true on the secrets created during the managed clusters
+	// activation; an existing secret with the AutoImportSecretName name is replaced only if it has this label.
+	// If not defined, the value is set to cluster.open-cluster-management.io/restore-auto-import-secret.
+	// +optional
+	ActivationLabel string `json:"activationLabel,omitempty"`
+
+	// MinClustersAvailablePercent is the minimum percentage of managed clusters activated by the restore
+	// which must be available for the restore to be successful. When set, the Complete condition is set to true
+	// after the managed clusters activation only if this percentage of activated clusters joined the hub and
+	// is available; otherwise the Degraded condition is set to true. The availability is checked again
+	// periodically until the clusters are available or the verification grace period ends.
+	// If not defined, or set to 0, the availability of the activated clusters is not checked.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MinClustersAvailablePercent int `json:"minClustersAvailablePercent,omitempty"`
+
+	// Set this to true if you want to verify, before the restore starts, that the CRDs for
+	// the resources stored by the resources backup are installed on this cluster.
+	// Missing CRDs are reported under the MissingCRDs status and don't stop the restore.
+	// If not defined, the value is set to false.
+	// +optional
+	ValidateCRDs bool `json:"validateCRDs,omitempty"`
+
+	// Set this to true if you want the resources restore to skip, instead of failing on, the resources
+	// stored by the resources backup with no CRD installed on this cluster. The skipped resource types
+	// are excluded from the velero restore and reported under the SkippedMissingCRDResources status.
+	// If not defined, the value is set to false.
+	// +optional
+	SkipMissingCRDResources bool `json:"skipMissingCRDResources,omitempty"`
+
+	// Set this to true if you want the restore to emit RestoreProgress events summarizing the restore
+	// phase, the percentage of velero restores completed and the number of activated managed clusters.
+	// An event is emitted only when the progress changes; progress changes within the same phase
+	// are reported at most once a minute.
+	// If not defined, the value is set to false.
+	// +optional
+	EmitProgressEvents bool `json:"emitProgressEvents,omitempty"`
+
+	// PostRestoreJob defines a Job to run after the restore completes, for example
+	// a smoke test validating the restored hub.
+	// +optional
+	// +nullable
+	PostRestoreJob *PostRestoreJobSpec `json:"postRestoreJob,omitempty"`
+
+	// SecretTransform defines a transform applied to the credential secrets
+	// after the credentials restore completes, for example to re-encrypt the
+	// secret data using an external KMS key.
+	// +optional
+	// +nullable
+	SecretTransform *SecretTransformSpec `json:"secretTransform,omitempty"`
+
+	// BackupManifest lists the exact velero backups restored for each backup type.
+	// A backup name set in the manifest is used instead of the VeleroManagedClustersBackupName,
+	// VeleroCredentialsBackupName or VeleroResourcesBackupName value, and the restore fails
+	// if this backup is not found. These properties still define which backup types are restored.
+	// +optional
+	// +nullable
+	BackupManifest *BackupManifest `json:"backupManifest,omitempty"`
+
+	// DependsOn is a list of names of other Restore resources in this namespace
+	// which must finish successfully before this restore starts, for example
+	// to restore the infrastructure resources before the applications.
+	// The restore fails if a dependency cycle is found or if one of these restores
+	// does not finish successfully.
+	// +optional
+	// +nullable
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Set this to true to hold an in progress restore, for example to manually fix
+	// resources before the managed clusters are activated. While on hold, no new velero
+	// restores are created and the post restore tasks are not executed; the velero restores
+	// already created continue to run. Set it back to false to continue the restore.
+	// If not defined, the value is set to false.
+	// +optional
+	Hold bool `json:"hold,omitempty"`
+
+	// SandboxNamespacePrefix is used to restore the namespaced resources from the backups into
+	// new namespaces named <prefix>-<original namespace>, for example to inspect the content of
+	// a backup without changing the hub resources. When set, cluster scoped resources are not restored,
+	// CleanupBeforeRestore must be set to None, VeleroManagedClustersBackupName must be set to skip
+	// and the NamespaceMapping option cannot be used.
+	// +kubebuilder:validation:MaxLength=40
+	// +optional
+	SandboxNamespacePrefix string `json:"sandboxNamespacePrefix,omitempty"`
+
+	// Set this to true to run this restore as a validation restore, testing that the backups can be restored
+	// without changing the hub resources. The resources are restored into the sandbox namespaces, so this option
+	// requires the SandboxNamespacePrefix option. When the restore completes, the number of restored items,
+	// warnings and errors is reported under status.validationRestoreResult.
+	// If not defined, the value is set to false.
+	// +optional
+	ValidationRestore bool `json:"validationRestore,omitempty"`
+
+	// Set this to true to delete the sandbox namespaces after a validation restore completes.
+	// Used only when the ValidationRestore option is enabled.
+	// If not defined, the value is set to false.
+	// +optional
+	CleanupValidationSandbox bool `json:"cleanupValidationSandbox,omitempty"`
+
+	// SingleClusterRestore is the name of a managed cluster to restore on its own. When set, the credentials,
+	// resources and managed clusters restores are scoped to this managed cluster namespace; the managed
+	// clusters restore also restores the ManagedCluster resource with the name label set to this value
+	// and the cluster scoped resources with no name label. The IncludedNamespaces, NamespaceMapping,
+	// ClusterSetMapping, LabelSelector, OrLabelSelectors and SandboxNamespacePrefix options
+	// cannot be used with this option.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	SingleClusterRestore string `json:"singleClusterRestore,omitempty"`
+
+	// SinceBackupName is the name of an older velero resources backup. When set, the resources restore
+	// restores only the resource types with items added or changed in the restored resources backup
+	// since this older backup. The delta is computed from the content of the two backups, read using
+	// velero DownloadRequests; the resources restore is skipped if no item was added or changed.
+	// The restore fails if this backup is not found or is not older than the restored resources backup,
+	// and the IncludedResources option cannot be used with this option.
+	// +optional
+	SinceBackupName string `json:"sinceBackupName,omitempty"`
+}
+
+// BackupManifest defines the velero backups restored for each backup type
+type BackupManifest struct {
+	// ManagedClustersBackupName is the name of the velero backup used to restore managed clusters.
+	// +optional
+	ManagedClustersBackupName string `json:"managedClustersBackupName,omitempty"`
+	// CredentialsBackupName is the name of the velero backup used to restore credentials.
+	// +optional
+	CredentialsBackupName string `json:"credentialsBackupName,omitempty"`
+	// ResourcesBackupName is the name of the velero backup used to restore resources.
+	// +optional
+	ResourcesBackupName string `json:"resourcesBackupName,omitempty"`
+	// ResourcesGenericBackupName is the name of the velero backup used to restore generic resources.
+	// +optional
+	ResourcesGenericBackupName string `json:"resourcesGenericBackupName,omitempty"`
+	// Set this to true if a backup name must be set in the manifest for each restored backup type.
+	// The restore fails if a backup name is missing, instead of selecting the backup
+	// using the VeleroManagedClustersBackupName, VeleroCredentialsBackupName or VeleroResourcesBackupName value.
+	// If not defined, the value is set to false.
+	// +optional
+	RequireAllBackups bool `json:"requireAllBackups,omitempty"`
+}
+
+// SecretTransformSpec defines the transform applied to the restored credential secrets
+type SecretTransformSpec struct {
+	// Name of the secret transform. The None transform leaves the secrets unchanged.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// KeyRef is a reference to the key used by the transform, for example a KMS key id
+	// +optional
+	KeyRef string `json:"keyRef,omitempty"`
+}
+
+// SecretTransformNone is the secret transform leaving the restored secrets unchanged
+const SecretTransformNone = "None"
+
+// PostRestoreJobSpec defines the Job created after the restore completes
+type PostRestoreJobSpec struct {
+	// Image is the container image used by the Job
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+	// Command is the entrypoint array used by the Job container.
+	// If not defined, the image entrypoint is used.
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// ServiceAccountName is the name of the ServiceAccount used to run the Job.
+	// If not defined, the default service account is used.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Set this to true if you want the restore to be set to FinishedWithErrors when the Job fails.
+	// If not defined, the value is set to false and the Job result is only reported on the status.
+	// +optional
+	FailRestoreOnJobFailure bool `json:"failRestoreOnJobFailure,omitempty"`
+}
+
+// PostRestoreJobPhase shows the phase of the post restore Job
+type PostRestoreJobPhase string
+
+const (
+	// PostRestoreJobPhaseRunning means the post restore Job was created and is running
+	PostRestoreJobPhaseRunning PostRestoreJobPhase = "Running"
+	// PostRestoreJobPhaseSucceeded means the post restore Job completed successfully
+	PostRestoreJobPhaseSucceeded PostRestoreJobPhase = "Succeeded"
+	// PostRestoreJobPhaseFailed means the post restore Job failed
+	PostRestoreJobPhaseFailed PostRestoreJobPhase = "Failed"
+)
+
+// PostRestoreJobStatus defines the observed state of the post restore Job
+type PostRestoreJobStatus struct {
+	// JobName is the name of the Job created after the restore completed
+	// +kubebuilder:validation:Optional
+	JobName string `json:"jobName,omitempty"`
+	// Phase is the current phase of the post restore Job
+	// +kubebuilder:validation:Optional
+	Phase PostRestoreJobPhase `json:"phase,omitempty"`
+	// Message on the last Job operation
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterJoinStatus reports if a managed cluster activated by the restore joined the hub
+type ClusterJoinStatus struct {
+	// Name of the managed cluster
+	// +kubebuilder:validation:Optional
+	Name string `json:"name"`
+	// Joined is true when the ManagedClusterJoined condition of the managed cluster is true
+	// +kubebuilder:validation:Optional
+	Joined bool `json:"joined"`
+	// Available is true when the ManagedClusterConditionAvailable condition of the managed cluster is true
+	// +kubebuilder:validation:Optional
+	Available bool `json:"available"`
 }
 
 // RestoreStatus defines the observed state of Restore
@@ -171,8 +558,16 @@ type RestoreStatus struct {
 	VeleroManagedClustersRestoreName string `json:"veleroManagedClustersRestoreName,omitempty"`
 	// +kubebuilder:validation:Optional
 	VeleroResourcesRestoreName string `json:"veleroResourcesRestoreName,omitempty"`
+	// VeleroClusterScopedResourcesRestoreName is the name of the velero restore created
+	// by the RestoreClusterScopedFirst option to restore the cluster scoped resources
+	// +kubebuilder:validation:Optional
+	VeleroClusterScopedResourcesRestoreName string `json:"veleroClusterScopedResourcesRestoreName,omitempty"`
 	// +kubebuilder:validation:Optional
 	VeleroGenericResourcesRestoreName string `json:"veleroGenericResourcesRestoreName,omitempty"`
+	// VeleroConfigMapsRestoreName is the name of the velero restore created for the configmaps backup,
+	// storing the ConfigMaps selected by the BackupSchedule IncludeConfigMapSelector option
+	// +kubebuilder:validation:Optional
+	VeleroConfigMapsRestoreName string `json:"veleroConfigMapsRestoreName,omitempty"`
 	// +kubebuilder:validation:Optional
 	VeleroCredentialsRestoreName string `json:"veleroCredentialsRestoreName,omitempty"`
 	// Phase is the current phase of the restore
@@ -185,10 +580,145 @@ type RestoreStatus struct {
 	// +optional
 	// +nullable
 	Messages []string `json:"messages,omitempty"`
+	// ActivationPhase is the phase of the managed clusters activation, run after the
+	// managed clusters restore completes. Used to resume the activation after an operator restart.
+	// +optional
+	ActivationPhase ActivationPhase `json:"activationPhase,omitempty"`
+	// ActivatedClusters lists the managed clusters for which an auto-import-secret was created
+	// by the managed clusters activation
+	// +optional
+	// +nullable
+	ActivatedClusters []string `json:"activatedClusters,omitempty"`
 	// CompletionTimestamp records the time the restore operation was completed.
 	// +optional
 	// +nullable
 	CompletionTimestamp *metav1.Time `json:"completionTimestamp,omitempty"`
+	// StartTimestamp is the earliest start time of the velero restores created by this restore
+	// +optional
+	// +nullable
+	StartTimestamp *metav1.Time `json:"startTimestamp,omitempty"`
+	// VeleroCompletionTimestamp is the latest completion time of the velero restores created by this restore,
+	// set when all these velero restores are completed
+	// +optional
+	// +nullable
+	VeleroCompletionTimestamp *metav1.Time `json:"veleroCompletionTimestamp,omitempty"`
+	// PostRestoreJobStatus reports the status of the Job defined by the PostRestoreJob property
+	// +optional
+	// +nullable
+	PostRestoreJobStatus *PostRestoreJobStatus `json:"postRestoreJobStatus,omitempty"`
+	// LastForceReconcile is the value of the cluster.open-cluster-management.io/force-reconcile
+	// annotation processed by the last forced reconcile
+	// +optional
+	LastForceReconcile string `json:"lastForceReconcile,omitempty"`
+	// PhaseTransitions records the latest phase changes of the restore, oldest first
+	// +optional
+	// +nullable
+	PhaseTransitions []PhaseTransition `json:"phaseTransitions,omitempty"`
+	// MissingCRDs lists the resources stored by the resources backup with no CRD installed
+	// on this cluster. Set only when the ValidateCRDs option is enabled.
+	// +optional
+	// +nullable
+	MissingCRDs []string `json:"missingCRDs,omitempty"`
+	// ItemOperationsInProgress is the number of asynchronous item operations started by the
+	// velero restores which did not complete yet. The restore stays in the Running phase
+	// until these operations complete.
+	// +optional
+	ItemOperationsInProgress int `json:"itemOperationsInProgress,omitempty"`
+	// SkippedMissingCRDResources lists the resources stored by the resources backup which were
+	// not restored because their CRD is not installed on this cluster.
+	// Set only when the SkipMissingCRDResources option is enabled.
+	// +optional
+	// +nullable
+	SkippedMissingCRDResources []string `json:"skippedMissingCRDResources,omitempty"`
+	// SkippedResourceTypes lists the resource types with the backup name set to skip,
+	// which are intentionally not restored
+	// +optional
+	// +nullable
+	SkippedResourceTypes []string `json:"skippedResourceTypes,omitempty"`
+	// InvalidImportTokens lists the managed clusters not imported during the activation
+	// because the auto-import secret token could not be used; these clusters must be imported manually
+	// +optional
+	// +nullable
+	InvalidImportTokens []string `json:"invalidImportTokens,omitempty"`
+	// ClusterJoinStatus reports, for each managed cluster activated by the restore, if the cluster
+	// joined the hub. Set only when the VerifyClusterJoin option is enabled.
+	// +optional
+	// +nullable
+	ClusterJoinStatus []ClusterJoinStatus `json:"clusterJoinStatus,omitempty"`
+	// LastProgressEvent is the restore progress reported by the last RestoreProgress event.
+	// Set only when the EmitProgressEvents option is enabled.
+	// +optional
+	// +nullable
+	LastProgressEvent *RestoreProgress `json:"lastProgressEvent,omitempty"`
+	// ValidationRestoreResult reports the result of a validation restore.
+	// Set only when the ValidationRestore option is enabled and the restore completed.
+	// +optional
+	// +nullable
+	ValidationRestoreResult *ValidationRestoreResult `json:"validationRestoreResult,omitempty"`
+	// FailedItemsByKind is the number of items which failed to restore for each resource kind,
+	// read from the results of the PartiallyFailed velero restores.
+	// Set only when a velero restore created by this restore is PartiallyFailed.
+	// +optional
+	FailedItemsByKind map[string]int `json:"failedItemsByKind,omitempty"`
+	// Conditions reports the latest observations of the restore
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// RestoreProgress summarizes the progress of a restore
+type RestoreProgress struct {
+	// Phase is the restore phase
+	// +kubebuilder:validation:Optional
+	Phase RestorePhase `json:"phase"`
+	// PercentComplete is the percentage of velero restores created by this restore which completed
+	// +kubebuilder:validation:Optional
+	PercentComplete int `json:"percentComplete"`
+	// ActivatedClusters is the number of managed clusters activated by the restore
+	// +kubebuilder:validation:Optional
+	ActivatedClusters int `json:"activatedClusters"`
+	// Timestamp is the time when the progress was reported
+	// +kubebuilder:validation:Optional
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// ValidationRestoreResult reports the result of a validation restore
+type ValidationRestoreResult struct {
+	// Succeeded is true if the restore finished with no velero restore errors
+	// +kubebuilder:validation:Optional
+	Succeeded bool `json:"succeeded"`
+	// TotalItems is the number of items to restore, for all velero restores
+	// +kubebuilder:validation:Optional
+	TotalItems int `json:"totalItems"`
+	// ItemsRestored is the number of items restored, for all velero restores
+	// +kubebuilder:validation:Optional
+	ItemsRestored int `json:"itemsRestored"`
+	// Warnings is the number of warnings reported by the velero restores
+	// +kubebuilder:validation:Optional
+	Warnings int `json:"warnings"`
+	// Errors is the number of errors reported by the velero restores
+	// +kubebuilder:validation:Optional
+	Errors int `json:"errors"`
+	// SandboxNamespaces lists the sandbox namespaces used by the velero restores
+	// +optional
+	SandboxNamespaces []string `json:"sandboxNamespaces,omitempty"`
+	// SandboxCleanedUp is true if the sandbox namespaces were deleted after the restore completed
+	// +kubebuilder:validation:Optional
+	SandboxCleanedUp bool `json:"sandboxCleanedUp"`
+}
+
+// PhaseTransition records a change of the restore phase
+type PhaseTransition struct {
+	// Phase is the restore phase set by this transition
+	// +kubebuilder:validation:Optional
+	Phase RestorePhase `json:"phase"`
+	// Timestamp is the time when the phase was set
+	// +kubebuilder:validation:Optional
+	Timestamp metav1.Time `json:"timestamp"`
+	// Message on the phase change
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -215,14 +745,55 @@ type Restore struct {
 const (
 	// RestoreComplete means the restore runs to completion
 	RestoreComplete = "Complete"
+	// RestoreClockSkewDetected is true when a restored backup was started in the future,
+	// relative to the current time of this cluster
+	RestoreClockSkewDetected = "ClockSkewDetected"
+	// RestoreManagedClusterNamespaceLabelMissing is true when a managed cluster namespace
+	// doesn't have the OCM managed cluster label
+	RestoreManagedClusterNamespaceLabelMissing = "ManagedClusterNamespaceLabelMissing"
+	// RestorePluginMismatch is true when a restored backup was created with velero plugins
+	// not installed on this cluster
+	RestorePluginMismatch = "RestorePluginMismatch"
+	// RestoreFailedFast is true when the restore was stopped by the FailFast option,
+	// after a prerequisite velero restore failed
+	RestoreFailedFast = "RestoreFailedFast"
+	// RestoreClustersJoined is true when all managed clusters activated by the restore joined the hub,
+	// set only when the VerifyClusterJoin option is enabled
+	RestoreClustersJoined = "ClustersJoined"
+	// RestoreDegraded is true when fewer managed clusters than required by the MinClustersAvailablePercent
+	// option are available after the restore
+	RestoreDegraded = "Degraded"
+	// RestoreActiveBackupScheduleDuringRestore is true when an enabled BackupSchedule exists
+	// in the restore namespace when the restore is processed
+	RestoreActiveBackupScheduleDuringRestore = "ActiveBackupScheduleDuringRestore"
 )
 
 // Valid Restore Reason
 const (
-	RestoreReasonNotStarted = "RestoreNotStarted"
-	RestoreReasonStarted    = "RestoreStarted"
-	RestoreReasonRunning    = "RestoreRunning"
-	RestoreReasonFinished   = "RestoreFinished"
+	RestoreReasonNotStarted  = "RestoreNotStarted"
+	RestoreReasonStarted     = "RestoreStarted"
+	RestoreReasonRunning     = "RestoreRunning"
+	RestoreReasonFinished    = "RestoreFinished"
+	RestoreReasonClockSkew   = "BackupStartedInFuture"
+	RestoreReasonNoClockSkew = "NoClockSkew"
+
+	RestoreReasonNamespaceLabelMissing = "NamespaceLabelMissing"
+	RestoreReasonNamespaceLabelFound   = "NamespaceLabelFound"
+
+	RestoreReasonPluginsMissing = "VeleroPluginsMissing"
+	RestoreReasonPluginsFound   = "VeleroPluginsFound"
+
+	RestoreReasonPrerequisiteFailed = "PrerequisiteRestoreFailed"
+
+	RestoreReasonClustersJoinPending = "ClustersJoinPending"
+	RestoreReasonClustersJoined      = "ClustersJoined"
+	RestoreReasonClustersJoinTimeout = "ClustersJoinTimeout"
+
+	RestoreReasonMinClustersAvailable    = "MinClustersAvailable"
+	RestoreReasonMinClustersNotAvailable = "MinClustersNotAvailable"
+
+	RestoreReasonBackupScheduleActive = "BackupScheduleActive"
+	RestoreReasonBackupSchedulePaused = "BackupSchedulePaused"
 )
 
 //+kubebuilder:object:root=true
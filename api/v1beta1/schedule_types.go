@@ -48,6 +48,59 @@ const (
 	SchedulePhasePaused SchedulePhase = "Paused"
 )
 
+// BackupSchedule condition types
+const (
+	// ScheduleConditionCronValid is true when the VeleroSchedule cron expression is valid
+	ScheduleConditionCronValid = "CronValid"
+	// ScheduleConditionSchedulesReconciled is true when all velero schedules are enabled
+	ScheduleConditionSchedulesReconciled = "SchedulesReconciled"
+	// ScheduleConditionBackupCollisionDetected is true when another hub is backing up
+	// to the same storage location
+	ScheduleConditionBackupCollisionDetected = "BackupCollisionDetected"
+	// ScheduleConditionStorageUsageWarning is true when the backup storage location usage
+	// reached the StorageUsageWarningThreshold
+	ScheduleConditionStorageUsageWarning = "StorageUsageWarning"
+	// ScheduleConditionUnownedSchedulesDetected is true when velero schedules created for
+	// this BackupSchedule exist but are not found as owned by this BackupSchedule
+	ScheduleConditionUnownedSchedulesDetected = "UnownedSchedulesDetected"
+	// ScheduleConditionSchedulesUpdated is true when the velero schedules were updated
+	// because the BackupSchedule spec changed; the message lists the changed fields
+	ScheduleConditionSchedulesUpdated = "SchedulesUpdated"
+	// ScheduleConditionBackupsOverdue is true when an enabled velero schedule did not create a backup
+	// for longer than BackupOverdueFactor times the interval between two scheduled backups
+	ScheduleConditionBackupsOverdue = "BackupsOverdue"
+	// ScheduleConditionBackupsFailing is true when the last BackupFailureThreshold backups
+	// created by a velero schedule failed or partially failed
+	ScheduleConditionBackupsFailing = "BackupsFailing"
+	// ScheduleConditionBackupsWithWarnings is true when the latest finished backup created
+	// by a velero schedule reported warnings
+	ScheduleConditionBackupsWithWarnings = "BackupsWithWarnings"
+)
+
+// Valid BackupSchedule condition reasons
+const (
+	ScheduleReasonCronValid          = "CronValid"
+	ScheduleReasonCronInvalid        = "CronInvalid"
+	ScheduleReasonSchedulesEnabled   = "SchedulesEnabled"
+	ScheduleReasonSchedulesNotReady  = "SchedulesNotReady"
+	ScheduleReasonSchedulesFailed    = "SchedulesFailed"
+	ScheduleReasonSchedulesPaused    = "SchedulesPaused"
+	ScheduleReasonBackupCollision    = "BackupCollision"
+	ScheduleReasonNoBackupCollision  = "NoBackupCollision"
+	ScheduleReasonStorageUsageHigh   = "StorageUsageHigh"
+	ScheduleReasonStorageUsageOK     = "StorageUsageOK"
+	ScheduleReasonUnownedSchedules   = "UnownedSchedules"
+	ScheduleReasonNoUnownedSchedules = "NoUnownedSchedules"
+	ScheduleReasonSpecChanged        = "SpecChanged"
+	ScheduleReasonSchedulesUpToDate  = "SchedulesUpToDate"
+	ScheduleReasonBackupsOverdue     = "BackupsOverdue"
+	ScheduleReasonBackupsOnTime      = "BackupsOnTime"
+	ScheduleReasonBackupsFailing     = "BackupsFailing"
+	ScheduleReasonBackupsSucceeding  = "BackupsSucceeding"
+	ScheduleReasonBackupWarnings     = "BackupWarnings"
+	ScheduleReasonNoBackupWarnings   = "NoBackupWarnings"
+)
+
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // BackupScheduleSpec defines the desired state of BackupSchedule
@@ -91,6 +144,23 @@ type BackupScheduleSpec struct {
 	// VolumeSnapshotLocations is a list containing names of VolumeSnapshotLocations associated with this backup.
 	VolumeSnapshotLocations []string `json:"volumeSnapshotLocations,omitempty"`
 	// +kubebuilder:validation:Optional
+	// ManagedClustersVolumeSnapshotLocations is a list containing names of VolumeSnapshotLocations
+	// associated with the managed clusters backup, replacing VolumeSnapshotLocations for this backup.
+	// If not defined, the managed clusters backup uses VolumeSnapshotLocations.
+	ManagedClustersVolumeSnapshotLocations []string `json:"managedClustersVolumeSnapshotLocations,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true to have the velero backups move the volume snapshots data to the backup storage
+	// location using the velero built-in data mover. The data mover uploads the snapshots data incrementally,
+	// only the data changed since the previous backup is uploaded. Requires the velero node agent and
+	// CSI snapshots support on the cluster.
+	// If not defined, the value is set to false.
+	IncrementalBackup bool `json:"incrementalBackup,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true to exclude the local cluster from the managed clusters backup. The ManagedCluster
+	// resource labeled with local-cluster=true is not backed up; the local cluster namespace is always excluded.
+	// If not defined, the value is set to false.
+	ExcludeLocalCluster bool `json:"excludeLocalCluster,omitempty"`
+	// +kubebuilder:validation:Optional
 	// When set to true, all velero Schedules generated by this BackupSchedule will be removed.
 	// Setting this option to false results in recreating the velero Schedules.
 	// If not defined, the value is set to false.
@@ -107,6 +177,141 @@ type BackupScheduleSpec struct {
 	// If false, backup will not be skipped immediately when schedule is unpaused, but will run at next schedule time.
 	// If not defined, the value is set to false.
 	SkipImmediately bool `json:"skipImmediately,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// StorageUsageWarningThreshold is the storage usage percentage that, when reached,
+	// sets the StorageUsageWarning condition on the BackupSchedule.
+	// Used only if the backup storage location exposes the storage capacity and usage.
+	// If not defined, the value is set to 80.
+	StorageUsageWarningThreshold int `json:"storageUsageWarningThreshold,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// BackupOverdueFactor sets the BackupsOverdue condition on the BackupSchedule when an enabled
+	// velero schedule did not create a backup for longer than this number of intervals
+	// between two scheduled backups.
+	// If not defined, the value is set to 3.
+	BackupOverdueFactor int `json:"backupOverdueFactor,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// BackupFailureThreshold sets the BackupsFailing condition on the BackupSchedule when
+	// this number of consecutive backups created by a velero schedule failed or partially failed.
+	// If not defined, the value is set to 3.
+	BackupFailureThreshold int `json:"backupFailureThreshold,omitempty"`
+	// +kubebuilder:validation:Optional
+	// ExcludedAddonNamespaces is a list of ManagedCluster addon namespaces excluded from the resources backup.
+	// Use this to skip namespaces where addons store large, transient working data.
+	// These namespaces are excluded only from the acm-resources-schedule backups.
+	ExcludedAddonNamespaces []string `json:"excludedAddonNamespaces,omitempty"`
+	// +kubebuilder:validation:Optional
+	// IncludedNamespaces is an explicit list of namespaces backed up by the acm-resources-schedule backups.
+	// If not defined, resources are backed up from all namespaces, except the ones excluded by the backup.
+	// The list cannot contain the BackupSchedule namespace, the local cluster namespace or
+	// any of the ExcludedAddonNamespaces.
+	IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+	// +kubebuilder:validation:Optional
+	// ManagedClusterSets is a list of ManagedClusterSet names. When set, the acm-managed-clusters-schedule
+	// backups include only the namespaced resources from the namespaces of the managed clusters in these sets.
+	// If not defined, the resources of all managed clusters are backed up.
+	ManagedClusterSets []string `json:"managedClusterSets,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// CredentialsOrLabelSelectors is a list of label selectors for additional secrets and configmaps
+	// backed up by the acm-credentials-schedule backups. These selectors are added to the
+	// default credentials selectors; a resource is backed up if it matches any of the selectors.
+	CredentialsOrLabelSelectors []*metav1.LabelSelector `json:"credentialsOrLabelSelectors,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the acm-resources-schedule backups to include OpenShift
+	// cluster configuration resources, such as the OAuth, proxy and image registry configuration.
+	// If not defined, the value is set to false.
+	IncludeOpenShiftResources bool `json:"includeOpenShiftResources,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the acm-resources-schedule backups to include the BackupSchedule
+	// and Restore resources, so the backup and restore configuration can be recovered after a hub loss.
+	// When set, the namespace of this BackupSchedule is no longer excluded from the resources backup.
+	// Restored Restore resources are not executed on the restore hub.
+	// If not defined, the value is set to false.
+	BackupOperatorConfig bool `json:"backupOperatorConfig,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the acm-resources-schedule backups to include the
+	// ClusterManagementAddOn, AddOnDeploymentConfig and AddOnTemplate resources,
+	// so the hub addon configuration is recovered on the restore hub.
+	// If not defined, the value is set to false.
+	IncludeAddonConfig bool `json:"includeAddonConfig,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the backups to include the observability configuration:
+	// the acm-resources-schedule backups include the ObservabilityAddon resources and the
+	// acm-credentials-schedule backups include the observability object storage, alertmanager
+	// and custom metrics allowlist secrets and configmaps.
+	// If not defined, the value is set to false.
+	IncludeObservabilityConfig bool `json:"includeObservabilityConfig,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// IncludeConfigMapSelector is a label selector for the application ConfigMaps to back up.
+	// When set, the acm-configmaps-schedule velero schedule is created to back up only the ConfigMaps
+	// matching this selector; other resources matching it, such as Secrets, are not backed up by this schedule.
+	// The configmaps backups are restored with the resources backups created at the same time.
+	// If not defined, no additional ConfigMaps are backed up.
+	IncludeConfigMapSelector *metav1.LabelSelector `json:"includeConfigMapSelector,omitempty"`
+	// +kubebuilder:validation:Optional
+	// velero option - Hooks represent custom behaviors that should be executed
+	// at different phases of the acm-resources-schedule backups.
+	Hooks veleroapi.BackupHooks `json:"hooks,omitempty"`
+	// +kubebuilder:validation:Optional
+	// HookTimeout is the maximum amount of time velero waits for an exec hook of the
+	// acm-resources-schedule backups to complete, for example 5m.
+	// It is used for the hooks with no timeout defined.
+	// If not defined, the velero default hook timeout is used.
+	HookTimeout metav1.Duration `json:"hookTimeout,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Continue;Fail
+	// HookOnError defines how velero behaves when an exec hook of the acm-resources-schedule
+	// backups fails: Continue runs the remaining hooks, Fail stops running the hooks.
+	// It is used for the hooks with no onError value defined.
+	// If not defined, the velero default is used.
+	HookOnError veleroapi.HookErrorMode `json:"hookOnError,omitempty"`
+	// +kubebuilder:validation:Optional
+	// MaxBackupRetentionDuration is a time.Duration-parseable string describing how long
+	// the backups created by this BackupSchedule are kept, for example 720h.
+	// Backups completed before the retention window are deleted.
+	// If not defined, backups are deleted only when the VeleroTTL expires.
+	MaxBackupRetentionDuration metav1.Duration `json:"maxBackupRetentionDuration,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if the backup retention is managed outside of this operator,
+	// for example with object storage lifecycle policies. When true, the operator doesn't
+	// delete any backups and the retention is governed only by the VeleroTTL or external policies.
+	// If not defined, the value is set to false.
+	DisableCleanup bool `json:"disableCleanup,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="VeleroNamespace is immutable"
+	// VeleroNamespace is the namespace where velero is running and where the velero schedules are created.
+	// If not defined, the velero schedules are created in the BackupSchedule namespace.
+	VeleroNamespace string `json:"veleroNamespace,omitempty"`
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="TargetNamespaces is immutable"
+	// TargetNamespaces lists the namespaces where velero is running and where the velero schedules are created,
+	// for hubs running velero in several namespaces. The velero schedules are created in each namespace,
+	// and the BackupSchedule status aggregates the velero schedules from all namespaces.
+	// Can't be used with the VeleroNamespace option.
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want a BackupCompleted event to be emitted on the BackupSchedule
+	// each time a backup created by one of the velero schedules completes.
+	// If not defined, the value is set to false.
+	EmitBackupCompletedEvents bool `json:"emitBackupCompletedEvents,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the operator to verify it has the permissions required
+	// to create the velero schedules before creating them.
+	// If a permission is missing, the BackupSchedule is set to Failed and the status message
+	// names the missing permission.
+	// If not defined, the value is set to false.
+	ValidatePermissions bool `json:"validatePermissions,omitempty"`
+	// +kubebuilder:validation:Optional
+	// Set this to true if you want the velero schedules to start at different times,
+	// to avoid running all backups at once. The managed clusters and resources schedules
+	// start a few minutes after the credentials schedule, using the minute field of the VeleroSchedule cron.
+	// If not defined, the value is set to false.
+	StaggerSchedules bool `json:"staggerSchedules,omitempty"`
 }
 
 // BackupScheduleStatus defines the observed state of BackupSchedule
@@ -126,6 +331,33 @@ type BackupScheduleStatus struct {
 	// Velero Schedule for backing up credentials
 	// +kubebuilder:validation:Optional
 	VeleroScheduleCredentials *veleroapi.Schedule `json:"veleroScheduleCredentials,omitempty"`
+	// VeleroScheduleNames lists the names of the velero schedules created by this BackupSchedule
+	// +kubebuilder:validation:Optional
+	VeleroScheduleNames []string `json:"veleroScheduleNames,omitempty"`
+	// StorageCapacity is the capacity of the backup storage location, if exposed by the storage location
+	// +kubebuilder:validation:Optional
+	StorageCapacity string `json:"storageCapacity,omitempty"`
+	// StorageUsed is the used storage of the backup storage location, if exposed by the storage location
+	// +kubebuilder:validation:Optional
+	StorageUsed string `json:"storageUsed,omitempty"`
+	// Conditions represent the latest available observations of the BackupSchedule state
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// LastObservedBackup is the name of the latest completed backup observed for each backup type.
+	// Set only when the EmitBackupCompletedEvents option is enabled.
+	// +kubebuilder:validation:Optional
+	LastObservedBackup map[string]string `json:"lastObservedBackup,omitempty"`
+	// BackupWarnings is the number of warnings reported by the latest finished backup for each backup type,
+	// for the backup types with warnings
+	// +kubebuilder:validation:Optional
+	BackupWarnings map[string]int `json:"backupWarnings,omitempty"`
+	// LastReconcileTime is the time of the latest reconcile of this BackupSchedule.
+	// Monitors can use it to detect a BackupSchedule no longer reconciled by the operator.
+	// +kubebuilder:validation:Optional
+	// +nullable
+	LastReconcileTime *metav1.Time `json:"lastReconcileTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
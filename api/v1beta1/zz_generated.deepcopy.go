@@ -26,6 +26,21 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupManifest) DeepCopyInto(out *BackupManifest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupManifest.
+func (in *BackupManifest) DeepCopy() *BackupManifest {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupManifest)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupSchedule) DeepCopyInto(out *BackupSchedule) {
 	*out = *in
@@ -95,6 +110,50 @@ func (in *BackupScheduleSpec) DeepCopyInto(out *BackupScheduleSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ManagedClustersVolumeSnapshotLocations != nil {
+		in, out := &in.ManagedClustersVolumeSnapshotLocations, &out.ManagedClustersVolumeSnapshotLocations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedAddonNamespaces != nil {
+		in, out := &in.ExcludedAddonNamespaces, &out.ExcludedAddonNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludedNamespaces != nil {
+		in, out := &in.IncludedNamespaces, &out.IncludedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManagedClusterSets != nil {
+		in, out := &in.ManagedClusterSets, &out.ManagedClusterSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsOrLabelSelectors != nil {
+		in, out := &in.CredentialsOrLabelSelectors, &out.CredentialsOrLabelSelectors
+		*out = make([]*metav1.LabelSelector, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(metav1.LabelSelector)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.IncludeConfigMapSelector != nil {
+		in, out := &in.IncludeConfigMapSelector, &out.IncludeConfigMapSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Hooks.DeepCopyInto(&out.Hooks)
+	out.HookTimeout = in.HookTimeout
+	out.MaxBackupRetentionDuration = in.MaxBackupRetentionDuration
+	if in.TargetNamespaces != nil {
+		in, out := &in.TargetNamespaces, &out.TargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleSpec.
@@ -125,6 +184,36 @@ func (in *BackupScheduleStatus) DeepCopyInto(out *BackupScheduleStatus) {
 		*out = new(v1.Schedule)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.VeleroScheduleNames != nil {
+		in, out := &in.VeleroScheduleNames, &out.VeleroScheduleNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastObservedBackup != nil {
+		in, out := &in.LastObservedBackup, &out.LastObservedBackup
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.BackupWarnings != nil {
+		in, out := &in.BackupWarnings, &out.BackupWarnings
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastReconcileTime != nil {
+		in, out := &in.LastReconcileTime, &out.LastReconcileTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupScheduleStatus.
@@ -137,6 +226,72 @@ func (in *BackupScheduleStatus) DeepCopy() *BackupScheduleStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterJoinStatus) DeepCopyInto(out *ClusterJoinStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterJoinStatus.
+func (in *ClusterJoinStatus) DeepCopy() *ClusterJoinStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterJoinStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhaseTransition) DeepCopyInto(out *PhaseTransition) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhaseTransition.
+func (in *PhaseTransition) DeepCopy() *PhaseTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(PhaseTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostRestoreJobSpec) DeepCopyInto(out *PostRestoreJobSpec) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostRestoreJobSpec.
+func (in *PostRestoreJobSpec) DeepCopy() *PostRestoreJobSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostRestoreJobSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostRestoreJobStatus) DeepCopyInto(out *PostRestoreJobStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostRestoreJobStatus.
+func (in *PostRestoreJobStatus) DeepCopy() *PostRestoreJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PostRestoreJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Restore) DeepCopyInto(out *Restore) {
 	*out = *in
@@ -196,6 +351,22 @@ func (in *RestoreList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreProgress) DeepCopyInto(out *RestoreProgress) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreProgress.
+func (in *RestoreProgress) DeepCopy() *RestoreProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 	*out = *in
@@ -215,6 +386,20 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 		**out = **in
 	}
 	out.RestoreSyncInterval = in.RestoreSyncInterval
+	if in.CleanupCreatedBefore != nil {
+		in, out := &in.CleanupCreatedBefore, &out.CleanupCreatedBefore
+		*out = (*in).DeepCopy()
+	}
+	if in.CleanupResourceOrder != nil {
+		in, out := &in.CleanupResourceOrder, &out.CleanupResourceOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CleanupForceDeleteKinds != nil {
+		in, out := &in.CleanupForceDeleteKinds, &out.CleanupForceDeleteKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.RestorePVs != nil {
 		in, out := &in.RestorePVs, &out.RestorePVs
 		*out = new(bool)
@@ -230,6 +415,17 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.IncludeClusterResources != nil {
+		in, out := &in.IncludeClusterResources, &out.IncludeClusterResources
+		*out = new(bool)
+		**out = **in
+	}
+	if in.UploaderConfig != nil {
+		in, out := &in.UploaderConfig, &out.UploaderConfig
+		*out = new(v1.UploaderConfigForRestore)
+		(*in).DeepCopyInto(*out)
+	}
+	out.ItemOperationTimeout = in.ItemOperationTimeout
 	in.Hooks.DeepCopyInto(&out.Hooks)
 	if in.IncludedNamespaces != nil {
 		in, out := &in.IncludedNamespaces, &out.IncludedNamespaces
@@ -267,6 +463,22 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 			}
 		}
 	}
+	if in.BackupLabelSelector != nil {
+		in, out := &in.BackupLabelSelector, &out.BackupLabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourcesRestoreOrLabelSelectors != nil {
+		in, out := &in.ResourcesRestoreOrLabelSelectors, &out.ResourcesRestoreOrLabelSelectors
+		*out = make([]*metav1.LabelSelector, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(metav1.LabelSelector)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
 	if in.NamespaceMapping != nil {
 		in, out := &in.NamespaceMapping, &out.NamespaceMapping
 		*out = make(map[string]string, len(*in))
@@ -274,6 +486,40 @@ func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ClusterSetMapping != nil {
+		in, out := &in.ClusterSetMapping, &out.ClusterSetMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ManagedClusterNamespaceLabels != nil {
+		in, out := &in.ManagedClusterNamespaceLabels, &out.ManagedClusterNamespaceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PostRestoreJob != nil {
+		in, out := &in.PostRestoreJob, &out.PostRestoreJob
+		*out = new(PostRestoreJobSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretTransform != nil {
+		in, out := &in.SecretTransform, &out.SecretTransform
+		*out = new(SecretTransformSpec)
+		**out = **in
+	}
+	if in.BackupManifest != nil {
+		in, out := &in.BackupManifest, &out.BackupManifest
+		*out = new(BackupManifest)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreSpec.
@@ -294,10 +540,84 @@ func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ActivatedClusters != nil {
+		in, out := &in.ActivatedClusters, &out.ActivatedClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.CompletionTimestamp != nil {
 		in, out := &in.CompletionTimestamp, &out.CompletionTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.StartTimestamp != nil {
+		in, out := &in.StartTimestamp, &out.StartTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.VeleroCompletionTimestamp != nil {
+		in, out := &in.VeleroCompletionTimestamp, &out.VeleroCompletionTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.PostRestoreJobStatus != nil {
+		in, out := &in.PostRestoreJobStatus, &out.PostRestoreJobStatus
+		*out = new(PostRestoreJobStatus)
+		**out = **in
+	}
+	if in.PhaseTransitions != nil {
+		in, out := &in.PhaseTransitions, &out.PhaseTransitions
+		*out = make([]PhaseTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MissingCRDs != nil {
+		in, out := &in.MissingCRDs, &out.MissingCRDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedMissingCRDResources != nil {
+		in, out := &in.SkippedMissingCRDResources, &out.SkippedMissingCRDResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedResourceTypes != nil {
+		in, out := &in.SkippedResourceTypes, &out.SkippedResourceTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InvalidImportTokens != nil {
+		in, out := &in.InvalidImportTokens, &out.InvalidImportTokens
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterJoinStatus != nil {
+		in, out := &in.ClusterJoinStatus, &out.ClusterJoinStatus
+		*out = make([]ClusterJoinStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastProgressEvent != nil {
+		in, out := &in.LastProgressEvent, &out.LastProgressEvent
+		*out = new(RestoreProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ValidationRestoreResult != nil {
+		in, out := &in.ValidationRestoreResult, &out.ValidationRestoreResult
+		*out = new(ValidationRestoreResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailedItemsByKind != nil {
+		in, out := &in.FailedItemsByKind, &out.FailedItemsByKind
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreStatus.
@@ -309,3 +629,38 @@ func (in *RestoreStatus) DeepCopy() *RestoreStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTransformSpec) DeepCopyInto(out *SecretTransformSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTransformSpec.
+func (in *SecretTransformSpec) DeepCopy() *SecretTransformSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTransformSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValidationRestoreResult) DeepCopyInto(out *ValidationRestoreResult) {
+	*out = *in
+	if in.SandboxNamespaces != nil {
+		in, out := &in.SandboxNamespaces, &out.SandboxNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValidationRestoreResult.
+func (in *ValidationRestoreResult) DeepCopy() *ValidationRestoreResult {
+	if in == nil {
+		return nil
+	}
+	out := new(ValidationRestoreResult)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,16 +19,24 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/discovery"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -41,6 +49,8 @@ var (
 	BackupScheduleTypeLabel string = "cluster.open-cluster-management.io/backup-schedule-type"
 	// BackupScheduleClusterUIDLabel is the label key used to identify the cluster id that generated the backup
 	BackupScheduleClusterLabel string = "cluster.open-cluster-management.io/backup-cluster"
+	// BackupScheduleGenerationLabel stores the generation of the BackupSchedule spec used to create the backup
+	BackupScheduleGenerationLabel string = "cluster.open-cluster-management.io/schedule-generation"
 	// BackupScheduleActivationLabel stores the name of the restore resources that resulted in creating this backup
 	BackupScheduleActivationLabel string = "cluster.open-cluster-management.io/backup-activation-restore"
 	// label for backups generated from velero schedules
@@ -51,6 +61,14 @@ var (
 	ClusterActivationLabel string = "cluster-activation"
 
 	ExcludeBackupLabel string = "velero.io/exclude-from-backup"
+
+	// ExcludedAddonNamespacesAnnotation stores the namespaces excluded from the resources schedule
+	// by the BackupSchedule ExcludedAddonNamespaces option
+	ExcludedAddonNamespacesAnnotation string = "cluster.open-cluster-management.io/excluded-addon-namespaces"
+
+	// BackupVeleroPluginsAnnotation lists, comma separated, the velero plugins
+	// installed on the hub when the backup was created
+	BackupVeleroPluginsAnnotation string = "cluster.open-cluster-management.io/velero-plugins"
 )
 var (
 	hiveSuffix = ".hive.openshift.io"
@@ -97,10 +115,11 @@ var (
 	}
 
 	// resources used to activate the connection between hub and managed clusters - activation resources
+	// the velero schedule ConfigMap can replace this list
 	backupManagedClusterResources = []string{
-		"clusterdeployment.hive.openshift.io",               // restore these first
-		"machinepool.hive.openshift.io",                     // restore these first
-		"managedcluster.cluster.open-cluster-management.io", //global
+		"clusterdeployment.hive.openshift.io", // restore these first
+		"machinepool.hive.openshift.io",       // restore these first
+		managedClusterResourceName,            //global
 		"klusterletaddonconfig.agent.open-cluster-management.io",
 		"managedclusteraddon.addon.open-cluster-management.io",
 		"clusterpool.hive.openshift.io",
@@ -124,15 +143,65 @@ var (
 		"configmap",
 	}
 
+	// OpenShift cluster configuration resources, added to the resources backup
+	// when the BackupSchedule IncludeOpenShiftResources option is set
+	openShiftBackupResources = []string{
+		"oauth.config.openshift.io",
+		"image.config.openshift.io",
+		"proxy.config.openshift.io",
+		"apiserver.config.openshift.io",
+		"ingress.config.openshift.io",
+		"config.imageregistry.operator.openshift.io",
+	}
+
+	// backup operator configuration resources, added to the resources backup
+	// when the BackupSchedule BackupOperatorConfig option is set
+	operatorConfigBackupResources = []string{
+		"backupschedule.cluster.open-cluster-management.io",
+		"restore.cluster.open-cluster-management.io",
+	}
+
+	// hub addon configuration resources, added to the resources backup
+	// when the BackupSchedule IncludeAddonConfig option is set
+	addonConfigBackupResources = []string{
+		"clustermanagementaddon.addon.open-cluster-management.io",
+		"addondeploymentconfig.addon.open-cluster-management.io",
+		"addontemplate.addon.open-cluster-management.io",
+	}
+
+	// observability resources, added to the resources backup
+	// when the BackupSchedule IncludeObservabilityConfig option is set
+	observabilityBackupResources = []string{
+		"observabilityaddon.observability.open-cluster-management.io",
+	}
+	// observability secrets and configmaps from the hub observability namespace,
+	// labeled for the credentials backup when the BackupSchedule IncludeObservabilityConfig option is set
+	observabilityConfigSecrets = []string{
+		"thanos-object-storage",
+		"alertmanager-config",
+	}
+	observabilityConfigConfigMaps = []string{
+		"observability-metrics-custom-allowlist",
+	}
+
 	// secrets and configmaps labels
 	backupCredsUserLabel    = "cluster.open-cluster-management.io/type"   // #nosec G101 -- This is a false positive
 	backupCredsHiveLabel    = "hive.openshift.io/secret-type"             // hive
 	backupCredsClusterLabel = "cluster.open-cluster-management.io/backup" // #nosec G101 -- This is a false positive
 	policyRootLabel         = "policy.open-cluster-management.io/root-policy"
+
+	// suffix of the velero schedule ConfigMap key listing the resources backed up by a velero schedule
+	backupResourcesKeySuffix   = ".resources"
+	managedClusterResourceName = "managedcluster.cluster.open-cluster-management.io"
+
+	// max delay between the start of two staggered velero schedules
+	maxScheduleStagger = time.Minute * 5
 )
 
 var (
 	apiGVString = v1beta1.GroupVersion.String()
+	// reference time used to compute the staggered velero schedules cron
+	scheduleStaggerReferenceTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
 	// create credentials schedule first since this is the fastest one, followed by resources
 	// mapping ResourceTypes to Velero schedule names
 	veleroScheduleNames = map[ResourceType]string{
@@ -142,8 +211,137 @@ var (
 		ManagedClusters:    "acm-managed-clusters-schedule",
 		ValidationSchedule: "acm-validation-policy-schedule",
 	}
+	// velero schedule backing up the ConfigMaps selected by the BackupSchedule IncludeConfigMapSelector option;
+	// this schedule is created only if the option is set, so it is not part of the veleroScheduleNames
+	configMapsScheduleName = "acm-configmaps-schedule"
 )
 
+// LoadVeleroScheduleConfig overrides the velero schedule names used for each resource type,
+// and the resources backed up by the managed clusters backup, with the values set by the ConfigMap
+// with the given name, in the operator namespace.
+// The ConfigMap data keys are the resource types, for example resources or credentials,
+// and the values are the velero schedule names. The managedClusters.resources key lists, comma separated,
+// the resources backed up by the managed clusters backup instead of the resources backup.
+// The defaults are kept if the ConfigMap is not found or is not valid.
+func LoadVeleroScheduleConfig(
+	ctx context.Context,
+	c client.Client,
+	configMapName string,
+) error {
+	if configMapName == "" {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Namespace: getOperatorNamespace(),
+		Name:      configMapName,
+	}, configMap); err != nil {
+		return fmt.Errorf("failed to get velero schedule ConfigMap %s: %w", configMapName, err)
+	}
+
+	scheduleNames, err := parseVeleroScheduleNames(configMap.Data)
+	if err != nil {
+		return fmt.Errorf("invalid velero schedule ConfigMap %s: %w", configMapName, err)
+	}
+	managedClusterResources, err := parseManagedClusterResources(configMap.Data)
+	if err != nil {
+		return fmt.Errorf("invalid velero schedule ConfigMap %s: %w", configMapName, err)
+	}
+
+	for key, name := range scheduleNames {
+		veleroScheduleNames[key] = name
+		// restores look for the backups created by the renamed schedules
+		veleroBackupNames[key] = name
+	}
+	if managedClusterResources != nil {
+		backupManagedClusterResources = managedClusterResources
+	}
+	return nil
+}
+
+// returns the velero schedule names set by the ConfigMap data,
+// or an error if a resource type is unknown or a schedule name is not valid or not unique
+func parseVeleroScheduleNames(
+	data map[string]string,
+) (map[ResourceType]string, error) {
+	scheduleNames := map[ResourceType]string{}
+	for key, name := range data {
+		if strings.HasSuffix(strings.TrimSpace(key), backupResourcesKeySuffix) {
+			// backed up resources, not a schedule name
+			continue
+		}
+		resourceType := ResourceType(strings.TrimSpace(key))
+		if _, ok := veleroScheduleNames[resourceType]; !ok {
+			return nil, fmt.Errorf("unknown resource type %s", key)
+		}
+		name = strings.TrimSpace(name)
+		// the schedule name is used as a label value on the velero backups
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid schedule name %s for resource type %s: %s",
+				name, key, strings.Join(errs, ", "))
+		}
+		scheduleNames[resourceType] = name
+	}
+
+	// schedule names must be unique, including the default names which are not overridden
+	usedBy := map[string]ResourceType{}
+	for key, defaultName := range veleroScheduleNames {
+		name := defaultName
+		if override, ok := scheduleNames[key]; ok {
+			name = override
+		}
+		if other, ok := usedBy[name]; ok {
+			return nil, fmt.Errorf("schedule name %s is used by both %s and %s resource types",
+				name, other, key)
+		}
+		usedBy[name] = key
+	}
+
+	return scheduleNames, nil
+}
+
+// returns the resources backed up by the managed clusters backup set by the ConfigMap data,
+// or nil if not set; the resources from the backed up api groups which are not listed
+// are backed up by the resources backup
+// returns an error if the resources are set for another resource type, a resource name is not valid,
+// or the ManagedCluster resource is not listed
+func parseManagedClusterResources(
+	data map[string]string,
+) ([]string, error) {
+	var managedClusterResources []string
+	for key, value := range data {
+		resourceType, found := strings.CutSuffix(strings.TrimSpace(key), backupResourcesKeySuffix)
+		if !found {
+			continue
+		}
+		if ResourceType(resourceType) != ManagedClusters {
+			// the other backups select the resources by api group or by label
+			return nil, fmt.Errorf("the backed up resources can be set only for the %s resource type, not %s",
+				ManagedClusters, resourceType)
+		}
+		managedClusterResources = []string{}
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			// resource names are set as <kind>.<api group>, in lower case
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid resource name %s for resource type %s: %s",
+					name, resourceType, strings.Join(errs, ", "))
+			}
+			managedClusterResources = appendUnique(managedClusterResources, name)
+		}
+		// the managed clusters are activated from the managed clusters backup
+		if !findValue(managedClusterResources, managedClusterResourceName) {
+			return nil, fmt.Errorf("the %s resource type must back up the %s resource",
+				resourceType, managedClusterResourceName)
+		}
+	}
+	return managedClusterResources, nil
+}
+
 // set all acm resources backup info
 func setResourcesBackupInfo(
 	ctx context.Context,
@@ -155,11 +353,14 @@ func setResourcesBackupInfo(
 	var clusterResource bool = true
 	veleroBackupTemplate.IncludeClusterResources = &clusterResource
 
-	// exclude backup chart NS
-	veleroBackupTemplate.ExcludedNamespaces = appendUnique(
-		veleroBackupTemplate.ExcludedNamespaces,
-		backupNS,
-	)
+	// exclude backup chart NS, unless the BackupSchedule and Restore resources
+	// from this namespace are backed up
+	if backupNS != "" {
+		veleroBackupTemplate.ExcludedNamespaces = appendUnique(
+			veleroBackupTemplate.ExcludedNamespaces,
+			backupNS,
+		)
+	}
 
 	veleroBackupTemplate.IncludedResources = getResourcesByBackupType(
 		resourcesToBackup,
@@ -205,6 +406,151 @@ func setResourcesBackupInfo(
 
 }
 
+// update the ExcludedNamespaces of the resources schedule with the addon namespaces
+// excluded by the BackupSchedule ExcludedAddonNamespaces option
+// the namespaces added by this option are tracked with the ExcludedAddonNamespacesAnnotation
+// so they can be removed from the ExcludedNamespaces when the option is updated
+// returns true if the schedule was updated
+func updateExcludedAddonNamespaces(
+	veleroSchedule *veleroapi.Schedule,
+	addonNamespaces []string,
+) bool {
+	veleroBackupTemplate := &veleroSchedule.Spec.Template
+
+	previouslyAdded := []string{}
+	if value := veleroSchedule.GetAnnotations()[ExcludedAddonNamespacesAnnotation]; value != "" {
+		previouslyAdded = strings.Split(value, ",")
+	}
+
+	// namespaces excluded by the backup operator, not by this option
+	excludedNamespaces := []string{}
+	for _, ns := range veleroBackupTemplate.ExcludedNamespaces {
+		if !findValue(previouslyAdded, ns) {
+			excludedNamespaces = append(excludedNamespaces, ns)
+		}
+	}
+
+	added := []string{}
+	for _, ns := range addonNamespaces {
+		if ns != "" && !findValue(excludedNamespaces, ns) {
+			added = appendUnique(added, ns)
+		}
+	}
+
+	if sortCompare(append([]string{}, previouslyAdded...), added) {
+		return false
+	}
+
+	veleroBackupTemplate.ExcludedNamespaces = append(excludedNamespaces, added...)
+
+	annotations := veleroSchedule.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if len(added) > 0 {
+		annotations[ExcludedAddonNamespacesAnnotation] = strings.Join(added, ",")
+	} else {
+		delete(annotations, ExcludedAddonNamespacesAnnotation)
+	}
+	veleroSchedule.SetAnnotations(annotations)
+
+	return true
+}
+
+// returns the namespace excluded from the resources backup, the BackupSchedule namespace,
+// or an empty string if the BackupOperatorConfig option is set
+func getResourcesBackupExcludedNamespace(
+	backupSchedule *v1beta1.BackupSchedule,
+) string {
+	if backupSchedule.Spec.BackupOperatorConfig {
+		return ""
+	}
+	return backupSchedule.Namespace
+}
+
+// exclude the BackupSchedule namespace from the resources schedule,
+// unless the BackupOperatorConfig option is set
+// returns true if the schedule was updated
+func updateBackupNamespaceExclusion(
+	veleroSchedule *veleroapi.Schedule,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	veleroBackupTemplate := &veleroSchedule.Spec.Template
+
+	excluded := findValue(veleroBackupTemplate.ExcludedNamespaces, backupSchedule.Namespace)
+	if excluded == !backupSchedule.Spec.BackupOperatorConfig {
+		return false
+	}
+
+	if excluded {
+		namespaces := []string{}
+		for _, ns := range veleroBackupTemplate.ExcludedNamespaces {
+			if ns != backupSchedule.Namespace {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		veleroBackupTemplate.ExcludedNamespaces = namespaces
+	} else {
+		veleroBackupTemplate.ExcludedNamespaces = appendUnique(
+			veleroBackupTemplate.ExcludedNamespaces,
+			backupSchedule.Namespace,
+		)
+	}
+	return true
+}
+
+// set the IncludedNamespaces of the resources schedule to the namespaces
+// defined by the BackupSchedule IncludedNamespaces option
+// returns true if the schedule was updated
+func updateIncludedNamespaces(
+	veleroSchedule *veleroapi.Schedule,
+	includedNamespaces []string,
+) bool {
+	veleroBackupTemplate := &veleroSchedule.Spec.Template
+
+	namespaces := []string{}
+	for _, ns := range includedNamespaces {
+		if ns != "" {
+			namespaces = appendUnique(namespaces, ns)
+		}
+	}
+
+	if sortCompare(append([]string{}, veleroBackupTemplate.IncludedNamespaces...), namespaces) {
+		return false
+	}
+
+	if len(namespaces) == 0 {
+		veleroBackupTemplate.IncludedNamespaces = nil
+	} else {
+		veleroBackupTemplate.IncludedNamespaces = namespaces
+	}
+	return true
+}
+
+// validate that the namespaces included in the resources backup
+// are not excluded by the backup schedule
+// returns an error message if the namespaces are not valid
+func validateIncludedNamespaces(
+	backupSchedule *v1beta1.BackupSchedule,
+	localClusterName string,
+) string {
+	excludedNamespaces := append([]string{backupSchedule.Namespace, localClusterName},
+		backupSchedule.Spec.ExcludedAddonNamespaces...)
+
+	conflicts := []string{}
+	for _, ns := range backupSchedule.Spec.IncludedNamespaces {
+		if ns != "" && findValue(excludedNamespaces, ns) {
+			conflicts = appendUnique(conflicts, ns)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Sprintf("IncludedNamespaces cannot contain excluded namespaces %v. "+
+			"Remove the namespaces from the IncludedNamespaces or ExcludedAddonNamespaces list.", conflicts)
+	}
+	return ""
+}
+
 // set generic backup info
 func setGenericResourcesBackupInfo(
 	veleroBackupTemplate *veleroapi.BackupSpec,
@@ -238,6 +584,7 @@ func setGenericResourcesBackupInfo(
 // set credentials backup info
 func setCredsBackupInfo(
 	veleroBackupTemplate *veleroapi.BackupSpec,
+	credentialsOrLabelSelectors []*v1.LabelSelector,
 ) {
 	var clusterResource bool = false
 	veleroBackupTemplate.IncludeClusterResources = &clusterResource
@@ -277,13 +624,218 @@ func setCredsBackupInfo(
 		&v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{*reqCls}},
 	)
 
+	// user defined selectors
+	for _, selector := range credentialsOrLabelSelectors {
+		if selector != nil {
+			OrSelectors = append(OrSelectors, selector.DeepCopy())
+		}
+	}
+
 	veleroBackupTemplate.OrLabelSelectors = OrSelectors
 }
 
+// set configmaps backup info; the configmaps schedule backs up only the ConfigMaps
+// matching the BackupSchedule IncludeConfigMapSelector option
+func setConfigMapsBackupInfo(
+	veleroBackupTemplate *veleroapi.BackupSpec,
+	configMapSelector *v1.LabelSelector,
+) {
+	var clusterResource bool = false
+	veleroBackupTemplate.IncludeClusterResources = &clusterResource
+
+	veleroBackupTemplate.IncludedResources = []string{"configmap"}
+	veleroBackupTemplate.LabelSelector = configMapSelector.DeepCopy()
+}
+
+// set the LabelSelector of the configmaps schedule to the BackupSchedule IncludeConfigMapSelector option
+// returns true if the schedule was updated
+func updateConfigMapsLabelSelector(
+	veleroSchedule *veleroapi.Schedule,
+	configMapSelector *v1.LabelSelector,
+) bool {
+	if configMapSelector == nil ||
+		equality.Semantic.DeepEqual(veleroSchedule.Spec.Template.LabelSelector, configMapSelector) {
+		return false
+	}
+	veleroSchedule.Spec.Template.LabelSelector = configMapSelector.DeepCopy()
+	return true
+}
+
+// set the OrLabelSelectors of the credentials schedule to the default credentials selectors
+// and the selectors defined by the BackupSchedule CredentialsOrLabelSelectors option
+// returns true if the schedule was updated
+func updateCredentialsOrLabelSelectors(
+	veleroSchedule *veleroapi.Schedule,
+	credentialsOrLabelSelectors []*v1.LabelSelector,
+) bool {
+	credsBackupTemplate := &veleroapi.BackupSpec{}
+	setCredsBackupInfo(credsBackupTemplate, credentialsOrLabelSelectors)
+
+	if equality.Semantic.DeepEqual(veleroSchedule.Spec.Template.OrLabelSelectors,
+		credsBackupTemplate.OrLabelSelectors) {
+		return false
+	}
+	veleroSchedule.Spec.Template.OrLabelSelectors = credsBackupTemplate.OrLabelSelectors
+	return true
+}
+
+// returns the hooks of the resources backup defined by the BackupSchedule Hooks option,
+// using the HookTimeout and HookOnError values for the exec hooks with no timeout or onError set
+func getResourcesBackupHooks(
+	backupSchedule *v1beta1.BackupSchedule,
+) veleroapi.BackupHooks {
+	hooks := backupSchedule.Spec.Hooks.DeepCopy()
+
+	setExecHookDefaults := func(resourceHooks []veleroapi.BackupResourceHook) {
+		for i := range resourceHooks {
+			execHook := resourceHooks[i].Exec
+			if execHook == nil {
+				continue
+			}
+			if execHook.Timeout.Duration == 0 {
+				execHook.Timeout = backupSchedule.Spec.HookTimeout
+			}
+			if execHook.OnError == "" {
+				execHook.OnError = backupSchedule.Spec.HookOnError
+			}
+		}
+	}
+	for i := range hooks.Resources {
+		setExecHookDefaults(hooks.Resources[i].PreHooks)
+		setExecHookDefaults(hooks.Resources[i].PostHooks)
+	}
+
+	return *hooks
+}
+
+// set the hooks of the resources schedule to the hooks defined by the BackupSchedule
+// returns true if the schedule was updated
+func updateResourcesBackupHooks(
+	veleroSchedule *veleroapi.Schedule,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	hooks := getResourcesBackupHooks(backupSchedule)
+
+	if equality.Semantic.DeepEqual(veleroSchedule.Spec.Template.Hooks, hooks) {
+		return false
+	}
+	veleroSchedule.Spec.Template.Hooks = hooks
+	return true
+}
+
+// set the BackupScheduleGenerationLabel of the backups created by the schedule to the BackupSchedule generation;
+// velero sets on the backups the schedule template labels, if defined, instead of the schedule labels
+// so the template labels include the schedule labels
+// returns true if the schedule was updated
+func updateScheduleGenerationLabel(
+	veleroSchedule *veleroapi.Schedule,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	if backupSchedule.Generation == 0 {
+		// generation not set
+		return false
+	}
+	generation := strconv.FormatInt(backupSchedule.Generation, 10)
+	templateLabels := veleroSchedule.Spec.Template.Metadata.Labels
+	if templateLabels[BackupScheduleGenerationLabel] == generation {
+		return false
+	}
+
+	if templateLabels == nil {
+		templateLabels = map[string]string{}
+		for k, v := range veleroSchedule.GetLabels() {
+			templateLabels[k] = v
+		}
+	}
+	templateLabels[BackupScheduleGenerationLabel] = generation
+	veleroSchedule.Spec.Template.Metadata.Labels = templateLabels
+	return true
+}
+
+// returns the volume snapshot locations of the backups created by the schedule with this type,
+// the managed clusters backup uses ManagedClustersVolumeSnapshotLocations when set
+func getVolumeSnapshotLocations(
+	backupSchedule *v1beta1.BackupSchedule,
+	scheduleKey ResourceType,
+) []string {
+	if scheduleKey == ManagedClusters &&
+		len(backupSchedule.Spec.ManagedClustersVolumeSnapshotLocations) > 0 {
+		return backupSchedule.Spec.ManagedClustersVolumeSnapshotLocations
+	}
+	if len(backupSchedule.Spec.VolumeSnapshotLocations) > 0 {
+		return backupSchedule.Spec.VolumeSnapshotLocations
+	}
+	// use the velero default volume snapshot location
+	return nil
+}
+
+// set the volume snapshot locations of the schedule to the locations defined by the BackupSchedule
+// returns true if the schedule was updated
+func updateVolumeSnapshotLocations(
+	veleroSchedule *veleroapi.Schedule,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	locations := getVolumeSnapshotLocations(backupSchedule,
+		ResourceType(veleroSchedule.GetLabels()[BackupScheduleTypeLabel]))
+
+	if len(veleroSchedule.Spec.Template.VolumeSnapshotLocations) == 0 && len(locations) == 0 {
+		return false
+	}
+	if equality.Semantic.DeepEqual(veleroSchedule.Spec.Template.VolumeSnapshotLocations, locations) {
+		return false
+	}
+	veleroSchedule.Spec.Template.VolumeSnapshotLocations = locations
+	return true
+}
+
+// set the snapshot data movement of the schedule based on the BackupSchedule IncrementalBackup option;
+// the velero data mover uploads only the snapshots data changed since the previous backup
+// returns true if the schedule was updated
+func updateIncrementalBackup(
+	veleroSchedule *veleroapi.Schedule,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	var snapshotMoveData *bool
+	if backupSchedule.Spec.IncrementalBackup {
+		snapshotMoveData = &backupSchedule.Spec.IncrementalBackup
+	}
+
+	if equality.Semantic.DeepEqual(veleroSchedule.Spec.Template.SnapshotMoveData, snapshotMoveData) {
+		return false
+	}
+	veleroSchedule.Spec.Template.SnapshotMoveData = snapshotMoveData
+	return true
+}
+
+// validate the label selectors set by the BackupSchedule CredentialsOrLabelSelectors
+// and IncludeConfigMapSelector options
+// returns an error message if a selector is not valid
+func validateCredentialsOrLabelSelectors(
+	backupSchedule *v1beta1.BackupSchedule,
+) string {
+	for i, selector := range backupSchedule.Spec.CredentialsOrLabelSelectors {
+		if selector == nil {
+			return fmt.Sprintf("invalid CredentialsOrLabelSelectors[%d] : selector is empty", i)
+		}
+		if _, err := v1.LabelSelectorAsSelector(selector); err != nil {
+			return fmt.Sprintf("invalid CredentialsOrLabelSelectors[%d] : %s", i, err.Error())
+		}
+	}
+	if selector := backupSchedule.Spec.IncludeConfigMapSelector; selector != nil {
+		if _, err := v1.LabelSelectorAsSelector(selector); err != nil {
+			return fmt.Sprintf("invalid IncludeConfigMapSelector : %s", err.Error())
+		}
+	}
+	return ""
+}
+
 // set managed clusters backup info
 func setManagedClustersBackupInfo(
+	ctx context.Context,
 	veleroBackupTemplate *veleroapi.BackupSpec,
 	resourcesToBackup []string,
+	backupSchedule *v1beta1.BackupSchedule,
+	c client.Client,
 ) {
 	var clusterResource bool = true // include cluster level resources
 	veleroBackupTemplate.IncludeClusterResources = &clusterResource
@@ -298,6 +850,121 @@ func setManagedClustersBackupInfo(
 		ManagedClusters,
 	)
 
+	setManagedClusterSetsNamespaces(ctx, c, veleroBackupTemplate, backupSchedule)
+	updateExcludeLocalCluster(veleroBackupTemplate, backupSchedule)
+}
+
+// add or remove the managed clusters backup label selector requirement excluding the resources
+// labeled with local-cluster=true, based on the BackupSchedule ExcludeLocalCluster option
+// returns true if the backup template was updated
+func updateExcludeLocalCluster(
+	veleroBackupTemplate *veleroapi.BackupSpec,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	found := -1
+	if veleroBackupTemplate.LabelSelector != nil {
+		for i, req := range veleroBackupTemplate.LabelSelector.MatchExpressions {
+			if req.Key == localClusterLabel {
+				found = i
+				break
+			}
+		}
+	}
+
+	if backupSchedule.Spec.ExcludeLocalCluster {
+		if found != -1 {
+			return false
+		}
+		if veleroBackupTemplate.LabelSelector == nil {
+			veleroBackupTemplate.LabelSelector = &v1.LabelSelector{}
+		}
+		veleroBackupTemplate.LabelSelector.MatchExpressions = append(
+			veleroBackupTemplate.LabelSelector.MatchExpressions,
+			v1.LabelSelectorRequirement{
+				Key:      localClusterLabel,
+				Operator: v1.LabelSelectorOpNotIn,
+				Values:   []string{"true"},
+			},
+		)
+		return true
+	}
+
+	if found == -1 {
+		return false
+	}
+	expressions := veleroBackupTemplate.LabelSelector.MatchExpressions
+	veleroBackupTemplate.LabelSelector.MatchExpressions = append(expressions[:found:found], expressions[found+1:]...)
+	if len(veleroBackupTemplate.LabelSelector.MatchExpressions) == 0 &&
+		len(veleroBackupTemplate.LabelSelector.MatchLabels) == 0 {
+		veleroBackupTemplate.LabelSelector = nil
+	}
+	return true
+}
+
+// returns the sorted namespaces of the managed clusters in the given ManagedClusterSets
+func getManagedClusterSetsNamespaces(
+	ctx context.Context,
+	c client.Client,
+	clusterSets []string,
+) ([]string, error) {
+	clusterSetSelector, err := labels.NewRequirement(clusterv1beta2.ClusterSetLabel, selection.In, clusterSets)
+	if err != nil {
+		return nil, err
+	}
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, managedClusters, &client.ListOptions{
+		LabelSelector: labels.NewSelector().Add(*clusterSetSelector),
+	}); err != nil {
+		return nil, err
+	}
+	namespaces := []string{}
+	for i := range managedClusters.Items {
+		// the managed cluster namespace has the same name as the managed cluster
+		namespaces = appendUnique(namespaces, managedClusters.Items[i].Name)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// set the IncludedNamespaces of the managed clusters backup to the namespaces of the managed clusters
+// in the BackupSchedule ManagedClusterSets; returns true if the included namespaces were changed
+func setManagedClusterSetsNamespaces(
+	ctx context.Context,
+	c client.Client,
+	veleroBackupTemplate *veleroapi.BackupSpec,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	if len(backupSchedule.Spec.ManagedClusterSets) == 0 {
+		if len(veleroBackupTemplate.IncludedNamespaces) == 0 {
+			return false
+		}
+		veleroBackupTemplate.IncludedNamespaces = nil
+		return true
+	}
+
+	clusterNamespaces, err := getManagedClusterSetsNamespaces(ctx, c, backupSchedule.Spec.ManagedClusterSets)
+	if err != nil {
+		log.FromContext(ctx).Info("cannot get the managed clusters for the ManagedClusterSets",
+			"managedClusterSets", backupSchedule.Spec.ManagedClusterSets, "error", err.Error())
+		return false
+	}
+	namespaces := []string{}
+	for i := range clusterNamespaces {
+		// velero rejects a namespace both included and excluded
+		if !findValue(veleroBackupTemplate.ExcludedNamespaces, clusterNamespaces[i]) {
+			namespaces = append(namespaces, clusterNamespaces[i])
+		}
+	}
+	if len(namespaces) == 0 {
+		// no managed clusters in these sets; an empty list would include all namespaces,
+		// so use the BackupSchedule namespace, which has no managed cluster resources
+		namespaces = []string{backupSchedule.Namespace}
+	}
+	if sortCompare(namespaces, veleroBackupTemplate.IncludedNamespaces) {
+		return false
+	}
+	veleroBackupTemplate.IncludedNamespaces = namespaces
+	return true
 }
 
 // set validation backup information
@@ -348,6 +1015,65 @@ func getValidationBackupTTL(VeleroSchedule string) v1.Duration {
 	return validationTTL
 }
 
+// returns the cron used by the velero schedule of the given type;
+// when the StaggerSchedules option is set, the managed clusters schedule starts one step
+// after the credentials schedule, and the resources schedules two steps after it.
+// The step is a third of the interval between two backups, up to maxScheduleStagger,
+// and is added to the cron minute field; the cron is not changed if the minute field is not a number.
+// The resources, generic resources and configmaps schedules use the same cron, since the generic
+// resources and configmaps backups are restored with the resources backup created at the same time.
+func getScheduleCron(
+	backupSchedule *v1beta1.BackupSchedule,
+	scheduleKey ResourceType,
+) string {
+	veleroSchedule := backupSchedule.Spec.VeleroSchedule
+	if !backupSchedule.Spec.StaggerSchedules {
+		return veleroSchedule
+	}
+
+	steps := 0
+	switch scheduleKey {
+	case ManagedClusters:
+		steps = 1
+	case Resources, ResourcesGeneric, ConfigMaps:
+		steps = 2
+	}
+	if steps == 0 {
+		return veleroSchedule
+	}
+
+	cronSchedule, err := cron.ParseStandard(veleroSchedule)
+	if err != nil {
+		return veleroSchedule
+	}
+	// use a fixed time so the same cron is always computed for this schedule
+	nextRunTime := cronSchedule.Next(scheduleStaggerReferenceTime)
+	step := cronSchedule.Next(nextRunTime).Sub(nextRunTime) / 3
+	if step > maxScheduleStagger {
+		step = maxScheduleStagger
+	}
+	stepMinutes := int(step / time.Minute)
+	if stepMinutes == 0 {
+		return veleroSchedule
+	}
+
+	fields := strings.Fields(veleroSchedule)
+	minuteIndex := 0
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		minuteIndex = 1
+	}
+	if len(fields) <= minuteIndex {
+		return veleroSchedule
+	}
+	minute, err := strconv.Atoi(fields[minuteIndex])
+	if err != nil {
+		return veleroSchedule
+	}
+	fields[minuteIndex] = strconv.Itoa((minute + steps*stepMinutes) % 60)
+
+	return strings.Join(fields, " ")
+}
+
 // creates the list of backup resources based on backup type
 func getResourcesByBackupType(
 	resourcesToBackup []string,
@@ -454,6 +1180,46 @@ func getResourcesToBackup(
 	return backupResourceNames
 }
 
+// returns the resources backed up by this BackupSchedule,
+// including the OpenShift resources if the IncludeOpenShiftResources option is set
+// the BackupSchedule and Restore resources if the BackupOperatorConfig option is set,
+// the addon configuration resources if the IncludeAddonConfig option is set
+// and the observability resources if the IncludeObservabilityConfig option is set
+func getScheduleResourcesToBackup(
+	ctx context.Context,
+	dc discovery.DiscoveryInterface,
+	backupSchedule *v1beta1.BackupSchedule,
+) []string {
+	resourcesToBackup := getResourcesToBackup(ctx, dc)
+	if !backupSchedule.Spec.IncludeOpenShiftResources && !backupSchedule.Spec.BackupOperatorConfig &&
+		!backupSchedule.Spec.IncludeAddonConfig && !backupSchedule.Spec.IncludeObservabilityConfig {
+		return resourcesToBackup
+	}
+
+	resourcesToBackup = append([]string{}, resourcesToBackup...)
+	if backupSchedule.Spec.IncludeOpenShiftResources {
+		for _, resource := range openShiftBackupResources {
+			resourcesToBackup = appendUnique(resourcesToBackup, resource)
+		}
+	}
+	if backupSchedule.Spec.BackupOperatorConfig {
+		for _, resource := range operatorConfigBackupResources {
+			resourcesToBackup = appendUnique(resourcesToBackup, resource)
+		}
+	}
+	if backupSchedule.Spec.IncludeAddonConfig {
+		for _, resource := range addonConfigBackupResources {
+			resourcesToBackup = appendUnique(resourcesToBackup, resource)
+		}
+	}
+	if backupSchedule.Spec.IncludeObservabilityConfig {
+		for _, resource := range observabilityBackupResources {
+			resourcesToBackup = appendUnique(resourcesToBackup, resource)
+		}
+	}
+	return resourcesToBackup
+}
+
 func processResourcesToBackup(
 	ctx context.Context,
 	dc discovery.DiscoveryInterface,
@@ -570,6 +1336,104 @@ func cleanupExpiredValidationBackups(
 	}
 }
 
+// delete the backups created by the BackupSchedule on this cluster, which were completed
+// before the MaxBackupRetentionDuration window; returns the names of the deleted backups.
+// No backups are deleted if the BackupSchedule DisableCleanup option is set or if a restore
+// is running in the BackupSchedule namespace, and the backups used by running velero restores are kept
+func cleanupBackups(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+	clusterID string,
+) []string {
+	backupLogger := log.FromContext(ctx)
+	deletedBackups := []string{}
+
+	retention := backupSchedule.Spec.MaxBackupRetentionDuration.Duration
+	if retention <= 0 || backupSchedule.Spec.DisableCleanup {
+		return deletedBackups
+	}
+
+	// the running restore could use one of the backups older than the retention duration
+	if restoreName := isRestoreRunning(ctx, c, backupSchedule); restoreName != "" {
+		backupLogger.Info(fmt.Sprintf("restore %s is running, skip the backups retention cleanup",
+			restoreName))
+		return deletedBackups
+	}
+
+	// validation backups are deleted when they expire, they are not part of the retention cleanup
+	scheduleNames := []string{configMapsScheduleName}
+	for resourceType, scheduleName := range veleroScheduleNames {
+		if resourceType != ValidationSchedule {
+			scheduleNames = append(scheduleNames, scheduleName)
+		}
+	}
+	scheduleNameReq, _ := labels.NewRequirement(BackupVeleroLabel, selection.In, scheduleNames)
+	clusterReq, _ := labels.NewRequirement(BackupScheduleClusterLabel, selection.Equals, []string{clusterID})
+	selector := labels.NewSelector().Add(*scheduleNameReq, *clusterReq)
+
+	retentionStart := v1.Now().Add(-retention)
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		veleroBackupList := veleroapi.BackupList{}
+		if err := c.List(ctx, &veleroBackupList, &client.ListOptions{
+			Namespace:     veleroNamespace,
+			LabelSelector: selector,
+		}); err != nil {
+			backupLogger.Error(err, "failed to list backups for retention cleanup", "namespace", veleroNamespace)
+			continue
+		}
+
+		restoredBackups := getBackupsUsedByRunningRestores(ctx, c, veleroNamespace)
+		for i := range veleroBackupList.Items {
+			backup := veleroBackupList.Items[i]
+			if backup.Status.CompletionTimestamp == nil ||
+				!backup.Status.CompletionTimestamp.Time.Before(retentionStart) {
+				continue
+			}
+			if _, found := find(restoredBackups, backup.Name); found {
+				backupLogger.Info(fmt.Sprintf("backup %s is used by a running restore, skip deleting it",
+					backup.Name))
+				continue
+			}
+			backupLogger.Info(fmt.Sprintf("backup %s is older than the retention duration %s, attempt to delete it",
+				backup.Name, retention))
+			if err := deleteBackup(ctx, &backup, c); err == nil {
+				deletedBackups = append(deletedBackups, backup.Name)
+			}
+		}
+	}
+	return deletedBackups
+}
+
+// returns the names of the backups used by the velero restores not yet completed in the namespace
+func getBackupsUsedByRunningRestores(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+) []string {
+	backupNames := []string{}
+
+	veleroRestoreList := veleroapi.RestoreList{}
+	if err := c.List(ctx, &veleroRestoreList, client.InNamespace(namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list velero restores")
+		return backupNames
+	}
+	for i := range veleroRestoreList.Items {
+		veleroRestore := veleroRestoreList.Items[i]
+		switch veleroRestore.Status.Phase {
+		case veleroapi.RestorePhaseCompleted,
+			veleroapi.RestorePhasePartiallyFailed,
+			veleroapi.RestorePhaseFailed,
+			veleroapi.RestorePhaseFailedValidation:
+			continue
+		}
+		if veleroRestore.Spec.BackupName != "" {
+			backupNames = appendUnique(backupNames, veleroRestore.Spec.BackupName)
+		}
+	}
+	return backupNames
+}
+
 // delete backup using a deletebackuprequest
 func deleteBackup(
 	ctx context.Context,
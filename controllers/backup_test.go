@@ -2,19 +2,26 @@ package controllers
 
 import (
 	"context"
+	"maps"
 	"math/rand"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 )
 
@@ -55,9 +62,10 @@ var _ = Describe("Backup", func() {
 			// returns the concatenated strings, no trimming
 			Expect(getValidKsRestoreName("a", "b")).Should(Equal("a-b"))
 
-			// returns substring of length 252
+			// returns truncated name of length 252, ending with a hash suffix
 			longName := RandStringBytesMask(260)
-			Expect(getValidKsRestoreName(longName, "b")).Should(Equal(longName[:252]))
+			Expect(getValidKsRestoreName(longName, "b")).Should(HaveLen(252))
+			Expect(getValidKsRestoreName(longName, "b")).Should(HavePrefix(longName[:243]))
 
 			Expect(isBackupFinished(nil)).Should(BeFalse())
 
@@ -325,3 +333,1424 @@ func Test_deleteBackup(t *testing.T) {
 		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
 }
+
+func Test_updateExcludedAddonNamespaces(t *testing.T) {
+	newSchedule := func(excluded []string, annotation string) *veleroapi.Schedule {
+		schedule := createSchedule(veleroScheduleNames[Resources], "ns").object
+		schedule.Spec.Template.ExcludedNamespaces = excluded
+		if annotation != "" {
+			schedule.SetAnnotations(map[string]string{
+				ExcludedAddonNamespacesAnnotation: annotation,
+			})
+		}
+		return schedule
+	}
+
+	tests := []struct {
+		name            string
+		schedule        *veleroapi.Schedule
+		addonNamespaces []string
+		wantUpdated     bool
+		wantExcluded    []string
+		wantAnnotation  string
+	}{
+		{
+			name:            "no addon namespaces",
+			schedule:        newSchedule([]string{"ns", "local-cluster"}, ""),
+			addonNamespaces: nil,
+			wantUpdated:     false,
+			wantExcluded:    []string{"ns", "local-cluster"},
+			wantAnnotation:  "",
+		},
+		{
+			name:            "addon namespaces added",
+			schedule:        newSchedule([]string{"ns", "local-cluster"}, ""),
+			addonNamespaces: []string{"addon-ns-1", "addon-ns-2", "addon-ns-1"},
+			wantUpdated:     true,
+			wantExcluded:    []string{"ns", "local-cluster", "addon-ns-1", "addon-ns-2"},
+			wantAnnotation:  "addon-ns-1,addon-ns-2",
+		},
+		{
+			name: "addon namespaces not changed",
+			schedule: newSchedule([]string{"ns", "local-cluster", "addon-ns-1", "addon-ns-2"},
+				"addon-ns-1,addon-ns-2"),
+			addonNamespaces: []string{"addon-ns-2", "addon-ns-1"},
+			wantUpdated:     false,
+			wantExcluded:    []string{"ns", "local-cluster", "addon-ns-1", "addon-ns-2"},
+			wantAnnotation:  "addon-ns-1,addon-ns-2",
+		},
+		{
+			name: "addon namespace removed",
+			schedule: newSchedule([]string{"ns", "local-cluster", "addon-ns-1", "addon-ns-2"},
+				"addon-ns-1,addon-ns-2"),
+			addonNamespaces: []string{"addon-ns-2"},
+			wantUpdated:     true,
+			wantExcluded:    []string{"ns", "local-cluster", "addon-ns-2"},
+			wantAnnotation:  "addon-ns-2",
+		},
+		{
+			name: "all addon namespaces removed",
+			schedule: newSchedule([]string{"ns", "local-cluster", "addon-ns-1"},
+				"addon-ns-1"),
+			addonNamespaces: []string{},
+			wantUpdated:     true,
+			wantExcluded:    []string{"ns", "local-cluster"},
+			wantAnnotation:  "",
+		},
+		{
+			name:            "addon namespace already excluded by the backup",
+			schedule:        newSchedule([]string{"ns", "local-cluster"}, ""),
+			addonNamespaces: []string{"local-cluster"},
+			wantUpdated:     false,
+			wantExcluded:    []string{"ns", "local-cluster"},
+			wantAnnotation:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateExcludedAddonNamespaces(tt.schedule, tt.addonNamespaces); got != tt.wantUpdated {
+				t.Errorf("updateExcludedAddonNamespaces() = %v, want %v", got, tt.wantUpdated)
+			}
+			if !sortCompare(tt.schedule.Spec.Template.ExcludedNamespaces, tt.wantExcluded) {
+				t.Errorf("updateExcludedAddonNamespaces() ExcludedNamespaces = %v, want %v",
+					tt.schedule.Spec.Template.ExcludedNamespaces, tt.wantExcluded)
+			}
+			if got := tt.schedule.GetAnnotations()[ExcludedAddonNamespacesAnnotation]; got != tt.wantAnnotation {
+				t.Errorf("updateExcludedAddonNamespaces() annotation = %v, want %v", got, tt.wantAnnotation)
+			}
+		})
+	}
+}
+
+func Test_updateIncludedNamespaces(t *testing.T) {
+	tests := []struct {
+		name               string
+		scheduleNamespaces []string
+		includedNamespaces []string
+		wantUpdated        bool
+		wantIncluded       []string
+	}{
+		{
+			name:               "no included namespaces",
+			scheduleNamespaces: nil,
+			includedNamespaces: nil,
+			wantUpdated:        false,
+			wantIncluded:       nil,
+		},
+		{
+			name:               "included namespaces set",
+			scheduleNamespaces: nil,
+			includedNamespaces: []string{"app-ns-1", "app-ns-2", "app-ns-1", ""},
+			wantUpdated:        true,
+			wantIncluded:       []string{"app-ns-1", "app-ns-2"},
+		},
+		{
+			name:               "included namespaces not changed",
+			scheduleNamespaces: []string{"app-ns-1", "app-ns-2"},
+			includedNamespaces: []string{"app-ns-2", "app-ns-1"},
+			wantUpdated:        false,
+			wantIncluded:       []string{"app-ns-1", "app-ns-2"},
+		},
+		{
+			name:               "included namespaces removed",
+			scheduleNamespaces: []string{"app-ns-1", "app-ns-2"},
+			includedNamespaces: nil,
+			wantUpdated:        true,
+			wantIncluded:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := createSchedule(veleroScheduleNames[Resources], "ns").object
+			schedule.Spec.Template.IncludedNamespaces = tt.scheduleNamespaces
+
+			if got := updateIncludedNamespaces(schedule, tt.includedNamespaces); got != tt.wantUpdated {
+				t.Errorf("updateIncludedNamespaces() = %v, want %v", got, tt.wantUpdated)
+			}
+			if !sortCompare(schedule.Spec.Template.IncludedNamespaces, tt.wantIncluded) {
+				t.Errorf("updateIncludedNamespaces() IncludedNamespaces = %v, want %v",
+					schedule.Spec.Template.IncludedNamespaces, tt.wantIncluded)
+			}
+		})
+	}
+}
+
+func Test_validateIncludedNamespaces(t *testing.T) {
+	tests := []struct {
+		name           string
+		backupSchedule *v1beta1.BackupSchedule
+		wantValid      bool
+	}{
+		{
+			name:           "no included namespaces",
+			backupSchedule: createBackupSchedule("acm", "backup-ns").object,
+			wantValid:      true,
+		},
+		{
+			name: "valid included namespaces",
+			backupSchedule: createBackupSchedule("acm", "backup-ns").
+				includedNamespaces([]string{"app-ns-1", "app-ns-2"}).
+				excludedAddonNamespaces([]string{"addon-ns"}).object,
+			wantValid: true,
+		},
+		{
+			name: "backup namespace is included",
+			backupSchedule: createBackupSchedule("acm", "backup-ns").
+				includedNamespaces([]string{"app-ns-1", "backup-ns"}).object,
+			wantValid: false,
+		},
+		{
+			name: "local cluster namespace is included",
+			backupSchedule: createBackupSchedule("acm", "backup-ns").
+				includedNamespaces([]string{"local-cluster"}).object,
+			wantValid: false,
+		},
+		{
+			name: "excluded addon namespace is included",
+			backupSchedule: createBackupSchedule("acm", "backup-ns").
+				includedNamespaces([]string{"app-ns-1", "addon-ns"}).
+				excludedAddonNamespaces([]string{"addon-ns"}).object,
+			wantValid: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateIncludedNamespaces(tt.backupSchedule, "local-cluster"); (got == "") != tt.wantValid {
+				t.Errorf("validateIncludedNamespaces() = %v, want valid %v", got, tt.wantValid)
+			}
+		})
+	}
+}
+
+func Test_cleanupBackups(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	clusterID := "cluster-1"
+	now := metav1.Now()
+	newBackup := func(name string, schedule string, cluster string, age time.Duration) *veleroapi.Backup {
+		backup := createBackup(name, ns).
+			labels(map[string]string{
+				BackupVeleroLabel:          schedule,
+				BackupScheduleClusterLabel: cluster,
+			})
+		if age > 0 {
+			backup = backup.completionTimestamp(metav1.NewTime(now.Add(-age)))
+		}
+		return backup.object
+	}
+	backups := []client.Object{
+		newBackup("creds-1d", veleroScheduleNames[Credentials], clusterID, time.Hour*24),
+		newBackup("creds-10d", veleroScheduleNames[Credentials], clusterID, time.Hour*24*10),
+		newBackup("resources-31d", veleroScheduleNames[Resources], clusterID, time.Hour*24*31),
+		newBackup("clusters-40d", veleroScheduleNames[ManagedClusters], clusterID, time.Hour*24*40),
+		newBackup("clusters-in-progress", veleroScheduleNames[ManagedClusters], clusterID, 0),
+		newBackup("other-cluster-40d", veleroScheduleNames[Resources], "cluster-2", time.Hour*24*40),
+		newBackup("validation-40d", veleroScheduleNames[ValidationSchedule], clusterID, time.Hour*24*40),
+	}
+
+	tests := []struct {
+		name           string
+		retention      metav1.Duration
+		disableCleanup bool
+		restores       []client.Object
+		wantDeleted    []string
+	}{
+		{
+			name:        "no retention duration",
+			retention:   metav1.Duration{},
+			wantDeleted: []string{},
+		},
+		{
+			name:        "retention duration of 30 days",
+			retention:   metav1.Duration{Duration: time.Hour * 24 * 30},
+			wantDeleted: []string{"resources-31d", "clusters-40d"},
+		},
+		{
+			name:        "retention duration of 5 days",
+			retention:   metav1.Duration{Duration: time.Hour * 24 * 5},
+			wantDeleted: []string{"creds-10d", "resources-31d", "clusters-40d"},
+		},
+		{
+			name:        "retention duration of 60 days",
+			retention:   metav1.Duration{Duration: time.Hour * 24 * 60},
+			wantDeleted: []string{},
+		},
+		{
+			name:           "cleanup disabled with backups older than the retention duration",
+			retention:      metav1.Duration{Duration: time.Hour * 24 * 5},
+			disableCleanup: true,
+			wantDeleted:    []string{},
+		},
+		{
+			name:      "velero restore in progress using a backup older than the retention duration",
+			retention: metav1.Duration{Duration: time.Hour * 24 * 5},
+			restores: []client.Object{
+				createRestore("restore-resources", ns).backupName("resources-31d").
+					phase(veleroapi.RestorePhaseInProgress).object,
+				createRestore("restore-creds", ns).backupName("creds-10d").
+					phase(veleroapi.RestorePhaseCompleted).object,
+			},
+			wantDeleted: []string{"creds-10d", "clusters-40d"},
+		},
+		{
+			name:      "restore running in the namespace",
+			retention: metav1.Duration{Duration: time.Hour * 24 * 5},
+			restores: []client.Object{
+				createACMRestore("acm-restore", ns).phase(v1beta1.RestorePhaseRunning).object,
+			},
+			wantDeleted: []string{},
+		},
+		{
+			name:      "restore finished in the namespace",
+			retention: metav1.Duration{Duration: time.Hour * 24 * 5},
+			restores: []client.Object{
+				createACMRestore("acm-restore", ns).phase(v1beta1.RestorePhaseFinished).object,
+			},
+			wantDeleted: []string{"creds-10d", "resources-31d", "clusters-40d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(append(tt.restores, backups...)...).
+				Build()
+
+			backupSchedule := createBackupSchedule("acm", ns).
+				maxBackupRetentionDuration(tt.retention).
+				disableCleanup(tt.disableCleanup).object
+			got := cleanupBackups(context.Background(), fakeClient, backupSchedule, clusterID)
+			if !sortCompare(got, tt.wantDeleted) {
+				t.Errorf("cleanupBackups() = %v, want %v", got, tt.wantDeleted)
+			}
+
+			deleteRequests := veleroapi.DeleteBackupRequestList{}
+			if err := fakeClient.List(context.Background(), &deleteRequests); err != nil {
+				t.Fatalf("Error listing delete requests: %s", err.Error())
+			}
+			if len(deleteRequests.Items) != len(tt.wantDeleted) {
+				t.Errorf("cleanupBackups() created %v DeleteBackupRequests, want %v",
+					len(deleteRequests.Items), len(tt.wantDeleted))
+			}
+		})
+	}
+}
+
+func Test_parseVeleroScheduleNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    map[ResourceType]string
+		wantErr bool
+	}{
+		{
+			name: "no data",
+			data: nil,
+			want: map[ResourceType]string{},
+		},
+		{
+			name: "valid schedule names",
+			data: map[string]string{
+				"resources":   " hub1-resources-schedule ",
+				"credentials": "hub1-credentials-schedule",
+			},
+			want: map[ResourceType]string{
+				Resources:   "hub1-resources-schedule",
+				Credentials: "hub1-credentials-schedule",
+			},
+		},
+		{
+			name:    "unknown resource type",
+			data:    map[string]string{"secrets": "hub1-secrets-schedule"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid schedule name",
+			data:    map[string]string{"resources": "Hub1_Resources"},
+			wantErr: true,
+		},
+		{
+			name:    "schedule name too long for a label value",
+			data:    map[string]string{"resources": strings.Repeat("a", 64)},
+			wantErr: true,
+		},
+		{
+			name: "schedule name used by two resource types",
+			data: map[string]string{
+				"resources":   "hub1-schedule",
+				"credentials": "hub1-schedule",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "schedule name used by a default schedule",
+			data:    map[string]string{"resources": veleroScheduleNames[Credentials]},
+			wantErr: true,
+		},
+		{
+			name: "backed up resources ignored",
+			data: map[string]string{
+				"resources":                 "hub1-resources-schedule",
+				"managedClusters.resources": managedClusterResourceName,
+			},
+			want: map[ResourceType]string{
+				Resources: "hub1-resources-schedule",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVeleroScheduleNames(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseVeleroScheduleNames() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseVeleroScheduleNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseManagedClusterResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no backed up resources",
+			data: map[string]string{"resources": "hub1-resources-schedule"},
+			want: nil,
+		},
+		{
+			name: "managed clusters backup resources",
+			data: map[string]string{
+				"managedClusters.resources": " " + managedClusterResourceName +
+					", clusterdeployment.hive.openshift.io,,placement.cluster.open-cluster-management.io, " +
+					managedClusterResourceName,
+			},
+			want: []string{
+				managedClusterResourceName,
+				"clusterdeployment.hive.openshift.io",
+				"placement.cluster.open-cluster-management.io",
+			},
+		},
+		{
+			name:    "resources of another backup",
+			data:    map[string]string{"resources.resources": "placement.cluster.open-cluster-management.io"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid resource name",
+			data:    map[string]string{"managedClusters.resources": managedClusterResourceName + ",Placement"},
+			wantErr: true,
+		},
+		{
+			name:    "managed cluster resource not listed",
+			data:    map[string]string{"managedClusters.resources": "clusterdeployment.hive.openshift.io"},
+			wantErr: true,
+		},
+		{
+			name:    "no resource listed",
+			data:    map[string]string{"managedClusters.resources": " , "},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseManagedClusterResources(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseManagedClusterResources() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseManagedClusterResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_LoadVeleroScheduleConfig(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "operator-ns"
+	t.Setenv(operatorNamespaceEnv, ns)
+
+	validConfigMap := createConfigMap("valid-names", ns, nil)
+	validConfigMap.Data = map[string]string{"resources": "hub1-resources-schedule"}
+	invalidConfigMap := createConfigMap("invalid-names", ns, nil)
+	invalidConfigMap.Data = map[string]string{"resources": "Hub1_Resources"}
+	validResourcesConfigMap := createConfigMap("valid-resources", ns, nil)
+	validResourcesConfigMap.Data = map[string]string{
+		"resources":                 "hub1-resources-schedule",
+		"managedClusters.resources": managedClusterResourceName + ",placement.cluster.open-cluster-management.io",
+	}
+	invalidResourcesConfigMap := createConfigMap("invalid-resources", ns, nil)
+	invalidResourcesConfigMap.Data = map[string]string{
+		"resources":                 "hub1-resources-schedule",
+		"managedClusters.resources": "placement.cluster.open-cluster-management.io",
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).
+		WithObjects(validConfigMap, invalidConfigMap, validResourcesConfigMap, invalidResourcesConfigMap).Build()
+
+	defaultManagedClusterResources := append([]string{}, backupManagedClusterResources...)
+	tests := []struct {
+		name                        string
+		configMapName               string
+		wantErr                     bool
+		wantResources               string
+		wantManagedClusterResources []string
+	}{
+		{
+			name:                        "no ConfigMap name, use defaults",
+			configMapName:               "",
+			wantResources:               "acm-resources-schedule",
+			wantManagedClusterResources: defaultManagedClusterResources,
+		},
+		{
+			name:                        "ConfigMap not found, use defaults",
+			configMapName:               "missing-names",
+			wantErr:                     true,
+			wantResources:               "acm-resources-schedule",
+			wantManagedClusterResources: defaultManagedClusterResources,
+		},
+		{
+			name:                        "invalid ConfigMap, use defaults",
+			configMapName:               "invalid-names",
+			wantErr:                     true,
+			wantResources:               "acm-resources-schedule",
+			wantManagedClusterResources: defaultManagedClusterResources,
+		},
+		{
+			name:                        "valid ConfigMap",
+			configMapName:               "valid-names",
+			wantResources:               "hub1-resources-schedule",
+			wantManagedClusterResources: defaultManagedClusterResources,
+		},
+		{
+			name:          "valid ConfigMap with the managed clusters backup resources",
+			configMapName: "valid-resources",
+			wantResources: "hub1-resources-schedule",
+			wantManagedClusterResources: []string{
+				managedClusterResourceName,
+				"placement.cluster.open-cluster-management.io",
+			},
+		},
+		{
+			name:                        "invalid managed clusters backup resources, use defaults",
+			configMapName:               "invalid-resources",
+			wantErr:                     true,
+			wantResources:               "acm-resources-schedule",
+			wantManagedClusterResources: defaultManagedClusterResources,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// restore the defaults after each test
+			defaultScheduleNames := maps.Clone(veleroScheduleNames)
+			defaultBackupNames := maps.Clone(veleroBackupNames)
+			defer func() {
+				veleroScheduleNames = defaultScheduleNames
+				veleroBackupNames = defaultBackupNames
+				backupManagedClusterResources = defaultManagedClusterResources
+			}()
+
+			err := LoadVeleroScheduleConfig(context.Background(), fakeClient, tt.configMapName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadVeleroScheduleConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if veleroScheduleNames[Resources] != tt.wantResources {
+				t.Errorf("veleroScheduleNames[Resources] = %v, want %v",
+					veleroScheduleNames[Resources], tt.wantResources)
+			}
+			if veleroBackupNames[Resources] != tt.wantResources {
+				t.Errorf("veleroBackupNames[Resources] = %v, want %v",
+					veleroBackupNames[Resources], tt.wantResources)
+			}
+			if veleroScheduleNames[Credentials] != "acm-credentials-schedule" {
+				t.Errorf("veleroScheduleNames[Credentials] = %v, want the default name",
+					veleroScheduleNames[Credentials])
+			}
+			if !reflect.DeepEqual(backupManagedClusterResources, tt.wantManagedClusterResources) {
+				t.Errorf("backupManagedClusterResources = %v, want %v",
+					backupManagedClusterResources, tt.wantManagedClusterResources)
+			}
+		})
+	}
+}
+
+func Test_getScheduleCron(t *testing.T) {
+	tests := []struct {
+		name           string
+		backupSchedule *v1beta1.BackupSchedule
+		want           map[ResourceType]string
+	}{
+		{
+			name:           "stagger not enabled",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("0 */2 * * *").object,
+			want: map[ResourceType]string{
+				Credentials:        "0 */2 * * *",
+				ManagedClusters:    "0 */2 * * *",
+				Resources:          "0 */2 * * *",
+				ResourcesGeneric:   "0 */2 * * *",
+				ValidationSchedule: "0 */2 * * *",
+			},
+		},
+		{
+			name: "stagger enabled, step limited to 5 minutes",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("0 */2 * * *").
+				staggerSchedules(true).object,
+			want: map[ResourceType]string{
+				Credentials:        "0 */2 * * *",
+				ManagedClusters:    "5 */2 * * *",
+				Resources:          "10 */2 * * *",
+				ResourcesGeneric:   "10 */2 * * *",
+				ValidationSchedule: "0 */2 * * *",
+			},
+		},
+		{
+			name: "stagger enabled, step is a third of the interval",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("50 * * * *").
+				staggerSchedules(true).object,
+			want: map[ResourceType]string{
+				Credentials:     "50 * * * *",
+				ManagedClusters: "55 * * * *",
+				Resources:       "0 * * * *",
+			},
+		},
+		{
+			name: "stagger enabled, minute field is not a number",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("*/6 * * * *").
+				staggerSchedules(true).object,
+			want: map[ResourceType]string{
+				Credentials:     "*/6 * * * *",
+				ManagedClusters: "*/6 * * * *",
+				Resources:       "*/6 * * * *",
+			},
+		},
+		{
+			name: "stagger enabled, cron with a timezone",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("CRON_TZ=Europe/Paris 0 1 * * *").
+				staggerSchedules(true).object,
+			want: map[ResourceType]string{
+				Credentials:     "CRON_TZ=Europe/Paris 0 1 * * *",
+				ManagedClusters: "CRON_TZ=Europe/Paris 5 1 * * *",
+				Resources:       "CRON_TZ=Europe/Paris 10 1 * * *",
+			},
+		},
+		{
+			name: "stagger enabled, descriptor not changed",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("@daily").
+				staggerSchedules(true).object,
+			want: map[ResourceType]string{
+				Credentials:     "@daily",
+				ManagedClusters: "@daily",
+				Resources:       "@daily",
+			},
+		},
+		{
+			name: "stagger enabled, invalid cron not changed",
+			backupSchedule: createBackupSchedule("name", "ns").schedule("invalid").
+				staggerSchedules(true).object,
+			want: map[ResourceType]string{
+				ManagedClusters: "invalid",
+				Resources:       "invalid",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for scheduleKey, want := range tt.want {
+				if got := getScheduleCron(tt.backupSchedule, scheduleKey); got != want {
+					t.Errorf("getScheduleCron(%s) = %v, want %v", scheduleKey, got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_setCredsBackupInfo(t *testing.T) {
+	userSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"backup-creds": "true"},
+	}
+	tests := []struct {
+		name              string
+		userSelectors     []*metav1.LabelSelector
+		wantSelectorCount int
+	}{
+		{
+			name:              "no user selectors",
+			wantSelectorCount: 3,
+		},
+		{
+			name:              "user selector merged with the default selectors",
+			userSelectors:     []*metav1.LabelSelector{userSelector, nil},
+			wantSelectorCount: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroBackupTemplate := &veleroapi.BackupSpec{}
+			setCredsBackupInfo(veleroBackupTemplate, tt.userSelectors)
+
+			selectors := veleroBackupTemplate.OrLabelSelectors
+			if len(selectors) != tt.wantSelectorCount {
+				t.Fatalf("setCredsBackupInfo() OrLabelSelectors = %v, want %d selectors",
+					selectors, tt.wantSelectorCount)
+			}
+			// default selectors are set first
+			for i, label := range []string{backupCredsHiveLabel, backupCredsUserLabel, backupCredsClusterLabel} {
+				if selectors[i].MatchExpressions[0].Key != label {
+					t.Errorf("setCredsBackupInfo() OrLabelSelectors[%d] = %v, want selector for %s",
+						i, selectors[i], label)
+				}
+			}
+			if len(tt.userSelectors) > 0 {
+				if !reflect.DeepEqual(selectors[3], userSelector) {
+					t.Errorf("setCredsBackupInfo() OrLabelSelectors[3] = %v, want %v", selectors[3], userSelector)
+				}
+				if selectors[3] == userSelector {
+					t.Errorf("setCredsBackupInfo() user selector should be copied")
+				}
+			}
+		})
+	}
+}
+
+func Test_setConfigMapsBackupInfo(t *testing.T) {
+	cmSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app-config": "true"},
+	}
+
+	veleroBackupTemplate := &veleroapi.BackupSpec{}
+	setConfigMapsBackupInfo(veleroBackupTemplate, cmSelector)
+
+	if !reflect.DeepEqual(veleroBackupTemplate.IncludedResources, []string{"configmap"}) {
+		t.Errorf("setConfigMapsBackupInfo() IncludedResources = %v, want only configmaps",
+			veleroBackupTemplate.IncludedResources)
+	}
+	if veleroBackupTemplate.IncludeClusterResources == nil || *veleroBackupTemplate.IncludeClusterResources {
+		t.Errorf("setConfigMapsBackupInfo() IncludeClusterResources = %v, want false",
+			veleroBackupTemplate.IncludeClusterResources)
+	}
+	if !reflect.DeepEqual(veleroBackupTemplate.LabelSelector, cmSelector) {
+		t.Errorf("setConfigMapsBackupInfo() LabelSelector = %v, want %v",
+			veleroBackupTemplate.LabelSelector, cmSelector)
+	}
+	if veleroBackupTemplate.LabelSelector == cmSelector {
+		t.Errorf("setConfigMapsBackupInfo() selector should be copied")
+	}
+	if len(veleroBackupTemplate.OrLabelSelectors) != 0 {
+		t.Errorf("setConfigMapsBackupInfo() OrLabelSelectors = %v, want none",
+			veleroBackupTemplate.OrLabelSelectors)
+	}
+}
+
+func Test_updateConfigMapsLabelSelector(t *testing.T) {
+	oldSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app-config": "true"},
+	}
+	newSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app-config": "prod"},
+	}
+	tests := []struct {
+		name         string
+		selector     *metav1.LabelSelector
+		want         bool
+		wantSelector *metav1.LabelSelector
+	}{
+		{
+			name:         "selector not changed",
+			selector:     oldSelector.DeepCopy(),
+			want:         false,
+			wantSelector: oldSelector,
+		},
+		{
+			name:         "selector changed",
+			selector:     newSelector,
+			want:         true,
+			wantSelector: newSelector,
+		},
+		{
+			name:         "selector removed, the schedule is deleted instead",
+			selector:     nil,
+			want:         false,
+			wantSelector: oldSelector,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroSchedule := &veleroapi.Schedule{}
+			veleroSchedule.Name = configMapsScheduleName
+			setConfigMapsBackupInfo(&veleroSchedule.Spec.Template, oldSelector)
+
+			if got := updateConfigMapsLabelSelector(veleroSchedule, tt.selector); got != tt.want {
+				t.Errorf("updateConfigMapsLabelSelector() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(veleroSchedule.Spec.Template.LabelSelector, tt.wantSelector) {
+				t.Errorf("updateConfigMapsLabelSelector() LabelSelector = %v, want %v",
+					veleroSchedule.Spec.Template.LabelSelector, tt.wantSelector)
+			}
+		})
+	}
+}
+
+func Test_validateCredentialsOrLabelSelectors(t *testing.T) {
+	tests := []struct {
+		name       string
+		selectors  []*metav1.LabelSelector
+		cmSelector *metav1.LabelSelector
+		wantMsg    string
+	}{
+		{
+			name: "no selectors",
+		},
+		{
+			name: "valid selector",
+			selectors: []*metav1.LabelSelector{
+				{MatchLabels: map[string]string{"backup-creds": "true"}},
+			},
+		},
+		{
+			name:      "empty selector",
+			selectors: []*metav1.LabelSelector{nil},
+			wantMsg:   "invalid CredentialsOrLabelSelectors[0] : selector is empty",
+		},
+		{
+			name: "invalid selector",
+			selectors: []*metav1.LabelSelector{
+				{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "backup-creds", Operator: "Unknown"},
+				}},
+			},
+			wantMsg: "invalid CredentialsOrLabelSelectors[0] : \"Unknown\" is not a valid label selector operator",
+		},
+		{
+			name: "invalid configmap selector",
+			cmSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "backup-cm", Operator: "Unknown"},
+			}},
+			wantMsg: "invalid IncludeConfigMapSelector : \"Unknown\" is not a valid label selector operator",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").
+				credentialsOrLabelSelectors(tt.selectors).
+				includeConfigMapSelector(tt.cmSelector).object
+			if got := validateCredentialsOrLabelSelectors(backupSchedule); got != tt.wantMsg {
+				t.Errorf("validateCredentialsOrLabelSelectors() = %v, want %v", got, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func Test_getScheduleResourcesToBackup(t *testing.T) {
+	fakeDiscovery := &discoveryfake.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "channels", SingularName: "channel", Kind: "Channel", Namespaced: true},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name                      string
+		includeOpenShiftResources bool
+		backupOperatorConfig      bool
+		includeAddonConfig        bool
+		includeObservability      bool
+	}{
+		{
+			name:                      "OpenShift resources not included",
+			includeOpenShiftResources: false,
+		},
+		{
+			name:                      "OpenShift resources included",
+			includeOpenShiftResources: true,
+		},
+		{
+			name:                 "operator configuration included",
+			backupOperatorConfig: true,
+		},
+		{
+			name:               "addon configuration included",
+			includeAddonConfig: true,
+		},
+		{
+			name:                 "observability configuration included",
+			includeObservability: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").
+				includeOpenShiftResources(tt.includeOpenShiftResources).
+				backupOperatorConfig(tt.backupOperatorConfig).
+				includeAddonConfig(tt.includeAddonConfig).
+				includeObservabilityConfig(tt.includeObservability).object
+			resourcesToBackup := getScheduleResourcesToBackup(context.Background(), fakeDiscovery, backupSchedule)
+
+			if !findValue(resourcesToBackup, "channel.apps.open-cluster-management.io") {
+				t.Errorf("getScheduleResourcesToBackup() = %v, should include the hub resources", resourcesToBackup)
+			}
+			// OpenShift, operator and addon configuration resources are backed up only by the resources backup
+			resources := getResourcesByBackupType(resourcesToBackup, Resources)
+			genericResources := getResourcesByBackupType(resourcesToBackup, ResourcesGeneric)
+			for _, resource := range openShiftBackupResources {
+				if findValue(resources, resource) != tt.includeOpenShiftResources {
+					t.Errorf("getResourcesByBackupType(Resources) = %v, %s included should be %v",
+						resources, resource, tt.includeOpenShiftResources)
+				}
+				if findValue(genericResources, resource) {
+					t.Errorf("getResourcesByBackupType(ResourcesGeneric) = %v, should not include %s",
+						genericResources, resource)
+				}
+			}
+			for _, resource := range operatorConfigBackupResources {
+				if findValue(resources, resource) != tt.backupOperatorConfig {
+					t.Errorf("getResourcesByBackupType(Resources) = %v, %s included should be %v",
+						resources, resource, tt.backupOperatorConfig)
+				}
+				if findValue(genericResources, resource) {
+					t.Errorf("getResourcesByBackupType(ResourcesGeneric) = %v, should not include %s",
+						genericResources, resource)
+				}
+			}
+			for _, resource := range addonConfigBackupResources {
+				if findValue(resources, resource) != tt.includeAddonConfig {
+					t.Errorf("getResourcesByBackupType(Resources) = %v, %s included should be %v",
+						resources, resource, tt.includeAddonConfig)
+				}
+				if findValue(genericResources, resource) {
+					t.Errorf("getResourcesByBackupType(ResourcesGeneric) = %v, should not include %s",
+						genericResources, resource)
+				}
+			}
+			for _, resource := range observabilityBackupResources {
+				if findValue(resources, resource) != tt.includeObservability {
+					t.Errorf("getResourcesByBackupType(Resources) = %v, %s included should be %v",
+						resources, resource, tt.includeObservability)
+				}
+				if findValue(genericResources, resource) {
+					t.Errorf("getResourcesByBackupType(ResourcesGeneric) = %v, should not include %s",
+						genericResources, resource)
+				}
+			}
+		})
+	}
+}
+
+func Test_getResourcesBackupHooks(t *testing.T) {
+	newHooks := func(timeout time.Duration, onError veleroapi.HookErrorMode) veleroapi.BackupHooks {
+		return veleroapi.BackupHooks{
+			Resources: []veleroapi.BackupResourceHookSpec{
+				{
+					Name:               "db-freeze",
+					IncludedNamespaces: []string{"db"},
+					PreHooks: []veleroapi.BackupResourceHook{
+						{Exec: &veleroapi.ExecHook{
+							Command: []string{"/bin/freeze"},
+							Timeout: metav1.Duration{Duration: timeout},
+							OnError: onError,
+						}},
+					},
+					PostHooks: []veleroapi.BackupResourceHook{
+						{Exec: &veleroapi.ExecHook{
+							Command: []string{"/bin/unfreeze"},
+							Timeout: metav1.Duration{Duration: timeout},
+							OnError: onError,
+						}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		hooks       veleroapi.BackupHooks
+		hookTimeout time.Duration
+		hookOnError veleroapi.HookErrorMode
+		want        veleroapi.BackupHooks
+	}{
+		{
+			name:        "no hooks",
+			hooks:       veleroapi.BackupHooks{},
+			hookTimeout: time.Minute * 5,
+			hookOnError: veleroapi.HookErrorModeContinue,
+			want:        veleroapi.BackupHooks{},
+		},
+		{
+			name:  "hooks with no defaults",
+			hooks: newHooks(0, ""),
+			want:  newHooks(0, ""),
+		},
+		{
+			name:        "defaults used for the hooks with no timeout and onError",
+			hooks:       newHooks(0, ""),
+			hookTimeout: time.Minute * 5,
+			hookOnError: veleroapi.HookErrorModeContinue,
+			want:        newHooks(time.Minute*5, veleroapi.HookErrorModeContinue),
+		},
+		{
+			name:        "hook timeout and onError take precedence over the defaults",
+			hooks:       newHooks(time.Minute, veleroapi.HookErrorModeFail),
+			hookTimeout: time.Minute * 5,
+			hookOnError: veleroapi.HookErrorModeContinue,
+			want:        newHooks(time.Minute, veleroapi.HookErrorModeFail),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").
+				hooks(tt.hooks).
+				hookTimeout(metav1.Duration{Duration: tt.hookTimeout}).
+				hookOnError(tt.hookOnError).object
+
+			if got := getResourcesBackupHooks(backupSchedule); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getResourcesBackupHooks() = %v, want %v", got, tt.want)
+			}
+			// the BackupSchedule hooks are not changed
+			if !reflect.DeepEqual(backupSchedule.Spec.Hooks, tt.hooks) {
+				t.Errorf("getResourcesBackupHooks() changed the BackupSchedule hooks to %v", backupSchedule.Spec.Hooks)
+			}
+
+			// the resources schedule is updated with the hooks, then left unchanged
+			veleroSchedule := createSchedule(veleroScheduleNames[Resources], "ns").object
+			wantUpdated := !reflect.DeepEqual(tt.want, veleroapi.BackupHooks{})
+			if got := updateResourcesBackupHooks(veleroSchedule, backupSchedule); got != wantUpdated {
+				t.Errorf("updateResourcesBackupHooks() = %v, want %v", got, wantUpdated)
+			}
+			if !reflect.DeepEqual(veleroSchedule.Spec.Template.Hooks, tt.want) {
+				t.Errorf("updateResourcesBackupHooks() hooks = %v, want %v", veleroSchedule.Spec.Template.Hooks, tt.want)
+			}
+			if updateResourcesBackupHooks(veleroSchedule, backupSchedule) {
+				t.Errorf("updateResourcesBackupHooks() = true, want false for unchanged hooks")
+			}
+		})
+	}
+}
+
+func Test_updateScheduleGenerationLabel(t *testing.T) {
+	scheduleLabels := map[string]string{
+		BackupScheduleTypeLabel:    string(Resources),
+		BackupScheduleClusterLabel: "cluster-id",
+	}
+	tests := []struct {
+		name               string
+		veleroSchedule     *veleroapi.Schedule
+		backupSchedule     *v1beta1.BackupSchedule
+		wantUpdated        bool
+		wantTemplateLabels map[string]string
+	}{
+		{
+			name:           "generation not set",
+			veleroSchedule: createSchedule("acm-resources-schedule", "ns").scheduleLabels(scheduleLabels).object,
+			backupSchedule: createBackupSchedule("acm", "ns").object,
+			wantUpdated:    false,
+		},
+		{
+			name:           "new schedule, template labels include the schedule labels",
+			veleroSchedule: createSchedule("acm-resources-schedule", "ns").scheduleLabels(scheduleLabels).object,
+			backupSchedule: createBackupSchedule("acm", "ns").generation(1).object,
+			wantUpdated:    true,
+			wantTemplateLabels: map[string]string{
+				BackupScheduleTypeLabel:       string(Resources),
+				BackupScheduleClusterLabel:    "cluster-id",
+				BackupScheduleGenerationLabel: "1",
+			},
+		},
+		{
+			name: "same generation",
+			veleroSchedule: createSchedule("acm-resources-schedule", "ns").scheduleLabels(scheduleLabels).
+				templateLabels(map[string]string{
+					BackupScheduleTypeLabel:       string(Resources),
+					BackupScheduleGenerationLabel: "2",
+				}).object,
+			backupSchedule: createBackupSchedule("acm", "ns").generation(2).object,
+			wantUpdated:    false,
+			wantTemplateLabels: map[string]string{
+				BackupScheduleTypeLabel:       string(Resources),
+				BackupScheduleGenerationLabel: "2",
+			},
+		},
+		{
+			name: "spec changed",
+			veleroSchedule: createSchedule("acm-resources-schedule", "ns").scheduleLabels(scheduleLabels).
+				templateLabels(map[string]string{
+					BackupScheduleTypeLabel:       string(Resources),
+					BackupScheduleGenerationLabel: "2",
+				}).object,
+			backupSchedule: createBackupSchedule("acm", "ns").generation(3).object,
+			wantUpdated:    true,
+			wantTemplateLabels: map[string]string{
+				BackupScheduleTypeLabel:       string(Resources),
+				BackupScheduleGenerationLabel: "3",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateScheduleGenerationLabel(tt.veleroSchedule, tt.backupSchedule); got != tt.wantUpdated {
+				t.Errorf("updateScheduleGenerationLabel() = %v, want %v", got, tt.wantUpdated)
+			}
+			if got := tt.veleroSchedule.Spec.Template.Metadata.Labels; !reflect.DeepEqual(got, tt.wantTemplateLabels) {
+				t.Errorf("template labels = %v, want %v", got, tt.wantTemplateLabels)
+			}
+		})
+	}
+}
+
+func Test_getVolumeSnapshotLocations(t *testing.T) {
+	tests := []struct {
+		name           string
+		backupSchedule *v1beta1.BackupSchedule
+		scheduleKey    ResourceType
+		want           []string
+	}{
+		{
+			name:           "velero default location",
+			backupSchedule: createBackupSchedule("acm", "ns").object,
+			scheduleKey:    ManagedClusters,
+			want:           nil,
+		},
+		{
+			name: "volume snapshot locations for all backups",
+			backupSchedule: createBackupSchedule("acm", "ns").
+				setVolumeSnapshotLocation([]string{"vsl-1"}).object,
+			scheduleKey: ManagedClusters,
+			want:        []string{"vsl-1"},
+		},
+		{
+			name: "managed clusters volume snapshot locations",
+			backupSchedule: createBackupSchedule("acm", "ns").
+				setVolumeSnapshotLocation([]string{"vsl-1"}).
+				managedClustersVolumeSnapshotLocations([]string{"vsl-clusters"}).object,
+			scheduleKey: ManagedClusters,
+			want:        []string{"vsl-clusters"},
+		},
+		{
+			name: "managed clusters volume snapshot locations not used by the resources backup",
+			backupSchedule: createBackupSchedule("acm", "ns").
+				setVolumeSnapshotLocation([]string{"vsl-1"}).
+				managedClustersVolumeSnapshotLocations([]string{"vsl-clusters"}).object,
+			scheduleKey: Resources,
+			want:        []string{"vsl-1"},
+		},
+		{
+			name: "only managed clusters volume snapshot locations",
+			backupSchedule: createBackupSchedule("acm", "ns").
+				managedClustersVolumeSnapshotLocations([]string{"vsl-clusters"}).object,
+			scheduleKey: Credentials,
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getVolumeSnapshotLocations(tt.backupSchedule, tt.scheduleKey); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getVolumeSnapshotLocations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_updateIncrementalBackup(t *testing.T) {
+	snapshotMoveData := true
+
+	tests := []struct {
+		name                 string
+		veleroSchedule       *veleroapi.Schedule
+		backupSchedule       *v1beta1.BackupSchedule
+		want                 bool
+		wantSnapshotMoveData *bool
+	}{
+		{
+			name:                 "incremental backup not enabled",
+			veleroSchedule:       createSchedule("acm-resources-schedule", "ns").object,
+			backupSchedule:       createBackupSchedule("acm", "ns").object,
+			want:                 false,
+			wantSnapshotMoveData: nil,
+		},
+		{
+			name:                 "incremental backup enabled",
+			veleroSchedule:       createSchedule("acm-resources-schedule", "ns").object,
+			backupSchedule:       createBackupSchedule("acm", "ns").incrementalBackup(true).object,
+			want:                 true,
+			wantSnapshotMoveData: &snapshotMoveData,
+		},
+		{
+			name:                 "incremental backup already enabled",
+			veleroSchedule:       createSchedule("acm-resources-schedule", "ns").snapshotMoveData(true).object,
+			backupSchedule:       createBackupSchedule("acm", "ns").incrementalBackup(true).object,
+			want:                 false,
+			wantSnapshotMoveData: &snapshotMoveData,
+		},
+		{
+			name:                 "incremental backup disabled",
+			veleroSchedule:       createSchedule("acm-resources-schedule", "ns").snapshotMoveData(true).object,
+			backupSchedule:       createBackupSchedule("acm", "ns").object,
+			want:                 true,
+			wantSnapshotMoveData: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateIncrementalBackup(tt.veleroSchedule, tt.backupSchedule); got != tt.want {
+				t.Errorf("updateIncrementalBackup() = %v, want %v", got, tt.want)
+			}
+			if got := tt.veleroSchedule.Spec.Template.SnapshotMoveData; !reflect.DeepEqual(got, tt.wantSnapshotMoveData) {
+				t.Errorf("updateIncrementalBackup() SnapshotMoveData = %v, want %v", got, tt.wantSnapshotMoveData)
+			}
+		})
+	}
+}
+
+func Test_updateExcludeLocalCluster(t *testing.T) {
+	localClusterReq := metav1.LabelSelectorRequirement{
+		Key:      localClusterLabel,
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   []string{"true"},
+	}
+	otherReq := metav1.LabelSelectorRequirement{
+		Key:      "app",
+		Operator: metav1.LabelSelectorOpExists,
+	}
+
+	tests := []struct {
+		name              string
+		labelSelector     *metav1.LabelSelector
+		backupSchedule    *v1beta1.BackupSchedule
+		want              bool
+		wantLabelSelector *metav1.LabelSelector
+	}{
+		{
+			name:              "local cluster not excluded",
+			labelSelector:     nil,
+			backupSchedule:    createBackupSchedule("acm", "ns").object,
+			want:              false,
+			wantLabelSelector: nil,
+		},
+		{
+			name:           "local cluster excluded",
+			labelSelector:  nil,
+			backupSchedule: createBackupSchedule("acm", "ns").excludeLocalCluster(true).object,
+			want:           true,
+			wantLabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{localClusterReq},
+			},
+		},
+		{
+			name: "local cluster already excluded",
+			labelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{localClusterReq},
+			},
+			backupSchedule: createBackupSchedule("acm", "ns").excludeLocalCluster(true).object,
+			want:           false,
+			wantLabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{localClusterReq},
+			},
+		},
+		{
+			name: "local cluster excluded, other requirements kept",
+			labelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{otherReq},
+			},
+			backupSchedule: createBackupSchedule("acm", "ns").excludeLocalCluster(true).object,
+			want:           true,
+			wantLabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{otherReq, localClusterReq},
+			},
+		},
+		{
+			name: "local cluster exclusion removed",
+			labelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{localClusterReq},
+			},
+			backupSchedule:    createBackupSchedule("acm", "ns").object,
+			want:              true,
+			wantLabelSelector: nil,
+		},
+		{
+			name: "local cluster exclusion removed, other requirements kept",
+			labelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{localClusterReq, otherReq},
+			},
+			backupSchedule: createBackupSchedule("acm", "ns").object,
+			want:           true,
+			wantLabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{otherReq},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroBackupTemplate := &veleroapi.BackupSpec{LabelSelector: tt.labelSelector}
+			if got := updateExcludeLocalCluster(veleroBackupTemplate, tt.backupSchedule); got != tt.want {
+				t.Errorf("updateExcludeLocalCluster() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(veleroBackupTemplate.LabelSelector, tt.wantLabelSelector) {
+				t.Errorf("updateExcludeLocalCluster() LabelSelector = %v, want %v",
+					veleroBackupTemplate.LabelSelector, tt.wantLabelSelector)
+			}
+		})
+	}
+}
+
+func Test_updateBackupNamespaceExclusion(t *testing.T) {
+	tests := []struct {
+		name                 string
+		excludedNamespaces   []string
+		backupOperatorConfig bool
+		want                 bool
+		wantExcluded         []string
+	}{
+		{
+			name:               "backup namespace already excluded",
+			excludedNamespaces: []string{"local-cluster", "ns"},
+			want:               false,
+			wantExcluded:       []string{"local-cluster", "ns"},
+		},
+		{
+			name:               "backup namespace not excluded",
+			excludedNamespaces: []string{"local-cluster"},
+			want:               true,
+			wantExcluded:       []string{"local-cluster", "ns"},
+		},
+		{
+			name:                 "operator configuration backed up, backup namespace no longer excluded",
+			excludedNamespaces:   []string{"local-cluster", "ns"},
+			backupOperatorConfig: true,
+			want:                 true,
+			wantExcluded:         []string{"local-cluster"},
+		},
+		{
+			name:                 "operator configuration backed up, backup namespace not excluded",
+			excludedNamespaces:   []string{"local-cluster"},
+			backupOperatorConfig: true,
+			want:                 false,
+			wantExcluded:         []string{"local-cluster"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroSchedule := createSchedule(veleroScheduleNames[Resources], "ns").
+				excludedNamespaces(tt.excludedNamespaces).object
+			backupSchedule := createBackupSchedule("name", "ns").
+				backupOperatorConfig(tt.backupOperatorConfig).object
+
+			if got := updateBackupNamespaceExclusion(veleroSchedule, backupSchedule); got != tt.want {
+				t.Errorf("updateBackupNamespaceExclusion() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(veleroSchedule.Spec.Template.ExcludedNamespaces, tt.wantExcluded) {
+				t.Errorf("updateBackupNamespaceExclusion() ExcludedNamespaces = %v, want %v",
+					veleroSchedule.Spec.Template.ExcludedNamespaces, tt.wantExcluded)
+			}
+		})
+	}
+}
+
+func Test_setManagedClusterSetsNamespaces(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			createManagedCluster("cluster-a1", false).clusterSet("set-a").object,
+			createManagedCluster("cluster-a2", false).clusterSet("set-a").object,
+			createManagedCluster("cluster-b1", false).clusterSet("set-b").object,
+			createManagedCluster("cluster-default", false).object,
+		).
+		Build()
+
+	tests := []struct {
+		name               string
+		clusterSets        []string
+		includedNamespaces []string
+		excludedNamespaces []string
+		wantUpdated        bool
+		wantNamespaces     []string
+	}{
+		{
+			name:           "no cluster sets",
+			wantUpdated:    false,
+			wantNamespaces: nil,
+		},
+		{
+			name:               "cluster sets removed",
+			includedNamespaces: []string{"cluster-a1"},
+			wantUpdated:        true,
+			wantNamespaces:     nil,
+		},
+		{
+			name:           "only set-a clusters",
+			clusterSets:    []string{"set-a"},
+			wantUpdated:    true,
+			wantNamespaces: []string{"cluster-a1", "cluster-a2"},
+		},
+		{
+			name:           "set-a and set-b clusters",
+			clusterSets:    []string{"set-a", "set-b"},
+			wantUpdated:    true,
+			wantNamespaces: []string{"cluster-a1", "cluster-a2", "cluster-b1"},
+		},
+		{
+			name:               "set-a clusters already included",
+			clusterSets:        []string{"set-a"},
+			includedNamespaces: []string{"cluster-a2", "cluster-a1"},
+			wantUpdated:        false,
+			wantNamespaces:     []string{"cluster-a1", "cluster-a2"},
+		},
+		{
+			name:               "excluded cluster namespace is not included",
+			clusterSets:        []string{"set-a"},
+			excludedNamespaces: []string{"cluster-a1"},
+			wantUpdated:        true,
+			wantNamespaces:     []string{"cluster-a2"},
+		},
+		{
+			name:           "no clusters in the set",
+			clusterSets:    []string{"set-c"},
+			wantUpdated:    true,
+			wantNamespaces: []string{"backup-ns"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "backup-ns").
+				managedClusterSets(tt.clusterSets).object
+			veleroBackupTemplate := &veleroapi.BackupSpec{
+				IncludedNamespaces: tt.includedNamespaces,
+				ExcludedNamespaces: tt.excludedNamespaces,
+			}
+			if got := setManagedClusterSetsNamespaces(context.Background(), fakeClient,
+				veleroBackupTemplate, backupSchedule); got != tt.wantUpdated {
+				t.Errorf("setManagedClusterSetsNamespaces() = %v, want %v", got, tt.wantUpdated)
+			}
+			if !reflect.DeepEqual(veleroBackupTemplate.IncludedNamespaces, tt.wantNamespaces) {
+				t.Errorf("setManagedClusterSetsNamespaces() IncludedNamespaces = %v, want %v",
+					veleroBackupTemplate.IncludedNamespaces, tt.wantNamespaces)
+			}
+		})
+	}
+}
@@ -201,6 +201,11 @@ func (b *BackupHelper) startTimestamp(timestamp metav1.Time) *BackupHelper {
 	return b
 }
 
+func (b *BackupHelper) completionTimestamp(timestamp metav1.Time) *BackupHelper {
+	b.object.Status.CompletionTimestamp = &timestamp
+	return b
+}
+
 func (b *BackupHelper) phase(phase veleroapi.BackupPhase) *BackupHelper {
 	b.object.Status.Phase = phase
 	return b
@@ -211,6 +216,11 @@ func (b *BackupHelper) errors(error int) *BackupHelper {
 	return b
 }
 
+func (b *BackupHelper) warnings(warnings int) *BackupHelper {
+	b.object.Status.Warnings = warnings
+	return b
+}
+
 func (b *BackupHelper) includedResources(resources []string) *BackupHelper {
 	b.object.Spec.IncludedResources = resources
 	return b
@@ -221,6 +231,11 @@ func (b *BackupHelper) excludedResources(resources []string) *BackupHelper {
 	return b
 }
 
+func (b *BackupHelper) includedNamespaces(nspaces []string) *BackupHelper {
+	b.object.Spec.IncludedNamespaces = nspaces
+	return b
+}
+
 func (b *BackupHelper) excludedNamespaces(nspaces []string) *BackupHelper {
 	b.object.Spec.ExcludedNamespaces = nspaces
 	return b
@@ -231,6 +246,16 @@ func (b *BackupHelper) labels(list map[string]string) *BackupHelper {
 	return b
 }
 
+func (b *BackupHelper) annotations(list map[string]string) *BackupHelper {
+	b.object.Annotations = list
+	return b
+}
+
+func (b *BackupHelper) storageLocation(name string) *BackupHelper {
+	b.object.Spec.StorageLocation = name
+	return b
+}
+
 // velero schedule helper
 type ScheduleHelper struct {
 	object *veleroapi.Schedule
@@ -271,6 +296,46 @@ func (b *ScheduleHelper) ttl(duration metav1.Duration) *ScheduleHelper {
 	return b
 }
 
+func (b *ScheduleHelper) paused(paused bool) *ScheduleHelper {
+	b.object.Spec.Paused = paused
+	return b
+}
+
+func (b *ScheduleHelper) lastBackup(lastBackup metav1.Time) *ScheduleHelper {
+	b.object.Status.LastBackup = &lastBackup
+	return b
+}
+
+func (b *ScheduleHelper) creationTimestamp(creationTimestamp metav1.Time) *ScheduleHelper {
+	b.object.CreationTimestamp = creationTimestamp
+	return b
+}
+
+func (b *ScheduleHelper) orLabelSelectors(selectors []*metav1.LabelSelector) *ScheduleHelper {
+	b.object.Spec.Template.OrLabelSelectors = selectors
+	return b
+}
+
+func (b *ScheduleHelper) templateLabels(labels map[string]string) *ScheduleHelper {
+	b.object.Spec.Template.Metadata.Labels = labels
+	return b
+}
+
+func (b *ScheduleHelper) volumeSnapshotLocations(locations []string) *ScheduleHelper {
+	b.object.Spec.Template.VolumeSnapshotLocations = locations
+	return b
+}
+
+func (b *ScheduleHelper) snapshotMoveData(moveData bool) *ScheduleHelper {
+	b.object.Spec.Template.SnapshotMoveData = &moveData
+	return b
+}
+
+func (b *ScheduleHelper) excludedNamespaces(nspaces []string) *ScheduleHelper {
+	b.object.Spec.Template.ExcludedNamespaces = nspaces
+	return b
+}
+
 // velero restore
 type RestoreHelper struct {
 	object *veleroapi.Restore
@@ -316,6 +381,35 @@ func (b *RestoreHelper) phase(phase veleroapi.RestorePhase) *RestoreHelper {
 	return b
 }
 
+func (b *RestoreHelper) namespaceMapping(mapping map[string]string) *RestoreHelper {
+	b.object.Spec.NamespaceMapping = mapping
+	return b
+}
+
+func (b *RestoreHelper) progress(totalItems, itemsRestored, warnings, errors int) *RestoreHelper {
+	b.object.Status.Progress = &veleroapi.RestoreProgress{TotalItems: totalItems, ItemsRestored: itemsRestored}
+	b.object.Status.Warnings = warnings
+	b.object.Status.Errors = errors
+	return b
+}
+
+func (b *RestoreHelper) startTimestamp(timestamp metav1.Time) *RestoreHelper {
+	b.object.Status.StartTimestamp = &timestamp
+	return b
+}
+
+func (b *RestoreHelper) completionTimestamp(timestamp metav1.Time) *RestoreHelper {
+	b.object.Status.CompletionTimestamp = &timestamp
+	return b
+}
+
+func (b *RestoreHelper) itemOperations(attempted, completed, failed int) *RestoreHelper {
+	b.object.Status.RestoreItemOperationsAttempted = attempted
+	b.object.Status.RestoreItemOperationsCompleted = completed
+	b.object.Status.RestoreItemOperationsFailed = failed
+	return b
+}
+
 // acm restore
 type ACMRestoreHelper struct {
 	object *v1beta1.Restore
@@ -381,6 +475,11 @@ func (b *ACMRestoreHelper) preserveNodePorts(preserve bool) *ACMRestoreHelper {
 	return b
 }
 
+func (b *ACMRestoreHelper) includeClusterResources(include bool) *ACMRestoreHelper {
+	b.object.Spec.IncludeClusterResources = &include
+	return b
+}
+
 func (b *ACMRestoreHelper) restoreLabelSelector(selector *metav1.LabelSelector) *ACMRestoreHelper {
 	b.object.Spec.LabelSelector = selector
 	return b
@@ -391,6 +490,43 @@ func (b *ACMRestoreHelper) restoreORLabelSelector(selectors []*metav1.LabelSelec
 	return b
 }
 
+func (b *ACMRestoreHelper) uploaderConfig(config *veleroapi.UploaderConfigForRestore) *ACMRestoreHelper {
+	b.object.Spec.UploaderConfig = config
+	return b
+}
+
+func (b *ACMRestoreHelper) itemOperationTimeout(timeout metav1.Duration) *ACMRestoreHelper {
+	b.object.Spec.ItemOperationTimeout = timeout
+	return b
+}
+
+func (b *ACMRestoreHelper) emitProgressEvents(emit bool) *ACMRestoreHelper {
+	b.object.Spec.EmitProgressEvents = emit
+	return b
+}
+
+func (b *ACMRestoreHelper) backupLabelSelector(selector *metav1.LabelSelector) *ACMRestoreHelper {
+	b.object.Spec.BackupLabelSelector = selector
+	return b
+}
+
+func (b *ACMRestoreHelper) resourcesRestoreOrLabelSelectors(
+	selectors []*metav1.LabelSelector,
+) *ACMRestoreHelper {
+	b.object.Spec.ResourcesRestoreOrLabelSelectors = selectors
+	return b
+}
+
+func (b *ACMRestoreHelper) validateCRDs(validate bool) *ACMRestoreHelper {
+	b.object.Spec.ValidateCRDs = validate
+	return b
+}
+
+func (b *ACMRestoreHelper) skipMissingCRDResources(skip bool) *ACMRestoreHelper {
+	b.object.Spec.SkipMissingCRDResources = skip
+	return b
+}
+
 func (b *ACMRestoreHelper) restorePVs(restorePV bool) *ACMRestoreHelper {
 	b.object.Spec.RestorePVs = &restorePV
 	return b
@@ -437,6 +573,107 @@ func (b *ACMRestoreHelper) namespaceMapping(nspaces map[string]string) *ACMResto
 	return b
 }
 
+func (b *ACMRestoreHelper) postRestoreJob(job *v1beta1.PostRestoreJobSpec) *ACMRestoreHelper {
+	b.object.Spec.PostRestoreJob = job
+	return b
+}
+
+func (b *ACMRestoreHelper) sandboxNamespacePrefix(prefix string) *ACMRestoreHelper {
+	b.object.Spec.SandboxNamespacePrefix = prefix
+	return b
+}
+
+func (b *ACMRestoreHelper) clusterSetMapping(mapping map[string]string) *ACMRestoreHelper {
+	b.object.Spec.ClusterSetMapping = mapping
+	return b
+}
+
+func (b *ACMRestoreHelper) tagRestoredResources(tag bool) *ACMRestoreHelper {
+	b.object.Spec.TagRestoredResources = tag
+	return b
+}
+
+func (b *ACMRestoreHelper) failFast(failFast bool) *ACMRestoreHelper {
+	b.object.Spec.FailFast = failFast
+	return b
+}
+
+func (b *ACMRestoreHelper) restoreClusterScopedFirst(clusterScopedFirst bool) *ACMRestoreHelper {
+	b.object.Spec.RestoreClusterScopedFirst = clusterScopedFirst
+	return b
+}
+
+func (b *ACMRestoreHelper) pauseActiveBackupSchedule(pause bool) *ACMRestoreHelper {
+	b.object.Spec.PauseActiveBackupSchedule = pause
+	return b
+}
+
+func (b *ACMRestoreHelper) pauseGitOpsDuringRestore(pause bool) *ACMRestoreHelper {
+	b.object.Spec.PauseGitOpsDuringRestore = pause
+	return b
+}
+
+func (b *ACMRestoreHelper) pointInTimeRecovery(pointInTimeRecovery bool) *ACMRestoreHelper {
+	b.object.Spec.PointInTimeRecovery = pointInTimeRecovery
+	return b
+}
+
+func (b *ACMRestoreHelper) verifyClusterJoin(verify bool) *ACMRestoreHelper {
+	b.object.Spec.VerifyClusterJoin = verify
+	return b
+}
+
+func (b *ACMRestoreHelper) minClustersAvailablePercent(percent int) *ACMRestoreHelper {
+	b.object.Spec.MinClustersAvailablePercent = percent
+	return b
+}
+
+func (b *ACMRestoreHelper) singleClusterRestore(clusterName string) *ACMRestoreHelper {
+	b.object.Spec.SingleClusterRestore = clusterName
+	return b
+}
+
+func (b *ACMRestoreHelper) sinceBackupName(name string) *ACMRestoreHelper {
+	b.object.Spec.SinceBackupName = name
+	return b
+}
+
+func (b *ACMRestoreHelper) validationRestore(cleanupSandbox bool) *ACMRestoreHelper {
+	b.object.Spec.ValidationRestore = true
+	b.object.Spec.CleanupValidationSandbox = cleanupSandbox
+	return b
+}
+
+func (b *ACMRestoreHelper) storageLocationPrefix(prefix string) *ACMRestoreHelper {
+	b.object.Spec.StorageLocationPrefix = prefix
+	return b
+}
+
+func (b *ACMRestoreHelper) storageLocation(name string) *ACMRestoreHelper {
+	b.object.Spec.StorageLocation = name
+	return b
+}
+
+func (b *ACMRestoreHelper) hold(hold bool) *ACMRestoreHelper {
+	b.object.Spec.Hold = hold
+	return b
+}
+
+func (b *ACMRestoreHelper) dependsOn(restoreNames []string) *ACMRestoreHelper {
+	b.object.Spec.DependsOn = restoreNames
+	return b
+}
+
+func (b *ACMRestoreHelper) backupManifest(manifest *v1beta1.BackupManifest) *ACMRestoreHelper {
+	b.object.Spec.BackupManifest = manifest
+	return b
+}
+
+func (b *ACMRestoreHelper) secretTransform(transform *v1beta1.SecretTransformSpec) *ACMRestoreHelper {
+	b.object.Spec.SecretTransform = transform
+	return b
+}
+
 // backup schedule
 type BackupScheduleHelper struct {
 	object *v1beta1.BackupSchedule
@@ -511,6 +748,26 @@ func (b *BackupScheduleHelper) setVolumeSnapshotLocation(locations []string) *Ba
 	return b
 }
 
+func (b *BackupScheduleHelper) generation(generation int64) *BackupScheduleHelper {
+	b.object.Generation = generation
+	return b
+}
+
+func (b *BackupScheduleHelper) managedClustersVolumeSnapshotLocations(locations []string) *BackupScheduleHelper {
+	b.object.Spec.ManagedClustersVolumeSnapshotLocations = locations
+	return b
+}
+
+func (b *BackupScheduleHelper) incrementalBackup(incremental bool) *BackupScheduleHelper {
+	b.object.Spec.IncrementalBackup = incremental
+	return b
+}
+
+func (b *BackupScheduleHelper) excludeLocalCluster(exclude bool) *BackupScheduleHelper {
+	b.object.Spec.ExcludeLocalCluster = exclude
+	return b
+}
+
 func (b *BackupScheduleHelper) useOwnerReferencesInBackup(useOwnerReferences bool) *BackupScheduleHelper {
 	b.object.Spec.UseOwnerReferencesInBackup = useOwnerReferences
 	return b
@@ -521,6 +778,105 @@ func (b *BackupScheduleHelper) skipImmediately(skipImmediately bool) *BackupSche
 	return b
 }
 
+func (b *BackupScheduleHelper) excludedAddonNamespaces(namespaces []string) *BackupScheduleHelper {
+	b.object.Spec.ExcludedAddonNamespaces = namespaces
+	return b
+}
+
+func (b *BackupScheduleHelper) includedNamespaces(namespaces []string) *BackupScheduleHelper {
+	b.object.Spec.IncludedNamespaces = namespaces
+	return b
+}
+
+func (b *BackupScheduleHelper) emitBackupCompletedEvents(emit bool) *BackupScheduleHelper {
+	b.object.Spec.EmitBackupCompletedEvents = emit
+	return b
+}
+
+func (b *BackupScheduleHelper) veleroNamespace(namespace string) *BackupScheduleHelper {
+	b.object.Spec.VeleroNamespace = namespace
+	return b
+}
+
+func (b *BackupScheduleHelper) targetNamespaces(namespaces []string) *BackupScheduleHelper {
+	b.object.Spec.TargetNamespaces = namespaces
+	return b
+}
+
+func (b *BackupScheduleHelper) validatePermissions(validate bool) *BackupScheduleHelper {
+	b.object.Spec.ValidatePermissions = validate
+	return b
+}
+
+func (b *BackupScheduleHelper) credentialsOrLabelSelectors(
+	selectors []*metav1.LabelSelector,
+) *BackupScheduleHelper {
+	b.object.Spec.CredentialsOrLabelSelectors = selectors
+	return b
+}
+
+func (b *BackupScheduleHelper) includeOpenShiftResources(include bool) *BackupScheduleHelper {
+	b.object.Spec.IncludeOpenShiftResources = include
+	return b
+}
+
+func (b *BackupScheduleHelper) staggerSchedules(stagger bool) *BackupScheduleHelper {
+	b.object.Spec.StaggerSchedules = stagger
+	return b
+}
+
+func (b *BackupScheduleHelper) maxBackupRetentionDuration(duration metav1.Duration) *BackupScheduleHelper {
+	b.object.Spec.MaxBackupRetentionDuration = duration
+	return b
+}
+
+func (b *BackupScheduleHelper) disableCleanup(disable bool) *BackupScheduleHelper {
+	b.object.Spec.DisableCleanup = disable
+	return b
+}
+
+func (b *BackupScheduleHelper) managedClusterSets(sets []string) *BackupScheduleHelper {
+	b.object.Spec.ManagedClusterSets = sets
+	return b
+}
+
+func (b *BackupScheduleHelper) backupOperatorConfig(backup bool) *BackupScheduleHelper {
+	b.object.Spec.BackupOperatorConfig = backup
+	return b
+}
+
+func (b *BackupScheduleHelper) includeAddonConfig(include bool) *BackupScheduleHelper {
+	b.object.Spec.IncludeAddonConfig = include
+	return b
+}
+
+func (b *BackupScheduleHelper) includeObservabilityConfig(include bool) *BackupScheduleHelper {
+	b.object.Spec.IncludeObservabilityConfig = include
+	return b
+}
+
+func (b *BackupScheduleHelper) includeConfigMapSelector(
+	selector *metav1.LabelSelector,
+) *BackupScheduleHelper {
+	b.object.Spec.IncludeConfigMapSelector = selector
+	return b
+}
+
+func (b *BackupScheduleHelper) hooks(hooks veleroapi.BackupHooks) *BackupScheduleHelper {
+	b.object.Spec.Hooks = hooks
+	return b
+}
+
+func (b *BackupScheduleHelper) hookTimeout(timeout metav1.Duration) *BackupScheduleHelper {
+	b.object.Spec.HookTimeout = timeout
+	return b
+}
+
+func (b *BackupScheduleHelper) hookOnError(onError veleroapi.HookErrorMode) *BackupScheduleHelper {
+	b.object.Spec.HookOnError = onError
+	return b
+}
+
 // storage location
 type StorageLocationHelper struct {
 	object *veleroapi.BackupStorageLocation
@@ -556,6 +912,21 @@ func (b *StorageLocationHelper) phase(phase veleroapi.BackupStorageLocationPhase
 	return b
 }
 
+func (b *StorageLocationHelper) prefix(prefix string) *StorageLocationHelper {
+	b.object.Spec.ObjectStorage.Prefix = prefix
+	return b
+}
+
+func (b *StorageLocationHelper) provider(provider string) *StorageLocationHelper {
+	b.object.Spec.Provider = provider
+	return b
+}
+
+func (b *StorageLocationHelper) annotations(annotations map[string]string) *StorageLocationHelper {
+	b.object.SetAnnotations(annotations)
+	return b
+}
+
 func (b *StorageLocationHelper) setOwner() *StorageLocationHelper {
 	b.object.OwnerReferences = []metav1.OwnerReference{
 		{
@@ -618,6 +989,24 @@ func (b *ManagedHelper) conditions(conditions []metav1.Condition) *ManagedHelper
 	return b
 }
 
+func (b *ManagedHelper) clusterSet(name string) *ManagedHelper {
+	if b.object.Labels == nil {
+		b.object.Labels = map[string]string{}
+	}
+	b.object.Labels["cluster.open-cluster-management.io/clusterset"] = name
+	return b
+}
+
+func (b *ManagedHelper) labels(labels map[string]string) *ManagedHelper {
+	if b.object.Labels == nil {
+		b.object.Labels = map[string]string{}
+	}
+	for key, value := range labels {
+		b.object.Labels[key] = value
+	}
+	return b
+}
+
 // channel helper
 type ChannelHelper struct {
 	object *chnv1.Channel
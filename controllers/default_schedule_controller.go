@@ -0,0 +1,151 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
+	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// DefaultBackupScheduleTemplateAnnotation stores, on a BackupSchedule created from the
+// default BackupSchedule template, the namespace and name of the template
+const DefaultBackupScheduleTemplateAnnotation = "cluster.open-cluster-management.io/backup-schedule-template"
+
+// DefaultBackupScheduleReconciler creates a BackupSchedule from the default BackupSchedule template
+// in each namespace with a velero BackupStorageLocation and no BackupSchedule or Restore
+type DefaultBackupScheduleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// namespace and name of the BackupSchedule used as template;
+	// no BackupSchedule is created if the name is empty
+	Template types.NamespacedName
+}
+
+//+kubebuilder:rbac:groups=velero.io,resources=backupstoragelocations,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=backupschedules,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=restores,verbs=get;list;watch
+
+// Reconcile creates the default BackupSchedule in the namespace of the reconciled
+// BackupStorageLocation, if this namespace has no BackupSchedule and no Restore
+func (r *DefaultBackupScheduleReconciler) Reconcile(
+	ctx context.Context,
+	req ctrl.Request,
+) (ctrl.Result, error) {
+	scheduleLogger := log.FromContext(ctx)
+
+	if r.Template.Name == "" || req.Namespace == r.Template.Namespace {
+		// default BackupSchedule not enabled or this is the template namespace
+		return ctrl.Result{}, nil
+	}
+
+	backupSchedules := v1beta1.BackupScheduleList{}
+	if err := r.List(ctx, &backupSchedules, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(backupSchedules.Items) > 0 {
+		// this namespace already has a BackupSchedule
+		return ctrl.Result{}, nil
+	}
+
+	restores := v1beta1.RestoreList{}
+	if err := r.List(ctx, &restores, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(restores.Items) > 0 {
+		// this is a passive hub, running or finished restores included; a BackupSchedule
+		// would write backups to the storage location shared with the primary hub
+		scheduleLogger.Info("default BackupSchedule not created, the namespace has a Restore",
+			"namespace", req.Namespace, "restore", restores.Items[0].Name)
+		return ctrl.Result{}, nil
+	}
+
+	template := &v1beta1.BackupSchedule{}
+	if err := r.Get(ctx, r.Template, template); err != nil {
+		if k8serr.IsNotFound(err) {
+			scheduleLogger.Info("default BackupSchedule template not found", "template", r.Template.String())
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	backupSchedule := newDefaultBackupSchedule(template, req.Namespace)
+	if err := r.Create(ctx, backupSchedule); err != nil {
+		if k8serr.IsAlreadyExists(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	msg := fmt.Sprintf("BackupSchedule %s created from the default BackupSchedule template %s",
+		backupSchedule.Name, r.Template.String())
+	scheduleLogger.Info(msg, "namespace", req.Namespace)
+	r.Recorder.Event(backupSchedule, corev1.EventTypeNormal, "DefaultBackupScheduleCreated", msg)
+
+	return ctrl.Result{}, nil
+}
+
+// returns a BackupSchedule in the given namespace with the spec of the template
+func newDefaultBackupSchedule(
+	template *v1beta1.BackupSchedule,
+	namespace string,
+) *v1beta1.BackupSchedule {
+	backupSchedule := &v1beta1.BackupSchedule{
+		TypeMeta: template.TypeMeta,
+	}
+	backupSchedule.Name = template.Name
+	backupSchedule.Namespace = namespace
+	backupSchedule.SetAnnotations(map[string]string{
+		DefaultBackupScheduleTemplateAnnotation: types.NamespacedName{
+			Namespace: template.Namespace,
+			Name:      template.Name,
+		}.String(),
+	})
+	backupSchedule.Spec = *template.Spec.DeepCopy()
+	// the velero schedules are created in the namespace where velero was found
+	backupSchedule.Spec.VeleroNamespace = ""
+	backupSchedule.Spec.TargetNamespaces = nil
+
+	return backupSchedule
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DefaultBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("default-backupschedule").
+		For(&veleroapi.BackupStorageLocation{}, builder.WithPredicates(predicate.Funcs{
+			// a namespace gains velero when its first storage location is created
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		})).
+		Complete(r)
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
+	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_DefaultBackupScheduleReconciler(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	template := createBackupSchedule("acm-default", "open-cluster-management-backup").
+		schedule("0 */2 * * *").
+		veleroTTL(metav1.Duration{Duration: time.Hour * 72}).
+		veleroNamespace("velero").object
+	templateName := types.NamespacedName{Namespace: template.Namespace, Name: template.Name}
+
+	tests := []struct {
+		name         string
+		template     types.NamespacedName
+		namespace    string
+		objects      []client.Object
+		wantSchedule bool
+	}{
+		{
+			name:      "default BackupSchedule not enabled",
+			template:  types.NamespacedName{},
+			namespace: "velero-ns",
+			objects: []client.Object{
+				template,
+			},
+			wantSchedule: false,
+		},
+		{
+			name:         "template not found",
+			template:     templateName,
+			namespace:    "velero-ns",
+			objects:      []client.Object{},
+			wantSchedule: false,
+		},
+		{
+			name:      "template namespace is skipped",
+			template:  templateName,
+			namespace: template.Namespace,
+			objects: []client.Object{
+				template,
+			},
+			wantSchedule: false,
+		},
+		{
+			name:      "namespace already has a BackupSchedule",
+			template:  templateName,
+			namespace: "velero-ns",
+			objects: []client.Object{
+				template,
+				createBackupSchedule("user-schedule", "velero-ns").schedule("0 */6 * * *").object,
+			},
+			wantSchedule: false,
+		},
+		{
+			name:      "namespace has a running Restore",
+			template:  templateName,
+			namespace: "velero-ns",
+			objects: []client.Object{
+				template,
+				createACMRestore("restore", "velero-ns").
+					phase(v1beta1.RestorePhaseEnabled).object,
+			},
+			wantSchedule: false,
+		},
+		{
+			name:      "namespace has a finished Restore",
+			template:  templateName,
+			namespace: "velero-ns",
+			objects: []client.Object{
+				template,
+				createStorageLocation("default", "velero-ns").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+				createACMRestore("restore", "velero-ns").
+					phase(v1beta1.RestorePhaseFinished).object,
+			},
+			wantSchedule: false,
+		},
+		{
+			name:      "default BackupSchedule created",
+			template:  templateName,
+			namespace: "velero-ns",
+			objects: []client.Object{
+				template,
+			},
+			wantSchedule: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(tt.objects...).Build()
+			r := &DefaultBackupScheduleReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme1,
+				Recorder: record.NewFakeRecorder(10),
+				Template: tt.template,
+			}
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: tt.namespace, Name: "default"},
+			}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			// find the BackupSchedules created from the template in this namespace
+			backupSchedules := v1beta1.BackupScheduleList{}
+			if err := fakeClient.List(context.Background(), &backupSchedules,
+				client.InNamespace(tt.namespace)); err != nil {
+				t.Fatalf("Error listing BackupSchedules: %s", err.Error())
+			}
+			var backupSchedule *v1beta1.BackupSchedule
+			for i := range backupSchedules.Items {
+				if _, ok := backupSchedules.Items[i].GetAnnotations()[DefaultBackupScheduleTemplateAnnotation]; ok {
+					backupSchedule = &backupSchedules.Items[i]
+				}
+			}
+			if tt.wantSchedule != (backupSchedule != nil) {
+				t.Fatalf("Reconcile() default BackupSchedule created = %v, want %v",
+					backupSchedule != nil, tt.wantSchedule)
+			}
+			if !tt.wantSchedule {
+				return
+			}
+			if backupSchedule.Spec.VeleroSchedule != template.Spec.VeleroSchedule ||
+				backupSchedule.Spec.VeleroTTL != template.Spec.VeleroTTL {
+				t.Errorf("Reconcile() default BackupSchedule spec = %v, want the template spec %v",
+					backupSchedule.Spec, template.Spec)
+			}
+			if backupSchedule.Spec.VeleroNamespace != "" {
+				t.Errorf("Reconcile() default BackupSchedule VeleroNamespace = %s, want empty",
+					backupSchedule.Spec.VeleroNamespace)
+			}
+			if backupSchedule.GetAnnotations()[DefaultBackupScheduleTemplateAnnotation] != templateName.String() {
+				t.Errorf("Reconcile() default BackupSchedule annotations = %v", backupSchedule.GetAnnotations())
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// HiveSecretReconciler sets the backup label on the hive secrets as soon as they are created,
+// instead of waiting for the next BackupSchedule reconcile to prepare the resources for backup
+type HiveSecretReconciler struct {
+	client.Client
+	// reads the secrets from the API server; the secrets are watched using their metadata only,
+	// so the secret data is not cached by this controller
+	APIReader client.Reader
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=hive.openshift.io,resources=clusterdeployments,verbs=get;list;watch
+
+// Reconcile sets the backup label on the created secret if this is the secret of a cluster pool,
+// or of a cluster deployment created by a cluster pool, from the secret namespace
+func (r *HiveSecretReconciler) Reconcile(
+	ctx context.Context,
+	req ctrl.Request,
+) (ctrl.Result, error) {
+	// same secrets as the ones labeled by updateHiveResources, using the resources from this namespace only
+	secretPrefixes := []string{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	if err := r.List(ctx, clusterDeployments, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range clusterDeployments.Items {
+		if clusterDeployments.Items[i].Spec.ClusterPoolRef != nil {
+			secretPrefixes = append(secretPrefixes, clusterDeployments.Items[i].Name)
+		}
+	}
+	clusterPools := &hivev1.ClusterPoolList{}
+	if err := r.List(ctx, clusterPools, client.InNamespace(req.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range clusterPools.Items {
+		secretPrefixes = append(secretPrefixes, clusterPools.Items[i].Name)
+	}
+	if len(secretPrefixes) == 0 {
+		// not a cluster pool namespace, the secret is not read
+		return ctrl.Result{}, nil
+	}
+
+	secret := corev1.Secret{}
+	if err := r.APIReader.Get(ctx, req.NamespacedName, &secret); err != nil {
+		if k8serr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	secrets := corev1.SecretList{Items: []corev1.Secret{secret}}
+	for _, prefix := range secretPrefixes {
+		updateSecretsLabels(ctx, r.Client, secrets, prefix,
+			backupCredsClusterLabel,
+			"clusterpool")
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// returns true if the secret has none of the backup labels
+func isSecretWithoutBackupLabel(obj client.Object) bool {
+	labels := obj.GetLabels()
+	return labels[backupCredsHiveLabel] == "" &&
+		labels[backupCredsUserLabel] == "" &&
+		labels[backupCredsClusterLabel] == ""
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *HiveSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("hive-secret").
+		// watch the secrets metadata only, the secret data is not needed to select the new secrets
+		For(&corev1.Secret{}, builder.OnlyMetadata, builder.WithPredicates(predicate.Funcs{
+			// only the new secrets not labeled yet for backup are processed
+			CreateFunc:  func(e event.CreateEvent) bool { return isSecretWithoutBackupLabel(e.Object) },
+			UpdateFunc:  func(event.UpdateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+		})).
+		Complete(r)
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_HiveSecretReconciler(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := hivev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	newSecret := func(name, ns string, labels map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels},
+		}
+	}
+	clusterPool := &hivev1.ClusterPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "pool-ns"},
+	}
+	poolClusterDeployment := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool-cluster", Namespace: "pool-cluster"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			ClusterPoolRef: &hivev1.ClusterPoolReference{Namespace: "pool-ns", PoolName: "pool"},
+		},
+	}
+	clusterDeployment := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "cluster"},
+	}
+
+	tests := []struct {
+		name      string
+		secret    *corev1.Secret
+		objects   []client.Object
+		wantLabel string
+	}{
+		{
+			name:      "secret not found",
+			secret:    newSecret("pool-install-config", "pool-ns", nil),
+			objects:   []client.Object{clusterPool},
+			wantLabel: "",
+		},
+		{
+			name:      "cluster pool secret is labeled",
+			secret:    newSecret("pool-install-config", "pool-ns", nil),
+			objects:   []client.Object{clusterPool, newSecret("pool-install-config", "pool-ns", nil)},
+			wantLabel: "clusterpool",
+		},
+		{
+			name:   "cluster pool bootstrap secret is not labeled",
+			secret: newSecret("pool-bootstrap-kubeconfig", "pool-ns", nil),
+			objects: []client.Object{
+				clusterPool,
+				newSecret("pool-bootstrap-kubeconfig", "pool-ns", nil),
+			},
+			wantLabel: "",
+		},
+		{
+			name:   "cluster pool secret already labeled is not updated",
+			secret: newSecret("pool-creds", "pool-ns", nil),
+			objects: []client.Object{
+				clusterPool,
+				newSecret("pool-creds", "pool-ns", map[string]string{backupCredsUserLabel: "user"}),
+			},
+			wantLabel: "",
+		},
+		{
+			name:   "cluster deployment secret from a cluster pool is labeled",
+			secret: newSecret("pool-cluster-admin-password", "pool-cluster", nil),
+			objects: []client.Object{
+				poolClusterDeployment,
+				newSecret("pool-cluster-admin-password", "pool-cluster", nil),
+			},
+			wantLabel: "clusterpool",
+		},
+		{
+			name:   "cluster deployment import secret is not labeled",
+			secret: newSecret("pool-cluster-import", "pool-cluster", nil),
+			objects: []client.Object{
+				poolClusterDeployment,
+				newSecret("pool-cluster-import", "pool-cluster", nil),
+			},
+			wantLabel: "",
+		},
+		{
+			name:   "cluster deployment secret not from a cluster pool is not labeled",
+			secret: newSecret("cluster-admin-password", "cluster", nil),
+			objects: []client.Object{
+				clusterDeployment,
+				newSecret("cluster-admin-password", "cluster", nil),
+			},
+			wantLabel: "",
+		},
+		{
+			name:      "secret outside the hive namespaces is not labeled",
+			secret:    newSecret("pool-install-config", "default", nil),
+			objects:   []client.Object{clusterPool, newSecret("pool-install-config", "default", nil)},
+			wantLabel: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(tt.objects...).Build()
+			r := &HiveSecretReconciler{Client: fakeClient, APIReader: fakeClient}
+
+			secretName := types.NamespacedName{Namespace: tt.secret.Namespace, Name: tt.secret.Name}
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: secretName,
+			}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			secret := corev1.Secret{}
+			if err := fakeClient.Get(context.Background(), secretName, &secret); err != nil {
+				return
+			}
+			if got := secret.GetLabels()[backupCredsClusterLabel]; got != tt.wantLabel {
+				t.Errorf("Reconcile() secret label %s = %s, want %s", backupCredsClusterLabel, got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func Test_HiveSecretReconciler_secretNotRead(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := hivev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pool-install-config", Namespace: "default"}}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(secret).Build()
+	// the secrets from a namespace with no cluster pool resources are not read
+	apiReader := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(secret).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object,
+				opts ...client.GetOption,
+			) error {
+				t.Errorf("Reconcile() read secret %s", key)
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+	r := &HiveSecretReconciler{Client: fakeClient, APIReader: apiReader}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKeyFromObject(secret),
+	}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+}
+
+func Test_isSecretWithoutBackupLabel(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name:   "no labels",
+			labels: nil,
+			want:   true,
+		},
+		{
+			name:   "other labels",
+			labels: map[string]string{"app": "test"},
+			want:   true,
+		},
+		{
+			name:   "hive label",
+			labels: map[string]string{backupCredsHiveLabel: "hive"},
+			want:   false,
+		},
+		{
+			name:   "user label",
+			labels: map[string]string{backupCredsUserLabel: "user"},
+			want:   false,
+		},
+		{
+			name:   "cluster label",
+			labels: map[string]string{backupCredsClusterLabel: "clusterpool"},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Labels: tt.labels}}
+			if got := isSecretWithoutBackupLabel(secret); got != tt.want {
+				t.Errorf("isSecretWithoutBackupLabel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// controller label values for the reconcile metrics
+	scheduleControllerName = "schedule"
+	restoreControllerName  = "restore"
+)
+
+var (
+	// reconcileDuration tracks the duration of the Reconcile calls, by controller
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "acm_backup_reconcile_duration_seconds",
+			Help:    "Duration of the backup and restore reconcile loops, in seconds.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"controller"},
+	)
+	// reconcileErrors counts the Reconcile calls returning an error, by controller
+	reconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_backup_reconcile_errors_total",
+			Help: "Number of backup and restore reconcile loops returning an error.",
+		},
+		[]string{"controller"},
+	)
+	// backupCollisions counts the backup collisions found for a BackupSchedule,
+	// when another hub stores backups at the same storage location
+	backupCollisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_backup_collisions_total",
+			Help: "Number of backup collisions found for a BackupSchedule.",
+		},
+		[]string{"namespace", "name"},
+	)
+	// backupSchedulePhase is set to 1 for the current phase of each BackupSchedule
+	backupSchedulePhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "acm_backup_schedule_phase",
+			Help: "Current phase of a BackupSchedule, set to 1 for the current phase.",
+		},
+		[]string{"namespace", "name", "phase"},
+	)
+	// restorePhase is set to 1 for the current phase of each Restore
+	restorePhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "acm_restore_phase",
+			Help: "Current phase of a Restore, set to 1 for the current phase.",
+		},
+		[]string{"namespace", "name", "phase"},
+	)
+	// veleroSchedulesCreated counts the velero schedules created for a BackupSchedule
+	veleroSchedulesCreated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_backup_velero_schedules_created_total",
+			Help: "Number of velero schedules created for a BackupSchedule.",
+		},
+		[]string{"namespace", "name"},
+	)
+	// veleroSchedulesDeleted counts the velero schedules deleted for a BackupSchedule
+	veleroSchedulesDeleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "acm_backup_velero_schedules_deleted_total",
+			Help: "Number of velero schedules deleted for a BackupSchedule.",
+		},
+		[]string{"namespace", "name"},
+	)
+	// restoreDuration tracks the time from the Restore creation to the Finished phase
+	restoreDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "acm_restore_duration_seconds",
+			Help:    "Time from the Restore creation until the restore is Finished, in seconds.",
+			Buckets: prometheus.ExponentialBuckets(30, 2, 10),
+		},
+	)
+)
+
+func init() {
+	// register the metrics with the manager metrics endpoint
+	metrics.Registry.MustRegister(reconcileDuration, reconcileErrors, backupCollisions,
+		backupSchedulePhase, restorePhase, veleroSchedulesCreated, veleroSchedulesDeleted, restoreDuration)
+}
+
+// records the duration of a reconcile started at the given time
+// and counts the reconcile as failed if an error was returned
+func recordReconcileMetrics(
+	controllerName string,
+	start time.Time,
+	err error,
+) {
+	reconcileDuration.WithLabelValues(controllerName).Observe(time.Since(start).Seconds())
+	if err != nil {
+		reconcileErrors.WithLabelValues(controllerName).Inc()
+	}
+}
+
+// sets the phase gauge of a resource to its current phase, removing the series of its previous phases;
+// all series of the resource are removed if the phase is empty, for example when the resource is deleted
+func setPhaseMetric(
+	gauge *prometheus.GaugeVec,
+	name types.NamespacedName,
+	phase string,
+) {
+	gauge.DeletePartialMatch(prometheus.Labels{"namespace": name.Namespace, "name": name.Name})
+	if phase != "" {
+		gauge.WithLabelValues(name.Namespace, name.Name, phase).Set(1)
+	}
+}
+
+// records the phase of the BackupSchedule at the end of a reconcile; the phase is removed
+// if the BackupSchedule was not found, its name is then empty, or is being deleted
+func recordBackupSchedulePhase(
+	name types.NamespacedName,
+	backupSchedule *v1beta1.BackupSchedule,
+) {
+	phase := ""
+	if backupSchedule.Name != "" && backupSchedule.DeletionTimestamp == nil {
+		phase = string(backupSchedule.Status.Phase)
+	}
+	setPhaseMetric(backupSchedulePhase, name, phase)
+}
+
+// records the phase of the Restore at the end of a reconcile and, when the restore
+// moves to the Finished phase, the time elapsed since the Restore was created; the phase is removed
+// if the Restore was not found, its name is then empty, or is being deleted
+func recordRestorePhase(
+	name types.NamespacedName,
+	restore *v1beta1.Restore,
+	previousPhase v1beta1.RestorePhase,
+) {
+	if restore.Name == "" || restore.DeletionTimestamp != nil {
+		setPhaseMetric(restorePhase, name, "")
+		return
+	}
+	setPhaseMetric(restorePhase, name, string(restore.Status.Phase))
+
+	if restore.Status.Phase == v1beta1.RestorePhaseFinished && previousPhase != v1beta1.RestorePhaseFinished {
+		finishedTime := time.Now()
+		if restore.Status.CompletionTimestamp != nil {
+			finishedTime = restore.Status.CompletionTimestamp.Time
+		}
+		restoreDuration.Observe(finishedTime.Sub(restore.CreationTimestamp.Time).Seconds())
+	}
+}
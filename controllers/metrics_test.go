@@ -0,0 +1,299 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
+	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// returns the number of reconcile durations observed for this controller
+func getReconcileDurationCount(t *testing.T, controllerName string) uint64 {
+	metric := &dto.Metric{}
+	histogram := reconcileDuration.WithLabelValues(controllerName).(prometheus.Histogram)
+	if err := histogram.Write(metric); err != nil {
+		t.Fatalf("Error reading metric: %s", err.Error())
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+// returns the number of restore durations observed
+func getRestoreDurationCount(t *testing.T) uint64 {
+	metric := &dto.Metric{}
+	if err := restoreDuration.Write(metric); err != nil {
+		t.Fatalf("Error reading metric: %s", err.Error())
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func Test_recordReconcileMetrics(t *testing.T) {
+	veleroScheme := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(veleroScheme); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restore", Namespace: "ns"}}
+	tests := []struct {
+		name       string
+		reconciler *RestoreReconciler
+		wantErr    bool
+	}{
+		{
+			name: "restore not found",
+			reconciler: &RestoreReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme1).Build(),
+			},
+			wantErr: false,
+		},
+		{
+			name: "restore kind not registered",
+			reconciler: &RestoreReconciler{
+				Client: fake.NewClientBuilder().WithScheme(veleroScheme).Build(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			durationCount := getReconcileDurationCount(t, restoreControllerName)
+			errorCount := testutil.ToFloat64(reconcileErrors.WithLabelValues(restoreControllerName))
+
+			_, err := tt.reconciler.Reconcile(context.Background(), request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := getReconcileDurationCount(t, restoreControllerName); got != durationCount+1 {
+				t.Errorf("acm_backup_reconcile_duration_seconds count = %v, want %v", got, durationCount+1)
+			}
+			wantErrorCount := errorCount
+			if tt.wantErr {
+				wantErrorCount++
+			}
+			if got := testutil.ToFloat64(
+				reconcileErrors.WithLabelValues(restoreControllerName)); got != wantErrorCount {
+				t.Errorf("acm_backup_reconcile_errors_total = %v, want %v", got, wantErrorCount)
+			}
+		})
+	}
+}
+
+func Test_reportBackupCollision(t *testing.T) {
+	collisionMsg := fmt.Sprintf(BackupCollisionPhaseMsg,
+		"acm-resources-schedule-20220922170041", "cluster-2", "cluster-1")
+
+	tests := []struct {
+		name           string
+		backupSchedule *v1beta1.BackupSchedule
+		collisions     int
+	}{
+		{
+			name:           "one collision",
+			backupSchedule: createBackupSchedule("schedule-collision-1", "ns").object,
+			collisions:     1,
+		},
+		{
+			name:           "collision found again for the same schedule",
+			backupSchedule: createBackupSchedule("schedule-collision-2", "ns").object,
+			collisions:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &BackupScheduleReconciler{Recorder: recorder}
+			counter := backupCollisions.WithLabelValues(tt.backupSchedule.Namespace, tt.backupSchedule.Name)
+			collisionCount := testutil.ToFloat64(counter)
+
+			for i := 0; i < tt.collisions; i++ {
+				r.reportBackupCollision(tt.backupSchedule, "cluster-2", collisionMsg)
+			}
+
+			if got := testutil.ToFloat64(counter); got != collisionCount+float64(tt.collisions) {
+				t.Errorf("acm_backup_collisions_total = %v, want %v", got, collisionCount+float64(tt.collisions))
+			}
+			if len(recorder.Events) != tt.collisions {
+				t.Fatalf("reportBackupCollision() emitted %d events, want %d", len(recorder.Events), tt.collisions)
+			}
+			event := <-recorder.Events
+			wantEvent := "Warning BackupCollision Backup collision with cluster cluster-2: " + collisionMsg
+			if event != wantEvent {
+				t.Errorf("reportBackupCollision() event = %v, want %v", event, wantEvent)
+			}
+		})
+	}
+}
+
+// returns the phases reported by the phase gauge for this resource, with their value
+func getPhaseMetrics(t *testing.T, gauge *prometheus.GaugeVec, namespace, name string) map[string]float64 {
+	metricsChan := make(chan prometheus.Metric, 100)
+	gauge.Collect(metricsChan)
+	close(metricsChan)
+
+	phases := map[string]float64{}
+	for metric := range metricsChan {
+		dtoMetric := &dto.Metric{}
+		if err := metric.Write(dtoMetric); err != nil {
+			t.Fatalf("Error reading metric: %s", err.Error())
+		}
+		labels := map[string]string{}
+		for _, label := range dtoMetric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["namespace"] == namespace && labels["name"] == name {
+			phases[labels["phase"]] = dtoMetric.GetGauge().GetValue()
+		}
+	}
+	return phases
+}
+
+func Test_recordBackupSchedulePhase(t *testing.T) {
+	name := types.NamespacedName{Name: "schedule-phase", Namespace: "ns"}
+	deletedSchedule := createBackupSchedule(name.Name, name.Namespace).
+		phase(v1beta1.SchedulePhaseEnabled).object
+	deletedSchedule.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+
+	tests := []struct {
+		name           string
+		backupSchedule *v1beta1.BackupSchedule
+		want           map[string]float64
+	}{
+		{
+			name: "enabled",
+			backupSchedule: createBackupSchedule(name.Name, name.Namespace).
+				phase(v1beta1.SchedulePhaseEnabled).object,
+			want: map[string]float64{string(v1beta1.SchedulePhaseEnabled): 1},
+		},
+		{
+			name: "paused, the enabled phase is no longer reported",
+			backupSchedule: createBackupSchedule(name.Name, name.Namespace).
+				phase(v1beta1.SchedulePhasePaused).object,
+			want: map[string]float64{string(v1beta1.SchedulePhasePaused): 1},
+		},
+		{
+			name:           "being deleted",
+			backupSchedule: deletedSchedule,
+			want:           map[string]float64{},
+		},
+		{
+			name: "enabled again",
+			backupSchedule: createBackupSchedule(name.Name, name.Namespace).
+				phase(v1beta1.SchedulePhaseEnabled).object,
+			want: map[string]float64{string(v1beta1.SchedulePhaseEnabled): 1},
+		},
+		{
+			name:           "not found",
+			backupSchedule: &v1beta1.BackupSchedule{},
+			want:           map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordBackupSchedulePhase(name, tt.backupSchedule)
+			if got := getPhaseMetrics(t, backupSchedulePhase, name.Namespace, name.Name); !reflect.DeepEqual(
+				got, tt.want) {
+				t.Errorf("acm_backup_schedule_phase = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_recordRestorePhase(t *testing.T) {
+	name := types.NamespacedName{Name: "restore-phase", Namespace: "ns"}
+	createdTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	newRestore := func(phase v1beta1.RestorePhase) *v1beta1.Restore {
+		restore := createACMRestore(name.Name, name.Namespace).phase(phase).object
+		restore.CreationTimestamp = createdTime
+		return restore
+	}
+
+	tests := []struct {
+		name          string
+		restore       *v1beta1.Restore
+		previousPhase v1beta1.RestorePhase
+		want          map[string]float64
+		wantObserved  uint64
+	}{
+		{
+			name:          "started",
+			restore:       newRestore(v1beta1.RestorePhaseStarted),
+			previousPhase: "",
+			want:          map[string]float64{v1beta1.RestorePhaseStarted: 1},
+		},
+		{
+			name:          "finished, the restore duration is observed",
+			restore:       newRestore(v1beta1.RestorePhaseFinished),
+			previousPhase: v1beta1.RestorePhaseRunning,
+			want:          map[string]float64{v1beta1.RestorePhaseFinished: 1},
+			wantObserved:  1,
+		},
+		{
+			name:          "already finished, the restore duration is not observed again",
+			restore:       newRestore(v1beta1.RestorePhaseFinished),
+			previousPhase: v1beta1.RestorePhaseFinished,
+			want:          map[string]float64{v1beta1.RestorePhaseFinished: 1},
+		},
+		{
+			name:          "finished with errors, the restore duration is not observed",
+			restore:       newRestore(v1beta1.RestorePhaseFinishedWithErrors),
+			previousPhase: v1beta1.RestorePhaseRunning,
+			want:          map[string]float64{v1beta1.RestorePhaseFinishedWithErrors: 1},
+		},
+		{
+			name:    "not found",
+			restore: &v1beta1.Restore{},
+			want:    map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observed := getRestoreDurationCount(t)
+
+			recordRestorePhase(name, tt.restore, tt.previousPhase)
+
+			if got := getPhaseMetrics(t, restorePhase, name.Namespace, name.Name); !reflect.DeepEqual(
+				got, tt.want) {
+				t.Errorf("acm_restore_phase = %v, want %v", got, tt.want)
+			}
+			if got := getRestoreDurationCount(t); got != observed+tt.wantObserved {
+				t.Errorf("acm_restore_duration_seconds count = %v, want %v", got, observed+tt.wantObserved)
+			}
+		})
+	}
+}
@@ -58,6 +58,8 @@ const (
 	addon_work_label      = "open-cluster-management.io/addon-name-work"
 	addon_label           = "open-cluster-management.io/addon-name-work"
 	role_name             = "klusterlet-bootstrap-kubeconfig"
+	obs_hub_ns            = "open-cluster-management-observability"
+	obs_backup_label      = "observability"
 	msa_api               = "authentication.open-cluster-management.io/v1beta1"
 
 	manifest_work_name                   = "addon-" + msa_addon + "-import"
@@ -142,6 +144,10 @@ func (r *BackupScheduleReconciler) prepareForBackup(
 	updateAISecrets(ctx, r.Client)
 	updateMetalSecrets(ctx, r.Client)
 
+	if backupSchedule.Spec.IncludeObservabilityConfig {
+		updateObservabilityConfig(ctx, r.Client)
+	}
+
 	if useMSA && err == nil && dr != nil {
 		// managedserviceaccount is enabled, add backup labels
 		updateMSAResources(ctx, r.Client, dr)
@@ -183,6 +189,7 @@ func cleanupMSAForImportedClusters(
 				[]string{},
 				localClusterName,
 				false, // don't skip resource if ExcludeBackupLabel is set
+				nil,
 			)
 		}
 	}
@@ -915,6 +922,45 @@ func updateMetalSecrets(ctx context.Context,
 	}
 }
 
+// prepare the observability configuration, set by the user on the hub observability namespace
+func updateObservabilityConfig(ctx context.Context,
+	c client.Client,
+) {
+	logger := log.FromContext(ctx)
+
+	obsSecrets := &corev1.SecretList{}
+	if err := c.List(ctx, obsSecrets, client.InNamespace(obs_hub_ns)); err == nil {
+		for s := range obsSecrets.Items {
+			if findValue(observabilityConfigSecrets, obsSecrets.Items[s].Name) {
+				updateSecret(ctx, c, obsSecrets.Items[s],
+					backupCredsClusterLabel,
+					obs_backup_label, true)
+			}
+		}
+	}
+
+	obsConfigMaps := &corev1.ConfigMapList{}
+	if err := c.List(ctx, obsConfigMaps, client.InNamespace(obs_hub_ns)); err == nil {
+		for i := range obsConfigMaps.Items {
+			configMap := obsConfigMaps.Items[i]
+			if !findValue(observabilityConfigConfigMaps, configMap.Name) ||
+				!isSecretWithoutBackupLabel(&configMap) {
+				// not an observability configmap or already labeled for backup
+				continue
+			}
+			labels := configMap.GetLabels()
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[backupCredsClusterLabel] = obs_backup_label
+			configMap.SetLabels(labels)
+			if err := c.Update(ctx, &configMap, &client.UpdateOptions{}); err == nil {
+				logger.Info(fmt.Sprintf("Updated configmap %s in ns %s", configMap.Name, configMap.Namespace))
+			}
+		}
+	}
+}
+
 // set backup label for hive secrets not having the label set
 func updateSecretsLabels(ctx context.Context,
 	c client.Client,
@@ -38,6 +38,7 @@ import (
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workv1 "open-cluster-management.io/api/work/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -1143,3 +1144,90 @@ func Test_retrieveMSAImportSecrets(t *testing.T) {
 		})
 	}
 }
+
+func Test_updateObservabilityConfig(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			createSecret("thanos-object-storage", obs_hub_ns, nil, nil, nil),
+			createSecret("alertmanager-config", obs_hub_ns, map[string]string{
+				backupCredsUserLabel: "user",
+			}, nil, nil),
+			createSecret("observability-grafana-certs", obs_hub_ns, nil, nil, nil),
+			createSecret("thanos-object-storage", "other-ns", nil, nil, nil),
+			createConfigMap("observability-metrics-custom-allowlist", obs_hub_ns, nil),
+			createConfigMap("observability-other-config", obs_hub_ns, nil),
+		).
+		Build()
+
+	updateObservabilityConfig(context.Background(), fakeClient)
+
+	tests := []struct {
+		name      string
+		obj       client.Object
+		objName   string
+		namespace string
+		wantLabel string
+	}{
+		{
+			name:      "object storage secret labeled",
+			obj:       &corev1.Secret{},
+			objName:   "thanos-object-storage",
+			namespace: obs_hub_ns,
+			wantLabel: obs_backup_label,
+		},
+		{
+			name:      "alertmanager secret already labeled for backup",
+			obj:       &corev1.Secret{},
+			objName:   "alertmanager-config",
+			namespace: obs_hub_ns,
+			wantLabel: "",
+		},
+		{
+			name:      "other observability secret not labeled",
+			obj:       &corev1.Secret{},
+			objName:   "observability-grafana-certs",
+			namespace: obs_hub_ns,
+			wantLabel: "",
+		},
+		{
+			name:      "object storage secret from other namespace not labeled",
+			obj:       &corev1.Secret{},
+			objName:   "thanos-object-storage",
+			namespace: "other-ns",
+			wantLabel: "",
+		},
+		{
+			name:      "custom metrics allowlist labeled",
+			obj:       &corev1.ConfigMap{},
+			objName:   "observability-metrics-custom-allowlist",
+			namespace: obs_hub_ns,
+			wantLabel: obs_backup_label,
+		},
+		{
+			name:      "other observability configmap not labeled",
+			obj:       &corev1.ConfigMap{},
+			objName:   "observability-other-config",
+			namespace: obs_hub_ns,
+			wantLabel: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{
+				Name:      tt.objName,
+				Namespace: tt.namespace,
+			}, tt.obj); err != nil {
+				t.Fatalf("Error getting %s: %s", tt.objName, err.Error())
+			}
+			if got := tt.obj.GetLabels()[backupCredsClusterLabel]; got != tt.wantLabel {
+				t.Errorf("updateObservabilityConfig() %s label = %q, want %q",
+					backupCredsClusterLabel, got, tt.wantLabel)
+			}
+		})
+	}
+}
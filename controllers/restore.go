@@ -17,18 +17,38 @@ limitations under the License.
 package controllers
 
 import (
+	"archive/tar"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -37,20 +57,53 @@ import (
 const (
 	/* #nosec G101 -- This is a false positive */
 	activateLabel = "cluster.open-cluster-management.io/restore-auto-import-secret"
+	// RestoreOwnerLabel is set on the velero restores created by a Restore, the value is
+	// the name of the Restore; used to find the velero restores orphaned by a deleted Restore
+	RestoreOwnerLabel = "cluster.open-cluster-management.io/restore-owner"
+	// OrphanedRestoreLabel is set on the velero restores whose owning Restore no longer exists,
+	// the value is the name of the deleted Restore
+	OrphanedRestoreLabel = "cluster.open-cluster-management.io/orphaned-restore"
+	// label set on the ManagedCluster resources with the name of the managed cluster
+	managedClusterNameLabel = "name"
 	/* #nosec G101 -- This is a false positive */
 	keepAutoImportSecret = "managedcluster-import-controller.open-cluster-management.io/keeping-auto-import-secret"
 	/* #nosec G101 -- This is a false positive */
 	autoImportSecretName = "auto-import-secret"
+	// max number of phase transitions kept on the restore status
+	maxPhaseTransitions = 20
+	// backups started later than this, relative to the current time, indicate a clock skew
+	maxClockSkew = time.Minute
+	// name of the velero deployment, used to find the velero plugins installed on this hub
+	veleroDeploymentName = "velero"
+	// GitOpsPausedReplicasAnnotation is set on the GitOps controllers scaled down by a restore
+	// with the PauseGitOpsDuringRestore option, the value is the number of replicas to scale back to
+	GitOpsPausedReplicasAnnotation = "cluster.open-cluster-management.io/gitops-paused-replicas"
+	// label used to find the GitOps controllers paused by the PauseGitOpsDuringRestore option
+	gitOpsControllerNameLabel = "app.kubernetes.io/name"
+	// ClusterScopedRestoreLabel is set on the velero restore created by the RestoreClusterScopedFirst option
+	// to restore the cluster scoped resources of the resources backup
+	ClusterScopedRestoreLabel = "cluster.open-cluster-management.io/cluster-scoped-restore"
+	// suffix of the name of the velero restore created by the RestoreClusterScopedFirst option
+	clusterScopedRestoreSuffix = "cluster-scoped"
 )
 
+// GitOps controllers paused by the PauseGitOpsDuringRestore option, by app.kubernetes.io/name label value
+var gitOpsControllerNames = []string{
+	"argocd-application-controller",
+	"argocd-applicationset-controller",
+	"openshift-gitops-application-controller",
+	"openshift-gitops-applicationset-controller",
+}
+
 // resources should be restored in this order, higher priority starting from 0
 var ResourceTypePriority map[ResourceType]int = map[ResourceType]int{
 	Credentials:        0,
 	CredentialsHive:    1,
 	CredentialsCluster: 2,
-	Resources:          3,
-	ResourcesGeneric:   4,
-	ManagedClusters:    5,
+	ConfigMaps:         3,
+	Resources:          4,
+	ResourcesGeneric:   5,
+	ManagedClusters:    6,
 }
 
 // used for restore purposes; it differs from the
@@ -62,6 +115,7 @@ var veleroBackupNames = map[ResourceType]string{
 	CredentialsCluster: "acm-credentials-cluster-schedule",
 	Resources:          "acm-resources-schedule",
 	ResourcesGeneric:   "acm-resources-generic-schedule",
+	ConfigMaps:         "acm-configmaps-schedule",
 	ManagedClusters:    "acm-managed-clusters-schedule",
 	ValidationSchedule: "acm-validation-policy-schedule",
 }
@@ -123,9 +177,28 @@ func isValidSyncOptions(restore *v1beta1.Restore) (bool, string) {
 	if backupName != latestBackupStr {
 		return false, "VeleroResourcesBackupName should be set to latest."
 	}
+
+	if syncInterval := restore.Spec.RestoreSyncInterval.Duration; syncInterval != 0 &&
+		syncInterval < minRestoreSyncInterval {
+		return true, fmt.Sprintf("RestoreSyncInterval %s is lower than the minimum value, %s is used instead.",
+			syncInterval, minRestoreSyncInterval)
+	}
 	return true, ""
 }
 
+// returns the interval used to check for new backups when SyncRestoreWithNewBackups is set,
+// RestoreSyncInterval values lower than minRestoreSyncInterval are set to minRestoreSyncInterval
+func getRestoreSyncInterval(restore *v1beta1.Restore) time.Duration {
+	syncInterval := restore.Spec.RestoreSyncInterval.Duration
+	if syncInterval == 0 {
+		return restoreSyncInterval
+	}
+	if syncInterval < minRestoreSyncInterval {
+		return minRestoreSyncInterval
+	}
+	return syncInterval
+}
+
 func isSkipAllRestores(restore *v1beta1.Restore) bool {
 	backupName := ""
 
@@ -159,6 +232,30 @@ func isSkipAllRestores(restore *v1beta1.Restore) bool {
 	return true
 }
 
+// returns the resource types with the backup name explicitly set to skip
+func getSkippedResourceTypes(restore *v1beta1.Restore) []string {
+	backupNames := []struct {
+		key        ResourceType
+		backupName *string
+	}{
+		{ManagedClusters, restore.Spec.VeleroManagedClustersBackupName},
+		{Credentials, restore.Spec.VeleroCredentialsBackupName},
+		{Resources, restore.Spec.VeleroResourcesBackupName},
+	}
+
+	skipped := []string{}
+	for _, backupName := range backupNames {
+		if backupName.backupName != nil &&
+			strings.ToLower(strings.TrimSpace(*backupName.backupName)) == skipRestoreStr {
+			skipped = append(skipped, string(backupName.key))
+		}
+	}
+	if len(skipped) == 0 {
+		return nil
+	}
+	return skipped
+}
+
 func updateRestoreStatus(
 	logger logr.Logger,
 	status v1beta1.RestorePhase,
@@ -167,8 +264,7 @@ func updateRestoreStatus(
 ) {
 	logger.Info(msg)
 
-	restore.Status.Phase = status
-	restore.Status.LastMessage = msg
+	transitionRestorePhase(restore, status, msg)
 
 	// set CompletionTimestamp when restore is completed
 	restoreCompleted := (restore.Status.Phase == v1beta1.RestorePhaseFinished ||
@@ -180,6 +276,162 @@ func updateRestoreStatus(
 	}
 }
 
+// set the restore phase and message, recording the phase transition
+func transitionRestorePhase(
+	restore *v1beta1.Restore,
+	phase v1beta1.RestorePhase,
+	msg string,
+) {
+	previousPhase := restore.Status.Phase
+	restore.Status.Phase = phase
+	restore.Status.LastMessage = msg
+	recordPhaseTransition(restore, previousPhase)
+}
+
+// add a phase transition to the restore status if the phase has changed,
+// keeping only the latest maxPhaseTransitions entries
+func recordPhaseTransition(
+	restore *v1beta1.Restore,
+	previousPhase v1beta1.RestorePhase,
+) {
+	if restore.Status.Phase == previousPhase {
+		return
+	}
+
+	restore.Status.PhaseTransitions = append(restore.Status.PhaseTransitions, v1beta1.PhaseTransition{
+		Phase:     restore.Status.Phase,
+		Timestamp: metav1.Now(),
+		Message:   restore.Status.LastMessage,
+	})
+	if len(restore.Status.PhaseTransitions) > maxPhaseTransitions {
+		restore.Status.PhaseTransitions = restore.Status.PhaseTransitions[len(
+			restore.Status.PhaseTransitions)-maxPhaseTransitions:]
+	}
+}
+
+// returns the resources included by the velero backup which are not available on this cluster,
+// for example because the resource CRD is not installed
+func getMissingCRDs(
+	mapper meta.RESTMapper,
+	veleroBackup *veleroapi.Backup,
+) []string {
+	missingCRDs := []string{}
+	if veleroBackup == nil {
+		return missingCRDs
+	}
+
+	for _, resourceName := range veleroBackup.Spec.IncludedResources {
+		kind, groupName := getResourceDetails(resourceName)
+		if _, err := mapper.KindFor(schema.GroupVersionResource{
+			Group:    groupName,
+			Resource: kind,
+		}); err != nil {
+			missingCRDs = appendUnique(missingCRDs, resourceName)
+		}
+	}
+	sort.Strings(missingCRDs)
+
+	return missingCRDs
+}
+
+// DiffResult reports the differences between a backup and the resources on the cluster.
+// Velero does not expose the backed up items on the Backup resource, so the live resources
+// of each type included in the backup are compared using the velero backup name label
+// set on the restored resources.
+type DiffResult struct {
+	// resource types included in the backup with no CRD on this cluster,
+	// their resources exist only in the backup
+	OnlyInBackup []string
+	// live resources not restored from any backup
+	OnlyLive []string
+	// live resources restored from another backup, they may differ from this backup
+	Differ []string
+	// live resources restored from this backup
+	Restored []string
+}
+
+// DiffBackupAgainstCluster compares, without making any change, the velero backup
+// with the given name against the live resources on the cluster
+func DiffBackupAgainstCluster(
+	ctx context.Context,
+	c client.Client,
+	dc discovery.DiscoveryInterface,
+	dyn dynamic.Interface,
+	backupName string,
+) (DiffResult, error) {
+	result := DiffResult{
+		OnlyLive: []string{},
+		Differ:   []string{},
+		Restored: []string{},
+	}
+
+	backups := &veleroapi.BackupList{}
+	if err := c.List(ctx, backups); err != nil {
+		return result, err
+	}
+	var veleroBackup *veleroapi.Backup
+	for i := range backups.Items {
+		if backups.Items[i].Name == backupName {
+			veleroBackup = &backups.Items[i]
+			break
+		}
+	}
+	if veleroBackup == nil {
+		return result, fmt.Errorf("backup %s not found", backupName)
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc))
+	result.OnlyInBackup = getMissingCRDs(mapper, veleroBackup)
+
+	// use the same resources and labels as the cleanup of the restored resources
+	resources := veleroBackup.Spec.IncludedResources
+	genericLabel := fmt.Sprintf("!%s", backupCredsClusterLabel)
+	if veleroBackup.GetLabels()[BackupScheduleTypeLabel] == string(ResourcesGeneric) {
+		genericLabel = backupCredsClusterLabel
+		resources = getGenericCRDFromAPIGroups(ctx, dc, veleroBackup)
+	}
+
+	for _, resourceName := range resources {
+		kind, groupName := getResourceDetails(resourceName)
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: groupName, Kind: kind}, "")
+		if err != nil {
+			// no CRD on this cluster, reported with OnlyInBackup
+			continue
+		}
+
+		liveResources, err := dyn.Resource(mapping.Resource).List(ctx,
+			v1.ListOptions{LabelSelector: genericLabel})
+		if err != nil {
+			return result, err
+		}
+		for i := range liveResources.Items {
+			resource := &liveResources.Items[i]
+			if isResourceLocalCluster(resource) || resource.GetLabels()[ExcludeBackupLabel] == "true" {
+				// not backed up
+				continue
+			}
+
+			resourceID := resourceName + "/" + resource.GetName()
+			if resource.GetNamespace() != "" {
+				resourceID = resourceName + "/" + resource.GetNamespace() + "/" + resource.GetName()
+			}
+			switch resource.GetLabels()[BackupNameVeleroLabel] {
+			case "":
+				result.OnlyLive = append(result.OnlyLive, resourceID)
+			case backupName:
+				result.Restored = append(result.Restored, resourceID)
+			default:
+				result.Differ = append(result.Differ, resourceID)
+			}
+		}
+	}
+	sort.Strings(result.OnlyLive)
+	sort.Strings(result.Differ)
+	sort.Strings(result.Restored)
+
+	return result, nil
+}
+
 // set cumulative status of restores
 //
 //nolint:funlen
@@ -190,6 +442,12 @@ func setRestorePhase(
 	// returns true if the status has changed and resource delta need to be cleaned up
 	cleanupOnEnabled := false
 
+	previousPhase := restore.Status.Phase
+	defer recordPhaseTransition(restore, previousPhase)
+
+	restore.Status.SkippedResourceTypes = getSkippedResourceTypes(restore)
+	restore.Status.ItemOperationsInProgress = getItemOperationsInProgress(veleroRestoreList)
+
 	if restore.Status.Phase == v1beta1.RestorePhaseEnabled &&
 		restore.Spec.SyncRestoreWithNewBackups {
 		return restore.Status.Phase, cleanupOnEnabled
@@ -235,6 +493,17 @@ func setRestorePhase(
 			)
 			return restore.Status.Phase, cleanupOnEnabled
 		}
+		if veleroRestore.Status.Phase == veleroapi.RestorePhaseWaitingForPluginOperations ||
+			veleroRestore.Status.Phase == veleroapi.RestorePhaseWaitingForPluginOperationsPartiallyFailed {
+			// the resources are restored but the asynchronous item operations are still running
+			restore.Status.Phase = v1beta1.RestorePhaseRunning
+			restore.Status.LastMessage = fmt.Sprintf(
+				"Velero restore %s is waiting for %d item operations to complete",
+				veleroRestore.Name,
+				getVeleroRestoreItemOperationsInProgress(veleroRestore),
+			)
+			return restore.Status.Phase, cleanupOnEnabled
+		}
 		if veleroRestore.Status.Phase == veleroapi.RestorePhaseFailed ||
 			veleroRestore.Status.Phase == veleroapi.RestorePhaseFailedValidation {
 			restore.Status.Phase = v1beta1.RestorePhaseError
@@ -273,6 +542,123 @@ func setRestorePhase(
 	return restore.Status.Phase, cleanupOnEnabled
 }
 
+// returns the number of asynchronous item operations started by the velero restores
+// which are not completed or failed yet
+func getItemOperationsInProgress(
+	veleroRestoreList *veleroapi.RestoreList,
+) int {
+	inProgress := 0
+	if veleroRestoreList == nil {
+		return inProgress
+	}
+	for i := range veleroRestoreList.Items {
+		inProgress += getVeleroRestoreItemOperationsInProgress(&veleroRestoreList.Items[i])
+	}
+	return inProgress
+}
+
+// returns the velero restores created by a Restore which no longer exists
+func getOrphanedVeleroRestores(
+	veleroRestoreList *veleroapi.RestoreList,
+	restoreList *v1beta1.RestoreList,
+) []*veleroapi.Restore {
+	restoreNames := map[string]bool{}
+	for i := range restoreList.Items {
+		restoreNames[restoreList.Items[i].Name] = true
+	}
+
+	orphanedRestores := []*veleroapi.Restore{}
+	for i := range veleroRestoreList.Items {
+		veleroRestore := &veleroRestoreList.Items[i]
+		restoreName, created := veleroRestore.GetLabels()[RestoreOwnerLabel]
+		if !created || restoreNames[restoreName] {
+			// not created by a Restore, or the Restore still exists
+			continue
+		}
+		orphanedRestores = append(orphanedRestores, veleroRestore)
+	}
+	return orphanedRestores
+}
+
+// returns the progress of the restore: the phase, the percentage of velero restores completed
+// and the number of activated managed clusters
+func getRestoreProgress(
+	restore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+	currentTime time.Time,
+) v1beta1.RestoreProgress {
+	progress := v1beta1.RestoreProgress{
+		Phase:             restore.Status.Phase,
+		ActivatedClusters: len(restore.Status.ActivatedClusters),
+		Timestamp:         metav1.NewTime(currentTime),
+	}
+	switch {
+	case restore.Status.Phase == v1beta1.RestorePhaseFinished ||
+		restore.Status.Phase == v1beta1.RestorePhaseFinishedWithErrors:
+		progress.PercentComplete = 100
+	case veleroRestoreList != nil && len(veleroRestoreList.Items) > 0:
+		completed := 0
+		for i := range veleroRestoreList.Items {
+			switch veleroRestoreList.Items[i].Status.Phase {
+			case veleroapi.RestorePhaseCompleted,
+				veleroapi.RestorePhasePartiallyFailed,
+				veleroapi.RestorePhaseFailed,
+				veleroapi.RestorePhaseFailedValidation:
+				completed++
+			}
+		}
+		progress.PercentComplete = completed * 100 / len(veleroRestoreList.Items)
+	}
+	return progress
+}
+
+// with the EmitProgressEvents option, emits a RestoreProgress event when the restore progress changed
+// since the last event; progress changes within the same phase are emitted at most once per progressEventInterval
+func emitRestoreProgressEvent(
+	recorder record.EventRecorder,
+	restore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+	currentTime time.Time,
+) {
+	if !restore.Spec.EmitProgressEvents {
+		return
+	}
+
+	progress := getRestoreProgress(restore, veleroRestoreList, currentTime)
+	if last := restore.Status.LastProgressEvent; last != nil {
+		if last.Phase == progress.Phase &&
+			last.PercentComplete == progress.PercentComplete &&
+			last.ActivatedClusters == progress.ActivatedClusters {
+			// nothing changed
+			return
+		}
+		if last.Phase == progress.Phase &&
+			currentTime.Sub(last.Timestamp.Time) < progressEventInterval {
+			// report this change later
+			return
+		}
+	}
+
+	recorder.Event(restore, corev1.EventTypeNormal, "RestoreProgress",
+		fmt.Sprintf("Restore %s is %s, %d%% complete, %d managed clusters activated",
+			restore.Name, progress.Phase, progress.PercentComplete, progress.ActivatedClusters))
+	restore.Status.LastProgressEvent = &progress
+}
+
+// returns the number of asynchronous item operations started by the velero restore
+// which are not completed or failed yet
+func getVeleroRestoreItemOperationsInProgress(
+	veleroRestore *veleroapi.Restore,
+) int {
+	status := veleroRestore.Status
+	inProgress := status.RestoreItemOperationsAttempted - status.RestoreItemOperationsCompleted -
+		status.RestoreItemOperationsFailed
+	if inProgress < 0 {
+		return 0
+	}
+	return inProgress
+}
+
 // check if there is any active resource on this cluster
 func isOtherResourcesRunning(
 	ctx context.Context,
@@ -314,6 +700,59 @@ func isOtherResourcesRunning(
 	return "", nil
 }
 
+// report with the ActiveBackupScheduleDuringRestore condition an enabled BackupSchedule
+// found in the restore namespace; with the PauseActiveBackupSchedule or PointInTimeRecovery options,
+// the BackupSchedule is paused and the returned message is set while waiting for the BackupSchedule to be paused
+func checkActiveBackupSchedule(
+	ctx context.Context,
+	c client.Client,
+	restore *v1beta1.Restore,
+) (string, error) {
+	backupScheduleList := v1beta1.BackupScheduleList{}
+	if err := c.List(
+		ctx,
+		&backupScheduleList,
+		client.InNamespace(restore.Namespace),
+	); err != nil {
+		return "", err
+	}
+	backupScheduleName := isBackupScheduleRunning(backupScheduleList.Items)
+	if backupScheduleName == "" {
+		return "", nil
+	}
+
+	if !restore.Spec.PauseActiveBackupSchedule && !restore.Spec.PointInTimeRecovery {
+		meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+			Type:   v1beta1.RestoreActiveBackupScheduleDuringRestore,
+			Status: metav1.ConditionTrue,
+			Reason: v1beta1.RestoreReasonBackupScheduleActive,
+			Message: fmt.Sprintf("BackupSchedule %s is active, pause it by setting spec.paused to true "+
+				"or set the pauseActiveBackupSchedule option on restore %s", backupScheduleName, restore.Name),
+			ObservedGeneration: restore.Generation,
+		})
+		return "", nil
+	}
+
+	for i := range backupScheduleList.Items {
+		backupSchedule := &backupScheduleList.Items[i]
+		if backupSchedule.Name != backupScheduleName || backupSchedule.Spec.Paused {
+			continue
+		}
+		backupSchedule.Spec.Paused = true
+		if err := c.Update(ctx, backupSchedule); err != nil {
+			return "", fmt.Errorf("could not pause BackupSchedule %s: %w", backupScheduleName, err)
+		}
+	}
+	meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+		Type:               v1beta1.RestoreActiveBackupScheduleDuringRestore,
+		Status:             metav1.ConditionTrue,
+		Reason:             v1beta1.RestoreReasonBackupSchedulePaused,
+		Message:            fmt.Sprintf("BackupSchedule %s was paused by restore %s", backupScheduleName, restore.Name),
+		ObservedGeneration: restore.Generation,
+	})
+	return fmt.Sprintf("Waiting for BackupSchedule %s to be paused", backupScheduleName), nil
+}
+
 // check if there is a backup schedule running on this cluster
 func isBackupScheduleRunning(
 	schedules []v1beta1.BackupSchedule,
@@ -347,6 +786,10 @@ func isOtherRestoresRunning(
 		if restoreItem.Name == restoreName {
 			continue
 		}
+		if isRestoreWaitingForDependencies(&restoreItem) {
+			// not started yet, waiting for other restores to finish
+			continue
+		}
 		if restoreItem.Status.Phase != v1beta1.RestorePhaseFinished &&
 			restoreItem.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors {
 			return restoreItem.Name
@@ -356,6 +799,163 @@ func isOtherRestoresRunning(
 	return ""
 }
 
+// sets the Held phase on a restore with the Hold option set and
+// moves a held restore back to the Started phase when the hold is released;
+// returns true if the restore is on hold and must not be processed
+func setRestoreHold(
+	logger logr.Logger,
+	restore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+) bool {
+	if !restore.Spec.Hold {
+		if restore.Status.Phase == v1beta1.RestorePhaseHeld {
+			updateRestoreStatus(logger, v1beta1.RestorePhaseStarted,
+				fmt.Sprintf("Restore %s hold released", restore.Name), restore)
+		}
+		return false
+	}
+
+	inProgress := []string{}
+	for i := range veleroRestoreList.Items {
+		veleroRestore := &veleroRestoreList.Items[i]
+		if veleroRestore.Status.Phase == "" ||
+			veleroRestore.Status.Phase == veleroapi.RestorePhaseNew ||
+			veleroRestore.Status.Phase == veleroapi.RestorePhaseInProgress {
+			inProgress = append(inProgress, veleroRestore.Name)
+		}
+	}
+	msg := fmt.Sprintf("Restore %s is on hold, set the hold property to false to continue", restore.Name)
+	if len(inProgress) > 0 {
+		msg = fmt.Sprintf("%s ; Velero restores in progress: %s", msg, strings.Join(inProgress, ", "))
+	}
+	if restore.Status.Phase != v1beta1.RestorePhaseHeld || restore.Status.LastMessage != msg {
+		updateRestoreStatus(logger, v1beta1.RestorePhaseHeld, msg, restore)
+	}
+	return true
+}
+
+// returns true if the restore depends on other restores and was not started yet
+func isRestoreWaitingForDependencies(
+	restore *v1beta1.Restore,
+) bool {
+	return len(restore.Spec.DependsOn) > 0 &&
+		(restore.Status.Phase == "" || restore.Status.Phase == v1beta1.RestorePhaseWaiting)
+}
+
+// checks the restores listed under the DependsOn property of this restore;
+// returns the names of the restores not finished yet and an error message
+// if a dependency cycle is found or if one of these restores did not finish successfully
+func checkRestoreDependencies(
+	restore *v1beta1.Restore,
+	restores []v1beta1.Restore,
+) ([]string, string) {
+	restoresByName := make(map[string]*v1beta1.Restore, len(restores))
+	for i := range restores {
+		restoresByName[restores[i].Name] = &restores[i]
+	}
+	restoresByName[restore.Name] = restore
+
+	if cycle := findRestoreDependencyCycle(restore.Name, restoresByName); len(cycle) > 0 {
+		return nil, fmt.Sprintf("Restore dependency cycle found: %s",
+			strings.Join(cycle, " -> "))
+	}
+
+	waitingFor := []string{}
+	for _, dependencyName := range restore.Spec.DependsOn {
+		dependency, found := restoresByName[dependencyName]
+		if !found {
+			// the restore might not be created yet
+			waitingFor = appendUnique(waitingFor, dependencyName)
+			continue
+		}
+		switch dependency.Status.Phase {
+		case v1beta1.RestorePhaseFinished:
+			continue
+		case v1beta1.RestorePhaseFinishedWithErrors, v1beta1.RestorePhaseError:
+			return nil, fmt.Sprintf("Restore %s, listed under dependsOn, did not finish successfully",
+				dependencyName)
+		default:
+			waitingFor = appendUnique(waitingFor, dependencyName)
+		}
+	}
+	return waitingFor, ""
+}
+
+// returns the restores forming a dependency cycle starting with this restore,
+// or an empty list if there is no cycle
+func findRestoreDependencyCycle(
+	restoreName string,
+	restoresByName map[string]*v1beta1.Restore,
+) []string {
+	visited := map[string]bool{}
+	var visit func(path []string) []string
+	visit = func(path []string) []string {
+		restore, found := restoresByName[path[len(path)-1]]
+		if !found {
+			return nil
+		}
+		for _, dependencyName := range restore.Spec.DependsOn {
+			if dependencyName == restoreName {
+				return append(path, dependencyName)
+			}
+			if visited[dependencyName] {
+				continue
+			}
+			visited[dependencyName] = true
+			if cycle := visit(append(path[:len(path):len(path)], dependencyName)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit([]string{restoreName})
+}
+
+// keeps only the backups stored by the BackupStorageLocation set by the restore StorageLocation option
+// and by the BackupStorageLocations using the object storage prefix set by the StorageLocationPrefix option
+func filterBackupsByStorageLocation(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	veleroBackups *veleroapi.BackupList,
+) error {
+	name := acmRestore.Spec.StorageLocation
+	prefix := strings.Trim(acmRestore.Spec.StorageLocationPrefix, "/")
+	if name == "" && prefix == "" {
+		return nil
+	}
+
+	storageLocations := &veleroapi.BackupStorageLocationList{}
+	if err := c.List(ctx, storageLocations, client.InNamespace(acmRestore.Namespace)); err != nil {
+		return err
+	}
+	storageLocationNames := []string{}
+	for i := range storageLocations.Items {
+		if name != "" && storageLocations.Items[i].Name != name {
+			continue
+		}
+		objectStorage := storageLocations.Items[i].Spec.ObjectStorage
+		if prefix != "" && (objectStorage == nil || strings.Trim(objectStorage.Prefix, "/") != prefix) {
+			continue
+		}
+		storageLocationNames = append(storageLocationNames, storageLocations.Items[i].Name)
+	}
+	if len(storageLocationNames) == 0 {
+		if prefix == "" {
+			return fmt.Errorf("BackupStorageLocation %s not found", name)
+		}
+		if name == "" {
+			return fmt.Errorf("no BackupStorageLocation found with the object storage prefix %s", prefix)
+		}
+		return fmt.Errorf("BackupStorageLocation %s with the object storage prefix %s not found", name, prefix)
+	}
+
+	veleroBackups.Items = filterBackups(veleroBackups.Items, func(bkp veleroapi.Backup) bool {
+		return findValue(storageLocationNames, bkp.Spec.StorageLocation)
+	})
+	return nil
+}
+
 //nolint:funlen
 func isNewBackupAvailable(
 	ctx context.Context,
@@ -370,6 +970,10 @@ func isNewBackupAvailable(
 	// was used in the latest Velero restore for this resourceType
 	veleroBackups := &veleroapi.BackupList{}
 	if err := c.List(ctx, veleroBackups, client.InNamespace(restore.Namespace)); err == nil {
+		if err := filterBackupsByStorageLocation(ctx, c, restore, veleroBackups); err != nil {
+			logger.Error(err, "Failed to filter the Velero backups by storage location")
+			return false
+		}
 
 		newVeleroBackupName, newVeleroBackup, err := getVeleroBackupName(
 			ctx,
@@ -378,6 +982,8 @@ func isNewBackupAvailable(
 			resourceType,
 			latestBackupStr,
 			veleroBackups,
+			getBackupLabelSelector(restore),
+			restore.Spec.BackupManifest,
 		)
 		if err != nil {
 			logger.Error(
@@ -451,7 +1057,7 @@ func validateStorageSettings(
 	c client.Client,
 	_ string,
 	namespace string,
-	_ *v1beta1.Restore,
+	restore *v1beta1.Restore,
 ) (string, bool) {
 	retry := true
 	msg := ""
@@ -467,6 +1073,19 @@ func validateStorageSettings(
 		return msg, retry
 	}
 
+	// the storage location set by the restore StorageLocation option must be available
+	if restore != nil && restore.Spec.StorageLocation != "" {
+		if !isStorageLocationAvailable(veleroStorageLocations.Items, namespace, restore.Spec.StorageLocation) {
+			msg = "Backup storage location " + restore.Spec.StorageLocation + " not available in namespace " +
+				namespace + ". Check velero.io.BackupStorageLocation and validate storage credentials."
+
+			return msg, retry
+		}
+
+		retry = false
+		return msg, retry
+	}
+
 	// look for available VeleroStorageLocation
 	// and keep track of the velero oadp namespace
 	isValidStorageLocation := isValidStorageLocationDefined(
@@ -495,11 +1114,41 @@ func getVeleroBackupName(
 	resourceType ResourceType,
 	backupName string,
 	veleroBackups *veleroapi.BackupList,
+	backupSelector labels.Selector,
+	backupManifest *v1beta1.BackupManifest,
 ) (string, *veleroapi.Backup, error) {
 	if len(veleroBackups.Items) == 0 {
 		return "", nil, fmt.Errorf("no velero backups found")
 	}
 
+	if backupManifest != nil {
+		manifestBackupName := getManifestBackupName(backupManifest, resourceType)
+		switch {
+		case manifestBackupName != "" &&
+			(resourceType == CredentialsHive || resourceType == CredentialsCluster):
+			// find the hive or cluster credentials backup related to the manifest credentials backup
+			backupName = manifestBackupName
+		case manifestBackupName != "":
+			// use the backup set in the manifest, don't look for another backup if not found
+			manifestBackups := filterBackups(veleroBackups.Items, func(bkp veleroapi.Backup) bool {
+				return bkp.Name == manifestBackupName
+			})
+			if len(manifestBackups) == 0 {
+				return "", nil, fmt.Errorf(
+					"cannot find %s Velero Backup set in the backup manifest for resourceType %s",
+					manifestBackupName,
+					string(resourceType),
+				)
+			}
+			return manifestBackups[0].Name, &manifestBackups[0], nil
+		case backupManifest.RequireAllBackups:
+			return "", nil, fmt.Errorf(
+				"no backup set in the backup manifest for resourceType %s",
+				string(resourceType),
+			)
+		}
+	}
+
 	if backupName == latestBackupStr {
 		// backup name not available, find a proper backup
 		// filter available backups to get only the ones related to this resource type
@@ -514,30 +1163,42 @@ func getVeleroBackupName(
 			// when restoring backups which generated 3 backups for credentials
 			searchForBackupType = Credentials
 		}
+		if resourceType == ConfigMaps {
+			// the configmaps backup is optional, get the configmaps backup created
+			// at the same time as the latest resources backup, not an older configmaps backup
+			searchForBackupType = Resources
+		}
 		relatedBackups := filterBackups(veleroBackups.Items, func(bkp veleroapi.Backup) bool {
 			return strings.HasPrefix(bkp.Name, veleroBackupNames[searchForBackupType]) &&
 				(bkp.Status.Phase == veleroapi.BackupPhaseCompleted ||
-					bkp.Status.Phase == veleroapi.BackupPhasePartiallyFailed)
+					bkp.Status.Phase == veleroapi.BackupPhasePartiallyFailed) &&
+				(backupSelector == nil || backupSelector.Matches(labels.Set(bkp.GetLabels())))
 		})
 		if len(relatedBackups) == 0 {
 			return "", nil, fmt.Errorf("no backups found")
 		}
 		sort.Sort(mostRecent(relatedBackups))
+		if isBackupStartedInFuture(&relatedBackups[0], time.Now()) {
+			log.FromContext(ctx).Info("latest backup was started in the future, check the cluster clock",
+				"backup", relatedBackups[0].Name,
+				"startTimestamp", relatedBackups[0].Status.StartTimestamp)
+		}
 		// return found backup if the same type as the requested type
 		// or using the orSelector (credential backup)
 		if resourceType == searchForBackupType ||
 			len(relatedBackups[0].Spec.OrLabelSelectors) != 0 {
 			return relatedBackups[0].Name, &relatedBackups[0], nil
 		}
-		// otherwise, this is a hive or cluster credentials backup,
-		// find the most recent based on the credential backup name
+		// otherwise, this is a hive or cluster credentials backup or a configmaps backup,
+		// find the most recent based on the credential or resources backup name
 		backupName = relatedBackups[0].Name
 	}
 
 	// get the backup name for this type of resource, based on the requested resource timestamp
 	if resourceType == CredentialsHive ||
 		resourceType == CredentialsCluster ||
-		resourceType == ResourcesGeneric {
+		resourceType == ResourcesGeneric ||
+		resourceType == ConfigMaps {
 		// first try to find a backup for this resourceType with the exact timestamp
 		var computedName string
 		backupTimestamp := strings.LastIndex(backupName, "-")
@@ -629,26 +1290,174 @@ func retrieveRestoreDetails(
 	return restoreKeys, restoreMap, err
 }
 
-//nolint:funlen
-func processRetrieveRestoreDetails(
-	ctx context.Context,
-	c client.Client,
-	s *runtime.Scheme,
+// returns true if the backup was started in the future, relative to the current time
+func isBackupStartedInFuture(
+	veleroBackup *veleroapi.Backup,
+	now time.Time,
+) bool {
+	return veleroBackup != nil && veleroBackup.Status.StartTimestamp != nil &&
+		veleroBackup.Status.StartTimestamp.Time.Sub(now) > maxClockSkew
+}
+
+// set the ClockSkewDetected condition if any of the restored backups was started in the future
+func setClockSkewCondition(
 	acmRestore *v1beta1.Restore,
-	restoreKeys []ResourceType,
-) (map[ResourceType]*veleroapi.Restore,
-	error,
+	futureBackups []string,
 ) {
-	restoreLogger := log.FromContext(ctx)
+	if len(futureBackups) == 0 {
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreClockSkewDetected,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1beta1.RestoreReasonNoClockSkew,
+			ObservedGeneration: acmRestore.Generation,
+		})
+		return
+	}
+	meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+		Type:   v1beta1.RestoreClockSkewDetected,
+		Status: metav1.ConditionTrue,
+		Reason: v1beta1.RestoreReasonClockSkew,
+		Message: fmt.Sprintf("Backups %v were started in the future, relative to the current time on this cluster."+
+			" Verify the clock of this cluster and of the cluster creating the backups.", futureBackups),
+		ObservedGeneration: acmRestore.Generation,
+	})
+}
 
-	veleroRestoresToCreate := make(map[ResourceType]*veleroapi.Restore, len(restoreKeys))
+// returns the velero plugins recorded on the backup with the BackupVeleroPluginsAnnotation
+func getBackupVeleroPlugins(
+	veleroBackup *veleroapi.Backup,
+) []string {
+	plugins := []string{}
+	if veleroBackup == nil {
+		return plugins
+	}
+	for _, plugin := range strings.Split(veleroBackup.GetAnnotations()[BackupVeleroPluginsAnnotation], ",") {
+		if plugin = getVeleroPluginName(plugin); plugin != "" {
+			plugins = appendUnique(plugins, plugin)
+		}
+	}
+	return plugins
+}
 
-	veleroBackups := &veleroapi.BackupList{}
-	if err := c.List(ctx, veleroBackups, client.InNamespace(acmRestore.Namespace)); err == nil {
-		for i := range restoreKeys {
-			backupName := latestBackupStr
+// returns the plugin name from a plugin image,
+// for example velero-plugin-for-aws for quay.io/konveyor/velero-plugin-for-aws:latest
+func getVeleroPluginName(
+	image string,
+) string {
+	name := strings.TrimSpace(image)
+	if index := strings.LastIndex(name, "@"); index >= 0 {
+		name = name[:index]
+	}
+	if index := strings.LastIndex(name, "/"); index >= 0 {
+		name = name[index+1:]
+	}
+	if index := strings.LastIndex(name, ":"); index >= 0 {
+		name = name[:index]
+	}
+	return name
+}
 
-			key := restoreKeys[i]
+// returns the velero plugins installed on this hub, as set on the init containers of the velero deployment
+func getInstalledVeleroPlugins(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+) ([]string, error) {
+	veleroDeployment := &appsv1.Deployment{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Name:      veleroDeploymentName,
+		Namespace: namespace,
+	}, veleroDeployment); err != nil {
+		return nil, err
+	}
+	plugins := []string{}
+	for _, container := range veleroDeployment.Spec.Template.Spec.InitContainers {
+		if plugin := getVeleroPluginName(container.Image); plugin != "" {
+			plugins = appendUnique(plugins, plugin)
+		}
+	}
+	return plugins, nil
+}
+
+// set the RestorePluginMismatch condition if the restored backups were created
+// with velero plugins not installed on this hub
+func setPluginMismatchCondition(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	backupPlugins []string,
+) {
+	if len(backupPlugins) == 0 {
+		// the backups don't record the velero plugins
+		return
+	}
+	installedPlugins, err := getInstalledVeleroPlugins(ctx, c, acmRestore.Namespace)
+	if err != nil {
+		log.FromContext(ctx).Info("cannot get the installed velero plugins", "error", err.Error())
+		return
+	}
+	missingPlugins := []string{}
+	for _, plugin := range backupPlugins {
+		if !findValue(installedPlugins, plugin) {
+			missingPlugins = append(missingPlugins, plugin)
+		}
+	}
+	sort.Strings(missingPlugins)
+
+	if len(missingPlugins) == 0 {
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestorePluginMismatch,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1beta1.RestoreReasonPluginsFound,
+			ObservedGeneration: acmRestore.Generation,
+		})
+		return
+	}
+	meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+		Type:   v1beta1.RestorePluginMismatch,
+		Status: metav1.ConditionTrue,
+		Reason: v1beta1.RestoreReasonPluginsMissing,
+		Message: fmt.Sprintf("Velero plugins %v used by the restored backups are not installed on this cluster."+
+			" The restore may partially fail.", missingPlugins),
+		ObservedGeneration: acmRestore.Generation,
+	})
+}
+
+//nolint:funlen
+func processRetrieveRestoreDetails(
+	ctx context.Context,
+	c client.Client,
+	s *runtime.Scheme,
+	acmRestore *v1beta1.Restore,
+	restoreKeys []ResourceType,
+) (map[ResourceType]*veleroapi.Restore,
+	error,
+) {
+	restoreLogger := log.FromContext(ctx)
+
+	veleroRestoresToCreate := make(map[ResourceType]*veleroapi.Restore, len(restoreKeys))
+	futureBackups := []string{}
+	defer func() { setClockSkewCondition(acmRestore, futureBackups) }()
+	backupPlugins := []string{}
+	defer func() { setPluginMismatchCondition(ctx, c, acmRestore, backupPlugins) }()
+
+	clusterSetNamespaceMapping, err := getClusterSetNamespaceMapping(ctx, c, acmRestore.Spec.ClusterSetMapping)
+	if err != nil {
+		acmRestore.Status.LastMessage = "Could not get the managed clusters for the ClusterSetMapping option"
+		return veleroRestoresToCreate, err
+	}
+
+	veleroBackups := &veleroapi.BackupList{}
+	if err := c.List(ctx, veleroBackups, client.InNamespace(acmRestore.Namespace)); err == nil {
+		if err := filterBackupsByStorageLocation(ctx, c, acmRestore, veleroBackups); err != nil {
+			acmRestore.Status.LastMessage = err.Error()
+			return veleroRestoresToCreate, err
+		}
+
+		for i := range restoreKeys {
+			backupName := latestBackupStr
+
+			key := restoreKeys[i]
 			switch key {
 			case ManagedClusters:
 				if acmRestore.Spec.VeleroManagedClustersBackupName != nil {
@@ -658,7 +1467,7 @@ func processRetrieveRestoreDetails(
 				if acmRestore.Spec.VeleroCredentialsBackupName != nil {
 					backupName = *acmRestore.Spec.VeleroCredentialsBackupName
 				}
-			case ResourcesGeneric, Resources:
+			case ResourcesGeneric, Resources, ConfigMaps:
 				if acmRestore.Spec.VeleroResourcesBackupName != nil {
 					backupName = *acmRestore.Spec.VeleroResourcesBackupName
 				}
@@ -697,9 +1506,11 @@ func processRetrieveRestoreDetails(
 				key,
 				backupName,
 				veleroBackups,
+				getBackupLabelSelector(acmRestore),
+				acmRestore.Spec.BackupManifest,
 			)
 			if err != nil {
-				if key != CredentialsHive && key != CredentialsCluster && key != ResourcesGeneric {
+				if isVeleroBackupRequired(acmRestore, key) {
 					// ignore missing hive or cluster key backup files
 					// for the case when the backups were created with an older controller version
 					// or with oadp 1.1 when the OrSelector has being used
@@ -714,10 +1525,19 @@ func processRetrieveRestoreDetails(
 						backupName,
 						key,
 					)
+					if acmRestore.Spec.BackupManifest != nil {
+						acmRestore.Status.LastMessage = err.Error()
+					}
 
 					return veleroRestoresToCreate, err
 				}
 			} else {
+				if isBackupStartedInFuture(veleroBackup, time.Now()) {
+					futureBackups = appendUnique(futureBackups, veleroBackupName)
+				}
+				for _, plugin := range getBackupVeleroPlugins(veleroBackup) {
+					backupPlugins = appendUnique(backupPlugins, plugin)
+				}
 				veleroRestore.Name = getValidKsRestoreName(acmRestore.Name, veleroBackupName)
 
 				veleroRestore.Namespace = acmRestore.Namespace
@@ -729,9 +1549,34 @@ func processRetrieveRestoreDetails(
 					labels = make(map[string]string)
 				}
 				labels[BackupScheduleClusterLabel] = veleroBackup.GetLabels()[BackupScheduleClusterLabel]
+				if len(validation.IsValidLabelValue(acmRestore.Name)) == 0 {
+					// the velero restore owner reference is removed if the Restore is deleted
+					// with the orphan propagation policy, the label is kept
+					labels[RestoreOwnerLabel] = acmRestore.Name
+				}
 				veleroRestore.SetLabels(labels)
 
 				setOptionalProperties(key, acmRestore, veleroRestore)
+				setClusterSetNamespaceMapping(clusterSetNamespaceMapping, veleroRestore)
+				if acmRestore.Spec.SandboxNamespacePrefix != "" {
+					if err := setSandboxNamespaceMapping(ctx, c, acmRestore.Spec.SandboxNamespacePrefix,
+						veleroBackup, veleroRestore); err != nil {
+						acmRestore.Status.LastMessage = fmt.Sprintf(
+							"Could not set the sandbox namespaces for resource type: %s",
+							key,
+						)
+						return veleroRestoresToCreate, err
+					}
+				}
+
+				if key == Resources && acmRestore.Spec.SinceBackupName != "" {
+					// the delta is computed from the backups content before the velero restore is created
+					if err := validateSinceBackup(veleroBackup, veleroBackups,
+						acmRestore.Spec.SinceBackupName); err != nil {
+						acmRestore.Status.LastMessage = err.Error()
+						return veleroRestoresToCreate, err
+					}
+				}
 
 				if err := ctrl.SetControllerReference(acmRestore, veleroRestore, s); err != nil {
 					acmRestore.Status.LastMessage = fmt.Sprintf(
@@ -747,16 +1592,26 @@ func processRetrieveRestoreDetails(
 	return veleroRestoresToCreate, nil
 }
 
+// returns the includeClusterResources value for the velero restore of this resource type;
+// cluster scoped resources are restored for all restores except credentials,
+// unless the IncludeClusterResources option is set
+func getIncludeClusterResources(
+	key ResourceType,
+	acmRestore *v1beta1.Restore,
+) *bool {
+	clusterResource := key == Resources || key == ManagedClusters || key == ResourcesGeneric
+	if acmRestore.Spec.IncludeClusterResources != nil {
+		clusterResource = *acmRestore.Spec.IncludeClusterResources
+	}
+	return &clusterResource
+}
+
 func setOptionalProperties(
 	key ResourceType,
 	acmRestore *v1beta1.Restore,
 	veleroRestore *veleroapi.Restore,
 ) {
-	// set includeClusterResources for all restores except credentials
-	if key == Resources || key == ManagedClusters || key == ResourcesGeneric {
-		var clusterResource bool = true
-		veleroRestore.Spec.IncludeClusterResources = &clusterResource
-	}
+	veleroRestore.Spec.IncludeClusterResources = getIncludeClusterResources(key, acmRestore)
 
 	veleroRestore.Spec.ExcludedResources = append(veleroRestore.Spec.ExcludedResources, "CustomResourceDefinition")
 
@@ -773,6 +1628,12 @@ func setOptionalProperties(
 	if acmRestore.Spec.RestorePVs != nil {
 		veleroRestore.Spec.RestorePVs = acmRestore.Spec.RestorePVs
 	}
+	if acmRestore.Spec.UploaderConfig != nil && key == Resources {
+		veleroRestore.Spec.UploaderConfig = acmRestore.Spec.UploaderConfig.DeepCopy()
+	}
+	if acmRestore.Spec.ItemOperationTimeout.Duration != 0 && key == Resources {
+		veleroRestore.Spec.ItemOperationTimeout = acmRestore.Spec.ItemOperationTimeout
+	}
 	if len(acmRestore.Spec.Hooks.Resources) > 0 {
 		veleroRestore.Spec.Hooks.Resources = append(veleroRestore.Spec.Hooks.Resources,
 			acmRestore.Spec.Hooks.Resources...,
@@ -782,10 +1643,636 @@ func setOptionalProperties(
 	// set user options for resource filtering
 	setUserRestoreFilters(acmRestore, veleroRestore)
 
+	// OR label selectors set only for the resources restore
+	if len(acmRestore.Spec.ResourcesRestoreOrLabelSelectors) > 0 && key == Resources {
+		veleroRestore.Spec.OrLabelSelectors = make([]*v1.LabelSelector, 0,
+			len(acmRestore.Spec.ResourcesRestoreOrLabelSelectors))
+		for i := range acmRestore.Spec.ResourcesRestoreOrLabelSelectors {
+			veleroRestore.Spec.OrLabelSelectors = append(veleroRestore.Spec.OrLabelSelectors,
+				acmRestore.Spec.ResourcesRestoreOrLabelSelectors[i].DeepCopy())
+		}
+	}
+
 	// allow namespace mapping
 	if acmRestore.Spec.NamespaceMapping != nil {
 		veleroRestore.Spec.NamespaceMapping = acmRestore.Spec.NamespaceMapping
 	}
+
+	// scope the restore to a single managed cluster
+	setSingleClusterRestoreFilters(key, acmRestore, veleroRestore)
+}
+
+// returns true if the resources restore is run in two steps by the RestoreClusterScopedFirst option,
+// the cluster scoped resources first and then the namespaced resources;
+// the option is not used when syncing with new backups or when the cluster scoped resources are not restored
+func isClusterScopedFirstRestore(
+	acmRestore *v1beta1.Restore,
+	veleroRestore *veleroapi.Restore,
+	sync bool,
+) bool {
+	return acmRestore.Spec.RestoreClusterScopedFirst && !sync &&
+		veleroRestore.Spec.IncludeClusterResources != nil &&
+		*veleroRestore.Spec.IncludeClusterResources
+}
+
+// returns the velero restore restoring only the cluster scoped resources from the backup
+// restored by this velero restore; velero restores no namespaced resources
+// when the included namespaces list contains only the empty namespace
+func getClusterScopedResourcesRestore(
+	veleroRestore *veleroapi.Restore,
+) *veleroapi.Restore {
+	clusterScopedRestore := veleroRestore.DeepCopy()
+	clusterScopedRestore.Name = getValidKsRestoreName(veleroRestore.Name, clusterScopedRestoreSuffix)
+
+	labels := clusterScopedRestore.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ClusterScopedRestoreLabel] = "true"
+	clusterScopedRestore.SetLabels(labels)
+
+	includeClusterResources := true
+	clusterScopedRestore.Spec.IncludeClusterResources = &includeClusterResources
+	clusterScopedRestore.Spec.IncludedNamespaces = []string{""}
+	clusterScopedRestore.Spec.ExcludedNamespaces = nil
+	clusterScopedRestore.Spec.NamespaceMapping = nil
+	return clusterScopedRestore
+}
+
+// scope the velero restore to the managed cluster set by the SingleClusterRestore option;
+// only the managed cluster namespace is restored and, for the managed clusters restore,
+// the ManagedCluster with this name and the cluster scoped resources not labeled with a cluster name
+func setSingleClusterRestoreFilters(
+	key ResourceType,
+	acmRestore *v1beta1.Restore,
+	veleroRestore *veleroapi.Restore,
+) {
+	clusterName := acmRestore.Spec.SingleClusterRestore
+	if clusterName == "" {
+		return
+	}
+
+	veleroRestore.Spec.IncludedNamespaces = []string{clusterName}
+
+	clusterResource := key == ManagedClusters
+	veleroRestore.Spec.IncludeClusterResources = &clusterResource
+	if key != ManagedClusters {
+		return
+	}
+
+	veleroRestore.Spec.LabelSelector = nil
+	veleroRestore.Spec.OrLabelSelectors = []*v1.LabelSelector{
+		{
+			MatchLabels: map[string]string{managedClusterNameLabel: clusterName},
+		},
+		{
+			MatchExpressions: []v1.LabelSelectorRequirement{
+				{
+					Key:      managedClusterNameLabel,
+					Operator: v1.LabelSelectorOpDoesNotExist,
+				},
+			},
+		},
+	}
+}
+
+// returns an error if the sinceBackupName backup, used to restore only the delta
+// with the velero backup, is not found or is not older than the velero backup
+func validateSinceBackup(
+	veleroBackup *veleroapi.Backup,
+	veleroBackups *veleroapi.BackupList,
+	sinceBackupName string,
+) error {
+	var sinceBackup *veleroapi.Backup
+	for i := range veleroBackups.Items {
+		if veleroBackups.Items[i].Name == sinceBackupName {
+			sinceBackup = &veleroBackups.Items[i]
+			break
+		}
+	}
+	if sinceBackup == nil {
+		return fmt.Errorf("SinceBackupName backup %s not found", sinceBackupName)
+	}
+	if sinceBackup.Status.StartTimestamp == nil || veleroBackup.Status.StartTimestamp == nil ||
+		!sinceBackup.Status.StartTimestamp.Before(veleroBackup.Status.StartTimestamp) {
+		return fmt.Errorf("SinceBackupName backup %s is not older than the restored backup %s",
+			sinceBackupName, veleroBackup.Name)
+	}
+	return nil
+}
+
+// returns the resource types with items added or changed in the backup since the older
+// sinceBackupName backup, used to restore only the delta between the two backups;
+// returns false if velero did not process yet the download requests used to read the backups content
+func getIncrementalResources(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	backupName string,
+	sinceBackupName string,
+) ([]string, bool, error) {
+	// request the content of both backups before waiting for velero to process the download requests
+	sinceItems, sinceReady, err := getBackupContentItems(ctx, c, namespace, sinceBackupName)
+	if err != nil {
+		return nil, false, err
+	}
+	items, ready, err := getBackupContentItems(ctx, c, namespace, backupName)
+	if err != nil || !ready || !sinceReady {
+		return nil, false, err
+	}
+
+	includedResources := []string{}
+	for resourceName, resourceItems := range items {
+		for itemPath, checksum := range resourceItems {
+			if sinceItems[resourceName][itemPath] != checksum {
+				// item added or changed since the older backup
+				includedResources = append(includedResources, resourceName)
+				break
+			}
+		}
+	}
+	sort.Strings(includedResources)
+	return includedResources, true, nil
+}
+
+// returns the checksum of each item stored by the backup, by resource name and item path,
+// read from the backup content; velero stores each item under
+// resources/<resource name>/[<version>-preferredversion/]{namespaces/<namespace>|cluster}/<name>.json
+// the backup resource list is not used, it doesn't tell if an item changed between two backups
+// returns false if velero did not process yet the download request used to read the backup content
+func getBackupContentItems(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	backupName string,
+) (map[string]map[string]string, bool, error) {
+	items := map[string]map[string]string{}
+	ready, err := readVeleroDownload(ctx, c,
+		types.NamespacedName{Name: backupName + "-contents", Namespace: namespace},
+		veleroapi.DownloadTarget{Kind: veleroapi.DownloadTargetKindBackupContents, Name: backupName},
+		func(content io.Reader) error {
+			tarReader := tar.NewReader(content)
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				itemPath, found := strings.CutPrefix(header.Name, "resources/")
+				if !found || header.Typeflag != tar.TypeReg {
+					continue
+				}
+				resourceName, itemPath, found := strings.Cut(itemPath, "/")
+				if !found {
+					continue
+				}
+				hash := sha256.New()
+				// #nosec G110 -- the backup content is read from the velero backup storage location
+				if _, err := io.Copy(hash, tarReader); err != nil {
+					return err
+				}
+				if items[resourceName] == nil {
+					items[resourceName] = map[string]string{}
+				}
+				items[resourceName][itemPath] = hex.EncodeToString(hash.Sum(nil))
+			}
+		})
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read the content of backup %s: %w", backupName, err)
+	}
+	return items, ready, nil
+}
+
+// returns the backup name set in the backup manifest for this resource type,
+// or an empty string if not set; the hive and cluster credentials backups
+// are found using the credentials backup name
+func getManifestBackupName(
+	backupManifest *v1beta1.BackupManifest,
+	resourceType ResourceType,
+) string {
+	switch resourceType {
+	case ManagedClusters:
+		return backupManifest.ManagedClustersBackupName
+	case Credentials, CredentialsHive, CredentialsCluster:
+		return backupManifest.CredentialsBackupName
+	case Resources:
+		return backupManifest.ResourcesBackupName
+	case ResourcesGeneric:
+		return backupManifest.ResourcesGenericBackupName
+	}
+	return ""
+}
+
+// returns true if the restore must fail when the velero backup for this resource type is not found;
+// the hive and cluster credentials and the configmaps backups are optional, the generic resources
+// backup is optional unless it is set or required by the backup manifest
+func isVeleroBackupRequired(
+	acmRestore *v1beta1.Restore,
+	resourceType ResourceType,
+) bool {
+	if resourceType == CredentialsHive || resourceType == CredentialsCluster || resourceType == ConfigMaps {
+		return false
+	}
+	if resourceType != ResourcesGeneric {
+		return true
+	}
+	backupManifest := acmRestore.Spec.BackupManifest
+	return backupManifest != nil &&
+		(backupManifest.ResourcesGenericBackupName != "" || backupManifest.RequireAllBackups)
+}
+
+// returns the selector used to filter the backups when the latest backup is restored,
+// or nil if the BackupLabelSelector option is not set or not valid
+func getBackupLabelSelector(
+	acmRestore *v1beta1.Restore,
+) labels.Selector {
+	if acmRestore.Spec.BackupLabelSelector == nil {
+		return nil
+	}
+	selector, err := v1.LabelSelectorAsSelector(acmRestore.Spec.BackupLabelSelector)
+	if err != nil {
+		return nil
+	}
+	return selector
+}
+
+// returns an error message if the BackupLabelSelector option is not valid
+func isValidBackupLabelSelector(
+	acmRestore *v1beta1.Restore,
+) string {
+	if acmRestore.Spec.BackupLabelSelector == nil {
+		return ""
+	}
+	if _, err := v1.LabelSelectorAsSelector(acmRestore.Spec.BackupLabelSelector); err != nil {
+		return "invalid BackupLabelSelector : " + err.Error()
+	}
+	return ""
+}
+
+// returns an error message if a namespace is set on both
+// the IncludedNamespaces and ExcludedNamespaces options
+func isValidNamespaceFilters(
+	acmRestore *v1beta1.Restore,
+) string {
+	overlap := []string{}
+	for _, ns := range acmRestore.Spec.ExcludedNamespaces {
+		if findValue(acmRestore.Spec.IncludedNamespaces, ns) {
+			overlap = appendUnique(overlap, ns)
+		}
+	}
+	if len(overlap) > 0 {
+		return fmt.Sprintf("namespaces %v cannot be set on both the IncludedNamespaces "+
+			"and ExcludedNamespaces options", overlap)
+	}
+	return ""
+}
+
+// returns an error message if the SandboxNamespacePrefix option is not valid
+// or is used with options changing the hub resources
+func isValidSandboxOptions(
+	acmRestore *v1beta1.Restore,
+) string {
+	prefix := acmRestore.Spec.SandboxNamespacePrefix
+	if prefix == "" {
+		if acmRestore.Spec.ValidationRestore {
+			return "ValidationRestore requires the SandboxNamespacePrefix option"
+		}
+		return ""
+	}
+
+	if len(prefix) > maxSandboxNamespacePrefixLength {
+		return fmt.Sprintf("invalid SandboxNamespacePrefix : must be no more than %d characters",
+			maxSandboxNamespacePrefixLength)
+	}
+	if errs := validation.IsDNS1123Label(prefix); len(errs) > 0 {
+		return fmt.Sprintf("invalid SandboxNamespacePrefix : %s", strings.Join(errs, ", "))
+	}
+	if acmRestore.Spec.CleanupBeforeRestore != v1beta1.CleanupTypeNone {
+		return "SandboxNamespacePrefix can be used only with the CleanupBeforeRestore option set to None"
+	}
+	if acmRestore.Spec.VeleroManagedClustersBackupName == nil ||
+		strings.ToLower(strings.TrimSpace(*acmRestore.Spec.VeleroManagedClustersBackupName)) != skipRestoreStr {
+		return "SandboxNamespacePrefix can be used only with VeleroManagedClustersBackupName set to skip"
+	}
+	if len(acmRestore.Spec.NamespaceMapping) > 0 {
+		return "SandboxNamespacePrefix cannot be used together with the NamespaceMapping option"
+	}
+	if acmRestore.Spec.IncludeClusterResources != nil && *acmRestore.Spec.IncludeClusterResources {
+		return "SandboxNamespacePrefix cannot be used with the IncludeClusterResources option set to true"
+	}
+	return ""
+}
+
+// returns the namespaces restored from this backup in the sandbox namespaces;
+// velero doesn't list the backed up namespaces on the Backup resource, so these are
+// the namespaces included by the backup, or the namespaces on this cluster if the backup
+// includes all namespaces, without the namespaces excluded by the backup and the sandbox namespaces
+func getSandboxSourceNamespaces(
+	ctx context.Context,
+	c client.Client,
+	prefix string,
+	veleroBackup *veleroapi.Backup,
+) ([]string, error) {
+	namespaces := []string{}
+	for _, ns := range veleroBackup.Spec.IncludedNamespaces {
+		if ns != "*" && ns != "" {
+			namespaces = appendUnique(namespaces, ns)
+		}
+	}
+	if len(namespaces) == 0 {
+		namespaceList := corev1.NamespaceList{}
+		if err := c.List(ctx, &namespaceList); err != nil {
+			return nil, err
+		}
+		for i := range namespaceList.Items {
+			namespaces = appendUnique(namespaces, namespaceList.Items[i].Name)
+		}
+	}
+
+	sourceNamespaces := []string{}
+	for _, ns := range namespaces {
+		if findValue(veleroBackup.Spec.ExcludedNamespaces, ns) ||
+			strings.HasPrefix(ns, prefix+"-") {
+			continue
+		}
+		sourceNamespaces = append(sourceNamespaces, ns)
+	}
+	sort.Strings(sourceNamespaces)
+	return sourceNamespaces, nil
+}
+
+// restores the namespaced resources from the backup into the sandbox namespaces,
+// named <prefix>-<original namespace>, and skips the cluster scoped resources
+func setSandboxNamespaceMapping(
+	ctx context.Context,
+	c client.Client,
+	prefix string,
+	veleroBackup *veleroapi.Backup,
+	veleroRestore *veleroapi.Restore,
+) error {
+	// namespaces included by the user are the only ones restored
+	sourceNamespaces := veleroRestore.Spec.IncludedNamespaces
+	if len(sourceNamespaces) == 0 {
+		var err error
+		if sourceNamespaces, err = getSandboxSourceNamespaces(ctx, c, prefix, veleroBackup); err != nil {
+			return err
+		}
+	}
+	if len(sourceNamespaces) == 0 {
+		return fmt.Errorf("no namespaces found to restore from backup %s", veleroBackup.Name)
+	}
+
+	clusterResource := false
+	veleroRestore.Spec.IncludeClusterResources = &clusterResource
+	// restore only the mapped namespaces, so no resource is restored in the original namespace
+	veleroRestore.Spec.IncludedNamespaces = sourceNamespaces
+	veleroRestore.Spec.NamespaceMapping = make(map[string]string, len(sourceNamespaces))
+	for _, ns := range sourceNamespaces {
+		veleroRestore.Spec.NamespaceMapping[ns] = getSandboxNamespaceName(prefix, ns)
+	}
+	return nil
+}
+
+// returns the namespace mapping for the ClusterSetMapping option; the namespace of each
+// managed cluster on this hub, in a mapped ManagedClusterSet, is mapped to <prefix>-<cluster namespace>
+func getClusterSetNamespaceMapping(
+	ctx context.Context,
+	c client.Client,
+	clusterSetMapping map[string]string,
+) (map[string]string, error) {
+	namespaceMapping := map[string]string{}
+	if len(clusterSetMapping) == 0 {
+		return namespaceMapping, nil
+	}
+
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, managedClusters, &client.ListOptions{}); err != nil {
+		return namespaceMapping, err
+	}
+	for i := range managedClusters.Items {
+		managedCluster := managedClusters.Items[i]
+		prefix, ok := clusterSetMapping[managedCluster.GetLabels()[clusterv1beta2.ClusterSetLabel]]
+		if !ok {
+			continue
+		}
+		// the managed cluster namespace has the same name as the managed cluster
+		namespaceMapping[managedCluster.Name] = getSandboxNamespaceName(prefix, managedCluster.Name)
+	}
+	return namespaceMapping, nil
+}
+
+// adds the namespaces expanded from the ClusterSetMapping option to the velero restore NamespaceMapping;
+// the NamespaceMapping entries set by the user take precedence
+func setClusterSetNamespaceMapping(
+	clusterSetNamespaceMapping map[string]string,
+	veleroRestore *veleroapi.Restore,
+) {
+	if len(clusterSetNamespaceMapping) == 0 {
+		return
+	}
+
+	namespaceMapping := make(map[string]string,
+		len(clusterSetNamespaceMapping)+len(veleroRestore.Spec.NamespaceMapping))
+	for source, target := range clusterSetNamespaceMapping {
+		namespaceMapping[source] = target
+	}
+	for source, target := range veleroRestore.Spec.NamespaceMapping {
+		namespaceMapping[source] = target
+	}
+	veleroRestore.Spec.NamespaceMapping = namespaceMapping
+}
+
+// returns an error message if the SingleClusterRestore option is not valid
+// or is used with options changing the restored namespaces or label selectors
+func isValidSingleClusterRestore(
+	acmRestore *v1beta1.Restore,
+) string {
+	clusterName := acmRestore.Spec.SingleClusterRestore
+	if clusterName == "" {
+		return ""
+	}
+
+	if errs := validation.IsDNS1123Label(clusterName); len(errs) > 0 {
+		return fmt.Sprintf("invalid SingleClusterRestore : %s", strings.Join(errs, ", "))
+	}
+	if len(acmRestore.Spec.IncludedNamespaces) > 0 || len(acmRestore.Spec.NamespaceMapping) > 0 ||
+		len(acmRestore.Spec.ClusterSetMapping) > 0 || acmRestore.Spec.SandboxNamespacePrefix != "" {
+		return "SingleClusterRestore cannot be used together with the IncludedNamespaces, " +
+			"NamespaceMapping, ClusterSetMapping or SandboxNamespacePrefix options"
+	}
+	if acmRestore.Spec.LabelSelector != nil || len(acmRestore.Spec.OrLabelSelectors) > 0 {
+		return "SingleClusterRestore cannot be used together with the LabelSelector or OrLabelSelectors options"
+	}
+	return ""
+}
+
+// returns an error message if the SinceBackupName option is used with the IncludedResources option
+func isValidSinceBackupName(
+	acmRestore *v1beta1.Restore,
+) string {
+	if acmRestore.Spec.SinceBackupName == "" {
+		return ""
+	}
+
+	if len(acmRestore.Spec.IncludedResources) > 0 {
+		return "SinceBackupName cannot be used together with the IncludedResources option"
+	}
+	return ""
+}
+
+// returns an error message if the PauseGitOpsDuringRestore option is not valid
+func isValidPauseGitOpsOption(
+	acmRestore *v1beta1.Restore,
+) string {
+	if acmRestore.Spec.PauseGitOpsDuringRestore && acmRestore.Spec.SyncRestoreWithNewBackups {
+		// a sync restore never completes, the GitOps controllers would stay paused
+		return "PauseGitOpsDuringRestore cannot be used together with the SyncRestoreWithNewBackups option"
+	}
+	return ""
+}
+
+// returns an error message if the AutoImportSecretName or ActivationLabel options are not valid
+func isValidActivationOptions(
+	acmRestore *v1beta1.Restore,
+) string {
+	if name := acmRestore.Spec.AutoImportSecretName; name != "" {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Sprintf("invalid AutoImportSecretName %s : %s", name, strings.Join(errs, ", "))
+		}
+	}
+	if label := acmRestore.Spec.ActivationLabel; label != "" {
+		if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+			return fmt.Sprintf("invalid ActivationLabel %s : %s", label, strings.Join(errs, ", "))
+		}
+	}
+	return ""
+}
+
+// returns an error message if the ManagedClusterNamespaceLabels option is not valid
+func isValidManagedClusterNamespaceLabels(
+	acmRestore *v1beta1.Restore,
+) string {
+	for key, value := range acmRestore.Spec.ManagedClusterNamespaceLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Sprintf("invalid ManagedClusterNamespaceLabels key %s : %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Sprintf("invalid ManagedClusterNamespaceLabels value for %s : %s", key, strings.Join(errs, ", "))
+		}
+	}
+	return ""
+}
+
+// returns an error message if the PointInTimeRecovery option is not valid
+func isValidPointInTimeRecovery(
+	acmRestore *v1beta1.Restore,
+) string {
+	if !acmRestore.Spec.PointInTimeRecovery {
+		return ""
+	}
+
+	if acmRestore.Spec.CleanupBeforeRestore != v1beta1.CleanupTypeNone &&
+		acmRestore.Spec.CleanupBeforeRestore != v1beta1.CleanupTypeAll {
+		return "PointInTimeRecovery can be used only with the CleanupBeforeRestore option set to None or CleanupAll"
+	}
+	if acmRestore.Spec.SyncRestoreWithNewBackups {
+		return "PointInTimeRecovery cannot be used together with the SyncRestoreWithNewBackups option"
+	}
+	if acmRestore.Spec.SandboxNamespacePrefix != "" {
+		return "PointInTimeRecovery cannot be used together with the SandboxNamespacePrefix option"
+	}
+	if acmRestore.Spec.VeleroResourcesBackupName == nil ||
+		*acmRestore.Spec.VeleroResourcesBackupName == skipRestoreStr {
+		return "PointInTimeRecovery requires the VeleroResourcesBackupName option, set to a backup name or latest"
+	}
+	return ""
+}
+
+// returns an error message if one of the backups restored with the PointInTimeRecovery option
+// is not completed, the hub is not cleaned up using an incomplete backup
+func getIncompleteRecoveryBackups(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	resKeys []ResourceType,
+	veleroRestoresToCreate map[ResourceType]*veleroapi.Restore,
+) (string, error) {
+	incompleteBackups := []string{}
+	for _, key := range resKeys {
+		veleroRestore := veleroRestoresToCreate[key]
+		if veleroRestore == nil || veleroRestore.Spec.BackupName == "" {
+			continue
+		}
+		veleroBackup := veleroapi.Backup{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Name:      veleroRestore.Spec.BackupName,
+			Namespace: namespace,
+		}, &veleroBackup); err != nil {
+			return "", fmt.Errorf("could not get backup %s: %w", veleroRestore.Spec.BackupName, err)
+		}
+		if veleroBackup.Status.Phase != veleroapi.BackupPhaseCompleted {
+			incompleteBackups = appendUnique(incompleteBackups,
+				fmt.Sprintf("%s (%s)", veleroBackup.Name, veleroBackup.Status.Phase))
+		}
+	}
+	if len(incompleteBackups) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("PointInTimeRecovery requires completed backups, backups not completed: %s",
+		strings.Join(incompleteBackups, ", ")), nil
+}
+
+// returns an error message if the ClusterSetMapping option is not valid
+func isValidClusterSetMapping(
+	acmRestore *v1beta1.Restore,
+) string {
+	if len(acmRestore.Spec.ClusterSetMapping) == 0 {
+		return ""
+	}
+
+	if acmRestore.Spec.SandboxNamespacePrefix != "" {
+		return "ClusterSetMapping cannot be used together with the SandboxNamespacePrefix option"
+	}
+	for clusterSet, prefix := range acmRestore.Spec.ClusterSetMapping {
+		if clusterSet == "" {
+			return "invalid ClusterSetMapping : the ManagedClusterSet name cannot be empty"
+		}
+		if len(prefix) > maxSandboxNamespacePrefixLength {
+			return fmt.Sprintf("invalid ClusterSetMapping prefix for %s : must be no more than %d characters",
+				clusterSet, maxSandboxNamespacePrefixLength)
+		}
+		if errs := validation.IsDNS1123Label(prefix); len(errs) > 0 {
+			return fmt.Sprintf("invalid ClusterSetMapping prefix for %s : %s", clusterSet, strings.Join(errs, ", "))
+		}
+	}
+	return ""
+}
+
+// returns an error message if the ResourcesRestoreOrLabelSelectors option is not valid
+func isValidResourcesOrLabelSelectors(
+	acmRestore *v1beta1.Restore,
+) string {
+	if len(acmRestore.Spec.ResourcesRestoreOrLabelSelectors) == 0 {
+		return ""
+	}
+
+	if acmRestore.Spec.LabelSelector != nil || len(acmRestore.Spec.OrLabelSelectors) > 0 {
+		return "ResourcesRestoreOrLabelSelectors cannot be used together with " +
+			"the LabelSelector or OrLabelSelectors options"
+	}
+
+	for i, selector := range acmRestore.Spec.ResourcesRestoreOrLabelSelectors {
+		if selector == nil {
+			return fmt.Sprintf("invalid ResourcesRestoreOrLabelSelectors[%d] : selector is empty", i)
+		}
+		if _, err := v1.LabelSelectorAsSelector(selector); err != nil {
+			return fmt.Sprintf("invalid ResourcesRestoreOrLabelSelectors[%d] : %s", i, err.Error())
+		}
+	}
+
+	return ""
 }
 
 // set user options for resource filtering
@@ -840,3 +2327,155 @@ func setUserRestoreFilters(
 		}
 	}
 }
+
+// a GitOps controller workload, a Deployment or a StatefulSet
+type gitOpsController struct {
+	kind     string
+	object   client.Object
+	replicas **int32
+}
+
+// returns the Deployments and StatefulSets running the known GitOps controllers on this hub
+func getGitOpsControllers(
+	ctx context.Context,
+	c client.Client,
+) ([]gitOpsController, error) {
+	requirement, err := labels.NewRequirement(gitOpsControllerNameLabel, selection.In, gitOpsControllerNames)
+	if err != nil {
+		return nil, err
+	}
+	listOptions := &client.ListOptions{LabelSelector: labels.NewSelector().Add(*requirement)}
+
+	controllers := []gitOpsController{}
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, listOptions); err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		controllers = append(controllers, gitOpsController{
+			kind:     "Deployment",
+			object:   &deployments.Items[i],
+			replicas: &deployments.Items[i].Spec.Replicas,
+		})
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, listOptions); err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		controllers = append(controllers, gitOpsController{
+			kind:     "StatefulSet",
+			object:   &statefulSets.Items[i],
+			replicas: &statefulSets.Items[i].Spec.Replicas,
+		})
+	}
+	return controllers, nil
+}
+
+// scale down the running GitOps controllers, so they don't recreate the resources deleted by the restore cleanup;
+// the number of replicas is saved under the GitOpsPausedReplicasAnnotation, controllers already paused are skipped
+func pauseGitOpsControllers(
+	ctx context.Context,
+	c client.Client,
+) error {
+	logger := log.FromContext(ctx)
+
+	controllers, err := getGitOpsControllers(ctx, c)
+	if err != nil {
+		return err
+	}
+	for _, controller := range controllers {
+		obj := controller.object
+		if _, paused := obj.GetAnnotations()[GitOpsPausedReplicasAnnotation]; paused {
+			continue
+		}
+		// replicas default to 1 if not set
+		replicas := int32(1)
+		if *controller.replicas != nil {
+			replicas = **controller.replicas
+		}
+		if replicas == 0 {
+			// already scaled down, nothing to resume
+			continue
+		}
+
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[GitOpsPausedReplicasAnnotation] = strconv.Itoa(int(replicas))
+		obj.SetAnnotations(annotations)
+		noReplicas := int32(0)
+		*controller.replicas = &noReplicas
+
+		logger.Info("Pausing GitOps controller during restore", "kind", controller.kind,
+			"namespace", obj.GetNamespace(), "name", obj.GetName(), "replicas", replicas)
+		if err := c.Update(ctx, obj); err != nil {
+			return fmt.Errorf("failed to pause GitOps controller %s %s/%s: %w",
+				controller.kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// resume the GitOps controllers paused by the PauseGitOpsDuringRestore option once the restore
+// reaches a final phase, on any path finishing the restore; returns true if the controllers were resumed
+func resumeGitOpsOnCompletion(
+	ctx context.Context,
+	c client.Client,
+	restore *v1beta1.Restore,
+) bool {
+	if !restore.Spec.PauseGitOpsDuringRestore ||
+		(restore.Status.Phase != v1beta1.RestorePhaseFinished &&
+			restore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors &&
+			restore.Status.Phase != v1beta1.RestorePhaseError) {
+		return false
+	}
+	if err := resumeGitOpsControllers(ctx, c); err != nil {
+		log.FromContext(ctx).Error(err, "Error resuming the GitOps controllers")
+		return false
+	}
+	return true
+}
+
+// scale the GitOps controllers paused by pauseGitOpsControllers back to their saved number of replicas
+func resumeGitOpsControllers(
+	ctx context.Context,
+	c client.Client,
+) error {
+	logger := log.FromContext(ctx)
+
+	controllers, err := getGitOpsControllers(ctx, c)
+	if err != nil {
+		return err
+	}
+	for _, controller := range controllers {
+		obj := controller.object
+		value, paused := obj.GetAnnotations()[GitOpsPausedReplicasAnnotation]
+		if !paused {
+			continue
+		}
+		replicas, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			// don't guess the number of replicas, leave the controller for the user to scale back
+			logger.Info("Warning - GitOps controller not resumed, invalid replicas annotation",
+				"kind", controller.kind, "namespace", obj.GetNamespace(), "name", obj.GetName(),
+				"annotation", GitOpsPausedReplicasAnnotation, "value", value)
+			continue
+		}
+
+		annotations := obj.GetAnnotations()
+		delete(annotations, GitOpsPausedReplicasAnnotation)
+		obj.SetAnnotations(annotations)
+		resumedReplicas := int32(replicas)
+		*controller.replicas = &resumedReplicas
+
+		logger.Info("Resuming GitOps controller after restore", "kind", controller.kind,
+			"namespace", obj.GetNamespace(), "name", obj.GetName(), "replicas", resumedReplicas)
+		if err := c.Update(ctx, obj); err != nil {
+			return fmt.Errorf("failed to resume GitOps controller %s %s/%s: %w",
+				controller.kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
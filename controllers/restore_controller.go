@@ -24,8 +24,10 @@ import (
 
 	"github.com/pkg/errors"
 
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -52,14 +54,36 @@ var (
 )
 
 const (
-	restoreOwnerKey            = ".metadata.controller"
-	skipRestoreStr      string = "skip"
-	latestBackupStr     string = "latest"
-	restoreSyncInterval        = time.Minute * 30
-	noopMsg                    = "Nothing to do for restore %s"
+	restoreOwnerKey               = ".metadata.controller"
+	skipRestoreStr         string = "skip"
+	latestBackupStr        string = "latest"
+	restoreSyncInterval           = time.Minute * 30
+	minRestoreSyncInterval        = time.Minute * 1
+	noopMsg                       = "Nothing to do for restore %s"
+	failFastWaitMsg               = "Waiting for velero restore %s to complete before restoring the other resources"
+	clusterScopedWaitMsg          = "Waiting for velero restore %s to complete before restoring the namespaced resources"
+	sinceBackupWaitMsg            = "Waiting for velero to read the content of backups %s and %s"
 
 	backupPVCLabel  = "cluster.open-cluster-management.io/backup-pvc"
 	pvcWaitInterval = time.Second * 10
+	// interval used to check again if the managed clusters activated by a restore joined the hub
+	managedClusterImportInterval = time.Minute * 1
+	// time given to the managed clusters activated by a restore to join the hub
+	clusterJoinGracePeriod = time.Minute * 30
+	// interval used to check again the restores listed under DependsOn
+	restoreDependencyWaitInterval = time.Second * 30
+	// interval used to check again if the BackupSchedule paused by the PauseActiveBackupSchedule option is paused
+	backupSchedulePauseWaitInterval = time.Second * 10
+	// min interval between two RestoreProgress events reporting progress within the same phase
+	progressEventInterval = time.Minute * 1
+	// interval used to check again if velero processed the restore results download requests
+	restoreResultsWaitInterval = time.Second * 10
+	// max length of the sandbox namespaces prefix, so the namespace names can include part of the original name
+	maxSandboxNamespacePrefixLength = 40
+
+	// ForceReconcileAnnotation is used to force a restore to be processed again,
+	// even if the restore spec is not changed; set it to a new value to trigger a new reconcile
+	ForceReconcileAnnotation = "cluster.open-cluster-management.io/force-reconcile"
 )
 
 type DynamicStruct struct {
@@ -68,9 +92,26 @@ type DynamicStruct struct {
 }
 
 type RestoreOptions struct {
-	dynamicArgs DynamicStruct
-	cleanupType v1beta1.CleanupType
-	mapper      *restmapper.DeferredDiscoveryRESTMapper
+	dynamicArgs        DynamicStruct
+	cleanupType        v1beta1.CleanupType
+	cleanupConcurrency int
+	// resources created after this time are not cleaned up, if set
+	cleanupCreatedBefore *metav1.Time
+	// only resources from the namespaces stored by the backup are cleaned up, if set
+	cleanupScopeToBackup bool
+	// order in which the resource kinds are cleaned up, set by the CleanupResourceOrder option
+	cleanupResourceOrder []string
+	// resource kinds deleted even if they have the ExcludeBackupLabel, set by the CleanupForceDeleteKinds option
+	cleanupForceDeleteKinds []string
+	// namespaces stored by the backup being cleaned up, used with cleanupScopeToBackup
+	backupNamespaces []string
+	mapper           *restmapper.DeferredDiscoveryRESTMapper
+	// namespaces of the managed clusters, never deleted by the cleanup
+	managedClusterNamespaces []string
+	// namespace of the velero restores, resources from this namespace are never deleted by the cleanup
+	veleroNamespace string
+	// resources from the infra namespaces are never deleted by the cleanup, set by the PointInTimeRecovery option
+	protectInfraNamespaces bool
 }
 
 // RestoreReconciler reconciles a Restore object
@@ -81,40 +122,161 @@ type RestoreReconciler struct {
 	DynamicClient   dynamic.Interface
 	Scheme          *runtime.Scheme
 	Recorder        record.EventRecorder
+	// delete the velero restores whose owning Restore was deleted;
+	// if not set, these velero restores are only reported
+	CleanupOrphanedRestores bool
 }
 
 //nolint:lll
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=restores,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=restores/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=restores/finalizers,verbs=update
+//+kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=backupschedules,verbs=get;list;update
 //+kubebuilder:rbac:groups=velero.io,resources=backups,verbs=get;list
-//+kubebuilder:rbac:groups=velero.io,resources=restores,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=velero.io,resources=restores,verbs=get;list;watch;create;update;delete
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups=velero.io,resources=backupstoragelocations,verbs=get;list;watch
 //+kubebuilder:rbac:groups=velero.io,resources=deletebackuprequests,verbs=create;list;watch
+//+kubebuilder:rbac:groups=velero.io,resources=downloadrequests,verbs=get;create;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;update
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 //
 //nolint:funlen
-func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	defer func(start time.Time) {
+		recordReconcileMetrics(restoreControllerName, start, err)
+	}(time.Now())
+
 	restoreLogger := log.FromContext(ctx)
 	restore := &v1beta1.Restore{}
+	var previousPhase v1beta1.RestorePhase
+	defer func() {
+		recordRestorePhase(req.NamespacedName, restore, previousPhase)
+	}()
 
 	// velero doesn't delete expired backups if they are in FailedValidation
 	// workaround and delete expired or invalid validation backups them now
 	cleanupExpiredValidationBackups(ctx, req.Namespace, r.Client)
+	// velero restores are left behind when the owning Restore is deleted with the orphan propagation policy
+	r.processOrphanedVeleroRestores(ctx, req.Namespace)
 
 	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	previousPhase = restore.Status.Phase
+	// the GitOps controllers paused by this restore are resumed once the restore is completed,
+	// whichever path completes it
+	defer resumeGitOpsOnCompletion(ctx, r.Client, restore)
+
+	// don't execute a Restore resource restored from a backup, created with the BackupSchedule
+	// BackupOperatorConfig option, so the restore of another hub configuration doesn't start new restores
+	if isRestoredFromBackup(restore) {
+		// a new force reconcile annotation value is required to run this restore
+		restore.Status.LastForceReconcile = restore.GetAnnotations()[ForceReconcileAnnotation]
+		updateRestoreStatus(
+			restoreLogger,
+			v1beta1.RestorePhaseFinished,
+			fmt.Sprintf("Restore %s was restored from backup %s and is not executed. "+
+				"Set the %s annotation to a new value to run this restore.",
+				restore.Name, restore.GetLabels()[BackupNameVeleroLabel], ForceReconcileAnnotation),
+			restore,
+		)
+		return ctrl.Result{}, errors.Wrap(
+			r.Client.Status().Update(ctx, restore),
+			"could not update status",
+		)
+	}
+
+	forceReconcile := isForceReconcileRequested(restore)
+	if forceReconcile && isCompletedRestoreRun(restore) {
+		// only re-evaluate the status of a completed restore; the cleanup, the managed clusters
+		// activation and the BackupSchedule checks must not run again on a hub that was restored
+		restoreLogger.Info("Force reconcile requested, re-evaluating the completed restore status",
+			"annotation", ForceReconcileAnnotation,
+			"value", restore.GetAnnotations()[ForceReconcileAnnotation])
+		restore.Status.LastForceReconcile = restore.GetAnnotations()[ForceReconcileAnnotation]
+		return r.reevaluateRestoreStatus(ctx, restore)
+	}
+	if forceReconcile {
+		// process the restore now, if it's waiting for the next sync interval
+		// or was restored from a backup and never run on this hub
+		restoreLogger.Info("Force reconcile requested",
+			"annotation", ForceReconcileAnnotation,
+			"value", restore.GetAnnotations()[ForceReconcileAnnotation])
+		restore.Status.LastForceReconcile = restore.GetAnnotations()[ForceReconcileAnnotation]
+		// clear the status reported by a previous run
+		restore.Status.ActivationPhase = ""
+		restore.Status.ActivatedClusters = nil
+		restore.Status.ClusterJoinStatus = nil
+		meta.RemoveStatusCondition(&restore.Status.Conditions, v1beta1.RestoreClustersJoined)
+		meta.RemoveStatusCondition(&restore.Status.Conditions, v1beta1.RestoreComplete)
+		meta.RemoveStatusCondition(&restore.Status.Conditions, v1beta1.RestoreDegraded)
+		restore.Status.FailedItemsByKind = nil
+	}
 
-	if restore.Status.Phase == v1beta1.RestorePhaseFinished ||
-		restore.Status.Phase == v1beta1.RestorePhaseFinishedWithErrors {
+	// a restore with the managed clusters activation in progress was interrupted, for example
+	// by an operator restart; process it again to resume the activation
+	if !forceReconcile && (restore.Status.Phase == v1beta1.RestorePhaseFinished ||
+		restore.Status.Phase == v1beta1.RestorePhaseFinishedWithErrors) &&
+		restore.Status.ActivationPhase != v1beta1.ActivationPhaseInProgress {
 		// don't process a restore resource if it's completed
+		// only report the result of the post restore job, if any
+		jobStatusUpdated := updatePostRestoreJobStatus(ctx, r.Client, restore)
+		// the items which failed to restore
+		failedItemsUpdated, failedItemsPending := updateFailedItemsByKind(ctx, r.Client, restore)
+		// and the managed clusters join verification
+		if verifyClusterJoin(ctx, r.Client, restore, time.Now()) || jobStatusUpdated || failedItemsUpdated {
+			return sendResult(restore, r.Client.Status().Update(ctx, restore))
+		}
+		if failedItemsPending {
+			return ctrl.Result{RequeueAfter: restoreResultsWaitInterval}, nil
+		}
 		return ctrl.Result{}, nil
 	}
 
+	// don't create restores until the restores listed under DependsOn are finished
+	if isRestoreWaitingForDependencies(restore) {
+		restoreList := v1beta1.RestoreList{}
+		if err := r.List(ctx, &restoreList, client.InNamespace(restore.Namespace)); err != nil {
+			return ctrl.Result{}, err
+		}
+		waitingFor, msg := checkRestoreDependencies(restore, restoreList.Items)
+		if msg != "" {
+			updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseFinishedWithErrors, msg, restore)
+			return ctrl.Result{}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				msg,
+			)
+		}
+		if len(waitingFor) > 0 {
+			msg = "Waiting for restores to finish: " + strings.Join(waitingFor, ", ")
+			updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseWaiting, msg, restore)
+			return ctrl.Result{RequeueAfter: restoreDependencyWaitInterval}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				msg,
+			)
+		}
+	}
+
+	// report an active BackupSchedule and, with the PauseActiveBackupSchedule option,
+	// wait for the BackupSchedule to be paused
+	pauseWaitMsg, err := checkActiveBackupSchedule(ctx, r.Client, restore)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pauseWaitMsg != "" {
+		updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseWaiting, pauseWaitMsg, restore)
+		return ctrl.Result{RequeueAfter: backupSchedulePauseWaitInterval}, errors.Wrap(
+			r.Client.Status().Update(ctx, restore),
+			pauseWaitMsg,
+		)
+	}
+
 	// don't create restores if there is any other active resource in this namespace
 	activeResourceMsg, err := isOtherResourcesRunning(ctx, r.Client, restore)
 	if err != nil {
@@ -148,6 +310,54 @@ func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		)
 	}
 
+	// don't create restores if the resources OR label selectors, the backup label selector,
+	// the namespace filters, the sandbox options, the cluster set mapping, the single cluster
+	// restore, the incremental restore, the point-in-time recovery, the managed cluster
+	// namespace labels, the activation or the GitOps pause options are not valid
+	activeResourceMsg = isValidResourcesOrLabelSelectors(restore)
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidBackupLabelSelector(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidNamespaceFilters(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidSandboxOptions(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidClusterSetMapping(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidSingleClusterRestore(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidSinceBackupName(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidPointInTimeRecovery(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidManagedClusterNamespaceLabels(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidActivationOptions(restore)
+	}
+	if activeResourceMsg == "" {
+		activeResourceMsg = isValidPauseGitOpsOption(restore)
+	}
+	if activeResourceMsg != "" {
+		updateRestoreStatus(
+			restoreLogger,
+			v1beta1.RestorePhaseFinishedWithErrors,
+			activeResourceMsg,
+			restore,
+		)
+		return ctrl.Result{}, errors.Wrap(
+			r.Client.Status().Update(ctx, restore),
+			activeResourceMsg,
+		)
+	}
+
 	if msg, retry := validateStorageSettings(ctx, r.Client, req.Name, req.Namespace, restore); msg != "" {
 
 		updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseError, msg, restore)
@@ -165,11 +375,10 @@ func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		)
 	}
 
-	if restore.Spec.CleanupBeforeRestore != v1beta1.CleanupTypeNone &&
-		restore.Status.Phase == "" {
+	if getCleanupType(restore) != v1beta1.CleanupTypeNone &&
+		(restore.Status.Phase == "" || restore.Status.Phase == v1beta1.RestorePhaseWaiting) {
 		// update state only at the very beginning
-		restore.Status.Phase = v1beta1.RestorePhaseStarted
-		restore.Status.LastMessage = "Prepare to restore, cleaning up resources"
+		transitionRestorePhase(restore, v1beta1.RestorePhaseStarted, "Prepare to restore, cleaning up resources")
 		err = r.Client.Status().Update(ctx, restore)
 		if err != nil {
 			restoreLogger.Error(err, "Error updating restore status")
@@ -194,12 +403,101 @@ func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	// the owned velero restores are the source of truth for the velero restore names,
+	// the status update may have failed after the velero restores were created
+	setVeleroRestoreNamesFromOwned(restore, &veleroRestoreList)
+	setVeleroRestoreTimestamps(restore, &veleroRestoreList)
+
+	// don't create velero restores or run the post restore tasks while the restore is on hold,
+	// the velero restores already created continue to run
+	if setRestoreHold(restoreLogger, restore, &veleroRestoreList) {
+		return ctrl.Result{}, errors.Wrap(
+			r.Client.Status().Update(ctx, restore),
+			restore.Status.LastMessage,
+		)
+	}
+
 	isValidSync, msg := isValidSyncOptions(restore)
 	sync := isValidSync && restore.Status.Phase == v1beta1.RestorePhaseEnabled
 	isPVCStep := isPVCInitializationStep(restore, veleroRestoreList)
 	initRestoreCond := len(veleroRestoreList.Items) == 0 || sync
 
-	if initRestoreCond || isPVCStep {
+	// with the FailFast option, create the other velero restores only after the credentials restore completes
+	credsRestorePhase, isFailFastStep := getFailFastCredentialsRestorePhase(restore, veleroRestoreList)
+	if isFailFastStep {
+		switch credsRestorePhase {
+		case veleroapi.RestorePhaseCompleted:
+			// continue with the other velero restores
+		case veleroapi.RestorePhaseFailed,
+			veleroapi.RestorePhasePartiallyFailed,
+			veleroapi.RestorePhaseFailedValidation:
+			failMsg := fmt.Sprintf("Restore %s stopped, velero restore %s is %s",
+				restore.Name, restore.Status.VeleroCredentialsRestoreName, credsRestorePhase)
+			meta.SetStatusCondition(&restore.Status.Conditions, metav1.Condition{
+				Type:               v1beta1.RestoreFailedFast,
+				Status:             metav1.ConditionTrue,
+				Reason:             v1beta1.RestoreReasonPrerequisiteFailed,
+				Message:            failMsg,
+				ObservedGeneration: restore.Generation,
+			})
+			updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseFinishedWithErrors, failMsg, restore)
+			return ctrl.Result{}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				failMsg,
+			)
+		default:
+			transitionRestorePhase(restore, v1beta1.RestorePhaseStarted,
+				fmt.Sprintf(failFastWaitMsg, restore.Status.VeleroCredentialsRestoreName))
+			return ctrl.Result{RequeueAfter: pvcWaitInterval}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				restore.Status.LastMessage,
+			)
+		}
+	}
+
+	// with the RestoreClusterScopedFirst option, restore the namespaced resources
+	// only after the cluster scoped resources restore completes
+	clusterScopedRestorePhase, isClusterScopedStep := getClusterScopedRestorePhase(restore, veleroRestoreList)
+	if isClusterScopedStep {
+		switch clusterScopedRestorePhase {
+		case veleroapi.RestorePhaseCompleted,
+			veleroapi.RestorePhasePartiallyFailed:
+			// continue with the namespaced resources restore
+		case veleroapi.RestorePhaseFailed,
+			veleroapi.RestorePhaseFailedValidation:
+			failMsg := fmt.Sprintf("Restore %s stopped, velero restore %s is %s",
+				restore.Name, restore.Status.VeleroClusterScopedResourcesRestoreName, clusterScopedRestorePhase)
+			updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseFinishedWithErrors, failMsg, restore)
+			return ctrl.Result{}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				failMsg,
+			)
+		default:
+			transitionRestorePhase(restore, v1beta1.RestorePhaseStarted, fmt.Sprintf(clusterScopedWaitMsg,
+				restore.Status.VeleroClusterScopedResourcesRestoreName))
+			return ctrl.Result{RequeueAfter: pvcWaitInterval}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				restore.Status.LastMessage,
+			)
+		}
+	}
+
+	if initRestoreCond && restore.Spec.PauseGitOpsDuringRestore {
+		// scale down the GitOps controllers before the velero restores are created,
+		// they are resumed once the restore and the cleanup are completed
+		if err := pauseGitOpsControllers(ctx, r.Client); err != nil {
+			msg := fmt.Sprintf("unable to pause the GitOps controllers for restore %s/%s: %v",
+				req.Namespace, req.Name, err)
+			restoreLogger.Error(err, msg)
+			updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseError, msg, restore)
+			return ctrl.Result{RequeueAfter: failureInterval}, errors.Wrap(
+				r.Client.Status().Update(ctx, restore),
+				msg,
+			)
+		}
+	}
+
+	if initRestoreCond || isPVCStep || isFailFastStep || isClusterScopedStep {
 		mustwait, waitmsg, err := r.initVeleroRestores(ctx, restore, sync)
 		if err != nil {
 			msg := fmt.Sprintf(
@@ -219,8 +517,7 @@ func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 
 		if mustwait {
-			restore.Status.Phase = v1beta1.RestorePhaseStarted
-			restore.Status.LastMessage = waitmsg
+			transitionRestorePhase(restore, v1beta1.RestorePhaseStarted, waitmsg)
 			return ctrl.Result{RequeueAfter: pvcWaitInterval}, errors.Wrap(
 				r.Client.Status().Update(ctx, restore),
 				waitmsg,
@@ -234,12 +531,88 @@ func (r *RestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if restore.Spec.SyncRestoreWithNewBackups && !isValidSync {
 		restore.Status.LastMessage = restore.Status.LastMessage +
 			" ; SyncRestoreWithNewBackups option is ignored because " + msg
+	} else if isValidSync && msg != "" && !strings.Contains(restore.Status.LastMessage, msg) {
+		restore.Status.LastMessage = restore.Status.LastMessage + " ; " + msg
 	}
 
+	emitRestoreProgressEvent(r.Recorder, restore, &veleroRestoreList, time.Now())
+
 	err = r.Client.Status().Update(ctx, restore)
 	return sendResult(restore, err)
 }
 
+// returns true if the restore is completed and created velero restores on this hub, or is
+// not a Restore resource restored from a backup; a force reconcile only re-evaluates its status
+func isCompletedRestoreRun(
+	restore *v1beta1.Restore,
+) bool {
+	if restore.Status.Phase != v1beta1.RestorePhaseFinished &&
+		restore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors {
+		return false
+	}
+	_, restored := restore.GetLabels()[RestoreNameVeleroLabel]
+	return !restored ||
+		restore.Status.VeleroManagedClustersRestoreName != "" ||
+		restore.Status.VeleroCredentialsRestoreName != "" ||
+		restore.Status.VeleroResourcesRestoreName != "" ||
+		restore.Status.VeleroClusterScopedResourcesRestoreName != "" ||
+		restore.Status.VeleroGenericResourcesRestoreName != "" ||
+		restore.Status.VeleroConfigMapsRestoreName != ""
+}
+
+// re-evaluates the status of a completed restore from its velero restores, the items which
+// failed to restore, the post restore job and the managed clusters join;
+// no resource is created or deleted
+func (r *RestoreReconciler) reevaluateRestoreStatus(
+	ctx context.Context,
+	restore *v1beta1.Restore,
+) (ctrl.Result, error) {
+	veleroRestoreList := veleroapi.RestoreList{}
+	if err := r.List(
+		ctx,
+		&veleroRestoreList,
+		client.InNamespace(restore.Namespace),
+		client.MatchingFields{restoreOwnerKey: restore.Name},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(veleroRestoreList.Items) > 0 {
+		// the velero restores may be deleted after the restore completed, keep the phase then
+		setRestorePhase(&veleroRestoreList, restore)
+	}
+
+	restore.Status.FailedItemsByKind = nil
+	_, failedItemsPending := updateFailedItemsByKind(ctx, r.Client, restore)
+	updatePostRestoreJobStatus(ctx, r.Client, restore)
+	verifyClusterJoin(ctx, r.Client, restore, time.Now())
+
+	if err := r.Client.Status().Update(ctx, restore); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "could not update status")
+	}
+	if failedItemsPending {
+		return ctrl.Result{RequeueAfter: restoreResultsWaitInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// returns true if this Restore resource was restored by velero from a backup
+// and was not processed yet on this hub
+func isRestoredFromBackup(
+	restore *v1beta1.Restore,
+) bool {
+	_, restored := restore.GetLabels()[RestoreNameVeleroLabel]
+	return restored && restore.Status.Phase == ""
+}
+
+// returns true if the force reconcile annotation was set to a value
+// not processed yet by this restore
+func isForceReconcileRequested(
+	restore *v1beta1.Restore,
+) bool {
+	value := restore.GetAnnotations()[ForceReconcileAnnotation]
+	return value != "" && value != restore.Status.LastForceReconcile
+}
+
 // If any pvcs were created on the backup hub using the backup-pvc label,
 // wait for the pvc to be created by the pvc configmap
 // the config map is restored with the credentials backup, which is the first backup to be restored
@@ -276,6 +649,138 @@ func isPVCInitializationStep(
 	return true
 }
 
+// set the velero restore names under the restore status using the velero restores owned by this restore;
+// for each backup type, the name of the most recently created velero restore is used
+func setVeleroRestoreNamesFromOwned(
+	restore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+) {
+	statusNames := map[ResourceType]*string{
+		ManagedClusters:  &restore.Status.VeleroManagedClustersRestoreName,
+		Credentials:      &restore.Status.VeleroCredentialsRestoreName,
+		Resources:        &restore.Status.VeleroResourcesRestoreName,
+		ResourcesGeneric: &restore.Status.VeleroGenericResourcesRestoreName,
+		ConfigMaps:       &restore.Status.VeleroConfigMapsRestoreName,
+	}
+	latestRestores := map[ResourceType]*veleroapi.Restore{}
+	for i := range veleroRestoreList.Items {
+		veleroRestore := &veleroRestoreList.Items[i]
+		if veleroRestore.GetLabels()[ClusterScopedRestoreLabel] == "true" {
+			// the cluster scoped resources restore created by the RestoreClusterScopedFirst option
+			restore.Status.VeleroClusterScopedResourcesRestoreName = veleroRestore.Name
+			continue
+		}
+		for key := range statusNames {
+			if !strings.HasPrefix(veleroRestore.Spec.BackupName, veleroBackupNames[key]+"-") {
+				continue
+			}
+			latest := latestRestores[key]
+			if latest == nil ||
+				latest.CreationTimestamp.Before(&veleroRestore.CreationTimestamp) ||
+				(latest.CreationTimestamp.Equal(&veleroRestore.CreationTimestamp) &&
+					latest.Name < veleroRestore.Name) {
+				latestRestores[key] = veleroRestore
+			}
+		}
+	}
+	for key, veleroRestore := range latestRestores {
+		*statusNames[key] = veleroRestore.Name
+	}
+}
+
+// set the restore start and velero completion timestamps using the velero restores named under the
+// restore status: the earliest start time and, once they are all completed, the latest completion time
+func setVeleroRestoreTimestamps(
+	restore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+) {
+	names := map[string]bool{}
+	for _, name := range []string{
+		restore.Status.VeleroManagedClustersRestoreName,
+		restore.Status.VeleroCredentialsRestoreName,
+		restore.Status.VeleroResourcesRestoreName,
+		restore.Status.VeleroGenericResourcesRestoreName,
+		restore.Status.VeleroConfigMapsRestoreName,
+		restore.Status.VeleroClusterScopedResourcesRestoreName,
+	} {
+		if name != "" {
+			names[name] = true
+		}
+	}
+
+	var startTimestamp, completionTimestamp *metav1.Time
+	completed := len(names) > 0
+	for i := range veleroRestoreList.Items {
+		veleroRestore := &veleroRestoreList.Items[i]
+		if !names[veleroRestore.Name] {
+			continue
+		}
+		if start := veleroRestore.Status.StartTimestamp; start != nil &&
+			(startTimestamp == nil || start.Before(startTimestamp)) {
+			startTimestamp = start.DeepCopy()
+		}
+		completion := veleroRestore.Status.CompletionTimestamp
+		if completion == nil {
+			completed = false
+			continue
+		}
+		if completionTimestamp == nil || completionTimestamp.Before(completion) {
+			completionTimestamp = completion.DeepCopy()
+		}
+	}
+	if !completed {
+		completionTimestamp = nil
+	}
+
+	restore.Status.StartTimestamp = startTimestamp
+	restore.Status.VeleroCompletionTimestamp = completionTimestamp
+}
+
+// returns the phase of the credentials velero restore and true if the FailFast option is set
+// and the other velero restores for this restore were not created yet
+func getFailFastCredentialsRestorePhase(
+	acmRestore *v1beta1.Restore,
+	veleroRestoreList veleroapi.RestoreList,
+) (veleroapi.RestorePhase, bool) {
+	if !acmRestore.Spec.FailFast ||
+		acmRestore.Status.Phase == v1beta1.RestorePhaseEnabled ||
+		acmRestore.Status.VeleroCredentialsRestoreName == "" ||
+		acmRestore.Status.VeleroManagedClustersRestoreName != "" ||
+		acmRestore.Status.VeleroResourcesRestoreName != "" ||
+		acmRestore.Status.VeleroGenericResourcesRestoreName != "" ||
+		acmRestore.Status.VeleroConfigMapsRestoreName != "" {
+		return "", false
+	}
+
+	for i := range veleroRestoreList.Items {
+		if veleroRestoreList.Items[i].Name == acmRestore.Status.VeleroCredentialsRestoreName {
+			return veleroRestoreList.Items[i].Status.Phase, true
+		}
+	}
+	return "", false
+}
+
+// returns the phase of the cluster scoped resources velero restore and true if the RestoreClusterScopedFirst
+// option is set and the namespaced resources velero restore for this restore was not created yet
+func getClusterScopedRestorePhase(
+	acmRestore *v1beta1.Restore,
+	veleroRestoreList veleroapi.RestoreList,
+) (veleroapi.RestorePhase, bool) {
+	if !acmRestore.Spec.RestoreClusterScopedFirst ||
+		acmRestore.Status.Phase == v1beta1.RestorePhaseEnabled ||
+		acmRestore.Status.VeleroClusterScopedResourcesRestoreName == "" ||
+		acmRestore.Status.VeleroResourcesRestoreName != "" {
+		return "", false
+	}
+
+	for i := range veleroRestoreList.Items {
+		if veleroRestoreList.Items[i].Name == acmRestore.Status.VeleroClusterScopedResourcesRestoreName {
+			return veleroRestoreList.Items[i].Status.Phase, true
+		}
+	}
+	return "", false
+}
+
 // call clean up resources after the velero restore is completed
 // execute any other post restore tasks
 func (r *RestoreReconciler) cleanupOnRestore(
@@ -305,13 +810,37 @@ func (r *RestoreReconciler) cleanupOnRestore(
 		dyn: r.DynamicClient,
 	}
 	restoreOptions := RestoreOptions{
-		dynamicArgs: reconcileArgs,
-		cleanupType: acmRestore.Spec.CleanupBeforeRestore,
-		mapper:      restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(r.DiscoveryClient)),
+		dynamicArgs:             reconcileArgs,
+		cleanupType:             getCleanupType(acmRestore),
+		cleanupConcurrency:      acmRestore.Spec.CleanupConcurrency,
+		cleanupCreatedBefore:    acmRestore.Spec.CleanupCreatedBefore,
+		cleanupScopeToBackup:    acmRestore.Spec.CleanupScopeToBackup || acmRestore.Spec.PointInTimeRecovery,
+		cleanupResourceOrder:    acmRestore.Spec.CleanupResourceOrder,
+		cleanupForceDeleteKinds: acmRestore.Spec.CleanupForceDeleteKinds,
+		mapper:                  restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(r.DiscoveryClient)),
+		veleroNamespace:         acmRestore.Namespace,
+		protectInfraNamespaces:  acmRestore.Spec.PointInTimeRecovery,
 	}
 
 	cleanupDeltaResources(ctx, r.Client, acmRestore, cleanupOnRestore, restoreOptions)
+	activationCompleted := acmRestore.Status.ActivationPhase == v1beta1.ActivationPhaseCompleted
 	executePostRestoreTasks(ctx, r.Client, acmRestore)
+	verifyClusterJoin(ctx, r.Client, acmRestore, time.Now())
+	verifyMinClustersAvailable(ctx, r.Client, acmRestore, time.Now())
+	if !activationCompleted && len(acmRestore.Status.InvalidImportTokens) > 0 {
+		r.Recorder.Event(
+			acmRestore,
+			v1.EventTypeWarning,
+			"Invalid import tokens",
+			fmt.Sprintf("Managed clusters not imported, the auto-import token cannot be used: %s",
+				strings.Join(acmRestore.Status.InvalidImportTokens, ",")),
+		)
+	}
+	transformRestoredSecrets(ctx, r.Client, acmRestore, &veleroRestoreList)
+	tagRestoredResources(ctx, reconcileArgs, acmRestore)
+	labelManagedClusterNamespaces(ctx, r.Client, acmRestore, &veleroRestoreList)
+	processValidationRestore(ctx, r.Client, acmRestore, &veleroRestoreList)
+	createPostRestoreJob(ctx, r.Client, acmRestore)
 
 	// set CompletionTimestamp when cleanupOnRestore is true or restore is completed
 	// the CompletionTimestamp must be set after cleanupDeltaResources and executePostRestoreTasks are completed
@@ -328,11 +857,7 @@ func sendResult(restore *v1beta1.Restore, err error) (ctrl.Result, error) {
 	if restore.Spec.SyncRestoreWithNewBackups &&
 		restore.Status.Phase == v1beta1.RestorePhaseEnabled {
 
-		tryAgain := restoreSyncInterval
-		if restore.Spec.RestoreSyncInterval.Duration != 0 {
-			tryAgain = restore.Spec.RestoreSyncInterval.Duration
-		}
-		return ctrl.Result{RequeueAfter: tryAgain}, errors.Wrap(
+		return ctrl.Result{RequeueAfter: getRestoreSyncInterval(restore)}, errors.Wrap(
 			err,
 			fmt.Sprintf(
 				"could not update status for restore %s/%s",
@@ -342,6 +867,14 @@ func sendResult(restore *v1beta1.Restore, err error) (ctrl.Result, error) {
 		)
 	}
 
+	if isClusterJoinPending(restore) || isMinClustersAvailablePending(restore, time.Now()) {
+		// check again if the activated managed clusters joined the hub
+		return ctrl.Result{RequeueAfter: managedClusterImportInterval}, errors.Wrap(
+			err,
+			fmt.Sprintf("could not update status for restore %s/%s", restore.Namespace, restore.Name),
+		)
+	}
+
 	return ctrl.Result{}, errors.Wrap(
 		err,
 		fmt.Sprintf("could not update status for restore %s/%s", restore.Namespace, restore.Name),
@@ -354,24 +887,28 @@ func (r *RestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		context.Background(),
 		&veleroapi.Restore{},
 		restoreOwnerKey,
-		func(rawObj client.Object) []string {
-			// grab the job object, extract the owner...
-			job := rawObj.(*veleroapi.Restore)
-			owner := metav1.GetControllerOf(job)
-			if owner == nil || owner.APIVersion != apiGVStr || owner.Kind != "Restore" {
-				return nil
-			}
-
-			return []string{owner.Name}
-		}); err != nil {
+		indexRestoreOwner); err != nil {
 		return err
 	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1beta1.Restore{}).
 		Owns(&veleroapi.Restore{}).
+		Owns(&batchv1.Job{}).
 		Complete(r)
 }
 
+// returns the name of the acm restore owning the velero restore
+func indexRestoreOwner(rawObj client.Object) []string {
+	// grab the job object, extract the owner...
+	job := rawObj.(*veleroapi.Restore)
+	owner := metav1.GetControllerOf(job)
+	if owner == nil || owner.APIVersion != apiGVStr || owner.Kind != "Restore" {
+		return nil
+	}
+
+	return []string{owner.Name}
+}
+
 // mostRecent defines type and code to sort velero backups
 // according to start timestamp
 type mostRecent []veleroapi.Backup
@@ -426,6 +963,32 @@ func (r *RestoreReconciler) initVeleroRestores(
 	if err != nil {
 		return false, "", err
 	}
+	// with the SinceBackupName option, restore only the resource types with items added or changed
+	// since the older backup; computed once, before the resources restore is created
+	if restore.Spec.SinceBackupName != "" && veleroRestoresToCreate[Resources] != nil &&
+		restore.Status.VeleroResourcesRestoreName == "" {
+		backupName := veleroRestoresToCreate[Resources].Spec.BackupName
+		includedResources, ready, err := getIncrementalResources(ctx, r.Client, restore.Namespace,
+			backupName, restore.Spec.SinceBackupName)
+		if err != nil {
+			return false, "", err
+		}
+		if !ready {
+			return true, fmt.Sprintf(sinceBackupWaitMsg, backupName, restore.Spec.SinceBackupName), nil
+		}
+		if len(includedResources) == 0 {
+			restoreLogger.Info(
+				"no resources added or changed since backup, skipping restore for",
+				"name", restore.Name,
+				"namespace", restore.Namespace,
+				"type", Resources,
+				"since", restore.Spec.SinceBackupName,
+			)
+			delete(veleroRestoresToCreate, Resources)
+		} else {
+			veleroRestoresToCreate[Resources].Spec.IncludedResources = includedResources
+		}
+	}
 	if len(veleroRestoresToCreate) == 0 {
 		updateRestoreStatus(
 			restoreLogger,
@@ -436,6 +999,27 @@ func (r *RestoreReconciler) initVeleroRestores(
 		return false, "", nil
 	}
 
+	// with the PointInTimeRecovery option, don't restore and clean up the hub from incomplete backups
+	if restore.Spec.PointInTimeRecovery {
+		msg, err := getIncompleteRecoveryBackups(ctx, r.Client, restore.Namespace, resKeys, veleroRestoresToCreate)
+		if err != nil {
+			return false, "", err
+		}
+		if msg != "" {
+			updateRestoreStatus(restoreLogger, v1beta1.RestorePhaseFinishedWithErrors, msg, restore)
+			return false, "", nil
+		}
+	}
+
+	// report any resources from the resources backup with no CRD on this cluster
+	if restore.Spec.ValidateCRDs && veleroRestoresToCreate[Resources] != nil {
+		r.validateRestoreCRDs(ctx, restore, veleroRestoresToCreate[Resources].Spec.BackupName)
+	}
+	// skip the resources from the resources backup with no CRD on this cluster
+	if restore.Spec.SkipMissingCRDResources && veleroRestoresToCreate[Resources] != nil {
+		r.skipMissingCRDResources(ctx, restore, veleroRestoresToCreate[Resources])
+	}
+
 	newVeleroRestoreCreated := false
 
 	// now create the restore resources and start the actual restore
@@ -461,6 +1045,17 @@ func (r *RestoreReconciler) initVeleroRestores(
 		}
 
 		isCredsClsOnActiveStep := updateLabelsForActiveResources(restore, key, veleroRestoresToCreate)
+
+		// with the RestoreClusterScopedFirst option, the cluster scoped resources are restored first;
+		// the namespaced resources are restored after the cluster scoped resources restore completes
+		if key == Resources && isClusterScopedFirstRestore(restore, restoreObj, sync) {
+			if restore.Status.VeleroClusterScopedResourcesRestoreName == "" {
+				return r.createClusterScopedResourcesRestore(ctx, restore, restoreObj)
+			}
+			includeClusterResources := false
+			restoreObj.Spec.IncludeClusterResources = &includeClusterResources
+		}
+
 		err := r.Create(ctx, veleroRestoresToCreate[key], &client.CreateOptions{})
 
 		if err != nil {
@@ -492,9 +1087,16 @@ func (r *RestoreReconciler) initVeleroRestores(
 				restore.Status.VeleroResourcesRestoreName = veleroRestoresToCreate[key].Name
 			case ResourcesGeneric:
 				restore.Status.VeleroGenericResourcesRestoreName = veleroRestoresToCreate[key].Name
-
+			case ConfigMaps:
+				restore.Status.VeleroConfigMapsRestoreName = veleroRestoresToCreate[key].Name
 			}
 		}
+		// with the FailFast option, wait for the new credentials restore to complete
+		// before creating the other velero restores
+		if restore.Spec.FailFast && !sync && err == nil && key == Credentials &&
+			len(veleroRestoresToCreate) > 1 {
+			return true, fmt.Sprintf(failFastWaitMsg, veleroRestoresToCreate[key].Name), nil
+		}
 		// check if needed to wait for pvcs to be created before the app data is restored
 		if isCredsClsOnActiveStep {
 			if shouldWait, waitMsg := processRestoreWait(ctx, r.Client,
@@ -506,15 +1108,170 @@ func (r *RestoreReconciler) initVeleroRestores(
 	}
 
 	if newVeleroRestoreCreated {
-		restore.Status.Phase = v1beta1.RestorePhaseStarted
-		restore.Status.LastMessage = fmt.Sprintf("Restore %s started", restore.Name)
+		transitionRestorePhase(restore, v1beta1.RestorePhaseStarted,
+			fmt.Sprintf("Restore %s started", restore.Name))
 	} else {
-		restore.Status.Phase = v1beta1.RestorePhaseFinished
-		restore.Status.LastMessage = fmt.Sprintf("Restore %s completed", restore.Name)
+		transitionRestorePhase(restore, v1beta1.RestorePhaseFinished,
+			fmt.Sprintf("Restore %s completed", restore.Name))
 	}
 	return false, "", nil
 }
 
+// creates the velero restore restoring the cluster scoped resources from the resources backup,
+// used by the RestoreClusterScopedFirst option; returns true and a wait message, since the namespaced
+// resources are restored only after this velero restore completes
+func (r *RestoreReconciler) createClusterScopedResourcesRestore(
+	ctx context.Context,
+	restore *v1beta1.Restore,
+	veleroRestore *veleroapi.Restore,
+) (bool, string, error) {
+	clusterScopedRestore := getClusterScopedResourcesRestore(veleroRestore)
+	err := r.Create(ctx, clusterScopedRestore, &client.CreateOptions{})
+	if err != nil && !k8serr.IsAlreadyExists(err) {
+		return false, "", err
+	}
+	if err == nil {
+		r.Recorder.Event(
+			restore,
+			v1.EventTypeNormal,
+			"Velero restore created:",
+			clusterScopedRestore.Name,
+		)
+	}
+	restore.Status.VeleroClusterScopedResourcesRestoreName = clusterScopedRestore.Name
+	return true, fmt.Sprintf(clusterScopedWaitMsg, clusterScopedRestore.Name), nil
+}
+
+// set the MissingCRDs status with the resources stored by the backup
+// which are not available on this cluster
+func (r *RestoreReconciler) validateRestoreCRDs(
+	ctx context.Context,
+	restore *v1beta1.Restore,
+	backupName string,
+) {
+	restoreLogger := log.FromContext(ctx)
+
+	missingCRDs, err := r.getBackupMissingCRDs(ctx, restore.Namespace, backupName)
+	if err != nil {
+		restoreLogger.Error(err, "unable to get backup to validate CRDs", "backup", backupName)
+		return
+	}
+
+	restore.Status.MissingCRDs = missingCRDs
+	if len(restore.Status.MissingCRDs) > 0 {
+		msg := fmt.Sprintf("Backup %s contains resources with no CRD on this cluster: %s",
+			backupName, strings.Join(restore.Status.MissingCRDs, ","))
+		restoreLogger.Info(msg)
+		r.Recorder.Event(restore, v1.EventTypeWarning, "Missing CRDs", msg)
+	}
+}
+
+// exclude from the velero restore the resources stored by the backup
+// which are not available on this cluster and set the SkippedMissingCRDResources status
+func (r *RestoreReconciler) skipMissingCRDResources(
+	ctx context.Context,
+	restore *v1beta1.Restore,
+	veleroRestore *veleroapi.Restore,
+) {
+	restoreLogger := log.FromContext(ctx)
+
+	backupName := veleroRestore.Spec.BackupName
+	missingCRDs, err := r.getBackupMissingCRDs(ctx, restore.Namespace, backupName)
+	if err != nil {
+		restoreLogger.Error(err, "unable to get backup to skip the missing CRDs", "backup", backupName)
+		return
+	}
+
+	restore.Status.SkippedMissingCRDResources = missingCRDs
+	if len(missingCRDs) == 0 {
+		return
+	}
+	for i := range missingCRDs {
+		veleroRestore.Spec.ExcludedResources = appendUnique(veleroRestore.Spec.ExcludedResources,
+			missingCRDs[i])
+	}
+	msg := fmt.Sprintf("Skipping the resources from backup %s with no CRD on this cluster: %s",
+		backupName, strings.Join(missingCRDs, ","))
+	restoreLogger.Info(msg)
+	r.Recorder.Event(restore, v1.EventTypeWarning, "Skipped missing CRDs", msg)
+}
+
+// report the velero restores from this namespace whose owning Restore no longer exists,
+// labeling them with the OrphanedRestoreLabel; the velero restores are deleted instead
+// when the CleanupOrphanedRestores option is set
+func (r *RestoreReconciler) processOrphanedVeleroRestores(
+	ctx context.Context,
+	namespace string,
+) {
+	restoreLogger := log.FromContext(ctx)
+
+	veleroRestoreList := veleroapi.RestoreList{}
+	if err := r.List(ctx, &veleroRestoreList, client.InNamespace(namespace)); err != nil {
+		restoreLogger.Error(err, "unable to list velero restores", "namespace", namespace)
+		return
+	}
+	restoreList := v1beta1.RestoreList{}
+	if err := r.List(ctx, &restoreList, client.InNamespace(namespace)); err != nil {
+		restoreLogger.Error(err, "unable to list restores", "namespace", namespace)
+		return
+	}
+
+	orphanedRestores := getOrphanedVeleroRestores(&veleroRestoreList, &restoreList)
+	for i := range orphanedRestores {
+		veleroRestore := orphanedRestores[i]
+		restoreName := veleroRestore.GetLabels()[RestoreOwnerLabel]
+
+		if r.CleanupOrphanedRestores {
+			if err := r.Delete(ctx, veleroRestore); err != nil && !k8serr.IsNotFound(err) {
+				restoreLogger.Error(err, "unable to delete orphaned velero restore", "name", veleroRestore.Name)
+				continue
+			}
+			msg := fmt.Sprintf("Deleted velero restore %s, the owning restore %s no longer exists",
+				veleroRestore.Name, restoreName)
+			restoreLogger.Info(msg)
+			r.Recorder.Event(veleroRestore, v1.EventTypeNormal, "Orphaned velero restore deleted", msg)
+			continue
+		}
+
+		if _, reported := veleroRestore.GetLabels()[OrphanedRestoreLabel]; reported {
+			continue
+		}
+		restoreLabels := veleroRestore.GetLabels()
+		if restoreLabels == nil {
+			restoreLabels = map[string]string{}
+		}
+		restoreLabels[OrphanedRestoreLabel] = restoreName
+		veleroRestore.SetLabels(restoreLabels)
+		if err := r.Update(ctx, veleroRestore); err != nil {
+			restoreLogger.Error(err, "unable to label orphaned velero restore", "name", veleroRestore.Name)
+			continue
+		}
+		msg := fmt.Sprintf("Velero restore %s is orphaned, the owning restore %s no longer exists",
+			veleroRestore.Name, restoreName)
+		restoreLogger.Info(msg)
+		r.Recorder.Event(veleroRestore, v1.EventTypeWarning, "Orphaned velero restore", msg)
+	}
+}
+
+// returns the resources stored by the backup with the given name
+// which are not available on this cluster
+func (r *RestoreReconciler) getBackupMissingCRDs(
+	ctx context.Context,
+	namespace string,
+	backupName string,
+) ([]string, error) {
+	veleroBackup := &veleroapi.Backup{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Name:      backupName,
+		Namespace: namespace,
+	}, veleroBackup); err != nil {
+		return nil, err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(r.DiscoveryClient))
+	return getMissingCRDs(mapper, veleroBackup), nil
+}
+
 // for an activation phase update restore labels to include activation resources
 func updateLabelsForActiveResources(
 	restore *v1beta1.Restore,
@@ -17,13 +17,24 @@ limitations under the License.
 package controllers
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/util/results"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,8 +43,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/dynamic"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -46,8 +60,63 @@ const (
 	// RestoreClusterLabel is the label key used to identify the cluster id
 	// that had run a restore clusters operation, so it had become the active hub
 	RestoreClusterLabel string = "cluster.open-cluster-management.io/restore-cluster"
+
+	// PostRestoreJobLabel is the label key used to identify the restore
+	// that created the post restore Job
+	PostRestoreJobLabel string = "cluster.open-cluster-management.io/post-restore-job"
+
+	// max length for a Job name, the name is used as a label value on the Job pods
+	maxJobNameLength = 63
+
+	// SecretTransformAnnotation is the annotation set on restored credential secrets
+	// after being processed by the Restore SecretTransform, the value is the transform name
+	SecretTransformAnnotation string = "cluster.open-cluster-management.io/secret-transform"
+	// label set by velero on the restored resources
+	RestoreNameVeleroLabel string = "velero.io/restore-name"
+	// RestoreTagLabel is the label set on the resources restored by a restore
+	// with the TagRestoredResources option, the value is the restore name
+	RestoreTagLabel string = "cluster.open-cluster-management.io/restore"
+	// kind used to report the velero restore errors not related to a resource
+	unknownFailedItemKind = "unknown"
+	// default cleanup rank of the namespaces, deleted after all other resources
+	maxDefaultCleanupRank = 3
 )
 
+// namespaced kinds other resources depend on, deleted by the restore cleanup after the other namespaced resources
+var cleanupParentKinds = []string{
+	"channel",
+	"placement",
+	"placementrule",
+	"clusterpool",
+}
+
+// client used to download the velero restore results and backup contents
+var veleroDownloadHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// SecretTransformer transforms a credential secret restored by the credentials restore,
+// for example to re-encrypt the secret data using an external KMS key
+type SecretTransformer interface {
+	Transform(ctx context.Context, secret *corev1.Secret, keyRef string) error
+}
+
+// default secret transform, leaves the secret unchanged
+type noopSecretTransformer struct{}
+
+func (noopSecretTransformer) Transform(_ context.Context, _ *corev1.Secret, _ string) error {
+	return nil
+}
+
+// secret transforms available to the Restore SecretTransform option, by name
+var secretTransformers = map[string]SecretTransformer{
+	v1beta1.SecretTransformNone: noopSecretTransformer{},
+}
+
+// RegisterSecretTransformer makes a secret transform available to the Restore SecretTransform option;
+// it must be called before the restore controller is started
+func RegisterSecretTransformer(name string, transformer SecretTransformer) {
+	secretTransformers[name] = transformer
+}
+
 // execute any tasks after restore is done
 func executePostRestoreTasks(
 	ctx context.Context,
@@ -62,10 +131,22 @@ func executePostRestoreTasks(
 		acmRestore.Status.Phase == v1beta1.RestorePhaseFinishedWithErrors) &&
 		*acmRestore.Spec.VeleroManagedClustersBackupName != skipRestoreStr {
 
-		// workaround for ACM-8406
-		deleteObsClientCert(ctx, c)
-		// broadcast the restore managed clusters operation by creating a backup resource
-		recordClustersRestoreOperation(ctx, c, acmRestore)
+		if acmRestore.Status.ActivationPhase == v1beta1.ActivationPhaseCompleted {
+			// activation already completed
+			return true
+		}
+
+		if acmRestore.Status.ActivationPhase == v1beta1.ActivationPhaseInProgress {
+			// the activation was interrupted, for example by an operator restart
+			// the tasks below were already executed, resume the activation
+			logger.Info("Resume managed clusters activation",
+				"activated", acmRestore.Status.ActivatedClusters)
+		} else {
+			// workaround for ACM-8406
+			deleteObsClientCert(ctx, c)
+			// broadcast the restore managed clusters operation by creating a backup resource
+			recordClustersRestoreOperation(ctx, c, acmRestore)
+		}
 
 		localClusterName, err := getLocalClusterName(ctx, c)
 		if err != nil {
@@ -83,14 +164,803 @@ func executePostRestoreTasks(
 		}
 
 		processed = true
+		acmRestore.Status.ActivationPhase = v1beta1.ActivationPhaseInProgress
+
 		// this cluster was activated so try to auto import pending managed clusters
-		_, activationMessages := postRestoreActivation(ctx, c, getMSASecrets(ctx, c, ""),
-			managedClusters.Items, localClusterName, time.Now().In(time.UTC))
+		// persist each activated cluster so the activation can be resumed if the operator restarts
+		secretName, secretLabel := getActivationNames(acmRestore)
+		_, activationMessages, invalidImportTokens := postRestoreActivation(ctx, c, getMSASecrets(ctx, c, ""),
+			managedClusters.Items, localClusterName, time.Now().In(time.UTC),
+			acmRestore.Status.ActivatedClusters, secretName, secretLabel,
+			func(clusterName string) {
+				acmRestore.Status.ActivatedClusters = append(acmRestore.Status.ActivatedClusters, clusterName)
+				if err := c.Status().Update(ctx, acmRestore); err != nil {
+					logger.Error(err, "Error updating restore status with activated cluster",
+						"cluster", clusterName)
+				}
+			})
 		acmRestore.Status.Messages = activationMessages
+		acmRestore.Status.InvalidImportTokens = invalidImportTokens
+		acmRestore.Status.ActivationPhase = v1beta1.ActivationPhaseCompleted
 	}
 	return processed
 }
 
+// apply the SecretTransform to the secrets restored by the credentials restore,
+// once the credentials velero restore is completed
+// returns true if the secrets were processed
+func transformRestoredSecrets(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+) bool {
+	logger := log.FromContext(ctx)
+
+	secretTransform := acmRestore.Spec.SecretTransform
+	credsRestoreName := acmRestore.Status.VeleroCredentialsRestoreName
+	if secretTransform == nil || credsRestoreName == "" || veleroRestoreList == nil {
+		return false
+	}
+
+	credsRestoreFinished := false
+	for i := range veleroRestoreList.Items {
+		if veleroRestoreList.Items[i].Name == credsRestoreName {
+			credsRestoreFinished = isVeleroRestoreFinished(&veleroRestoreList.Items[i])
+			break
+		}
+	}
+	if !credsRestoreFinished {
+		return false
+	}
+
+	transformer, ok := secretTransformers[secretTransform.Name]
+	if !ok {
+		msg := fmt.Sprintf("Secret transform %s is not available", secretTransform.Name)
+		acmRestore.Status.Messages = appendUnique(acmRestore.Status.Messages, msg)
+		return false
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.MatchingLabels{RestoreNameVeleroLabel: credsRestoreName}); err != nil {
+		logger.Error(err, "failed to list restored secrets", "restore", credsRestoreName)
+		return false
+	}
+
+	for i := range secrets.Items {
+		secret := secrets.Items[i].DeepCopy()
+		if secret.GetAnnotations()[SecretTransformAnnotation] == secretTransform.Name {
+			// already transformed
+			continue
+		}
+
+		if err := transformer.Transform(ctx, secret, secretTransform.KeyRef); err != nil {
+			msg := fmt.Sprintf("Failed to apply secret transform %s on secret %s/%s: %s",
+				secretTransform.Name, secret.Namespace, secret.Name, err.Error())
+			acmRestore.Status.Messages = appendUnique(acmRestore.Status.Messages, msg)
+			continue
+		}
+
+		annotations := secret.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[SecretTransformAnnotation] = secretTransform.Name
+		secret.SetAnnotations(annotations)
+
+		if err := c.Update(ctx, secret); err != nil {
+			logger.Error(err, "failed to update transformed secret",
+				"namespace", secret.Namespace, "name", secret.Name)
+		}
+	}
+
+	return true
+}
+
+// reports under the FailedItemsByKind status the number of items which failed to restore for each
+// resource kind, read from the results of the PartiallyFailed velero restores created by this restore;
+// velero exposes the results using a DownloadRequest, processed asynchronously
+// returns true if the status was updated, and true if velero did not process yet the download requests
+func updateFailedItemsByKind(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+) (bool, bool) {
+	logger := log.FromContext(ctx)
+
+	if acmRestore.Status.FailedItemsByKind != nil ||
+		acmRestore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors {
+		// already reported or no failed item
+		return false, false
+	}
+
+	failedItems := map[string]int{}
+	pending := false
+	for _, name := range []string{
+		acmRestore.Status.VeleroManagedClustersRestoreName,
+		acmRestore.Status.VeleroCredentialsRestoreName,
+		acmRestore.Status.VeleroGenericResourcesRestoreName,
+		acmRestore.Status.VeleroConfigMapsRestoreName,
+		acmRestore.Status.VeleroResourcesRestoreName,
+	} {
+		if name == "" {
+			continue
+		}
+		veleroRestore := &veleroapi.Restore{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: acmRestore.Namespace},
+			veleroRestore); err != nil ||
+			!strings.Contains(string(veleroRestore.Status.Phase), string(veleroapi.RestorePhasePartiallyFailed)) {
+			continue
+		}
+
+		restoreResults, ready, err := getVeleroRestoreResults(ctx, c, veleroRestore)
+		if err != nil {
+			// the failed items are reported with the unknown kind
+			logger.Error(err, "failed to read the velero restore results", "name", veleroRestore.Name)
+			failedItems[unknownFailedItemKind] += veleroRestore.Status.Errors
+			continue
+		}
+		if !ready {
+			pending = true
+			continue
+		}
+		countFailedItemsByKind(restoreResults, failedItems)
+	}
+
+	if pending || len(failedItems) == 0 {
+		return false, pending
+	}
+	acmRestore.Status.FailedItemsByKind = failedItems
+	return true, false
+}
+
+// returns the errors reported by the velero restore results, and false if velero
+// did not process yet the DownloadRequest used to read the results; the DownloadRequest is
+// created on the first call and deleted after the results are read
+func getVeleroRestoreResults(
+	ctx context.Context,
+	c client.Client,
+	veleroRestore *veleroapi.Restore,
+) (results.Result, bool, error) {
+	resultsByType := map[string]results.Result{}
+	ready, err := readVeleroDownload(ctx, c,
+		types.NamespacedName{Name: veleroRestore.Name + "-results", Namespace: veleroRestore.Namespace},
+		veleroapi.DownloadTarget{Kind: veleroapi.DownloadTargetKindRestoreResults, Name: veleroRestore.Name},
+		func(content io.Reader) error {
+			return json.NewDecoder(content).Decode(&resultsByType)
+		})
+	if err != nil || !ready {
+		return results.Result{}, false, err
+	}
+	return resultsByType["errors"], true, nil
+}
+
+// reads the gzip compressed velero data targeted by the DownloadRequest with the given name,
+// passing the uncompressed content to read; returns false if velero did not process yet the
+// DownloadRequest, the DownloadRequest is created on the first call and deleted after the data is read
+func readVeleroDownload(
+	ctx context.Context,
+	c client.Client,
+	downloadRequestName types.NamespacedName,
+	target veleroapi.DownloadTarget,
+	read func(content io.Reader) error,
+) (bool, error) {
+	downloadRequest := &veleroapi.DownloadRequest{}
+	if err := c.Get(ctx, downloadRequestName, downloadRequest); err != nil {
+		if !k8serr.IsNotFound(err) {
+			return false, err
+		}
+		downloadRequest.Name = downloadRequestName.Name
+		downloadRequest.Namespace = downloadRequestName.Namespace
+		downloadRequest.Spec.Target = target
+		return false, c.Create(ctx, downloadRequest)
+	}
+	if downloadRequest.Status.Phase != veleroapi.DownloadRequestPhaseProcessed ||
+		downloadRequest.Status.DownloadURL == "" {
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadRequest.Status.DownloadURL, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := veleroDownloadHTTPClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d downloading the %s %s",
+			resp.StatusCode, target.Kind, target.Name)
+	}
+	gzipReader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	defer gzipReader.Close()
+
+	if err := read(gzipReader); err != nil {
+		return false, err
+	}
+	if err := c.Delete(ctx, downloadRequest); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "failed to delete the download request",
+			"name", downloadRequest.Name)
+	}
+	return true, nil
+}
+
+// adds to failedItems the number of errors reported by the velero restore results for each resource kind;
+// velero reports an item error as "error restoring <resource>/<namespace>/<name>: <error>",
+// other errors are counted with the unknown kind
+func countFailedItemsByKind(
+	restoreResults results.Result,
+	failedItems map[string]int,
+) {
+	messages := append([]string{}, restoreResults.Velero...)
+	messages = append(messages, restoreResults.Cluster...)
+	for _, namespaceMessages := range restoreResults.Namespaces {
+		messages = append(messages, namespaceMessages...)
+	}
+
+	for _, msg := range messages {
+		kind := unknownFailedItemKind
+		if resourceID, found := strings.CutPrefix(msg, "error restoring "); found {
+			if resource, _, found := strings.Cut(resourceID, "/"); found && resource != "" {
+				kind = resource
+			}
+		}
+		failedItems[kind]++
+	}
+}
+
+// reports the result of a validation restore under the ValidationRestoreResult status,
+// once the restore is completed, and deletes the sandbox namespaces if the CleanupValidationSandbox option is set
+// returns true if the result was reported
+func processValidationRestore(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+) bool {
+	logger := log.FromContext(ctx)
+
+	if !acmRestore.Spec.ValidationRestore || acmRestore.Status.CompletionTimestamp != nil ||
+		(acmRestore.Status.Phase != v1beta1.RestorePhaseFinished &&
+			acmRestore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors) {
+		// not set, already processed when the restore completed or not completed yet
+		return false
+	}
+
+	result := &v1beta1.ValidationRestoreResult{
+		Succeeded: acmRestore.Status.Phase == v1beta1.RestorePhaseFinished,
+	}
+	for i := range veleroRestoreList.Items {
+		veleroRestore := &veleroRestoreList.Items[i]
+		if veleroRestore.Status.Progress != nil {
+			result.TotalItems += veleroRestore.Status.Progress.TotalItems
+			result.ItemsRestored += veleroRestore.Status.Progress.ItemsRestored
+		}
+		result.Warnings += veleroRestore.Status.Warnings
+		result.Errors += veleroRestore.Status.Errors
+		for _, ns := range veleroRestore.Spec.NamespaceMapping {
+			// only the sandbox namespaces are reported and deleted
+			if strings.HasPrefix(ns, acmRestore.Spec.SandboxNamespacePrefix+"-") {
+				result.SandboxNamespaces = appendUnique(result.SandboxNamespaces, ns)
+			}
+		}
+	}
+	result.Succeeded = result.Succeeded && result.Errors == 0
+	sort.Strings(result.SandboxNamespaces)
+
+	if acmRestore.Spec.CleanupValidationSandbox {
+		result.SandboxCleanedUp = true
+		for _, ns := range result.SandboxNamespaces {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+			if err := c.Delete(ctx, namespace); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "failed to delete the validation restore sandbox namespace", "namespace", ns)
+				result.SandboxCleanedUp = false
+			}
+		}
+	}
+
+	acmRestore.Status.ValidationRestoreResult = result
+	logger.Info("validation restore completed", "succeeded", result.Succeeded,
+		"itemsRestored", result.ItemsRestored, "totalItems", result.TotalItems,
+		"sandboxCleanedUp", result.SandboxCleanedUp)
+	return true
+}
+
+// label the resources restored by the velero restores with the RestoreTagLabel,
+// once the restore is completed and if the TagRestoredResources option is set
+// returns true if the resources were processed
+func tagRestoredResources(
+	ctx context.Context,
+	dynamicArgs DynamicStruct,
+	acmRestore *v1beta1.Restore,
+) bool {
+	logger := log.FromContext(ctx)
+
+	if !acmRestore.Spec.TagRestoredResources || acmRestore.Status.CompletionTimestamp != nil ||
+		(acmRestore.Status.Phase != v1beta1.RestorePhaseFinished &&
+			acmRestore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors) {
+		// not set, already processed when the restore completed or not completed yet
+		return false
+	}
+
+	if errs := validation.IsValidLabelValue(acmRestore.Name); len(errs) > 0 {
+		msg := fmt.Sprintf("Restored resources not tagged, the restore name is not a valid label value: %s",
+			strings.Join(errs, ", "))
+		acmRestore.Status.Messages = appendUnique(acmRestore.Status.Messages, msg)
+		return false
+	}
+
+	veleroRestoreNames := []string{}
+	for _, name := range []string{
+		acmRestore.Status.VeleroManagedClustersRestoreName,
+		acmRestore.Status.VeleroCredentialsRestoreName,
+		acmRestore.Status.VeleroGenericResourcesRestoreName,
+		acmRestore.Status.VeleroConfigMapsRestoreName,
+		acmRestore.Status.VeleroResourcesRestoreName,
+	} {
+		if name != "" {
+			veleroRestoreNames = append(veleroRestoreNames, name)
+		}
+	}
+	if len(veleroRestoreNames) == 0 {
+		return false
+	}
+
+	groupList, err := dynamicArgs.dc.ServerGroups()
+	if err != nil || groupList == nil {
+		logger.Error(err, "failed to get server groups, restored resources not tagged")
+		return false
+	}
+
+	// resources restored by these velero restores and not already tagged
+	labelSelector := fmt.Sprintf("%s in (%s), %s!=%s", RestoreNameVeleroLabel,
+		strings.Join(veleroRestoreNames, ","), RestoreTagLabel, acmRestore.Name)
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, RestoreTagLabel, acmRestore.Name))
+
+	for _, group := range groupList.Groups {
+		groupVersion := group.PreferredVersion.GroupVersion
+		resourceList, err := dynamicArgs.dc.ServerResourcesForGroupVersion(groupVersion)
+		if err != nil || resourceList == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			continue
+		}
+		for _, resource := range resourceList.APIResources {
+			if strings.Contains(resource.Name, "/") ||
+				!findValue(resource.Verbs, "list") || !findValue(resource.Verbs, "patch") {
+				// skip subresources and resources which cannot be tagged
+				continue
+			}
+			dr := dynamicArgs.dyn.Resource(gv.WithResource(resource.Name))
+			dynamiclist, err := dr.List(ctx, v1.ListOptions{LabelSelector: labelSelector})
+			if err != nil {
+				continue
+			}
+			for i := range dynamiclist.Items {
+				item := dynamiclist.Items[i]
+				if _, err := dr.Namespace(item.GetNamespace()).Patch(ctx, item.GetName(),
+					types.MergePatchType, patch, v1.PatchOptions{}); err != nil {
+					logger.Error(err, "failed to tag restored resource", "resource", resource.Name,
+						"namespace", item.GetNamespace(), "name", item.GetName())
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// apply the ManagedClusterNamespaceLabels to the namespace of each managed cluster restored
+// by the managed clusters velero restore, once this velero restore is completed;
+// the local cluster namespace is not labeled
+// returns true if the namespaces were processed
+func labelManagedClusterNamespaces(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	veleroRestoreList *veleroapi.RestoreList,
+) bool {
+	logger := log.FromContext(ctx)
+
+	restoreName := acmRestore.Status.VeleroManagedClustersRestoreName
+	if len(acmRestore.Spec.ManagedClusterNamespaceLabels) == 0 || restoreName == "" {
+		return false
+	}
+	restoreCompleted := false
+	for i := range veleroRestoreList.Items {
+		veleroRestore := veleroRestoreList.Items[i]
+		if veleroRestore.Name == restoreName {
+			restoreCompleted = veleroRestore.Status.Phase == veleroapi.RestorePhaseCompleted ||
+				veleroRestore.Status.Phase == veleroapi.RestorePhasePartiallyFailed
+			break
+		}
+	}
+	if !restoreCompleted {
+		return false
+	}
+
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, managedClusters, client.MatchingLabels{RestoreNameVeleroLabel: restoreName}); err != nil {
+		logger.Error(err, "Error listing the restored managed clusters, namespaces not labeled")
+		return false
+	}
+
+	for i := range managedClusters.Items {
+		managedCluster := &managedClusters.Items[i]
+		if managedCluster.Name == localClusterLabel || hasLocalClusterLabel(managedCluster) {
+			continue
+		}
+
+		namespace := &corev1.Namespace{}
+		if err := c.Get(ctx, types.NamespacedName{Name: managedCluster.Name}, namespace); err != nil {
+			if !k8serr.IsNotFound(err) {
+				logger.Error(err, "Error getting the managed cluster namespace", "namespace", managedCluster.Name)
+			}
+			continue
+		}
+
+		patch := client.MergeFrom(namespace.DeepCopy())
+		updated := false
+		nsLabels := namespace.GetLabels()
+		if nsLabels == nil {
+			nsLabels = map[string]string{}
+		}
+		for key, value := range acmRestore.Spec.ManagedClusterNamespaceLabels {
+			if current, ok := nsLabels[key]; !ok || current != value {
+				nsLabels[key] = value
+				updated = true
+			}
+		}
+		if !updated {
+			// already labeled
+			continue
+		}
+		namespace.SetLabels(nsLabels)
+		if err := c.Patch(ctx, namespace, patch); err != nil {
+			logger.Error(err, "Error labeling the managed cluster namespace", "namespace", namespace.Name)
+		}
+	}
+
+	return true
+}
+
+// create the Job defined by the PostRestoreJob property, once the restore is completed
+// returns true if the Job was processed
+func createPostRestoreJob(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+) bool {
+	logger := log.FromContext(ctx)
+
+	if acmRestore.Spec.PostRestoreJob == nil ||
+		acmRestore.Status.PostRestoreJobStatus != nil ||
+		(acmRestore.Status.Phase != v1beta1.RestorePhaseFinished &&
+			acmRestore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors) {
+		// no job defined, already created or restore not completed
+		return false
+	}
+
+	// do not retry the job, the result is reported as is
+	backoffLimit := int32(0)
+	jobName := getValidKsRestoreName(acmRestore.Name, "post-restore")
+	if len(jobName) > maxJobNameLength {
+		jobName = jobName[:maxJobNameLength]
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: acmRestore.Namespace,
+			Labels: map[string]string{
+				PostRestoreJobLabel: acmRestore.Name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						PostRestoreJobLabel: acmRestore.Name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: acmRestore.Spec.PostRestoreJob.ServiceAccountName,
+					Containers: []corev1.Container{
+						{
+							Name:    "post-restore",
+							Image:   acmRestore.Spec.PostRestoreJob.Image,
+							Command: acmRestore.Spec.PostRestoreJob.Command,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	acmRestore.Status.PostRestoreJobStatus = &v1beta1.PostRestoreJobStatus{
+		JobName: jobName,
+		Phase:   v1beta1.PostRestoreJobPhaseRunning,
+		Message: "Post restore job " + jobName + " created",
+	}
+
+	err := ctrl.SetControllerReference(acmRestore, job, c.Scheme())
+	if err == nil {
+		err = c.Create(ctx, job, &client.CreateOptions{})
+	}
+	if err != nil && !k8serr.IsAlreadyExists(err) {
+		logger.Error(err, "Failed to create post restore job", "name", jobName)
+		setPostRestoreJobFailed(acmRestore, "Failed to create post restore job "+jobName+": "+err.Error())
+		return true
+	}
+
+	logger.Info("Post restore job created", "name", jobName)
+	return true
+}
+
+// update the PostRestoreJobStatus with the result of the running post restore Job
+// returns true if the status was updated
+func updatePostRestoreJobStatus(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+) bool {
+	jobStatus := acmRestore.Status.PostRestoreJobStatus
+	if jobStatus == nil || jobStatus.Phase != v1beta1.PostRestoreJobPhaseRunning {
+		return false
+	}
+
+	job := &batchv1.Job{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Name:      jobStatus.JobName,
+		Namespace: acmRestore.Namespace,
+	}, job); err != nil {
+		if k8serr.IsNotFound(err) {
+			setPostRestoreJobFailed(acmRestore, "Post restore job "+jobStatus.JobName+" not found")
+			return true
+		}
+		log.FromContext(ctx).Error(err, "Failed to get post restore job", "name", jobStatus.JobName)
+		return false
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			jobStatus.Phase = v1beta1.PostRestoreJobPhaseSucceeded
+			jobStatus.Message = "Post restore job " + jobStatus.JobName + " completed successfully"
+			return true
+		case batchv1.JobFailed:
+			setPostRestoreJobFailed(acmRestore, fmt.Sprintf("Post restore job %s failed: %s",
+				jobStatus.JobName, condition.Message))
+			return true
+		}
+	}
+
+	return false
+}
+
+// set the post restore Job status to failed and, if requested,
+// set the restore to FinishedWithErrors
+func setPostRestoreJobFailed(
+	acmRestore *v1beta1.Restore,
+	msg string,
+) {
+	acmRestore.Status.PostRestoreJobStatus.Phase = v1beta1.PostRestoreJobPhaseFailed
+	acmRestore.Status.PostRestoreJobStatus.Message = msg
+
+	if acmRestore.Spec.PostRestoreJob.FailRestoreOnJobFailure {
+		transitionRestorePhase(acmRestore, v1beta1.RestorePhaseFinishedWithErrors, msg)
+	}
+}
+
+// returns true if the VerifyClusterJoin option is set and the managed clusters activated
+// by this restore are still verified
+func isClusterJoinPending(
+	acmRestore *v1beta1.Restore,
+) bool {
+	if !acmRestore.Spec.VerifyClusterJoin ||
+		acmRestore.Status.ActivationPhase != v1beta1.ActivationPhaseCompleted ||
+		len(acmRestore.Status.ActivatedClusters) == 0 {
+		return false
+	}
+	condition := meta.FindStatusCondition(acmRestore.Status.Conditions, v1beta1.RestoreClustersJoined)
+	return condition == nil || condition.Reason == v1beta1.RestoreReasonClustersJoinPending
+}
+
+// check if the managed clusters activated by this restore joined the hub and are available,
+// report the result for each cluster under ClusterJoinStatus and set the ClustersJoined condition;
+// the restore is set to FinishedWithErrors if some clusters did not join within clusterJoinGracePeriod
+// returns true if the status was updated
+func verifyClusterJoin(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	currentTime time.Time,
+) bool {
+	if !isClusterJoinPending(acmRestore) {
+		return false
+	}
+
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, managedClusters, &client.ListOptions{}); err != nil {
+		log.FromContext(ctx).Error(err, "Error listing managed clusters, not able to verify the cluster join")
+		return false
+	}
+
+	clusterJoinStatus := getClusterJoinStatus(managedClusters, acmRestore.Status.ActivatedClusters)
+	notJoined := []string{}
+	for _, status := range clusterJoinStatus {
+		if !status.Joined || !status.Available {
+			notJoined = append(notJoined, status.Name)
+		}
+	}
+	acmRestore.Status.ClusterJoinStatus = clusterJoinStatus
+
+	condition := meta.FindStatusCondition(acmRestore.Status.Conditions, v1beta1.RestoreClustersJoined)
+	switch {
+	case len(notJoined) == 0:
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreClustersJoined,
+			Status:             metav1.ConditionTrue,
+			Reason:             v1beta1.RestoreReasonClustersJoined,
+			Message:            "All activated managed clusters joined the hub",
+			ObservedGeneration: acmRestore.Generation,
+		})
+	case condition != nil && currentTime.Sub(condition.LastTransitionTime.Time) > clusterJoinGracePeriod:
+		msg := fmt.Sprintf("Managed clusters %s did not join the hub within %s",
+			strings.Join(notJoined, ","), clusterJoinGracePeriod)
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreClustersJoined,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1beta1.RestoreReasonClustersJoinTimeout,
+			Message:            msg,
+			ObservedGeneration: acmRestore.Generation,
+		})
+		transitionRestorePhase(acmRestore, v1beta1.RestorePhaseFinishedWithErrors, msg)
+	default:
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreClustersJoined,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1beta1.RestoreReasonClustersJoinPending,
+			Message:            "Waiting for managed clusters to join the hub: " + strings.Join(notJoined, ","),
+			ObservedGeneration: acmRestore.Generation,
+			LastTransitionTime: metav1.NewTime(currentTime),
+		})
+	}
+	return true
+}
+
+// returns, for each of the given managed clusters, if the cluster joined the hub and is available
+func getClusterJoinStatus(
+	managedClusters *clusterv1.ManagedClusterList,
+	clusterNames []string,
+) []v1beta1.ClusterJoinStatus {
+	clusterJoinStatus := []v1beta1.ClusterJoinStatus{}
+	for _, clusterName := range clusterNames {
+		status := v1beta1.ClusterJoinStatus{Name: clusterName}
+		for i := range managedClusters.Items {
+			if managedClusters.Items[i].Name == clusterName {
+				conditions := managedClusters.Items[i].Status.Conditions
+				status.Joined = meta.IsStatusConditionTrue(conditions, clusterv1.ManagedClusterConditionJoined)
+				status.Available = meta.IsStatusConditionTrue(conditions, clusterv1.ManagedClusterConditionAvailable)
+				break
+			}
+		}
+		clusterJoinStatus = append(clusterJoinStatus, status)
+	}
+	return clusterJoinStatus
+}
+
+// returns true if the MinClustersAvailablePercent option is set and the availability of the managed clusters
+// activated by this completed restore must be checked again: the required percentage of clusters is not available
+// yet and the verification grace period did not end
+func isMinClustersAvailablePending(
+	acmRestore *v1beta1.Restore,
+	currentTime time.Time,
+) bool {
+	if acmRestore.Spec.MinClustersAvailablePercent <= 0 ||
+		acmRestore.Status.ActivationPhase != v1beta1.ActivationPhaseCompleted {
+		return false
+	}
+	condition := meta.FindStatusCondition(acmRestore.Status.Conditions, v1beta1.RestoreDegraded)
+	return condition != nil && condition.Status == metav1.ConditionTrue &&
+		currentTime.Sub(condition.LastTransitionTime.Time) <= clusterJoinGracePeriod
+}
+
+// with the MinClustersAvailablePercent option, check the percentage of managed clusters activated by this
+// completed restore which joined the hub and are available; set the Complete condition to true if the required
+// percentage is available, otherwise set the Degraded condition to true
+// returns true if the status was updated
+func verifyMinClustersAvailable(
+	ctx context.Context,
+	c client.Client,
+	acmRestore *v1beta1.Restore,
+	currentTime time.Time,
+) bool {
+	minPercent := acmRestore.Spec.MinClustersAvailablePercent
+	if minPercent <= 0 ||
+		acmRestore.Status.ActivationPhase != v1beta1.ActivationPhaseCompleted ||
+		(acmRestore.Status.Phase != v1beta1.RestorePhaseFinished &&
+			acmRestore.Status.Phase != v1beta1.RestorePhaseFinishedWithErrors) {
+		return false
+	}
+	if meta.IsStatusConditionTrue(acmRestore.Status.Conditions, v1beta1.RestoreComplete) {
+		// the required clusters were available
+		return false
+	}
+
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, managedClusters, &client.ListOptions{}); err != nil {
+		log.FromContext(ctx).Error(err, "Error listing managed clusters, not able to verify the clusters availability")
+		return false
+	}
+
+	activatedClusters := acmRestore.Status.ActivatedClusters
+	available := 0
+	for _, status := range getClusterJoinStatus(managedClusters, activatedClusters) {
+		if status.Joined && status.Available {
+			available++
+		}
+	}
+	availablePercent := 100
+	if len(activatedClusters) > 0 {
+		availablePercent = available * 100 / len(activatedClusters)
+	}
+	msg := fmt.Sprintf("%d of %d activated managed clusters are available (%d%%), the restore requires %d%%",
+		available, len(activatedClusters), availablePercent, minPercent)
+
+	if availablePercent >= minPercent {
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreComplete,
+			Status:             metav1.ConditionTrue,
+			Reason:             v1beta1.RestoreReasonFinished,
+			Message:            msg,
+			ObservedGeneration: acmRestore.Generation,
+		})
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreDegraded,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1beta1.RestoreReasonMinClustersAvailable,
+			Message:            msg,
+			ObservedGeneration: acmRestore.Generation,
+		})
+		return true
+	}
+
+	meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+		Type:               v1beta1.RestoreComplete,
+		Status:             metav1.ConditionFalse,
+		Reason:             v1beta1.RestoreReasonMinClustersNotAvailable,
+		Message:            msg,
+		ObservedGeneration: acmRestore.Generation,
+	})
+	if !meta.IsStatusConditionTrue(acmRestore.Status.Conditions, v1beta1.RestoreDegraded) {
+		// the verification grace period starts now
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             v1beta1.RestoreReasonMinClustersNotAvailable,
+			Message:            msg,
+			ObservedGeneration: acmRestore.Generation,
+			LastTransitionTime: metav1.NewTime(currentTime),
+		})
+		return true
+	}
+	condition := meta.FindStatusCondition(acmRestore.Status.Conditions, v1beta1.RestoreDegraded)
+	condition.Message = msg
+	return true
+}
+
 // workaround for ACM-8406
 func deleteObsClientCert(
 	ctx context.Context,
@@ -191,12 +1061,16 @@ func cleanupDeltaResources(
 ) bool {
 	processed := false
 
-	if acmRestore.Spec.CleanupBeforeRestore == v1beta1.CleanupTypeNone {
+	if getCleanupType(acmRestore) == v1beta1.CleanupTypeNone {
 		// request to not process cleanup, return now
 		return processed
 	}
 	restoreCompleted := (acmRestore.Status.Phase == v1beta1.RestorePhaseFinished ||
 		acmRestore.Status.Phase == v1beta1.RestorePhaseFinishedWithErrors)
+	if acmRestore.Spec.PointInTimeRecovery && acmRestore.Status.Phase != v1beta1.RestorePhaseFinished {
+		// with a point-in-time recovery, clean up the hub only after all velero restores completed
+		return processed
+	}
 
 	if cleanupOnRestore || restoreCompleted {
 		// clean up delta resources, restored resources not created by the latest restore
@@ -204,11 +1078,24 @@ func cleanupDeltaResources(
 		logger := log.FromContext(ctx)
 		logger.Info("enter cleanupDeltaResources ")
 
+		// protect the managed cluster namespaces, including the ones missing the OCM label
+		if clusterNamespaces, unlabeledNamespaces, err := getManagedClusterNamespaces(ctx, c); err != nil {
+			logger.Error(err, "Error getting the managed cluster namespaces")
+		} else {
+			restoreOptions.managedClusterNamespaces = clusterNamespaces
+			setNamespaceLabelMissingCondition(acmRestore, unlabeledNamespaces)
+			if len(unlabeledNamespaces) > 0 {
+				logger.Info("Warning - managed cluster namespaces are missing the managed cluster label",
+					"label", OCMManagedClusterNamespaceLabelKey,
+					"namespaces", unlabeledNamespaces)
+			}
+		}
+
 		// clean up credentials
 		backupName, veleroBackup := getBackupInfoFromRestore(ctx, c,
 			acmRestore.Status.VeleroCredentialsRestoreName, acmRestore.Namespace)
 		cleanupDeltaForCredentials(ctx, c,
-			backupName, veleroBackup, acmRestore.Spec.CleanupBeforeRestore,
+			backupName, veleroBackup, getCleanupType(acmRestore),
 			*acmRestore.Spec.VeleroManagedClustersBackupName != skipRestoreStr)
 
 		// clean up resources and generic resources
@@ -225,6 +1112,31 @@ func cleanupDeltaResources(
 	return processed
 }
 
+// set the ManagedClusterNamespaceLabelMissing condition, listing the managed cluster
+// namespaces with no OCMManagedClusterNamespaceLabelKey label
+func setNamespaceLabelMissingCondition(
+	acmRestore *v1beta1.Restore,
+	unlabeledNamespaces []string,
+) {
+	if len(unlabeledNamespaces) == 0 {
+		meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+			Type:               v1beta1.RestoreManagedClusterNamespaceLabelMissing,
+			Status:             metav1.ConditionFalse,
+			Reason:             v1beta1.RestoreReasonNamespaceLabelFound,
+			ObservedGeneration: acmRestore.Generation,
+		})
+		return
+	}
+	meta.SetStatusCondition(&acmRestore.Status.Conditions, metav1.Condition{
+		Type:   v1beta1.RestoreManagedClusterNamespaceLabelMissing,
+		Status: metav1.ConditionTrue,
+		Reason: v1beta1.RestoreReasonNamespaceLabelMissing,
+		Message: fmt.Sprintf("Managed cluster namespaces %v don't have the %s label",
+			unlabeledNamespaces, OCMManagedClusterNamespaceLabelKey),
+		ObservedGeneration: acmRestore.Generation,
+	})
+}
+
 func cleanupDeltaForCredentials(
 	ctx context.Context,
 	c client.Client,
@@ -323,7 +1235,7 @@ func deleteSecretsForBackupType(
 		if backupName, _, _ := getVeleroBackupName(ctx, c, relatedVeleroBackup.Namespace,
 			backupType,
 			relatedVeleroBackup.Name,
-			veleroBackups); backupName != "" {
+			veleroBackups, nil, nil); backupName != "" {
 			deleteSecretsWithLabelSelector(ctx, c, backupName, cleanupType, secretsSelector)
 		}
 	}
@@ -452,6 +1364,7 @@ func deleteDynamicResourcesForBackup(
 	}
 	labelSelector := fmt.Sprintf("%s, %s notin (%s), %s",
 		BackupNameVeleroLabel, BackupNameVeleroLabel, backupName, genericLabel)
+	mappings := []*meta.RESTMapping{}
 	if restoreOptions.cleanupType == v1beta1.CleanupTypeAll {
 		// get all resources, including user created
 		labelSelector = genericLabel
@@ -468,6 +1381,12 @@ func deleteDynamicResourcesForBackup(
 			continue
 		}
 
+		if kind == "clustermanagementaddon" && restoreOptions.cleanupType == v1beta1.CleanupTypeAll {
+			// the hub addon managers create their own ClusterManagementAddOn resources,
+			// don't delete them when cleaning up the resources not created by a restore
+			continue
+		}
+
 		if kind == "clusterdeployment" || kind == "machinepool" {
 			// old backups have a short version for these resource
 			groupName = "hive.openshift.io"
@@ -483,14 +1402,190 @@ func deleteDynamicResourcesForBackup(
 				groupKind, err.Error()))
 			continue
 		}
-		err = invokeDynamicDelete(ctx, c, restoreOptions, labelSelector, veleroBackup, mapping)
+		mappings = append(mappings, mapping)
+	}
+
+	if restoreOptions.cleanupScopeToBackup {
+		restoreOptions.backupNamespaces = getBackupNamespaces(ctx, restoreOptions, veleroBackup, mappings)
+		logger.Info("cleanup scoped to the backup namespaces",
+			"backup", backupName, "namespaces", restoreOptions.backupNamespaces)
+	}
+
+	// delete the dependent resources before their parents
+	sortCleanupMappings(mappings, restoreOptions.cleanupResourceOrder)
+
+	for _, mapping := range mappings {
+		err := invokeDynamicDelete(ctx, c, restoreOptions, labelSelector, veleroBackup, mapping)
 		// Log err and keep going
 		if err != nil {
-			logger.Error(err, "Error with invokeDynamicDelete", "groupKind", groupKind)
+			logger.Error(err, "Error with invokeDynamicDelete", "groupKind", mapping.GroupVersionKind.GroupKind())
 		}
 	}
 }
 
+// sort the resource kinds cleaned up for a backup: the kinds listed by the CleanupResourceOrder option
+// before the "*" entry are deleted first and the ones listed after it are deleted last, in the listed order;
+// the other kinds are deleted children before parents, using getDefaultCleanupRank
+func sortCleanupMappings(
+	mappings []*meta.RESTMapping,
+	cleanupResourceOrder []string,
+) {
+	wildcard := len(cleanupResourceOrder)
+	if index, found := find(cleanupResourceOrder, "*"); found {
+		wildcard = index
+	}
+
+	getRank := func(mapping *meta.RESTMapping) int {
+		kind := strings.ToLower(mapping.GroupVersionKind.Kind)
+		for i, entry := range cleanupResourceOrder {
+			entryKind, entryGroup := getResourceDetails(strings.ToLower(entry))
+			if i == wildcard || entryKind != kind ||
+				(entryGroup != "" && entryGroup != mapping.GroupVersionKind.Group) {
+				continue
+			}
+			if i < wildcard {
+				// deleted first, before all default ranks
+				return i - len(cleanupResourceOrder)
+			}
+			// deleted last, after all default ranks
+			return maxDefaultCleanupRank + 1 + i
+		}
+		return getDefaultCleanupRank(mapping)
+	}
+
+	sort.SliceStable(mappings, func(i, j int) bool {
+		return getRank(mappings[i]) < getRank(mappings[j])
+	})
+}
+
+// returns true if the resource kind is listed by the CleanupForceDeleteKinds option,
+// as a kind or as a kind with the api group
+func isCleanupForceDeleteKind(
+	mapping *meta.RESTMapping,
+	cleanupForceDeleteKinds []string,
+) bool {
+	kind := strings.ToLower(mapping.GroupVersionKind.Kind)
+	for _, entry := range cleanupForceDeleteKinds {
+		entryKind, entryGroup := getResourceDetails(strings.ToLower(entry))
+		if entryKind == kind && (entryGroup == "" || entryGroup == mapping.GroupVersionKind.Group) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the default cleanup rank of a resource kind, the kinds with a lower rank are deleted first:
+// namespaced resources, then the known parent kinds, cluster scoped resources and namespaces last
+func getDefaultCleanupRank(
+	mapping *meta.RESTMapping,
+) int {
+	kind := strings.ToLower(mapping.GroupVersionKind.Kind)
+	switch {
+	case mapping.GroupVersionKind.Kind == namespaceKind:
+		return maxDefaultCleanupRank
+	case mapping.Scope.Name() != meta.RESTScopeNameNamespace:
+		return 2
+	case findValue(cleanupParentKinds, kind):
+		return 1
+	}
+	return 0
+}
+
+// returns the namespaces stored by the backup: the IncludedNamespaces of the backup if set,
+// otherwise the namespaces of the resources restored from this backup
+func getBackupNamespaces(
+	ctx context.Context,
+	restoreOptions RestoreOptions,
+	veleroBackup *veleroapi.Backup,
+	mappings []*meta.RESTMapping,
+) []string {
+	logger := log.FromContext(ctx)
+
+	includedNamespaces := veleroBackup.Spec.IncludedNamespaces
+	if len(includedNamespaces) > 0 && !findValue(includedNamespaces, "*") {
+		namespaces := append([]string{}, includedNamespaces...)
+		sort.Strings(namespaces)
+		return namespaces
+	}
+
+	namespaces := []string{}
+	listOptions := v1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", BackupNameVeleroLabel, veleroBackup.Name),
+	}
+	for _, mapping := range mappings {
+		dynamiclist, err := restoreOptions.dynamicArgs.dyn.Resource(mapping.Resource).List(ctx, listOptions)
+		if err != nil {
+			logger.Info("Failed to list the restored resources", "resource", mapping.Resource.String(),
+				"error", err.Error())
+			continue
+		}
+		for i := range dynamiclist.Items {
+			item := dynamiclist.Items[i]
+			if mapping.GroupVersionKind.Kind == namespaceKind {
+				namespaces = appendUnique(namespaces, item.GetName())
+			} else if item.GetNamespace() != "" {
+				namespaces = appendUnique(namespaces, item.GetNamespace())
+			}
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// returns the resources from the given namespaces; cluster scoped resources
+// are returned unless they are namespaces not found in the given list
+func filterResourcesByNamespace(
+	mapping *meta.RESTMapping,
+	resources []unstructured.Unstructured,
+	namespaces []string,
+) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for i := range resources {
+		resource := resources[i]
+		switch {
+		case mapping.Scope.Name() == meta.RESTScopeNameNamespace:
+			if !findValue(namespaces, resource.GetNamespace()) {
+				continue
+			}
+		case mapping.GroupVersionKind.Kind == namespaceKind:
+			if !findValue(namespaces, resource.GetName()) {
+				continue
+			}
+		}
+		filtered = append(filtered, resource)
+	}
+	return filtered
+}
+
+// returns true if the namespace is owned by the platform: default, openshift, openshift-* or kube-*
+func isInfraNamespace(namespace string) bool {
+	return namespace == "default" || namespace == "openshift" ||
+		strings.HasPrefix(namespace, "openshift-") || strings.HasPrefix(namespace, "kube-")
+}
+
+// remove from the resources list the infra namespaces and the resources from these namespaces
+func filterInfraNamespaceResources(
+	mapping *meta.RESTMapping,
+	resources []unstructured.Unstructured,
+) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for i := range resources {
+		resource := resources[i]
+		switch {
+		case mapping.Scope.Name() == meta.RESTScopeNameNamespace:
+			if isInfraNamespace(resource.GetNamespace()) {
+				continue
+			}
+		case mapping.GroupVersionKind.Kind == namespaceKind:
+			if isInfraNamespace(resource.GetName()) {
+				continue
+			}
+		}
+		filtered = append(filtered, resource)
+	}
+	return filtered
+}
+
 func invokeDynamicDelete(
 	ctx context.Context,
 	c client.Client,
@@ -512,6 +1607,7 @@ func invokeDynamicDelete(
 		}
 		if dynamiclist, err := dr.List(ctx, listOptions); err == nil {
 			// get all items and delete them
+			itemsToDelete := []unstructured.Unstructured{}
 			for i := range dynamiclist.Items {
 				item := dynamiclist.Items[i]
 				if restoreOptions.cleanupType == v1beta1.CleanupTypeAll &&
@@ -519,21 +1615,109 @@ func invokeDynamicDelete(
 					// exclude here resources with the same backup as the last restore
 					continue
 				}
+				itemsToDelete = append(itemsToDelete, item)
+			}
+			if restoreOptions.cleanupScopeToBackup {
+				// leave untouched the namespaces the backup doesn't know about
+				itemsToDelete = filterResourcesByNamespace(mapping, itemsToDelete, restoreOptions.backupNamespaces)
+			}
+			if restoreOptions.protectInfraNamespaces {
+				// never delete the platform resources with a point-in-time recovery
+				itemsToDelete = filterInfraNamespaceResources(mapping, itemsToDelete)
+			}
 
-				deleteDynamicResource(
+			excludedNamespaces := append([]string{}, veleroBackup.Spec.ExcludedNamespaces...)
+			if restoreOptions.veleroNamespace != "" {
+				// never delete resources from the velero namespace, used by the running restore
+				excludedNamespaces = appendUnique(excludedNamespaces, restoreOptions.veleroNamespace)
+			}
+			if mapping.GroupVersionKind.Kind == namespaceKind {
+				// never delete the managed cluster namespaces
+				excludedNamespaces = append(excludedNamespaces, restoreOptions.managedClusterNamespaces...)
+			}
+
+			// skip the resources with the ExcludeBackupLabel, unless the kind is listed
+			// by the CleanupForceDeleteKinds option and all resources are cleaned up
+			skipExcludedBackupLabel := restoreOptions.cleanupType != v1beta1.CleanupTypeAll ||
+				!isCleanupForceDeleteKind(mapping, restoreOptions.cleanupForceDeleteKinds)
+
+			return deleteDynamicResources(
+				ctx,
+				mapping,
+				dr,
+				itemsToDelete,
+				excludedNamespaces,
+				localClusterName,
+				skipExcludedBackupLabel,
+				restoreOptions.cleanupConcurrency,
+				restoreOptions.cleanupCreatedBefore,
+			)
+		}
+	}
+
+	return nil
+}
+
+// delete resources using a bounded number of parallel workers
+// a failure to delete one resource doesn't stop the other deletes;
+// all errors are returned as an aggregated error
+func deleteDynamicResources(
+	ctx context.Context,
+	mapping *meta.RESTMapping,
+	dr dynamic.NamespaceableResourceInterface,
+	resources []unstructured.Unstructured,
+	excludedNamespaces []string,
+	localClusterName string,
+	skipExcludedBackupLabel bool,
+	concurrency int,
+	createdBefore *metav1.Time,
+) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// never clean up resources from the operator namespace
+	if operatorNamespace := getOperatorNamespace(); operatorNamespace != "" {
+		excludedNamespaces = appendUnique(append([]string{}, excludedNamespaces...), operatorNamespace)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	items := make(chan unstructured.Unstructured)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if _, errMsg := deleteDynamicResource(
 					ctx,
 					mapping,
 					dr,
 					item,
-					veleroBackup.Spec.ExcludedNamespaces,
+					excludedNamespaces,
 					localClusterName,
-					true, // skip resource if ExcludeBackupLabel is set
-				)
+					skipExcludedBackupLabel,
+					createdBefore,
+				); errMsg != "" {
+					mu.Lock()
+					errs = append(errs, errors.New(errMsg))
+					mu.Unlock()
+				}
 			}
-		}
+		}()
 	}
 
-	return nil
+	for i := range resources {
+		items <- resources[i]
+	}
+	close(items)
+	wg.Wait()
+
+	return utilerrors.NewAggregate(errs)
 }
 
 // get the backup used by this restore
@@ -563,6 +1747,8 @@ func getBackupInfoFromRestore(
 }
 
 // activate managed clusters by creating auto-import-secret
+// clusters in the activatedClusters list were activated by a previous run and are skipped
+// onActivated, if set, is called for each cluster with a newly created auto-import-secret
 //
 //nolint:funlen
 func postRestoreActivation(
@@ -572,7 +1758,11 @@ func postRestoreActivation(
 	managedClusters []clusterv1.ManagedCluster,
 	localClusterName string,
 	currentTime time.Time,
-) ([]string, []string) {
+	activatedClusters []string,
+	secretName string,
+	secretLabel string,
+	onActivated func(clusterName string),
+) ([]string, []string, []string) {
 	logger := log.FromContext(ctx)
 	logger.Info("enter postRestoreActivation")
 	// return the list of auto import secrets created here
@@ -581,6 +1771,8 @@ func postRestoreActivation(
 	activationMessages := []string{}
 
 	processedClusters := []string{}
+	// clusters with an auto import secret token which cannot be used
+	invalidTokenClusters := []string{}
 	for s := range msaSecrets {
 		secret := msaSecrets[s]
 
@@ -597,12 +1789,21 @@ func postRestoreActivation(
 				secret.Namespace, secret.Name)
 			activationMessages = append(activationMessages, msg)
 			logger.Info(msg)
+			invalidTokenClusters = appendUnique(invalidTokenClusters, clusterName)
 			continue
 		}
 
 		// found a valid access token for this cluster name, add it to the list
 		processedClusters = append(processedClusters, clusterName)
 
+		if findValue(activatedClusters, clusterName) {
+			// auto-import-secret created by a previous activation run, don't re-create it
+			msg := fmt.Sprintf("Cluster (%s) already activated", clusterName)
+			activationMessages = append(activationMessages, msg)
+			logger.Info(msg)
+			continue
+		}
+
 		reimport, url, message := managedClusterShouldReimport(ctx, managedClusters, clusterName)
 		if message != "" {
 			activationMessages = append(activationMessages, message)
@@ -622,13 +1823,13 @@ func postRestoreActivation(
 		// see if an auto-import-secret already exists
 		// delete and re-create if is from a previous post-restore activation
 		secretIdentity := types.NamespacedName{
-			Name:      autoImportSecretName,
+			Name:      secretName,
 			Namespace: clusterName,
 		}
 		autoImportSecret := &corev1.Secret{}
 		if err := c.Get(ctx, secretIdentity, autoImportSecret); err == nil &&
 			autoImportSecret.GetLabels() != nil &&
-			autoImportSecret.GetLabels()[activateLabel] == "true" {
+			autoImportSecret.GetLabels()[secretLabel] == "true" {
 
 			msg := fmt.Sprintf(
 				"failed to delete the auto-import-secret from namespace %s",
@@ -643,7 +1844,7 @@ func postRestoreActivation(
 		}
 
 		// create an auto-import-secret for this managed cluster
-		if err := createAutoImportSecret(ctx, c, clusterName, accessToken, url); err != nil {
+		if err := createAutoImportSecret(ctx, c, clusterName, accessToken, url, secretName, secretLabel); err != nil {
 			msg := fmt.Sprintf("Failed to create auto-import-secret for (%s)",
 				clusterName)
 			activationMessages = append(activationMessages, msg)
@@ -654,11 +1855,39 @@ func postRestoreActivation(
 				clusterName)
 			activationMessages = append(activationMessages, msg)
 			logger.Info(msg)
+			if onActivated != nil {
+				onActivated(clusterName)
+			}
 		}
 	}
 	logger.Info("exit postRestoreActivation")
 
-	return autoImportSecretsCreated, activationMessages
+	// report only the clusters with no valid token
+	invalidImportTokens := []string{}
+	for _, clusterName := range invalidTokenClusters {
+		if !findValue(processedClusters, clusterName) {
+			invalidImportTokens = append(invalidImportTokens, clusterName)
+		}
+	}
+	sort.Strings(invalidImportTokens)
+
+	return autoImportSecretsCreated, activationMessages, invalidImportTokens
+}
+
+// returns the name of the auto import secrets created by the managed clusters activation
+// and the label set on these secrets, using the AutoImportSecretName and ActivationLabel options if set
+func getActivationNames(
+	acmRestore *v1beta1.Restore,
+) (string, string) {
+	secretName := autoImportSecretName
+	if acmRestore.Spec.AutoImportSecretName != "" {
+		secretName = acmRestore.Spec.AutoImportSecretName
+	}
+	secretLabel := activateLabel
+	if acmRestore.Spec.ActivationLabel != "" {
+		secretLabel = acmRestore.Spec.ActivationLabel
+	}
+	return secretName, secretLabel
 }
 
 // create an autoImportSecret using the url and accessToken
@@ -668,14 +1897,16 @@ func createAutoImportSecret(
 	namespace string,
 	accessToken string,
 	url string,
+	secretName string,
+	secretLabel string,
 ) error {
 	autoImportSecret := &corev1.Secret{}
-	autoImportSecret.Name = autoImportSecretName
+	autoImportSecret.Name = secretName
 	autoImportSecret.Namespace = namespace
 	autoImportSecret.Type = corev1.SecretTypeOpaque
 	// set labels
 	labels := make(map[string]string)
-	labels[activateLabel] = "true"
+	labels[secretLabel] = "true"
 	autoImportSecret.SetLabels(labels)
 	// add annotation to keep secret
 	annotations := make(map[string]string)
@@ -691,6 +1922,17 @@ func createAutoImportSecret(
 	return c.Create(ctx, autoImportSecret, &client.CreateOptions{})
 }
 
+// returns the cleanup type used by the restore, the PointInTimeRecovery option
+// cleans up all resources not restored from the backups
+func getCleanupType(
+	acmRestore *v1beta1.Restore,
+) v1beta1.CleanupType {
+	if acmRestore.Spec.PointInTimeRecovery {
+		return v1beta1.CleanupTypeAll
+	}
+	return acmRestore.Spec.CleanupBeforeRestore
+}
+
 func isValidCleanupOption(
 	acmRestore *v1beta1.Restore,
 ) string {
@@ -723,6 +1965,7 @@ func deleteDynamicResource(
 	excludedNamespaces []string,
 	localClusterName string, /* may be "" if no local cluster */
 	skipExcludedBackupLabel bool,
+	createdBefore *metav1.Time, /* may be nil if resources are deleted regardless of the creation time */
 ) (bool, string) {
 	logger := log.FromContext(ctx)
 
@@ -735,8 +1978,9 @@ func deleteDynamicResource(
 	if isResourceLocalCluster(&resource) ||
 		(mapping.Scope.Name() == meta.RESTScopeNameNamespace &&
 			(resource.GetNamespace() == localClusterName ||
-				findValue(excludedNamespaces, resource.GetNamespace()))) {
-		// do not clean up local-cluster resources or resources from excluded NS
+				findValue(excludedNamespaces, resource.GetNamespace()))) ||
+		(resource.GetKind() == namespaceKind && findValue(excludedNamespaces, resource.GetName())) {
+		// do not clean up local-cluster resources, resources from excluded NS or the excluded NS
 		logger.Info(nsSkipMsg)
 		return false, ""
 	}
@@ -750,6 +1994,13 @@ func deleteDynamicResource(
 		return false, ""
 	}
 
+	creationTime := resource.GetCreationTimestamp()
+	if createdBefore != nil && createdBefore.Before(&creationTime) {
+		// do not clean up resources created after the cutoff time
+		logger.Info(nsSkipMsg)
+		return false, ""
+	}
+
 	nsScopedMsg := fmt.Sprintf(
 		"Deleting resource %s [%s.%s]",
 		resource.GetKind(),
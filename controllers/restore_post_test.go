@@ -18,18 +18,25 @@ limitations under the License.
 package controllers
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	"github.com/vmware-tanzu/velero/pkg/util/results"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -41,13 +48,17 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	discoveryclient "k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
+	discoveryfake "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -105,9 +116,10 @@ func Test_postRestoreActivation(t *testing.T) {
 		currentTime     time.Time
 	}
 	tests := []struct {
-		name string
-		args args
-		want []string
+		name        string
+		args        args
+		want        []string
+		wantInvalid []string
 	}{
 		{
 			name: "create NO auto import secrets, managed1 is active",
@@ -239,16 +251,74 @@ func Test_postRestoreActivation(t *testing.T) {
 						}),
 				},
 			},
-			want: []string{"managed1"},
+			want:        []string{"managed1"},
+			wantInvalid: []string{},
+		},
+		{
+			name: "report managed3 with an expired token, create auto import for managed1 cluster",
+			args: args{
+				ctx:         context.Background(),
+				currentTime: current,
+				managedClusters: []clusterv1.ManagedCluster{
+					*createManagedCluster("local-cluster", true).object,
+					*createManagedCluster("managed1", false).clusterUrl("someurl").
+						conditions([]metav1.Condition{
+							v1.Condition{
+								Status: v1.ConditionFalse,
+							},
+						}).
+						object,
+					*createManagedCluster("managed3", false).clusterUrl("someurl").
+						conditions([]metav1.Condition{
+							v1.Condition{
+								Status: v1.ConditionFalse,
+							},
+						}).
+						object,
+				},
+				secrets: []corev1.Secret{
+					// expired token, managed1 has another valid token
+					*createSecret("auto-import-expired", "managed1",
+						nil, map[string]string{
+							"lastRefreshTimestamp": fourHoursAgo,
+							"expirationTimestamp":  fourHoursAgo,
+						}, map[string][]byte{
+							"token": []byte("YWRtaW4="),
+						}),
+					*createSecret("auto-import-account", "managed1",
+						nil, map[string]string{
+							"lastRefreshTimestamp": fourHoursAgo,
+							"expirationTimestamp":  nextTenHours,
+						}, map[string][]byte{
+							"token": []byte("YWRtaW4="),
+						}),
+					// expired token, no other token for managed3
+					*createSecret("auto-import-account", "managed3",
+						nil, map[string]string{
+							"lastRefreshTimestamp": fourHoursAgo,
+							"expirationTimestamp":  fourHoursAgo,
+						}, map[string][]byte{
+							"token": []byte("YWRtaW4="),
+						}),
+				},
+			},
+			want:        []string{"managed1"},
+			wantInvalid: []string{"managed3"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got, _ := postRestoreActivation(tt.args.ctx, k8sClient1,
-				tt.args.secrets, tt.args.managedClusters, "local-cluster", tt.args.currentTime); len(got) != len(tt.want) {
+			got, _, gotInvalid := postRestoreActivation(tt.args.ctx, k8sClient1,
+				tt.args.secrets, tt.args.managedClusters, "local-cluster", tt.args.currentTime,
+				nil, autoImportSecretName, activateLabel, nil)
+			if len(got) != len(tt.want) {
 				t.Errorf("postRestoreActivation() returns = %v, want %v", got, tt.want)
 			}
+			if tt.wantInvalid != nil && !reflect.DeepEqual(gotInvalid, tt.wantInvalid) {
+				t.Errorf("postRestoreActivation() invalid import tokens = %v, want %v",
+					gotInvalid, tt.wantInvalid)
+			}
 		})
 	}
 
@@ -257,6 +327,107 @@ func Test_postRestoreActivation(t *testing.T) {
 	}
 }
 
+func Test_postRestoreActivation_customNames(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding apis to scheme: %s", err.Error())
+	}
+
+	secretName := "custom-import-secret"
+	secretLabel := "example.com/restore-import"
+	fourHoursAgo := "2022-07-26T11:25:34Z"
+	nextTenHours := "2022-07-27T04:25:34Z"
+	current, _ := time.Parse(time.RFC3339, "2022-07-26T15:25:34Z")
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(
+		// created by a previous activation, replaced
+		createSecret(secretName, "managed1", map[string]string{secretLabel: "true"}, nil,
+			map[string][]byte{"token": []byte("old")}),
+		// not created by an activation, left untouched
+		createSecret(secretName, "managed2", nil, nil, map[string][]byte{"token": []byte("user")}),
+	).Build()
+
+	managedClusters := []clusterv1.ManagedCluster{}
+	msaSecrets := []corev1.Secret{}
+	for _, name := range []string{"managed1", "managed2", "managed3"} {
+		managedClusters = append(managedClusters, *createManagedCluster(name, false).clusterUrl("someurl").
+			conditions([]metav1.Condition{{Status: v1.ConditionFalse}}).object)
+		msaSecrets = append(msaSecrets, *createSecret("auto-import-account", name,
+			nil, map[string]string{
+				"lastRefreshTimestamp": fourHoursAgo,
+				"expirationTimestamp":  nextTenHours,
+			}, map[string][]byte{
+				"token": []byte("YWRtaW4="),
+			}))
+	}
+
+	got, _, _ := postRestoreActivation(context.Background(), fakeClient, msaSecrets, managedClusters,
+		"local-cluster", current, nil, secretName, secretLabel, nil)
+	if want := []string{"managed1", "managed3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("postRestoreActivation() returns = %v, want %v", got, want)
+	}
+
+	for _, ns := range []string{"managed1", "managed3"} {
+		secret := &corev1.Secret{}
+		if err := fakeClient.Get(context.Background(),
+			types.NamespacedName{Name: secretName, Namespace: ns}, secret); err != nil {
+			t.Fatalf("auto import secret not created in %s: %v", ns, err)
+		}
+		if secret.Labels[secretLabel] != "true" || secret.StringData["token"] != "YWRtaW4=" {
+			t.Errorf("auto import secret in %s has labels %v, token %s", ns, secret.Labels,
+				secret.StringData["token"])
+		}
+		if err := fakeClient.Get(context.Background(),
+			types.NamespacedName{Name: autoImportSecretName, Namespace: ns}, &corev1.Secret{}); err == nil {
+			t.Errorf("unexpected %s secret in %s", autoImportSecretName, ns)
+		}
+	}
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(context.Background(),
+		types.NamespacedName{Name: secretName, Namespace: "managed2"}, secret); err != nil {
+		t.Fatalf("secret not found in managed2: %v", err)
+	}
+	if string(secret.Data["token"]) != "user" {
+		t.Errorf("secret in managed2 was replaced")
+	}
+}
+
+func Test_getActivationNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		restore   *v1beta1.Restore
+		wantName  string
+		wantLabel string
+	}{
+		{
+			name:      "defaults",
+			restore:   createACMRestore("restore", "ns").object,
+			wantName:  autoImportSecretName,
+			wantLabel: activateLabel,
+		},
+		{
+			name: "customized names",
+			restore: func() *v1beta1.Restore {
+				restore := createACMRestore("restore", "ns").object
+				restore.Spec.AutoImportSecretName = "custom-import-secret"
+				restore.Spec.ActivationLabel = "example.com/restore-import"
+				return restore
+			}(),
+			wantName:  "custom-import-secret",
+			wantLabel: "example.com/restore-import",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, gotLabel := getActivationNames(tt.restore)
+			if gotName != tt.wantName || gotLabel != tt.wantLabel {
+				t.Errorf("getActivationNames() = %v, %v, want %v, %v", gotName, gotLabel,
+					tt.wantName, tt.wantLabel)
+			}
+		})
+	}
+}
+
 func Test_executePostRestoreTasks(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
@@ -291,6 +462,18 @@ func Test_executePostRestoreTasks(t *testing.T) {
 		obs_addon_ns, map[string]string{}, nil, nil)); err != nil {
 		t.Fatalf("failed to create secret %s ", err.Error())
 	}
+	// the auto-import token of managed-expired cannot be used to activate the cluster
+	fourHoursAgo := time.Now().In(time.UTC).Add(-4 * time.Hour).Format(time.RFC3339)
+	if err := k8sClient1.Create(context.Background(), createNamespace("managed-expired")); err != nil {
+		t.Fatalf("failed to create namespace %s ", err.Error())
+	}
+	if err := k8sClient1.Create(context.Background(), createSecret(msa_service_name, "managed-expired",
+		map[string]string{msa_label: "true"}, map[string]string{
+			"lastRefreshTimestamp": fourHoursAgo,
+			"expirationTimestamp":  fourHoursAgo,
+		}, map[string][]byte{"token": []byte("YWRtaW4=")})); err != nil {
+		t.Fatalf("failed to create secret %s ", err.Error())
+	}
 
 	type args struct {
 		ctx     context.Context
@@ -298,9 +481,10 @@ func Test_executePostRestoreTasks(t *testing.T) {
 		restore *v1beta1.Restore
 	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name        string
+		args        args
+		want        bool
+		wantInvalid []string
 	}{
 		{
 			name: "post activation  should NOT run now, managed clusters are skipped",
@@ -326,7 +510,8 @@ func Test_executePostRestoreTasks(t *testing.T) {
 					veleroResourcesBackupName(latestBackupStr).
 					phase(v1beta1.RestorePhaseFinished).object,
 			},
-			want: true,
+			want:        true,
+			wantInvalid: []string{"managed-expired"},
 		},
 	}
 
@@ -336,6 +521,10 @@ func Test_executePostRestoreTasks(t *testing.T) {
 				tt.args.restore); got != tt.want {
 				t.Errorf("executePostRestoreTasks() returns = %v, want %v", got, tt.want)
 			}
+			if !reflect.DeepEqual(tt.args.restore.Status.InvalidImportTokens, tt.wantInvalid) {
+				t.Errorf("executePostRestoreTasks() InvalidImportTokens = %v, want %v",
+					tt.args.restore.Status.InvalidImportTokens, tt.wantInvalid)
+			}
 		})
 	}
 
@@ -484,6 +673,15 @@ func Test_deleteDynamicResource(t *testing.T) {
 		},
 	})
 
+	// Channel resources created before and after the cleanup cutoff time
+	cutoffTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	res_created_recent := res_default.DeepCopy()
+	res_created_recent.SetName("channel-new-default-recent")
+	res_created_recent.SetCreationTimestamp(metav1.NewTime(time.Now()))
+	res_created_old := res_default.DeepCopy()
+	res_created_old.SetName("channel-new-default-old")
+	res_created_old.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-2 * time.Hour)))
+
 	unstructuredScheme := runtime.NewScheme()
 	err := chnv1.AddToScheme(unstructuredScheme)
 	if err != nil {
@@ -521,6 +719,14 @@ func Test_deleteDynamicResource(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Err creating: %s", err.Error())
 	}
+	_, err = resInterface.Namespace("default").Create(context.Background(), res_created_recent, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Err creating: %s", err.Error())
+	}
+	_, err = resInterface.Namespace("default").Create(context.Background(), res_created_old, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Err creating: %s", err.Error())
+	}
 	_, err = resInterface.Create(context.Background(), res_global, v1.CreateOptions{})
 	if err != nil {
 		t.Fatalf("Err creating: %s", err.Error())
@@ -544,6 +750,7 @@ func Test_deleteDynamicResource(t *testing.T) {
 		deleteOptions           v1.DeleteOptions
 		excludedNamespaces      []string
 		skipExcludedBackupLabel bool
+		createdBefore           *metav1.Time
 	}
 	tests := []struct {
 		name        string
@@ -551,6 +758,38 @@ func Test_deleteDynamicResource(t *testing.T) {
 		want        bool
 		errMsgEmpty bool
 	}{
+		{
+			name: "Do not delete resource created after the cutoff time",
+			args: args{
+				ctx:                     context.Background(),
+				mapping:                 &targetMapping,
+				dr:                      resInterface,
+				resource:                *res_created_recent,
+				localClusterName:        "local-cluster",
+				deleteOptions:           delOptions,
+				excludedNamespaces:      []string{},
+				skipExcludedBackupLabel: true,
+				createdBefore:           &cutoffTime,
+			},
+			want:        false,
+			errMsgEmpty: true,
+		},
+		{
+			name: "Delete resource created before the cutoff time",
+			args: args{
+				ctx:                     context.Background(),
+				mapping:                 &targetMapping,
+				dr:                      resInterface,
+				resource:                *res_created_old,
+				localClusterName:        "local-cluster",
+				deleteOptions:           delOptions,
+				excludedNamespaces:      []string{},
+				skipExcludedBackupLabel: true,
+				createdBefore:           &cutoffTime,
+			},
+			want:        true,
+			errMsgEmpty: true,
+		},
 		{
 			name: "Delete local cluster resource (local-cluster named 'local-cluster')",
 			args: args{
@@ -726,7 +965,8 @@ func Test_deleteDynamicResource(t *testing.T) {
 				tt.args.resource,
 				tt.args.excludedNamespaces,
 				tt.args.localClusterName,
-				tt.args.skipExcludedBackupLabel); got != tt.want ||
+				tt.args.skipExcludedBackupLabel,
+				tt.args.createdBefore); got != tt.want ||
 				(tt.errMsgEmpty && len(msg) != 0) ||
 				(!tt.errMsgEmpty && len(msg) == 0) {
 				t.Errorf("deleteDynamicResource() = %v, want %v, emptyMsg=%v, msg=%v", got,
@@ -736,254 +976,495 @@ func Test_deleteDynamicResource(t *testing.T) {
 	}
 }
 
-func Test_cleanupDeltaResources(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
+func Test_deleteDynamicResources(t *testing.T) {
+	newChannel := func(name, namespace string, lbls map[string]interface{}) *unstructured.Unstructured {
+		metadata := map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		}
+		if lbls != nil {
+			metadata["labels"] = lbls
+		}
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": "apps.open-cluster-management.io/v1",
+			"kind":       "Channel",
+			"metadata":   metadata,
+			"spec": map[string]interface{}{
+				"type":     "Git",
+				"pathname": "https://github.com/test/app-samples",
+			},
+		})
+		return res
 	}
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
+	targetGVK := schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1", Kind: "Channel"}
+	targetGVR := targetGVK.GroupVersion().WithResource("channels")
+	targetMapping := meta.RESTMapping{
+		Resource: targetGVR, GroupVersionKind: targetGVK,
+		Scope: meta.RESTScopeNamespace,
 	}
-	scheme1 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme1)
-	if err != nil {
+
+	unstructuredScheme := runtime.NewScheme()
+	if err := chnv1.AddToScheme(unstructuredScheme); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error creating new client: %s", err.Error())
-	}
 
-	type args struct {
-		ctx              context.Context
-		c                client.Client
-		restore          *v1beta1.Restore
-		cleanupOnRestore bool
-		restoreOptions   RestoreOptions
-	}
+	t.Setenv(operatorNamespaceEnv, "operator-ns")
+
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name        string
+		concurrency int
+		forceDelete bool
 	}{
 		{
-			name: "post activation  should NOT run now, CleanupTypeNone",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				restore: createACMRestore("Restore", "veleroNamespace").
-					cleanupBeforeRestore(v1beta1.CleanupTypeNone).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					phase(v1beta1.RestorePhaseEnabled).object,
-				cleanupOnRestore: true,
-				restoreOptions:   RestoreOptions{},
-			},
-			want: false,
+			name:        "concurrency not set, delete resources one at a time",
+			concurrency: 0,
 		},
 		{
-			name: "post activation  should NOT run now, state is enabled but cleanup is false",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				restore: createACMRestore("Restore", "veleroNamespace").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					phase(v1beta1.RestorePhaseEnabled).object,
-				cleanupOnRestore: false,
-				restoreOptions:   RestoreOptions{},
-			},
-			want: false,
+			name:        "force delete, resources with the exclude label are deleted",
+			concurrency: 1,
+			forceDelete: true,
 		},
 		{
-			name: "post activation  should run now, state is enabled AND cleanup is true",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				restore: createACMRestore("Restore", "veleroNamespace").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					phase(v1beta1.RestorePhaseEnabled).object,
-				cleanupOnRestore: true,
-				restoreOptions:   RestoreOptions{},
-			},
-			want: true,
+			name:        "concurrency set to 1",
+			concurrency: 1,
 		},
 		{
-			name: "post activation should run now, state is Finished - and cleanup is false, not counting",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				restore: createACMRestore("Restore", "veleroNamespace").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					phase(v1beta1.RestorePhaseFinished).object,
-				cleanupOnRestore: false,
-				restoreOptions:   RestoreOptions{},
-			},
-			want: true,
+			name:        "concurrency set to 4",
+			concurrency: 4,
+		},
+		{
+			name:        "concurrency higher than the number of resources",
+			concurrency: 50,
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := cleanupDeltaResources(tt.args.ctx, tt.args.c,
-				tt.args.restore, tt.args.cleanupOnRestore, tt.args.restoreOptions); got != tt.want {
-				t.Errorf("cleanupDeltaResources() returns = %v, want %v", got, tt.want)
+			eligible := []*unstructured.Unstructured{}
+			for i := 0; i < 10; i++ {
+				eligible = append(eligible, newChannel(fmt.Sprintf("channel-%d", i), "default", nil))
+			}
+			skipped := []*unstructured.Unstructured{
+				newChannel("channel-local", "local-cluster", nil),
+				newChannel("channel-excluded-ns", "excluded-ns", nil),
+				newChannel("channel-operator-ns", "operator-ns", nil),
+			}
+			excludedLabel := newChannel("channel-excluded-label", "default", map[string]interface{}{
+				ExcludeBackupLabel: "true",
+			})
+			if tt.forceDelete {
+				eligible = append(eligible, excludedLabel)
+			} else {
+				skipped = append(skipped, excludedLabel)
+			}
+
+			objects := []runtime.Object{}
+			resources := []unstructured.Unstructured{}
+			for _, res := range append(append([]*unstructured.Unstructured{}, eligible...), skipped...) {
+				objects = append(objects, res)
+				resources = append(resources, *res)
+			}
+			dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
+				map[schema.GroupVersionResource]string{targetGVR: "ChannelList"}, objects...)
+			resInterface := dynClient.Resource(targetGVR)
+
+			if err := deleteDynamicResources(context.Background(), &targetMapping, resInterface, resources,
+				[]string{"excluded-ns"}, "local-cluster", !tt.forceDelete, tt.concurrency, nil); err != nil {
+				t.Errorf("deleteDynamicResources() unexpected error %v", err)
+			}
+
+			for _, res := range eligible {
+				if _, err := resInterface.Namespace(res.GetNamespace()).Get(context.Background(),
+					res.GetName(), v1.GetOptions{}); err == nil {
+					t.Errorf("resource %s/%s should be deleted", res.GetNamespace(), res.GetName())
+				}
+			}
+			for _, res := range skipped {
+				if _, err := resInterface.Namespace(res.GetNamespace()).Get(context.Background(),
+					res.GetName(), v1.GetOptions{}); err != nil {
+					t.Errorf("resource %s/%s should not be deleted, got %v", res.GetNamespace(), res.GetName(), err)
+				}
 			}
 		})
 	}
 
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
+	// errors are aggregated and don't stop other deletes
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
+		map[schema.GroupVersionResource]string{targetGVR: "ChannelList"}, newChannel("channel-found", "default", nil))
+	resInterface := dynClient.Resource(targetGVR)
+	err := deleteDynamicResources(context.Background(), &targetMapping, resInterface,
+		[]unstructured.Unstructured{
+			*newChannel("channel-not-found-1", "default", nil),
+			*newChannel("channel-found", "default", nil),
+			*newChannel("channel-not-found-2", "default", nil),
+		}, nil, "", true, 2, nil)
+	if err == nil {
+		t.Errorf("deleteDynamicResources() expected an error for resources not found")
+	}
+	if _, getErr := resInterface.Namespace("default").Get(context.Background(),
+		"channel-found", v1.GetOptions{}); getErr == nil {
+		t.Errorf("channel-found should be deleted even if other deletes failed")
 	}
 }
 
-func Test_getBackupInfoFromRestore(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-
-	namespace := "ns"
-	backupName := "passive-sync-2-acm-credentials-schedule-20220929220007"
-	validRestoreName := "restore-acm-passive-sync-2-acm-credentials-schedule-2022092922123"
-	validRestoreNameWBackup := "restore-acm-" + backupName
-
+func Test_createPostRestoreJob(t *testing.T) {
 	scheme1 := runtime.NewScheme()
-	err := veleroapi.AddToScheme(scheme1)
-	if err != nil {
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = corev1.AddToScheme(scheme1)
-	if err != nil {
+	if err := batchv1.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
+	jobSpec := &v1beta1.PostRestoreJobSpec{
+		Image:              "quay.io/test/smoke:latest",
+		Command:            []string{"/bin/sh", "-c", "echo ok"},
+		ServiceAccountName: "smoke-sa",
 	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error creating new client: %s", err.Error())
+
+	tests := []struct {
+		name          string
+		restore       *v1beta1.Restore
+		wantProcessed bool
+		wantJob       bool
+	}{
+		{
+			name: "no post restore job defined",
+			restore: createACMRestore("restore", "ns").
+				phase(v1beta1.RestorePhaseFinished).object,
+			wantProcessed: false,
+			wantJob:       false,
+		},
+		{
+			name: "restore not completed",
+			restore: createACMRestore("restore", "ns").
+				postRestoreJob(jobSpec).
+				phase(v1beta1.RestorePhaseRunning).object,
+			wantProcessed: false,
+			wantJob:       false,
+		},
+		{
+			name: "restore completed, create job",
+			restore: createACMRestore("restore", "ns").
+				postRestoreJob(jobSpec).
+				phase(v1beta1.RestorePhaseFinished).object,
+			wantProcessed: true,
+			wantJob:       true,
+		},
+		{
+			name: "restore completed with errors, create job",
+			restore: createACMRestore("restore", "ns").
+				postRestoreJob(jobSpec).
+				phase(v1beta1.RestorePhaseFinishedWithErrors).object,
+			wantProcessed: true,
+			wantJob:       true,
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).Build()
 
-	type args struct {
-		ctx         context.Context
-		c           client.Client
-		restoreName string
-		namespace   string
+			if got := createPostRestoreJob(context.Background(), fakeClient, tt.restore); got != tt.wantProcessed {
+				t.Errorf("createPostRestoreJob() = %v, want %v", got, tt.wantProcessed)
+			}
+
+			jobs := &batchv1.JobList{}
+			if err := fakeClient.List(context.Background(), jobs, client.InNamespace("ns")); err != nil {
+				t.Fatalf("Error listing jobs: %s", err.Error())
+			}
+			if (len(jobs.Items) == 1) != tt.wantJob {
+				t.Errorf("createPostRestoreJob() created %v jobs, want job %v", len(jobs.Items), tt.wantJob)
+			}
+			if !tt.wantJob {
+				return
+			}
+
+			job := jobs.Items[0]
+			if job.Name != "restore-post-restore" ||
+				job.Spec.Template.Spec.ServiceAccountName != jobSpec.ServiceAccountName ||
+				job.Spec.Template.Spec.Containers[0].Image != jobSpec.Image ||
+				!reflect.DeepEqual(job.Spec.Template.Spec.Containers[0].Command, jobSpec.Command) {
+				t.Errorf("createPostRestoreJob() job not created from spec %v", job.Spec)
+			}
+			if owner := metav1.GetControllerOf(&job); owner == nil || owner.Name != "restore" {
+				t.Errorf("createPostRestoreJob() job should be owned by the restore")
+			}
+			if tt.restore.Status.PostRestoreJobStatus == nil ||
+				tt.restore.Status.PostRestoreJobStatus.JobName != job.Name ||
+				tt.restore.Status.PostRestoreJobStatus.Phase != v1beta1.PostRestoreJobPhaseRunning {
+				t.Errorf("createPostRestoreJob() status not set %v", tt.restore.Status.PostRestoreJobStatus)
+			}
+
+			// job is not created again
+			if got := createPostRestoreJob(context.Background(), fakeClient, tt.restore); got {
+				t.Errorf("createPostRestoreJob() should not process an existing job")
+			}
+		})
+	}
+}
+
+func Test_updatePostRestoreJobStatus(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := batchv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	newJob := func(conditionType batchv1.JobConditionType) *batchv1.Job {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "restore-post-restore",
+				Namespace: "ns",
+			},
+		}
+		if conditionType != "" {
+			job.Status.Conditions = []batchv1.JobCondition{
+				{
+					Type:    conditionType,
+					Status:  corev1.ConditionTrue,
+					Message: "job message",
+				},
+			}
+		}
+		return job
+	}
+	newRestore := func(failRestore bool) *v1beta1.Restore {
+		return createACMRestore("restore", "ns").
+			postRestoreJob(&v1beta1.PostRestoreJobSpec{
+				Image:                   "quay.io/test/smoke:latest",
+				FailRestoreOnJobFailure: failRestore,
+			}).
+			restoreACMStatus(v1beta1.RestoreStatus{
+				Phase: v1beta1.RestorePhaseFinished,
+				PostRestoreJobStatus: &v1beta1.PostRestoreJobStatus{
+					JobName: "restore-post-restore",
+					Phase:   v1beta1.PostRestoreJobPhaseRunning,
+				},
+			}).object
+	}
+
+	tests := []struct {
+		name             string
+		restore          *v1beta1.Restore
+		job              *batchv1.Job
+		wantUpdated      bool
+		wantJobPhase     v1beta1.PostRestoreJobPhase
+		wantRestorePhase v1beta1.RestorePhase
+	}{
+		{
+			name:             "job is running",
+			restore:          newRestore(false),
+			job:              newJob(""),
+			wantUpdated:      false,
+			wantJobPhase:     v1beta1.PostRestoreJobPhaseRunning,
+			wantRestorePhase: v1beta1.RestorePhaseFinished,
+		},
+		{
+			name:             "job completed",
+			restore:          newRestore(true),
+			job:              newJob(batchv1.JobComplete),
+			wantUpdated:      true,
+			wantJobPhase:     v1beta1.PostRestoreJobPhaseSucceeded,
+			wantRestorePhase: v1beta1.RestorePhaseFinished,
+		},
+		{
+			name:             "job failed, restore result does not depend on the job",
+			restore:          newRestore(false),
+			job:              newJob(batchv1.JobFailed),
+			wantUpdated:      true,
+			wantJobPhase:     v1beta1.PostRestoreJobPhaseFailed,
+			wantRestorePhase: v1beta1.RestorePhaseFinished,
+		},
+		{
+			name:             "job failed, restore result depends on the job",
+			restore:          newRestore(true),
+			job:              newJob(batchv1.JobFailed),
+			wantUpdated:      true,
+			wantJobPhase:     v1beta1.PostRestoreJobPhaseFailed,
+			wantRestorePhase: v1beta1.RestorePhaseFinishedWithErrors,
+		},
+		{
+			name:             "job not found",
+			restore:          newRestore(true),
+			job:              nil,
+			wantUpdated:      true,
+			wantJobPhase:     v1beta1.PostRestoreJobPhaseFailed,
+			wantRestorePhase: v1beta1.RestorePhaseFinishedWithErrors,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme1)
+			if tt.job != nil {
+				builder = builder.WithObjects(tt.job)
+			}
+			fakeClient := builder.Build()
+
+			if got := updatePostRestoreJobStatus(context.Background(), fakeClient, tt.restore); got != tt.wantUpdated {
+				t.Errorf("updatePostRestoreJobStatus() = %v, want %v", got, tt.wantUpdated)
+			}
+			if tt.restore.Status.PostRestoreJobStatus.Phase != tt.wantJobPhase {
+				t.Errorf("updatePostRestoreJobStatus() job phase = %v, want %v",
+					tt.restore.Status.PostRestoreJobStatus.Phase, tt.wantJobPhase)
+			}
+			if tt.restore.Status.Phase != tt.wantRestorePhase {
+				t.Errorf("updatePostRestoreJobStatus() restore phase = %v, want %v",
+					tt.restore.Status.Phase, tt.wantRestorePhase)
+			}
+		})
+	}
+}
+
+// fake secret transform, encrypts the secret data by prefixing it with the key
+type fakeSecretTransformer struct {
+	failOn string
+}
+
+func (f fakeSecretTransformer) Transform(_ context.Context, secret *corev1.Secret, keyRef string) error {
+	if secret.Name == f.failOn {
+		return fmt.Errorf("key %s not available", keyRef)
+	}
+	for key, value := range secret.Data {
+		secret.Data[key] = append([]byte(keyRef+":"), value...)
+	}
+	return nil
+}
+
+func Test_transformRestoredSecrets(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	RegisterSecretTransformer("fake-kms", fakeSecretTransformer{failOn: "secret-fail"})
+	defer delete(secretTransformers, "fake-kms")
+
+	credsRestoreName := "restore-acm-credentials"
+	restoredLabels := map[string]string{RestoreNameVeleroLabel: credsRestoreName}
+	newSecrets := func() []client.Object {
+		return []client.Object{
+			createSecret("secret-1", "ns1", restoredLabels, nil,
+				map[string][]byte{"password": []byte("pass1")}),
+			createSecret("secret-2", "ns2", restoredLabels,
+				map[string]string{SecretTransformAnnotation: "fake-kms"},
+				map[string][]byte{"password": []byte("kms-key:pass2")}),
+			createSecret("secret-fail", "ns1", restoredLabels, nil,
+				map[string][]byte{"password": []byte("pass3")}),
+			createSecret("secret-other", "ns1",
+				map[string]string{RestoreNameVeleroLabel: "other-restore"}, nil,
+				map[string][]byte{"password": []byte("pass4")}),
+		}
+	}
+	veleroRestores := func(phase veleroapi.RestorePhase) *veleroapi.RestoreList {
+		return &veleroapi.RestoreList{
+			Items: []veleroapi.Restore{
+				*createRestore(credsRestoreName, "ns").phase(phase).object,
+			},
+		}
+	}
+	newRestore := func(transform *v1beta1.SecretTransformSpec) *v1beta1.Restore {
+		return createACMRestore("restore", "ns").
+			veleroCredentialsRestoreName(credsRestoreName).
+			secretTransform(transform).object
 	}
+	kmsTransform := &v1beta1.SecretTransformSpec{Name: "fake-kms", KeyRef: "kms-key"}
+
 	tests := []struct {
 		name           string
-		args           args
-		wantBackupName string
+		restore        *v1beta1.Restore
+		veleroRestores *veleroapi.RestoreList
+		wantProcessed  bool
+		wantData       map[string]string
+		wantMessages   int
 	}{
 		{
-			name: "restore name is empty",
-			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				restoreName: "",
-				namespace:   namespace,
+			name:           "no secret transform",
+			restore:        newRestore(nil),
+			veleroRestores: veleroRestores(veleroapi.RestorePhaseCompleted),
+			wantProcessed:  false,
+			wantData: map[string]string{
+				"secret-1":     "pass1",
+				"secret-other": "pass4",
 			},
-			wantBackupName: "",
 		},
 		{
-			name: "restore name not found",
-			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				restoreName: "some-restore",
-				namespace:   namespace,
+			name:           "credentials restore not completed",
+			restore:        newRestore(kmsTransform),
+			veleroRestores: veleroRestores(veleroapi.RestorePhaseInProgress),
+			wantProcessed:  false,
+			wantData: map[string]string{
+				"secret-1": "pass1",
 			},
-			wantBackupName: "",
 		},
 		{
-			name: "restore name not found",
-			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				restoreName: "some-restore",
-				namespace:   namespace,
+			name:           "secret transform not available",
+			restore:        newRestore(&v1beta1.SecretTransformSpec{Name: "unknown"}),
+			veleroRestores: veleroRestores(veleroapi.RestorePhaseCompleted),
+			wantProcessed:  false,
+			wantData: map[string]string{
+				"secret-1": "pass1",
 			},
-			wantBackupName: "",
+			wantMessages: 1,
 		},
 		{
-			name: "restore found but no backup",
-			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				restoreName: validRestoreName,
-				namespace:   namespace,
+			name:           "none secret transform",
+			restore:        newRestore(&v1beta1.SecretTransformSpec{Name: v1beta1.SecretTransformNone}),
+			veleroRestores: veleroRestores(veleroapi.RestorePhaseCompleted),
+			wantProcessed:  true,
+			wantData: map[string]string{
+				"secret-1":    "pass1",
+				"secret-fail": "pass3",
 			},
-			wantBackupName: "",
 		},
 		{
-			name: "restore found with backup",
-			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				restoreName: validRestoreNameWBackup,
-				namespace:   namespace,
+			name:           "restored secrets are encrypted",
+			restore:        newRestore(kmsTransform),
+			veleroRestores: veleroRestores(veleroapi.RestorePhaseCompleted),
+			wantProcessed:  true,
+			wantData: map[string]string{
+				"secret-1":     "kms-key:pass1",
+				"secret-2":     "kms-key:pass2",
+				"secret-fail":  "pass3",
+				"secret-other": "pass4",
 			},
-			wantBackupName: backupName,
+			wantMessages: 1,
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(newSecrets()...).
+				Build()
+
+			if got := transformRestoredSecrets(context.Background(), fakeClient,
+				tt.restore, tt.veleroRestores); got != tt.wantProcessed {
+				t.Errorf("transformRestoredSecrets() = %v, want %v", got, tt.wantProcessed)
+			}
+			if len(tt.restore.Status.Messages) != tt.wantMessages {
+				t.Errorf("transformRestoredSecrets() messages = %v, want %v messages",
+					tt.restore.Status.Messages, tt.wantMessages)
+			}
 
-	for index, tt := range tests {
-		if index == 0 {
-			ns1 := *createNamespace(namespace)
-			veleroRestore := *createRestore(validRestoreName,
-				namespace).object
-
-			veleroRestoreWBackup := *createRestore(validRestoreNameWBackup,
-				namespace).
-				backupName(backupName).
-				object
-
-			veleroBackup := *createBackup(backupName, namespace).object
-
-			errs := []error{}
-			errs = append(errs, k8sClient1.Create(tt.args.ctx, &ns1))
-			errs = append(errs, k8sClient1.Create(tt.args.ctx, &veleroRestore))
-			errs = append(errs, k8sClient1.Create(tt.args.ctx, &veleroRestoreWBackup))
-			errs = append(errs, k8sClient1.Create(tt.args.ctx, &veleroBackup))
-			if err := errors.Join(errs...); err != nil {
-				t.Errorf("Error creating objs to setup for test: %s", err.Error())
+			secrets := &corev1.SecretList{}
+			if err := fakeClient.List(context.Background(), secrets); err != nil {
+				t.Fatalf("Error listing secrets: %s", err.Error())
 			}
-		}
-		t.Run(tt.name, func(t *testing.T) {
-			if got, _ := getBackupInfoFromRestore(tt.args.ctx, tt.args.c,
-				tt.args.restoreName, tt.args.namespace); got != tt.wantBackupName {
-				t.Errorf("getBackupInfoFromRestore() returns = %v, want %v", got, tt.wantBackupName)
+			for i := range secrets.Items {
+				secret := secrets.Items[i]
+				want, ok := tt.wantData[secret.Name]
+				if !ok {
+					continue
+				}
+				if got := string(secret.Data["password"]); got != want {
+					t.Errorf("transformRestoredSecrets() secret %s data = %v, want %v", secret.Name, got, want)
+				}
 			}
 		})
-
-	}
-
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
 }
 
-func Test_deleteSecretsWithLabelSelector(t *testing.T) {
+func Test_cleanupDeltaResources(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -992,235 +1473,121 @@ func Test_deleteSecretsWithLabelSelector(t *testing.T) {
 		ErrorIfCRDPathMissing: true,
 	}
 
-	namespace := "ns"
-
-	scheme1 := runtime.NewScheme()
-	err := veleroapi.AddToScheme(scheme1)
+	cfg, err := testEnv.Start()
 	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
+		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
-	err = corev1.AddToScheme(scheme1)
+	scheme1 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme1)
 	if err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
 	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
 	if err != nil {
 		t.Fatalf("Error creating new client: %s", err.Error())
 	}
 
-	ns1 := *createNamespace(namespace)
-	err = k8sClient1.Create(context.Background(), &ns1)
-	if err != nil {
-		t.Fatalf("Error creating ns: %s", err.Error())
-	}
-
-	hiveCredsLabel, _ := labels.NewRequirement(backupCredsHiveLabel,
-		selection.Exists, []string{})
-
 	type args struct {
-		ctx         context.Context
-		c           client.Client
-		backupName  string
-		cleanupType v1beta1.CleanupType
-		otherLabels []labels.Requirement
+		ctx              context.Context
+		c                client.Client
+		restore          *v1beta1.Restore
+		cleanupOnRestore bool
+		restoreOptions   RestoreOptions
 	}
 	tests := []struct {
-		name            string
-		args            args
-		secretsToKeep   []string
-		secretsToDelete []string
-		secretsToCreate []corev1.Secret
-		mapsToKeep      []string
-		mapsToDelete    []string
-		mapsToCreate    []corev1.ConfigMap
+		name string
+		args args
+		want bool
 	}{
 		{
-			name: "keep secrets with no backup or backup matching cleanupAll",
+			name: "post activation  should NOT run now, CleanupTypeNone",
 			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				backupName:  "name1",
-				cleanupType: v1beta1.CleanupTypeAll,
-				otherLabels: []labels.Requirement{*hiveCredsLabel},
-			},
-			secretsToKeep: []string{
-				"aws-creds-1", // has the same backup label as the backup
-				"aws-creds-2", // this is not an ACM secret, no ACM label
-				"aws-creds-3", // this is not an ACM secret, no ACM label
-
+				ctx: context.Background(),
+				c:   k8sClient1,
+				restore: createACMRestore("Restore", "veleroNamespace").
+					cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					phase(v1beta1.RestorePhaseEnabled).object,
+				cleanupOnRestore: true,
+				restoreOptions:   RestoreOptions{},
 			},
-			secretsToDelete: []string{
-				"aws-creds-4", // ACM secret and different backup
-				"aws-creds-5", // ACM secret no backup label
-			},
-			secretsToCreate: []corev1.Secret{
-				*createSecret("aws-creds-1", namespace, map[string]string{
-					BackupNameVeleroLabel: "name1",
-					backupCredsHiveLabel:  "hive",
-				}, nil, nil),
-				*createSecret("aws-creds-2", namespace, map[string]string{
-					"velero.io/backup-name-dummy": "name2",
-				}, nil, nil), // no backup label
-				*createSecret("aws-creds-3", namespace, map[string]string{
-					BackupNameVeleroLabel: "name2",
-				}, nil, nil), // has backup label but no ACM secret label
-				*createSecret("aws-creds-4", namespace, map[string]string{
-					BackupNameVeleroLabel: "name2",
-					backupCredsHiveLabel:  "hive",
-				}, nil, nil),
-				*createSecret("aws-creds-5", namespace, map[string]string{
-					backupCredsHiveLabel: "hive",
-				}, nil, nil),
-			},
-			mapsToKeep: []string{
-				"aws-map-1", // has the same backup label as the backup
-				"aws-map-2", // this is not an ACM map, no ACM label
-				"aws-map-3", // this is not an ACM map, no ACM label
-
-			},
-			mapsToDelete: []string{
-				"aws-map-4", // ACM secret and different backup
-				"aws-ap-5",  // ACM map no backup label
-			},
-			mapsToCreate: []corev1.ConfigMap{
-				*createConfigMap("aws-map-1", namespace, map[string]string{
-					BackupNameVeleroLabel: "name1",
-					backupCredsHiveLabel:  "hive",
-				}),
-				*createConfigMap("aws-map-2", namespace, map[string]string{
-					"velero.io/backup-name-dummy": "name2",
-				}), // no backup label
-				*createConfigMap("aws-map-3", namespace, map[string]string{
-					BackupNameVeleroLabel: "name2",
-				}), // has backup label but no ACM secret label
-				*createConfigMap("aws-map-4", namespace, map[string]string{
-					BackupNameVeleroLabel: "name2",
-					backupCredsHiveLabel:  "hive",
-				}),
-				*createConfigMap("aws-map-5", namespace, map[string]string{
-					backupCredsHiveLabel: "hive",
-				}),
+			want: false,
+		},
+		{
+			name: "post activation  should NOT run now, state is enabled but cleanup is false",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				restore: createACMRestore("Restore", "veleroNamespace").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					phase(v1beta1.RestorePhaseEnabled).object,
+				cleanupOnRestore: false,
+				restoreOptions:   RestoreOptions{},
 			},
+			want: false,
 		},
 		{
-			name: "keep secrets with no backup or backup matching cleanupRestored",
+			name: "post activation  should run now, state is enabled AND cleanup is true",
 			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				backupName:  "name1",
-				cleanupType: v1beta1.CleanupTypeRestored,
-				otherLabels: []labels.Requirement{*hiveCredsLabel},
-			},
-			secretsToKeep: []string{
-				"aws-creds-1", // has the same backup label as the backup
-				"aws-creds-2", // this is not an ACM secret, no ACM label
-				"aws-creds-3", // this is not an ACM secret, no ACM label
-				"aws-creds-5", // user created secret, not deleted when CleanupTypeRestored
-			},
-			secretsToDelete: []string{
-				"aws-creds-4", // ACM secret and different backup
-			},
-			secretsToCreate: []corev1.Secret{
-				*createSecret("aws-creds-4", namespace, map[string]string{
-					BackupNameVeleroLabel: "name2",
-					backupCredsHiveLabel:  "hive",
-				}, nil, nil),
-				*createSecret("aws-creds-5", namespace, map[string]string{
-					backupCredsHiveLabel: "hive",
-				}, nil, nil),
-			},
-			mapsToKeep: []string{
-				"aws-map-1", // has the same backup label as the backup
-				"aws-map-2", // this is not an ACM map, no ACM label
-				"aws-map-3", // this is not an ACM map, no ACM label
-				"aws-map-5", // user created map, not deleted when CleanupTypeRestored
+				ctx: context.Background(),
+				c:   k8sClient1,
+				restore: createACMRestore("Restore", "veleroNamespace").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					phase(v1beta1.RestorePhaseEnabled).object,
+				cleanupOnRestore: true,
+				restoreOptions:   RestoreOptions{},
 			},
-			mapsToDelete: []string{
-				"aws-map-4", // ACM map and different backup
+			want: true,
+		},
+		{
+			name: "post activation should run now, state is Finished - and cleanup is false, not counting",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				restore: createACMRestore("Restore", "veleroNamespace").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					phase(v1beta1.RestorePhaseFinished).object,
+				cleanupOnRestore: false,
+				restoreOptions:   RestoreOptions{},
 			},
-			mapsToCreate: []corev1.ConfigMap{
-				*createConfigMap("aws-map-4", namespace, map[string]string{
-					BackupNameVeleroLabel: "name2",
-					backupCredsHiveLabel:  "hive",
-				}),
-				*createConfigMap("aws-map-5", namespace, map[string]string{
-					backupCredsHiveLabel: "hive",
-				}),
+			want: true,
+		},
+		{
+			name: "point-in-time recovery, cleanup runs after the velero restores finished",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				restore: createACMRestore("Restore", "veleroNamespace").
+					pointInTimeRecovery(true).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					phase(v1beta1.RestorePhaseFinished).object,
+				cleanupOnRestore: false,
+				restoreOptions:   RestoreOptions{},
 			},
+			want: true,
 		},
 	}
 
 	for _, tt := range tests {
-
-		for i := range tt.secretsToCreate {
-			if err := k8sClient1.Create(context.Background(), &tt.secretsToCreate[i]); err != nil {
-				t.Errorf("failed to create secret %s ", err.Error())
-			}
-		}
-
-		for i := range tt.mapsToCreate {
-			if err := k8sClient1.Create(context.Background(), &tt.mapsToCreate[i]); err != nil {
-				t.Errorf("failed to create map %s ", err.Error())
-			}
-		}
-
 		t.Run(tt.name, func(t *testing.T) {
-			deleteSecretsWithLabelSelector(tt.args.ctx, tt.args.c,
-				tt.args.backupName, tt.args.cleanupType, tt.args.otherLabels)
-
-			// no matching backups so secrets should be deleted
-			for i := range tt.secretsToDelete {
-				secret := corev1.Secret{}
-				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-					Name: tt.secretsToDelete[i], Namespace: namespace,
-				},
-					&secret); err == nil {
-					t.Errorf("deleteSecretsWithLabelSelector() secret %s should be deleted",
-						tt.secretsToDelete[i])
-				}
-
-			}
-
-			for i := range tt.secretsToKeep {
-				secret := corev1.Secret{}
-				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-					Name: tt.secretsToKeep[i], Namespace: namespace,
-				},
-					&secret); err != nil {
-					t.Errorf("deleteSecretsWithLabelSelector() %s should be found",
-						tt.secretsToKeep[i])
-				}
-			}
-
-			for i := range tt.mapsToDelete {
-				maps := corev1.ConfigMap{}
-				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-					Name: tt.mapsToDelete[i], Namespace: namespace,
-				},
-					&maps); err == nil {
-					t.Errorf("deleteSecretsWithLabelSelector() map %s should be deleted",
-						tt.mapsToDelete[i])
-				}
-
-			}
-
-			for i := range tt.mapsToKeep {
-				maps := corev1.ConfigMap{}
-				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-					Name: tt.mapsToKeep[i], Namespace: namespace,
-				},
-					&maps); err != nil {
-					t.Errorf("deleteSecretsWithLabelSelector()map  %s should be found",
-						tt.mapsToKeep[i])
-				}
+			if got := cleanupDeltaResources(tt.args.ctx, tt.args.c,
+				tt.args.restore, tt.args.cleanupOnRestore, tt.args.restoreOptions); got != tt.want {
+				t.Errorf("cleanupDeltaResources() returns = %v, want %v", got, tt.want)
 			}
 		})
-
 	}
 
 	if err := testEnv.Stop(); err != nil {
@@ -1228,7 +1595,7 @@ func Test_deleteSecretsWithLabelSelector(t *testing.T) {
 	}
 }
 
-func Test_deleteSecretsForBackupType(t *testing.T) {
+func Test_getBackupInfoFromRestore(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -1238,6 +1605,9 @@ func Test_deleteSecretsForBackupType(t *testing.T) {
 	}
 
 	namespace := "ns"
+	backupName := "passive-sync-2-acm-credentials-schedule-20220929220007"
+	validRestoreName := "restore-acm-passive-sync-2-acm-credentials-schedule-2022092922123"
+	validRestoreNameWBackup := "restore-acm-" + backupName
 
 	scheme1 := runtime.NewScheme()
 	err := veleroapi.AddToScheme(scheme1)
@@ -1258,238 +1628,95 @@ func Test_deleteSecretsForBackupType(t *testing.T) {
 		t.Fatalf("Error creating new client: %s", err.Error())
 	}
 
-	ns1 := *createNamespace(namespace)
-
-	timeNow, _ := time.Parse(time.RFC3339, "2022-07-26T15:25:34Z")
-	rightNow := v1.NewTime(timeNow)
-	tenHourAgo := rightNow.Add(-10 * time.Hour)
-	aFewSecondsAgo := rightNow.Add(-2 * time.Second)
-
-	currentTime := rightNow.Format("20060102150405")
-	tenHourAgoTime := tenHourAgo.Format("20060102150405")
-	aFewSecondsAgoTime := aFewSecondsAgo.Format("20060102150405")
-
-	secretKeepCloseToCredsBackup := *createSecret("secret-keep-close-to-creds-backup", namespace, map[string]string{
-		backupCredsHiveLabel:  "hive",
-		BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + aFewSecondsAgoTime,
-	}, nil, nil) // matches backup label from hive backup and it has the hive backup; keep it
-
-	secretKeepIgnoredCloseToCredsBackup := *createSecret("secret-keep-ignored-close-to-creds-backup", namespace,
-		map[string]string{
-			BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + aFewSecondsAgoTime,
-		}, nil, nil) // matches backup label from hive backup and it DOES NOT have the hive backup; keep it, ignore it
-
-	secretKeep2 := *createSecret("aws-creds-keep2", namespace, map[string]string{
-		"velero.io/backup-name-dummy": "name2",
-	}, nil, nil) // no backup label
-
-	deleteSecretFromOldBackup := *createSecret("delete-secret-from-old-backup", namespace, map[string]string{
-		backupCredsHiveLabel:  "hive",
-		BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + tenHourAgoTime,
-	}, nil, nil) // from the old backup, delete
-
-	ignoreSecretFromOldBackup := *createSecret("ignore-secret-from-old-backup", namespace, map[string]string{
-		BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + tenHourAgoTime,
-	}, nil, nil) // from the old backup, ignore since it doesn't have the hive label
-
-	secretDelete := *createSecret("creds-delete", namespace, map[string]string{
-		backupCredsHiveLabel:  "hive",
-		BackupNameVeleroLabel: "name2", // doesn't match the CloseToCredsBackup and it has the hive label
-	}, nil, nil)
-
-	keepSecretNoHiveLabel := *createSecret("keep-secret-no-hive-label", namespace, map[string]string{
-		BackupNameVeleroLabel: "name2", // doesn't match the CloseToCredsBackup but it DOES NOT have the hive label, ignore it
-	}, nil, nil)
-
-	ctx := context.Background()
-	errs := []error{}
-	errs = append(errs, k8sClient1.Create(ctx, &ns1))
-	// keep secrets
-	errs = append(errs, k8sClient1.Create(ctx, &secretKeepCloseToCredsBackup))
-	errs = append(errs, k8sClient1.Create(ctx, &secretKeepIgnoredCloseToCredsBackup))
-	errs = append(errs, k8sClient1.Create(ctx, &secretKeep2))
-	errs = append(errs, k8sClient1.Create(ctx, &keepSecretNoHiveLabel))
-	errs = append(errs, k8sClient1.Create(ctx, &ignoreSecretFromOldBackup))
-	// delete secrets
-	errs = append(errs, k8sClient1.Create(ctx, &deleteSecretFromOldBackup))
-	errs = append(errs, k8sClient1.Create(ctx, &secretDelete))
-	if err := errors.Join(errs...); err != nil {
-		t.Fatalf("Error creating objs for test setup: %s", err.Error())
-	}
-
-	relatedCredentialsBackup := *createBackup("acm-credentials-schedule-"+currentTime, namespace).
-		labels(map[string]string{
-			BackupScheduleTypeLabel: string(Credentials),
-			BackupScheduleNameLabel: "acm-credentials-hive-schedule-" + currentTime,
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		startTimestamp(rightNow).
-		object
-
-	err = k8sClient1.Create(ctx, &relatedCredentialsBackup)
-	if err != nil {
-		t.Fatalf("Error creating: %s", err.Error())
-	}
-
-	hiveCredsLabel, _ := labels.NewRequirement(backupCredsHiveLabel,
-		selection.Exists, []string{})
-
-	// no matching backups so non secrets should be deleted
-	secretsToBeDeleted := []string{
-		deleteSecretFromOldBackup.Name,
-		secretDelete.Name,
-	}
-	secretsToKeep := []string{
-		secretKeepCloseToCredsBackup.Name,
-		secretKeepIgnoredCloseToCredsBackup.Name,
-		secretKeep2.Name,
-		keepSecretNoHiveLabel.Name,
-		ignoreSecretFromOldBackup.Name,
-	}
-
 	type args struct {
-		ctx                 context.Context
-		c                   client.Client
-		backupType          ResourceType
-		relatedVeleroBackup veleroapi.Backup
-		cleanupType         v1beta1.CleanupType
-		otherLabels         []labels.Requirement
+		ctx         context.Context
+		c           client.Client
+		restoreName string
+		namespace   string
 	}
 	tests := []struct {
-		name string
-		args args
+		name           string
+		args           args
+		wantBackupName string
 	}{
 		{
-			name: "keep secrets with no backup or backup matching",
+			name: "restore name is empty",
 			args: args{
-				ctx:                 context.Background(),
-				c:                   k8sClient1,
-				backupType:          CredentialsHive,
-				cleanupType:         v1beta1.CleanupTypeAll,
-				relatedVeleroBackup: relatedCredentialsBackup,
-				otherLabels:         []labels.Requirement{*hiveCredsLabel},
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				restoreName: "",
+				namespace:   namespace,
 			},
+			wantBackupName: "",
 		},
 		{
-			name: "keep secrets with no backup or backup matching, added an old backup",
+			name: "restore name not found",
 			args: args{
-				ctx:                 context.Background(),
-				c:                   k8sClient1,
-				backupType:          CredentialsHive,
-				cleanupType:         v1beta1.CleanupTypeAll,
-				relatedVeleroBackup: relatedCredentialsBackup,
-				otherLabels:         []labels.Requirement{*hiveCredsLabel},
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				restoreName: "some-restore",
+				namespace:   namespace,
 			},
+			wantBackupName: "",
 		},
 		{
-			name: "keep secrets with no backup or backup matching, added a matching backup and an old backup",
+			name: "restore name not found",
 			args: args{
-				ctx:                 context.Background(),
-				c:                   k8sClient1,
-				backupType:          CredentialsHive,
-				cleanupType:         v1beta1.CleanupTypeAll,
-				relatedVeleroBackup: relatedCredentialsBackup,
-				otherLabels:         []labels.Requirement{*hiveCredsLabel},
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				restoreName: "some-restore",
+				namespace:   namespace,
+			},
+			wantBackupName: "",
+		},
+		{
+			name: "restore found but no backup",
+			args: args{
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				restoreName: validRestoreName,
+				namespace:   namespace,
+			},
+			wantBackupName: "",
+		},
+		{
+			name: "restore found with backup",
+			args: args{
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				restoreName: validRestoreNameWBackup,
+				namespace:   namespace,
 			},
+			wantBackupName: backupName,
 		},
 	}
 
 	for index, tt := range tests {
+		if index == 0 {
+			ns1 := *createNamespace(namespace)
+			veleroRestore := *createRestore(validRestoreName,
+				namespace).object
 
-		if index == 1 {
-			hiveOldBackup := *createBackup("acm-credentials-hive-schedule-"+tenHourAgoTime, namespace).
-				labels(map[string]string{
-					BackupScheduleTypeLabel: string(CredentialsHive),
-					BackupScheduleNameLabel: "acm-credentials-hive-schedule-" + tenHourAgoTime,
-				}).
-				phase(veleroapi.BackupPhaseCompleted).
-				startTimestamp(v1.NewTime(tenHourAgo)).
+			veleroRestoreWBackup := *createRestore(validRestoreNameWBackup,
+				namespace).
+				backupName(backupName).
 				object
 
-			err := k8sClient1.Create(ctx, &hiveOldBackup)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
-		}
-
-		if index == 2 {
-			hiveCloseToCredsBackup := *createBackup("acm-credentials-hive-schedule-"+aFewSecondsAgoTime, namespace).
-				labels(map[string]string{
-					BackupScheduleTypeLabel: string(CredentialsHive),
-					BackupScheduleNameLabel: "acm-credentials-hive-schedule-" + aFewSecondsAgoTime,
-				}).
-				phase(veleroapi.BackupPhaseCompleted).
-				startTimestamp(v1.NewTime(aFewSecondsAgo)).
-				object
+			veleroBackup := *createBackup(backupName, namespace).object
 
-			err := k8sClient1.Create(ctx, &hiveCloseToCredsBackup)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
+			errs := []error{}
+			errs = append(errs, k8sClient1.Create(tt.args.ctx, &ns1))
+			errs = append(errs, k8sClient1.Create(tt.args.ctx, &veleroRestore))
+			errs = append(errs, k8sClient1.Create(tt.args.ctx, &veleroRestoreWBackup))
+			errs = append(errs, k8sClient1.Create(tt.args.ctx, &veleroBackup))
+			if err := errors.Join(errs...); err != nil {
+				t.Errorf("Error creating objs to setup for test: %s", err.Error())
 			}
 		}
-
 		t.Run(tt.name, func(t *testing.T) {
-			deleteSecretsForBackupType(tt.args.ctx, tt.args.c,
-				tt.args.backupType, tt.args.relatedVeleroBackup,
-				tt.args.cleanupType, tt.args.otherLabels)
-
-			if index == 0 {
-				// no matching backups so non secrets should be deleted
-				secret := corev1.Secret{}
-				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-					Name: deleteSecretFromOldBackup.Name, Namespace: namespace,
-				}, &secret); err != nil {
-					t.Errorf("deleteSecretsForBackupType() deleteSecretFromOldBackup should be found, there was no hive backup " +
-						"matching the related backup  !")
-				}
-			}
-
-			if index == 1 {
-				// no matching backups so secrets should be deleted
-				for i := range secretsToBeDeleted {
-					secret := corev1.Secret{}
-					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-						Name: secretsToBeDeleted[i], Namespace: namespace,
-					}, &secret); err != nil {
-						t.Errorf("deleteSecretsForBackupType() index=%v, secret %s should be found",
-							index, secretsToBeDeleted[i])
-					}
-
-				}
-
-				for i := range secretsToKeep {
-					secret := corev1.Secret{}
-					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-						Name: secretsToKeep[i], Namespace: namespace,
-					}, &secret); err != nil {
-						t.Errorf("deleteSecretsForBackupType() index=%v, %s should be found",
-							index, secretsToKeep[i])
-					}
-				}
-			}
-
-			if index == 2 {
-				for i := range secretsToBeDeleted {
-					secret := corev1.Secret{}
-					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-						Name: secretsToBeDeleted[i], Namespace: namespace,
-					}, &secret); err == nil {
-						t.Errorf("deleteSecretsForBackupType() index=%v, secret %s should NOT be found",
-							index, secretsToBeDeleted[i])
-					}
-
-				}
-
-				for i := range secretsToKeep {
-					secret := corev1.Secret{}
-					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-						Name: secretsToKeep[i], Namespace: namespace,
-					}, &secret); err != nil {
-						t.Errorf("deleteSecretsForBackupType() index=%v, %s should be found",
-							index, secretsToKeep[i])
-					}
-
-				}
+			if got, _ := getBackupInfoFromRestore(tt.args.ctx, tt.args.c,
+				tt.args.restoreName, tt.args.namespace); got != tt.wantBackupName {
+				t.Errorf("getBackupInfoFromRestore() returns = %v, want %v", got, tt.wantBackupName)
 			}
 		})
 
@@ -1500,7 +1727,7 @@ func Test_deleteSecretsForBackupType(t *testing.T) {
 	}
 }
 
-func Test_cleanupDeltaForCredentials(t *testing.T) {
+func Test_deleteSecretsWithLabelSelector(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -1509,78 +1736,235 @@ func Test_cleanupDeltaForCredentials(t *testing.T) {
 		ErrorIfCRDPathMissing: true,
 	}
 
-	cfg, err := testEnv.Start()
+	namespace := "ns"
+
+	scheme1 := runtime.NewScheme()
+	err := veleroapi.AddToScheme(scheme1)
 	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	scheme1 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme1)
+	err = corev1.AddToScheme(scheme1)
 	if err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
 	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
 	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+		t.Fatalf("Error creating new client: %s", err.Error())
+	}
+
+	ns1 := *createNamespace(namespace)
+	err = k8sClient1.Create(context.Background(), &ns1)
+	if err != nil {
+		t.Fatalf("Error creating ns: %s", err.Error())
 	}
 
+	hiveCredsLabel, _ := labels.NewRequirement(backupCredsHiveLabel,
+		selection.Exists, []string{})
+
 	type args struct {
-		ctx          context.Context
-		c            client.Client
-		veleroBackup *veleroapi.Backup
-		backupName   string
-		cleanupType  v1beta1.CleanupType
+		ctx         context.Context
+		c           client.Client
+		backupName  string
+		cleanupType v1beta1.CleanupType
+		otherLabels []labels.Requirement
 	}
 	tests := []struct {
-		name string
-		args args
+		name            string
+		args            args
+		secretsToKeep   []string
+		secretsToDelete []string
+		secretsToCreate []corev1.Secret
+		mapsToKeep      []string
+		mapsToDelete    []string
+		mapsToCreate    []corev1.ConfigMap
 	}{
 		{
-			name: "no backup name, return",
+			name: "keep secrets with no backup or backup matching cleanupAll",
 			args: args{
 				ctx:         context.Background(),
 				c:           k8sClient1,
+				backupName:  "name1",
 				cleanupType: v1beta1.CleanupTypeAll,
-				backupName:  "",
-				veleroBackup: createBackup("acm-credentials-hive-schedule-20220726152532", "veleroNamespace").
-					object,
+				otherLabels: []labels.Requirement{*hiveCredsLabel},
 			},
-		},
-		{
-			name: "with backup name, no ORSelector",
-			args: args{
-				ctx:         context.Background(),
-				c:           k8sClient1,
-				cleanupType: v1beta1.CleanupTypeAll,
-				backupName:  "acm-credentials-hive-schedule-20220726152532",
-				veleroBackup: createBackup("acm-credentials-hive-schedule-20220726152532", "veleroNamespace").
-					object,
+			secretsToKeep: []string{
+				"aws-creds-1", // has the same backup label as the backup
+				"aws-creds-2", // this is not an ACM secret, no ACM label
+				"aws-creds-3", // this is not an ACM secret, no ACM label
+
+			},
+			secretsToDelete: []string{
+				"aws-creds-4", // ACM secret and different backup
+				"aws-creds-5", // ACM secret no backup label
+			},
+			secretsToCreate: []corev1.Secret{
+				*createSecret("aws-creds-1", namespace, map[string]string{
+					BackupNameVeleroLabel: "name1",
+					backupCredsHiveLabel:  "hive",
+				}, nil, nil),
+				*createSecret("aws-creds-2", namespace, map[string]string{
+					"velero.io/backup-name-dummy": "name2",
+				}, nil, nil), // no backup label
+				*createSecret("aws-creds-3", namespace, map[string]string{
+					BackupNameVeleroLabel: "name2",
+				}, nil, nil), // has backup label but no ACM secret label
+				*createSecret("aws-creds-4", namespace, map[string]string{
+					BackupNameVeleroLabel: "name2",
+					backupCredsHiveLabel:  "hive",
+				}, nil, nil),
+				*createSecret("aws-creds-5", namespace, map[string]string{
+					backupCredsHiveLabel: "hive",
+				}, nil, nil),
+			},
+			mapsToKeep: []string{
+				"aws-map-1", // has the same backup label as the backup
+				"aws-map-2", // this is not an ACM map, no ACM label
+				"aws-map-3", // this is not an ACM map, no ACM label
+
+			},
+			mapsToDelete: []string{
+				"aws-map-4", // ACM secret and different backup
+				"aws-ap-5",  // ACM map no backup label
+			},
+			mapsToCreate: []corev1.ConfigMap{
+				*createConfigMap("aws-map-1", namespace, map[string]string{
+					BackupNameVeleroLabel: "name1",
+					backupCredsHiveLabel:  "hive",
+				}),
+				*createConfigMap("aws-map-2", namespace, map[string]string{
+					"velero.io/backup-name-dummy": "name2",
+				}), // no backup label
+				*createConfigMap("aws-map-3", namespace, map[string]string{
+					BackupNameVeleroLabel: "name2",
+				}), // has backup label but no ACM secret label
+				*createConfigMap("aws-map-4", namespace, map[string]string{
+					BackupNameVeleroLabel: "name2",
+					backupCredsHiveLabel:  "hive",
+				}),
+				*createConfigMap("aws-map-5", namespace, map[string]string{
+					backupCredsHiveLabel: "hive",
+				}),
 			},
 		},
 		{
-			name: "with backup name, and ORSelector",
+			name: "keep secrets with no backup or backup matching cleanupRestored",
 			args: args{
 				ctx:         context.Background(),
 				c:           k8sClient1,
-				cleanupType: v1beta1.CleanupTypeAll,
-				backupName:  "acm-credentials-hive-schedule-20220726152532",
-				veleroBackup: createBackup("acm-credentials-hive-schedule-20220726152532", "veleroNamespace").
-					orLabelSelectors([]*metav1.LabelSelector{
-						{
-							MatchLabels: map[string]string{
-								"test": "test",
-							},
-						},
-					}).
-					object,
+				backupName:  "name1",
+				cleanupType: v1beta1.CleanupTypeRestored,
+				otherLabels: []labels.Requirement{*hiveCredsLabel},
+			},
+			secretsToKeep: []string{
+				"aws-creds-1", // has the same backup label as the backup
+				"aws-creds-2", // this is not an ACM secret, no ACM label
+				"aws-creds-3", // this is not an ACM secret, no ACM label
+				"aws-creds-5", // user created secret, not deleted when CleanupTypeRestored
+			},
+			secretsToDelete: []string{
+				"aws-creds-4", // ACM secret and different backup
+			},
+			secretsToCreate: []corev1.Secret{
+				*createSecret("aws-creds-4", namespace, map[string]string{
+					BackupNameVeleroLabel: "name2",
+					backupCredsHiveLabel:  "hive",
+				}, nil, nil),
+				*createSecret("aws-creds-5", namespace, map[string]string{
+					backupCredsHiveLabel: "hive",
+				}, nil, nil),
+			},
+			mapsToKeep: []string{
+				"aws-map-1", // has the same backup label as the backup
+				"aws-map-2", // this is not an ACM map, no ACM label
+				"aws-map-3", // this is not an ACM map, no ACM label
+				"aws-map-5", // user created map, not deleted when CleanupTypeRestored
+			},
+			mapsToDelete: []string{
+				"aws-map-4", // ACM map and different backup
+			},
+			mapsToCreate: []corev1.ConfigMap{
+				*createConfigMap("aws-map-4", namespace, map[string]string{
+					BackupNameVeleroLabel: "name2",
+					backupCredsHiveLabel:  "hive",
+				}),
+				*createConfigMap("aws-map-5", namespace, map[string]string{
+					backupCredsHiveLabel: "hive",
+				}),
 			},
 		},
 	}
 
 	for _, tt := range tests {
+
+		for i := range tt.secretsToCreate {
+			if err := k8sClient1.Create(context.Background(), &tt.secretsToCreate[i]); err != nil {
+				t.Errorf("failed to create secret %s ", err.Error())
+			}
+		}
+
+		for i := range tt.mapsToCreate {
+			if err := k8sClient1.Create(context.Background(), &tt.mapsToCreate[i]); err != nil {
+				t.Errorf("failed to create map %s ", err.Error())
+			}
+		}
+
 		t.Run(tt.name, func(t *testing.T) {
-			cleanupDeltaForCredentials(tt.args.ctx, tt.args.c,
-				tt.args.backupName, tt.args.veleroBackup, tt.args.cleanupType, false)
+			deleteSecretsWithLabelSelector(tt.args.ctx, tt.args.c,
+				tt.args.backupName, tt.args.cleanupType, tt.args.otherLabels)
+
+			// no matching backups so secrets should be deleted
+			for i := range tt.secretsToDelete {
+				secret := corev1.Secret{}
+				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+					Name: tt.secretsToDelete[i], Namespace: namespace,
+				},
+					&secret); err == nil {
+					t.Errorf("deleteSecretsWithLabelSelector() secret %s should be deleted",
+						tt.secretsToDelete[i])
+				}
+
+			}
+
+			for i := range tt.secretsToKeep {
+				secret := corev1.Secret{}
+				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+					Name: tt.secretsToKeep[i], Namespace: namespace,
+				},
+					&secret); err != nil {
+					t.Errorf("deleteSecretsWithLabelSelector() %s should be found",
+						tt.secretsToKeep[i])
+				}
+			}
+
+			for i := range tt.mapsToDelete {
+				maps := corev1.ConfigMap{}
+				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+					Name: tt.mapsToDelete[i], Namespace: namespace,
+				},
+					&maps); err == nil {
+					t.Errorf("deleteSecretsWithLabelSelector() map %s should be deleted",
+						tt.mapsToDelete[i])
+				}
+
+			}
+
+			for i := range tt.mapsToKeep {
+				maps := corev1.ConfigMap{}
+				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+					Name: tt.mapsToKeep[i], Namespace: namespace,
+				},
+					&maps); err != nil {
+					t.Errorf("deleteSecretsWithLabelSelector()map  %s should be found",
+						tt.mapsToKeep[i])
+				}
+			}
 		})
+
 	}
 
 	if err := testEnv.Stop(); err != nil {
@@ -1588,9 +1972,7 @@ func Test_cleanupDeltaForCredentials(t *testing.T) {
 	}
 }
 
-func Test_cleanupDeltaForResourcesAndClustersBackup(t *testing.T) {
-	log.SetLogger(zap.New())
-
+func Test_deleteSecretsForBackupType(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -1599,29 +1981,29 @@ func Test_cleanupDeltaForResourcesAndClustersBackup(t *testing.T) {
 		ErrorIfCRDPathMissing: true,
 	}
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
+	namespace := "ns"
+
 	scheme1 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme1)
+	err := veleroapi.AddToScheme(scheme1)
 	if err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = clusterv1.AddToScheme(scheme1)
+	err = corev1.AddToScheme(scheme1)
 	if err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = corev1.AddToScheme(scheme1)
+
+	cfg, err := testEnv.Start()
 	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
+		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
 	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
 	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+		t.Fatalf("Error creating new client: %s", err.Error())
 	}
 
-	namespaceName := "open-cluster-management-backup"
+	ns1 := *createNamespace(namespace)
+
 	timeNow, _ := time.Parse(time.RFC3339, "2022-07-26T15:25:34Z")
 	rightNow := v1.NewTime(timeNow)
 	tenHourAgo := rightNow.Add(-10 * time.Hour)
@@ -1631,1200 +2013,3276 @@ func Test_cleanupDeltaForResourcesAndClustersBackup(t *testing.T) {
 	tenHourAgoTime := tenHourAgo.Format("20060102150405")
 	aFewSecondsAgoTime := aFewSecondsAgo.Format("20060102150405")
 
-	veleroResourcesBackupNameOlder := veleroScheduleNames[Resources] + "-" + tenHourAgoTime
-	veleroResourcesBackupName := veleroScheduleNames[Resources] + "-" + currentTime
+	secretKeepCloseToCredsBackup := *createSecret("secret-keep-close-to-creds-backup", namespace, map[string]string{
+		backupCredsHiveLabel:  "hive",
+		BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + aFewSecondsAgoTime,
+	}, nil, nil) // matches backup label from hive backup and it has the hive backup; keep it
 
-	veleroGenericBackupNameOlder := veleroScheduleNames[ResourcesGeneric] + "-" + tenHourAgoTime
-	veleroGenericBackupName := veleroScheduleNames[ResourcesGeneric] + "-" + aFewSecondsAgoTime
+	secretKeepIgnoredCloseToCredsBackup := *createSecret("secret-keep-ignored-close-to-creds-backup", namespace,
+		map[string]string{
+			BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + aFewSecondsAgoTime,
+		}, nil, nil) // matches backup label from hive backup and it DOES NOT have the hive backup; keep it, ignore it
 
-	veleroClustersBackupNameOlder := veleroScheduleNames[ManagedClusters] + "-" + tenHourAgoTime
-	veleroClustersBackupName := veleroScheduleNames[ManagedClusters] + "-" + aFewSecondsAgoTime
+	secretKeep2 := *createSecret("aws-creds-keep2", namespace, map[string]string{
+		"velero.io/backup-name-dummy": "name2",
+	}, nil, nil) // no backup label
 
-	// Create a local cluster managedcluster object
-	if err := k8sClient1.Create(context.Background(), createNamespace("myhub1")); err != nil {
-		t.Fatalf("cannot create ns %s ", err.Error())
-	}
-	err = k8sClient1.Create(context.Background(), createManagedCluster("myhub1", true /* local cluster */).object)
-	if err != nil {
-		t.Fatalf("cannot create %s ", err.Error())
-	}
+	deleteSecretFromOldBackup := *createSecret("delete-secret-from-old-backup", namespace, map[string]string{
+		backupCredsHiveLabel:  "hive",
+		BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + tenHourAgoTime,
+	}, nil, nil) // from the old backup, delete
 
-	// create a resources backup
-	resources := []string{
-		"crd-not-found.apps.open-cluster-management.io",
-		"channel.apps.open-cluster-management.io",
-	}
-	resources = append(resources, backupResources...)
+	ignoreSecretFromOldBackup := *createSecret("ignore-secret-from-old-backup", namespace, map[string]string{
+		BackupNameVeleroLabel: "acm-credentials-hive-schedule-" + tenHourAgoTime,
+	}, nil, nil) // from the old backup, ignore since it doesn't have the hive label
 
-	resourcesBackup := *createBackup(veleroResourcesBackupName, namespaceName).
-		includedResources(resources).
-		startTimestamp(rightNow).
-		excludedNamespaces([]string{"local-cluster", "open-cluster-management-backup"}).
-		labels(map[string]string{
-			BackupScheduleTypeLabel: string(Resources),
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		object
+	secretDelete := *createSecret("creds-delete", namespace, map[string]string{
+		backupCredsHiveLabel:  "hive",
+		BackupNameVeleroLabel: "name2", // doesn't match the CloseToCredsBackup and it has the hive label
+	}, nil, nil)
 
-	genericBackup := *createBackup(veleroGenericBackupName, namespaceName).
-		excludedResources(backupManagedClusterResources).
-		startTimestamp(v1.NewTime(aFewSecondsAgo)).
-		labels(map[string]string{
-			BackupScheduleTypeLabel: string(ResourcesGeneric),
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		object
+	keepSecretNoHiveLabel := *createSecret("keep-secret-no-hive-label", namespace, map[string]string{
+		BackupNameVeleroLabel: "name2", // doesn't match the CloseToCredsBackup but it DOES NOT have the hive label, ignore it
+	}, nil, nil)
 
-	genericBackupOld := *createBackup(veleroGenericBackupNameOlder, namespaceName).
-		excludedResources(backupManagedClusterResources).
-		startTimestamp(v1.NewTime(tenHourAgo)).
-		labels(map[string]string{
-			BackupScheduleTypeLabel: string(ResourcesGeneric),
-		}).
-		object
+	ctx := context.Background()
+	errs := []error{}
+	errs = append(errs, k8sClient1.Create(ctx, &ns1))
+	// keep secrets
+	errs = append(errs, k8sClient1.Create(ctx, &secretKeepCloseToCredsBackup))
+	errs = append(errs, k8sClient1.Create(ctx, &secretKeepIgnoredCloseToCredsBackup))
+	errs = append(errs, k8sClient1.Create(ctx, &secretKeep2))
+	errs = append(errs, k8sClient1.Create(ctx, &keepSecretNoHiveLabel))
+	errs = append(errs, k8sClient1.Create(ctx, &ignoreSecretFromOldBackup))
+	// delete secrets
+	errs = append(errs, k8sClient1.Create(ctx, &deleteSecretFromOldBackup))
+	errs = append(errs, k8sClient1.Create(ctx, &secretDelete))
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("Error creating objs for test setup: %s", err.Error())
+	}
 
-	clustersBackup := *createBackup(veleroClustersBackupName, namespaceName).
-		includedResources(backupManagedClusterResources).
-		excludedNamespaces([]string{"local-cluster"}).
-		startTimestamp(v1.NewTime(aFewSecondsAgo)).
+	relatedCredentialsBackup := *createBackup("acm-credentials-schedule-"+currentTime, namespace).
 		labels(map[string]string{
-			BackupScheduleTypeLabel: string(ManagedClusters),
+			BackupScheduleTypeLabel: string(Credentials),
+			BackupScheduleNameLabel: "acm-credentials-hive-schedule-" + currentTime,
 		}).
 		phase(veleroapi.BackupPhaseCompleted).
+		startTimestamp(rightNow).
 		object
 
-	clustersBackupOld := *createBackup(veleroClustersBackupNameOlder, namespaceName).
-		includedResources(backupManagedClusterResources).
-		excludedNamespaces([]string{"local-cluster"}).
-		startTimestamp(v1.NewTime(tenHourAgo)).
-		labels(map[string]string{
-			BackupScheduleTypeLabel: string(ManagedClusters),
-		}).
-		object
-
-	res_NS := &unstructured.Unstructured{}
-	res_NS.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "v1",
-		"kind":       "Namespace",
-		"metadata": map[string]interface{}{
-			"name": namespaceName,
-		},
-	})
+	err = k8sClient1.Create(ctx, &relatedCredentialsBackup)
+	if err != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
 
-	cls_with_backup_label_same := &unstructured.Unstructured{}
-	cls_with_backup_label_same.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
-		"kind":       "ManagedCluster",
-		"metadata": map[string]interface{}{
-			"name":      "cls-with-backup-label-same",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel: clustersBackup.Name,
-			},
-		},
-	})
-	cls_with_backup_label_diff_excl_ns := &unstructured.Unstructured{}
-	cls_with_backup_label_diff_excl_ns.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
-		"kind":       "ManagedCluster",
-		"metadata": map[string]interface{}{
-			"name":      "cls-with-backup-label-diff-excl-ns",
-			"namespace": "local-cluster",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel: veleroClustersBackupNameOlder,
-			},
-		},
-	})
-	cls_with_backup_label_diff := &unstructured.Unstructured{}
-	cls_with_backup_label_diff.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
-		"kind":       "ManagedCluster",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-diff",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel: veleroClustersBackupNameOlder,
-			},
-			"finalizers": []interface{}{"hive.openshift.io/deprovision"},
-		},
-	})
+	hiveCredsLabel, _ := labels.NewRequirement(backupCredsHiveLabel,
+		selection.Exists, []string{})
 
-	cls_with_no_backup_label := &unstructured.Unstructured{}
-	cls_with_no_backup_label.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
-		"kind":       "ManagedCluster",
-		"metadata": map[string]interface{}{
-			"name":      "cls-with-no-backup-label",
-			"namespace": "default",
-		},
-	})
+	// no matching backups so non secrets should be deleted
+	secretsToBeDeleted := []string{
+		deleteSecretFromOldBackup.Name,
+		secretDelete.Name,
+	}
+	secretsToKeep := []string{
+		secretKeepCloseToCredsBackup.Name,
+		secretKeepIgnoredCloseToCredsBackup.Name,
+		secretKeep2.Name,
+		keepSecretNoHiveLabel.Name,
+		ignoreSecretFromOldBackup.Name,
+	}
 
-	channel_with_backup_label_same := &unstructured.Unstructured{}
-	channel_with_backup_label_same.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-same",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel: resourcesBackup.Name,
-			},
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
-	channel_with_backup_label_diff_excl_ns := &unstructured.Unstructured{}
-	channel_with_backup_label_diff_excl_ns.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-diff-excl-ns",
-			"namespace": "local-cluster",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel: veleroResourcesBackupNameOlder,
+	type args struct {
+		ctx                 context.Context
+		c                   client.Client
+		backupType          ResourceType
+		relatedVeleroBackup veleroapi.Backup
+		cleanupType         v1beta1.CleanupType
+		otherLabels         []labels.Requirement
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "keep secrets with no backup or backup matching",
+			args: args{
+				ctx:                 context.Background(),
+				c:                   k8sClient1,
+				backupType:          CredentialsHive,
+				cleanupType:         v1beta1.CleanupTypeAll,
+				relatedVeleroBackup: relatedCredentialsBackup,
+				otherLabels:         []labels.Requirement{*hiveCredsLabel},
 			},
 		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
-	channel_with_backup_label_diff := &unstructured.Unstructured{}
-	channel_with_backup_label_diff.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-diff",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel: veleroResourcesBackupNameOlder,
+		{
+			name: "keep secrets with no backup or backup matching, added an old backup",
+			args: args{
+				ctx:                 context.Background(),
+				c:                   k8sClient1,
+				backupType:          CredentialsHive,
+				cleanupType:         v1beta1.CleanupTypeAll,
+				relatedVeleroBackup: relatedCredentialsBackup,
+				otherLabels:         []labels.Requirement{*hiveCredsLabel},
 			},
-			"finalizers": []interface{}{"hive.openshift.io/deprovision"},
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
 		},
-	})
-	channel_with_backup_label_generic := &unstructured.Unstructured{}
-	channel_with_backup_label_generic.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-generic",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel:   veleroResourcesBackupName,
-				backupCredsClusterLabel: "i-am-a-generic-resource",
+		{
+			name: "keep secrets with no backup or backup matching, added a matching backup and an old backup",
+			args: args{
+				ctx:                 context.Background(),
+				c:                   k8sClient1,
+				backupType:          CredentialsHive,
+				cleanupType:         v1beta1.CleanupTypeAll,
+				relatedVeleroBackup: relatedCredentialsBackup,
+				otherLabels:         []labels.Requirement{*hiveCredsLabel},
 			},
 		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+	}
 
-	channel_with_no_backup_label := &unstructured.Unstructured{}
-	channel_with_no_backup_label.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-no-backup-label",
-			"namespace": "default",
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+	for index, tt := range tests {
 
-	msaObj := &unstructured.Unstructured{}
-	msaObj.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "authentication.open-cluster-management.io/v1beta1",
-		"kind":       "ManagedServiceAccount",
-		"metadata": map[string]interface{}{
-			"name":      "auto-import-account",
-			"namespace": "managed1",
-			"labels": map[string]interface{}{
-				msa_label: msa_service_name,
-			},
-		},
-		"spec": map[string]interface{}{
-			"somethingelse": "aaa",
-			"rotation": map[string]interface{}{
-				"validity": "50h",
-				"enabled":  true,
-			},
-		},
-	})
+		if index == 1 {
+			hiveOldBackup := *createBackup("acm-credentials-hive-schedule-"+tenHourAgoTime, namespace).
+				labels(map[string]string{
+					BackupScheduleTypeLabel: string(CredentialsHive),
+					BackupScheduleNameLabel: "acm-credentials-hive-schedule-" + tenHourAgoTime,
+				}).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(tenHourAgo)).
+				object
 
-	channel_with_backup_label_generic_match := &unstructured.Unstructured{}
-	channel_with_backup_label_generic_match.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-generic-match",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel:   veleroGenericBackupName,
-				backupCredsClusterLabel: "i-am-a-generic-resource",
-			},
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+			err := k8sClient1.Create(ctx, &hiveOldBackup)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
 
-	channel_with_backup_label_generic_old := &unstructured.Unstructured{}
-	channel_with_backup_label_generic_old.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-generic-match-old",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel:   veleroGenericBackupNameOlder,
-				backupCredsClusterLabel: "i-am-a-generic-resource",
-			},
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+		if index == 2 {
+			hiveCloseToCredsBackup := *createBackup("acm-credentials-hive-schedule-"+aFewSecondsAgoTime, namespace).
+				labels(map[string]string{
+					BackupScheduleTypeLabel: string(CredentialsHive),
+					BackupScheduleNameLabel: "acm-credentials-hive-schedule-" + aFewSecondsAgoTime,
+				}).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(aFewSecondsAgo)).
+				object
 
-	channel_with_backup_label_generic_match_activ := &unstructured.Unstructured{}
-	channel_with_backup_label_generic_match_activ.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-generic-match-activ",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel:   veleroGenericBackupName,
-				backupCredsClusterLabel: ClusterActivationLabel,
-			},
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+			err := k8sClient1.Create(ctx, &hiveCloseToCredsBackup)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
 
-	channel_with_backup_label_generic_old_activ := &unstructured.Unstructured{}
-	channel_with_backup_label_generic_old_activ.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1beta1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-with-backup-label-generic-old-activ",
-			"namespace": "default",
-			"labels": map[string]interface{}{
-				BackupNameVeleroLabel:   veleroGenericBackupNameOlder,
-				backupCredsClusterLabel: ClusterActivationLabel,
-			},
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+		t.Run(tt.name, func(t *testing.T) {
+			deleteSecretsForBackupType(tt.args.ctx, tt.args.c,
+				tt.args.backupType, tt.args.relatedVeleroBackup,
+				tt.args.cleanupType, tt.args.otherLabels)
 
-	clsvObj := &unstructured.Unstructured{}
-	clsvObj.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "config.openshift.io/v1",
-		"kind":       "ClusterVersion",
-		"metadata": map[string]interface{}{
-			"name": "version",
-		},
-		"spec": map[string]interface{}{
-			"clusterID": "1234",
-		},
-	})
+			if index == 0 {
+				// no matching backups so non secrets should be deleted
+				secret := corev1.Secret{}
+				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+					Name: deleteSecretFromOldBackup.Name, Namespace: namespace,
+				}, &secret); err != nil {
+					t.Errorf("deleteSecretsForBackupType() deleteSecretFromOldBackup should be found, there was no hive backup " +
+						"matching the related backup  !")
+				}
+			}
 
-	msaGVK := schema.GroupVersionKind{
-		Group:   "authentication.open-cluster-management.io",
-		Version: "v1beta1", Kind: "ManagedServiceAccount",
-	}
-	msaGVRList := schema.GroupVersionResource{
-		Group:   "authentication.open-cluster-management.io",
-		Version: "v1beta1", Resource: "managedserviceaccounts",
-	}
+			if index == 1 {
+				// no matching backups so secrets should be deleted
+				for i := range secretsToBeDeleted {
+					secret := corev1.Secret{}
+					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+						Name: secretsToBeDeleted[i], Namespace: namespace,
+					}, &secret); err != nil {
+						t.Errorf("deleteSecretsForBackupType() index=%v, secret %s should be found",
+							index, secretsToBeDeleted[i])
+					}
 
-	// cluster deployments
-	clsDGVK := schema.GroupVersionKind{
-		Group:   "hive.openshift.io",
-		Version: "v1", Kind: "ClusterDeployment",
-	}
-	clsDGVKList := schema.GroupVersionResource{
-		Group:   "hive.openshift.io",
-		Version: "v1", Resource: "clusterdeployments",
-	}
+				}
+
+				for i := range secretsToKeep {
+					secret := corev1.Secret{}
+					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+						Name: secretsToKeep[i], Namespace: namespace,
+					}, &secret); err != nil {
+						t.Errorf("deleteSecretsForBackupType() index=%v, %s should be found",
+							index, secretsToKeep[i])
+					}
+				}
+			}
+
+			if index == 2 {
+				for i := range secretsToBeDeleted {
+					secret := corev1.Secret{}
+					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+						Name: secretsToBeDeleted[i], Namespace: namespace,
+					}, &secret); err == nil {
+						t.Errorf("deleteSecretsForBackupType() index=%v, secret %s should NOT be found",
+							index, secretsToBeDeleted[i])
+					}
+
+				}
+
+				for i := range secretsToKeep {
+					secret := corev1.Secret{}
+					if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+						Name: secretsToKeep[i], Namespace: namespace,
+					}, &secret); err != nil {
+						t.Errorf("deleteSecretsForBackupType() index=%v, %s should be found",
+							index, secretsToKeep[i])
+					}
+
+				}
+			}
+		})
 
-	// placements
-	plsGVK := schema.GroupVersionKind{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Kind: "Placement",
-	}
-	plsGVKGVKList := schema.GroupVersionResource{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Resource: "placements",
-	}
-	// curators
-	crGVK := schema.GroupVersionKind{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Kind: "ClusterCurator",
 	}
-	crGVKGVKList := schema.GroupVersionResource{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Resource: "clustercurators",
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
-	// channels
-	chGVK := schema.GroupVersionKind{
-		Group:   "apps.open-cluster-management.io",
-		Version: "v1beta1", Kind: "Channel",
+}
+
+func Test_cleanupDeltaForCredentials(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
 	}
-	chGVKList := schema.GroupVersionResource{
-		Group:   "apps.open-cluster-management.io",
-		Version: "v1beta1", Resource: "channels",
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
-	// subs
-	subsGVK := schema.GroupVersionKind{
-		Group:   "apps.open-cluster-management.io",
-		Version: "v1beta1", Kind: "Subscription",
+	scheme1 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	subsGVKList := schema.GroupVersionResource{
-		Group:   "apps.open-cluster-management.io",
-		Version: "v1beta1", Resource: "subscriptions",
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
 	}
 
-	// managed clusters
-	clsGVK := schema.GroupVersionKind{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Kind: "ManagedCluster",
-	}
-	clsGVKList := schema.GroupVersionResource{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Resource: "managedclusters",
-	}
-	// managed clusters sets
-	clsSGVK := schema.GroupVersionKind{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Kind: "ManagedClusterSet",
-	}
-	clsSGVKList := schema.GroupVersionResource{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Resource: "managedclustersets",
-	}
-	// backups
-	bsSGVK := schema.GroupVersionKind{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Kind: "BackupSchedule",
-	}
-	bsSGVKList := schema.GroupVersionResource{
-		Group:   "cluster.open-cluster-management.io",
-		Version: "v1beta1", Resource: "backupschedules",
-	}
-	// mutators
-	mGVK := schema.GroupVersionKind{
-		Group:   "proxy.open-cluster-management.io",
-		Version: "v1beta1", Kind: "AdmissionReview",
+	type args struct {
+		ctx          context.Context
+		c            client.Client
+		veleroBackup *veleroapi.Backup
+		backupName   string
+		cleanupType  v1beta1.CleanupType
 	}
-	mVKList := schema.GroupVersionResource{
-		Group:   "proxy.open-cluster-management.io",
-		Version: "v1beta1", Resource: "managedclustermutators",
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "no backup name, return",
+			args: args{
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				cleanupType: v1beta1.CleanupTypeAll,
+				backupName:  "",
+				veleroBackup: createBackup("acm-credentials-hive-schedule-20220726152532", "veleroNamespace").
+					object,
+			},
+		},
+		{
+			name: "with backup name, no ORSelector",
+			args: args{
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				cleanupType: v1beta1.CleanupTypeAll,
+				backupName:  "acm-credentials-hive-schedule-20220726152532",
+				veleroBackup: createBackup("acm-credentials-hive-schedule-20220726152532", "veleroNamespace").
+					object,
+			},
+		},
+		{
+			name: "with backup name, and ORSelector",
+			args: args{
+				ctx:         context.Background(),
+				c:           k8sClient1,
+				cleanupType: v1beta1.CleanupTypeAll,
+				backupName:  "acm-credentials-hive-schedule-20220726152532",
+				veleroBackup: createBackup("acm-credentials-hive-schedule-20220726152532", "veleroNamespace").
+					orLabelSelectors([]*metav1.LabelSelector{
+						{
+							MatchLabels: map[string]string{
+								"test": "test",
+							},
+						},
+					}).
+					object,
+			},
+		},
 	}
-	// pools
-	cpGVK := schema.GroupVersionKind{
-		Group:   "hive.openshift.io",
-		Version: "v1", Kind: "ClusterPool",
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupDeltaForCredentials(tt.args.ctx, tt.args.c,
+				tt.args.backupName, tt.args.veleroBackup, tt.args.cleanupType, false)
+		})
 	}
-	cpVKList := schema.GroupVersionResource{
-		Group:   "hive.openshift.io",
-		Version: "v1", Resource: "clusterpools",
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
-	// dns
-	dnsGVK := schema.GroupVersionKind{
-		Group:   "hive.openshift.io",
-		Version: "v1", Kind: "DNSZone",
+}
+
+func Test_cleanupDeltaForResourcesAndClustersBackup(t *testing.T) {
+	log.SetLogger(zap.New())
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
 	}
-	dnsVKList := schema.GroupVersionResource{
-		Group:   "hive.openshift.io",
-		Version: "v1", Resource: "dnszones",
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
-	// image set
-	imgGVK := schema.GroupVersionKind{
-		Group:   "hive.openshift.io",
-		Version: "v1", Kind: "ClusterImageSet",
+	scheme1 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	imgVKList := schema.GroupVersionResource{
-		Group:   "hive.openshift.io",
-		Version: "v1", Resource: "clusterimageset",
+	err = clusterv1.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	// hive config
-	hGVK := schema.GroupVersionKind{
-		Group:   "hive.openshift.io",
-		Version: "v1", Kind: "HiveConfig",
+	err = corev1.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	hVKList := schema.GroupVersionResource{
-		Group:   "hive.openshift.io",
-		Version: "v1", Resource: "hiveconfig",
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
 	}
 
-	// addon
-	aoGVK := schema.GroupVersionKind{
-		Group:   "addon.open-cluster-management.io",
-		Version: "v1alpha1", Kind: "ManagedClusterAddOn",
-	}
-	aoVKList := schema.GroupVersionResource{
-		Group:   "addon.open-cluster-management.io",
-		Version: "v1alpha1", Resource: "managedclusteraddons",
-	}
+	namespaceName := "open-cluster-management-backup"
+	timeNow, _ := time.Parse(time.RFC3339, "2022-07-26T15:25:34Z")
+	rightNow := v1.NewTime(timeNow)
+	tenHourAgo := rightNow.Add(-10 * time.Hour)
+	aFewSecondsAgo := rightNow.Add(-2 * time.Second)
 
-	// cluster version
-	clsVGVK := schema.GroupVersionKind{
-		Group:   "config.openshift.io",
-		Version: "v1", Kind: "ClusterVersion",
+	currentTime := rightNow.Format("20060102150405")
+	tenHourAgoTime := tenHourAgo.Format("20060102150405")
+	aFewSecondsAgoTime := aFewSecondsAgo.Format("20060102150405")
+
+	veleroResourcesBackupNameOlder := veleroScheduleNames[Resources] + "-" + tenHourAgoTime
+	veleroResourcesBackupName := veleroScheduleNames[Resources] + "-" + currentTime
+
+	veleroGenericBackupNameOlder := veleroScheduleNames[ResourcesGeneric] + "-" + tenHourAgoTime
+	veleroGenericBackupName := veleroScheduleNames[ResourcesGeneric] + "-" + aFewSecondsAgoTime
+
+	veleroClustersBackupNameOlder := veleroScheduleNames[ManagedClusters] + "-" + tenHourAgoTime
+	veleroClustersBackupName := veleroScheduleNames[ManagedClusters] + "-" + aFewSecondsAgoTime
+
+	// Create a local cluster managedcluster object
+	if err := k8sClient1.Create(context.Background(), createNamespace("myhub1")); err != nil {
+		t.Fatalf("cannot create ns %s ", err.Error())
 	}
-	clsVGVKList := schema.GroupVersionResource{
-		Group:   "config.openshift.io",
-		Version: "v1", Resource: "clusterversions",
+	err = k8sClient1.Create(context.Background(), createManagedCluster("myhub1", true /* local cluster */).object)
+	if err != nil {
+		t.Fatalf("cannot create %s ", err.Error())
 	}
 
-	///
-	gvrToListKind := map[schema.GroupVersionResource]string{
-		msaGVRList:    "ManagedServiceAccountList",
-		clsVGVKList:   "ClusterVersionList",
-		clsDGVKList:   "ClusterDeploymentList",
-		plsGVKGVKList: "PlacementList",
-		crGVKGVKList:  "ClusterCuratorList",
-		chGVKList:     "ChannelList",
-		clsGVKList:    "ManagedClusterList",
-		clsSGVKList:   "ManagedClusterSetList",
-		bsSGVKList:    "BackupScheduleList",
-		mVKList:       "AdmissionReviewList",
-		cpVKList:      "ClusterPoolList",
-		dnsVKList:     "DNSZoneList",
-		imgVKList:     "ClusterImageSetList",
-		hVKList:       "HiveConfigList",
-		subsGVKList:   "SubscriptionList",
-		aoVKList:      "ManagedClusterAddOnList",
+	// create a resources backup
+	resources := []string{
+		"crd-not-found.apps.open-cluster-management.io",
+		"channel.apps.open-cluster-management.io",
 	}
+	resources = append(resources, backupResources...)
 
-	channelRuntimeObjects := []runtime.Object{
-		channel_with_backup_label_same,
-		channel_with_backup_label_diff,
-		channel_with_backup_label_diff_excl_ns,
-		channel_with_backup_label_generic,
-		channel_with_no_backup_label,
-		channel_with_backup_label_generic_match,
-		channel_with_backup_label_generic_old,
-		channel_with_backup_label_generic_match_activ,
-		channel_with_backup_label_generic_old_activ,
-	}
-	clusterRuntimeObjects := []runtime.Object{
-		cls_with_backup_label_same,
-		cls_with_backup_label_diff,
-		cls_with_backup_label_diff_excl_ns,
-		cls_with_no_backup_label,
-	}
-	unstructuredScheme := runtime.NewScheme()
-	unstructuredScheme.AddKnownTypes(msaGVK.GroupVersion(), msaObj)
-	unstructuredScheme.AddKnownTypes(clsVGVK.GroupVersion(), clsvObj)
-	unstructuredScheme.AddKnownTypes(clsDGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(plsGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(crGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(chGVK.GroupVersion(), channelRuntimeObjects...)
-	unstructuredScheme.AddKnownTypes(clsGVK.GroupVersion(), clusterRuntimeObjects...)
-	unstructuredScheme.AddKnownTypes(clsSGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(bsSGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(mGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(cpGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(dnsGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(imgGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(hGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(subsGVK.GroupVersion())
-	unstructuredScheme.AddKnownTypes(aoGVK.GroupVersion())
+	resourcesBackup := *createBackup(veleroResourcesBackupName, namespaceName).
+		includedResources(resources).
+		startTimestamp(rightNow).
+		excludedNamespaces([]string{"local-cluster", "open-cluster-management-backup"}).
+		labels(map[string]string{
+			BackupScheduleTypeLabel: string(Resources),
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		object
+
+	genericBackup := *createBackup(veleroGenericBackupName, namespaceName).
+		excludedResources(backupManagedClusterResources).
+		startTimestamp(v1.NewTime(aFewSecondsAgo)).
+		labels(map[string]string{
+			BackupScheduleTypeLabel: string(ResourcesGeneric),
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		object
+
+	genericBackupOld := *createBackup(veleroGenericBackupNameOlder, namespaceName).
+		excludedResources(backupManagedClusterResources).
+		startTimestamp(v1.NewTime(tenHourAgo)).
+		labels(map[string]string{
+			BackupScheduleTypeLabel: string(ResourcesGeneric),
+		}).
+		object
+
+	clustersBackup := *createBackup(veleroClustersBackupName, namespaceName).
+		includedResources(backupManagedClusterResources).
+		excludedNamespaces([]string{"local-cluster"}).
+		startTimestamp(v1.NewTime(aFewSecondsAgo)).
+		labels(map[string]string{
+			BackupScheduleTypeLabel: string(ManagedClusters),
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		object
+
+	clustersBackupOld := *createBackup(veleroClustersBackupNameOlder, namespaceName).
+		includedResources(backupManagedClusterResources).
+		excludedNamespaces([]string{"local-cluster"}).
+		startTimestamp(v1.NewTime(tenHourAgo)).
+		labels(map[string]string{
+			BackupScheduleTypeLabel: string(ManagedClusters),
+		}).
+		object
+
+	res_NS := &unstructured.Unstructured{}
+	res_NS.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": namespaceName,
+		},
+	})
+
+	cls_with_backup_label_same := &unstructured.Unstructured{}
+	cls_with_backup_label_same.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name":      "cls-with-backup-label-same",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel: clustersBackup.Name,
+			},
+		},
+	})
+	cls_with_backup_label_diff_excl_ns := &unstructured.Unstructured{}
+	cls_with_backup_label_diff_excl_ns.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name":      "cls-with-backup-label-diff-excl-ns",
+			"namespace": "local-cluster",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel: veleroClustersBackupNameOlder,
+			},
+		},
+	})
+	cls_with_backup_label_diff := &unstructured.Unstructured{}
+	cls_with_backup_label_diff.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-diff",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel: veleroClustersBackupNameOlder,
+			},
+			"finalizers": []interface{}{"hive.openshift.io/deprovision"},
+		},
+	})
+
+	cls_with_no_backup_label := &unstructured.Unstructured{}
+	cls_with_no_backup_label.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "cluster.open-cluster-management.io/v1beta1",
+		"kind":       "ManagedCluster",
+		"metadata": map[string]interface{}{
+			"name":      "cls-with-no-backup-label",
+			"namespace": "default",
+		},
+	})
+
+	channel_with_backup_label_same := &unstructured.Unstructured{}
+	channel_with_backup_label_same.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-same",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel: resourcesBackup.Name,
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+	channel_with_backup_label_diff_excl_ns := &unstructured.Unstructured{}
+	channel_with_backup_label_diff_excl_ns.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-diff-excl-ns",
+			"namespace": "local-cluster",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel: veleroResourcesBackupNameOlder,
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+	channel_with_backup_label_diff := &unstructured.Unstructured{}
+	channel_with_backup_label_diff.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-diff",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel: veleroResourcesBackupNameOlder,
+			},
+			"finalizers": []interface{}{"hive.openshift.io/deprovision"},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+	channel_with_backup_label_generic := &unstructured.Unstructured{}
+	channel_with_backup_label_generic.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-generic",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel:   veleroResourcesBackupName,
+				backupCredsClusterLabel: "i-am-a-generic-resource",
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	channel_with_no_backup_label := &unstructured.Unstructured{}
+	channel_with_no_backup_label.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-no-backup-label",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	msaObj := &unstructured.Unstructured{}
+	msaObj.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "authentication.open-cluster-management.io/v1beta1",
+		"kind":       "ManagedServiceAccount",
+		"metadata": map[string]interface{}{
+			"name":      "auto-import-account",
+			"namespace": "managed1",
+			"labels": map[string]interface{}{
+				msa_label: msa_service_name,
+			},
+		},
+		"spec": map[string]interface{}{
+			"somethingelse": "aaa",
+			"rotation": map[string]interface{}{
+				"validity": "50h",
+				"enabled":  true,
+			},
+		},
+	})
+
+	channel_with_backup_label_generic_match := &unstructured.Unstructured{}
+	channel_with_backup_label_generic_match.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-generic-match",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel:   veleroGenericBackupName,
+				backupCredsClusterLabel: "i-am-a-generic-resource",
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	channel_with_backup_label_generic_old := &unstructured.Unstructured{}
+	channel_with_backup_label_generic_old.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-generic-match-old",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel:   veleroGenericBackupNameOlder,
+				backupCredsClusterLabel: "i-am-a-generic-resource",
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	channel_with_backup_label_generic_match_activ := &unstructured.Unstructured{}
+	channel_with_backup_label_generic_match_activ.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-generic-match-activ",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel:   veleroGenericBackupName,
+				backupCredsClusterLabel: ClusterActivationLabel,
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	channel_with_backup_label_generic_old_activ := &unstructured.Unstructured{}
+	channel_with_backup_label_generic_old_activ.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1beta1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-with-backup-label-generic-old-activ",
+			"namespace": "default",
+			"labels": map[string]interface{}{
+				BackupNameVeleroLabel:   veleroGenericBackupNameOlder,
+				backupCredsClusterLabel: ClusterActivationLabel,
+			},
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	clsvObj := &unstructured.Unstructured{}
+	clsvObj.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "ClusterVersion",
+		"metadata": map[string]interface{}{
+			"name": "version",
+		},
+		"spec": map[string]interface{}{
+			"clusterID": "1234",
+		},
+	})
+
+	msaGVK := schema.GroupVersionKind{
+		Group:   "authentication.open-cluster-management.io",
+		Version: "v1beta1", Kind: "ManagedServiceAccount",
+	}
+	msaGVRList := schema.GroupVersionResource{
+		Group:   "authentication.open-cluster-management.io",
+		Version: "v1beta1", Resource: "managedserviceaccounts",
+	}
+
+	// cluster deployments
+	clsDGVK := schema.GroupVersionKind{
+		Group:   "hive.openshift.io",
+		Version: "v1", Kind: "ClusterDeployment",
+	}
+	clsDGVKList := schema.GroupVersionResource{
+		Group:   "hive.openshift.io",
+		Version: "v1", Resource: "clusterdeployments",
+	}
+
+	// placements
+	plsGVK := schema.GroupVersionKind{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Kind: "Placement",
+	}
+	plsGVKGVKList := schema.GroupVersionResource{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Resource: "placements",
+	}
+	// curators
+	crGVK := schema.GroupVersionKind{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Kind: "ClusterCurator",
+	}
+	crGVKGVKList := schema.GroupVersionResource{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Resource: "clustercurators",
+	}
+	// channels
+	chGVK := schema.GroupVersionKind{
+		Group:   "apps.open-cluster-management.io",
+		Version: "v1beta1", Kind: "Channel",
+	}
+	chGVKList := schema.GroupVersionResource{
+		Group:   "apps.open-cluster-management.io",
+		Version: "v1beta1", Resource: "channels",
+	}
+	// subs
+	subsGVK := schema.GroupVersionKind{
+		Group:   "apps.open-cluster-management.io",
+		Version: "v1beta1", Kind: "Subscription",
+	}
+	subsGVKList := schema.GroupVersionResource{
+		Group:   "apps.open-cluster-management.io",
+		Version: "v1beta1", Resource: "subscriptions",
+	}
+
+	// managed clusters
+	clsGVK := schema.GroupVersionKind{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Kind: "ManagedCluster",
+	}
+	clsGVKList := schema.GroupVersionResource{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Resource: "managedclusters",
+	}
+	// managed clusters sets
+	clsSGVK := schema.GroupVersionKind{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Kind: "ManagedClusterSet",
+	}
+	clsSGVKList := schema.GroupVersionResource{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Resource: "managedclustersets",
+	}
+	// backups
+	bsSGVK := schema.GroupVersionKind{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Kind: "BackupSchedule",
+	}
+	bsSGVKList := schema.GroupVersionResource{
+		Group:   "cluster.open-cluster-management.io",
+		Version: "v1beta1", Resource: "backupschedules",
+	}
+	// mutators
+	mGVK := schema.GroupVersionKind{
+		Group:   "proxy.open-cluster-management.io",
+		Version: "v1beta1", Kind: "AdmissionReview",
+	}
+	mVKList := schema.GroupVersionResource{
+		Group:   "proxy.open-cluster-management.io",
+		Version: "v1beta1", Resource: "managedclustermutators",
+	}
+	// pools
+	cpGVK := schema.GroupVersionKind{
+		Group:   "hive.openshift.io",
+		Version: "v1", Kind: "ClusterPool",
+	}
+	cpVKList := schema.GroupVersionResource{
+		Group:   "hive.openshift.io",
+		Version: "v1", Resource: "clusterpools",
+	}
+	// dns
+	dnsGVK := schema.GroupVersionKind{
+		Group:   "hive.openshift.io",
+		Version: "v1", Kind: "DNSZone",
+	}
+	dnsVKList := schema.GroupVersionResource{
+		Group:   "hive.openshift.io",
+		Version: "v1", Resource: "dnszones",
+	}
+	// image set
+	imgGVK := schema.GroupVersionKind{
+		Group:   "hive.openshift.io",
+		Version: "v1", Kind: "ClusterImageSet",
+	}
+	imgVKList := schema.GroupVersionResource{
+		Group:   "hive.openshift.io",
+		Version: "v1", Resource: "clusterimageset",
+	}
+	// hive config
+	hGVK := schema.GroupVersionKind{
+		Group:   "hive.openshift.io",
+		Version: "v1", Kind: "HiveConfig",
+	}
+	hVKList := schema.GroupVersionResource{
+		Group:   "hive.openshift.io",
+		Version: "v1", Resource: "hiveconfig",
+	}
+
+	// addon
+	aoGVK := schema.GroupVersionKind{
+		Group:   "addon.open-cluster-management.io",
+		Version: "v1alpha1", Kind: "ManagedClusterAddOn",
+	}
+	aoVKList := schema.GroupVersionResource{
+		Group:   "addon.open-cluster-management.io",
+		Version: "v1alpha1", Resource: "managedclusteraddons",
+	}
+
+	// cluster version
+	clsVGVK := schema.GroupVersionKind{
+		Group:   "config.openshift.io",
+		Version: "v1", Kind: "ClusterVersion",
+	}
+	clsVGVKList := schema.GroupVersionResource{
+		Group:   "config.openshift.io",
+		Version: "v1", Resource: "clusterversions",
+	}
+
+	///
+	gvrToListKind := map[schema.GroupVersionResource]string{
+		msaGVRList:    "ManagedServiceAccountList",
+		clsVGVKList:   "ClusterVersionList",
+		clsDGVKList:   "ClusterDeploymentList",
+		plsGVKGVKList: "PlacementList",
+		crGVKGVKList:  "ClusterCuratorList",
+		chGVKList:     "ChannelList",
+		clsGVKList:    "ManagedClusterList",
+		clsSGVKList:   "ManagedClusterSetList",
+		bsSGVKList:    "BackupScheduleList",
+		mVKList:       "AdmissionReviewList",
+		cpVKList:      "ClusterPoolList",
+		dnsVKList:     "DNSZoneList",
+		imgVKList:     "ClusterImageSetList",
+		hVKList:       "HiveConfigList",
+		subsGVKList:   "SubscriptionList",
+		aoVKList:      "ManagedClusterAddOnList",
+	}
+
+	channelRuntimeObjects := []runtime.Object{
+		channel_with_backup_label_same,
+		channel_with_backup_label_diff,
+		channel_with_backup_label_diff_excl_ns,
+		channel_with_backup_label_generic,
+		channel_with_no_backup_label,
+		channel_with_backup_label_generic_match,
+		channel_with_backup_label_generic_old,
+		channel_with_backup_label_generic_match_activ,
+		channel_with_backup_label_generic_old_activ,
+	}
+	clusterRuntimeObjects := []runtime.Object{
+		cls_with_backup_label_same,
+		cls_with_backup_label_diff,
+		cls_with_backup_label_diff_excl_ns,
+		cls_with_no_backup_label,
+	}
+	unstructuredScheme := runtime.NewScheme()
+	unstructuredScheme.AddKnownTypes(msaGVK.GroupVersion(), msaObj)
+	unstructuredScheme.AddKnownTypes(clsVGVK.GroupVersion(), clsvObj)
+	unstructuredScheme.AddKnownTypes(clsDGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(plsGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(crGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(chGVK.GroupVersion(), channelRuntimeObjects...)
+	unstructuredScheme.AddKnownTypes(clsGVK.GroupVersion(), clusterRuntimeObjects...)
+	unstructuredScheme.AddKnownTypes(clsSGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(bsSGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(mGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(cpGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(dnsGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(imgGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(hGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(subsGVK.GroupVersion())
+	unstructuredScheme.AddKnownTypes(aoGVK.GroupVersion())
+
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
+		gvrToListKind,
+		channelRuntimeObjects...)
+
+	argov1alphaInfo := metav1.APIResourceList{
+		GroupVersion: "argoproj.io/v1alpha1",
+		APIResources: []metav1.APIResource{
+			{Name: "applications", Namespaced: true, Kind: "Application"},
+			{Name: "applicationsets", Namespaced: true, Kind: "ApplicationSet"},
+			{Name: "argocds", Namespaced: true, Kind: "Argocd"},
+		},
+	}
+	openshiftv1Info := metav1.APIResourceList{
+		GroupVersion: "config.openshift.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "clusterversions", Namespaced: false, Kind: "ClusterVersion"},
+		},
+	}
+	appsInfo := metav1.APIResourceList{
+		GroupVersion: "apps.open-cluster-management.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "channels", Namespaced: true, Kind: "Channel"},
+			{Name: "subscriptions", Namespaced: true, Kind: "Subscription"},
+		},
+	}
+	appsInfoV1 := metav1.APIResourceList{
+		GroupVersion: "apps.open-cluster-management.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "channels", Namespaced: true, Kind: "Channel"},
+			{Name: "subscriptions", Namespaced: true, Kind: "Subscription"},
+		},
+	}
+	addonInfo := metav1.APIResourceList{
+		GroupVersion: "addon.open-cluster-management.io/v1alpha1",
+		APIResources: []metav1.APIResource{
+			{Name: "managedclusteraddons", Namespaced: true, Kind: "ManagedClusterAddOn"},
+		},
+	}
+	clusterv1beta1Info := metav1.APIResourceList{
+		GroupVersion: "cluster.open-cluster-management.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "placements", Namespaced: true, Kind: "Placement"},
+			{Name: "clustercurators", Namespaced: true, Kind: "ClusterCurator"},
+			{Name: "managedclustersets", Namespaced: false, Kind: "ManagedClusterSet"},
+			{Name: "backupschedules", Namespaced: true, Kind: "BackupSchedule"},
+			{Name: "managedclusters", Namespaced: true, Kind: "ManagedCluster"},
+		},
+	}
+	clusterv1Info := metav1.APIResourceList{
+		GroupVersion: "cluster.open-cluster-management.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "placements", Namespaced: true, Kind: "Placement"},
+			{Name: "clustercurators", Namespaced: true, Kind: "ClusterCurator"},
+			{Name: "managedclustersets", Namespaced: false, Kind: "ManagedClusterSet"},
+			{Name: "backupschedules", Namespaced: true, Kind: "BackupSchedule"},
+			{Name: "managedclusters", Namespaced: true, Kind: "ManagedCluster"},
+		},
+	}
+	excluded := metav1.APIResourceList{
+		GroupVersion: "proxy.open-cluster-management.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "managedclustermutators", Namespaced: false, Kind: "AdmissionReview"},
+		},
+	}
+	hiveInfo := metav1.APIResourceList{
+		GroupVersion: "hive.openshift.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "clusterpools", Namespaced: true, Kind: "ClusterPool"},
+			{Name: "clusterdeployments", Namespaced: false, Kind: "ClusterDeployment"},
+			{Name: "dnszones", Namespaced: false, Kind: "DNSZone"},
+			{Name: "clusterimageset", Namespaced: false, Kind: "ClusterImageSet"},
+			{Name: "hiveconfig", Namespaced: false, Kind: "HiveConfig"},
+		},
+	}
+	authAlpha1 := metav1.APIResourceList{
+		GroupVersion: "authentication.open-cluster-management.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "managedserviceaccounts", Namespaced: true, Kind: "ManagedServiceAccount"},
+		},
+	}
+
+	server1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var list interface{}
+		switch req.URL.Path {
+		case "/apis/cluster.open-cluster-management.io/v1beta1":
+			list = &clusterv1beta1Info
+		case "/apis/cluster.open-cluster-management.io/v1":
+			list = &clusterv1Info
+		case "/apis/proxy.open-cluster-management.io/v1beta1":
+			list = &excluded
+		case "/apis/hive.openshift.io/v1":
+			list = &hiveInfo
+		case "/apis/authentication.open-cluster-management.io/v1beta1":
+			list = authAlpha1
+		case "/apis/apps.open-cluster-management.io/v1beta1":
+			list = &appsInfo
+		case "/apis/apps.open-cluster-management.io/v1":
+			list = &appsInfoV1
+		case "/apis/argoproj.io/v1alpha1":
+			list = &argov1alphaInfo
+		case "/apis/config.openshift.io/v1":
+			list = &openshiftv1Info
+		case "/apis/addon.open-cluster-management.io/v1alpha1":
+			list = &addonInfo
+
+		case "/api":
+			list = &metav1.APIVersions{
+				Versions: []string{
+					"v1",
+					"v1beta1",
+				},
+			}
+		case "/apis":
+			list = &metav1.APIGroupList{
+				Groups: []metav1.APIGroup{
+					{
+						Name: "config.openshift.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: "config.openshift.io/v1",
+								Version:      "v1",
+							},
+						},
+					},
+					{
+						Name: "argoproj.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: "argoproj.io/v1",
+								Version:      "v1",
+							},
+							{
+								GroupVersion: "argoproj.io/v1beta1",
+								Version:      "v1beta1",
+							},
+						},
+					},
+					{
+						Name: "cluster.open-cluster-management.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: "cluster.open-cluster-management.io/v1beta1",
+								Version:      "v1beta1",
+							},
+							{
+								GroupVersion: "cluster.open-cluster-management.io/v1",
+								Version:      "v1",
+							},
+						},
+					},
+					{
+						Name: "proxy.open-cluster-management.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: "proxy.open-cluster-management.io/v1beta1",
+								Version:      "v1beta1",
+							},
+							{
+								GroupVersion: "proxy.open-cluster-management.io/v1",
+								Version:      "v1",
+							},
+						},
+					},
+					{
+						Name: "hive.openshift.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{GroupVersion: "hive.openshift.io/v1", Version: "v1"},
+							{GroupVersion: "hive.openshift.io/v1beta1", Version: "v1beta1"},
+						},
+					},
+					{
+						Name: "apps.open-cluster-management.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{GroupVersion: "apps.open-cluster-management.io/v1beta1", Version: "v1beta1"},
+							{GroupVersion: "apps.open-cluster-management.io/v1", Version: "v1"},
+						},
+					},
+					{
+						Name: "authentication.open-cluster-management.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{GroupVersion: "authentication.open-cluster-management.io/v1beta1", Version: "v1beta1"},
+						},
+					},
+					{
+						Name: "addon.open-cluster-management.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{GroupVersion: "addon.open-cluster-management.io/v1alpha1", Version: "v1alpha1"},
+						},
+					},
+				},
+			}
+		default:
+			// t.Logf("unexpected request: %s", req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		output, err := json.Marshal(list)
+		if err != nil {
+			// t.Errorf("unexpected encoding error: %v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(output)
+		if err != nil {
+			return
+		}
+	}))
+
+	fakeDiscovery := discoveryclient.NewDiscoveryClientForConfigOrDie(
+		&restclient.Config{Host: server1.URL},
+	)
+
+	testRequest := "authentication.open-cluster-management.io/v1beta1"
+	_, err = fakeDiscovery.ServerResourcesForGroupVersion(testRequest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	// create some channel resources
+	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
+		channel_with_backup_label_same, v1.CreateOptions{})
+	if client.IgnoreAlreadyExists(err) != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
+		channel_with_backup_label_diff, v1.CreateOptions{})
+	if client.IgnoreAlreadyExists(err) != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(chGVKList).Namespace("local-cluster").Create(context.Background(),
+		channel_with_backup_label_diff_excl_ns, v1.CreateOptions{})
+	if client.IgnoreAlreadyExists(err) != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
+		channel_with_backup_label_generic, v1.CreateOptions{})
+	if client.IgnoreAlreadyExists(err) != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
+		channel_with_no_backup_label, v1.CreateOptions{})
+	if client.IgnoreAlreadyExists(err) != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+
+	// create some cluster resources
+	_, err = dyn.Resource(clsGVKList).Namespace("default").Create(context.Background(),
+		cls_with_backup_label_diff, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(clsGVKList).Namespace("default").Create(context.Background(),
+		cls_with_backup_label_same, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(clsGVKList).Namespace("local-cluster").Create(context.Background(),
+		cls_with_backup_label_diff_excl_ns, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+	_, err = dyn.Resource(clsGVKList).Namespace("default").Create(context.Background(),
+		cls_with_no_backup_label, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+
+	//
+	_, err = dyn.Resource(msaGVRList).Namespace("managed1").Create(context.Background(),
+		msaObj, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating: %s", err.Error())
+	}
+
+	reconcileArgs := DynamicStruct{
+		dc:  fakeDiscovery,
+		dyn: dyn,
+	}
+
+	m := restmapper.NewDeferredDiscoveryRESTMapper(
+		memory.NewMemCacheClient(fakeDiscovery),
+	)
+
+	resOptionsCleanupAll := RestoreOptions{
+		dynamicArgs: reconcileArgs,
+		cleanupType: v1beta1.CleanupTypeAll,
+		mapper:      m,
+	}
+
+	resOptionsCleanupRestored := RestoreOptions{
+		dynamicArgs: reconcileArgs,
+		cleanupType: v1beta1.CleanupTypeRestored,
+		mapper:      m,
+	}
+
+	type args struct {
+		ctx            context.Context
+		c              client.Client
+		restoreOptions RestoreOptions
+		acmRestore     *v1beta1.Restore
+		veleroBackup   *veleroapi.Backup
+		backupName     string
+	}
+	testsResources := []struct {
+		name                 string
+		args                 args
+		resourcesToBeDeleted []unstructured.Unstructured
+		resourcesToKeep      []unstructured.Unstructured
+		extraBackups         []veleroapi.Backup
+		resourcesToCreate    []unstructured.Unstructured
+	}{
+		{
+			name: "cleanup resources backup, no generic backup found",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupRestored,
+				veleroBackup:   &resourcesBackup,
+				backupName:     veleroResourcesBackupName,
+				acmRestore: createACMRestore("restore-acm", namespaceName).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName:        veleroResourcesBackupName,
+						VeleroGenericResourcesRestoreName: veleroResourcesBackupName,
+					}).
+					phase(v1beta1.RestorePhaseEnabled).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{*channel_with_backup_label_diff},
+			resourcesToKeep: []unstructured.Unstructured{
+				*channel_with_no_backup_label,
+				*channel_with_backup_label_generic,
+				*channel_with_backup_label_same,
+				*channel_with_backup_label_diff_excl_ns,
+			},
+			extraBackups:      []veleroapi.Backup{},
+			resourcesToCreate: []unstructured.Unstructured{},
+		},
+		{
+			name: "cleanup resources backup, with generic backup NOT found, old one available",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupRestored,
+				veleroBackup:   &resourcesBackup,
+				backupName:     veleroResourcesBackupName,
+				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName:        veleroResourcesBackupName,
+						VeleroGenericResourcesRestoreName: veleroGenericBackupNameOlder,
+					}).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{*channel_with_backup_label_diff},
+			resourcesToKeep: []unstructured.Unstructured{
+				*channel_with_no_backup_label,
+				*channel_with_backup_label_generic,
+				*channel_with_backup_label_same,
+				*channel_with_backup_label_diff_excl_ns,
+			},
+			extraBackups: []veleroapi.Backup{genericBackupOld},
+			resourcesToCreate: []unstructured.Unstructured{
+				*channel_with_backup_label_diff, // this is the one deleted by the previous test, add it back
+			},
+		},
+		{
+			name: "cleanup resources backup, with generic backup NOT found and clusters backup not skipped",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupRestored,
+				veleroBackup:   &resourcesBackup,
+				backupName:     veleroResourcesBackupName,
+				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
+					veleroManagedClustersBackupName(veleroClustersBackupName).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName:        veleroResourcesBackupName,
+						VeleroGenericResourcesRestoreName: "invalid-generic-name",
+					}).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{
+				*channel_with_backup_label_diff,
+			},
+			resourcesToKeep: []unstructured.Unstructured{
+				*channel_with_no_backup_label,
+				*channel_with_backup_label_same,
+				*channel_with_backup_label_diff_excl_ns,
+				*channel_with_backup_label_generic_match,
+				*channel_with_backup_label_generic_match_activ,
+				*channel_with_backup_label_generic_old, // should not be deleted since the matching generic backup was not found
+				*channel_with_backup_label_generic,     // should not be deleted since the matching generic backup was not found
+
+			},
+			extraBackups: []veleroapi.Backup{},
+			resourcesToCreate: []unstructured.Unstructured{ // this is the one deleted by the previous test, add it back
+				*channel_with_backup_label_diff,
+			},
+		},
+		{
+			name: "cleanup resources backup, with generic backup found",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupRestored,
+				veleroBackup:   &resourcesBackup,
+				backupName:     veleroResourcesBackupName,
+				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName:        veleroResourcesBackupName,
+						VeleroGenericResourcesRestoreName: veleroGenericBackupName,
+					}).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{
+				*channel_with_backup_label_diff,
+				*channel_with_backup_label_generic_old,
+				*channel_with_backup_label_generic, // it's deleted bc the backup name doesn't match the generic backup
+			},
+			resourcesToKeep: []unstructured.Unstructured{
+				*channel_with_no_backup_label,
+				*channel_with_backup_label_same,
+				*channel_with_backup_label_diff_excl_ns,
+				*channel_with_backup_label_generic_match,
+				*channel_with_backup_label_generic_match_activ,
+				*channel_with_backup_label_generic_old_activ,
+			},
+			extraBackups: []veleroapi.Backup{genericBackup},
+			resourcesToCreate: []unstructured.Unstructured{
+				*channel_with_backup_label_diff, // this is the one deleted by the previous test, add it back
+			},
+		},
+		{
+			name: "cleanup resources backup, with generic backup found and clusters backup not skipped",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupRestored,
+				veleroBackup:   &resourcesBackup,
+				backupName:     veleroResourcesBackupName,
+				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
+					veleroManagedClustersBackupName(veleroClustersBackupName).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName:        veleroResourcesBackupName,
+						VeleroGenericResourcesRestoreName: veleroGenericBackupName,
+					}).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{
+				*channel_with_backup_label_diff,
+				*channel_with_backup_label_generic_old,
+				*channel_with_backup_label_generic,           // it's deleted bc the backup name doesn't match the generic backup
+				*channel_with_backup_label_generic_old_activ, // deleted bc the clusters backup is enabled
+			},
+			resourcesToKeep: []unstructured.Unstructured{
+				*channel_with_no_backup_label,
+				*channel_with_backup_label_same,
+				*channel_with_backup_label_diff_excl_ns,
+				*channel_with_backup_label_generic_match,
+				*channel_with_backup_label_generic_match_activ,
+			},
+			extraBackups: []veleroapi.Backup{},
+			resourcesToCreate: []unstructured.Unstructured{ // this is the one deleted by the previous test, add it back
+				*channel_with_backup_label_diff,
+			},
+		},
+		{
+			name: "cleanup resources backup, with generic backup found and clusters backup not skipped, cleanup all",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupAll,
+				veleroBackup:   &resourcesBackup,
+				backupName:     veleroResourcesBackupName,
+				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
+					veleroManagedClustersBackupName(veleroClustersBackupName).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName:        veleroResourcesBackupName,
+						VeleroGenericResourcesRestoreName: veleroGenericBackupName,
+					}).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{
+				*channel_with_backup_label_diff,
+				*channel_with_backup_label_generic_old,
+				*channel_with_backup_label_generic,           // it's deleted bc the backup name doesn't match the generic backup
+				*channel_with_backup_label_generic_old_activ, // deleted bc the clusters backup is enabled
+				*channel_with_no_backup_label,                // delete all, even the ones with no label
+			},
+			resourcesToKeep: []unstructured.Unstructured{
+				*channel_with_backup_label_same,
+				*channel_with_backup_label_diff_excl_ns,
+				*channel_with_backup_label_generic_match,
+				*channel_with_backup_label_generic_match_activ,
+			},
+			extraBackups: []veleroapi.Backup{},
+			resourcesToCreate: []unstructured.Unstructured{ // this is the one deleted by the previous test, add it back
+				*channel_with_backup_label_diff,
+				*channel_with_backup_label_generic_old,
+				*channel_with_backup_label_generic,           // it's deleted bc the backup name doesn't match the generic backup
+				*channel_with_backup_label_generic_old_activ, // deleted bc the clusters backup is enabled
+			},
+		},
+	}
+
+	testsClusters := []struct {
+		name                 string
+		args                 args
+		resourcesToBeDeleted []unstructured.Unstructured
+		resourcesToKeep      []unstructured.Unstructured
+		extraBackups         []veleroapi.Backup
+		resourcesToCreate    []unstructured.Unstructured
+	}{
+		{
+			name: "cleanup clusters backup",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				restoreOptions: resOptionsCleanupRestored,
+				veleroBackup:   &clustersBackup,
+				backupName:     veleroClustersBackupName,
+				acmRestore: createACMRestore("acm-restore", namespaceName).
+					veleroManagedClustersBackupName(veleroClustersBackupName).
+					restoreACMStatus(v1beta1.RestoreStatus{
+						VeleroResourcesRestoreName: veleroClustersBackupName,
+					}).
+					object,
+			},
+			resourcesToBeDeleted: []unstructured.Unstructured{*cls_with_backup_label_diff},
+			resourcesToKeep: []unstructured.Unstructured{
+				*cls_with_no_backup_label,
+				*cls_with_backup_label_same,
+				*cls_with_backup_label_diff_excl_ns,
+			},
+			extraBackups:      []veleroapi.Backup{clustersBackupOld},
+			resourcesToCreate: []unstructured.Unstructured{},
+		},
+	}
+
+	if err := k8sClient1.Create(context.Background(), createNamespace(genericBackup.GetNamespace())); err != nil {
+		t.Fatalf("cannot create ns %s ", err.Error())
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(
+		memory.NewMemCacheClient(resOptionsCleanupRestored.dynamicArgs.dc),
+	)
+
+	// create restore resources
+	////////
+	createRestores := []veleroapi.Restore{
+		*createRestore(veleroResourcesBackupName, namespaceName).
+			backupName(veleroResourcesBackupName).object,
+		*createRestore(veleroClustersBackupNameOlder, namespaceName).
+			backupName(veleroClustersBackupNameOlder).object,
+		*createRestore(veleroClustersBackupName, namespaceName).
+			backupName(veleroClustersBackupName).object,
+		*createRestore(veleroGenericBackupName, namespaceName).
+			backupName(veleroGenericBackupName).object,
+		*createRestore(veleroResourcesBackupNameOlder, namespaceName).
+			backupName(veleroResourcesBackupNameOlder).object,
+	}
+	for i := range createRestores {
+		if err := k8sClient1.Create(context.Background(), &createRestores[i]); err != nil {
+			t.Fatalf("cannot create restore %s ", err.Error())
+		}
+	}
+	////////
+
+	for _, tt := range testsResources {
+		_ = k8sClient1.Create(tt.args.ctx, tt.args.veleroBackup)
+
+		// create extra backups for this test
+		for i := range tt.extraBackups {
+			if err := k8sClient1.Create(tt.args.ctx, &tt.extraBackups[i]); err != nil {
+				t.Errorf("cannot create backup %s ", err.Error())
+			}
+		}
+
+		// create resources for this test
+		for i := range tt.resourcesToCreate {
+			if _, err := dyn.Resource(chGVKList).Namespace(tt.resourcesToCreate[i].GetNamespace()).Create(context.Background(),
+				&tt.resourcesToCreate[i], v1.CreateOptions{}); err != nil {
+				t.Errorf("cannot create resource %s ", err.Error())
+			}
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupDeltaForResourcesBackup(tt.args.ctx,
+				tt.args.c,
+				tt.args.restoreOptions,
+				tt.args.acmRestore)
+		})
+
+		groupKind := schema.GroupKind{
+			Group: chGVK.Group,
+			Kind:  chGVK.Kind,
+		}
+
+		mapping, _ := mapper.RESTMapping(groupKind, "")
+		dr := tt.args.restoreOptions.dynamicArgs.dyn.Resource(mapping.Resource)
+
+		for i := range tt.resourcesToBeDeleted {
+			if _, err := dr.Namespace(tt.resourcesToBeDeleted[i].GetNamespace()).
+				Get(tt.args.ctx, tt.resourcesToBeDeleted[i].GetName(), v1.GetOptions{}); err == nil {
+				t.Errorf("cleanupDeltaForResourcesBackup(%s) resource %s should NOT be found",
+					tt.name, tt.resourcesToBeDeleted[i])
+			}
+		}
+
+		for i := range tt.resourcesToKeep {
+			if _, err := dr.Namespace(tt.resourcesToKeep[i].GetNamespace()).
+				Get(tt.args.ctx, tt.resourcesToKeep[i].GetName(), v1.GetOptions{}); err != nil {
+				t.Errorf("cleanupDeltaForResourcesBackup(%s) resource %s should be found ! they were deleted",
+					tt.name, tt.resourcesToKeep[i].GetName())
+			}
+		}
+
+	}
+
+	for _, tt := range testsClusters {
+
+		if err := k8sClient1.Create(tt.args.ctx, tt.args.veleroBackup); err != nil {
+			t.Errorf("Error creating veleroBackup: %s", err.Error())
+		}
+		// create extra backups for this test
+		for i := range tt.extraBackups {
+			if err := k8sClient1.Create(tt.args.ctx, &tt.extraBackups[i]); err != nil {
+				t.Errorf("cannot create backup %s ", err.Error())
+			}
+		}
+
+		// create resources for this test
+		for i := range tt.resourcesToCreate {
+			if _, err := dyn.Resource(chGVKList).Namespace(tt.resourcesToCreate[i].GetNamespace()).Create(context.Background(),
+				&tt.resourcesToCreate[i], v1.CreateOptions{}); err != nil {
+				t.Errorf("cannot create resource %s ", err.Error())
+			}
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupDeltaForClustersBackup(tt.args.ctx,
+				tt.args.c,
+				tt.args.restoreOptions,
+				tt.args.backupName,
+				tt.args.veleroBackup)
+		})
+
+		// managed cluster group
+		groupKind := schema.GroupKind{
+			Group: clsGVK.Group,
+			Kind:  clsGVK.Kind,
+		}
+		mapping, _ := mapper.RESTMapping(groupKind, "")
+		dr := tt.args.restoreOptions.dynamicArgs.dyn.Resource(mapping.Resource)
+
+		for i := range tt.resourcesToBeDeleted {
+			if _, err := dr.Namespace(tt.resourcesToBeDeleted[i].GetNamespace()).
+				Get(tt.args.ctx, tt.resourcesToBeDeleted[i].GetName(), v1.GetOptions{}); err == nil {
+				t.Errorf("cleanupDeltaForClustersBackup(%s) resource %s should NOT be found",
+					tt.name, tt.resourcesToBeDeleted[i])
+			}
+		}
 
-	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
-		gvrToListKind,
-		channelRuntimeObjects...)
+		for i := range tt.resourcesToKeep {
+			if _, err := dr.Namespace(tt.resourcesToKeep[i].GetNamespace()).
+				Get(tt.args.ctx, tt.resourcesToKeep[i].GetName(), v1.GetOptions{}); err != nil {
+				t.Errorf("cleanupDeltaForClustersBackup(%s) resource %s should be found ! they were deleted",
+					tt.name, tt.resourcesToKeep[i].GetName())
+			}
+		}
 
-	argov1alphaInfo := metav1.APIResourceList{
-		GroupVersion: "argoproj.io/v1alpha1",
-		APIResources: []metav1.APIResource{
-			{Name: "applications", Namespaced: true, Kind: "Application"},
-			{Name: "applicationsets", Namespaced: true, Kind: "ApplicationSet"},
-			{Name: "argocds", Namespaced: true, Kind: "Argocd"},
-		},
 	}
-	openshiftv1Info := metav1.APIResourceList{
-		GroupVersion: "config.openshift.io/v1",
-		APIResources: []metav1.APIResource{
-			{Name: "clusterversions", Namespaced: false, Kind: "ClusterVersion"},
-		},
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
-	appsInfo := metav1.APIResourceList{
-		GroupVersion: "apps.open-cluster-management.io/v1beta1",
-		APIResources: []metav1.APIResource{
-			{Name: "channels", Namespaced: true, Kind: "Channel"},
-			{Name: "subscriptions", Namespaced: true, Kind: "Subscription"},
-		},
+}
+
+func Test_executePostRestoreTasks_resumeActivation(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	schemeErrs := []error{}
+	schemeErrs = append(schemeErrs, veleroapi.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, clusterv1.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, corev1.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, v1beta1.AddToScheme(scheme1))
+	if err := errors.Join(schemeErrs...); err != nil {
+		t.Fatalf("Error adding api(s) to scheme: %s", err.Error())
 	}
-	appsInfoV1 := metav1.APIResourceList{
-		GroupVersion: "apps.open-cluster-management.io/v1",
-		APIResources: []metav1.APIResource{
-			{Name: "channels", Namespaced: true, Kind: "Channel"},
-			{Name: "subscriptions", Namespaced: true, Kind: "Subscription"},
-		},
+
+	now := time.Now().In(time.UTC)
+	msaAnnotations := map[string]string{
+		"lastRefreshTimestamp": now.Add(-time.Hour).Format(time.RFC3339),
+		"expirationTimestamp":  now.Add(10 * time.Hour).Format(time.RFC3339),
+	}
+	msaData := map[string][]byte{"token": []byte("YWRtaW4=")}
+	notAvailable := []metav1.Condition{{Status: v1.ConditionFalse}}
+
+	// the restore was interrupted after managed1 was activated
+	acmRestore := createACMRestore("acm-restore", "acm-ns").
+		veleroManagedClustersBackupName(latestBackupStr).
+		veleroCredentialsBackupName(latestBackupStr).
+		veleroResourcesBackupName(latestBackupStr).
+		phase(v1beta1.RestorePhaseFinished).object
+	acmRestore.Status.ActivationPhase = v1beta1.ActivationPhaseInProgress
+	acmRestore.Status.ActivatedClusters = []string{"managed1"}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithStatusSubresource(&v1beta1.Restore{}).
+		WithObjects(
+			acmRestore,
+			createManagedCluster("managed1", false).clusterUrl("someurl").conditions(notAvailable).object,
+			createManagedCluster("managed2", false).clusterUrl("someurl").conditions(notAvailable).object,
+			createSecret(msa_service_name, "managed1",
+				map[string]string{msa_label: "true"}, msaAnnotations, msaData),
+			createSecret(msa_service_name, "managed2",
+				map[string]string{msa_label: "true"}, msaAnnotations, msaData),
+			// auto-import-secret created before the restart
+			createSecret(autoImportSecretName, "managed1",
+				map[string]string{activateLabel: "true"}, nil, map[string][]byte{"token": []byte("before-restart")}),
+		).
+		Build()
+
+	if !executePostRestoreTasks(context.Background(), fakeClient, acmRestore) {
+		t.Fatalf("executePostRestoreTasks() returns = false, want true")
+	}
+
+	if acmRestore.Status.ActivationPhase != v1beta1.ActivationPhaseCompleted {
+		t.Errorf("ActivationPhase = %v, want %v", acmRestore.Status.ActivationPhase,
+			v1beta1.ActivationPhaseCompleted)
+	}
+	wantActivated := []string{"managed1", "managed2"}
+	if !reflect.DeepEqual(acmRestore.Status.ActivatedClusters, wantActivated) {
+		t.Errorf("ActivatedClusters = %v, want %v", acmRestore.Status.ActivatedClusters, wantActivated)
+	}
+
+	// the activated clusters are persisted while the activation runs
+	persistedRestore := &v1beta1.Restore{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(acmRestore),
+		persistedRestore); err != nil {
+		t.Fatalf("Error getting restore: %s", err.Error())
+	}
+	if !reflect.DeepEqual(persistedRestore.Status.ActivatedClusters, wantActivated) {
+		t.Errorf("persisted ActivatedClusters = %v, want %v",
+			persistedRestore.Status.ActivatedClusters, wantActivated)
+	}
+
+	// managed1 was not activated again
+	secret := corev1.Secret{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{
+		Name:      autoImportSecretName,
+		Namespace: "managed1",
+	}, &secret); err != nil || string(secret.Data["token"]) != "before-restart" {
+		t.Errorf("auto-import-secret for managed1 should not be re-created, err %v", err)
+	}
+	// managed2 was activated
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{
+		Name:      autoImportSecretName,
+		Namespace: "managed2",
+	}, &secret); err != nil {
+		t.Errorf("auto-import-secret for managed2 should be created, err %v", err)
+	}
+
+	// no backup recording the restore operation is created when resuming the activation
+	backups := veleroapi.BackupList{}
+	if err := fakeClient.List(context.Background(), &backups); err != nil || len(backups.Items) != 0 {
+		t.Errorf("no velero backup should be created when resuming the activation, got %d, err %v",
+			len(backups.Items), err)
 	}
-	addonInfo := metav1.APIResourceList{
-		GroupVersion: "addon.open-cluster-management.io/v1alpha1",
-		APIResources: []metav1.APIResource{
-			{Name: "managedclusteraddons", Namespaced: true, Kind: "ManagedClusterAddOn"},
-		},
+}
+
+func Test_RestoreReconciler_cleanupOnRestore_invalidImportTokens(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	schemeErrs := []error{}
+	schemeErrs = append(schemeErrs, veleroapi.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, clusterv1.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, corev1.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, v1beta1.AddToScheme(scheme1))
+	if err := errors.Join(schemeErrs...); err != nil {
+		t.Fatalf("Error adding api(s) to scheme: %s", err.Error())
 	}
-	clusterv1beta1Info := metav1.APIResourceList{
-		GroupVersion: "cluster.open-cluster-management.io/v1beta1",
-		APIResources: []metav1.APIResource{
-			{Name: "placements", Namespaced: true, Kind: "Placement"},
-			{Name: "clustercurators", Namespaced: true, Kind: "ClusterCurator"},
-			{Name: "managedclustersets", Namespaced: false, Kind: "ManagedClusterSet"},
-			{Name: "backupschedules", Namespaced: true, Kind: "BackupSchedule"},
-			{Name: "managedclusters", Namespaced: true, Kind: "ManagedCluster"},
-		},
+
+	now := time.Now().In(time.UTC)
+	validAnnotations := map[string]string{
+		"lastRefreshTimestamp": now.Add(-time.Hour).Format(time.RFC3339),
+		"expirationTimestamp":  now.Add(10 * time.Hour).Format(time.RFC3339),
 	}
-	clusterv1Info := metav1.APIResourceList{
-		GroupVersion: "cluster.open-cluster-management.io/v1",
-		APIResources: []metav1.APIResource{
-			{Name: "placements", Namespaced: true, Kind: "Placement"},
-			{Name: "clustercurators", Namespaced: true, Kind: "ClusterCurator"},
-			{Name: "managedclustersets", Namespaced: false, Kind: "ManagedClusterSet"},
-			{Name: "backupschedules", Namespaced: true, Kind: "BackupSchedule"},
-			{Name: "managedclusters", Namespaced: true, Kind: "ManagedCluster"},
-		},
+	expiredAnnotations := map[string]string{
+		"lastRefreshTimestamp": now.Add(-4 * time.Hour).Format(time.RFC3339),
+		"expirationTimestamp":  now.Add(-4 * time.Hour).Format(time.RFC3339),
 	}
-	excluded := metav1.APIResourceList{
-		GroupVersion: "proxy.open-cluster-management.io/v1beta1",
-		APIResources: []metav1.APIResource{
-			{Name: "managedclustermutators", Namespaced: false, Kind: "AdmissionReview"},
+	msaData := map[string][]byte{"token": []byte("YWRtaW4=")}
+	notAvailable := []metav1.Condition{{Status: v1.ConditionFalse}}
+
+	acmRestore := createACMRestore("acm-restore", "acm-ns").
+		cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+		veleroManagedClustersBackupName(latestBackupStr).
+		veleroCredentialsBackupName(latestBackupStr).
+		veleroResourcesBackupName(latestBackupStr).
+		phase(v1beta1.RestorePhaseStarted).object
+	veleroRestore := createRestore("acm-restore-managed-clusters", "acm-ns").
+		phase(veleroapi.RestorePhaseCompleted).object
+	veleroRestore.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: apiGVStr,
+			Kind:       "Restore",
+			Name:       acmRestore.Name,
+			UID:        "fed287da-02ea-4c83-a7f8-906ce662451a",
+			Controller: &[]bool{true}[0],
 		},
+	})
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithStatusSubresource(&v1beta1.Restore{}).
+		WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+		WithObjects(
+			acmRestore,
+			veleroRestore,
+			createManagedCluster("managed1", false).clusterUrl("someurl").conditions(notAvailable).object,
+			createManagedCluster("managed2", false).clusterUrl("someurl").conditions(notAvailable).object,
+			createSecret(msa_service_name, "managed1",
+				map[string]string{msa_label: "true"}, validAnnotations, msaData),
+			// managed2 has only an expired token
+			createSecret(msa_service_name, "managed2",
+				map[string]string{msa_label: "true"}, expiredAnnotations, msaData),
+		).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &RestoreReconciler{
+		Client:          fakeClient,
+		Scheme:          scheme1,
+		DiscoveryClient: &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{}},
+		Recorder:        recorder,
+	}
+
+	r.cleanupOnRestore(context.Background(), acmRestore)
+
+	if acmRestore.Status.ActivationPhase != v1beta1.ActivationPhaseCompleted {
+		t.Errorf("ActivationPhase = %v, want %v", acmRestore.Status.ActivationPhase,
+			v1beta1.ActivationPhaseCompleted)
+	}
+	if want := []string{"managed2"}; !reflect.DeepEqual(acmRestore.Status.InvalidImportTokens, want) {
+		t.Errorf("InvalidImportTokens = %v, want %v", acmRestore.Status.InvalidImportTokens, want)
+	}
+	if want := []string{"managed1"}; !reflect.DeepEqual(acmRestore.Status.ActivatedClusters, want) {
+		t.Errorf("ActivatedClusters = %v, want %v", acmRestore.Status.ActivatedClusters, want)
+	}
+
+	// a warning event lists the clusters not imported
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, corev1.EventTypeWarning+" Invalid import tokens") ||
+			!strings.HasSuffix(event, ": managed2") {
+			t.Errorf("event = %v, want a warning for managed2", event)
+		}
+	default:
+		t.Errorf("no event recorded, want a warning for managed2")
 	}
-	hiveInfo := metav1.APIResourceList{
-		GroupVersion: "hive.openshift.io/v1",
-		APIResources: []metav1.APIResource{
-			{Name: "clusterpools", Namespaced: true, Kind: "ClusterPool"},
-			{Name: "clusterdeployments", Namespaced: false, Kind: "ClusterDeployment"},
-			{Name: "dnszones", Namespaced: false, Kind: "DNSZone"},
-			{Name: "clusterimageset", Namespaced: false, Kind: "ClusterImageSet"},
-			{Name: "hiveconfig", Namespaced: false, Kind: "HiveConfig"},
-		},
+
+	// the warning is not repeated once the activation is completed
+	r.cleanupOnRestore(context.Background(), acmRestore)
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("unexpected event %v, the activation is already completed", event)
+	default:
 	}
-	authAlpha1 := metav1.APIResourceList{
-		GroupVersion: "authentication.open-cluster-management.io/v1beta1",
-		APIResources: []metav1.APIResource{
-			{Name: "managedserviceaccounts", Namespaced: true, Kind: "ManagedServiceAccount"},
-		},
+}
+
+func Test_deleteDynamicResources_managedClusterNamespaces(t *testing.T) {
+	newNamespace := func(name string) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+		})
+		return res
 	}
 
-	server1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		var list interface{}
-		switch req.URL.Path {
-		case "/apis/cluster.open-cluster-management.io/v1beta1":
-			list = &clusterv1beta1Info
-		case "/apis/cluster.open-cluster-management.io/v1":
-			list = &clusterv1Info
-		case "/apis/proxy.open-cluster-management.io/v1beta1":
-			list = &excluded
-		case "/apis/hive.openshift.io/v1":
-			list = &hiveInfo
-		case "/apis/authentication.open-cluster-management.io/v1beta1":
-			list = authAlpha1
-		case "/apis/apps.open-cluster-management.io/v1beta1":
-			list = &appsInfo
-		case "/apis/apps.open-cluster-management.io/v1":
-			list = &appsInfoV1
-		case "/apis/argoproj.io/v1alpha1":
-			list = &argov1alphaInfo
-		case "/apis/config.openshift.io/v1":
-			list = &openshiftv1Info
-		case "/apis/addon.open-cluster-management.io/v1alpha1":
-			list = &addonInfo
+	targetGVK := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"}
+	targetGVR := targetGVK.GroupVersion().WithResource("namespaces")
+	targetMapping := meta.RESTMapping{
+		Resource: targetGVR, GroupVersionKind: targetGVK,
+		Scope: meta.RESTScopeRoot,
+	}
 
-		case "/api":
-			list = &metav1.APIVersions{
-				Versions: []string{
-					"v1",
-					"v1beta1",
-				},
-			}
-		case "/apis":
-			list = &metav1.APIGroupList{
-				Groups: []metav1.APIGroup{
-					{
-						Name: "config.openshift.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{
-								GroupVersion: "config.openshift.io/v1",
-								Version:      "v1",
-							},
-						},
-					},
-					{
-						Name: "argoproj.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{
-								GroupVersion: "argoproj.io/v1",
-								Version:      "v1",
-							},
-							{
-								GroupVersion: "argoproj.io/v1beta1",
-								Version:      "v1beta1",
-							},
-						},
-					},
-					{
-						Name: "cluster.open-cluster-management.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{
-								GroupVersion: "cluster.open-cluster-management.io/v1beta1",
-								Version:      "v1beta1",
-							},
-							{
-								GroupVersion: "cluster.open-cluster-management.io/v1",
-								Version:      "v1",
-							},
-						},
-					},
-					{
-						Name: "proxy.open-cluster-management.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{
-								GroupVersion: "proxy.open-cluster-management.io/v1beta1",
-								Version:      "v1beta1",
-							},
-							{
-								GroupVersion: "proxy.open-cluster-management.io/v1",
-								Version:      "v1",
-							},
-						},
-					},
-					{
-						Name: "hive.openshift.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{GroupVersion: "hive.openshift.io/v1", Version: "v1"},
-							{GroupVersion: "hive.openshift.io/v1beta1", Version: "v1beta1"},
-						},
-					},
-					{
-						Name: "apps.open-cluster-management.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{GroupVersion: "apps.open-cluster-management.io/v1beta1", Version: "v1beta1"},
-							{GroupVersion: "apps.open-cluster-management.io/v1", Version: "v1"},
-						},
-					},
-					{
-						Name: "authentication.open-cluster-management.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{GroupVersion: "authentication.open-cluster-management.io/v1beta1", Version: "v1beta1"},
-						},
-					},
-					{
-						Name: "addon.open-cluster-management.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{GroupVersion: "addon.open-cluster-management.io/v1alpha1", Version: "v1alpha1"},
-						},
-					},
-				},
-			}
-		default:
-			// t.Logf("unexpected request: %s", req.URL.Path)
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-		output, err := json.Marshal(list)
-		if err != nil {
-			// t.Errorf("unexpected encoding error: %v", err)
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(output)
-		if err != nil {
-			return
+	unstructuredScheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(unstructuredScheme); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	// managed1 is labeled, managed2 matches a managed cluster name
+	acmRestore := createACMRestore("acm-restore", "acm-ns").object
+	setNamespaceLabelMissingCondition(acmRestore, []string{"managed2"})
+	condition := meta.FindStatusCondition(acmRestore.Status.Conditions,
+		v1beta1.RestoreManagedClusterNamespaceLabelMissing)
+	if condition == nil || condition.Status != v1.ConditionTrue {
+		t.Errorf("%s condition should be true, got %v",
+			v1beta1.RestoreManagedClusterNamespaceLabelMissing, condition)
+	}
+	setNamespaceLabelMissingCondition(acmRestore, nil)
+	condition = meta.FindStatusCondition(acmRestore.Status.Conditions,
+		v1beta1.RestoreManagedClusterNamespaceLabelMissing)
+	if condition == nil || condition.Status != v1.ConditionFalse {
+		t.Errorf("%s condition should be false, got %v",
+			v1beta1.RestoreManagedClusterNamespaceLabelMissing, condition)
+	}
+
+	namespaces := []*unstructured.Unstructured{
+		newNamespace("managed1"),
+		newNamespace("managed2"),
+		newNamespace("app-ns"),
+	}
+	objects := []runtime.Object{}
+	resources := []unstructured.Unstructured{}
+	for _, ns := range namespaces {
+		objects = append(objects, ns)
+		resources = append(resources, *ns)
+	}
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
+		map[schema.GroupVersionResource]string{targetGVR: "NamespaceList"}, objects...)
+	resInterface := dynClient.Resource(targetGVR)
+
+	if err := deleteDynamicResources(context.Background(), &targetMapping, resInterface, resources,
+		[]string{"managed1", "managed2"}, "local-cluster", true, 1, nil); err != nil {
+		t.Errorf("deleteDynamicResources() unexpected error %v", err)
+	}
+
+	for _, name := range []string{"managed1", "managed2"} {
+		if _, err := resInterface.Get(context.Background(), name, v1.GetOptions{}); err != nil {
+			t.Errorf("managed cluster namespace %s should not be deleted, got %v", name, err)
 		}
-	}))
+	}
+	if _, err := resInterface.Get(context.Background(), "app-ns", v1.GetOptions{}); err == nil {
+		t.Errorf("namespace app-ns should be deleted")
+	}
+}
 
-	fakeDiscovery := discoveryclient.NewDiscoveryClientForConfigOrDie(
-		&restclient.Config{Host: server1.URL},
-	)
+func Test_invokeDynamicDelete_veleroNamespace(t *testing.T) {
+	newChannel := func(name, namespace string) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": "apps.open-cluster-management.io/v1",
+			"kind":       "Channel",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					BackupNameVeleroLabel: "acm-resources-schedule-20220922170041",
+				},
+			},
+		})
+		return res
+	}
 
-	testRequest := "authentication.open-cluster-management.io/v1beta1"
-	_, err = fakeDiscovery.ServerResourcesForGroupVersion(testRequest)
-	if err != nil {
-		t.Fatalf("Unexpected error: %s", err.Error())
+	targetGVK := schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1", Kind: "Channel"}
+	targetGVR := targetGVK.GroupVersion().WithResource("channels")
+	targetMapping := meta.RESTMapping{
+		Resource: targetGVR, GroupVersionKind: targetGVK,
+		Scope: meta.RESTScopeNamespace,
 	}
 
-	// create some channel resources
-	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
-		channel_with_backup_label_same, v1.CreateOptions{})
-	if client.IgnoreAlreadyExists(err) != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+	unstructuredScheme := runtime.NewScheme()
+	scheme1 := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
-		channel_with_backup_label_diff, v1.CreateOptions{})
-	if client.IgnoreAlreadyExists(err) != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).Build()
+
+	veleroBackup := createBackup("acm-resources-schedule-20220922180041", "velero-ns").object
+
+	for _, cleanupType := range []v1beta1.CleanupType{v1beta1.CleanupTypeAll, v1beta1.CleanupTypeRestored} {
+		t.Run(string(cleanupType), func(t *testing.T) {
+			dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
+				map[schema.GroupVersionResource]string{targetGVR: "ChannelList"},
+				newChannel("channel-velero", "velero-ns"),
+				newChannel("channel-default", "default"),
+			)
+			restoreOptions := RestoreOptions{
+				dynamicArgs:     DynamicStruct{dyn: dynClient},
+				cleanupType:     cleanupType,
+				veleroNamespace: "velero-ns",
+			}
+			if err := invokeDynamicDelete(context.Background(), fakeClient, restoreOptions,
+				"", veleroBackup, &targetMapping); err != nil {
+				t.Errorf("invokeDynamicDelete() unexpected error %v", err)
+			}
+
+			resInterface := dynClient.Resource(targetGVR)
+			if _, err := resInterface.Namespace("velero-ns").Get(context.Background(),
+				"channel-velero", v1.GetOptions{}); err != nil {
+				t.Errorf("resource in the velero namespace should not be deleted, got %v", err)
+			}
+			if _, err := resInterface.Namespace("default").Get(context.Background(),
+				"channel-default", v1.GetOptions{}); err == nil {
+				t.Errorf("resource in the default namespace should be deleted")
+			}
+		})
 	}
-	_, err = dyn.Resource(chGVKList).Namespace("local-cluster").Create(context.Background(),
-		channel_with_backup_label_diff_excl_ns, v1.CreateOptions{})
-	if client.IgnoreAlreadyExists(err) != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+}
+
+func Test_getBackupNamespaces(t *testing.T) {
+	newChannel := func(name, namespace, backupName string) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": "apps.open-cluster-management.io/v1",
+			"kind":       "Channel",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					BackupNameVeleroLabel: backupName,
+				},
+			},
+		})
+		return res
 	}
-	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
-		channel_with_backup_label_generic, v1.CreateOptions{})
-	if client.IgnoreAlreadyExists(err) != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+
+	targetGVK := schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1", Kind: "Channel"}
+	targetGVR := targetGVK.GroupVersion().WithResource("channels")
+	targetMapping := meta.RESTMapping{
+		Resource: targetGVR, GroupVersionKind: targetGVK,
+		Scope: meta.RESTScopeNamespace,
 	}
-	_, err = dyn.Resource(chGVKList).Namespace("default").Create(context.Background(),
-		channel_with_no_backup_label, v1.CreateOptions{})
-	if client.IgnoreAlreadyExists(err) != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+
+	backupName := "acm-resources-schedule-20220922170041"
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{targetGVR: "ChannelList"},
+		newChannel("channel-1", "app-ns-2", backupName),
+		newChannel("channel-2", "app-ns-1", backupName),
+		newChannel("channel-3", "app-ns-1", backupName),
+		newChannel("channel-old", "old-ns", "acm-resources-schedule-20220922160041"),
+	)
+	restoreOptions := RestoreOptions{
+		dynamicArgs: DynamicStruct{dyn: dynClient},
 	}
 
-	// create some cluster resources
-	_, err = dyn.Resource(clsGVKList).Namespace("default").Create(context.Background(),
-		cls_with_backup_label_diff, v1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+	tests := []struct {
+		name         string
+		veleroBackup *veleroapi.Backup
+		want         []string
+	}{
+		{
+			name: "backup included namespaces",
+			veleroBackup: createBackup(backupName, "velero-ns").
+				includedNamespaces([]string{"ns-2", "ns-1"}).object,
+			want: []string{"ns-1", "ns-2"},
+		},
+		{
+			name: "backup with all namespaces, use the restored resources namespaces",
+			veleroBackup: createBackup(backupName, "velero-ns").
+				includedNamespaces([]string{"*"}).object,
+			want: []string{"app-ns-1", "app-ns-2"},
+		},
+		{
+			name:         "no included namespaces, use the restored resources namespaces",
+			veleroBackup: createBackup(backupName, "velero-ns").object,
+			want:         []string{"app-ns-1", "app-ns-2"},
+		},
+		{
+			name:         "no restored resources",
+			veleroBackup: createBackup("acm-resources-schedule-20220922180041", "velero-ns").object,
+			want:         []string{},
+		},
 	}
-	_, err = dyn.Resource(clsGVKList).Namespace("default").Create(context.Background(),
-		cls_with_backup_label_same, v1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBackupNamespaces(context.Background(), restoreOptions, tt.veleroBackup,
+				[]*meta.RESTMapping{&targetMapping}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getBackupNamespaces() = %v, want %v", got, tt.want)
+			}
+		})
 	}
-	_, err = dyn.Resource(clsGVKList).Namespace("local-cluster").Create(context.Background(),
-		cls_with_backup_label_diff_excl_ns, v1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+}
+
+func Test_invokeDynamicDelete_cleanupScopeToBackup(t *testing.T) {
+	newChannel := func(name, namespace string) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": "apps.open-cluster-management.io/v1",
+			"kind":       "Channel",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+		})
+		return res
 	}
-	_, err = dyn.Resource(clsGVKList).Namespace("default").Create(context.Background(),
-		cls_with_no_backup_label, v1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+
+	targetGVK := schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1", Kind: "Channel"}
+	targetGVR := targetGVK.GroupVersion().WithResource("channels")
+	targetMapping := meta.RESTMapping{
+		Resource: targetGVR, GroupVersionKind: targetGVK,
+		Scope: meta.RESTScopeNamespace,
 	}
 
-	//
-	_, err = dyn.Resource(msaGVRList).Namespace("managed1").Create(context.Background(),
-		msaObj, v1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating: %s", err.Error())
+	scheme1 := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).Build()
 
-	reconcileArgs := DynamicStruct{
-		dc:  fakeDiscovery,
-		dyn: dyn,
+	veleroBackup := createBackup("acm-resources-schedule-20220922180041", "velero-ns").object
+
+	tests := []struct {
+		name                 string
+		cleanupScopeToBackup bool
+		wantDeleted          []string
+	}{
+		{
+			name:                 "cleanup not scoped to the backup",
+			cleanupScopeToBackup: false,
+			wantDeleted:          []string{"backup-ns", "live-ns"},
+		},
+		{
+			name:                 "cleanup scoped to the backup namespaces",
+			cleanupScopeToBackup: true,
+			wantDeleted:          []string{"backup-ns"},
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{targetGVR: "ChannelList"},
+				newChannel("channel", "backup-ns"),
+				newChannel("channel", "live-ns"),
+			)
+			restoreOptions := RestoreOptions{
+				dynamicArgs:          DynamicStruct{dyn: dynClient},
+				cleanupType:          v1beta1.CleanupTypeAll,
+				cleanupScopeToBackup: tt.cleanupScopeToBackup,
+				backupNamespaces:     []string{"backup-ns"},
+			}
+			if err := invokeDynamicDelete(context.Background(), fakeClient, restoreOptions,
+				"", veleroBackup, &targetMapping); err != nil {
+				t.Errorf("invokeDynamicDelete() unexpected error %v", err)
+			}
 
-	m := restmapper.NewDeferredDiscoveryRESTMapper(
-		memory.NewMemCacheClient(fakeDiscovery),
-	)
+			resInterface := dynClient.Resource(targetGVR)
+			for _, ns := range []string{"backup-ns", "live-ns"} {
+				_, err := resInterface.Namespace(ns).Get(context.Background(), "channel", v1.GetOptions{})
+				if deleted := err != nil; deleted != findValue(tt.wantDeleted, ns) {
+					t.Errorf("resource in the %s namespace deleted = %v, want %v",
+						ns, deleted, findValue(tt.wantDeleted, ns))
+				}
+			}
+		})
+	}
+}
 
-	resOptionsCleanupAll := RestoreOptions{
-		dynamicArgs: reconcileArgs,
-		cleanupType: v1beta1.CleanupTypeAll,
-		mapper:      m,
+func Test_invokeDynamicDelete_cleanupForceDeleteKinds(t *testing.T) {
+	newChannel := func(name string, lbls map[string]interface{}) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": "apps.open-cluster-management.io/v1",
+			"kind":       "Channel",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "default",
+				"labels":    lbls,
+			},
+		})
+		return res
 	}
 
-	resOptionsCleanupRestored := RestoreOptions{
-		dynamicArgs: reconcileArgs,
-		cleanupType: v1beta1.CleanupTypeRestored,
-		mapper:      m,
+	targetGVK := schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1", Kind: "Channel"}
+	targetGVR := targetGVK.GroupVersion().WithResource("channels")
+	targetMapping := meta.RESTMapping{
+		Resource: targetGVR, GroupVersionKind: targetGVK,
+		Scope: meta.RESTScopeNamespace,
 	}
 
-	type args struct {
-		ctx            context.Context
-		c              client.Client
-		restoreOptions RestoreOptions
-		acmRestore     *v1beta1.Restore
-		veleroBackup   *veleroapi.Backup
-		backupName     string
+	scheme1 := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	testsResources := []struct {
-		name                 string
-		args                 args
-		resourcesToBeDeleted []unstructured.Unstructured
-		resourcesToKeep      []unstructured.Unstructured
-		extraBackups         []veleroapi.Backup
-		resourcesToCreate    []unstructured.Unstructured
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).Build()
+
+	veleroBackup := createBackup("acm-resources-schedule-20220922180041", "velero-ns").object
+
+	tests := []struct {
+		name                    string
+		cleanupType             v1beta1.CleanupType
+		cleanupForceDeleteKinds []string
+		wantDeleted             []string
 	}{
 		{
-			name: "cleanup resources backup, no generic backup found",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupRestored,
-				veleroBackup:   &resourcesBackup,
-				backupName:     veleroResourcesBackupName,
-				acmRestore: createACMRestore("restore-acm", namespaceName).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName:        veleroResourcesBackupName,
-						VeleroGenericResourcesRestoreName: veleroResourcesBackupName,
-					}).
-					phase(v1beta1.RestorePhaseEnabled).
-					object,
-			},
-			resourcesToBeDeleted: []unstructured.Unstructured{*channel_with_backup_label_diff},
-			resourcesToKeep: []unstructured.Unstructured{
-				*channel_with_no_backup_label,
-				*channel_with_backup_label_generic,
-				*channel_with_backup_label_same,
-				*channel_with_backup_label_diff_excl_ns,
-			},
-			extraBackups:      []veleroapi.Backup{},
-			resourcesToCreate: []unstructured.Unstructured{},
+			name:        "no force delete kinds, excluded resource is kept",
+			cleanupType: v1beta1.CleanupTypeAll,
+			wantDeleted: []string{"channel"},
 		},
 		{
-			name: "cleanup resources backup, with generic backup NOT found, old one available",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupRestored,
-				veleroBackup:   &resourcesBackup,
-				backupName:     veleroResourcesBackupName,
-				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName:        veleroResourcesBackupName,
-						VeleroGenericResourcesRestoreName: veleroGenericBackupNameOlder,
-					}).
-					object,
-			},
-			resourcesToBeDeleted: []unstructured.Unstructured{*channel_with_backup_label_diff},
-			resourcesToKeep: []unstructured.Unstructured{
-				*channel_with_no_backup_label,
-				*channel_with_backup_label_generic,
-				*channel_with_backup_label_same,
-				*channel_with_backup_label_diff_excl_ns,
-			},
-			extraBackups: []veleroapi.Backup{genericBackupOld},
-			resourcesToCreate: []unstructured.Unstructured{
-				*channel_with_backup_label_diff, // this is the one deleted by the previous test, add it back
-			},
+			name:                    "force delete kind, excluded resource is deleted",
+			cleanupType:             v1beta1.CleanupTypeAll,
+			cleanupForceDeleteKinds: []string{"Channel"},
+			wantDeleted:             []string{"channel", "channel-excluded"},
 		},
 		{
-			name: "cleanup resources backup, with generic backup NOT found and clusters backup not skipped",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupRestored,
-				veleroBackup:   &resourcesBackup,
-				backupName:     veleroResourcesBackupName,
-				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
-					veleroManagedClustersBackupName(veleroClustersBackupName).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName:        veleroResourcesBackupName,
-						VeleroGenericResourcesRestoreName: "invalid-generic-name",
-					}).
-					object,
-			},
-			resourcesToBeDeleted: []unstructured.Unstructured{
-				*channel_with_backup_label_diff,
-			},
-			resourcesToKeep: []unstructured.Unstructured{
-				*channel_with_no_backup_label,
-				*channel_with_backup_label_same,
-				*channel_with_backup_label_diff_excl_ns,
-				*channel_with_backup_label_generic_match,
-				*channel_with_backup_label_generic_match_activ,
-				*channel_with_backup_label_generic_old, // should not be deleted since the matching generic backup was not found
-				*channel_with_backup_label_generic,     // should not be deleted since the matching generic backup was not found
+			name:                    "force delete kind with group, excluded resource is deleted",
+			cleanupType:             v1beta1.CleanupTypeAll,
+			cleanupForceDeleteKinds: []string{"channel.apps.open-cluster-management.io"},
+			wantDeleted:             []string{"channel", "channel-excluded"},
+		},
+		{
+			name:                    "force delete kind with another group, excluded resource is kept",
+			cleanupType:             v1beta1.CleanupTypeAll,
+			cleanupForceDeleteKinds: []string{"channel.other.io"},
+			wantDeleted:             []string{"channel"},
+		},
+		{
+			name:                    "force delete kind not used by the CleanupRestored cleanup",
+			cleanupType:             v1beta1.CleanupTypeRestored,
+			cleanupForceDeleteKinds: []string{"channel"},
+			wantDeleted:             []string{"channel"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{targetGVR: "ChannelList"},
+				newChannel("channel", nil),
+				newChannel("channel-excluded", map[string]interface{}{ExcludeBackupLabel: "true"}),
+			)
+			restoreOptions := RestoreOptions{
+				dynamicArgs:             DynamicStruct{dyn: dynClient},
+				cleanupType:             tt.cleanupType,
+				cleanupForceDeleteKinds: tt.cleanupForceDeleteKinds,
+			}
+			if err := invokeDynamicDelete(context.Background(), fakeClient, restoreOptions,
+				"", veleroBackup, &targetMapping); err != nil {
+				t.Errorf("invokeDynamicDelete() unexpected error %v", err)
+			}
 
-			},
-			extraBackups: []veleroapi.Backup{},
-			resourcesToCreate: []unstructured.Unstructured{ // this is the one deleted by the previous test, add it back
-				*channel_with_backup_label_diff,
+			resInterface := dynClient.Resource(targetGVR)
+			for _, name := range []string{"channel", "channel-excluded"} {
+				_, err := resInterface.Namespace("default").Get(context.Background(), name, v1.GetOptions{})
+				if deleted := err != nil; deleted != findValue(tt.wantDeleted, name) {
+					t.Errorf("resource %s deleted = %v, want %v", name, deleted, findValue(tt.wantDeleted, name))
+				}
+			}
+		})
+	}
+}
+
+func Test_sortCleanupMappings(t *testing.T) {
+	mapping := func(kind string, group string, namespaced bool) *meta.RESTMapping {
+		scope := meta.RESTScopeRoot
+		if namespaced {
+			scope = meta.RESTScopeNamespace
+		}
+		return &meta.RESTMapping{
+			GroupVersionKind: schema.GroupVersionKind{Group: group, Version: "v1", Kind: kind},
+			Scope:            scope,
+		}
+	}
+	newMappings := func() []*meta.RESTMapping {
+		return []*meta.RESTMapping{
+			mapping("Namespace", "", false),
+			mapping("ManagedClusterSet", "cluster.open-cluster-management.io", false),
+			mapping("Channel", "apps.open-cluster-management.io", true),
+			mapping("Subscription", "apps.open-cluster-management.io", true),
+			mapping("Placement", "cluster.open-cluster-management.io", true),
+			mapping("PlacementBinding", "policy.open-cluster-management.io", true),
+			mapping("Policy", "policy.open-cluster-management.io", true),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		order     []string
+		wantKinds []string
+	}{
+		{
+			name: "default order, children before parents",
+			wantKinds: []string{
+				"Subscription", "PlacementBinding", "Policy",
+				"Channel", "Placement",
+				"ManagedClusterSet",
+				"Namespace",
 			},
 		},
 		{
-			name: "cleanup resources backup, with generic backup found",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupRestored,
-				veleroBackup:   &resourcesBackup,
-				backupName:     veleroResourcesBackupName,
-				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName:        veleroResourcesBackupName,
-						VeleroGenericResourcesRestoreName: veleroGenericBackupName,
-					}).
-					object,
-			},
-			resourcesToBeDeleted: []unstructured.Unstructured{
-				*channel_with_backup_label_diff,
-				*channel_with_backup_label_generic_old,
-				*channel_with_backup_label_generic, // it's deleted bc the backup name doesn't match the generic backup
-			},
-			resourcesToKeep: []unstructured.Unstructured{
-				*channel_with_no_backup_label,
-				*channel_with_backup_label_same,
-				*channel_with_backup_label_diff_excl_ns,
-				*channel_with_backup_label_generic_match,
-				*channel_with_backup_label_generic_match_activ,
-				*channel_with_backup_label_generic_old_activ,
-			},
-			extraBackups: []veleroapi.Backup{genericBackup},
-			resourcesToCreate: []unstructured.Unstructured{
-				*channel_with_backup_label_diff, // this is the one deleted by the previous test, add it back
+			name:  "kinds deleted first",
+			order: []string{"policy", "placementbinding.policy.open-cluster-management.io"},
+			wantKinds: []string{
+				"Policy", "PlacementBinding",
+				"Subscription",
+				"Channel", "Placement",
+				"ManagedClusterSet",
+				"Namespace",
 			},
 		},
 		{
-			name: "cleanup resources backup, with generic backup found and clusters backup not skipped",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupRestored,
-				veleroBackup:   &resourcesBackup,
-				backupName:     veleroResourcesBackupName,
-				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
-					veleroManagedClustersBackupName(veleroClustersBackupName).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName:        veleroResourcesBackupName,
-						VeleroGenericResourcesRestoreName: veleroGenericBackupName,
-					}).
-					object,
-			},
-			resourcesToBeDeleted: []unstructured.Unstructured{
-				*channel_with_backup_label_diff,
-				*channel_with_backup_label_generic_old,
-				*channel_with_backup_label_generic,           // it's deleted bc the backup name doesn't match the generic backup
-				*channel_with_backup_label_generic_old_activ, // deleted bc the clusters backup is enabled
-			},
-			resourcesToKeep: []unstructured.Unstructured{
-				*channel_with_no_backup_label,
-				*channel_with_backup_label_same,
-				*channel_with_backup_label_diff_excl_ns,
-				*channel_with_backup_label_generic_match,
-				*channel_with_backup_label_generic_match_activ,
-			},
-			extraBackups: []veleroapi.Backup{},
-			resourcesToCreate: []unstructured.Unstructured{ // this is the one deleted by the previous test, add it back
-				*channel_with_backup_label_diff,
+			name:  "kinds deleted first and last",
+			order: []string{"Placement", "*", "Subscription", "channel"},
+			wantKinds: []string{
+				"Placement",
+				"PlacementBinding", "Policy",
+				"ManagedClusterSet",
+				"Namespace",
+				"Subscription", "Channel",
 			},
 		},
 		{
-			name: "cleanup resources backup, with generic backup found and clusters backup not skipped, cleanup all",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupAll,
-				veleroBackup:   &resourcesBackup,
-				backupName:     veleroResourcesBackupName,
-				acmRestore: createACMRestore("restore-"+veleroResourcesBackupName, namespaceName).
-					veleroManagedClustersBackupName(veleroClustersBackupName).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName:        veleroResourcesBackupName,
-						VeleroGenericResourcesRestoreName: veleroGenericBackupName,
-					}).
-					object,
+			name:  "kind with a different group not matched",
+			order: []string{"policy.other.io"},
+			wantKinds: []string{
+				"Subscription", "PlacementBinding", "Policy",
+				"Channel", "Placement",
+				"ManagedClusterSet",
+				"Namespace",
 			},
-			resourcesToBeDeleted: []unstructured.Unstructured{
-				*channel_with_backup_label_diff,
-				*channel_with_backup_label_generic_old,
-				*channel_with_backup_label_generic,           // it's deleted bc the backup name doesn't match the generic backup
-				*channel_with_backup_label_generic_old_activ, // deleted bc the clusters backup is enabled
-				*channel_with_no_backup_label,                // delete all, even the ones with no label
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappings := newMappings()
+			sortCleanupMappings(mappings, tt.order)
+
+			kinds := []string{}
+			for _, mapping := range mappings {
+				kinds = append(kinds, mapping.GroupVersionKind.Kind)
+			}
+			if !reflect.DeepEqual(kinds, tt.wantKinds) {
+				t.Errorf("sortCleanupMappings() = %v, want %v", kinds, tt.wantKinds)
+			}
+		})
+	}
+}
+
+func Test_filterResourcesByNamespace(t *testing.T) {
+	newResource := func(kind, name, namespace string) unstructured.Unstructured {
+		res := unstructured.Unstructured{}
+		res.SetKind(kind)
+		res.SetName(name)
+		res.SetNamespace(namespace)
+		return res
+	}
+	namespaceMapping := &meta.RESTMapping{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: namespaceKind},
+		Scope:            meta.RESTScopeRoot,
+	}
+	channelMapping := &meta.RESTMapping{
+		GroupVersionKind: schema.GroupVersionKind{Group: "apps.open-cluster-management.io", Version: "v1",
+			Kind: "Channel"},
+		Scope: meta.RESTScopeNamespace,
+	}
+	clusterSetMapping := &meta.RESTMapping{
+		GroupVersionKind: schema.GroupVersionKind{Group: "cluster.open-cluster-management.io", Version: "v1beta2",
+			Kind: "ManagedClusterSet"},
+		Scope: meta.RESTScopeRoot,
+	}
+
+	backupNamespaces := []string{"app-ns"}
+	tests := []struct {
+		name      string
+		mapping   *meta.RESTMapping
+		resources []unstructured.Unstructured
+		want      []string
+	}{
+		{
+			name:    "namespaced resources",
+			mapping: channelMapping,
+			resources: []unstructured.Unstructured{
+				newResource("Channel", "channel-1", "app-ns"),
+				newResource("Channel", "channel-2", "live-ns"),
 			},
-			resourcesToKeep: []unstructured.Unstructured{
-				*channel_with_backup_label_same,
-				*channel_with_backup_label_diff_excl_ns,
-				*channel_with_backup_label_generic_match,
-				*channel_with_backup_label_generic_match_activ,
+			want: []string{"channel-1"},
+		},
+		{
+			name:    "namespaces",
+			mapping: namespaceMapping,
+			resources: []unstructured.Unstructured{
+				newResource(namespaceKind, "app-ns", ""),
+				newResource(namespaceKind, "live-ns", ""),
 			},
-			extraBackups: []veleroapi.Backup{},
-			resourcesToCreate: []unstructured.Unstructured{ // this is the one deleted by the previous test, add it back
-				*channel_with_backup_label_diff,
-				*channel_with_backup_label_generic_old,
-				*channel_with_backup_label_generic,           // it's deleted bc the backup name doesn't match the generic backup
-				*channel_with_backup_label_generic_old_activ, // deleted bc the clusters backup is enabled
+			want: []string{"app-ns"},
+		},
+		{
+			name:    "cluster scoped resources",
+			mapping: clusterSetMapping,
+			resources: []unstructured.Unstructured{
+				newResource("ManagedClusterSet", "set-1", ""),
 			},
+			want: []string{"set-1"},
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, resource := range filterResourcesByNamespace(tt.mapping, tt.resources, backupNamespaces) {
+				got = append(got, resource.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterResourcesByNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
-	testsClusters := []struct {
-		name                 string
-		args                 args
-		resourcesToBeDeleted []unstructured.Unstructured
-		resourcesToKeep      []unstructured.Unstructured
-		extraBackups         []veleroapi.Backup
-		resourcesToCreate    []unstructured.Unstructured
+func Test_filterInfraNamespaceResources(t *testing.T) {
+	newResource := func(kind, name, namespace string) unstructured.Unstructured {
+		res := unstructured.Unstructured{}
+		res.SetKind(kind)
+		res.SetName(name)
+		res.SetNamespace(namespace)
+		return res
+	}
+	namespaceMapping := &meta.RESTMapping{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: namespaceKind},
+		Scope:            meta.RESTScopeRoot,
+	}
+	secretMapping := &meta.RESTMapping{
+		GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+		Scope:            meta.RESTScopeNamespace,
+	}
+
+	tests := []struct {
+		name      string
+		mapping   *meta.RESTMapping
+		resources []unstructured.Unstructured
+		want      []string
 	}{
 		{
-			name: "cleanup clusters backup",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				restoreOptions: resOptionsCleanupRestored,
-				veleroBackup:   &clustersBackup,
-				backupName:     veleroClustersBackupName,
-				acmRestore: createACMRestore("acm-restore", namespaceName).
-					veleroManagedClustersBackupName(veleroClustersBackupName).
-					restoreACMStatus(v1beta1.RestoreStatus{
-						VeleroResourcesRestoreName: veleroClustersBackupName,
-					}).
-					object,
+			name:    "namespaced resources",
+			mapping: secretMapping,
+			resources: []unstructured.Unstructured{
+				newResource("Secret", "secret-1", "app-ns"),
+				newResource("Secret", "secret-2", "default"),
+				newResource("Secret", "secret-3", "openshift-config"),
+				newResource("Secret", "secret-4", "kube-system"),
+				newResource("Secret", "secret-5", "openshift"),
+				newResource("Secret", "secret-6", "openshiftapps"),
 			},
-			resourcesToBeDeleted: []unstructured.Unstructured{*cls_with_backup_label_diff},
-			resourcesToKeep: []unstructured.Unstructured{
-				*cls_with_no_backup_label,
-				*cls_with_backup_label_same,
-				*cls_with_backup_label_diff_excl_ns,
+			want: []string{"secret-1", "secret-6"},
+		},
+		{
+			name:    "namespaces",
+			mapping: namespaceMapping,
+			resources: []unstructured.Unstructured{
+				newResource(namespaceKind, "app-ns", ""),
+				newResource(namespaceKind, "default", ""),
+				newResource(namespaceKind, "openshift-monitoring", ""),
+				newResource(namespaceKind, "kube-public", ""),
 			},
-			extraBackups:      []veleroapi.Backup{clustersBackupOld},
-			resourcesToCreate: []unstructured.Unstructured{},
+			want: []string{"app-ns"},
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, resource := range filterInfraNamespaceResources(tt.mapping, tt.resources) {
+				got = append(got, resource.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterInfraNamespaceResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
-	if err := k8sClient1.Create(context.Background(), createNamespace(genericBackup.GetNamespace())); err != nil {
-		t.Fatalf("cannot create ns %s ", err.Error())
+func Test_getCleanupType(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    v1beta1.CleanupType
+	}{
+		{
+			name:    "cleanup option",
+			restore: createACMRestore("restore", "ns").cleanupBeforeRestore(v1beta1.CleanupTypeRestored).object,
+			want:    v1beta1.CleanupTypeRestored,
+		},
+		{
+			name: "point-in-time recovery cleans up all resources",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).object,
+			want: v1beta1.CleanupTypeAll,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getCleanupType(tt.restore); got != tt.want {
+				t.Errorf("getCleanupType() = %v, want %v", got, tt.want)
+			}
+		})
 	}
+}
 
-	mapper := restmapper.NewDeferredDiscoveryRESTMapper(
-		memory.NewMemCacheClient(resOptionsCleanupRestored.dynamicArgs.dc),
-	)
+func Test_cleanupDeltaResources_pointInTimeRecoveryAfterRestore(t *testing.T) {
+	// the point-in-time recovery restores the backups first, then cleans up the resources
+	// not restored from these backups; the cleanup runs only once all velero restores
+	// completed successfully, so the hub is never left cleaned up and not restored
+	scheme1 := runtime.NewScheme()
+	schemeErrs := []error{}
+	schemeErrs = append(schemeErrs, veleroapi.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, clusterv1.AddToScheme(scheme1))
+	schemeErrs = append(schemeErrs, corev1.AddToScheme(scheme1))
+	if err := errors.Join(schemeErrs...); err != nil {
+		t.Fatalf("Error adding api(s) to scheme: %s", err.Error())
+	}
 
-	// create restore resources
-	////////
-	createRestores := []veleroapi.Restore{
-		*createRestore(veleroResourcesBackupName, namespaceName).
-			backupName(veleroResourcesBackupName).object,
-		*createRestore(veleroClustersBackupNameOlder, namespaceName).
-			backupName(veleroClustersBackupNameOlder).object,
-		*createRestore(veleroClustersBackupName, namespaceName).
-			backupName(veleroClustersBackupName).object,
-		*createRestore(veleroGenericBackupName, namespaceName).
-			backupName(veleroGenericBackupName).object,
-		*createRestore(veleroResourcesBackupNameOlder, namespaceName).
-			backupName(veleroResourcesBackupNameOlder).object,
+	ns := "velero-ns"
+	credsBackupName := "acm-credentials-schedule-20220922170041"
+	credsRestoreName := "restore-acm-credentials-schedule-20220922170041"
+
+	tests := []struct {
+		name        string
+		phase       v1beta1.RestorePhase
+		wantCleanup bool
+	}{
+		{
+			name:        "velero restores started, hub not cleaned up",
+			phase:       v1beta1.RestorePhaseStarted,
+			wantCleanup: false,
+		},
+		{
+			name:        "velero restores running, hub not cleaned up",
+			phase:       v1beta1.RestorePhaseRunning,
+			wantCleanup: false,
+		},
+		{
+			name:        "velero restores finished with errors, hub not cleaned up",
+			phase:       v1beta1.RestorePhaseFinishedWithErrors,
+			wantCleanup: false,
+		},
+		{
+			name:        "velero restores finished, hub cleaned up after the restore",
+			phase:       v1beta1.RestorePhaseFinished,
+			wantCleanup: true,
+		},
 	}
-	for i := range createRestores {
-		if err := k8sClient1.Create(context.Background(), &createRestores[i]); err != nil {
-			t.Fatalf("cannot create restore %s ", err.Error())
-		}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", ns).pointInTimeRecovery(true).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(latestBackupStr).
+				veleroResourcesBackupName(latestBackupStr).
+				phase(tt.phase).object
+			restore.Status.VeleroCredentialsRestoreName = credsRestoreName
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(
+					createRestore(credsRestoreName, ns).backupName(credsBackupName).object,
+					createBackup(credsBackupName, ns).object,
+					// user credentials restored from the backup
+					createSecret("restored-creds", "default", map[string]string{
+						backupCredsUserLabel:  "user",
+						BackupNameVeleroLabel: credsBackupName,
+					}, nil, nil),
+					// user credentials not in the backup
+					createSecret("user-creds", "app-ns",
+						map[string]string{backupCredsUserLabel: "user"}, nil, nil),
+				).
+				Build()
+
+			if got := cleanupDeltaResources(context.Background(), fakeClient, restore, false,
+				RestoreOptions{protectInfraNamespaces: true}); got != tt.wantCleanup {
+				t.Errorf("cleanupDeltaResources() = %v, want %v", got, tt.wantCleanup)
+			}
+
+			err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "user-creds", Namespace: "app-ns"}, &corev1.Secret{})
+			if tt.wantCleanup != k8serr.IsNotFound(err) {
+				t.Errorf("user credentials not in the backup deleted = %v, want %v, err %v",
+					k8serr.IsNotFound(err), tt.wantCleanup, err)
+			}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restored-creds", Namespace: "default"}, &corev1.Secret{}); err != nil {
+				t.Errorf("restored user credentials should not be deleted, err %v", err)
+			}
+		})
+	}
+}
+
+func Test_labelManagedClusterNamespaces(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	err = clusterv1.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	err = corev1.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error creating new client: %s", err.Error())
 	}
-	////////
 
-	for _, tt := range testsResources {
-		_ = k8sClient1.Create(tt.args.ctx, tt.args.veleroBackup)
+	veleroRestoreName := "restore-acm-managed-clusters-schedule-20220726152532"
+	restoredLabels := map[string]string{RestoreNameVeleroLabel: veleroRestoreName}
+	nsLabels := map[string]string{"restored-by": "acm-restore"}
 
-		// create extra backups for this test
-		for i := range tt.extraBackups {
-			if err := k8sClient1.Create(tt.args.ctx, &tt.extraBackups[i]); err != nil {
-				t.Errorf("cannot create backup %s ", err.Error())
-			}
+	// cluster1 and the local cluster are restored, cluster2 is not restored by this restore
+	for _, name := range []string{"cluster1", "cluster2", "local-cluster"} {
+		if err := k8sClient1.Create(context.Background(), createNamespace(name)); err != nil {
+			t.Fatalf("cannot create ns %s ", err.Error())
+		}
+	}
+	for _, managedCluster := range []*clusterv1.ManagedCluster{
+		createManagedCluster("cluster1", false).labels(restoredLabels).object,
+		createManagedCluster("cluster2", false).object,
+		createManagedCluster("local-cluster", true).labels(restoredLabels).object,
+	} {
+		if err := k8sClient1.Create(context.Background(), managedCluster); err != nil {
+			t.Fatalf("cannot create managed cluster %s ", err.Error())
 		}
+	}
 
-		// create resources for this test
-		for i := range tt.resourcesToCreate {
-			if _, err := dyn.Resource(chGVKList).Namespace(tt.resourcesToCreate[i].GetNamespace()).Create(context.Background(),
-				&tt.resourcesToCreate[i], v1.CreateOptions{}); err != nil {
-				t.Errorf("cannot create resource %s ", err.Error())
-			}
+	newRestoreList := func(phase veleroapi.RestorePhase) *veleroapi.RestoreList {
+		return &veleroapi.RestoreList{
+			Items: []veleroapi.Restore{*createRestore(veleroRestoreName, "ns").phase(phase).object},
 		}
+	}
+	newACMRestore := func(labels map[string]string) *v1beta1.Restore {
+		restore := createACMRestore("restore", "ns").object
+		restore.Spec.ManagedClusterNamespaceLabels = labels
+		restore.Status.VeleroManagedClustersRestoreName = veleroRestoreName
+		return restore
+	}
 
+	tests := []struct {
+		name          string
+		restore       *v1beta1.Restore
+		veleroRestore *veleroapi.RestoreList
+		want          bool
+		wantLabeled   []string
+	}{
+		{
+			name:          "no labels to apply",
+			restore:       newACMRestore(nil),
+			veleroRestore: newRestoreList(veleroapi.RestorePhaseCompleted),
+			want:          false,
+		},
+		{
+			name:          "managed clusters restore not completed",
+			restore:       newACMRestore(nsLabels),
+			veleroRestore: newRestoreList(veleroapi.RestorePhaseInProgress),
+			want:          false,
+		},
+		{
+			name:          "restored namespaces are labeled",
+			restore:       newACMRestore(nsLabels),
+			veleroRestore: newRestoreList(veleroapi.RestorePhaseCompleted),
+			want:          true,
+			wantLabeled:   []string{"cluster1"},
+		},
+		{
+			name:          "restored namespaces already labeled",
+			restore:       newACMRestore(nsLabels),
+			veleroRestore: newRestoreList(veleroapi.RestorePhasePartiallyFailed),
+			want:          true,
+			wantLabeled:   []string{"cluster1"},
+		},
+	}
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cleanupDeltaForResourcesBackup(tt.args.ctx,
-				tt.args.c,
-				tt.args.restoreOptions,
-				tt.args.acmRestore)
+			if got := labelManagedClusterNamespaces(context.Background(), k8sClient1,
+				tt.restore, tt.veleroRestore); got != tt.want {
+				t.Errorf("labelManagedClusterNamespaces() = %v, want %v", got, tt.want)
+			}
+			for _, name := range []string{"cluster1", "cluster2", "local-cluster"} {
+				namespace := &corev1.Namespace{}
+				if err := k8sClient1.Get(context.Background(), types.NamespacedName{Name: name},
+					namespace); err != nil {
+					t.Fatalf("cannot get ns %s ", err.Error())
+				}
+				labeled := namespace.Labels["restored-by"] == "acm-restore"
+				if labeled != findValue(tt.wantLabeled, name) {
+					t.Errorf("namespace %s labeled = %v, want %v", name, labeled, !labeled)
+				}
+			}
 		})
+	}
 
-		groupKind := schema.GroupKind{
-			Group: chGVK.Group,
-			Kind:  chGVK.Kind,
-		}
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
 
-		mapping, _ := mapper.RESTMapping(groupKind, "")
-		dr := tt.args.restoreOptions.dynamicArgs.dyn.Resource(mapping.Resource)
+func Test_tagRestoredResources(t *testing.T) {
+	newResource := func(apiVersion, kind, name, namespace string, lbls map[string]interface{}) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetUnstructuredContent(map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels":    lbls,
+			},
+		})
+		return res
+	}
+
+	channelGVR := schema.GroupVersionResource{Group: "apps.open-cluster-management.io", Version: "v1", Resource: "channels"}
+	configMapGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	fakeDiscovery := &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "apps.open-cluster-management.io/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "channels", Namespaced: true, Kind: "Channel", Verbs: []string{"list", "patch"}},
+					{Name: "channels/status", Namespaced: true, Kind: "Channel", Verbs: []string{"patch"}},
+				},
+			},
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"list", "patch"}},
+				},
+			},
+		},
+	}}
 
-		for i := range tt.resourcesToBeDeleted {
-			if _, err := dr.Namespace(tt.resourcesToBeDeleted[i].GetNamespace()).
-				Get(tt.args.ctx, tt.resourcesToBeDeleted[i].GetName(), v1.GetOptions{}); err == nil {
-				t.Errorf("cleanupDeltaForResourcesBackup(%s) resource %s should NOT be found",
-					tt.name, tt.resourcesToBeDeleted[i])
-			}
-		}
+	unstructuredScheme := runtime.NewScheme()
+	newDynamicClient := func() dynamic.Interface {
+		return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(unstructuredScheme,
+			map[schema.GroupVersionResource]string{
+				channelGVR:   "ChannelList",
+				configMapGVR: "ConfigMapList",
+			},
+			newResource("apps.open-cluster-management.io/v1", "Channel", "channel-restored", "ns1",
+				map[string]interface{}{RestoreNameVeleroLabel: "restore-acm-resources"}),
+			newResource("apps.open-cluster-management.io/v1", "Channel", "channel-other-restore", "ns1",
+				map[string]interface{}{RestoreNameVeleroLabel: "other-restore"}),
+			newResource("apps.open-cluster-management.io/v1", "Channel", "channel-user", "ns1", nil),
+			newResource("v1", "ConfigMap", "cm-restored", "ns2",
+				map[string]interface{}{RestoreNameVeleroLabel: "restore-acm-credentials"}),
+		)
+	}
+
+	finishedRestore := func() *v1beta1.Restore {
+		restore := createACMRestore("restore", "ns").
+			phase(v1beta1.RestorePhaseFinished).
+			tagRestoredResources(true).object
+		restore.Status.VeleroResourcesRestoreName = "restore-acm-resources"
+		restore.Status.VeleroCredentialsRestoreName = "restore-acm-credentials"
+		return restore
+	}
+	completedRestore := finishedRestore()
+	completedRestore.Status.CompletionTimestamp = &metav1.Time{Time: time.Now()}
+	runningRestore := finishedRestore()
+	runningRestore.Status.Phase = v1beta1.RestorePhaseRunning
+	notTaggedRestore := finishedRestore()
+	notTaggedRestore.Spec.TagRestoredResources = false
 
-		for i := range tt.resourcesToKeep {
-			if _, err := dr.Namespace(tt.resourcesToKeep[i].GetNamespace()).
-				Get(tt.args.ctx, tt.resourcesToKeep[i].GetName(), v1.GetOptions{}); err != nil {
-				t.Errorf("cleanupDeltaForResourcesBackup(%s) resource %s should be found ! they were deleted",
-					tt.name, tt.resourcesToKeep[i].GetName())
+	tests := []struct {
+		name       string
+		restore    *v1beta1.Restore
+		want       bool
+		wantTagged []string
+	}{
+		{
+			name:       "restore finished, restored resources are tagged",
+			restore:    finishedRestore(),
+			want:       true,
+			wantTagged: []string{"channel-restored", "cm-restored"},
+		},
+		{
+			name:       "option not set",
+			restore:    notTaggedRestore,
+			want:       false,
+			wantTagged: []string{},
+		},
+		{
+			name:       "restore still running",
+			restore:    runningRestore,
+			want:       false,
+			wantTagged: []string{},
+		},
+		{
+			name:       "restore already completed",
+			restore:    completedRestore,
+			want:       false,
+			wantTagged: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dyn := newDynamicClient()
+			if got := tagRestoredResources(context.Background(),
+				DynamicStruct{dc: fakeDiscovery, dyn: dyn}, tt.restore); got != tt.want {
+				t.Errorf("tagRestoredResources() = %v, want %v", got, tt.want)
 			}
-		}
 
+			tagged := []string{}
+			for _, gvr := range []schema.GroupVersionResource{channelGVR, configMapGVR} {
+				list, err := dyn.Resource(gvr).List(context.Background(), v1.ListOptions{
+					LabelSelector: fmt.Sprintf("%s=%s", RestoreTagLabel, tt.restore.Name),
+				})
+				if err != nil {
+					t.Fatalf("Error listing %s: %s", gvr.Resource, err.Error())
+				}
+				for i := range list.Items {
+					tagged = append(tagged, list.Items[i].GetName())
+				}
+			}
+			if !sortCompare(tagged, tt.wantTagged) {
+				t.Errorf("tagRestoredResources() tagged = %v, want %v", tagged, tt.wantTagged)
+			}
+		})
 	}
+}
 
-	for _, tt := range testsClusters {
+func Test_verifyClusterJoin(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
 
-		if err := k8sClient1.Create(tt.args.ctx, tt.args.veleroBackup); err != nil {
-			t.Errorf("Error creating veleroBackup: %s", err.Error())
-		}
-		// create extra backups for this test
-		for i := range tt.extraBackups {
-			if err := k8sClient1.Create(tt.args.ctx, &tt.extraBackups[i]); err != nil {
-				t.Errorf("cannot create backup %s ", err.Error())
-			}
+	currentTime := time.Now()
+	joinedConditions := []metav1.Condition{
+		{Type: clusterv1.ManagedClusterConditionJoined, Status: metav1.ConditionTrue,
+			Reason: "Joined", LastTransitionTime: metav1.Now()},
+		{Type: clusterv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue,
+			Reason: "Available", LastTransitionTime: metav1.Now()},
+	}
+	notAvailableConditions := []metav1.Condition{
+		{Type: clusterv1.ManagedClusterConditionJoined, Status: metav1.ConditionTrue,
+			Reason: "Joined", LastTransitionTime: metav1.Now()},
+		{Type: clusterv1.ManagedClusterConditionAvailable, Status: metav1.ConditionUnknown,
+			Reason: "Unknown", LastTransitionTime: metav1.Now()},
+	}
+	pendingCondition := func(since time.Duration) []metav1.Condition {
+		return []metav1.Condition{
+			{
+				Type:               v1beta1.RestoreClustersJoined,
+				Status:             metav1.ConditionFalse,
+				Reason:             v1beta1.RestoreReasonClustersJoinPending,
+				LastTransitionTime: metav1.NewTime(currentTime.Add(-since)),
+			},
 		}
+	}
+	newRestore := func(verify bool, activated []string, conditions []metav1.Condition) *v1beta1.Restore {
+		return createACMRestore("restore", "ns").
+			verifyClusterJoin(verify).
+			restoreACMStatus(v1beta1.RestoreStatus{
+				Phase:             v1beta1.RestorePhaseFinished,
+				ActivationPhase:   v1beta1.ActivationPhaseCompleted,
+				ActivatedClusters: activated,
+				Conditions:        conditions,
+			}).object
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			createManagedCluster("joined", false).conditions(joinedConditions).object,
+			createManagedCluster("not-available", false).conditions(notAvailableConditions).object,
+			createManagedCluster("not-joined", false).object,
+		).
+		Build()
 
-		// create resources for this test
-		for i := range tt.resourcesToCreate {
-			if _, err := dyn.Resource(chGVKList).Namespace(tt.resourcesToCreate[i].GetNamespace()).Create(context.Background(),
-				&tt.resourcesToCreate[i], v1.CreateOptions{}); err != nil {
-				t.Errorf("cannot create resource %s ", err.Error())
+	tests := []struct {
+		name          string
+		restore       *v1beta1.Restore
+		wantUpdated   bool
+		wantReason    string
+		wantPhase     v1beta1.RestorePhase
+		wantJoinState []v1beta1.ClusterJoinStatus
+	}{
+		{
+			name:        "verification not requested",
+			restore:     newRestore(false, []string{"joined", "not-joined"}, nil),
+			wantUpdated: false,
+			wantPhase:   v1beta1.RestorePhaseFinished,
+		},
+		{
+			name:        "no activated clusters",
+			restore:     newRestore(true, nil, nil),
+			wantUpdated: false,
+			wantPhase:   v1beta1.RestorePhaseFinished,
+		},
+		{
+			name:        "all clusters joined",
+			restore:     newRestore(true, []string{"joined"}, nil),
+			wantUpdated: true,
+			wantReason:  v1beta1.RestoreReasonClustersJoined,
+			wantPhase:   v1beta1.RestorePhaseFinished,
+			wantJoinState: []v1beta1.ClusterJoinStatus{
+				{Name: "joined", Joined: true, Available: true},
+			},
+		},
+		{
+			name:        "clusters not joined, start waiting",
+			restore:     newRestore(true, []string{"joined", "not-available", "not-joined"}, nil),
+			wantUpdated: true,
+			wantReason:  v1beta1.RestoreReasonClustersJoinPending,
+			wantPhase:   v1beta1.RestorePhaseFinished,
+			wantJoinState: []v1beta1.ClusterJoinStatus{
+				{Name: "joined", Joined: true, Available: true},
+				{Name: "not-available", Joined: true, Available: false},
+				{Name: "not-joined", Joined: false, Available: false},
+			},
+		},
+		{
+			name:        "clusters not joined, within grace period",
+			restore:     newRestore(true, []string{"joined", "not-joined"}, pendingCondition(time.Minute*10)),
+			wantUpdated: true,
+			wantReason:  v1beta1.RestoreReasonClustersJoinPending,
+			wantPhase:   v1beta1.RestorePhaseFinished,
+			wantJoinState: []v1beta1.ClusterJoinStatus{
+				{Name: "joined", Joined: true, Available: true},
+				{Name: "not-joined", Joined: false, Available: false},
+			},
+		},
+		{
+			name:        "clusters not joined, grace period expired",
+			restore:     newRestore(true, []string{"joined", "not-joined"}, pendingCondition(time.Minute*31)),
+			wantUpdated: true,
+			wantReason:  v1beta1.RestoreReasonClustersJoinTimeout,
+			wantPhase:   v1beta1.RestorePhaseFinishedWithErrors,
+			wantJoinState: []v1beta1.ClusterJoinStatus{
+				{Name: "joined", Joined: true, Available: true},
+				{Name: "not-joined", Joined: false, Available: false},
+			},
+		},
+		{
+			name: "verification already timed out",
+			restore: newRestore(true, []string{"not-joined"}, []metav1.Condition{
+				{
+					Type:   v1beta1.RestoreClustersJoined,
+					Status: metav1.ConditionFalse,
+					Reason: v1beta1.RestoreReasonClustersJoinTimeout,
+				},
+			}),
+			wantUpdated: false,
+			wantReason:  v1beta1.RestoreReasonClustersJoinTimeout,
+			wantPhase:   v1beta1.RestorePhaseFinished,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyClusterJoin(context.Background(), fakeClient, tt.restore, currentTime); got != tt.wantUpdated {
+				t.Errorf("verifyClusterJoin() = %v, want %v", got, tt.wantUpdated)
+			}
+			if tt.restore.Status.Phase != tt.wantPhase {
+				t.Errorf("verifyClusterJoin() phase = %v, want %v", tt.restore.Status.Phase, tt.wantPhase)
 			}
+			condition := meta.FindStatusCondition(tt.restore.Status.Conditions, v1beta1.RestoreClustersJoined)
+			if tt.wantReason == "" {
+				if condition != nil {
+					t.Errorf("verifyClusterJoin() condition = %v, want none", condition)
+				}
+			} else if condition == nil || condition.Reason != tt.wantReason {
+				t.Errorf("verifyClusterJoin() condition = %v, want reason %v", condition, tt.wantReason)
+			}
+			if !reflect.DeepEqual(tt.restore.Status.ClusterJoinStatus, tt.wantJoinState) {
+				t.Errorf("verifyClusterJoin() ClusterJoinStatus = %v, want %v",
+					tt.restore.Status.ClusterJoinStatus, tt.wantJoinState)
+			}
+		})
+	}
+}
+
+func Test_verifyMinClustersAvailable(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	currentTime := time.Now()
+	availableConditions := []metav1.Condition{
+		{Type: clusterv1.ManagedClusterConditionJoined, Status: metav1.ConditionTrue,
+			Reason: "Joined", LastTransitionTime: metav1.Now()},
+		{Type: clusterv1.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue,
+			Reason: "Available", LastTransitionTime: metav1.Now()},
+	}
+	degradedCondition := func(since time.Duration) []metav1.Condition {
+		return []metav1.Condition{
+			{
+				Type:               v1beta1.RestoreDegraded,
+				Status:             metav1.ConditionTrue,
+				Reason:             v1beta1.RestoreReasonMinClustersNotAvailable,
+				LastTransitionTime: metav1.NewTime(currentTime.Add(-since)),
+			},
 		}
+	}
+	newRestore := func(
+		minPercent int,
+		phase v1beta1.RestorePhase,
+		activated []string,
+		conditions []metav1.Condition,
+	) *v1beta1.Restore {
+		return createACMRestore("restore", "ns").
+			minClustersAvailablePercent(minPercent).
+			restoreACMStatus(v1beta1.RestoreStatus{
+				Phase:             phase,
+				ActivationPhase:   v1beta1.ActivationPhaseCompleted,
+				ActivatedClusters: activated,
+				Conditions:        conditions,
+			}).object
+	}
+
+	// 3 of the 4 activated clusters are available
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			createManagedCluster("available-1", false).conditions(availableConditions).object,
+			createManagedCluster("available-2", false).conditions(availableConditions).object,
+			createManagedCluster("available-3", false).conditions(availableConditions).object,
+			createManagedCluster("not-available", false).object,
+		).
+		Build()
+	activated := []string{"available-1", "available-2", "available-3", "not-available"}
 
+	tests := []struct {
+		name          string
+		restore       *v1beta1.Restore
+		wantUpdated   bool
+		wantComplete  metav1.ConditionStatus
+		wantDegraded  metav1.ConditionStatus
+		wantRequeue   bool
+		wantInMessage string
+	}{
+		{
+			name:        "option not set",
+			restore:     newRestore(0, v1beta1.RestorePhaseFinished, activated, nil),
+			wantUpdated: false,
+		},
+		{
+			name:        "restore not completed",
+			restore:     newRestore(50, v1beta1.RestorePhaseRunning, activated, nil),
+			wantUpdated: false,
+		},
+		{
+			name:          "available fraction above the threshold",
+			restore:       newRestore(50, v1beta1.RestorePhaseFinished, activated, nil),
+			wantUpdated:   true,
+			wantComplete:  metav1.ConditionTrue,
+			wantDegraded:  metav1.ConditionFalse,
+			wantInMessage: "3 of 4 activated managed clusters are available (75%)",
+		},
+		{
+			name:          "available fraction equal to the threshold",
+			restore:       newRestore(75, v1beta1.RestorePhaseFinishedWithErrors, activated, nil),
+			wantUpdated:   true,
+			wantComplete:  metav1.ConditionTrue,
+			wantDegraded:  metav1.ConditionFalse,
+			wantInMessage: "3 of 4 activated managed clusters are available (75%)",
+		},
+		{
+			name:          "available fraction below the threshold",
+			restore:       newRestore(100, v1beta1.RestorePhaseFinished, activated, nil),
+			wantUpdated:   true,
+			wantComplete:  metav1.ConditionFalse,
+			wantDegraded:  metav1.ConditionTrue,
+			wantRequeue:   true,
+			wantInMessage: "the restore requires 100%",
+		},
+		{
+			name: "available fraction reaches the threshold within the grace period",
+			restore: newRestore(75, v1beta1.RestorePhaseFinished, activated,
+				degradedCondition(clusterJoinGracePeriod/2)),
+			wantUpdated:  true,
+			wantComplete: metav1.ConditionTrue,
+			wantDegraded: metav1.ConditionFalse,
+		},
+		{
+			name: "available fraction still below the threshold within the grace period",
+			restore: newRestore(100, v1beta1.RestorePhaseFinished, activated,
+				degradedCondition(clusterJoinGracePeriod/2)),
+			wantUpdated:  true,
+			wantComplete: metav1.ConditionFalse,
+			wantDegraded: metav1.ConditionTrue,
+			wantRequeue:  true,
+		},
+		{
+			name: "available fraction still below the threshold after the grace period",
+			restore: newRestore(100, v1beta1.RestorePhaseFinished, activated,
+				degradedCondition(clusterJoinGracePeriod+time.Minute)),
+			wantUpdated:  true,
+			wantComplete: metav1.ConditionFalse,
+			wantDegraded: metav1.ConditionTrue,
+			wantRequeue:  false,
+		},
+		{
+			name: "available fraction reaches the threshold after the grace period",
+			restore: newRestore(75, v1beta1.RestorePhaseFinished, activated,
+				degradedCondition(clusterJoinGracePeriod+time.Minute)),
+			wantUpdated:  true,
+			wantComplete: metav1.ConditionTrue,
+			wantDegraded: metav1.ConditionFalse,
+		},
+		{
+			name:          "no activated clusters",
+			restore:       newRestore(100, v1beta1.RestorePhaseFinished, nil, nil),
+			wantUpdated:   true,
+			wantComplete:  metav1.ConditionTrue,
+			wantDegraded:  metav1.ConditionFalse,
+			wantInMessage: "0 of 0 activated managed clusters are available (100%)",
+		},
+	}
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cleanupDeltaForClustersBackup(tt.args.ctx,
-				tt.args.c,
-				tt.args.restoreOptions,
-				tt.args.backupName,
-				tt.args.veleroBackup)
+			if got := verifyMinClustersAvailable(context.Background(), fakeClient, tt.restore,
+				currentTime); got != tt.wantUpdated {
+				t.Errorf("verifyMinClustersAvailable() = %v, want %v", got, tt.wantUpdated)
+			}
+			complete := meta.FindStatusCondition(tt.restore.Status.Conditions, v1beta1.RestoreComplete)
+			if (tt.wantComplete == "") != (complete == nil) ||
+				(complete != nil && complete.Status != tt.wantComplete) {
+				t.Errorf("%s condition = %v, want %s", v1beta1.RestoreComplete, complete, tt.wantComplete)
+			}
+			degraded := meta.FindStatusCondition(tt.restore.Status.Conditions, v1beta1.RestoreDegraded)
+			if (tt.wantDegraded == "") != (degraded == nil) ||
+				(degraded != nil && degraded.Status != tt.wantDegraded) {
+				t.Errorf("%s condition = %v, want %s", v1beta1.RestoreDegraded, degraded, tt.wantDegraded)
+			}
+			if tt.wantInMessage != "" && (degraded == nil || !strings.Contains(degraded.Message, tt.wantInMessage)) {
+				t.Errorf("%s condition = %v, want message with %s", v1beta1.RestoreDegraded, degraded, tt.wantInMessage)
+			}
+			if got := isMinClustersAvailablePending(tt.restore, currentTime); got != tt.wantRequeue {
+				t.Errorf("isMinClustersAvailablePending() = %v, want %v", got, tt.wantRequeue)
+			}
 		})
+	}
+}
 
-		// managed cluster group
-		groupKind := schema.GroupKind{
-			Group: clsGVK.Group,
-			Kind:  clsGVK.Kind,
-		}
-		mapping, _ := mapper.RESTMapping(groupKind, "")
-		dr := tt.args.restoreOptions.dynamicArgs.dyn.Resource(mapping.Resource)
+func Test_processValidationRestore(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
 
-		for i := range tt.resourcesToBeDeleted {
-			if _, err := dr.Namespace(tt.resourcesToBeDeleted[i].GetNamespace()).
-				Get(tt.args.ctx, tt.resourcesToBeDeleted[i].GetName(), v1.GetOptions{}); err == nil {
-				t.Errorf("cleanupDeltaForClustersBackup(%s) resource %s should NOT be found",
-					tt.name, tt.resourcesToBeDeleted[i])
+	veleroRestores := &veleroapi.RestoreList{
+		Items: []veleroapi.Restore{
+			*createRestore("restore-credentials", "ns").
+				namespaceMapping(map[string]string{"app-ns": "sandbox-app-ns"}).
+				progress(10, 10, 0, 0).object,
+			*createRestore("restore-resources", "ns").
+				namespaceMapping(map[string]string{"app-ns": "sandbox-app-ns", "other-ns": "sandbox-other-ns"}).
+				progress(30, 28, 2, 0).object,
+		},
+	}
+	veleroRestoresWithErrors := &veleroapi.RestoreList{
+		Items: []veleroapi.Restore{
+			*createRestore("restore-resources", "ns").
+				namespaceMapping(map[string]string{"app-ns": "sandbox-app-ns"}).
+				progress(30, 25, 0, 5).object,
+		},
+	}
+
+	tests := []struct {
+		name               string
+		restore            *v1beta1.Restore
+		veleroRestores     *veleroapi.RestoreList
+		want               bool
+		wantResult         *v1beta1.ValidationRestoreResult
+		wantSandboxDeleted bool
+	}{
+		{
+			name: "not a validation restore",
+			restore: createACMRestore("restore", "ns").
+				sandboxNamespacePrefix("sandbox").
+				phase(v1beta1.RestorePhaseFinished).object,
+			veleroRestores: veleroRestores,
+			want:           false,
+		},
+		{
+			name: "validation restore not completed",
+			restore: createACMRestore("restore", "ns").
+				sandboxNamespacePrefix("sandbox").
+				validationRestore(true).
+				phase(v1beta1.RestorePhaseRunning).object,
+			veleroRestores: veleroRestores,
+			want:           false,
+		},
+		{
+			name: "validation restore succeeded, sandbox kept",
+			restore: createACMRestore("restore", "ns").
+				sandboxNamespacePrefix("sandbox").
+				validationRestore(false).
+				phase(v1beta1.RestorePhaseFinished).object,
+			veleroRestores: veleroRestores,
+			want:           true,
+			wantResult: &v1beta1.ValidationRestoreResult{
+				Succeeded:         true,
+				TotalItems:        40,
+				ItemsRestored:     38,
+				Warnings:          2,
+				SandboxNamespaces: []string{"sandbox-app-ns", "sandbox-other-ns"},
+			},
+			wantSandboxDeleted: false,
+		},
+		{
+			name: "validation restore succeeded, sandbox cleaned up",
+			restore: createACMRestore("restore", "ns").
+				sandboxNamespacePrefix("sandbox").
+				validationRestore(true).
+				phase(v1beta1.RestorePhaseFinished).object,
+			veleroRestores: veleroRestores,
+			want:           true,
+			wantResult: &v1beta1.ValidationRestoreResult{
+				Succeeded:         true,
+				TotalItems:        40,
+				ItemsRestored:     38,
+				Warnings:          2,
+				SandboxNamespaces: []string{"sandbox-app-ns", "sandbox-other-ns"},
+				SandboxCleanedUp:  true,
+			},
+			wantSandboxDeleted: true,
+		},
+		{
+			name: "validation restore with errors",
+			restore: createACMRestore("restore", "ns").
+				sandboxNamespacePrefix("sandbox").
+				validationRestore(true).
+				phase(v1beta1.RestorePhaseFinished).object,
+			veleroRestores: veleroRestoresWithErrors,
+			want:           true,
+			wantResult: &v1beta1.ValidationRestoreResult{
+				Succeeded:         false,
+				TotalItems:        30,
+				ItemsRestored:     25,
+				Errors:            5,
+				SandboxNamespaces: []string{"sandbox-app-ns"},
+				SandboxCleanedUp:  true,
+			},
+			wantSandboxDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(createNamespace("app-ns"), createNamespace("sandbox-app-ns")).
+				Build()
+
+			if got := processValidationRestore(context.Background(), fakeClient, tt.restore,
+				tt.veleroRestores); got != tt.want {
+				t.Errorf("processValidationRestore() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.restore.Status.ValidationRestoreResult, tt.wantResult) {
+				t.Errorf("processValidationRestore() result = %v, want %v",
+					tt.restore.Status.ValidationRestoreResult, tt.wantResult)
 			}
-		}
 
-		for i := range tt.resourcesToKeep {
-			if _, err := dr.Namespace(tt.resourcesToKeep[i].GetNamespace()).
-				Get(tt.args.ctx, tt.resourcesToKeep[i].GetName(), v1.GetOptions{}); err != nil {
-				t.Errorf("cleanupDeltaForClustersBackup(%s) resource %s should be found ! they were deleted",
-					tt.name, tt.resourcesToKeep[i].GetName())
+			err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "sandbox-app-ns"},
+				&corev1.Namespace{})
+			if tt.wantSandboxDeleted != k8serr.IsNotFound(err) {
+				t.Errorf("processValidationRestore() sandbox namespace deleted = %v, want %v",
+					k8serr.IsNotFound(err), tt.wantSandboxDeleted)
 			}
-		}
+			// the restored namespaces are never deleted
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "app-ns"},
+				&corev1.Namespace{}); err != nil {
+				t.Errorf("processValidationRestore() restored namespace app-ns error = %v", err)
+			}
+		})
+	}
+}
 
+func Test_countFailedItemsByKind(t *testing.T) {
+	restoreResults := results.Result{
+		Velero:  []string{"error getting the backup"},
+		Cluster: []string{"error restoring managedclusters.cluster.open-cluster-management.io/cluster1: conflict"},
+		Namespaces: map[string][]string{
+			"ns1": {
+				"error restoring configmaps/ns1/cm1: denied",
+				"error restoring configmaps/ns1/cm2: denied",
+			},
+			"ns2": {
+				"error restoring channels.apps.open-cluster-management.io/ns2/ch1: no matches for kind",
+			},
+		},
 	}
+	failedItems := map[string]int{"configmaps": 1}
 
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
+	countFailedItemsByKind(restoreResults, failedItems)
+
+	want := map[string]int{
+		"configmaps": 3,
+		"managedclusters.cluster.open-cluster-management.io": 1,
+		"channels.apps.open-cluster-management.io":           1,
+		"unknown": 1,
+	}
+	if !reflect.DeepEqual(failedItems, want) {
+		t.Errorf("countFailedItemsByKind() = %v, want %v", failedItems, want)
+	}
+}
+
+func Test_updateFailedItemsByKind(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	resultsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gzipWriter := gzip.NewWriter(w)
+		defer gzipWriter.Close()
+		_ = json.NewEncoder(gzipWriter).Encode(map[string]results.Result{
+			"errors": {
+				Namespaces: map[string][]string{
+					"ns1": {
+						"error restoring configmaps/ns1/cm1: denied",
+						"error restoring secrets/ns1/s1: denied",
+					},
+				},
+			},
+			"warnings": {
+				Namespaces: map[string][]string{
+					"ns1": {"could not restore, configmaps already exists"},
+				},
+			},
+		})
+	}))
+	defer resultsServer.Close()
+
+	ns := "velero-ns"
+	acmRestore := createACMRestore("restore", ns).
+		phase(v1beta1.RestorePhaseFinishedWithErrors).
+		veleroCredentialsRestoreName("restore-credentials").object
+	acmRestore.Status.VeleroResourcesRestoreName = "restore-resources"
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			createRestore("restore-credentials", ns).phase(veleroapi.RestorePhaseCompleted).object,
+			createRestore("restore-resources", ns).phase(veleroapi.RestorePhasePartiallyFailed).object,
+		).
+		Build()
+
+	// the download request is created, wait for velero to process it
+	updated, pending := updateFailedItemsByKind(context.Background(), fakeClient, acmRestore)
+	if updated || !pending {
+		t.Fatalf("updateFailedItemsByKind() = %v, %v, want false, true", updated, pending)
+	}
+	downloadRequest := &veleroapi.DownloadRequest{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{
+		Name: "restore-resources-results", Namespace: ns}, downloadRequest); err != nil {
+		t.Fatalf("download request not created: %s", err.Error())
+	}
+	if downloadRequest.Spec.Target.Kind != veleroapi.DownloadTargetKindRestoreResults ||
+		downloadRequest.Spec.Target.Name != "restore-resources" {
+		t.Errorf("updateFailedItemsByKind() download request target = %v", downloadRequest.Spec.Target)
+	}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{
+		Name: "restore-credentials-results", Namespace: ns}, &veleroapi.DownloadRequest{}); !k8serr.IsNotFound(err) {
+		t.Errorf("updateFailedItemsByKind() download request created for a completed restore")
+	}
+
+	// velero did not process the download request yet
+	updated, pending = updateFailedItemsByKind(context.Background(), fakeClient, acmRestore)
+	if updated || !pending {
+		t.Fatalf("updateFailedItemsByKind() = %v, %v, want false, true", updated, pending)
+	}
+
+	// the download request is processed, the failed items are reported
+	downloadRequest.Status.Phase = veleroapi.DownloadRequestPhaseProcessed
+	downloadRequest.Status.DownloadURL = resultsServer.URL
+	if err := fakeClient.Update(context.Background(), downloadRequest); err != nil {
+		t.Fatalf("Error updating download request: %s", err.Error())
+	}
+	updated, pending = updateFailedItemsByKind(context.Background(), fakeClient, acmRestore)
+	if !updated || pending {
+		t.Fatalf("updateFailedItemsByKind() = %v, %v, want true, false", updated, pending)
+	}
+	want := map[string]int{"configmaps": 1, "secrets": 1}
+	if !reflect.DeepEqual(acmRestore.Status.FailedItemsByKind, want) {
+		t.Errorf("updateFailedItemsByKind() FailedItemsByKind = %v, want %v",
+			acmRestore.Status.FailedItemsByKind, want)
+	}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{
+		Name: "restore-resources-results", Namespace: ns}, &veleroapi.DownloadRequest{}); !k8serr.IsNotFound(err) {
+		t.Errorf("updateFailedItemsByKind() download request not deleted after the results were read")
+	}
+
+	// already reported
+	updated, pending = updateFailedItemsByKind(context.Background(), fakeClient, acmRestore)
+	if updated || pending {
+		t.Errorf("updateFailedItemsByKind() = %v, %v, want false, false", updated, pending)
 	}
 }
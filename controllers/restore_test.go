@@ -18,20 +18,42 @@ limitations under the License.
 package controllers
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 )
 
@@ -137,9 +159,10 @@ func Test_isValidSyncOptions(t *testing.T) {
 		restore *v1beta1.Restore
 	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name    string
+		args    args
+		want    bool
+		wantMsg string
 	}{
 		{
 			name: "Skip all",
@@ -205,12 +228,81 @@ func Test_isValidSyncOptions(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "Valid config, sync interval lower than the minimum",
+			args: args{
+				restore: createACMRestore("Restore", "veleroNamespace").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Second * 5}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestore).
+					veleroCredentialsBackupName(latestBackup).
+					veleroResourcesBackupName(latestBackup).object,
+			},
+			want:    true,
+			wantMsg: "RestoreSyncInterval 5s is lower than the minimum value, 1m0s is used instead.",
+		},
+		{
+			name: "Valid config, sync interval set to the minimum",
+			args: args{
+				restore: createACMRestore("Restore", "veleroNamespace").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 1}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestore).
+					veleroCredentialsBackupName(latestBackup).
+					veleroResourcesBackupName(latestBackup).object,
+			},
+			want: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got, msg := isValidSyncOptions(tt.args.restore); got != tt.want {
+			got, msg := isValidSyncOptions(tt.args.restore)
+			if got != tt.want {
 				t.Errorf("failed test %s isValidSyncOptions() = %v, want %v, message: %s", tt.name, got, tt.want, msg)
 			}
+			if got && msg != tt.wantMsg {
+				t.Errorf("failed test %s isValidSyncOptions() message = %v, want %v", tt.name, msg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func Test_getRestoreSyncInterval(t *testing.T) {
+	tests := []struct {
+		name         string
+		syncInterval time.Duration
+		want         time.Duration
+	}{
+		{
+			name:         "interval not set",
+			syncInterval: 0,
+			want:         restoreSyncInterval,
+		},
+		{
+			name:         "interval lower than the minimum",
+			syncInterval: time.Millisecond * 100,
+			want:         minRestoreSyncInterval,
+		},
+		{
+			name:         "negative interval",
+			syncInterval: -time.Minute,
+			want:         minRestoreSyncInterval,
+		},
+		{
+			name:         "valid interval",
+			syncInterval: time.Minute * 15,
+			want:         time.Minute * 15,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("Restore", "veleroNamespace").
+				restoreSyncInterval(v1.Duration{Duration: tt.syncInterval}).object
+			if got := getRestoreSyncInterval(restore); got != tt.want {
+				t.Errorf("getRestoreSyncInterval() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -288,6 +380,66 @@ func Test_isSkipAllRestores(t *testing.T) {
 	}
 }
 
+func Test_getSkippedResourceTypes(t *testing.T) {
+	skipRestore := "skip"
+	latestBackup := "latest"
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    []string
+	}{
+		{
+			name:    "No backup name",
+			restore: createACMRestore("Restore", "veleroNamespace").object,
+			want:    nil,
+		},
+		{
+			name: "Nothing skipped",
+			restore: createACMRestore("Restore", "veleroNamespace").
+				veleroManagedClustersBackupName(latestBackup).
+				veleroCredentialsBackupName(latestBackup).
+				veleroResourcesBackupName(latestBackup).object,
+			want: nil,
+		},
+		{
+			name: "Skip managed clusters",
+			restore: createACMRestore("Restore", "veleroNamespace").
+				veleroManagedClustersBackupName(skipRestore).
+				veleroCredentialsBackupName(latestBackup).
+				veleroResourcesBackupName(latestBackup).object,
+			want: []string{string(ManagedClusters)},
+		},
+		{
+			name: "Skip credentials and resources, name not trimmed",
+			restore: createACMRestore("Restore", "veleroNamespace").
+				veleroManagedClustersBackupName(latestBackup).
+				veleroCredentialsBackupName(" Skip ").
+				veleroResourcesBackupName(skipRestore).object,
+			want: []string{string(Credentials), string(Resources)},
+		},
+		{
+			name: "Skip all",
+			restore: createACMRestore("Restore", "veleroNamespace").
+				veleroManagedClustersBackupName(skipRestore).
+				veleroCredentialsBackupName(skipRestore).
+				veleroResourcesBackupName(skipRestore).object,
+			want: []string{string(ManagedClusters), string(Credentials), string(Resources)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSkippedResourceTypes(tt.restore); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSkippedResourceTypes() = %v, want %v", got, tt.want)
+			}
+			setRestorePhase(nil, tt.restore)
+			if !reflect.DeepEqual(tt.restore.Status.SkippedResourceTypes, tt.want) {
+				t.Errorf("Status.SkippedResourceTypes = %v, want %v",
+					tt.restore.Status.SkippedResourceTypes, tt.want)
+			}
+		})
+	}
+}
+
 func Test_sendResults(t *testing.T) {
 	skipRestore := "skip"
 	type args struct {
@@ -452,1159 +604,1395 @@ func Test_setRestorePhase(t *testing.T) {
 	}
 }
 
-func Test_getVeleroBackupName(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-
-	veleroNamespaceName := "backup-ns"
-	veleroNamespace := *createNamespace(veleroNamespaceName)
-
-	backup := *createBackup("acm-credentials-schedule-20220922170041", veleroNamespaceName).
-		labels(map[string]string{
-			BackupVeleroLabel:          "aa",
-			BackupScheduleClusterLabel: "abcd",
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		errors(0).object
-
-	backupClsNoMatch := *createBackup("acm-credentials-cluster-schedule-20220922170039", veleroNamespaceName).
-		labels(map[string]string{
-			BackupVeleroLabel:          "aa",
-			BackupScheduleClusterLabel: "abcd",
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		errors(0).object
-
-	backupClsExactTime := *createBackup("acm-credentials-cluster-schedule-20220922170041", veleroNamespaceName).
-		labels(map[string]string{
-			BackupVeleroLabel:          "aa",
-			BackupScheduleClusterLabel: "abcd",
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		errors(0).object
-
-	backupTime, _ := time.Parse(time.RFC3339, "2022-09-22T17:00:15Z")
-
-	backupClsExactWithin30s := *createBackup("acm-credentials-cluster-schedule-202209221745", veleroNamespaceName).
-		labels(map[string]string{
-			BackupVeleroLabel:          "aa",
-			BackupScheduleClusterLabel: "abcd",
-		}).
-		startTimestamp(metav1.NewTime(backupTime)).
-		phase(veleroapi.BackupPhaseCompleted).
-		errors(0).object
-
-	cfg, _ := testEnv.Start()
-	k8sClient1, _ := client.New(cfg, client.Options{Scheme: scheme.Scheme})
-
-	type args struct {
-		ctx              context.Context
-		c                client.Client
-		restoreNamespace string
-		resourceType     ResourceType
-		backupName       string
-	}
+func Test_setRestorePhase_itemOperations(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want string
+		name           string
+		veleroRestores []veleroapi.Restore
+		wantPhase      v1beta1.RestorePhase
+		wantInProgress int
 	}{
 		{
-			name: "no kind is registered for the type v1.BackupList",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsCluster,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name: "item operations in progress",
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-credentials", "ns").
+					phase(veleroapi.RestorePhaseCompleted).object,
+				*createRestore("restore-resources", "ns").
+					phase(veleroapi.RestorePhaseWaitingForPluginOperations).
+					itemOperations(5, 2, 0).object,
 			},
-			want: "",
+			wantPhase:      v1beta1.RestorePhaseRunning,
+			wantInProgress: 3,
 		},
 		{
-			name: "no backup items",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsCluster,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name: "item operations in progress after errors",
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-resources", "ns").
+					phase(veleroapi.RestorePhaseWaitingForPluginOperationsPartiallyFailed).
+					itemOperations(5, 2, 1).object,
 			},
-			want: "",
+			wantPhase:      v1beta1.RestorePhaseRunning,
+			wantInProgress: 2,
 		},
 		{
-			name: "found backup item but time is not matching",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsCluster,
-				backupName:       "acm-credentials-schedule-20220822170041",
-				restoreNamespace: veleroNamespaceName,
+			name: "item operations completed",
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-resources", "ns").
+					phase(veleroapi.RestorePhaseCompleted).
+					itemOperations(5, 5, 0).object,
 			},
-			want: "",
+			wantPhase:      v1beta1.RestorePhaseFinished,
+			wantInProgress: 0,
 		},
 		{
-			name: "found backup item for credentials",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     Credentials,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name: "item operations failed",
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-resources", "ns").
+					phase(veleroapi.RestorePhasePartiallyFailed).
+					itemOperations(5, 4, 1).object,
 			},
-			want: backup.Name,
+			wantPhase:      v1beta1.RestorePhaseFinishedWithErrors,
+			wantInProgress: 0,
 		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(latestBackupStr).
+				veleroCredentialsBackupName(latestBackupStr).
+				veleroResourcesBackupName(latestBackupStr).object
+
+			phase, _ := setRestorePhase(&veleroapi.RestoreList{Items: tt.veleroRestores}, restore)
+			if phase != tt.wantPhase {
+				t.Errorf("setRestorePhase() phase = %v, want %v", phase, tt.wantPhase)
+			}
+			if restore.Status.ItemOperationsInProgress != tt.wantInProgress {
+				t.Errorf("setRestorePhase() ItemOperationsInProgress = %v, want %v",
+					restore.Status.ItemOperationsInProgress, tt.wantInProgress)
+			}
+		})
+	}
+}
+
+func Test_emitRestoreProgressEvent(t *testing.T) {
+	startTime := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+	restore := createACMRestore("acm-restore", "ns").
+		emitProgressEvents(true).
+		phase(v1beta1.RestorePhaseStarted).object
+
+	// the steps run in order on the same restore
+	steps := []struct {
+		name              string
+		phase             v1beta1.RestorePhase
+		veleroRestores    []veleroapi.Restore
+		activatedClusters []string
+		elapsed           time.Duration
+		wantEvent         bool
+		wantPercent       int
+	}{
 		{
-			name: "NOT found backup item for credentials hive",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsHive,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name:      "first progress is reported",
+			phase:     v1beta1.RestorePhaseStarted,
+			elapsed:   0,
+			wantEvent: true,
+		},
+		{
+			name:      "no-op reconcile",
+			phase:     v1beta1.RestorePhaseStarted,
+			elapsed:   time.Minute * 5,
+			wantEvent: false,
+		},
+		{
+			name:  "phase change is reported",
+			phase: v1beta1.RestorePhaseRunning,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-credentials", "ns").phase(veleroapi.RestorePhaseInProgress).object,
+				*createRestore("restore-resources", "ns").phase(veleroapi.RestorePhaseInProgress).object,
 			},
-			want: "",
+			elapsed:   time.Minute*5 + time.Second,
+			wantEvent: true,
 		},
 		{
-			name: "found backup item for credentials cluster NOT found no exact match on timestamp and not within 30s",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsCluster,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name:  "percentage change within the rate limit interval",
+			phase: v1beta1.RestorePhaseRunning,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-credentials", "ns").phase(veleroapi.RestorePhaseCompleted).object,
+				*createRestore("restore-resources", "ns").phase(veleroapi.RestorePhaseInProgress).object,
 			},
-			want: "",
+			elapsed:   time.Minute*5 + time.Second*30,
+			wantEvent: false,
 		},
 		{
-			name: "found backup item for credentials cluster when exact match on timestamp",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsCluster,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name:  "percentage change is reported after the rate limit interval",
+			phase: v1beta1.RestorePhaseRunning,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-credentials", "ns").phase(veleroapi.RestorePhaseCompleted).object,
+				*createRestore("restore-resources", "ns").phase(veleroapi.RestorePhaseInProgress).object,
 			},
-			want: backupClsExactTime.Name,
+			elapsed:     time.Minute * 7,
+			wantEvent:   true,
+			wantPercent: 50,
 		},
 		{
-			name: "found backup item for credentials cluster when NOT exact match on timestamp but withn 30s",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				resourceType:     CredentialsCluster,
-				backupName:       latestBackupStr,
-				restoreNamespace: veleroNamespaceName,
+			name:  "no-op reconcile after the rate limit interval",
+			phase: v1beta1.RestorePhaseRunning,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-credentials", "ns").phase(veleroapi.RestorePhaseCompleted).object,
+				*createRestore("restore-resources", "ns").phase(veleroapi.RestorePhaseInProgress).object,
 			},
-			want: backupClsExactWithin30s.Name,
+			elapsed:     time.Minute * 10,
+			wantEvent:   false,
+			wantPercent: 50,
+		},
+		{
+			name:  "restore finished",
+			phase: v1beta1.RestorePhaseFinished,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-credentials", "ns").phase(veleroapi.RestorePhaseCompleted).object,
+				*createRestore("restore-resources", "ns").phase(veleroapi.RestorePhaseCompleted).object,
+			},
+			activatedClusters: []string{"cluster1", "cluster2"},
+			elapsed:           time.Minute*10 + time.Second,
+			wantEvent:         true,
+			wantPercent:       100,
 		},
 	}
 
-	for index, tt := range tests {
+	for _, step := range steps {
+		recorder := record.NewFakeRecorder(10)
+		restore.Status.Phase = step.phase
+		restore.Status.ActivatedClusters = step.activatedClusters
 
-		if index == 1 {
-			if err := veleroapi.AddToScheme(scheme.Scheme); err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
-			}
+		emitRestoreProgressEvent(recorder, restore,
+			&veleroapi.RestoreList{Items: step.veleroRestores}, startTime.Add(step.elapsed))
+
+		if gotEvent := len(recorder.Events) > 0; gotEvent != step.wantEvent {
+			t.Errorf("step %q: event emitted = %v, want %v", step.name, gotEvent, step.wantEvent)
+			continue
 		}
-		if index == 2 {
-			err := k8sClient1.Create(context.Background(), &veleroNamespace)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
-			err = k8sClient1.Create(context.Background(), &backup)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
-			err = k8sClient1.Create(context.Background(), &backupClsNoMatch)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
+		if !step.wantEvent {
+			continue
 		}
-		if index == len(tests)-2 {
-			err := k8sClient1.Create(context.Background(), &backupClsExactTime)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
+		event := <-recorder.Events
+		want := fmt.Sprintf("RestoreProgress Restore acm-restore is %s, %d%% complete, %d managed clusters activated",
+			step.phase, step.wantPercent, len(step.activatedClusters))
+		if !strings.Contains(event, want) {
+			t.Errorf("step %q: event = %s, want %s", step.name, event, want)
 		}
-		if index == len(tests)-1 {
-			err := k8sClient1.Create(context.Background(), &backupClsExactWithin30s)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
-			err = k8sClient1.Delete(context.Background(), &backupClsExactTime)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
+		if restore.Status.LastProgressEvent == nil ||
+			restore.Status.LastProgressEvent.Timestamp.Time != startTime.Add(step.elapsed) {
+			t.Errorf("step %q: LastProgressEvent = %v", step.name, restore.Status.LastProgressEvent)
 		}
-		t.Run(tt.name, func(t *testing.T) {
-			veleroBackups := &veleroapi.BackupList{}
-			if index > 0 { // First test intentionally doesn't have velero in client scheme
-				err := tt.args.c.List(tt.args.ctx, veleroBackups, client.InNamespace(veleroNamespace.Name))
-				if err != nil {
-					t.Errorf("Error listing veleroBackups: %s", err.Error())
-				}
-			}
-			if name, _, _ := getVeleroBackupName(tt.args.ctx, tt.args.c,
-				tt.args.restoreNamespace, tt.args.resourceType, tt.args.backupName, veleroBackups); name != tt.want {
-				t.Errorf("getVeleroBackupName() returns = %v, want %v", name, tt.want)
-			}
-		})
-	}
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Errorf("Error stopping testenv: %s", err.Error())
 	}
-}
 
-func Test_isNewBackupAvailable(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
-	scheme1 := runtime.NewScheme()
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+	// no events when the option is not set
+	recorder := record.NewFakeRecorder(10)
+	emitRestoreProgressEvent(recorder,
+		createACMRestore("acm-restore", "ns").phase(v1beta1.RestorePhaseStarted).object,
+		nil, startTime)
+	if len(recorder.Events) > 0 {
+		t.Errorf("event emitted with EmitProgressEvents not set")
 	}
+}
 
-	skipRestore := "skip"
-	latestBackup := "latest"
-
-	veleroNamespaceName := "backup-ns"
-	veleroNamespace := *createNamespace(veleroNamespaceName)
-
-	passiveStr := "passive"
-	backupName := "acm-credentials-schedule-20220922170041"
-	restoreName := passiveStr + "-" + backupName
-
-	backup := *createBackup(backupName, veleroNamespaceName).
-		labels(map[string]string{
-			BackupVeleroLabel:          "aa",
-			BackupScheduleClusterLabel: "abcd",
-		}).
-		phase(veleroapi.BackupPhaseCompleted).
-		errors(0).object
+func Test_setOptionalProperties_itemOperationTimeout(t *testing.T) {
+	acmRestore := createACMRestore("acm-restore", "ns").
+		itemOperationTimeout(v1.Duration{Duration: time.Hour * 4}).object
 
-	veleroRestore := veleroapi.Restore{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "velero/v1",
-			Kind:       "Restore",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      restoreName,
-			Namespace: veleroNamespaceName,
-		},
-		Spec: veleroapi.RestoreSpec{
-			BackupName: backupName,
-		},
-		Status: veleroapi.RestoreStatus{
-			Phase: "Completed",
-		},
+	resourcesRestore := createRestore("resources-restore", "ns").object
+	setOptionalProperties(Resources, acmRestore, resourcesRestore)
+	if resourcesRestore.Spec.ItemOperationTimeout.Duration != time.Hour*4 {
+		t.Errorf("setOptionalProperties() resources restore ItemOperationTimeout = %v, want %v",
+			resourcesRestore.Spec.ItemOperationTimeout.Duration, time.Hour*4)
 	}
 
-	restoreCreds := *createACMRestore(passiveStr, veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(skipRestore).
-		veleroCredentialsBackupName(latestBackup).
-		veleroResourcesBackupName(latestBackup).object
-
-	restoreCredSameBackup := *createACMRestore(passiveStr, veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(skipRestore).
-		veleroCredentialsBackupName(latestBackup).
-		veleroResourcesBackupName(latestBackup).
-		veleroCredentialsBackupName(veleroRestore.Name).object
+	credentialsRestore := createRestore("credentials-restore", "ns").object
+	setOptionalProperties(Credentials, acmRestore, credentialsRestore)
+	if credentialsRestore.Spec.ItemOperationTimeout.Duration != 0 {
+		t.Errorf("setOptionalProperties() credentials restore ItemOperationTimeout = %v, want not set",
+			credentialsRestore.Spec.ItemOperationTimeout.Duration)
+	}
+}
 
-	restoreCredNewBackup := *createACMRestore(passiveStr, veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		restoreSyncInterval(metav1.Duration{Duration: time.Minute * 20}).
-		veleroManagedClustersBackupName(skipRestore).
-		veleroCredentialsBackupName(latestBackup).
-		veleroResourcesBackupName(latestBackup).
-		veleroCredentialsRestoreName(veleroRestore.Name + "11").object
+func Test_setOptionalProperties_singleClusterRestore(t *testing.T) {
+	acmRestore := createACMRestore("acm-restore", "ns").
+		singleClusterRestore("managed1").
+		excludedNamespaces([]string{"ns1"}).object
 
-	type args struct {
-		ctx          context.Context
-		c            client.Client
-		restore      *v1beta1.Restore
-		resourceType ResourceType
-	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name                 string
+		resourceType         ResourceType
+		wantClusterResources bool
+		wantOrLabelSelectors []*v1.LabelSelector
 	}{
 		{
-			name: "no kind is registered for the type v1.BackupList",
-			args: args{
-				ctx:          context.Background(),
-				c:            k8sClient1,
-				restore:      &restoreCreds,
-				resourceType: CredentialsCluster,
-			},
-			want: false,
-		},
-		{
-			name: "no backup items",
-			args: args{
-				ctx:          context.Background(),
-				c:            k8sClient1,
-				resourceType: CredentialsCluster,
-				restore:      &restoreCreds,
-			},
-			want: false,
-		},
-
-		{
-			name: "NOT found restore item ",
-			args: args{
-				ctx:          context.Background(),
-				c:            k8sClient1,
-				resourceType: CredentialsCluster,
-				restore:      &restoreCreds,
-			},
-			want: false,
+			name:                 "credentials restore",
+			resourceType:         Credentials,
+			wantClusterResources: false,
 		},
 		{
-			name: "found restore item but not the latest backup",
-			args: args{
-				ctx:          context.Background(),
-				c:            k8sClient1,
-				resourceType: Credentials,
-				restore:      &restoreCredSameBackup,
-			},
-			want: false,
+			name:                 "resources restore",
+			resourceType:         Resources,
+			wantClusterResources: false,
 		},
 		{
-			name: "found restore item AND new backup",
-			args: args{
-				ctx:          context.Background(),
-				c:            k8sClient1,
-				resourceType: Credentials,
-				restore:      &restoreCredNewBackup,
-			},
-			want: true,
+			name:                 "generic resources restore",
+			resourceType:         ResourcesGeneric,
+			wantClusterResources: false,
 		},
 		{
-			name: "found restore item AND new backup, with restore found",
-			args: args{
-				ctx:          context.Background(),
-				c:            k8sClient1,
-				resourceType: Credentials,
-				restore:      &restoreCredNewBackup,
+			name:                 "managed clusters restore",
+			resourceType:         ManagedClusters,
+			wantClusterResources: true,
+			wantOrLabelSelectors: []*v1.LabelSelector{
+				{
+					MatchLabels: map[string]string{"name": "managed1"},
+				},
+				{
+					MatchExpressions: []v1.LabelSelectorRequirement{
+						{
+							Key:      "name",
+							Operator: v1.LabelSelectorOpDoesNotExist,
+						},
+					},
+				},
 			},
-			want: false,
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroRestore := createRestore("velero-restore", "ns").object
+			setOptionalProperties(tt.resourceType, acmRestore, veleroRestore)
 
-	for index, tt := range tests {
-
-		if index == 1 {
-			err := corev1.AddToScheme(scheme1)
-			if err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
-			}
-			err = v1beta1.AddToScheme(scheme1)
-			if err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
-			}
-			err = veleroapi.AddToScheme(scheme1)
-			if err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
-			}
-		}
-		if index == 2 {
-			err := k8sClient1.Create(tt.args.ctx, &veleroNamespace)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
-			}
-			err = k8sClient1.Create(tt.args.ctx, &backup)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
+			if !reflect.DeepEqual(veleroRestore.Spec.IncludedNamespaces, []string{"managed1"}) {
+				t.Errorf("setOptionalProperties() IncludedNamespaces = %v, want [managed1]",
+					veleroRestore.Spec.IncludedNamespaces)
 			}
-			err = k8sClient1.Create(context.Background(), &veleroRestore)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
+			if !reflect.DeepEqual(veleroRestore.Spec.ExcludedNamespaces, []string{"ns1"}) {
+				t.Errorf("setOptionalProperties() ExcludedNamespaces = %v, want [ns1]",
+					veleroRestore.Spec.ExcludedNamespaces)
 			}
-		}
-		if index == len(tests)-1 {
-			// create restore
-			err := k8sClient1.Create(tt.args.ctx, &restoreCredNewBackup)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
+			if veleroRestore.Spec.IncludeClusterResources == nil ||
+				*veleroRestore.Spec.IncludeClusterResources != tt.wantClusterResources {
+				t.Errorf("setOptionalProperties() IncludeClusterResources = %v, want %v",
+					veleroRestore.Spec.IncludeClusterResources, tt.wantClusterResources)
 			}
-		}
-		t.Run(tt.name, func(t *testing.T) {
-			if got := isNewBackupAvailable(tt.args.ctx, tt.args.c,
-				tt.args.restore, tt.args.resourceType); got != tt.want {
-				t.Errorf("isNewBackupAvailable() returns = %v, want %v, %v", got, tt.want, tt.args.resourceType)
+			if !reflect.DeepEqual(veleroRestore.Spec.OrLabelSelectors, tt.wantOrLabelSelectors) {
+				t.Errorf("setOptionalProperties() OrLabelSelectors = %v, want %v",
+					veleroRestore.Spec.OrLabelSelectors, tt.wantOrLabelSelectors)
 			}
 		})
 	}
-
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
-	}
 }
 
-func Test_isBackupScheduleRunning(t *testing.T) {
-	type args struct {
-		backupSchedules []v1beta1.BackupSchedule
-	}
+func Test_isValidSingleClusterRestore(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		restore *v1beta1.Restore
+		want    string
 	}{
 		{
-			name: "backup list is empty",
-			args: args{
-				backupSchedules: []v1beta1.BackupSchedule{},
-			},
-			want: "",
+			name:    "single cluster restore not used",
+			restore: createACMRestore("restore", "ns").object,
+			want:    "",
 		},
 		{
-			name: "backup without backupcollision running",
-			args: args{
-				backupSchedules: []v1beta1.BackupSchedule{
-					{
-						TypeMeta: metav1.TypeMeta{
-							APIVersion: "cluster.open-cluster-management.io/v1beta1",
-							Kind:       "BackupSchedule",
-						},
-						ObjectMeta: metav1.ObjectMeta{
-							Name:      "backup-name",
-							Namespace: "ns",
-						},
-						Status: v1beta1.BackupScheduleStatus{
-							Phase: v1beta1.SchedulePhaseEnabled,
-						},
-					},
-				},
-			},
-			want: "backup-name",
+			name:    "valid cluster name",
+			restore: createACMRestore("restore", "ns").singleClusterRestore("managed1").object,
+			want:    "",
 		},
 		{
-			name: "backup WITH backupcollision",
-			args: args{
-				backupSchedules: []v1beta1.BackupSchedule{
-					*createBackupSchedule("backup-name", "ns").
-						phase(v1beta1.SchedulePhaseBackupCollision).
-						object,
-				},
-			},
-			want: "",
+			name:    "invalid cluster name",
+			restore: createACMRestore("restore", "ns").singleClusterRestore("Managed1").object,
+			want:    "invalid SingleClusterRestore : a lowercase RFC 1123 label must consist of",
 		},
 		{
-			name: "backup WITH paused schedule",
-			args: args{
-				backupSchedules: []v1beta1.BackupSchedule{
-					*createBackupSchedule("backup-name", "ns").
-						phase(v1beta1.SchedulePhasePaused).
-						object,
-				},
-			},
-			want: "",
+			name: "used with included namespaces",
+			restore: createACMRestore("restore", "ns").
+				singleClusterRestore("managed1").
+				includedNamespaces([]string{"ns1"}).object,
+			want: "SingleClusterRestore cannot be used together with the IncludedNamespaces",
+		},
+		{
+			name: "used with the sandbox prefix",
+			restore: createACMRestore("restore", "ns").
+				singleClusterRestore("managed1").
+				sandboxNamespacePrefix("sandbox").object,
+			want: "SingleClusterRestore cannot be used together with the IncludedNamespaces",
+		},
+		{
+			name: "used with a label selector",
+			restore: createACMRestore("restore", "ns").
+				singleClusterRestore("managed1").
+				restoreLabelSelector(&v1.LabelSelector{MatchLabels: map[string]string{"a": "b"}}).object,
+			want: "SingleClusterRestore cannot be used together with the LabelSelector or OrLabelSelectors options",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isBackupScheduleRunning(tt.args.backupSchedules); got != tt.want {
-				t.Errorf("isBackupScheduleRunning() = %v, want %v", got, tt.want)
+			got := isValidSingleClusterRestore(tt.restore)
+			if (tt.want == "") != (got == "") || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("isValidSingleClusterRestore() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_isOtherRestoresRunning(t *testing.T) {
-	type args struct {
-		restores    []v1beta1.Restore
-		restoreName string
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
+func Test_setRestorePhase_phaseTransitions(t *testing.T) {
+	restore := createACMRestore("restore", "ns").
+		cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+		veleroManagedClustersBackupName(latestBackupStr).
+		veleroCredentialsBackupName(latestBackupStr).
+		veleroResourcesBackupName(latestBackupStr).object
+
+	veleroRestores := func(phase veleroapi.RestorePhase) *veleroapi.RestoreList {
+		return &veleroapi.RestoreList{
+			Items: []veleroapi.Restore{
+				*createRestore("restore-resources", "ns").phase(phase).object,
+			},
+		}
+	}
+
+	// the same phase is set twice, the transition is recorded only once
+	steps := []*veleroapi.RestoreList{
+		nil,
+		veleroRestores(veleroapi.RestorePhaseNew),
+		veleroRestores(veleroapi.RestorePhaseInProgress),
+		veleroRestores(veleroapi.RestorePhaseInProgress),
+		veleroRestores(veleroapi.RestorePhaseCompleted),
+	}
+	for _, step := range steps {
+		setRestorePhase(step, restore)
+	}
+
+	wantPhases := []v1beta1.RestorePhase{
+		v1beta1.RestorePhaseStarted,
+		v1beta1.RestorePhaseRunning,
+		v1beta1.RestorePhaseFinished,
+	}
+	if len(restore.Status.PhaseTransitions) != len(wantPhases) {
+		t.Fatalf("setRestorePhase() phase transitions = %v, want phases %v",
+			restore.Status.PhaseTransitions, wantPhases)
+	}
+	for i, transition := range restore.Status.PhaseTransitions {
+		if transition.Phase != wantPhases[i] {
+			t.Errorf("setRestorePhase() phase transition %d = %v, want %v", i, transition.Phase, wantPhases[i])
+		}
+		if transition.Message == "" || transition.Timestamp.IsZero() {
+			t.Errorf("setRestorePhase() phase transition %d has no message or timestamp", i)
+		}
+		if i > 0 && transition.Timestamp.Before(&restore.Status.PhaseTransitions[i-1].Timestamp) {
+			t.Errorf("setRestorePhase() phase transition %d is recorded before the previous one", i)
+		}
+	}
+}
+
+func Test_recordPhaseTransition(t *testing.T) {
+	restore := createACMRestore("restore", "ns").object
+
+	// no phase change, nothing recorded
+	recordPhaseTransition(restore, restore.Status.Phase)
+	if len(restore.Status.PhaseTransitions) != 0 {
+		t.Errorf("recordPhaseTransition() recorded %v, want no transitions", restore.Status.PhaseTransitions)
+	}
+
+	// the transitions are capped, the oldest transitions are removed
+	phases := []v1beta1.RestorePhase{v1beta1.RestorePhaseEnabled, v1beta1.RestorePhaseRunning}
+	for i := 0; i < maxPhaseTransitions+5; i++ {
+		previousPhase := restore.Status.Phase
+		restore.Status.Phase = phases[i%2]
+		restore.Status.LastMessage = fmt.Sprintf("message %d", i)
+		recordPhaseTransition(restore, previousPhase)
+	}
+	if len(restore.Status.PhaseTransitions) != maxPhaseTransitions {
+		t.Errorf("recordPhaseTransition() recorded %v transitions, want %v",
+			len(restore.Status.PhaseTransitions), maxPhaseTransitions)
+	}
+	if got := restore.Status.PhaseTransitions[0].Message; got != "message 5" {
+		t.Errorf("recordPhaseTransition() oldest transition message = %v, want message 5", got)
+	}
+	if got := restore.Status.PhaseTransitions[maxPhaseTransitions-1].Message; got != fmt.Sprintf(
+		"message %d", maxPhaseTransitions+4) {
+		t.Errorf("recordPhaseTransition() latest transition message = %v, want message %d",
+			got, maxPhaseTransitions+4)
+	}
+}
+
+func Test_transitionRestorePhase(t *testing.T) {
+	restore := createACMRestore("restore", "ns").object
+
+	transitionRestorePhase(restore, v1beta1.RestorePhaseStarted, "waiting")
+	transitionRestorePhase(restore, v1beta1.RestorePhaseStarted, "still waiting")
+	transitionRestorePhase(restore, v1beta1.RestorePhaseFinished, "completed")
+
+	if restore.Status.Phase != v1beta1.RestorePhaseFinished || restore.Status.LastMessage != "completed" {
+		t.Errorf("transitionRestorePhase() phase = %v, message = %v, want %v, completed",
+			restore.Status.Phase, restore.Status.LastMessage, v1beta1.RestorePhaseFinished)
+	}
+	// the same phase set twice is recorded only once, with the first message
+	want := []v1beta1.PhaseTransition{
+		{Phase: v1beta1.RestorePhaseStarted, Message: "waiting"},
+		{Phase: v1beta1.RestorePhaseFinished, Message: "completed"},
+	}
+	if len(restore.Status.PhaseTransitions) != len(want) {
+		t.Fatalf("transitionRestorePhase() phase transitions = %v, want %v",
+			restore.Status.PhaseTransitions, want)
+	}
+	for i, transition := range restore.Status.PhaseTransitions {
+		if transition.Phase != want[i].Phase || transition.Message != want[i].Message {
+			t.Errorf("transitionRestorePhase() phase transition %d = %v, want %v", i, transition, want[i])
+		}
+	}
+}
+
+func Test_getMissingCRDs(t *testing.T) {
+	fakeDiscovery := &discoveryfake.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{
+						{Name: "secrets", SingularName: "secret", Kind: "Secret", Namespaced: true},
+					},
+				},
+				{
+					GroupVersion: "cluster.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "managedclusters", SingularName: "managedcluster", Kind: "ManagedCluster"},
+					},
+				},
+				{
+					GroupVersion: "apps.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "channels", SingularName: "channel", Kind: "Channel", Namespaced: true},
+					},
+				},
+			},
+		},
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(fakeDiscovery))
+
+	tests := []struct {
+		name   string
+		backup *veleroapi.Backup
+		want   []string
 	}{
 		{
-			name: "restore list is empty",
-			args: args{
-				restores: []v1beta1.Restore{},
-			},
-			want: "",
+			name:   "no backup",
+			backup: nil,
+			want:   []string{},
 		},
 		{
-			name: "restore list has one running item ",
-			args: args{
-				restoreName: "some-name",
-				restores: []v1beta1.Restore{
-					*createACMRestore("some-name", "ns").object,
-					*createACMRestore("some-other-name", "ns").
-						phase(v1beta1.RestorePhaseEnabled).object,
-				},
-			},
-			want: "some-other-name",
+			name: "all CRDs installed",
+			backup: createBackup("acm-resources-schedule-20220922170041", "ns").
+				includedResources([]string{
+					"channel.apps.open-cluster-management.io",
+					"managedcluster.cluster.open-cluster-management.io",
+					"secret",
+				}).object,
+			want: []string{},
 		},
 		{
-			name: "restore list has one completed item ",
-			args: args{
-				restoreName: "some-name",
-				restores: []v1beta1.Restore{
-					*createACMRestore("some-name", "ns").object,
-					*createACMRestore("some-other-name", "ns").
-						phase(v1beta1.RestorePhaseFinishedWithErrors).object,
-				},
+			name: "some CRDs are missing",
+			backup: createBackup("acm-resources-schedule-20220922170041", "ns").
+				includedResources([]string{
+					"policy.policy.open-cluster-management.io",
+					"channel.apps.open-cluster-management.io",
+					"clusterdeployment.hive.openshift.io",
+					"placement.cluster.open-cluster-management.io",
+				}).object,
+			want: []string{
+				"clusterdeployment.hive.openshift.io",
+				"placement.cluster.open-cluster-management.io",
+				"policy.policy.open-cluster-management.io",
 			},
-			want: "",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isOtherRestoresRunning(tt.args.restores, tt.args.restoreName); got != tt.want {
-				t.Errorf("isOtherRestoresRunning() = %v, want %v", got, tt.want)
+			if got := getMissingCRDs(mapper, tt.backup); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getMissingCRDs() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_setOptionalProperties(t *testing.T) {
-	type args struct {
-		restype       ResourceType
-		acmRestore    *v1beta1.Restore
-		veleroRestore *veleroapi.Restore
+func Test_DiffBackupAgainstCluster(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	backupName := "acm-resources-schedule-20220922170041"
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(
+		createBackup(backupName, "ns").
+			includedResources([]string{
+				"channel.apps.open-cluster-management.io",
+				"managedcluster.cluster.open-cluster-management.io",
+				"policy.policy.open-cluster-management.io",
+			}).object,
+	).Build()
+
+	fakeDiscovery := &discoveryfake.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "channels", SingularName: "channel", Kind: "Channel", Namespaced: true},
+					},
+				},
+				{
+					GroupVersion: "cluster.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "managedclusters", SingularName: "managedcluster", Kind: "ManagedCluster"},
+					},
+				},
+			},
+		},
+	}
+
+	liveResource := func(apiVersion, kind, ns, name string, labels map[string]interface{}) *unstructured.Unstructured {
+		metadata := map[string]interface{}{"name": name}
+		if ns != "" {
+			metadata["namespace"] = ns
+		}
+		if labels != nil {
+			metadata["labels"] = labels
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   metadata,
+		}}
 	}
+	channelsGVR := schema.GroupVersionResource{Group: "apps.open-cluster-management.io", Version: "v1",
+		Resource: "channels"}
+	clustersGVR := schema.GroupVersionResource{Group: "cluster.open-cluster-management.io", Version: "v1",
+		Resource: "managedclusters"}
+	dynClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			channelsGVR: "ChannelList",
+			clustersGVR: "ManagedClusterList",
+		},
+		liveResource("apps.open-cluster-management.io/v1", "Channel", "ns1", "restored",
+			map[string]interface{}{BackupNameVeleroLabel: backupName}),
+		liveResource("apps.open-cluster-management.io/v1", "Channel", "ns1", "other-backup",
+			map[string]interface{}{BackupNameVeleroLabel: "acm-resources-schedule-20220922160041"}),
+		liveResource("apps.open-cluster-management.io/v1", "Channel", "ns2", "user-created", nil),
+		// backed up by the generic resources backup
+		liveResource("apps.open-cluster-management.io/v1", "Channel", "ns2", "generic",
+			map[string]interface{}{backupCredsClusterLabel: "true"}),
+		// not backed up
+		liveResource("apps.open-cluster-management.io/v1", "Channel", "ns2", "excluded",
+			map[string]interface{}{ExcludeBackupLabel: "true"}),
+		liveResource("cluster.open-cluster-management.io/v1", "ManagedCluster", "", "cluster1", nil),
+		liveResource("cluster.open-cluster-management.io/v1", "ManagedCluster", "", "local-cluster",
+			map[string]interface{}{localClusterLabel: "true"}),
+	)
 
 	tests := []struct {
-		name string
-		args args
+		name       string
+		backupName string
+		want       DiffResult
+		wantErr    bool
 	}{
 		{
-			name: "verify that CRDs are excluded from restore",
-			args: args{
-				restype: Credentials,
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName("skip").
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestore: createRestore("credentials-restore", "ns").object,
+			name:       "backup not found",
+			backupName: "acm-resources-schedule-missing",
+			wantErr:    true,
+		},
+		{
+			name:       "backup compared with the live resources",
+			backupName: backupName,
+			want: DiffResult{
+				OnlyInBackup: []string{"policy.policy.open-cluster-management.io"},
+				OnlyLive: []string{
+					"channel.apps.open-cluster-management.io/ns2/user-created",
+					"managedcluster.cluster.open-cluster-management.io/cluster1",
+				},
+				Differ:   []string{"channel.apps.open-cluster-management.io/ns1/other-backup"},
+				Restored: []string{"channel.apps.open-cluster-management.io/ns1/restored"},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			setOptionalProperties(tt.args.restype, tt.args.acmRestore, tt.args.veleroRestore)
-			if !findValue(tt.args.veleroRestore.Spec.ExcludedResources, "CustomResourceDefinition") {
-				t.Errorf("CustomResourceDefinition should be excluded from restore and be part of " +
-					"veleroRestore.Spec.ExcludedResources")
+			got, err := DiffBackupAgainstCluster(context.Background(), fakeClient, fakeDiscovery,
+				dynClient, tt.backupName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DiffBackupAgainstCluster() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffBackupAgainstCluster() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_retrieveRestoreDetails(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
+func Test_validateRestoreCRDs(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	skipRestore := "skip"
-	veleroNamespaceName := "default"
-	invalidBackupName := ""
-	backupName := "backup-name"
-
-	backup := *createBackup(backupName, veleroNamespaceName).
-		phase(veleroapi.BackupPhaseCompleted).
-		errors(0).object
-
-	restoreCredsNoError := *createACMRestore("restore1", veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(skipRestore).
-		veleroCredentialsBackupName(skipRestore).
-		veleroResourcesBackupName(backupName).object
+	backupName := "acm-resources-schedule-20220922170041"
+	backup := createBackup(backupName, "ns").
+		includedResources([]string{
+			"channel.apps.open-cluster-management.io",
+			"policy.policy.open-cluster-management.io",
+		}).object
+	fakeDiscovery := &discoveryfake.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "channels", SingularName: "channel", Kind: "Channel", Namespaced: true},
+					},
+				},
+			},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &RestoreReconciler{
+		Client:          fake.NewClientBuilder().WithScheme(scheme1).WithObjects(backup).Build(),
+		DiscoveryClient: fakeDiscovery,
+		Scheme:          scheme1,
+		Recorder:        recorder,
+	}
 
-	restoreCredsInvalidBackupName := *createACMRestore("restore1", veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(latestBackupStr).
-		veleroCredentialsBackupName(skipRestore).
-		veleroResourcesBackupName(invalidBackupName).object
+	restore := createACMRestore("restore", "ns").validateCRDs(true).object
+	r.validateRestoreCRDs(context.Background(), restore, backupName)
+	if !reflect.DeepEqual(restore.Status.MissingCRDs, []string{"policy.policy.open-cluster-management.io"}) {
+		t.Errorf("validateRestoreCRDs() MissingCRDs = %v", restore.Status.MissingCRDs)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("validateRestoreCRDs() expected a warning event for the missing CRDs")
+	}
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
+	// backup not found, nothing reported
+	restore = createACMRestore("restore", "ns").validateCRDs(true).object
+	r.validateRestoreCRDs(context.Background(), restore, "missing-backup")
+	if len(restore.Status.MissingCRDs) != 0 {
+		t.Errorf("validateRestoreCRDs() MissingCRDs = %v, want none", restore.Status.MissingCRDs)
 	}
+}
+
+func Test_skipMissingCRDResources(t *testing.T) {
 	scheme1 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme1)
-	if err != nil {
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
-	}
-	err = k8sClient1.Create(context.Background(), &backup, &client.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating backup: %s", err.Error())
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	type args struct {
-		ctx                        context.Context
-		c                          client.Client
-		s                          *runtime.Scheme
-		restore                    *v1beta1.Restore
-		restoreOnlyManagedClusters bool
+	backupName := "acm-resources-schedule-20220922170041"
+	backup := createBackup(backupName, "ns").
+		includedResources([]string{
+			"channel.apps.open-cluster-management.io",
+			"policy.policy.open-cluster-management.io",
+			"clusterdeployment.hive.openshift.io",
+		}).object
+	fakeDiscovery := &discoveryfake.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps.open-cluster-management.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "channels", SingularName: "channel", Kind: "Channel", Namespaced: true},
+					},
+				},
+			},
+		},
 	}
+
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name              string
+		backupName        string
+		excludedResources []string
+		wantSkipped       []string
+		wantExcluded      []string
+		wantEvents        int
 	}{
 		{
-			name: "retrieveRestoreDetails has error, no backups found",
-			args: args{
-				ctx:                        context.Background(),
-				c:                          k8sClient1,
-				s:                          scheme1,
-				restore:                    &restoreCredsNoError,
-				restoreOnlyManagedClusters: false,
+			name:              "resources with missing CRDs are excluded",
+			backupName:        backupName,
+			excludedResources: []string{"CustomResourceDefinition"},
+			wantSkipped: []string{
+				"clusterdeployment.hive.openshift.io",
+				"policy.policy.open-cluster-management.io",
 			},
-			want: false, // has error, restore not found
+			wantExcluded: []string{
+				"CustomResourceDefinition",
+				"clusterdeployment.hive.openshift.io",
+				"policy.policy.open-cluster-management.io",
+			},
+			wantEvents: 1,
 		},
-
 		{
-			name: "retrieveRestoreDetails has error, no backup name",
-			args: args{
-				ctx:                        context.Background(),
-				c:                          k8sClient1,
-				s:                          scheme1,
-				restore:                    &restoreCredsInvalidBackupName,
-				restoreOnlyManagedClusters: false,
-			},
-			want: false, // has error, backup name is invalid
+			name:              "backup not found, nothing skipped",
+			backupName:        "missing-backup",
+			excludedResources: []string{"CustomResourceDefinition"},
+			wantSkipped:       nil,
+			wantExcluded:      []string{"CustomResourceDefinition"},
+			wantEvents:        0,
 		},
 	}
-
-	for index, tt := range tests {
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			keys, _, got := retrieveRestoreDetails(tt.args.ctx, tt.args.c,
-				tt.args.s, tt.args.restore, tt.args.restoreOnlyManagedClusters)
-			if (got == nil) != tt.want {
-				t.Errorf("retrieveRestoreDetails() returns = %v, want %v", got == nil, tt.want)
+			recorder := record.NewFakeRecorder(10)
+			r := &RestoreReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(scheme1).WithObjects(backup).Build(),
+				DiscoveryClient: fakeDiscovery,
+				Scheme:          scheme1,
+				Recorder:        recorder,
 			}
-			if keys[0] != Credentials {
-				t.Errorf("retrieveRestoreDetails() error, Credentials should be first key to restore ")
+			restore := createACMRestore("restore", "ns").skipMissingCRDResources(true).object
+			veleroRestore := createRestore("restore-acm-resources", "ns").
+				backupName(tt.backupName).object
+			veleroRestore.Spec.ExcludedResources = tt.excludedResources
+
+			r.skipMissingCRDResources(context.Background(), restore, veleroRestore)
+			if !reflect.DeepEqual(restore.Status.SkippedMissingCRDResources, tt.wantSkipped) {
+				t.Errorf("skipMissingCRDResources() SkippedMissingCRDResources = %v, want %v",
+					restore.Status.SkippedMissingCRDResources, tt.wantSkipped)
 			}
-			if keys[len(keys)-1] != ManagedClusters {
-				t.Errorf("retrieveRestoreDetails() error, ManagedClusters should be last key for restore ")
+			if !reflect.DeepEqual(veleroRestore.Spec.ExcludedResources, tt.wantExcluded) {
+				t.Errorf("skipMissingCRDResources() ExcludedResources = %v, want %v",
+					veleroRestore.Spec.ExcludedResources, tt.wantExcluded)
 			}
-		})
-		if index == len(tests)-1 {
-			// clean up
-			if err := testEnv.Stop(); err != nil {
-				t.Errorf("Error stopping testenv: %s", err.Error())
+			if len(recorder.Events) != tt.wantEvents {
+				t.Errorf("skipMissingCRDResources() events = %v, want %v", len(recorder.Events), tt.wantEvents)
 			}
-		}
+		})
 	}
 }
 
-func Test_isOtherResourcesRunning(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
+func Test_processRetrieveRestoreDetails_clockSkew(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	veleroNamespaceName := "default"
-	restoreName := "restore-backup-name"
-	backupName := "backup-name"
+	ns := "backup-ns"
+	now := time.Now()
+	credsBackup := createBackup("acm-credentials-schedule-20220922170041", ns).
+		startTimestamp(v1.NewTime(now.Add(-time.Hour))).object
+	resourcesBackup := createBackup("acm-resources-schedule-20220922170041", ns).
+		startTimestamp(v1.NewTime(now.Add(-time.Hour))).object
+	futureResourcesBackup := createBackup("acm-resources-schedule-20990922170041", ns).
+		startTimestamp(v1.NewTime(now.Add(time.Hour * 2))).object
 
-	restore := *createACMRestore(restoreName, veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(latestBackupStr).
-		veleroCredentialsBackupName(skipRestoreStr).
-		veleroResourcesBackupName(backupName).object
+	tests := []struct {
+		name          string
+		backups       []client.Object
+		wantCondition v1.ConditionStatus
+	}{
+		{
+			name:          "backups started in the past",
+			backups:       []client.Object{credsBackup, resourcesBackup},
+			wantCondition: v1.ConditionFalse,
+		},
+		{
+			name:          "latest backup started in the future",
+			backups:       []client.Object{credsBackup, resourcesBackup, futureResourcesBackup},
+			wantCondition: v1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(tt.backups...).
+				Build()
 
-	restoreOther := *createACMRestore("other-"+restoreName, veleroNamespaceName).
-		syncRestoreWithNewBackups(true).
-		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(latestBackupStr).
-		veleroCredentialsBackupName(skipRestoreStr).
-		veleroResourcesBackupName(backupName).object
-
-	backupCollision := *createBackupSchedule(backupName+"-collision", veleroNamespaceName).
-		phase(v1beta1.SchedulePhaseBackupCollision).
-		object
-	backupFailed := *createBackupSchedule(backupName+"-failed", veleroNamespaceName).
-		object
+			restore := createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(latestBackupStr).
+				veleroResourcesBackupName(latestBackupStr).object
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
-	scheme1 := runtime.NewScheme()
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+			if _, err := processRetrieveRestoreDetails(context.Background(), fakeClient, scheme1, restore,
+				[]ResourceType{Credentials, Resources}); err != nil {
+				t.Errorf("processRetrieveRestoreDetails() error = %v", err)
+			}
+			condition := meta.FindStatusCondition(restore.Status.Conditions, v1beta1.RestoreClockSkewDetected)
+			if condition == nil || condition.Status != tt.wantCondition {
+				t.Errorf("processRetrieveRestoreDetails() %s condition = %v, want %v",
+					v1beta1.RestoreClockSkewDetected, condition, tt.wantCondition)
+			}
+			if tt.wantCondition == v1.ConditionTrue &&
+				!strings.Contains(condition.Message, futureResourcesBackup.Name) {
+				t.Errorf("processRetrieveRestoreDetails() condition message %s should contain backup %s",
+					condition.Message, futureResourcesBackup.Name)
+			}
+		})
 	}
+}
 
-	type args struct {
-		ctx     context.Context
-		c       client.Client
-		restore *v1beta1.Restore
-	}
+func Test_getVeleroPluginName(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want string
+		name  string
+		image string
+		want  string
 	}{
 		{
-			name: "isOtherResourcesRunning has error, CRD not installed",
-			args: args{
-				ctx:     context.Background(),
-				c:       k8sClient1,
-				restore: &restore,
-			},
-			want: "",
+			name:  "image with registry and tag",
+			image: "quay.io/konveyor/velero-plugin-for-aws:oadp-1.4",
+			want:  "velero-plugin-for-aws",
 		},
 		{
-			name: "isOtherResourcesRunning has no errors, no backups found",
-			args: args{
-				ctx:     context.Background(),
-				c:       k8sClient1,
-				restore: &restore,
-			},
-			want: "",
+			name:  "image with registry port and digest",
+			image: "registry:5000/velero/velero-plugin-for-gcp@sha256:abcd",
+			want:  "velero-plugin-for-gcp",
 		},
 		{
-			name: "isOtherResourcesRunning has no errors, backup found but in collision state",
-			args: args{
-				ctx:     context.Background(),
-				c:       k8sClient1,
-				restore: &restore,
-			},
-			want: "",
+			name:  "plugin name",
+			image: " openshift-velero-plugin ",
+			want:  "openshift-velero-plugin",
 		},
 		{
-			name: "isOtherResourcesRunning has errors, backup found and not in collision state",
-			args: args{
-				ctx:     context.Background(),
-				c:       k8sClient1,
-				restore: &restore,
+			name:  "empty",
+			image: "",
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getVeleroPluginName(tt.image); got != tt.want {
+				t.Errorf("getVeleroPluginName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_processRetrieveRestoreDetails_pluginMismatch(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := appsv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	credsBackup := createBackup("acm-credentials-schedule-20220922170041", ns).
+		annotations(map[string]string{
+			BackupVeleroPluginsAnnotation: "velero-plugin-for-aws,openshift-velero-plugin",
+		}).object
+	resourcesBackup := createBackup("acm-resources-schedule-20220922170041", ns).
+		annotations(map[string]string{
+			BackupVeleroPluginsAnnotation: "velero-plugin-for-aws,velero-plugin-for-gcp",
+		}).object
+	noPluginsCredsBackup := createBackup("acm-credentials-schedule-20220922170041", ns).object
+	noPluginsResourcesBackup := createBackup("acm-resources-schedule-20220922170041", ns).object
+
+	veleroDeployment := func(images ...string) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      veleroDeploymentName,
+				Namespace: ns,
 			},
-			want: "This resource is ignored because BackupSchedule resource backup-name-failed is currently active, " +
-				"before creating another resource verify that any active resources are removed.",
+		}
+		for i, image := range images {
+			deployment.Spec.Template.Spec.InitContainers = append(
+				deployment.Spec.Template.Spec.InitContainers,
+				corev1.Container{Name: fmt.Sprintf("plugin-%d", i), Image: image},
+			)
+		}
+		return deployment
+	}
+
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		wantCondition v1.ConditionStatus
+		wantMissing   []string
+	}{
+		{
+			name: "hub missing one plugin",
+			objects: []client.Object{credsBackup, resourcesBackup, veleroDeployment(
+				"quay.io/konveyor/velero-plugin-for-aws:latest",
+				"quay.io/konveyor/openshift-velero-plugin:latest",
+			)},
+			wantCondition: v1.ConditionTrue,
+			wantMissing:   []string{"velero-plugin-for-gcp"},
 		},
 		{
-			name: "isOtherResourcesRunning has no errors, no another restore is running",
-			args: args{
-				ctx:     context.Background(),
-				c:       k8sClient1,
-				restore: &restore,
-			},
-			want: "",
+			name: "hub has all plugins",
+			objects: []client.Object{credsBackup, resourcesBackup, veleroDeployment(
+				"quay.io/konveyor/velero-plugin-for-aws:latest",
+				"quay.io/konveyor/openshift-velero-plugin:latest",
+				"quay.io/konveyor/velero-plugin-for-gcp:latest",
+			)},
+			wantCondition: v1.ConditionFalse,
 		},
 		{
-			name: "isOtherResourcesRunning has errors, another restore is running",
-			args: args{
-				ctx:     context.Background(),
-				c:       k8sClient1,
-				restore: &restore,
-			},
-			want: "This resource is ignored because Restore resource other-restore-backup-name is currently active, " +
-				"before creating another resource verify that any active resources are removed.",
+			name: "backups don't record plugins",
+			objects: []client.Object{noPluginsCredsBackup, noPluginsResourcesBackup, veleroDeployment(
+				"quay.io/konveyor/velero-plugin-for-aws:latest",
+			)},
+		},
+		{
+			name:    "velero deployment not found",
+			objects: []client.Object{credsBackup, resourcesBackup},
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(tt.objects...).
+				Build()
 
-	for index, tt := range tests {
-
-		if index == 1 {
-			// create CRD
-			if err := v1beta1.AddToScheme(scheme1); err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
-			}
-			if err := k8sClient1.Create(tt.args.ctx, &restore, &client.CreateOptions{}); err != nil {
-				t.Errorf("Error creating restore: %s", err.Error())
-			}
+			restore := createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(latestBackupStr).
+				veleroResourcesBackupName(latestBackupStr).object
 
-		}
-		if index == 2 {
-			// create backup in collision state
-			err := k8sClient1.Create(tt.args.ctx, &backupCollision, &client.CreateOptions{})
-			if err != nil {
-				t.Errorf("Error creating backupschedule: %s", err.Error())
-			}
-			err = k8sClient1.Get(tt.args.ctx, types.NamespacedName{
-				Name:      backupCollision.Name,
-				Namespace: backupCollision.Namespace,
-			}, &backupCollision)
-			if err != nil {
-				t.Errorf("Error getting backupschedule: %s", err.Error())
+			if _, err := processRetrieveRestoreDetails(context.Background(), fakeClient, scheme1, restore,
+				[]ResourceType{Credentials, Resources}); err != nil {
+				t.Errorf("processRetrieveRestoreDetails() error = %v", err)
 			}
-			backupCollision.Status.Phase = v1beta1.SchedulePhaseBackupCollision
-			err = k8sClient1.Status().Update(tt.args.ctx, &backupCollision)
-			if err != nil {
-				t.Errorf("Error updating backupschedule: %s", err.Error())
+			condition := meta.FindStatusCondition(restore.Status.Conditions, v1beta1.RestorePluginMismatch)
+			if tt.wantCondition == "" {
+				if condition != nil {
+					t.Errorf("processRetrieveRestoreDetails() %s condition = %v, want none",
+						v1beta1.RestorePluginMismatch, condition)
+				}
+				return
 			}
-		}
-		if index == 3 {
-			err := k8sClient1.Create(tt.args.ctx, &backupFailed)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
+			if condition == nil || condition.Status != tt.wantCondition {
+				t.Fatalf("processRetrieveRestoreDetails() %s condition = %v, want %v",
+					v1beta1.RestorePluginMismatch, condition, tt.wantCondition)
 			}
-		}
-		if index == len(tests)-2 {
-			err := k8sClient1.Delete(tt.args.ctx, &backupFailed)
-			if err != nil {
-				t.Errorf("Error deleting: %s", err.Error())
+			for _, plugin := range tt.wantMissing {
+				if !strings.Contains(condition.Message, plugin) {
+					t.Errorf("processRetrieveRestoreDetails() condition message %s should contain plugin %s",
+						condition.Message, plugin)
+				}
 			}
-		}
-		if index == len(tests)-1 {
-			err := k8sClient1.Create(tt.args.ctx, &restoreOther)
-			if err != nil {
-				t.Errorf("Error creating: %s", err.Error())
+		})
+	}
+}
+
+func Test_getVeleroBackupName_backupLabelSelector(t *testing.T) {
+	ns := "backup-ns"
+	approvedLabel := map[string]string{"backup-approved": "true"}
+	veleroBackups := &veleroapi.BackupList{
+		Items: []veleroapi.Backup{
+			*createBackup("acm-resources-schedule-20220922160041", ns).
+				labels(approvedLabel).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-3 * time.Hour))).object,
+			*createBackup("acm-resources-schedule-20220922170041", ns).
+				labels(approvedLabel).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+			*createBackup("acm-resources-schedule-20220922180041", ns).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
+			*createBackup("acm-credentials-schedule-20220922180041", ns).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		resourceType ResourceType
+		backupName   string
+		selector     *v1.LabelSelector
+		want         string
+	}{
+		{
+			name:         "no selector, latest backup",
+			resourceType: Resources,
+			backupName:   latestBackupStr,
+			want:         "acm-resources-schedule-20220922180041",
+		},
+		{
+			name:         "selector set, latest approved backup",
+			resourceType: Resources,
+			backupName:   latestBackupStr,
+			selector:     &v1.LabelSelector{MatchLabels: approvedLabel},
+			want:         "acm-resources-schedule-20220922170041",
+		},
+		{
+			name:         "selector set, no approved credentials backup",
+			resourceType: Credentials,
+			backupName:   latestBackupStr,
+			selector:     &v1.LabelSelector{MatchLabels: approvedLabel},
+			want:         "",
+		},
+		{
+			name:         "selector set, backup name is not latest",
+			resourceType: Resources,
+			backupName:   "acm-resources-schedule-20220922180041",
+			selector:     &v1.LabelSelector{MatchLabels: approvedLabel},
+			want:         "acm-resources-schedule-20220922180041",
+		},
+	}
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithLists(veleroBackups).
+		Build()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acmRestore := createACMRestore("restore", ns).backupLabelSelector(tt.selector).object
+			if name, _, _ := getVeleroBackupName(context.Background(), fakeClient, ns,
+				tt.resourceType, tt.backupName, veleroBackups, getBackupLabelSelector(acmRestore),
+				nil); name != tt.want {
+				t.Errorf("getVeleroBackupName() returns = %v, want %v", name, tt.want)
 			}
-		}
+		})
+	}
+}
+
+func Test_getVeleroBackupName_configMaps(t *testing.T) {
+	ns := "backup-ns"
+	resourcesBackups := []veleroapi.Backup{
+		*createBackup("acm-resources-schedule-20220922160041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+		*createBackup("acm-resources-schedule-20220922170041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
+	}
+	oldConfigMapsBackup := *createBackup("acm-configmaps-schedule-20220922160041", ns).
+		phase(veleroapi.BackupPhaseCompleted).
+		startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object
+	latestConfigMapsBackup := *createBackup("acm-configmaps-schedule-20220922170041", ns).
+		phase(veleroapi.BackupPhaseCompleted).
+		startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object
+
+	tests := []struct {
+		name          string
+		configMapsBkp []veleroapi.Backup
+		backupName    string
+		want          string
+	}{
+		{
+			name:       "no configmaps backup",
+			backupName: latestBackupStr,
+			want:       "",
+		},
+		{
+			name:          "latest, configmaps backup created with the latest resources backup",
+			configMapsBkp: []veleroapi.Backup{oldConfigMapsBackup, latestConfigMapsBackup},
+			backupName:    latestBackupStr,
+			want:          "acm-configmaps-schedule-20220922170041",
+		},
+		{
+			name:          "latest, configmaps backup older than the latest resources backup is not restored",
+			configMapsBkp: []veleroapi.Backup{oldConfigMapsBackup},
+			backupName:    latestBackupStr,
+			want:          "",
+		},
+		{
+			name:          "resources backup name set, configmaps backup created at the same time",
+			configMapsBkp: []veleroapi.Backup{oldConfigMapsBackup, latestConfigMapsBackup},
+			backupName:    "acm-resources-schedule-20220922160041",
+			want:          "acm-configmaps-schedule-20220922160041",
+		},
+	}
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
 
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got, _ := isOtherResourcesRunning(tt.args.ctx, tt.args.c,
-				tt.args.restore); got != tt.want {
-				t.Errorf("isOtherResourcesRunning() returns = %v, want %v", got, tt.want)
+			veleroBackups := &veleroapi.BackupList{
+				Items: append(append([]veleroapi.Backup{}, resourcesBackups...), tt.configMapsBkp...),
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithLists(veleroBackups).
+				Build()
+			name, _, err := getVeleroBackupName(context.Background(), fakeClient, ns,
+				ConfigMaps, tt.backupName, veleroBackups, nil, nil)
+			if name != tt.want {
+				t.Errorf("getVeleroBackupName() returns = %v, want %v", name, tt.want)
+			}
+			if (err != nil) != (tt.want == "") {
+				t.Errorf("getVeleroBackupName() error = %v", err)
 			}
 		})
 	}
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
+
+	// the configmaps backup is optional, the restore is not failed if not found
+	if isVeleroBackupRequired(createACMRestore("restore", ns).object, ConfigMaps) {
+		t.Errorf("isVeleroBackupRequired() = true, want false for the configmaps backup")
 	}
 }
 
-func Test_updateLabelsForActiveResources(t *testing.T) {
-	type args struct {
-		acmRestore             *v1beta1.Restore
-		restype                ResourceType
-		veleroRestoresToCreate map[ResourceType]*veleroapi.Restore
+func Test_getVeleroBackupName_backupManifest(t *testing.T) {
+	ns := "backup-ns"
+	veleroBackups := &veleroapi.BackupList{
+		Items: []veleroapi.Backup{
+			*createBackup("acm-resources-schedule-20220922160041", ns).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+			*createBackup("acm-resources-schedule-20220922170041", ns).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
+			*createBackup("acm-credentials-schedule-20220922160041", ns).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+			*createBackup("acm-credentials-cluster-schedule-20220922160041", ns).
+				phase(veleroapi.BackupPhaseCompleted).
+				startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+		},
 	}
 
 	tests := []struct {
-		name        string
-		args        args
-		want        bool
-		wantResName string // the name of the restore after parsing the current state;
-		// could have suffix -active if on the activation step
+		name         string
+		resourceType ResourceType
+		manifest     *v1beta1.BackupManifest
+		want         string
+		wantErr      bool
 	}{
 		{
-			name: "Credentials restore with no ManagedCluster, should return false",
-			args: args{
-				restype: Credentials,
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
-					Credentials: createRestore("credentials-restore", "ns").object,
-				},
+			name:         "no manifest, latest backup",
+			resourceType: Resources,
+			want:         "acm-resources-schedule-20220922170041",
+		},
+		{
+			name:         "backup set in manifest",
+			resourceType: Resources,
+			manifest: &v1beta1.BackupManifest{
+				ResourcesBackupName: "acm-resources-schedule-20220922160041",
 			},
-			want:        false,
-			wantResName: "credentials-restore",
+			want: "acm-resources-schedule-20220922160041",
 		},
 		{
-			name: "Credentials restore with ManagedCluster latest and sync, should return true",
-			args: args{
-				restype: Credentials,
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
-					Credentials:     createRestore("credentials-restore", "ns").object,
-					ManagedClusters: createRestore("clusters-restore", "ns").object,
-				},
+			name:         "backup set in manifest not found, no fallback to latest",
+			resourceType: Resources,
+			manifest: &v1beta1.BackupManifest{
+				ResourcesBackupName: "acm-resources-schedule-20220922150041",
 			},
-			want:        true,
-			wantResName: "credentials-restore-active",
+			want:    "",
+			wantErr: true,
 		},
 		{
-			name: "Credentials restore for ManagedCluster latest with no sync, should return true",
-			args: args{
-				restype: Credentials,
-				acmRestore: createACMRestore("acm-restore", "ns").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(skipRestoreStr).
-					veleroResourcesBackupName(skipRestoreStr).object,
-				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
-					Credentials:     createRestore("credentials-restore", "ns").object,
-					ManagedClusters: createRestore("clusters-restore", "ns").object,
-				},
+			name:         "backup not set in manifest, latest backup",
+			resourceType: Resources,
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName: "acm-credentials-schedule-20220922160041",
 			},
-			want:        true,
-			wantResName: "credentials-restore", // creds was skipped
+			want: "acm-resources-schedule-20220922170041",
 		},
 		{
-			name: "Generic Res restore with ManagedCluster and no sync, should return false no active",
-			args: args{
-				restype: ResourcesGeneric,
-				acmRestore: createACMRestore("acm-restore", "ns").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
-					Resources:        createRestore("resources-restore", "ns").object,
-					ResourcesGeneric: createRestore("generic-restore", "ns").object,
-					ManagedClusters:  createRestore("clusters-restore", "ns").object,
-					Credentials:      createRestore("credentials-restore", "ns").object,
-				},
+			name:         "backup not set in manifest, all backups required",
+			resourceType: Resources,
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName: "acm-credentials-schedule-20220922160041",
+				RequireAllBackups:     true,
 			},
-			want:        false,
-			wantResName: "generic-restore",
+			want:    "",
+			wantErr: true,
 		},
 		{
-			name: "Generic Res restore with ManagedCluster and sync, should return false and active",
-			args: args{
-				restype: ResourcesGeneric,
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
-					Resources:        createRestore("resources-restore", "ns").object,
-					ResourcesGeneric: createRestore("generic-restore", "ns").object,
-					ManagedClusters:  createRestore("clusters-restore", "ns").object,
-					Credentials:      createRestore("credentials-restore", "ns").object,
-				},
+			name:         "cluster credentials backup found using the manifest credentials backup",
+			resourceType: CredentialsCluster,
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName: "acm-credentials-schedule-20220922160041",
+				RequireAllBackups:     true,
 			},
-			want:        false,
-			wantResName: "generic-restore-active",
+			want: "acm-credentials-cluster-schedule-20220922160041",
 		},
 	}
+
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithLists(veleroBackups).
+		Build()
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := updateLabelsForActiveResources(tt.args.acmRestore, tt.args.restype, tt.args.veleroRestoresToCreate)
-			if got != tt.want {
-				t.Errorf("error updating labels for: %s", tt.name)
+			name, _, err := getVeleroBackupName(context.Background(), fakeClient, ns,
+				tt.resourceType, latestBackupStr, veleroBackups, nil, tt.manifest)
+			if name != tt.want {
+				t.Errorf("getVeleroBackupName() returns = %v, want %v", name, tt.want)
 			}
-			if tt.wantResName != tt.args.veleroRestoresToCreate[tt.args.restype].Name {
-				t.Errorf("The restore resource name should be  %v, but got %v",
-					tt.wantResName, tt.args.veleroRestoresToCreate[tt.args.restype].Name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getVeleroBackupName() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_isPVCInitializationStep(t *testing.T) {
-	type args struct {
-		acmRestore        *v1beta1.Restore
-		veleroRestoreList veleroapi.RestoreList
+func Test_processRetrieveRestoreDetails_backupManifest(t *testing.T) {
+	ns := "backup-ns"
+	backups := []client.Object{
+		createBackup("acm-credentials-schedule-20220922160041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+		createBackup("acm-resources-schedule-20220922160041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+		createBackup("acm-resources-generic-schedule-20220922160041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-2 * time.Hour))).object,
+		createBackup("acm-credentials-schedule-20220922170041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
+		createBackup("acm-resources-schedule-20220922170041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
+		createBackup("acm-resources-generic-schedule-20220922170041", ns).
+			phase(veleroapi.BackupPhaseCompleted).
+			startTimestamp(v1.NewTime(time.Now().Add(-1 * time.Hour))).object,
 	}
 
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name        string
+		manifest    *v1beta1.BackupManifest
+		wantBackups map[ResourceType]string
+		wantErr     bool
 	}{
 		{
-			name: "sync restore with ManagedCluster skip, should return false",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{},
+			name: "complete manifest",
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName:      "acm-credentials-schedule-20220922160041",
+				ResourcesBackupName:        "acm-resources-schedule-20220922160041",
+				ResourcesGenericBackupName: "acm-resources-generic-schedule-20220922160041",
+				RequireAllBackups:          true,
+			},
+			wantBackups: map[ResourceType]string{
+				Credentials:      "acm-credentials-schedule-20220922160041",
+				Resources:        "acm-resources-schedule-20220922160041",
+				ResourcesGeneric: "acm-resources-generic-schedule-20220922160041",
 			},
-			want: false,
 		},
 		{
-			name: "no sync and ManagedCluster is skipped, should return false",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(false).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{
-					Items: []veleroapi.Restore{
-						*createRestore("credentials-restore", "ns").
-							scheduleName(veleroScheduleNames[Credentials]).
-							object,
-					},
-				},
+			name: "incomplete manifest, latest backup used for the missing types",
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName: "acm-credentials-schedule-20220922160041",
+			},
+			wantBackups: map[ResourceType]string{
+				Credentials:      "acm-credentials-schedule-20220922160041",
+				Resources:        "acm-resources-schedule-20220922170041",
+				ResourcesGeneric: "acm-resources-generic-schedule-20220922170041",
 			},
-			want: false,
 		},
 		{
-			name: "sync restore with ManagedCluster, and only credentials restore, should return true",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{
-					Items: []veleroapi.Restore{
-						*createRestore("credentials-restore", "ns").
-							scheduleName(veleroScheduleNames[Credentials]).
-							object,
-					},
-				},
+			name: "incomplete manifest, all backups required",
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName: "acm-credentials-schedule-20220922160041",
+				ResourcesBackupName:   "acm-resources-schedule-20220922160041",
+				RequireAllBackups:     true,
 			},
-			want: true,
+			wantErr: true,
 		},
 		{
-			name: "sync restore with ManagedCluster, and credentials plus generic restored, should return true",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{
-					Items: []veleroapi.Restore{
-						*createRestore("credentials-restore", "ns").
-							scheduleName(veleroScheduleNames[Credentials]).
-							object,
-						*createRestore("generic-restore", "ns").
-							scheduleName(veleroScheduleNames[ResourcesGeneric]).
-							object,
-					},
-				},
+			name: "generic resources backup set in manifest not found",
+			manifest: &v1beta1.BackupManifest{
+				CredentialsBackupName:      "acm-credentials-schedule-20220922160041",
+				ResourcesBackupName:        "acm-resources-schedule-20220922160041",
+				ResourcesGenericBackupName: "acm-resources-generic-schedule-20220922150041",
 			},
-			want: true,
+			wantErr: true,
 		},
+	}
+
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(backups...).
+				Build()
+
+			restore := createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(latestBackupStr).
+				veleroResourcesBackupName(latestBackupStr).
+				backupManifest(tt.manifest).object
+
+			veleroRestores, err := processRetrieveRestoreDetails(context.Background(), fakeClient, scheme1,
+				restore, []ResourceType{Credentials, ResourcesGeneric, Resources})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("processRetrieveRestoreDetails() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if restore.Status.LastMessage != err.Error() {
+					t.Errorf("processRetrieveRestoreDetails() LastMessage = %v, want %v",
+						restore.Status.LastMessage, err.Error())
+				}
+				return
+			}
+			for key, backupName := range tt.wantBackups {
+				if veleroRestores[key] == nil || veleroRestores[key].Spec.BackupName != backupName {
+					t.Errorf("processRetrieveRestoreDetails() %s restore = %v, want backup %v",
+						key, veleroRestores[key], backupName)
+				}
+			}
+		})
+	}
+}
+
+func Test_isValidBackupLabelSelector(t *testing.T) {
+	tests := []struct {
+		name      string
+		selector  *v1.LabelSelector
+		wantError bool
+	}{
 		{
-			name: "no sync restore with ManagedCluster, and credentials plus generic restored, should return true",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(false).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{
-					Items: []veleroapi.Restore{
-						*createRestore("credentials-restore", "ns").
-							scheduleName(veleroScheduleNames[Credentials]).
-							object,
-						*createRestore("generic-restore", "ns").
-							scheduleName(veleroScheduleNames[ResourcesGeneric]).
-							object,
-					},
-				},
-			},
-			want: true,
+			name:      "no selector",
+			wantError: false,
 		},
 		{
-			name: "no sync restore with ManagedCluster, and credentials plus generic and managedcls restored, " +
-				"should return false",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(false).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{
-					Items: []veleroapi.Restore{
-						*createRestore("credentials-restore", "ns").
-							scheduleName(veleroScheduleNames[Credentials]).
-							object,
-						*createRestore("generic-restore", "ns").
-							scheduleName(veleroScheduleNames[ResourcesGeneric]).
-							object,
-						*createRestore("clusters-restore", "ns").
-							scheduleName(veleroScheduleNames[ManagedClusters]).
-							object,
-					},
-				},
-			},
-			want: false,
+			name:      "valid selector",
+			selector:  &v1.LabelSelector{MatchLabels: map[string]string{"backup-approved": "true"}},
+			wantError: false,
 		},
 		{
-			name: "sync restore with ManagedCluster, and credentials plus generic and managedcls restored, should return false",
-			args: args{
-				acmRestore: createACMRestore("acm-restore", "ns").
-					syncRestoreWithNewBackups(true).
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroManagedClustersBackupName(latestBackupStr).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).object,
-				veleroRestoreList: veleroapi.RestoreList{
-					Items: []veleroapi.Restore{
-						*createRestore("credentials-restore", "ns").
-							scheduleName(veleroScheduleNames[Credentials]).
-							object,
-						*createRestore("generic-restore", "ns").
-							scheduleName(veleroScheduleNames[ResourcesGeneric]).
-							object,
-						*createRestore("clusters-restore", "ns").
-							scheduleName(veleroScheduleNames[ManagedClusters]).
-							object,
-					},
-				},
-			},
-			want: false,
+			name: "invalid selector",
+			selector: &v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+				{Key: "backup-approved", Operator: "BadOperator"},
+			}},
+			wantError: true,
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := isPVCInitializationStep(tt.args.acmRestore, tt.args.veleroRestoreList)
-			if got != tt.want {
-				t.Errorf("error with isPVCInitializationStep for: %s", tt.name)
+			acmRestore := createACMRestore("restore", "ns").backupLabelSelector(tt.selector).object
+			if got := isValidBackupLabelSelector(acmRestore); (got != "") != tt.wantError {
+				t.Errorf("isValidBackupLabelSelector() = %v, wantError %v", got, tt.wantError)
 			}
 		})
 	}
 }
 
-func Test_processRestoreWait(t *testing.T) {
+func Test_getVeleroBackupName(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -1613,162 +2001,208 @@ func Test_processRestoreWait(t *testing.T) {
 		ErrorIfCRDPathMissing: true,
 	}
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
-	}
+	veleroNamespaceName := "backup-ns"
+	veleroNamespace := *createNamespace(veleroNamespaceName)
 
-	type args struct {
-		ctx              context.Context
-		c                client.Client
-		restoreName      string
-		restoreNamespace string
-		veleroRestore    veleroapi.Restore
-		pvMap            corev1.ConfigMap
-		pvc              corev1.PersistentVolumeClaim
-	}
-	tests := []struct {
-		name string
-		args args
-		want bool
-	}{
-		{
-			name: "no velero restore found for restoreName, wait",
-			args: args{
-				ctx:              context.Background(),
-				c:                k8sClient1,
-				restoreName:      "creds-restore-name",
-				restoreNamespace: "default",
-				veleroRestore:    *createRestore("creds-restore-name-1", "default").object,
-				pvMap:            *createConfigMap("some-other-map-1", "default", nil),
-				pvc:              *createPVC("mongo-storage-1", "default"),
+	backup := *createBackup("acm-credentials-schedule-20220922170041", veleroNamespaceName).
+		labels(map[string]string{
+			BackupVeleroLabel:          "aa",
+			BackupScheduleClusterLabel: "abcd",
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		errors(0).object
+
+	backupClsNoMatch := *createBackup("acm-credentials-cluster-schedule-20220922170039", veleroNamespaceName).
+		labels(map[string]string{
+			BackupVeleroLabel:          "aa",
+			BackupScheduleClusterLabel: "abcd",
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		errors(0).object
+
+	backupClsExactTime := *createBackup("acm-credentials-cluster-schedule-20220922170041", veleroNamespaceName).
+		labels(map[string]string{
+			BackupVeleroLabel:          "aa",
+			BackupScheduleClusterLabel: "abcd",
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		errors(0).object
+
+	backupTime, _ := time.Parse(time.RFC3339, "2022-09-22T17:00:15Z")
+
+	backupClsExactWithin30s := *createBackup("acm-credentials-cluster-schedule-202209221745", veleroNamespaceName).
+		labels(map[string]string{
+			BackupVeleroLabel:          "aa",
+			BackupScheduleClusterLabel: "abcd",
+		}).
+		startTimestamp(metav1.NewTime(backupTime)).
+		phase(veleroapi.BackupPhaseCompleted).
+		errors(0).object
+
+	cfg, _ := testEnv.Start()
+	k8sClient1, _ := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+
+	type args struct {
+		ctx              context.Context
+		c                client.Client
+		restoreNamespace string
+		resourceType     ResourceType
+		backupName       string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "no kind is registered for the type v1.BackupList",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				resourceType:     CredentialsCluster,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
 			},
-			want: true,
+			want: "",
 		},
 		{
-			name: "velero restore found but status is not completed, so wait",
+			name: "no backup items",
 			args: args{
 				ctx:              context.Background(),
 				c:                k8sClient1,
-				restoreName:      "creds-restore-name-2",
-				restoreNamespace: "default",
-				veleroRestore:    *createRestore("creds-restore-name-2", "default").object,
-				pvMap:            *createConfigMap("some-other-map-2", "default", nil),
-				pvc:              *createPVC("mongo-storage-2", "default"),
+				resourceType:     CredentialsCluster,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
 			},
-			want: true,
+			want: "",
 		},
 		{
-			name: "velero restore found and status is completed, no wait bc no config map",
+			name: "found backup item but time is not matching",
 			args: args{
 				ctx:              context.Background(),
 				c:                k8sClient1,
-				restoreName:      "creds-restore-name-3",
-				restoreNamespace: "default",
-				veleroRestore:    *createRestore("creds-restore-name-3", "default").phase("Completed").object,
-				pvMap:            *createConfigMap("some-other-map-3", "default", nil),
-				pvc:              *createPVC("mongo-storage-3", "default"),
+				resourceType:     CredentialsCluster,
+				backupName:       "acm-credentials-schedule-20220822170041",
+				restoreNamespace: veleroNamespaceName,
 			},
-			want: false,
+			want: "",
 		},
 		{
-			name: "velero restore found and status is completed, have config map so wait!",
+			name: "found backup item for credentials",
 			args: args{
 				ctx:              context.Background(),
 				c:                k8sClient1,
-				restoreName:      "creds-restore-name-4",
-				restoreNamespace: "default",
-				veleroRestore:    *createRestore("creds-restore-name-4", "default").phase("Completed").object,
-				pvMap: *createConfigMap("hub-pvc-backup-mongo-storage-4", "default", map[string]string{
-					"cluster.open-cluster-management.io/backup-pvc": "mongo-storage",
-				}),
-				pvc: *createPVC("mongo-storage-4", "default"), // this is NOT the expected PVC, not matching the map label !
+				resourceType:     Credentials,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
 			},
-			want: true,
+			want: backup.Name,
 		},
 		{
-			name: "velero restore found and status is completed, have config map but the PVC exists so NO wait!",
+			name: "NOT found backup item for credentials hive",
 			args: args{
 				ctx:              context.Background(),
 				c:                k8sClient1,
-				restoreName:      "creds-restore-name-5",
-				restoreNamespace: "default",
-				veleroRestore:    *createRestore("creds-restore-name-5", "default").phase("Completed").object,
-				pvMap: *createConfigMap("hub-pvc-backup-mongo-storage-5", "default", map[string]string{
-					"cluster.open-cluster-management.io/backup-pvc": "mongo-storage",
-				}),
-				pvc: *createPVC("mongo-storage", "default"), // this is the expected PVC, matching the map label !
+				resourceType:     CredentialsHive,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
 			},
-			want: false,
+			want: "",
+		},
+		{
+			name: "found backup item for credentials cluster NOT found no exact match on timestamp and not within 30s",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				resourceType:     CredentialsCluster,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
+			},
+			want: "",
+		},
+		{
+			name: "found backup item for credentials cluster when exact match on timestamp",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				resourceType:     CredentialsCluster,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
+			},
+			want: backupClsExactTime.Name,
+		},
+		{
+			name: "found backup item for credentials cluster when NOT exact match on timestamp but withn 30s",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				resourceType:     CredentialsCluster,
+				backupName:       latestBackupStr,
+				restoreNamespace: veleroNamespaceName,
+			},
+			want: backupClsExactWithin30s.Name,
 		},
 	}
 
-	for _, tt := range tests {
+	for index, tt := range tests {
 
-		if err := k8sClient1.Create(context.Background(), &tt.args.veleroRestore); err != nil {
-			t.Errorf("cannot create resource %v", err.Error())
+		if index == 1 {
+			if err := veleroapi.AddToScheme(scheme.Scheme); err != nil {
+				t.Errorf("Error adding api to scheme: %s", err.Error())
+			}
 		}
-
-		if err := k8sClient1.Create(context.Background(), &tt.args.pvMap); err != nil {
-			t.Errorf("cannot create resource %v", err.Error())
+		if index == 2 {
+			err := k8sClient1.Create(context.Background(), &veleroNamespace)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+			err = k8sClient1.Create(context.Background(), &backup)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+			err = k8sClient1.Create(context.Background(), &backupClsNoMatch)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
 		}
-
-		if err := k8sClient1.Create(context.Background(), &tt.args.pvc); err != nil {
-			t.Errorf("cannot create resource %v", err.Error())
+		if index == len(tests)-2 {
+			err := k8sClient1.Create(context.Background(), &backupClsExactTime)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
+		if index == len(tests)-1 {
+			err := k8sClient1.Create(context.Background(), &backupClsExactWithin30s)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+			err = k8sClient1.Delete(context.Background(), &backupClsExactTime)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
 		}
-
 		t.Run(tt.name, func(t *testing.T) {
-			if shouldWait, msg := processRestoreWait(tt.args.ctx, tt.args.c,
-				tt.args.restoreName, tt.args.restoreNamespace); shouldWait != tt.want {
-				t.Errorf("processRestoreWait() returns = %v, want %v, msg=%v", shouldWait, tt.want, msg)
+			veleroBackups := &veleroapi.BackupList{}
+			if index > 0 { // First test intentionally doesn't have velero in client scheme
+				err := tt.args.c.List(tt.args.ctx, veleroBackups, client.InNamespace(veleroNamespace.Name))
+				if err != nil {
+					t.Errorf("Error listing veleroBackups: %s", err.Error())
+				}
+			}
+			if name, _, _ := getVeleroBackupName(tt.args.ctx, tt.args.c,
+				tt.args.restoreNamespace, tt.args.resourceType, tt.args.backupName, veleroBackups,
+				nil, nil); name != tt.want {
+				t.Errorf("getVeleroBackupName() returns = %v, want %v", name, tt.want)
 			}
 		})
 	}
 	// clean up
 	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
-	}
-}
-
-func hasOrActivationLabel(
-	restore veleroapi.Restore,
-) bool {
-	hasActivationOrSelector := false
-	if len(restore.Spec.OrLabelSelectors) > 0 {
-		for i := range restore.Spec.OrLabelSelectors {
-			requirements := restore.Spec.OrLabelSelectors[i].MatchExpressions
-			for idx := range requirements {
-				if requirements[idx].Key == backupCredsClusterLabel {
-					hasActivationOrSelector = true
-					break
-				}
-			}
-		}
-	}
-	return hasActivationOrSelector
-}
-
-func hasActivationLabel(
-	restore veleroapi.Restore,
-) bool {
-	hasActivationSelector := false
-	if restore.Spec.LabelSelector != nil {
-		requirements := restore.Spec.LabelSelector.MatchExpressions
-		for idx := range requirements {
-			if requirements[idx].Key == backupCredsClusterLabel {
-				hasActivationSelector = true
-				break
-			}
-		}
+		t.Errorf("Error stopping testenv: %s", err.Error())
 	}
-	return hasActivationSelector
 }
 
-func Test_actLabelNotOnManagedClsRestore(t *testing.T) {
+func Test_isNewBackupAvailable(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -1776,7 +2210,6 @@ func Test_actLabelNotOnManagedClsRestore(t *testing.T) {
 		},
 		ErrorIfCRDPathMissing: true,
 	}
-
 	cfg, err := testEnv.Start()
 	if err != nil {
 		t.Fatalf("Error starting testEnv: %s", err.Error())
@@ -1786,158 +2219,4416 @@ func Test_actLabelNotOnManagedClsRestore(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Error starting client: %s", err.Error())
 	}
-	err = veleroapi.AddToScheme(scheme1)
-	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
-	}
-	err = v1beta1.AddToScheme(scheme1)
-	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
-	}
 
-	orLabelSelector := []*metav1.LabelSelector{
-		{
-			MatchLabels: map[string]string{
-				"restore-test-1": "restore-test-1-value",
+	skipRestore := "skip"
+	latestBackup := "latest"
+
+	veleroNamespaceName := "backup-ns"
+	veleroNamespace := *createNamespace(veleroNamespaceName)
+
+	passiveStr := "passive"
+	backupName := "acm-credentials-schedule-20220922170041"
+	restoreName := passiveStr + "-" + backupName
+
+	backup := *createBackup(backupName, veleroNamespaceName).
+		labels(map[string]string{
+			BackupVeleroLabel:          "aa",
+			BackupScheduleClusterLabel: "abcd",
+		}).
+		phase(veleroapi.BackupPhaseCompleted).
+		errors(0).object
+
+	veleroRestore := veleroapi.Restore{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "velero/v1",
+			Kind:       "Restore",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreName,
+			Namespace: veleroNamespaceName,
+		},
+		Spec: veleroapi.RestoreSpec{
+			BackupName: backupName,
+		},
+		Status: veleroapi.RestoreStatus{
+			Phase: "Completed",
+		},
+	}
+
+	restoreCreds := *createACMRestore(passiveStr, veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(skipRestore).
+		veleroCredentialsBackupName(latestBackup).
+		veleroResourcesBackupName(latestBackup).object
+
+	restoreCredSameBackup := *createACMRestore(passiveStr, veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(skipRestore).
+		veleroCredentialsBackupName(latestBackup).
+		veleroResourcesBackupName(latestBackup).
+		veleroCredentialsBackupName(veleroRestore.Name).object
+
+	restoreCredNewBackup := *createACMRestore(passiveStr, veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		restoreSyncInterval(metav1.Duration{Duration: time.Minute * 20}).
+		veleroManagedClustersBackupName(skipRestore).
+		veleroCredentialsBackupName(latestBackup).
+		veleroResourcesBackupName(latestBackup).
+		veleroCredentialsRestoreName(veleroRestore.Name + "11").object
+
+	type args struct {
+		ctx          context.Context
+		c            client.Client
+		restore      *v1beta1.Restore
+		resourceType ResourceType
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "no kind is registered for the type v1.BackupList",
+			args: args{
+				ctx:          context.Background(),
+				c:            k8sClient1,
+				restore:      &restoreCreds,
+				resourceType: CredentialsCluster,
+			},
+			want: false,
+		},
+		{
+			name: "no backup items",
+			args: args{
+				ctx:          context.Background(),
+				c:            k8sClient1,
+				resourceType: CredentialsCluster,
+				restore:      &restoreCreds,
+			},
+			want: false,
+		},
+
+		{
+			name: "NOT found restore item ",
+			args: args{
+				ctx:          context.Background(),
+				c:            k8sClient1,
+				resourceType: CredentialsCluster,
+				restore:      &restoreCreds,
+			},
+			want: false,
+		},
+		{
+			name: "found restore item but not the latest backup",
+			args: args{
+				ctx:          context.Background(),
+				c:            k8sClient1,
+				resourceType: Credentials,
+				restore:      &restoreCredSameBackup,
+			},
+			want: false,
+		},
+		{
+			name: "found restore item AND new backup",
+			args: args{
+				ctx:          context.Background(),
+				c:            k8sClient1,
+				resourceType: Credentials,
+				restore:      &restoreCredNewBackup,
+			},
+			want: true,
+		},
+		{
+			name: "found restore item AND new backup, with restore found",
+			args: args{
+				ctx:          context.Background(),
+				c:            k8sClient1,
+				resourceType: Credentials,
+				restore:      &restoreCredNewBackup,
+			},
+			want: false,
+		},
+	}
+
+	for index, tt := range tests {
+
+		if index == 1 {
+			err := corev1.AddToScheme(scheme1)
+			if err != nil {
+				t.Errorf("Error adding api to scheme: %s", err.Error())
+			}
+			err = v1beta1.AddToScheme(scheme1)
+			if err != nil {
+				t.Errorf("Error adding api to scheme: %s", err.Error())
+			}
+			err = veleroapi.AddToScheme(scheme1)
+			if err != nil {
+				t.Errorf("Error adding api to scheme: %s", err.Error())
+			}
+		}
+		if index == 2 {
+			err := k8sClient1.Create(tt.args.ctx, &veleroNamespace)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+			err = k8sClient1.Create(tt.args.ctx, &backup)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+			err = k8sClient1.Create(context.Background(), &veleroRestore)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
+		if index == len(tests)-1 {
+			// create restore
+			err := k8sClient1.Create(tt.args.ctx, &restoreCredNewBackup)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNewBackupAvailable(tt.args.ctx, tt.args.c,
+				tt.args.restore, tt.args.resourceType); got != tt.want {
+				t.Errorf("isNewBackupAvailable() returns = %v, want %v, %v", got, tt.want, tt.args.resourceType)
+			}
+		})
+	}
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_isBackupScheduleRunning(t *testing.T) {
+	type args struct {
+		backupSchedules []v1beta1.BackupSchedule
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "backup list is empty",
+			args: args{
+				backupSchedules: []v1beta1.BackupSchedule{},
+			},
+			want: "",
+		},
+		{
+			name: "backup without backupcollision running",
+			args: args{
+				backupSchedules: []v1beta1.BackupSchedule{
+					{
+						TypeMeta: metav1.TypeMeta{
+							APIVersion: "cluster.open-cluster-management.io/v1beta1",
+							Kind:       "BackupSchedule",
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "backup-name",
+							Namespace: "ns",
+						},
+						Status: v1beta1.BackupScheduleStatus{
+							Phase: v1beta1.SchedulePhaseEnabled,
+						},
+					},
+				},
+			},
+			want: "backup-name",
+		},
+		{
+			name: "backup WITH backupcollision",
+			args: args{
+				backupSchedules: []v1beta1.BackupSchedule{
+					*createBackupSchedule("backup-name", "ns").
+						phase(v1beta1.SchedulePhaseBackupCollision).
+						object,
+				},
+			},
+			want: "",
+		},
+		{
+			name: "backup WITH paused schedule",
+			args: args{
+				backupSchedules: []v1beta1.BackupSchedule{
+					*createBackupSchedule("backup-name", "ns").
+						phase(v1beta1.SchedulePhasePaused).
+						object,
+				},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBackupScheduleRunning(tt.args.backupSchedules); got != tt.want {
+				t.Errorf("isBackupScheduleRunning() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkActiveBackupSchedule(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	tests := []struct {
+		name            string
+		restore         *v1beta1.Restore
+		backupSchedules []client.Object
+		wantMsg         string
+		wantReason      string
+		wantPaused      bool
+	}{
+		{
+			name:    "no BackupSchedule",
+			restore: createACMRestore("restore", "ns").object,
+		},
+		{
+			name:    "paused BackupSchedule",
+			restore: createACMRestore("restore", "ns").object,
+			backupSchedules: []client.Object{
+				createBackupSchedule("schedule", "ns").paused(true).
+					phase(v1beta1.SchedulePhasePaused).object,
+			},
+			wantPaused: true,
+		},
+		{
+			name:    "active BackupSchedule in another namespace",
+			restore: createACMRestore("restore", "ns").object,
+			backupSchedules: []client.Object{
+				createBackupSchedule("schedule", "other-ns").
+					phase(v1beta1.SchedulePhaseEnabled).object,
+			},
+		},
+		{
+			name:    "active BackupSchedule is reported",
+			restore: createACMRestore("restore", "ns").object,
+			backupSchedules: []client.Object{
+				createBackupSchedule("schedule", "ns").
+					phase(v1beta1.SchedulePhaseEnabled).object,
+			},
+			wantReason: v1beta1.RestoreReasonBackupScheduleActive,
+		},
+		{
+			name:    "active BackupSchedule is paused",
+			restore: createACMRestore("restore", "ns").pauseActiveBackupSchedule(true).object,
+			backupSchedules: []client.Object{
+				createBackupSchedule("schedule", "ns").
+					phase(v1beta1.SchedulePhaseEnabled).object,
+			},
+			wantMsg:    "Waiting for BackupSchedule schedule to be paused",
+			wantReason: v1beta1.RestoreReasonBackupSchedulePaused,
+			wantPaused: true,
+		},
+		{
+			name:    "active BackupSchedule is paused by a point-in-time recovery",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).object,
+			backupSchedules: []client.Object{
+				createBackupSchedule("schedule", "ns").
+					phase(v1beta1.SchedulePhaseEnabled).object,
+			},
+			wantMsg:    "Waiting for BackupSchedule schedule to be paused",
+			wantReason: v1beta1.RestoreReasonBackupSchedulePaused,
+			wantPaused: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).
+				WithObjects(tt.backupSchedules...).Build()
+
+			msg, err := checkActiveBackupSchedule(context.Background(), fakeClient, tt.restore)
+			if err != nil {
+				t.Fatalf("checkActiveBackupSchedule() error = %v", err)
+			}
+			if msg != tt.wantMsg {
+				t.Errorf("checkActiveBackupSchedule() = %v, want %v", msg, tt.wantMsg)
+			}
+
+			cond := meta.FindStatusCondition(tt.restore.Status.Conditions,
+				v1beta1.RestoreActiveBackupScheduleDuringRestore)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("unexpected condition %v", cond)
+				}
+			} else if cond == nil || cond.Reason != tt.wantReason || cond.Status != metav1.ConditionTrue {
+				t.Errorf("condition = %v, want reason %s", cond, tt.wantReason)
+			}
+
+			for _, obj := range tt.backupSchedules {
+				if obj.GetNamespace() != tt.restore.Namespace {
+					continue
+				}
+				backupSchedule := &v1beta1.BackupSchedule{}
+				if err := fakeClient.Get(context.Background(),
+					types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()},
+					backupSchedule); err != nil {
+					t.Fatalf("could not get BackupSchedule %s: %v", obj.GetName(), err)
+				}
+				if backupSchedule.Spec.Paused != tt.wantPaused {
+					t.Errorf("BackupSchedule paused = %v, want %v", backupSchedule.Spec.Paused, tt.wantPaused)
+				}
+			}
+		})
+	}
+}
+
+func Test_isOtherRestoresRunning(t *testing.T) {
+	type args struct {
+		restores    []v1beta1.Restore
+		restoreName string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "restore list is empty",
+			args: args{
+				restores: []v1beta1.Restore{},
+			},
+			want: "",
+		},
+		{
+			name: "restore list has one running item ",
+			args: args{
+				restoreName: "some-name",
+				restores: []v1beta1.Restore{
+					*createACMRestore("some-name", "ns").object,
+					*createACMRestore("some-other-name", "ns").
+						phase(v1beta1.RestorePhaseEnabled).object,
+				},
+			},
+			want: "some-other-name",
+		},
+		{
+			name: "restore list has one completed item ",
+			args: args{
+				restoreName: "some-name",
+				restores: []v1beta1.Restore{
+					*createACMRestore("some-name", "ns").object,
+					*createACMRestore("some-other-name", "ns").
+						phase(v1beta1.RestorePhaseFinishedWithErrors).object,
+				},
+			},
+			want: "",
+		},
+		{
+			name: "restore list has items waiting for this restore",
+			args: args{
+				restoreName: "infra-restore",
+				restores: []v1beta1.Restore{
+					*createACMRestore("infra-restore", "ns").object,
+					*createACMRestore("app-restore", "ns").
+						dependsOn([]string{"infra-restore"}).object,
+					*createACMRestore("other-app-restore", "ns").
+						dependsOn([]string{"app-restore"}).
+						phase(v1beta1.RestorePhaseWaiting).object,
+				},
+			},
+			want: "",
+		},
+		{
+			name: "restore list has one started item with dependencies",
+			args: args{
+				restoreName: "other-app-restore",
+				restores: []v1beta1.Restore{
+					*createACMRestore("app-restore", "ns").
+						dependsOn([]string{"infra-restore"}).
+						phase(v1beta1.RestorePhaseRunning).object,
+					*createACMRestore("other-app-restore", "ns").
+						dependsOn([]string{"app-restore"}).
+						phase(v1beta1.RestorePhaseWaiting).object,
+				},
+			},
+			want: "app-restore",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isOtherRestoresRunning(tt.args.restores, tt.args.restoreName); got != tt.want {
+				t.Errorf("isOtherRestoresRunning() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isValidClusterSetMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    string
+	}{
+		{
+			name:    "cluster set mapping not used",
+			restore: createACMRestore("restore", "ns").object,
+			want:    "",
+		},
+		{
+			name: "valid cluster set mapping",
+			restore: createACMRestore("restore", "ns").
+				clusterSetMapping(map[string]string{"set-a": "migrated", "set-b": "b"}).object,
+			want: "",
+		},
+		{
+			name: "used with the sandbox prefix",
+			restore: createACMRestore("restore", "ns").
+				sandboxNamespacePrefix("sandbox").
+				clusterSetMapping(map[string]string{"set-a": "migrated"}).object,
+			want: "ClusterSetMapping cannot be used together with the SandboxNamespacePrefix option",
+		},
+		{
+			name: "empty cluster set name",
+			restore: createACMRestore("restore", "ns").
+				clusterSetMapping(map[string]string{"": "migrated"}).object,
+			want: "invalid ClusterSetMapping : the ManagedClusterSet name cannot be empty",
+		},
+		{
+			name: "prefix too long",
+			restore: createACMRestore("restore", "ns").
+				clusterSetMapping(map[string]string{"set-a": strings.Repeat("a", 41)}).object,
+			want: "invalid ClusterSetMapping prefix for set-a : must be no more than 40 characters",
+		},
+		{
+			name: "invalid prefix",
+			restore: createACMRestore("restore", "ns").
+				clusterSetMapping(map[string]string{"set-a": "Migrated"}).object,
+			want: "invalid ClusterSetMapping prefix for set-a : a lowercase RFC 1123 label must consist of",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isValidClusterSetMapping(tt.restore)
+			if (tt.want == "") != (got == "") || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("isValidClusterSetMapping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_processRetrieveRestoreDetails_clusterSetMapping(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	objects := []client.Object{
+		createBackup("acm-resources-schedule-20220922170041", ns).object,
+		createManagedCluster("cluster-a1", false).clusterSet("set-a").object,
+		createManagedCluster("cluster-a2", false).clusterSet("set-a").object,
+		createManagedCluster("cluster-b1", false).clusterSet("set-b").object,
+		createManagedCluster("cluster-c1", false).clusterSet("set-c").object,
+		createManagedCluster("cluster-none", false).object,
+	}
+
+	tests := []struct {
+		name             string
+		restore          *v1beta1.Restore
+		wantNamespaceMap map[string]string
+	}{
+		{
+			name: "no cluster set mapping",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(latestBackupStr).object,
+			wantNamespaceMap: nil,
+		},
+		{
+			name: "cluster namespaces mapped by cluster set",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(latestBackupStr).
+				clusterSetMapping(map[string]string{"set-a": "migrated-a", "set-b": "migrated-b"}).object,
+			wantNamespaceMap: map[string]string{
+				"cluster-a1": "migrated-a-cluster-a1",
+				"cluster-a2": "migrated-a-cluster-a2",
+				"cluster-b1": "migrated-b-cluster-b1",
+			},
+		},
+		{
+			name: "namespace mapping takes precedence",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(latestBackupStr).
+				namespaceMapping(map[string]string{"cluster-a1": "custom-ns", "app": "app-new"}).
+				clusterSetMapping(map[string]string{"set-a": "migrated-a"}).object,
+			wantNamespaceMap: map[string]string{
+				"cluster-a1": "custom-ns",
+				"cluster-a2": "migrated-a-cluster-a2",
+				"app":        "app-new",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				Build()
+
+			veleroRestores, err := processRetrieveRestoreDetails(context.Background(), fakeClient, scheme1,
+				tt.restore, []ResourceType{Resources})
+			if err != nil {
+				t.Fatalf("processRetrieveRestoreDetails() error = %v", err)
+			}
+			if veleroRestores[Resources] == nil {
+				t.Fatalf("processRetrieveRestoreDetails() no restore created for %s", Resources)
+			}
+			if got := veleroRestores[Resources].Spec.NamespaceMapping; !reflect.DeepEqual(got, tt.wantNamespaceMap) {
+				t.Errorf("processRetrieveRestoreDetails() NamespaceMapping = %v, want %v", got, tt.wantNamespaceMap)
+			}
+		})
+	}
+}
+
+func Test_processRetrieveRestoreDetails_restoreOwnerLabel(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(createBackup("acm-resources-schedule-20220922170041", ns).object).
+		Build()
+
+	tests := []struct {
+		name        string
+		restoreName string
+		wantLabel   string
+	}{
+		{
+			name:        "restore name set on the velero restore",
+			restoreName: "restore",
+			wantLabel:   "restore",
+		},
+		{
+			name:        "restore name too long for a label value",
+			restoreName: strings.Repeat("r", 64),
+			wantLabel:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore(tt.restoreName, ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(latestBackupStr).object
+
+			veleroRestores, err := processRetrieveRestoreDetails(context.Background(), fakeClient, scheme1,
+				restore, []ResourceType{Resources})
+			if err != nil {
+				t.Fatalf("processRetrieveRestoreDetails() error = %v", err)
+			}
+			if veleroRestores[Resources] == nil {
+				t.Fatalf("processRetrieveRestoreDetails() no restore created for %s", Resources)
+			}
+			if got := veleroRestores[Resources].GetLabels()[RestoreOwnerLabel]; got != tt.wantLabel {
+				t.Errorf("processRetrieveRestoreDetails() %s label = %v, want %v", RestoreOwnerLabel, got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func Test_processRetrieveRestoreDetails_sinceBackupName(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	olderBackup := "acm-resources-schedule-20220922170041"
+	newerBackup := "acm-resources-schedule-20220922180041"
+	objects := []client.Object{
+		createBackup(olderBackup, ns).
+			startTimestamp(v1.NewTime(time.Date(2022, 9, 22, 17, 0, 41, 0, time.UTC))).object,
+		createBackup(newerBackup, ns).
+			startTimestamp(v1.NewTime(time.Date(2022, 9, 22, 18, 0, 41, 0, time.UTC))).object,
+	}
+
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		wantErr string
+	}{
+		{
+			name: "no since backup",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(newerBackup).object,
+		},
+		{
+			name: "since backup older than the restored backup",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(newerBackup).
+				sinceBackupName(olderBackup).object,
+		},
+		{
+			name: "since backup is the restored backup",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(olderBackup).
+				sinceBackupName(olderBackup).object,
+			wantErr: "SinceBackupName backup " + olderBackup + " is not older than the restored backup " + olderBackup,
+		},
+		{
+			name: "since backup newer than the restored backup",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(olderBackup).
+				sinceBackupName(newerBackup).object,
+			wantErr: "SinceBackupName backup " + newerBackup + " is not older than the restored backup " + olderBackup,
+		},
+		{
+			name: "since backup not found",
+			restore: createACMRestore("restore", ns).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(newerBackup).
+				sinceBackupName("acm-resources-schedule-20220922160041").object,
+			wantErr: "SinceBackupName backup acm-resources-schedule-20220922160041 not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				Build()
+
+			veleroRestores, err := processRetrieveRestoreDetails(context.Background(), fakeClient, scheme1,
+				tt.restore, []ResourceType{Resources})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("processRetrieveRestoreDetails() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.restore.Status.LastMessage != tt.wantErr {
+					t.Errorf("processRetrieveRestoreDetails() LastMessage = %v, want %v",
+						tt.restore.Status.LastMessage, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("processRetrieveRestoreDetails() error = %v", err)
+			}
+			if veleroRestores[Resources] == nil {
+				t.Fatalf("processRetrieveRestoreDetails() no restore created for %s", Resources)
+			}
+			// the delta is computed from the backups content when the velero restores are created
+			if got := veleroRestores[Resources].Spec.IncludedResources; got != nil {
+				t.Errorf("processRetrieveRestoreDetails() IncludedResources = %v, want nil", got)
+			}
+		})
+	}
+}
+
+// returns a server serving a gzip compressed tar archive with the given files, as velero
+// serves the backup content
+func newBackupContentServer(t *testing.T, files map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gzipWriter := gzip.NewWriter(w)
+		defer gzipWriter.Close()
+		tarWriter := tar.NewWriter(gzipWriter)
+		defer tarWriter.Close()
+		for name, content := range files {
+			if err := tarWriter.WriteHeader(&tar.Header{
+				Name:     name,
+				Mode:     0o600,
+				Size:     int64(len(content)),
+				Typeflag: tar.TypeReg,
+			}); err != nil {
+				t.Errorf("Error writing backup content: %s", err.Error())
+				return
+			}
+			if _, err := tarWriter.Write([]byte(content)); err != nil {
+				t.Errorf("Error writing backup content: %s", err.Error())
+				return
+			}
+		}
+	}))
+}
+
+// returns the download request for the content of the backup, processed by velero
+func createBackupContentDownloadRequest(backupName, ns, url string) *veleroapi.DownloadRequest {
+	return &veleroapi.DownloadRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      backupName + "-contents",
+			Namespace: ns,
+		},
+		Spec: veleroapi.DownloadRequestSpec{
+			Target: veleroapi.DownloadTarget{
+				Kind: veleroapi.DownloadTargetKindBackupContents,
+				Name: backupName,
+			},
+		},
+		Status: veleroapi.DownloadRequestStatus{
+			Phase:       veleroapi.DownloadRequestPhaseProcessed,
+			DownloadURL: url,
+		},
+	}
+}
+
+func Test_getIncrementalResources(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	olderBackup := "acm-resources-schedule-20220922170041"
+	newerBackup := "acm-resources-schedule-20220922180041"
+	// both backups are created by the same schedule, with the same included resources
+	olderServer := newBackupContentServer(t, map[string]string{
+		"metadata/version": "1",
+		"resources/placements.cluster.open-cluster-management.io/namespaces/app/placement.json":   `{"v":"1"}`,
+		"resources/channels.apps.open-cluster-management.io/namespaces/app/channel.json":          `{"v":"1"}`,
+		"resources/policies.policy.open-cluster-management.io/namespaces/policies/policy-a.json":  `{"v":"1"}`,
+		"resources/clustersets.cluster.open-cluster-management.io/cluster/set-a.json":             `{"v":"1"}`,
+		"resources/applications.app.k8s.io/v1beta1-preferredversion/namespaces/app/app.json":      `{"v":"1"}`,
+		"resources/applications.app.k8s.io/namespaces/app/app.json":                               `{"v":"1"}`,
+		"resources/subscriptions.apps.open-cluster-management.io/namespaces/app/removed-sub.json": `{"v":"1"}`,
+	})
+	defer olderServer.Close()
+	newerServer := newBackupContentServer(t, map[string]string{
+		"metadata/version": "1",
+		// changed
+		"resources/placements.cluster.open-cluster-management.io/namespaces/app/placement.json": `{"v":"2"}`,
+		// not changed
+		"resources/channels.apps.open-cluster-management.io/namespaces/app/channel.json": `{"v":"1"}`,
+		// added to an existing resource
+		"resources/policies.policy.open-cluster-management.io/namespaces/policies/policy-a.json": `{"v":"1"}`,
+		"resources/policies.policy.open-cluster-management.io/namespaces/policies/policy-b.json": `{"v":"1"}`,
+		// not changed, cluster scoped
+		"resources/clustersets.cluster.open-cluster-management.io/cluster/set-a.json": `{"v":"1"}`,
+		// not changed, stored under the preferred version too
+		"resources/applications.app.k8s.io/v1beta1-preferredversion/namespaces/app/app.json": `{"v":"1"}`,
+		"resources/applications.app.k8s.io/namespaces/app/app.json":                          `{"v":"1"}`,
+		// new resource
+		"resources/configmaps/namespaces/app/config.json": `{"v":"1"}`,
+	})
+	defer newerServer.Close()
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme1).Build()
+
+	// the download requests for both backups are created, wait for velero to process them
+	got, ready, err := getIncrementalResources(context.Background(), fakeClient, ns, newerBackup, olderBackup)
+	if err != nil || ready || got != nil {
+		t.Fatalf("getIncrementalResources() = %v, %v, %v, want nil, false, nil", got, ready, err)
+	}
+	for _, backupName := range []string{olderBackup, newerBackup} {
+		downloadRequest := &veleroapi.DownloadRequest{}
+		if err := fakeClient.Get(context.Background(), types.NamespacedName{
+			Name: backupName + "-contents", Namespace: ns}, downloadRequest); err != nil {
+			t.Fatalf("download request not created for %s: %s", backupName, err.Error())
+		}
+		if downloadRequest.Spec.Target.Kind != veleroapi.DownloadTargetKindBackupContents ||
+			downloadRequest.Spec.Target.Name != backupName {
+			t.Errorf("getIncrementalResources() download request target = %v", downloadRequest.Spec.Target)
+		}
+
+		// velero processed the download request
+		downloadRequest.Status.Phase = veleroapi.DownloadRequestPhaseProcessed
+		downloadRequest.Status.DownloadURL = olderServer.URL
+		if backupName == newerBackup {
+			downloadRequest.Status.DownloadURL = newerServer.URL
+		}
+		if err := fakeClient.Update(context.Background(), downloadRequest); err != nil {
+			t.Fatalf("Error updating download request: %s", err.Error())
+		}
+	}
+
+	got, ready, err = getIncrementalResources(context.Background(), fakeClient, ns, newerBackup, olderBackup)
+	if err != nil || !ready {
+		t.Fatalf("getIncrementalResources() ready = %v, error = %v, want true, nil", ready, err)
+	}
+	want := []string{
+		"configmaps",
+		"placements.cluster.open-cluster-management.io",
+		"policies.policy.open-cluster-management.io",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getIncrementalResources() = %v, want %v", got, want)
+	}
+	downloadRequests := veleroapi.DownloadRequestList{}
+	if err := fakeClient.List(context.Background(), &downloadRequests); err != nil {
+		t.Fatalf("Error listing download requests: %s", err.Error())
+	}
+	if len(downloadRequests.Items) != 0 {
+		t.Errorf("getIncrementalResources() download requests not deleted after the content was read")
+	}
+}
+
+func Test_RestoreReconciler_sinceBackupName(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
+		}
+	}
+
+	ns := "velero-ns"
+	olderBackup := "acm-resources-schedule-20220922170041"
+	newerBackup := "acm-resources-schedule-20220922180041"
+	includedResources := []string{
+		"placement.cluster.open-cluster-management.io",
+		"policy.policy.open-cluster-management.io",
+	}
+	olderContent := map[string]string{
+		"resources/placements.cluster.open-cluster-management.io/namespaces/app/placement.json": `{"v":"1"}`,
+		"resources/policies.policy.open-cluster-management.io/namespaces/app/policy.json":       `{"v":"1"}`,
+	}
+	olderServer := newBackupContentServer(t, olderContent)
+	defer olderServer.Close()
+	unchangedServer := newBackupContentServer(t, olderContent)
+	defer unchangedServer.Close()
+	changedServer := newBackupContentServer(t, map[string]string{
+		"resources/placements.cluster.open-cluster-management.io/namespaces/app/placement.json": `{"v":"1"}`,
+		"resources/policies.policy.open-cluster-management.io/namespaces/app/policy.json":       `{"v":"2"}`,
+	})
+	defer changedServer.Close()
+
+	tests := []struct {
+		name                  string
+		downloadRequests      []client.Object
+		wantPhase             v1beta1.RestorePhase
+		wantMessage           string
+		wantIncludedResources []string
+	}{
+		{
+			name:        "wait for velero to read the backups content",
+			wantPhase:   v1beta1.RestorePhaseStarted,
+			wantMessage: fmt.Sprintf(sinceBackupWaitMsg, newerBackup, olderBackup),
+		},
+		{
+			name: "changed resources restored",
+			downloadRequests: []client.Object{
+				createBackupContentDownloadRequest(olderBackup, ns, olderServer.URL),
+				createBackupContentDownloadRequest(newerBackup, ns, changedServer.URL),
+			},
+			wantPhase:             v1beta1.RestorePhaseStarted,
+			wantIncludedResources: []string{"policies.policy.open-cluster-management.io"},
+		},
+		{
+			name: "nothing changed since the older backup",
+			downloadRequests: []client.Object{
+				createBackupContentDownloadRequest(olderBackup, ns, olderServer.URL),
+				createBackupContentDownloadRequest(newerBackup, ns, unchangedServer.URL),
+			},
+			wantPhase:   v1beta1.RestorePhaseFinished,
+			wantMessage: fmt.Sprintf(noopMsg, "restore"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append([]client.Object{
+				createACMRestore("restore", ns).
+					cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(skipRestoreStr).
+					veleroResourcesBackupName(newerBackup).
+					sinceBackupName(olderBackup).object,
+				createStorageLocation("default", ns).setOwner().
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+				// both backups are created by the same schedule, with the same included resources
+				createBackup(olderBackup, ns).
+					startTimestamp(v1.NewTime(time.Date(2022, 9, 22, 17, 0, 41, 0, time.UTC))).
+					includedResources(includedResources).object,
+				createBackup(newerBackup, ns).
+					startTimestamp(v1.NewTime(time.Date(2022, 9, 22, 18, 0, 41, 0, time.UTC))).
+					includedResources(includedResources).object,
+			}, tt.downloadRequests...)
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+			r := &RestoreReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme1,
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, _ = r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			})
+
+			got := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, got); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if got.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %v",
+					got.Status.Phase, tt.wantPhase, got.Status.LastMessage)
+			}
+			if tt.wantMessage != "" && got.Status.LastMessage != tt.wantMessage {
+				t.Errorf("Reconcile() message = %v, want %v", got.Status.LastMessage, tt.wantMessage)
+			}
+			// the phase change is recorded as a phase transition
+			if n := len(got.Status.PhaseTransitions); n == 0 ||
+				got.Status.PhaseTransitions[n-1].Phase != tt.wantPhase ||
+				got.Status.PhaseTransitions[n-1].Message != got.Status.LastMessage {
+				t.Errorf("Reconcile() phase transitions = %v, want the last one for phase %v",
+					got.Status.PhaseTransitions, tt.wantPhase)
+			}
+
+			veleroRestores := veleroapi.RestoreList{}
+			if err := fakeClient.List(context.Background(), &veleroRestores, client.InNamespace(ns)); err != nil {
+				t.Fatalf("Error listing velero restores: %s", err.Error())
+			}
+			if tt.wantIncludedResources == nil {
+				if len(veleroRestores.Items) != 0 {
+					t.Errorf("Reconcile() velero restores created = %d, want 0", len(veleroRestores.Items))
+				}
+				return
+			}
+			if len(veleroRestores.Items) != 1 {
+				t.Fatalf("Reconcile() velero restores created = %d, want 1", len(veleroRestores.Items))
+			}
+			if got := veleroRestores.Items[0].Spec.IncludedResources; !reflect.DeepEqual(got,
+				tt.wantIncludedResources) {
+				t.Errorf("Reconcile() IncludedResources = %v, want %v", got, tt.wantIncludedResources)
+			}
+		})
+	}
+}
+
+func Test_isValidSinceBackupName(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    string
+	}{
+		{
+			name:    "since backup not used",
+			restore: createACMRestore("restore", "ns").includedResources([]string{"a"}).object,
+			want:    "",
+		},
+		{
+			name:    "since backup set",
+			restore: createACMRestore("restore", "ns").sinceBackupName("backup").object,
+			want:    "",
+		},
+		{
+			name: "used with included resources",
+			restore: createACMRestore("restore", "ns").
+				sinceBackupName("backup").
+				includedResources([]string{"a"}).object,
+			want: "SinceBackupName cannot be used together with the IncludedResources option",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidSinceBackupName(tt.restore); got != tt.want {
+				t.Errorf("isValidSinceBackupName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isValidActivationOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		secretName  string
+		secretLabel string
+		want        string
+	}{
+		{
+			name: "defaults",
+			want: "",
+		},
+		{
+			name:        "valid names",
+			secretName:  "custom-import-secret",
+			secretLabel: "example.com/restore-import",
+			want:        "",
+		},
+		{
+			name:       "invalid secret name",
+			secretName: "Custom_Secret",
+			want: "invalid AutoImportSecretName Custom_Secret : a lowercase RFC 1123 subdomain must consist of " +
+				"lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric " +
+				"character (e.g. 'example.com', regex used for validation is " +
+				"'[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
+		},
+		{
+			name:        "invalid label",
+			secretLabel: "restore import",
+			want: "invalid ActivationLabel restore import : name part must consist of alphanumeric characters, " +
+				"'-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or " +
+				"'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", "ns").object
+			restore.Spec.AutoImportSecretName = tt.secretName
+			restore.Spec.ActivationLabel = tt.secretLabel
+			if got := isValidActivationOptions(restore); got != tt.want {
+				t.Errorf("isValidActivationOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isValidManagedClusterNamespaceLabels(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   string
+	}{
+		{
+			name:   "no labels",
+			labels: nil,
+			want:   "",
+		},
+		{
+			name:   "valid labels",
+			labels: map[string]string{"example.com/restored": "true", "restored-by": ""},
+			want:   "",
+		},
+		{
+			name:   "invalid key",
+			labels: map[string]string{"restored by": "acm"},
+			want: "invalid ManagedClusterNamespaceLabels key restored by : name part must consist of " +
+				"alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character " +
+				"(e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is " +
+				"'([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')",
+		},
+		{
+			name:   "invalid value",
+			labels: map[string]string{"restored-by": "acm restore"},
+			want: "invalid ManagedClusterNamespaceLabels value for restored-by : a valid label must be " +
+				"an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end " +
+				"with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for " +
+				"validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", "ns").object
+			restore.Spec.ManagedClusterNamespaceLabels = tt.labels
+			if got := isValidManagedClusterNamespaceLabels(restore); got != tt.want {
+				t.Errorf("isValidManagedClusterNamespaceLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isValidPointInTimeRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    string
+	}{
+		{
+			name: "point-in-time recovery not used",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+				veleroResourcesBackupName(skipRestoreStr).object,
+			want: "",
+		},
+		{
+			name: "valid point-in-time recovery",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeAll).
+				veleroResourcesBackupName(latestBackupStr).object,
+			want: "",
+		},
+		{
+			name: "used with CleanupRestored",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+				veleroResourcesBackupName(latestBackupStr).object,
+			want: "PointInTimeRecovery can be used only with the CleanupBeforeRestore option set to None or CleanupAll",
+		},
+		{
+			name: "used with sync",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				syncRestoreWithNewBackups(true).
+				veleroResourcesBackupName(latestBackupStr).object,
+			want: "PointInTimeRecovery cannot be used together with the SyncRestoreWithNewBackups option",
+		},
+		{
+			name: "used with sandbox prefix",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				sandboxNamespacePrefix("sandbox").
+				veleroResourcesBackupName(latestBackupStr).object,
+			want: "PointInTimeRecovery cannot be used together with the SandboxNamespacePrefix option",
+		},
+		{
+			name: "resources backup skipped",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroResourcesBackupName(skipRestoreStr).object,
+			want: "PointInTimeRecovery requires the VeleroResourcesBackupName option, set to a backup name or latest",
+		},
+		{
+			name: "resources backup not set",
+			restore: createACMRestore("restore", "ns").pointInTimeRecovery(true).
+				cleanupBeforeRestore(v1beta1.CleanupTypeAll).object,
+			want: "PointInTimeRecovery requires the VeleroResourcesBackupName option, set to a backup name or latest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidPointInTimeRecovery(tt.restore); got != tt.want {
+				t.Errorf("isValidPointInTimeRecovery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getIncompleteRecoveryBackups(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	newVeleroRestore := func(backupName string) *veleroapi.Restore {
+		return createRestore("restore-"+backupName, "ns").backupName(backupName).object
+	}
+	resKeys := []ResourceType{Credentials, Resources, ManagedClusters}
+	tests := []struct {
+		name     string
+		backups  []client.Object
+		restores map[ResourceType]*veleroapi.Restore
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "all backups completed",
+			backups: []client.Object{
+				createBackup("acm-credentials-schedule-1", "ns").phase(veleroapi.BackupPhaseCompleted).object,
+				createBackup("acm-resources-schedule-1", "ns").phase(veleroapi.BackupPhaseCompleted).object,
+			},
+			restores: map[ResourceType]*veleroapi.Restore{
+				Credentials: newVeleroRestore("acm-credentials-schedule-1"),
+				Resources:   newVeleroRestore("acm-resources-schedule-1"),
+			},
+			want: "",
+		},
+		{
+			name: "backups not completed",
+			backups: []client.Object{
+				createBackup("acm-credentials-schedule-1", "ns").phase(veleroapi.BackupPhaseCompleted).object,
+				createBackup("acm-resources-schedule-1", "ns").phase(veleroapi.BackupPhasePartiallyFailed).object,
+				createBackup("acm-managed-clusters-schedule-1", "ns").phase(veleroapi.BackupPhaseInProgress).object,
+			},
+			restores: map[ResourceType]*veleroapi.Restore{
+				Credentials:     newVeleroRestore("acm-credentials-schedule-1"),
+				Resources:       newVeleroRestore("acm-resources-schedule-1"),
+				ManagedClusters: newVeleroRestore("acm-managed-clusters-schedule-1"),
+			},
+			want: "PointInTimeRecovery requires completed backups, backups not completed: " +
+				"acm-resources-schedule-1 (PartiallyFailed), acm-managed-clusters-schedule-1 (InProgress)",
+		},
+		{
+			name: "backup not found",
+			restores: map[ResourceType]*veleroapi.Restore{
+				Resources: newVeleroRestore("acm-resources-schedule-1"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(tt.backups...).Build()
+			got, err := getIncompleteRecoveryBackups(context.Background(), fakeClient, "ns", resKeys, tt.restores)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getIncompleteRecoveryBackups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getIncompleteRecoveryBackups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getIncludeClusterResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    map[ResourceType]bool
+	}{
+		{
+			name:    "per type defaults",
+			restore: createACMRestore("restore", "ns").object,
+			want: map[ResourceType]bool{
+				ManagedClusters:    true,
+				Resources:          true,
+				ResourcesGeneric:   true,
+				Credentials:        false,
+				CredentialsHive:    false,
+				CredentialsCluster: false,
+			},
+		},
+		{
+			name:    "cluster resources included for all types",
+			restore: createACMRestore("restore", "ns").includeClusterResources(true).object,
+			want: map[ResourceType]bool{
+				ManagedClusters:    true,
+				Resources:          true,
+				ResourcesGeneric:   true,
+				Credentials:        true,
+				CredentialsHive:    true,
+				CredentialsCluster: true,
+			},
+		},
+		{
+			name:    "cluster resources excluded for all types",
+			restore: createACMRestore("restore", "ns").includeClusterResources(false).object,
+			want: map[ResourceType]bool{
+				ManagedClusters:    false,
+				Resources:          false,
+				ResourcesGeneric:   false,
+				Credentials:        false,
+				CredentialsHive:    false,
+				CredentialsCluster: false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, want := range tt.want {
+				veleroRestore := &veleroapi.Restore{}
+				setOptionalProperties(key, tt.restore, veleroRestore)
+				got := veleroRestore.Spec.IncludeClusterResources
+				if got == nil || *got != want {
+					t.Errorf("setOptionalProperties() %s IncludeClusterResources = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_isValidSandboxOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    string
+	}{
+		{
+			name: "sandbox not used",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+				veleroManagedClustersBackupName(latestBackupStr).object,
+			want: "",
+		},
+		{
+			name: "validation restore without the sandbox prefix",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				validationRestore(true).object,
+			want: "ValidationRestore requires the SandboxNamespacePrefix option",
+		},
+		{
+			name: "valid sandbox options",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				sandboxNamespacePrefix("sandbox").object,
+			want: "",
+		},
+		{
+			name: "invalid prefix",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				sandboxNamespacePrefix("Sandbox").object,
+			want: "invalid SandboxNamespacePrefix : a lowercase RFC 1123 label must consist of lower case " +
+				"alphanumeric characters or '-', and must start and end with an alphanumeric character " +
+				"(e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+		{
+			name: "prefix too long",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				sandboxNamespacePrefix(strings.Repeat("a", 41)).object,
+			want: "invalid SandboxNamespacePrefix : must be no more than 40 characters",
+		},
+		{
+			name: "cleanup set",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				sandboxNamespacePrefix("sandbox").object,
+			want: "SandboxNamespacePrefix can be used only with the CleanupBeforeRestore option set to None",
+		},
+		{
+			name: "managed clusters restored",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(latestBackupStr).
+				sandboxNamespacePrefix("sandbox").object,
+			want: "SandboxNamespacePrefix can be used only with VeleroManagedClustersBackupName set to skip",
+		},
+		{
+			name: "namespace mapping set",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				namespaceMapping(map[string]string{"ns1": "ns2"}).
+				sandboxNamespacePrefix("sandbox").object,
+			want: "SandboxNamespacePrefix cannot be used together with the NamespaceMapping option",
+		},
+		{
+			name: "cluster resources included",
+			restore: createACMRestore("restore", "ns").
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				includeClusterResources(true).
+				sandboxNamespacePrefix("sandbox").object,
+			want: "SandboxNamespacePrefix cannot be used with the IncludeClusterResources option set to true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidSandboxOptions(tt.restore); got != tt.want {
+				t.Errorf("isValidSandboxOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_setSandboxNamespaceMapping(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			createNamespace("app-ns"),
+			createNamespace("managed1"),
+			createNamespace("open-cluster-management-backup"),
+			createNamespace("sandbox-app-ns"),
+		).
+		Build()
+
+	tests := []struct {
+		name               string
+		veleroBackup       *veleroapi.Backup
+		includedNamespaces []string
+		want               map[string]string
+		wantErr            bool
+	}{
+		{
+			name: "backup includes all namespaces",
+			veleroBackup: createBackup("acm-resources-schedule-20220922170041", "ns").
+				excludedNamespaces([]string{"open-cluster-management-backup"}).object,
+			want: map[string]string{
+				"app-ns":   "sandbox-app-ns",
+				"managed1": "sandbox-managed1",
+			},
+		},
+		{
+			name: "backup includes some namespaces",
+			veleroBackup: createBackup("acm-resources-schedule-20220922170041", "ns").
+				includedNamespaces([]string{"app-ns", "other-ns"}).object,
+			want: map[string]string{
+				"app-ns":   "sandbox-app-ns",
+				"other-ns": "sandbox-other-ns",
+			},
+		},
+		{
+			name: "restore includes some namespaces",
+			veleroBackup: createBackup("acm-resources-schedule-20220922170041", "ns").
+				object,
+			includedNamespaces: []string{"managed1"},
+			want: map[string]string{
+				"managed1": "sandbox-managed1",
+			},
+		},
+		{
+			name: "no namespaces to restore",
+			veleroBackup: createBackup("acm-resources-schedule-20220922170041", "ns").
+				includedNamespaces([]string{"sandbox-app-ns"}).object,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroRestore := createRestore("restore", "ns").object
+			veleroRestore.Spec.IncludedNamespaces = tt.includedNamespaces
+
+			err := setSandboxNamespaceMapping(context.Background(), fakeClient, "sandbox",
+				tt.veleroBackup, veleroRestore)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setSandboxNamespaceMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(veleroRestore.Spec.NamespaceMapping, tt.want) {
+				t.Errorf("setSandboxNamespaceMapping() NamespaceMapping = %v, want %v",
+					veleroRestore.Spec.NamespaceMapping, tt.want)
+			}
+			// only the mapped namespaces are restored
+			if len(veleroRestore.Spec.IncludedNamespaces) != len(tt.want) {
+				t.Errorf("setSandboxNamespaceMapping() IncludedNamespaces = %v, want the %v keys",
+					veleroRestore.Spec.IncludedNamespaces, tt.want)
+			}
+			for _, ns := range veleroRestore.Spec.IncludedNamespaces {
+				if _, found := tt.want[ns]; !found {
+					t.Errorf("setSandboxNamespaceMapping() namespace %s is included but not mapped", ns)
+				}
+			}
+			if veleroRestore.Spec.IncludeClusterResources == nil || *veleroRestore.Spec.IncludeClusterResources {
+				t.Errorf("setSandboxNamespaceMapping() cluster resources should not be restored")
+			}
+		})
+	}
+}
+
+func Test_setRestoreHold(t *testing.T) {
+	veleroRestoreList := &veleroapi.RestoreList{
+		Items: []veleroapi.Restore{
+			*createRestore("restore-acm-credentials", "ns").
+				phase(veleroapi.RestorePhaseCompleted).object,
+			*createRestore("restore-acm-resources", "ns").
+				phase(veleroapi.RestorePhaseInProgress).object,
+		},
+	}
+	tests := []struct {
+		name          string
+		restore       *v1beta1.Restore
+		veleroRestore *veleroapi.RestoreList
+		want          bool
+		wantPhase     v1beta1.RestorePhase
+		wantMsg       string
+	}{
+		{
+			name: "restore not on hold",
+			restore: createACMRestore("restore", "ns").
+				phase(v1beta1.RestorePhaseRunning).object,
+			veleroRestore: veleroRestoreList,
+			want:          false,
+			wantPhase:     v1beta1.RestorePhaseRunning,
+		},
+		{
+			name: "hold set before any velero restore is created",
+			restore: createACMRestore("restore", "ns").
+				hold(true).object,
+			veleroRestore: &veleroapi.RestoreList{},
+			want:          true,
+			wantPhase:     v1beta1.RestorePhaseHeld,
+			wantMsg:       "Restore restore is on hold, set the hold property to false to continue",
+		},
+		{
+			name: "hold set on a running restore",
+			restore: createACMRestore("restore", "ns").
+				hold(true).
+				phase(v1beta1.RestorePhaseRunning).object,
+			veleroRestore: veleroRestoreList,
+			want:          true,
+			wantPhase:     v1beta1.RestorePhaseHeld,
+			wantMsg: "Restore restore is on hold, set the hold property to false to continue" +
+				" ; Velero restores in progress: restore-acm-resources",
+		},
+		{
+			name: "hold released",
+			restore: createACMRestore("restore", "ns").
+				phase(v1beta1.RestorePhaseHeld).object,
+			veleroRestore: veleroRestoreList,
+			want:          false,
+			wantPhase:     v1beta1.RestorePhaseStarted,
+			wantMsg:       "Restore restore hold released",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setRestoreHold(logr.Discard(), tt.restore, tt.veleroRestore); got != tt.want {
+				t.Errorf("setRestoreHold() = %v, want %v", got, tt.want)
+			}
+			if tt.restore.Status.Phase != tt.wantPhase {
+				t.Errorf("setRestoreHold() phase = %v, want %v", tt.restore.Status.Phase, tt.wantPhase)
+			}
+			if tt.restore.Status.LastMessage != tt.wantMsg {
+				t.Errorf("setRestoreHold() message = %v, want %v", tt.restore.Status.LastMessage, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func Test_checkRestoreDependencies(t *testing.T) {
+	tests := []struct {
+		name           string
+		restore        *v1beta1.Restore
+		restores       []v1beta1.Restore
+		wantWaitingFor []string
+		wantMsg        string
+	}{
+		{
+			name: "dependency not finished yet",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"infra-restore"}).object,
+			restores: []v1beta1.Restore{
+				*createACMRestore("infra-restore", "ns").
+					phase(v1beta1.RestorePhaseRunning).object,
+			},
+			wantWaitingFor: []string{"infra-restore"},
+		},
+		{
+			name: "dependency finished",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"infra-restore"}).object,
+			restores: []v1beta1.Restore{
+				*createACMRestore("infra-restore", "ns").
+					phase(v1beta1.RestorePhaseFinished).object,
+			},
+			wantWaitingFor: []string{},
+		},
+		{
+			name: "dependency not created yet",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"infra-restore"}).object,
+			restores:       []v1beta1.Restore{},
+			wantWaitingFor: []string{"infra-restore"},
+		},
+		{
+			name: "dependency finished with errors",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"infra-restore"}).object,
+			restores: []v1beta1.Restore{
+				*createACMRestore("infra-restore", "ns").
+					phase(v1beta1.RestorePhaseFinishedWithErrors).object,
+			},
+			wantMsg: "Restore infra-restore, listed under dependsOn, did not finish successfully",
+		},
+		{
+			name: "restore depends on itself",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"app-restore"}).object,
+			wantMsg: "Restore dependency cycle found: app-restore -> app-restore",
+		},
+		{
+			name: "two restores depend on each other",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"infra-restore"}).object,
+			restores: []v1beta1.Restore{
+				*createACMRestore("infra-restore", "ns").
+					dependsOn([]string{"app-restore"}).
+					phase(v1beta1.RestorePhaseWaiting).object,
+			},
+			wantMsg: "Restore dependency cycle found: app-restore -> infra-restore -> app-restore",
+		},
+		{
+			name: "cycle not including this restore",
+			restore: createACMRestore("app-restore", "ns").
+				dependsOn([]string{"infra-restore"}).object,
+			restores: []v1beta1.Restore{
+				*createACMRestore("infra-restore", "ns").
+					dependsOn([]string{"base-restore"}).object,
+				*createACMRestore("base-restore", "ns").
+					dependsOn([]string{"infra-restore"}).object,
+			},
+			wantWaitingFor: []string{"infra-restore"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waitingFor, msg := checkRestoreDependencies(tt.restore, tt.restores)
+			if msg != tt.wantMsg {
+				t.Errorf("checkRestoreDependencies() msg = %v, want %v", msg, tt.wantMsg)
+			}
+			if !reflect.DeepEqual(waitingFor, tt.wantWaitingFor) {
+				t.Errorf("checkRestoreDependencies() waitingFor = %v, want %v", waitingFor, tt.wantWaitingFor)
+			}
+		})
+	}
+}
+
+func Test_setOptionalProperties(t *testing.T) {
+	type args struct {
+		restype       ResourceType
+		acmRestore    *v1beta1.Restore
+		veleroRestore *veleroapi.Restore
+	}
+
+	writeSparseFiles := true
+	uploaderConfig := &veleroapi.UploaderConfigForRestore{WriteSparseFiles: &writeSparseFiles}
+	restorePVsDisabled := false
+	restorePVsEnabled := true
+	orSelectors := []*v1.LabelSelector{
+		{MatchLabels: map[string]string{"app": "app1"}},
+		{MatchLabels: map[string]string{"app": "app2"}},
+	}
+
+	tests := []struct {
+		name                   string
+		args                   args
+		wantUploaderConfig     *veleroapi.UploaderConfigForRestore
+		wantOrLabelSelectors   []*v1.LabelSelector
+		wantExcludedNamespaces []string
+		wantRestorePVs         *bool
+	}{
+		{
+			name: "verify that CRDs are excluded from restore",
+			args: args{
+				restype: Credentials,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestore: createRestore("credentials-restore", "ns").object,
+			},
+			wantUploaderConfig: nil,
+		},
+		{
+			name: "uploader config is set on the resources restore",
+			args: args{
+				restype: Resources,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					uploaderConfig(uploaderConfig).object,
+				veleroRestore: createRestore("resources-restore", "ns").object,
+			},
+			wantUploaderConfig: uploaderConfig,
+		},
+		{
+			name: "uploader config is not set on the credentials restore",
+			args: args{
+				restype: Credentials,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					uploaderConfig(uploaderConfig).object,
+				veleroRestore: createRestore("credentials-restore", "ns").object,
+			},
+			wantUploaderConfig: nil,
+		},
+		{
+			name: "resources OR label selectors are set on the resources restore",
+			args: args{
+				restype: Resources,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					resourcesRestoreOrLabelSelectors(orSelectors).object,
+				veleroRestore: createRestore("resources-restore", "ns").object,
+			},
+			wantOrLabelSelectors: orSelectors,
+		},
+		{
+			name: "resources OR label selectors are not set on the generic resources restore",
+			args: args{
+				restype: ResourcesGeneric,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					resourcesRestoreOrLabelSelectors(orSelectors).object,
+				veleroRestore: createRestore("resources-generic-restore", "ns").object,
+			},
+			wantOrLabelSelectors: nil,
+		},
+		{
+			name: "excluded namespaces are set on the resources restore",
+			args: args{
+				restype: Resources,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					excludedNamespaces([]string{"ns1", "ns2"}).object,
+				veleroRestore: createRestore("resources-restore", "ns").object,
+			},
+			wantExcludedNamespaces: []string{"ns1", "ns2"},
+		},
+		{
+			name: "excluded namespaces are set on the credentials restore",
+			args: args{
+				restype: Credentials,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					excludedNamespaces([]string{"ns1", "ns2", "ns1"}).object,
+				veleroRestore: createRestore("credentials-restore", "ns").object,
+			},
+			wantExcludedNamespaces: []string{"ns1", "ns2"},
+		},
+		{
+			name: "restorePVs disabled on the resources restore",
+			args: args{
+				restype: Resources,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					restorePVs(false).object,
+				veleroRestore: createRestore("resources-restore", "ns").object,
+			},
+			wantRestorePVs: &restorePVsDisabled,
+		},
+		{
+			name: "restorePVs enabled on the generic resources restore",
+			args: args{
+				restype: ResourcesGeneric,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					restorePVs(true).object,
+				veleroRestore: createRestore("resources-generic-restore", "ns").object,
+			},
+			wantRestorePVs: &restorePVsEnabled,
+		},
+		{
+			name: "uploader config not defined",
+			args: args{
+				restype: Resources,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					veleroManagedClustersBackupName("skip").
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestore: createRestore("resources-restore", "ns").object,
+			},
+			wantUploaderConfig: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setOptionalProperties(tt.args.restype, tt.args.acmRestore, tt.args.veleroRestore)
+			if !findValue(tt.args.veleroRestore.Spec.ExcludedResources, "CustomResourceDefinition") {
+				t.Errorf("CustomResourceDefinition should be excluded from restore and be part of " +
+					"veleroRestore.Spec.ExcludedResources")
+			}
+			if !reflect.DeepEqual(tt.args.veleroRestore.Spec.UploaderConfig, tt.wantUploaderConfig) {
+				t.Errorf("veleroRestore.Spec.UploaderConfig = %v, want %v",
+					tt.args.veleroRestore.Spec.UploaderConfig, tt.wantUploaderConfig)
+			}
+			if !reflect.DeepEqual(tt.args.veleroRestore.Spec.OrLabelSelectors, tt.wantOrLabelSelectors) {
+				t.Errorf("veleroRestore.Spec.OrLabelSelectors = %v, want %v",
+					tt.args.veleroRestore.Spec.OrLabelSelectors, tt.wantOrLabelSelectors)
+			}
+			if !reflect.DeepEqual(tt.args.veleroRestore.Spec.ExcludedNamespaces, tt.wantExcludedNamespaces) {
+				t.Errorf("veleroRestore.Spec.ExcludedNamespaces = %v, want %v",
+					tt.args.veleroRestore.Spec.ExcludedNamespaces, tt.wantExcludedNamespaces)
+			}
+			// velero restores PVs by default when RestorePVs is not set
+			if !reflect.DeepEqual(tt.args.veleroRestore.Spec.RestorePVs, tt.wantRestorePVs) {
+				t.Errorf("veleroRestore.Spec.RestorePVs = %v, want %v",
+					tt.args.veleroRestore.Spec.RestorePVs, tt.wantRestorePVs)
+			}
+		})
+	}
+}
+
+func Test_isValidNamespaceFilters(t *testing.T) {
+	tests := []struct {
+		name       string
+		acmRestore *v1beta1.Restore
+		wantError  bool
+	}{
+		{
+			name:       "no namespace filters",
+			acmRestore: createACMRestore("acm-restore", "ns").object,
+			wantError:  false,
+		},
+		{
+			name: "only excluded namespaces",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				excludedNamespaces([]string{"ns1", "ns2"}).object,
+			wantError: false,
+		},
+		{
+			name: "included and excluded namespaces, no overlap",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				includedNamespaces([]string{"ns1"}).
+				excludedNamespaces([]string{"ns2"}).object,
+			wantError: false,
+		},
+		{
+			name: "namespace both included and excluded",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				includedNamespaces([]string{"ns1", "ns3"}).
+				excludedNamespaces([]string{"ns2", "ns3"}).object,
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidNamespaceFilters(tt.acmRestore); (got != "") != tt.wantError {
+				t.Errorf("isValidNamespaceFilters() = %v, wantError %v", got, tt.wantError)
+			}
+		})
+	}
+}
+
+func Test_isValidResourcesOrLabelSelectors(t *testing.T) {
+	validSelector := &v1.LabelSelector{MatchLabels: map[string]string{"app": "app1"}}
+	invalidSelector := &v1.LabelSelector{MatchExpressions: []v1.LabelSelectorRequirement{
+		{Key: "app", Operator: "BadOperator", Values: []string{"app2"}},
+	}}
+
+	tests := []struct {
+		name       string
+		acmRestore *v1beta1.Restore
+		wantError  bool
+	}{
+		{
+			name:       "no selectors",
+			acmRestore: createACMRestore("acm-restore", "ns").object,
+			wantError:  false,
+		},
+		{
+			name: "valid selectors",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				resourcesRestoreOrLabelSelectors([]*v1.LabelSelector{validSelector}).object,
+			wantError: false,
+		},
+		{
+			name: "invalid selector operator",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				resourcesRestoreOrLabelSelectors([]*v1.LabelSelector{validSelector, invalidSelector}).object,
+			wantError: true,
+		},
+		{
+			name: "nil selector",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				resourcesRestoreOrLabelSelectors([]*v1.LabelSelector{nil}).object,
+			wantError: true,
+		},
+		{
+			name: "selectors used with the label selector option",
+			acmRestore: createACMRestore("acm-restore", "ns").
+				resourcesRestoreOrLabelSelectors([]*v1.LabelSelector{validSelector}).
+				restoreLabelSelector(validSelector).object,
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidResourcesOrLabelSelectors(tt.acmRestore); (got != "") != tt.wantError {
+				t.Errorf("isValidResourcesOrLabelSelectors() = %v, wantError %v", got, tt.wantError)
+			}
+		})
+	}
+}
+
+func Test_retrieveRestoreDetails(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	skipRestore := "skip"
+	veleroNamespaceName := "default"
+	invalidBackupName := ""
+	backupName := "backup-name"
+
+	backup := *createBackup(backupName, veleroNamespaceName).
+		phase(veleroapi.BackupPhaseCompleted).
+		errors(0).object
+
+	restoreCredsNoError := *createACMRestore("restore1", veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(skipRestore).
+		veleroCredentialsBackupName(skipRestore).
+		veleroResourcesBackupName(backupName).object
+
+	restoreCredsInvalidBackupName := *createACMRestore("restore1", veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(latestBackupStr).
+		veleroCredentialsBackupName(skipRestore).
+		veleroResourcesBackupName(invalidBackupName).object
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+	err = k8sClient1.Create(context.Background(), &backup, &client.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating backup: %s", err.Error())
+	}
+
+	type args struct {
+		ctx                        context.Context
+		c                          client.Client
+		s                          *runtime.Scheme
+		restore                    *v1beta1.Restore
+		restoreOnlyManagedClusters bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "retrieveRestoreDetails has error, no backups found",
+			args: args{
+				ctx:                        context.Background(),
+				c:                          k8sClient1,
+				s:                          scheme1,
+				restore:                    &restoreCredsNoError,
+				restoreOnlyManagedClusters: false,
+			},
+			want: false, // has error, restore not found
+		},
+
+		{
+			name: "retrieveRestoreDetails has error, no backup name",
+			args: args{
+				ctx:                        context.Background(),
+				c:                          k8sClient1,
+				s:                          scheme1,
+				restore:                    &restoreCredsInvalidBackupName,
+				restoreOnlyManagedClusters: false,
+			},
+			want: false, // has error, backup name is invalid
+		},
+	}
+
+	for index, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, _, got := retrieveRestoreDetails(tt.args.ctx, tt.args.c,
+				tt.args.s, tt.args.restore, tt.args.restoreOnlyManagedClusters)
+			if (got == nil) != tt.want {
+				t.Errorf("retrieveRestoreDetails() returns = %v, want %v", got == nil, tt.want)
+			}
+			if keys[0] != Credentials {
+				t.Errorf("retrieveRestoreDetails() error, Credentials should be first key to restore ")
+			}
+			if keys[len(keys)-1] != ManagedClusters {
+				t.Errorf("retrieveRestoreDetails() error, ManagedClusters should be last key for restore ")
+			}
+		})
+		if index == len(tests)-1 {
+			// clean up
+			if err := testEnv.Stop(); err != nil {
+				t.Errorf("Error stopping testenv: %s", err.Error())
+			}
+		}
+	}
+}
+
+func Test_isOtherResourcesRunning(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	veleroNamespaceName := "default"
+	restoreName := "restore-backup-name"
+	backupName := "backup-name"
+
+	restore := *createACMRestore(restoreName, veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(latestBackupStr).
+		veleroCredentialsBackupName(skipRestoreStr).
+		veleroResourcesBackupName(backupName).object
+
+	restoreOther := *createACMRestore("other-"+restoreName, veleroNamespaceName).
+		syncRestoreWithNewBackups(true).
+		restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(latestBackupStr).
+		veleroCredentialsBackupName(skipRestoreStr).
+		veleroResourcesBackupName(backupName).object
+
+	backupCollision := *createBackupSchedule(backupName+"-collision", veleroNamespaceName).
+		phase(v1beta1.SchedulePhaseBackupCollision).
+		object
+	backupFailed := *createBackupSchedule(backupName+"-failed", veleroNamespaceName).
+		object
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	type args struct {
+		ctx     context.Context
+		c       client.Client
+		restore *v1beta1.Restore
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "isOtherResourcesRunning has error, CRD not installed",
+			args: args{
+				ctx:     context.Background(),
+				c:       k8sClient1,
+				restore: &restore,
+			},
+			want: "",
+		},
+		{
+			name: "isOtherResourcesRunning has no errors, no backups found",
+			args: args{
+				ctx:     context.Background(),
+				c:       k8sClient1,
+				restore: &restore,
+			},
+			want: "",
+		},
+		{
+			name: "isOtherResourcesRunning has no errors, backup found but in collision state",
+			args: args{
+				ctx:     context.Background(),
+				c:       k8sClient1,
+				restore: &restore,
+			},
+			want: "",
+		},
+		{
+			name: "isOtherResourcesRunning has errors, backup found and not in collision state",
+			args: args{
+				ctx:     context.Background(),
+				c:       k8sClient1,
+				restore: &restore,
+			},
+			want: "This resource is ignored because BackupSchedule resource backup-name-failed is currently active, " +
+				"before creating another resource verify that any active resources are removed.",
+		},
+		{
+			name: "isOtherResourcesRunning has no errors, no another restore is running",
+			args: args{
+				ctx:     context.Background(),
+				c:       k8sClient1,
+				restore: &restore,
+			},
+			want: "",
+		},
+		{
+			name: "isOtherResourcesRunning has errors, another restore is running",
+			args: args{
+				ctx:     context.Background(),
+				c:       k8sClient1,
+				restore: &restore,
+			},
+			want: "This resource is ignored because Restore resource other-restore-backup-name is currently active, " +
+				"before creating another resource verify that any active resources are removed.",
+		},
+	}
+
+	for index, tt := range tests {
+
+		if index == 1 {
+			// create CRD
+			if err := v1beta1.AddToScheme(scheme1); err != nil {
+				t.Errorf("Error adding api to scheme: %s", err.Error())
+			}
+			if err := k8sClient1.Create(tt.args.ctx, &restore, &client.CreateOptions{}); err != nil {
+				t.Errorf("Error creating restore: %s", err.Error())
+			}
+
+		}
+		if index == 2 {
+			// create backup in collision state
+			err := k8sClient1.Create(tt.args.ctx, &backupCollision, &client.CreateOptions{})
+			if err != nil {
+				t.Errorf("Error creating backupschedule: %s", err.Error())
+			}
+			err = k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+				Name:      backupCollision.Name,
+				Namespace: backupCollision.Namespace,
+			}, &backupCollision)
+			if err != nil {
+				t.Errorf("Error getting backupschedule: %s", err.Error())
+			}
+			backupCollision.Status.Phase = v1beta1.SchedulePhaseBackupCollision
+			err = k8sClient1.Status().Update(tt.args.ctx, &backupCollision)
+			if err != nil {
+				t.Errorf("Error updating backupschedule: %s", err.Error())
+			}
+		}
+		if index == 3 {
+			err := k8sClient1.Create(tt.args.ctx, &backupFailed)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
+		if index == len(tests)-2 {
+			err := k8sClient1.Delete(tt.args.ctx, &backupFailed)
+			if err != nil {
+				t.Errorf("Error deleting: %s", err.Error())
+			}
+		}
+		if index == len(tests)-1 {
+			err := k8sClient1.Create(tt.args.ctx, &restoreOther)
+			if err != nil {
+				t.Errorf("Error creating: %s", err.Error())
+			}
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := isOtherResourcesRunning(tt.args.ctx, tt.args.c,
+				tt.args.restore); got != tt.want {
+				t.Errorf("isOtherResourcesRunning() returns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// clean up
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_updateLabelsForActiveResources(t *testing.T) {
+	type args struct {
+		acmRestore             *v1beta1.Restore
+		restype                ResourceType
+		veleroRestoresToCreate map[ResourceType]*veleroapi.Restore
+	}
+
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantResName string // the name of the restore after parsing the current state;
+		// could have suffix -active if on the activation step
+	}{
+		{
+			name: "Credentials restore with no ManagedCluster, should return false",
+			args: args{
+				restype: Credentials,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
+					Credentials: createRestore("credentials-restore", "ns").object,
+				},
+			},
+			want:        false,
+			wantResName: "credentials-restore",
+		},
+		{
+			name: "Credentials restore with ManagedCluster latest and sync, should return true",
+			args: args{
+				restype: Credentials,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
+					Credentials:     createRestore("credentials-restore", "ns").object,
+					ManagedClusters: createRestore("clusters-restore", "ns").object,
+				},
+			},
+			want:        true,
+			wantResName: "credentials-restore-active",
+		},
+		{
+			name: "Credentials restore for ManagedCluster latest with no sync, should return true",
+			args: args{
+				restype: Credentials,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(skipRestoreStr).
+					veleroResourcesBackupName(skipRestoreStr).object,
+				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
+					Credentials:     createRestore("credentials-restore", "ns").object,
+					ManagedClusters: createRestore("clusters-restore", "ns").object,
+				},
+			},
+			want:        true,
+			wantResName: "credentials-restore", // creds was skipped
+		},
+		{
+			name: "Generic Res restore with ManagedCluster and no sync, should return false no active",
+			args: args{
+				restype: ResourcesGeneric,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
+					Resources:        createRestore("resources-restore", "ns").object,
+					ResourcesGeneric: createRestore("generic-restore", "ns").object,
+					ManagedClusters:  createRestore("clusters-restore", "ns").object,
+					Credentials:      createRestore("credentials-restore", "ns").object,
+				},
+			},
+			want:        false,
+			wantResName: "generic-restore",
+		},
+		{
+			name: "Generic Res restore with ManagedCluster and sync, should return false and active",
+			args: args{
+				restype: ResourcesGeneric,
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoresToCreate: map[ResourceType]*veleroapi.Restore{
+					Resources:        createRestore("resources-restore", "ns").object,
+					ResourcesGeneric: createRestore("generic-restore", "ns").object,
+					ManagedClusters:  createRestore("clusters-restore", "ns").object,
+					Credentials:      createRestore("credentials-restore", "ns").object,
+				},
+			},
+			want:        false,
+			wantResName: "generic-restore-active",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := updateLabelsForActiveResources(tt.args.acmRestore, tt.args.restype, tt.args.veleroRestoresToCreate)
+			if got != tt.want {
+				t.Errorf("error updating labels for: %s", tt.name)
+			}
+			if tt.wantResName != tt.args.veleroRestoresToCreate[tt.args.restype].Name {
+				t.Errorf("The restore resource name should be  %v, but got %v",
+					tt.wantResName, tt.args.veleroRestoresToCreate[tt.args.restype].Name)
+			}
+		})
+	}
+}
+
+func Test_isPVCInitializationStep(t *testing.T) {
+	type args struct {
+		acmRestore        *v1beta1.Restore
+		veleroRestoreList veleroapi.RestoreList
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "sync restore with ManagedCluster skip, should return false",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{},
+			},
+			want: false,
+		},
+		{
+			name: "no sync and ManagedCluster is skipped, should return false",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(false).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{
+					Items: []veleroapi.Restore{
+						*createRestore("credentials-restore", "ns").
+							scheduleName(veleroScheduleNames[Credentials]).
+							object,
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "sync restore with ManagedCluster, and only credentials restore, should return true",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{
+					Items: []veleroapi.Restore{
+						*createRestore("credentials-restore", "ns").
+							scheduleName(veleroScheduleNames[Credentials]).
+							object,
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "sync restore with ManagedCluster, and credentials plus generic restored, should return true",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					restoreSyncInterval(v1.Duration{Duration: time.Minute * 20}).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{
+					Items: []veleroapi.Restore{
+						*createRestore("credentials-restore", "ns").
+							scheduleName(veleroScheduleNames[Credentials]).
+							object,
+						*createRestore("generic-restore", "ns").
+							scheduleName(veleroScheduleNames[ResourcesGeneric]).
+							object,
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "no sync restore with ManagedCluster, and credentials plus generic restored, should return true",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(false).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{
+					Items: []veleroapi.Restore{
+						*createRestore("credentials-restore", "ns").
+							scheduleName(veleroScheduleNames[Credentials]).
+							object,
+						*createRestore("generic-restore", "ns").
+							scheduleName(veleroScheduleNames[ResourcesGeneric]).
+							object,
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "no sync restore with ManagedCluster, and credentials plus generic and managedcls restored, " +
+				"should return false",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(false).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{
+					Items: []veleroapi.Restore{
+						*createRestore("credentials-restore", "ns").
+							scheduleName(veleroScheduleNames[Credentials]).
+							object,
+						*createRestore("generic-restore", "ns").
+							scheduleName(veleroScheduleNames[ResourcesGeneric]).
+							object,
+						*createRestore("clusters-restore", "ns").
+							scheduleName(veleroScheduleNames[ManagedClusters]).
+							object,
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "sync restore with ManagedCluster, and credentials plus generic and managedcls restored, should return false",
+			args: args{
+				acmRestore: createACMRestore("acm-restore", "ns").
+					syncRestoreWithNewBackups(true).
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroManagedClustersBackupName(latestBackupStr).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).object,
+				veleroRestoreList: veleroapi.RestoreList{
+					Items: []veleroapi.Restore{
+						*createRestore("credentials-restore", "ns").
+							scheduleName(veleroScheduleNames[Credentials]).
+							object,
+						*createRestore("generic-restore", "ns").
+							scheduleName(veleroScheduleNames[ResourcesGeneric]).
+							object,
+						*createRestore("clusters-restore", "ns").
+							scheduleName(veleroScheduleNames[ManagedClusters]).
+							object,
+					},
+				},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := isPVCInitializationStep(tt.args.acmRestore, tt.args.veleroRestoreList)
+			if got != tt.want {
+				t.Errorf("error with isPVCInitializationStep for: %s", tt.name)
+			}
+		})
+	}
+}
+
+func Test_processRestoreWait(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	type args struct {
+		ctx              context.Context
+		c                client.Client
+		restoreName      string
+		restoreNamespace string
+		veleroRestore    veleroapi.Restore
+		pvMap            corev1.ConfigMap
+		pvc              corev1.PersistentVolumeClaim
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "no velero restore found for restoreName, wait",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				restoreName:      "creds-restore-name",
+				restoreNamespace: "default",
+				veleroRestore:    *createRestore("creds-restore-name-1", "default").object,
+				pvMap:            *createConfigMap("some-other-map-1", "default", nil),
+				pvc:              *createPVC("mongo-storage-1", "default"),
+			},
+			want: true,
+		},
+		{
+			name: "velero restore found but status is not completed, so wait",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				restoreName:      "creds-restore-name-2",
+				restoreNamespace: "default",
+				veleroRestore:    *createRestore("creds-restore-name-2", "default").object,
+				pvMap:            *createConfigMap("some-other-map-2", "default", nil),
+				pvc:              *createPVC("mongo-storage-2", "default"),
+			},
+			want: true,
+		},
+		{
+			name: "velero restore found and status is completed, no wait bc no config map",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				restoreName:      "creds-restore-name-3",
+				restoreNamespace: "default",
+				veleroRestore:    *createRestore("creds-restore-name-3", "default").phase("Completed").object,
+				pvMap:            *createConfigMap("some-other-map-3", "default", nil),
+				pvc:              *createPVC("mongo-storage-3", "default"),
+			},
+			want: false,
+		},
+		{
+			name: "velero restore found and status is completed, have config map so wait!",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				restoreName:      "creds-restore-name-4",
+				restoreNamespace: "default",
+				veleroRestore:    *createRestore("creds-restore-name-4", "default").phase("Completed").object,
+				pvMap: *createConfigMap("hub-pvc-backup-mongo-storage-4", "default", map[string]string{
+					"cluster.open-cluster-management.io/backup-pvc": "mongo-storage",
+				}),
+				pvc: *createPVC("mongo-storage-4", "default"), // this is NOT the expected PVC, not matching the map label !
+			},
+			want: true,
+		},
+		{
+			name: "velero restore found and status is completed, have config map but the PVC exists so NO wait!",
+			args: args{
+				ctx:              context.Background(),
+				c:                k8sClient1,
+				restoreName:      "creds-restore-name-5",
+				restoreNamespace: "default",
+				veleroRestore:    *createRestore("creds-restore-name-5", "default").phase("Completed").object,
+				pvMap: *createConfigMap("hub-pvc-backup-mongo-storage-5", "default", map[string]string{
+					"cluster.open-cluster-management.io/backup-pvc": "mongo-storage",
+				}),
+				pvc: *createPVC("mongo-storage", "default"), // this is the expected PVC, matching the map label !
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+
+		if err := k8sClient1.Create(context.Background(), &tt.args.veleroRestore); err != nil {
+			t.Errorf("cannot create resource %v", err.Error())
+		}
+
+		if err := k8sClient1.Create(context.Background(), &tt.args.pvMap); err != nil {
+			t.Errorf("cannot create resource %v", err.Error())
+		}
+
+		if err := k8sClient1.Create(context.Background(), &tt.args.pvc); err != nil {
+			t.Errorf("cannot create resource %v", err.Error())
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			if shouldWait, msg := processRestoreWait(tt.args.ctx, tt.args.c,
+				tt.args.restoreName, tt.args.restoreNamespace); shouldWait != tt.want {
+				t.Errorf("processRestoreWait() returns = %v, want %v, msg=%v", shouldWait, tt.want, msg)
+			}
+		})
+	}
+	// clean up
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func hasOrActivationLabel(
+	restore veleroapi.Restore,
+) bool {
+	hasActivationOrSelector := false
+	if len(restore.Spec.OrLabelSelectors) > 0 {
+		for i := range restore.Spec.OrLabelSelectors {
+			requirements := restore.Spec.OrLabelSelectors[i].MatchExpressions
+			for idx := range requirements {
+				if requirements[idx].Key == backupCredsClusterLabel {
+					hasActivationOrSelector = true
+					break
+				}
+			}
+		}
+	}
+	return hasActivationOrSelector
+}
+
+func hasActivationLabel(
+	restore veleroapi.Restore,
+) bool {
+	hasActivationSelector := false
+	if restore.Spec.LabelSelector != nil {
+		requirements := restore.Spec.LabelSelector.MatchExpressions
+		for idx := range requirements {
+			if requirements[idx].Key == backupCredsClusterLabel {
+				hasActivationSelector = true
+				break
+			}
+		}
+	}
+	return hasActivationSelector
+}
+
+func Test_actLabelNotOnManagedClsRestore(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	err = v1beta1.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	orLabelSelector := []*metav1.LabelSelector{
+		{
+			MatchLabels: map[string]string{
+				"restore-test-1": "restore-test-1-value",
+			},
+		},
+	}
+
+	activationLabel := &metav1.LabelSelectorRequirement{}
+	activationLabel.Key = backupCredsClusterLabel
+	activationLabel.Operator = "In"
+	activationLabel.Values = []string{ClusterActivationLabel}
+
+	type args struct {
+		ctx               context.Context
+		c                 client.Client
+		acmRestore        *v1beta1.Restore
+		credsRestore      *veleroapi.Restore
+		managedClsRestore *veleroapi.Restore
+	}
+	tests := []struct {
+		name string
+		args args
+		// true if the label activation must be part of the orLabelSelector - for creds and generic restore only!
+		wantActivationOrSelector bool
+		// true if the label activation must be part of the LabelSelector - for creds and generic restore only!
+		wantActivationSelector bool
+	}{
+		{
+			name: "restore passive with OR label",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				acmRestore: createACMRestore("acm-restore", "default").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					restoreORLabelSelector(orLabelSelector).
+					object,
+				credsRestore:      createRestore(veleroScheduleNames[Credentials], "default").object,
+				managedClsRestore: createRestore(veleroScheduleNames[ManagedClusters], "default").object,
+			},
+			wantActivationOrSelector: true,  // restore passive data, no managed clusters
+			wantActivationSelector:   false, // activation label is on the OR path
+		},
+		{
+			name: "restore passive with label",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				acmRestore: createACMRestore("acm-restore", "default").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					restoreLabelSelector(&metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"restorelabel": "value",
+						},
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{
+								Key:      "foo",
+								Operator: metav1.LabelSelectorOperator("In"),
+								Values:   []string{"bar"},
+							},
+						},
+					}).
+					object,
+				credsRestore:      createRestore(veleroScheduleNames[Credentials], "default").object,
+				managedClsRestore: createRestore(veleroScheduleNames[ManagedClusters], "default").object,
+			},
+			wantActivationOrSelector: false, // restore passive data, no managed clusters
+			wantActivationSelector:   true,  // activation label set here
+		},
+		{
+			name: "restore passive with label",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				acmRestore: createACMRestore("acm-restore", "default").
+					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+					veleroCredentialsBackupName(latestBackupStr).
+					veleroResourcesBackupName(latestBackupStr).
+					veleroManagedClustersBackupName(skipRestoreStr).
+					object,
+				credsRestore:      createRestore(veleroScheduleNames[Credentials], "default").object,
+				managedClsRestore: createRestore(veleroScheduleNames[ManagedClusters], "default").object,
+			},
+			wantActivationOrSelector: false, // restore passive data, no managed clusters
+			wantActivationSelector:   true,  // activation label set here
+		},
+	}
+
+	for _, tt := range tests {
+
+		if err := k8sClient1.Create(context.Background(), tt.args.acmRestore); err != nil {
+			t.Errorf("cannot create resource %v", err.Error())
+		}
+
+		if err := k8sClient1.Create(context.Background(), tt.args.credsRestore); err != nil {
+			t.Errorf("cannot create resource %v", err.Error())
+		}
+		if err := k8sClient1.Create(context.Background(), tt.args.managedClsRestore); err != nil {
+			t.Errorf("cannot create resource %v", err.Error())
+		}
+
+		// set user filters on both restores, using the acm restore
+		setUserRestoreFilters(tt.args.acmRestore, tt.args.credsRestore)
+		setUserRestoreFilters(tt.args.acmRestore, tt.args.managedClsRestore)
+
+		// add activation label to creds restore
+		addRestoreLabelSelector(tt.args.credsRestore, *activationLabel)
+
+		if hasOrActivationLabel(*tt.args.managedClsRestore) {
+			t.Errorf("managed cluster restore should not have the activation label on OR path ")
+		}
+		if hasActivationLabel(*tt.args.managedClsRestore) {
+			t.Errorf("managed cluster restore should not have the activation label ")
+		}
+		credsOrActLabel := hasOrActivationLabel(*tt.args.credsRestore)
+		if credsOrActLabel != tt.wantActivationOrSelector {
+			t.Errorf("creds restore OR activation label should be %v, got %v", tt.wantActivationOrSelector, credsOrActLabel)
+		}
+
+		credsActLabel := hasActivationLabel(*tt.args.credsRestore)
+		if credsActLabel != tt.wantActivationSelector {
+			t.Errorf("creds restore activation label should be %v, got %v", tt.wantActivationSelector, credsActLabel)
+		}
+
+		if err := k8sClient1.Delete(context.Background(), tt.args.acmRestore); err != nil {
+			t.Errorf("cannot delete resource %v", err.Error())
+		}
+
+		if err := k8sClient1.Delete(context.Background(), tt.args.credsRestore); err != nil {
+			t.Errorf("cannot delete resource %v", err.Error())
+		}
+		if err := k8sClient1.Delete(context.Background(), tt.args.managedClsRestore); err != nil {
+			t.Errorf("cannot delete resource %v", err.Error())
+		}
+	}
+	// clean up
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_isRestoredFromBackup(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		phase  v1beta1.RestorePhase
+		want   bool
+	}{
+		{
+			name: "restore created on this hub",
+			want: false,
+		},
+		{
+			name: "restored by velero, not processed",
+			labels: map[string]string{
+				RestoreNameVeleroLabel: "restore-acm-resources-schedule-20220922170041",
+				BackupNameVeleroLabel:  "acm-resources-schedule-20220922170041",
+			},
+			want: true,
+		},
+		{
+			name: "restored by velero, already processed",
+			labels: map[string]string{
+				RestoreNameVeleroLabel: "restore-acm-resources-schedule-20220922170041",
+				BackupNameVeleroLabel:  "acm-resources-schedule-20220922170041",
+			},
+			phase: v1beta1.RestorePhaseFinished,
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", "ns").
+				phase(tt.phase).object
+			restore.SetLabels(tt.labels)
+			if got := isRestoredFromBackup(restore); got != tt.want {
+				t.Errorf("isRestoredFromBackup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isCompletedRestoreRun(t *testing.T) {
+	restoredLabels := map[string]string{
+		RestoreNameVeleroLabel: "restore-acm-resources-schedule-20220922170041",
+		BackupNameVeleroLabel:  "acm-resources-schedule-20220922170041",
+	}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		status v1beta1.RestoreStatus
+		want   bool
+	}{
+		{
+			name:   "sync restore enabled",
+			status: v1beta1.RestoreStatus{Phase: v1beta1.RestorePhaseEnabled},
+			want:   false,
+		},
+		{
+			name:   "restore finished on this hub",
+			status: v1beta1.RestoreStatus{Phase: v1beta1.RestorePhaseFinished},
+			want:   true,
+		},
+		{
+			name:   "restore finished with errors on this hub",
+			status: v1beta1.RestoreStatus{Phase: v1beta1.RestorePhaseFinishedWithErrors},
+			want:   true,
+		},
+		{
+			name:   "restored by velero, never run on this hub",
+			labels: restoredLabels,
+			status: v1beta1.RestoreStatus{Phase: v1beta1.RestorePhaseFinished},
+			want:   false,
+		},
+		{
+			name:   "restored by velero, run on this hub",
+			labels: restoredLabels,
+			status: v1beta1.RestoreStatus{
+				Phase:                        v1beta1.RestorePhaseFinished,
+				VeleroCredentialsRestoreName: "restore-acm-credentials-schedule-20220922170041",
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", "ns").
+				restoreACMStatus(tt.status).object
+			restore.SetLabels(tt.labels)
+			if got := isCompletedRestoreRun(restore); got != tt.want {
+				t.Errorf("isCompletedRestoreRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isForceReconcileRequested(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		lastForced  string
+		want        bool
+	}{
+		{
+			name:        "no annotation",
+			annotations: nil,
+			want:        false,
+		},
+		{
+			name:        "empty annotation",
+			annotations: map[string]string{ForceReconcileAnnotation: ""},
+			want:        false,
+		},
+		{
+			name:        "new annotation value",
+			annotations: map[string]string{ForceReconcileAnnotation: "1"},
+			want:        true,
+		},
+		{
+			name:        "annotation value already processed",
+			annotations: map[string]string{ForceReconcileAnnotation: "1"},
+			lastForced:  "1",
+			want:        false,
+		},
+		{
+			name:        "annotation value changed",
+			annotations: map[string]string{ForceReconcileAnnotation: "2"},
+			lastForced:  "1",
+			want:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", "ns").
+				restoreACMStatus(v1beta1.RestoreStatus{LastForceReconcile: tt.lastForced}).object
+			restore.SetAnnotations(tt.annotations)
+			if got := isForceReconcileRequested(restore); got != tt.want {
+				t.Errorf("isForceReconcileRequested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_RestoreReconciler_forceReconcile(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		clusterv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
+		}
+	}
+
+	ns := "velero-ns"
+	newRestore := func(annotation string, lastForced string) *v1beta1.Restore {
+		restore := createACMRestore("restore", ns).
+			cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+			veleroManagedClustersBackupName(skipRestoreStr).
+			veleroCredentialsBackupName(latestBackupStr).
+			veleroResourcesBackupName(skipRestoreStr).
+			restoreACMStatus(v1beta1.RestoreStatus{
+				Phase:              v1beta1.RestorePhaseFinished,
+				LastForceReconcile: lastForced,
+			}).object
+		if annotation != "" {
+			restore.SetAnnotations(map[string]string{ForceReconcileAnnotation: annotation})
+		}
+		return restore
+	}
+	newVeleroRestore := func() *veleroapi.Restore {
+		veleroRestore := createRestore("restore-acm-credentials-schedule", ns).
+			backupName("acm-credentials-schedule-20220922170041").
+			phase(veleroapi.RestorePhaseCompleted).object
+		veleroRestore.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: apiGVStr,
+				Kind:       "Restore",
+				Name:       "restore",
+				UID:        "fed287da-02ea-4c83-a7f8-906ce662451a",
+				Controller: &[]bool{true}[0],
+			},
+		})
+		return veleroRestore
+	}
+
+	tests := []struct {
+		name           string
+		restore        *v1beta1.Restore
+		withStorage    bool
+		wantPhase      v1beta1.RestorePhase
+		wantLastForced string
+	}{
+		{
+			name:           "finished restore, no force annotation, restore is not processed",
+			restore:        newRestore("", ""),
+			withStorage:    false,
+			wantPhase:      v1beta1.RestorePhaseFinished,
+			wantLastForced: "",
+		},
+		{
+			name:           "finished restore, force annotation already processed, restore is not processed",
+			restore:        newRestore("1", "1"),
+			withStorage:    false,
+			wantPhase:      v1beta1.RestorePhaseFinished,
+			wantLastForced: "1",
+		},
+		{
+			name:           "finished restore, new force annotation, restore is processed",
+			restore:        newRestore("2", "1"),
+			withStorage:    true,
+			wantPhase:      v1beta1.RestorePhaseFinished,
+			wantLastForced: "2",
+		},
+		{
+			name:           "finished restore, new force annotation and no storage location, status is re-evaluated",
+			restore:        newRestore("2", "1"),
+			withStorage:    false,
+			wantPhase:      v1beta1.RestorePhaseFinished,
+			wantLastForced: "2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{tt.restore, newVeleroRestore()}
+			if tt.withStorage {
+				objects = append(objects, createStorageLocation("default", ns).setOwner().
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object)
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+
+			r := &RestoreReconciler{
+				Client: fakeClient,
+				Scheme: scheme1,
+			}
+			_, _ = r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			})
+
+			restore := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, restore); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if restore.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %v",
+					restore.Status.Phase, tt.wantPhase, restore.Status.LastMessage)
+			}
+			if restore.Status.LastForceReconcile != tt.wantLastForced {
+				t.Errorf("Reconcile() LastForceReconcile = %v, want %v",
+					restore.Status.LastForceReconcile, tt.wantLastForced)
+			}
+		})
+	}
+}
+
+func Test_RestoreReconciler_forceReconcile_completedRestore(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		clusterv1.AddToScheme,
+		corev1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
+		}
+	}
+
+	ns := "velero-ns"
+	credsBackupName := "acm-credentials-schedule-20220922170041"
+	credsRestoreName := "restore-acm-credentials-schedule"
+	now := time.Now().In(time.UTC)
+	msaAnnotations := map[string]string{
+		"lastRefreshTimestamp": now.Add(-time.Hour).Format(time.RFC3339),
+		"expirationTimestamp":  now.Add(10 * time.Hour).Format(time.RFC3339),
+	}
+
+	tests := []struct {
+		name         string
+		withSchedule bool
+	}{
+		{
+			name: "no BackupSchedule",
+		},
+		{
+			name:         "BackupSchedule created after the restore",
+			withSchedule: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the restore activated managed1 and cleaned up the hub
+			restore := createACMRestore("restore", ns).
+				cleanupBeforeRestore(v1beta1.CleanupTypeAll).
+				veleroManagedClustersBackupName(latestBackupStr).
+				veleroCredentialsBackupName(latestBackupStr).
+				veleroResourcesBackupName(skipRestoreStr).
+				restoreACMStatus(v1beta1.RestoreStatus{
+					Phase:                        v1beta1.RestorePhaseFinished,
+					LastForceReconcile:           "1",
+					VeleroCredentialsRestoreName: credsRestoreName,
+					ActivationPhase:              v1beta1.ActivationPhaseCompleted,
+					ActivatedClusters:            []string{"managed1"},
+				}).object
+			restore.SetAnnotations(map[string]string{ForceReconcileAnnotation: "2"})
+			veleroRestore := createRestore(credsRestoreName, ns).
+				backupName(credsBackupName).
+				phase(veleroapi.RestorePhaseCompleted).object
+			veleroRestore.SetOwnerReferences([]metav1.OwnerReference{
+				{
+					APIVersion: apiGVStr,
+					Kind:       "Restore",
+					Name:       "restore",
+					UID:        "fed287da-02ea-4c83-a7f8-906ce662451a",
+					Controller: &[]bool{true}[0],
+				},
+			})
+			objects := []client.Object{
+				restore,
+				veleroRestore,
+				createBackup(credsBackupName, ns).object,
+				createStorageLocation("default", ns).setOwner().
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+				// user credentials created after the restore, deleted by a cleanup
+				createSecret("user-creds", "default",
+					map[string]string{backupCredsUserLabel: "user"}, nil, nil),
+				// managed2 not activated by the restore, activated by a new activation
+				createManagedCluster("managed2", false).clusterUrl("someurl").
+					conditions([]metav1.Condition{{Status: metav1.ConditionFalse}}).object,
+				createSecret(msa_service_name, "managed2",
+					map[string]string{msa_label: "true"}, msaAnnotations,
+					map[string][]byte{"token": []byte("YWRtaW4=")}),
+			}
+			if tt.withSchedule {
+				objects = append(objects, createBackupSchedule("acm-schedule", ns).
+					schedule("0 */1 * * *").
+					phase(v1beta1.SchedulePhaseEnabled).object)
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+
+			r := &RestoreReconciler{
+				Client:          fakeClient,
+				Scheme:          scheme1,
+				DiscoveryClient: &discoveryfake.FakeDiscovery{Fake: &clienttesting.Fake{}},
+				DynamicClient:   dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+				Recorder:        record.NewFakeRecorder(10),
+			}
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			got := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, got); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if got.Status.Phase != v1beta1.RestorePhaseFinished {
+				t.Errorf("Reconcile() phase = %v, want %v, message %v",
+					got.Status.Phase, v1beta1.RestorePhaseFinished, got.Status.LastMessage)
+			}
+			if got.Status.LastForceReconcile != "2" {
+				t.Errorf("Reconcile() LastForceReconcile = %v, want 2", got.Status.LastForceReconcile)
+			}
+
+			// the managed clusters activation is not run again
+			if got.Status.ActivationPhase != v1beta1.ActivationPhaseCompleted ||
+				!reflect.DeepEqual(got.Status.ActivatedClusters, []string{"managed1"}) {
+				t.Errorf("Reconcile() activation = %v %v, want the previous activation",
+					got.Status.ActivationPhase, got.Status.ActivatedClusters)
+			}
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{
+				Name: autoImportSecretName, Namespace: "managed2",
+			}, &corev1.Secret{}); err == nil {
+				t.Errorf("Reconcile() created an auto-import-secret for managed2")
+			}
+			backups := veleroapi.BackupList{}
+			if err := fakeClient.List(context.Background(), &backups); err != nil || len(backups.Items) != 1 {
+				t.Errorf("Reconcile() velero backups = %d, want only the credentials backup, err %v",
+					len(backups.Items), err)
+			}
+
+			// the hub is not cleaned up again
+			if err := fakeClient.Get(context.Background(), types.NamespacedName{
+				Name: "user-creds", Namespace: "default",
+			}, &corev1.Secret{}); err != nil {
+				t.Errorf("Reconcile() deleted the user credentials created after the restore, err %v", err)
+			}
+
+			// the BackupSchedule is not paused
+			if tt.withSchedule {
+				schedule := &v1beta1.BackupSchedule{}
+				if err := fakeClient.Get(context.Background(), types.NamespacedName{
+					Name: "acm-schedule", Namespace: ns,
+				}, schedule); err != nil || schedule.Spec.Paused {
+					t.Errorf("Reconcile() BackupSchedule paused, err %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_filterBackupsByStorageLocation(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "backup-ns"
+	hubA := createStorageLocation("hub-a", ns).prefix("hubs/hub-a").object
+	hubB := createStorageLocation("hub-b", ns).prefix("hubs/hub-b").object
+	local := createStorageLocation("local", ns).provider("velero.io/local").prefix("").object
+	backups := func() *veleroapi.BackupList {
+		return &veleroapi.BackupList{
+			Items: []veleroapi.Backup{
+				*createBackup("acm-resources-schedule-20220922170041", ns).storageLocation("hub-a").object,
+				*createBackup("acm-resources-schedule-20220922180041", ns).storageLocation("hub-b").object,
+				*createBackup("acm-resources-schedule-20220922190041", ns).storageLocation("hub-b").object,
+				*createBackup("acm-resources-schedule-20220922200041", ns).storageLocation("local").object,
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		prefix          string
+		storageLocation string
+		wantErr         bool
+		wantBackups     []string
+	}{
+		{
+			name:   "prefix not set",
+			prefix: "",
+			wantBackups: []string{
+				"acm-resources-schedule-20220922170041",
+				"acm-resources-schedule-20220922180041",
+				"acm-resources-schedule-20220922190041",
+				"acm-resources-schedule-20220922200041",
+			},
+		},
+		{
+			name:        "prefix matching hub-a",
+			prefix:      "hubs/hub-a",
+			wantBackups: []string{"acm-resources-schedule-20220922170041"},
+		},
+		{
+			name:   "prefix matching hub-b with slashes",
+			prefix: "/hubs/hub-b/",
+			wantBackups: []string{
+				"acm-resources-schedule-20220922180041",
+				"acm-resources-schedule-20220922190041",
+			},
+		},
+		{
+			name:    "no storage location with prefix",
+			prefix:  "hubs/hub-c",
+			wantErr: true,
+		},
+		{
+			name:            "file system storage location",
+			storageLocation: "local",
+			wantBackups:     []string{"acm-resources-schedule-20220922200041"},
+		},
+		{
+			name:            "storage location and prefix matching hub-b",
+			prefix:          "hubs/hub-b",
+			storageLocation: "hub-b",
+			wantBackups: []string{
+				"acm-resources-schedule-20220922180041",
+				"acm-resources-schedule-20220922190041",
+			},
+		},
+		{
+			name:            "storage location not matching the prefix",
+			prefix:          "hubs/hub-b",
+			storageLocation: "hub-a",
+			wantErr:         true,
+		},
+		{
+			name:            "storage location not found",
+			storageLocation: "hub-c",
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(hubA, hubB, local).
+				Build()
+
+			restore := createACMRestore("restore", ns).
+				storageLocationPrefix(tt.prefix).
+				storageLocation(tt.storageLocation).object
+			veleroBackups := backups()
+			err := filterBackupsByStorageLocation(context.Background(), fakeClient, restore, veleroBackups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterBackupsByStorageLocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			names := []string{}
+			for i := range veleroBackups.Items {
+				names = append(names, veleroBackups.Items[i].Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantBackups) {
+				t.Errorf("filterBackupsByStorageLocation() backups = %v, want %v", names, tt.wantBackups)
+			}
+		})
+	}
+}
+
+func Test_RestoreReconciler_storageLocation(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		clusterv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
+		}
+	}
+
+	ns := "velero-ns"
+	localTime := metav1.NewTime(time.Date(2022, 9, 22, 17, 0, 41, 0, time.UTC))
+	cloudTime := metav1.NewTime(time.Date(2022, 9, 22, 18, 0, 41, 0, time.UTC))
+	newBackup := func(name, storageLocation string, startTime metav1.Time) *veleroapi.Backup {
+		return createBackup(name, ns).storageLocation(storageLocation).
+			phase(veleroapi.BackupPhaseCompleted).startTimestamp(startTime).object
+	}
+
+	tests := []struct {
+		name                 string
+		storageLocation      string
+		localPhase           veleroapi.BackupStorageLocationPhase
+		wantVeleroRestores   []string
+		wantMessageToContain string
+	}{
+		{
+			name:       "storage location not set, latest backups restored",
+			localPhase: veleroapi.BackupStorageLocationPhaseAvailable,
+			wantVeleroRestores: []string{
+				"restore-acm-credentials-schedule-20220922180041",
+				"restore-acm-resources-schedule-20220922180041",
+			},
+		},
+		{
+			name:            "file system storage location, its backups restored",
+			storageLocation: "local",
+			localPhase:      veleroapi.BackupStorageLocationPhaseAvailable,
+			wantVeleroRestores: []string{
+				"restore-acm-credentials-schedule-20220922170041",
+				"restore-acm-resources-schedule-20220922170041",
+			},
+		},
+		{
+			name:                 "file system storage location not available",
+			storageLocation:      "local",
+			localPhase:           veleroapi.BackupStorageLocationPhaseUnavailable,
+			wantVeleroRestores:   []string{},
+			wantMessageToContain: "Backup storage location local not available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(
+					createACMRestore("restore", ns).
+						cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+						veleroManagedClustersBackupName(skipRestoreStr).
+						veleroCredentialsBackupName(latestBackupStr).
+						veleroResourcesBackupName(latestBackupStr).
+						storageLocation(tt.storageLocation).object,
+					createStorageLocation("default", ns).setOwner().
+						phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+					// a file system storage location, not created by the OADP operator
+					createStorageLocation("local", ns).provider("velero.io/local").prefix("").
+						phase(tt.localPhase).object,
+					newBackup("acm-credentials-schedule-20220922170041", "local", localTime),
+					newBackup("acm-resources-schedule-20220922170041", "local", localTime),
+					newBackup("acm-credentials-schedule-20220922180041", "default", cloudTime),
+					newBackup("acm-resources-schedule-20220922180041", "default", cloudTime),
+				).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+
+			r := &RestoreReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme1,
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, _ = r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			})
+
+			restore := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, restore); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if !strings.Contains(restore.Status.LastMessage, tt.wantMessageToContain) {
+				t.Errorf("Reconcile() message = %v, should contain %v",
+					restore.Status.LastMessage, tt.wantMessageToContain)
+			}
+
+			veleroRestores := veleroapi.RestoreList{}
+			if err := fakeClient.List(context.Background(), &veleroRestores, client.InNamespace(ns)); err != nil {
+				t.Fatalf("Error listing velero restores: %s", err.Error())
+			}
+			names := []string{}
+			for i := range veleroRestores.Items {
+				names = append(names, veleroRestores.Items[i].Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantVeleroRestores) {
+				t.Errorf("Reconcile() velero restores = %v, want %v", names, tt.wantVeleroRestores)
+			}
+		})
+	}
+}
+
+func Test_RestoreReconciler_failFast(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		clusterv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
+		}
+	}
+
+	ns := "velero-ns"
+	credsRestoreName := "restore-acm-credentials-schedule-20220922170041"
+	resourcesRestoreName := "restore-acm-resources-schedule-20220922170041"
+	newRestore := func(status v1beta1.RestoreStatus) *v1beta1.Restore {
+		return createACMRestore("restore", ns).
+			cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+			veleroManagedClustersBackupName(skipRestoreStr).
+			veleroCredentialsBackupName(latestBackupStr).
+			veleroResourcesBackupName(latestBackupStr).
+			failFast(true).
+			restoreACMStatus(status).object
+	}
+	startedStatus := v1beta1.RestoreStatus{
+		Phase:                        v1beta1.RestorePhaseStarted,
+		VeleroCredentialsRestoreName: credsRestoreName,
+	}
+	newCredsRestore := func(phase veleroapi.RestorePhase) *veleroapi.Restore {
+		veleroRestore := createRestore(credsRestoreName, ns).
+			backupName("acm-credentials-schedule-20220922170041").
+			phase(phase).object
+		veleroRestore.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: apiGVStr,
+				Kind:       "Restore",
+				Name:       "restore",
+				UID:        "fed287da-02ea-4c83-a7f8-906ce662451a",
+				Controller: &[]bool{true}[0],
+			},
+		})
+		return veleroRestore
+	}
+
+	tests := []struct {
+		name              string
+		restore           *v1beta1.Restore
+		credsRestore      *veleroapi.Restore
+		wantPhase         v1beta1.RestorePhase
+		wantFailedFast    bool
+		wantVeleroRestore []string
+	}{
+		{
+			name:              "new restore, only the credentials restore is created",
+			restore:           newRestore(v1beta1.RestoreStatus{}),
+			wantPhase:         v1beta1.RestorePhaseStarted,
+			wantVeleroRestore: []string{credsRestoreName},
+		},
+		{
+			name:              "credentials restore in progress, wait",
+			restore:           newRestore(startedStatus),
+			credsRestore:      newCredsRestore(veleroapi.RestorePhaseInProgress),
+			wantPhase:         v1beta1.RestorePhaseStarted,
+			wantVeleroRestore: []string{credsRestoreName},
+		},
+		{
+			name:              "credentials restore failed, restore stopped",
+			restore:           newRestore(startedStatus),
+			credsRestore:      newCredsRestore(veleroapi.RestorePhaseFailed),
+			wantPhase:         v1beta1.RestorePhaseFinishedWithErrors,
+			wantFailedFast:    true,
+			wantVeleroRestore: []string{credsRestoreName},
+		},
+		{
+			name:              "credentials restore partially failed, restore stopped",
+			restore:           newRestore(startedStatus),
+			credsRestore:      newCredsRestore(veleroapi.RestorePhasePartiallyFailed),
+			wantPhase:         v1beta1.RestorePhaseFinishedWithErrors,
+			wantFailedFast:    true,
+			wantVeleroRestore: []string{credsRestoreName},
+		},
+		{
+			name:         "credentials restore completed, the other restores are created",
+			restore:      newRestore(startedStatus),
+			credsRestore: newCredsRestore(veleroapi.RestorePhaseCompleted),
+			// the new velero restore is not processed by velero yet
+			wantPhase:         v1beta1.RestorePhaseUnknown,
+			wantVeleroRestore: []string{credsRestoreName, resourcesRestoreName},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{
+				tt.restore,
+				createStorageLocation("default", ns).setOwner().
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+				createBackup("acm-credentials-schedule-20220922170041", ns).object,
+				createBackup("acm-resources-schedule-20220922170041", ns).object,
+			}
+			if tt.credsRestore != nil {
+				objects = append(objects, tt.credsRestore)
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+
+			r := &RestoreReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme1,
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, _ = r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			})
+
+			restore := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, restore); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if restore.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %v",
+					restore.Status.Phase, tt.wantPhase, restore.Status.LastMessage)
+			}
+			if got := meta.IsStatusConditionTrue(restore.Status.Conditions,
+				v1beta1.RestoreFailedFast); got != tt.wantFailedFast {
+				t.Errorf("Reconcile() %s condition = %v, want %v", v1beta1.RestoreFailedFast, got, tt.wantFailedFast)
+			}
+
+			veleroRestores := veleroapi.RestoreList{}
+			if err := fakeClient.List(context.Background(), &veleroRestores, client.InNamespace(ns)); err != nil {
+				t.Fatalf("Error listing velero restores: %s", err.Error())
+			}
+			names := []string{}
+			for i := range veleroRestores.Items {
+				names = append(names, veleroRestores.Items[i].Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantVeleroRestore) {
+				t.Errorf("Reconcile() velero restores = %v, want %v", names, tt.wantVeleroRestore)
+			}
+		})
+	}
+}
+
+func Test_RestoreReconciler_restoreClusterScopedFirst(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		clusterv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
+		}
+	}
+
+	ns := "velero-ns"
+	resourcesRestoreName := "restore-acm-resources-schedule-20220922170041"
+	clusterScopedRestoreName := resourcesRestoreName + "-" + clusterScopedRestoreSuffix
+	newRestore := func(status v1beta1.RestoreStatus) *v1beta1.Restore {
+		return createACMRestore("restore", ns).
+			cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+			veleroManagedClustersBackupName(skipRestoreStr).
+			veleroCredentialsBackupName(skipRestoreStr).
+			veleroResourcesBackupName(latestBackupStr).
+			restoreClusterScopedFirst(true).
+			restoreACMStatus(status).object
+	}
+	startedStatus := v1beta1.RestoreStatus{
+		Phase:                                   v1beta1.RestorePhaseStarted,
+		VeleroClusterScopedResourcesRestoreName: clusterScopedRestoreName,
+	}
+	newClusterScopedRestore := func(phase veleroapi.RestorePhase) *veleroapi.Restore {
+		veleroRestore := createRestore(clusterScopedRestoreName, ns).
+			backupName("acm-resources-schedule-20220922170041").
+			phase(phase).object
+		veleroRestore.SetLabels(map[string]string{ClusterScopedRestoreLabel: "true"})
+		veleroRestore.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: apiGVStr,
+				Kind:       "Restore",
+				Name:       "restore",
+				UID:        "fed287da-02ea-4c83-a7f8-906ce662451a",
+				Controller: &[]bool{true}[0],
+			},
+		})
+		return veleroRestore
+	}
+
+	tests := []struct {
+		name                 string
+		restore              *v1beta1.Restore
+		clusterScopedRestore *veleroapi.Restore
+		wantPhase            v1beta1.RestorePhase
+		wantVeleroRestore    []string
+	}{
+		{
+			name:              "new restore, only the cluster scoped resources restore is created",
+			restore:           newRestore(v1beta1.RestoreStatus{}),
+			wantPhase:         v1beta1.RestorePhaseStarted,
+			wantVeleroRestore: []string{clusterScopedRestoreName},
+		},
+		{
+			name:                 "cluster scoped resources restore in progress, wait",
+			restore:              newRestore(startedStatus),
+			clusterScopedRestore: newClusterScopedRestore(veleroapi.RestorePhaseInProgress),
+			wantPhase:            v1beta1.RestorePhaseStarted,
+			wantVeleroRestore:    []string{clusterScopedRestoreName},
+		},
+		{
+			name:                 "cluster scoped resources restore failed, restore stopped",
+			restore:              newRestore(startedStatus),
+			clusterScopedRestore: newClusterScopedRestore(veleroapi.RestorePhaseFailed),
+			wantPhase:            v1beta1.RestorePhaseFinishedWithErrors,
+			wantVeleroRestore:    []string{clusterScopedRestoreName},
+		},
+		{
+			name:                 "cluster scoped resources restore completed, the namespaced resources restore is created",
+			restore:              newRestore(startedStatus),
+			clusterScopedRestore: newClusterScopedRestore(veleroapi.RestorePhaseCompleted),
+			// the new velero restore is not processed by velero yet
+			wantPhase:         v1beta1.RestorePhaseUnknown,
+			wantVeleroRestore: []string{resourcesRestoreName, clusterScopedRestoreName},
+		},
+		{
+			name:                 "cluster scoped resources restore partially failed, the namespaced resources restore is created",
+			restore:              newRestore(startedStatus),
+			clusterScopedRestore: newClusterScopedRestore(veleroapi.RestorePhasePartiallyFailed),
+			wantPhase:            v1beta1.RestorePhaseUnknown,
+			wantVeleroRestore:    []string{resourcesRestoreName, clusterScopedRestoreName},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []client.Object{
+				tt.restore,
+				createStorageLocation("default", ns).setOwner().
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+				createBackup("acm-resources-schedule-20220922170041", ns).object,
+			}
+			if tt.clusterScopedRestore != nil {
+				objects = append(objects, tt.clusterScopedRestore)
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+
+			r := &RestoreReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme1,
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, _ = r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			})
+
+			restore := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, restore); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if restore.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %v",
+					restore.Status.Phase, tt.wantPhase, restore.Status.LastMessage)
+			}
+			if restore.Status.VeleroClusterScopedResourcesRestoreName != clusterScopedRestoreName {
+				t.Errorf("Reconcile() cluster scoped restore name = %v, want %v",
+					restore.Status.VeleroClusterScopedResourcesRestoreName, clusterScopedRestoreName)
+			}
+
+			veleroRestores := veleroapi.RestoreList{}
+			if err := fakeClient.List(context.Background(), &veleroRestores, client.InNamespace(ns)); err != nil {
+				t.Fatalf("Error listing velero restores: %s", err.Error())
+			}
+			names := []string{}
+			for i := range veleroRestores.Items {
+				veleroRestore := veleroRestores.Items[i]
+				names = append(names, veleroRestore.Name)
+
+				// the first velero restore restores only the cluster scoped resources,
+				// the second one only the namespaced resources
+				includeClusterResources := veleroRestore.Spec.IncludeClusterResources != nil &&
+					*veleroRestore.Spec.IncludeClusterResources
+				switch {
+				case veleroRestore.Name == resourcesRestoreName && includeClusterResources:
+					t.Errorf("velero restore %s IncludeClusterResources = true, want false", veleroRestore.Name)
+				case veleroRestore.Name == clusterScopedRestoreName && tt.clusterScopedRestore == nil &&
+					(!includeClusterResources ||
+						!reflect.DeepEqual(veleroRestore.Spec.IncludedNamespaces, []string{""})):
+					t.Errorf("velero restore %s IncludeClusterResources = %v, IncludedNamespaces = %v, "+
+						"want only the cluster scoped resources", veleroRestore.Name, includeClusterResources,
+						veleroRestore.Spec.IncludedNamespaces)
+				}
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantVeleroRestore) {
+				t.Errorf("Reconcile() velero restores = %v, want %v", names, tt.wantVeleroRestore)
+			}
+		})
+	}
+}
+
+func Test_setVeleroRestoreNamesFromOwned(t *testing.T) {
+	ns := "velero-ns"
+	newVeleroRestore := func(name, backupName string, created time.Time) veleroapi.Restore {
+		veleroRestore := createRestore(name, ns).backupName(backupName).object
+		veleroRestore.CreationTimestamp = metav1.NewTime(created)
+		return *veleroRestore
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		status         v1beta1.RestoreStatus
+		veleroRestores []veleroapi.Restore
+		wantStatus     v1beta1.RestoreStatus
+	}{
+		{
+			name:       "no owned velero restores, status unchanged",
+			status:     v1beta1.RestoreStatus{VeleroCredentialsRestoreName: "restore-creds"},
+			wantStatus: v1beta1.RestoreStatus{VeleroCredentialsRestoreName: "restore-creds"},
+		},
+		{
+			name:   "status names empty, set from the owned velero restores",
+			status: v1beta1.RestoreStatus{},
+			veleroRestores: []veleroapi.Restore{
+				newVeleroRestore("restore-acm-credentials-schedule-20220922170041",
+					"acm-credentials-schedule-20220922170041", now),
+				newVeleroRestore("restore-acm-resources-schedule-20220922170041",
+					"acm-resources-schedule-20220922170041", now),
+				newVeleroRestore("restore-acm-resources-generic-schedule-20220922170041",
+					"acm-resources-generic-schedule-20220922170041", now),
+				newVeleroRestore("restore-acm-managed-clusters-schedule-20220922170041",
+					"acm-managed-clusters-schedule-20220922170041", now),
+			},
+			wantStatus: v1beta1.RestoreStatus{
+				VeleroCredentialsRestoreName:      "restore-acm-credentials-schedule-20220922170041",
+				VeleroResourcesRestoreName:        "restore-acm-resources-schedule-20220922170041",
+				VeleroGenericResourcesRestoreName: "restore-acm-resources-generic-schedule-20220922170041",
+				VeleroManagedClustersRestoreName:  "restore-acm-managed-clusters-schedule-20220922170041",
+			},
+		},
+		{
+			name: "stale status names, set to the latest owned velero restores",
+			status: v1beta1.RestoreStatus{
+				VeleroCredentialsRestoreName: "restore-acm-credentials-schedule-20220922170041",
+				VeleroResourcesRestoreName:   "restore-acm-resources-schedule-20220922170041",
+			},
+			veleroRestores: []veleroapi.Restore{
+				newVeleroRestore("restore-acm-credentials-schedule-20220922180041",
+					"acm-credentials-schedule-20220922180041", now),
+				newVeleroRestore("restore-acm-credentials-schedule-20220922170041",
+					"acm-credentials-schedule-20220922170041", now.Add(-time.Hour)),
+				newVeleroRestore("restore-acm-resources-schedule-20220922170041",
+					"acm-resources-schedule-20220922170041", now.Add(-time.Hour)),
+				newVeleroRestore("restore-acm-resources-schedule-20220922180041",
+					"acm-resources-schedule-20220922180041", now),
+			},
+			wantStatus: v1beta1.RestoreStatus{
+				VeleroCredentialsRestoreName: "restore-acm-credentials-schedule-20220922180041",
+				VeleroResourcesRestoreName:   "restore-acm-resources-schedule-20220922180041",
+			},
+		},
+		{
+			name:   "activation credentials restore created in the same second",
+			status: v1beta1.RestoreStatus{},
+			veleroRestores: []veleroapi.Restore{
+				newVeleroRestore("restore-acm-credentials-schedule-20220922170041-active",
+					"acm-credentials-schedule-20220922170041", now),
+				newVeleroRestore("restore-acm-credentials-schedule-20220922170041",
+					"acm-credentials-schedule-20220922170041", now),
+			},
+			wantStatus: v1beta1.RestoreStatus{
+				VeleroCredentialsRestoreName: "restore-acm-credentials-schedule-20220922170041-active",
 			},
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", ns).restoreACMStatus(tt.status).object
+			setVeleroRestoreNamesFromOwned(restore, &veleroapi.RestoreList{Items: tt.veleroRestores})
+			if !reflect.DeepEqual(restore.Status, tt.wantStatus) {
+				t.Errorf("setVeleroRestoreNamesFromOwned() status = %+v, want %+v", restore.Status, tt.wantStatus)
+			}
+		})
+	}
+}
 
-	activationLabel := &metav1.LabelSelectorRequirement{}
-	activationLabel.Key = backupCredsClusterLabel
-	activationLabel.Operator = "In"
-	activationLabel.Values = []string{ClusterActivationLabel}
-
-	type args struct {
-		ctx               context.Context
-		c                 client.Client
-		acmRestore        *v1beta1.Restore
-		credsRestore      *veleroapi.Restore
-		managedClsRestore *veleroapi.Restore
+func Test_setVeleroRestoreTimestamps(t *testing.T) {
+	ns := "velero-ns"
+	start := metav1.NewTime(time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC))
+	minutes := func(m int) metav1.Time { return metav1.NewTime(start.Add(time.Duration(m) * time.Minute)) }
+	status := v1beta1.RestoreStatus{
+		VeleroCredentialsRestoreName:      "restore-creds",
+		VeleroResourcesRestoreName:        "restore-resources",
+		VeleroManagedClustersRestoreName:  "restore-clusters",
+		VeleroGenericResourcesRestoreName: "",
 	}
+
 	tests := []struct {
-		name string
-		args args
-		// true if the label activation must be part of the orLabelSelector - for creds and generic restore only!
-		wantActivationOrSelector bool
-		// true if the label activation must be part of the LabelSelector - for creds and generic restore only!
-		wantActivationSelector bool
+		name               string
+		status             v1beta1.RestoreStatus
+		veleroRestores     []veleroapi.Restore
+		wantStart          *metav1.Time
+		wantVeleroComplete *metav1.Time
 	}{
 		{
-			name: "restore passive with OR label",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				acmRestore: createACMRestore("acm-restore", "default").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					restoreORLabelSelector(orLabelSelector).
-					object,
-				credsRestore:      createRestore(veleroScheduleNames[Credentials], "default").object,
-				managedClsRestore: createRestore(veleroScheduleNames[ManagedClusters], "default").object,
+			name:               "no velero restores",
+			status:             v1beta1.RestoreStatus{},
+			wantStart:          nil,
+			wantVeleroComplete: nil,
+		},
+		{
+			name:   "velero restores not started",
+			status: status,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-creds", ns).object,
+				*createRestore("restore-resources", ns).object,
+				*createRestore("restore-clusters", ns).object,
 			},
-			wantActivationOrSelector: true,  // restore passive data, no managed clusters
-			wantActivationSelector:   false, // activation label is on the OR path
+			wantStart:          nil,
+			wantVeleroComplete: nil,
 		},
 		{
-			name: "restore passive with label",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				acmRestore: createACMRestore("acm-restore", "default").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					restoreLabelSelector(&metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							"restorelabel": "value",
-						},
-						MatchExpressions: []metav1.LabelSelectorRequirement{
-							{
-								Key:      "foo",
-								Operator: metav1.LabelSelectorOperator("In"),
-								Values:   []string{"bar"},
-							},
-						},
-					}).
-					object,
-				credsRestore:      createRestore(veleroScheduleNames[Credentials], "default").object,
-				managedClsRestore: createRestore(veleroScheduleNames[ManagedClusters], "default").object,
+			name:   "velero restores running, completion not set",
+			status: status,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-creds", ns).startTimestamp(minutes(2)).completionTimestamp(minutes(5)).object,
+				*createRestore("restore-resources", ns).startTimestamp(minutes(0)).object,
+				*createRestore("restore-clusters", ns).startTimestamp(minutes(1)).completionTimestamp(minutes(3)).object,
 			},
-			wantActivationOrSelector: false, // restore passive data, no managed clusters
-			wantActivationSelector:   true,  // activation label set here
+			wantStart:          &start,
+			wantVeleroComplete: nil,
 		},
 		{
-			name: "restore passive with label",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				acmRestore: createACMRestore("acm-restore", "default").
-					cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-					veleroCredentialsBackupName(latestBackupStr).
-					veleroResourcesBackupName(latestBackupStr).
-					veleroManagedClustersBackupName(skipRestoreStr).
-					object,
-				credsRestore:      createRestore(veleroScheduleNames[Credentials], "default").object,
-				managedClsRestore: createRestore(veleroScheduleNames[ManagedClusters], "default").object,
+			name:   "velero restores completed, earliest start and latest completion",
+			status: status,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-creds", ns).startTimestamp(minutes(2)).completionTimestamp(minutes(5)).object,
+				*createRestore("restore-resources", ns).startTimestamp(minutes(0)).completionTimestamp(minutes(9)).object,
+				*createRestore("restore-clusters", ns).startTimestamp(minutes(1)).completionTimestamp(minutes(3)).object,
 			},
-			wantActivationOrSelector: false, // restore passive data, no managed clusters
-			wantActivationSelector:   true,  // activation label set here
+			wantStart:          &start,
+			wantVeleroComplete: func() *metav1.Time { t := minutes(9); return &t }(),
+		},
+		{
+			name:   "velero restores not named under the status are ignored",
+			status: status,
+			veleroRestores: []veleroapi.Restore{
+				*createRestore("restore-creds", ns).startTimestamp(minutes(2)).completionTimestamp(minutes(5)).object,
+				*createRestore("restore-resources", ns).startTimestamp(minutes(0)).completionTimestamp(minutes(4)).object,
+				*createRestore("restore-clusters", ns).startTimestamp(minutes(1)).completionTimestamp(minutes(3)).object,
+				*createRestore("restore-old", ns).startTimestamp(minutes(-60)).completionTimestamp(minutes(20)).object,
+			},
+			wantStart:          &start,
+			wantVeleroComplete: func() *metav1.Time { t := minutes(5); return &t }(),
 		},
 	}
-
 	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := createACMRestore("restore", ns).restoreACMStatus(tt.status).object
+			setVeleroRestoreTimestamps(restore, &veleroapi.RestoreList{Items: tt.veleroRestores})
+			if !reflect.DeepEqual(restore.Status.StartTimestamp, tt.wantStart) {
+				t.Errorf("setVeleroRestoreTimestamps() StartTimestamp = %v, want %v",
+					restore.Status.StartTimestamp, tt.wantStart)
+			}
+			if !reflect.DeepEqual(restore.Status.VeleroCompletionTimestamp, tt.wantVeleroComplete) {
+				t.Errorf("setVeleroRestoreTimestamps() VeleroCompletionTimestamp = %v, want %v",
+					restore.Status.VeleroCompletionTimestamp, tt.wantVeleroComplete)
+			}
+		})
+	}
+}
 
-		if err := k8sClient1.Create(context.Background(), tt.args.acmRestore); err != nil {
-			t.Errorf("cannot create resource %v", err.Error())
+func Test_RestoreReconciler_ownedVeleroRestores(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		clusterv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
 		}
+	}
 
-		if err := k8sClient1.Create(context.Background(), tt.args.credsRestore); err != nil {
-			t.Errorf("cannot create resource %v", err.Error())
+	ns := "velero-ns"
+	// the velero restores were created but the restore status update failed
+	restore := createACMRestore("restore", ns).
+		cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+		veleroManagedClustersBackupName(skipRestoreStr).
+		veleroCredentialsBackupName(latestBackupStr).
+		veleroResourcesBackupName(latestBackupStr).
+		restoreACMStatus(v1beta1.RestoreStatus{}).object
+	restore.UID = "fed287da-02ea-4c83-a7f8-906ce662451a"
+	objects := []client.Object{
+		restore,
+		createStorageLocation("default", ns).setOwner().
+			phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+		createBackup("acm-credentials-schedule-20220922170041", ns).object,
+		createBackup("acm-resources-schedule-20220922170041", ns).object,
+	}
+	for _, backupName := range []string{
+		"acm-credentials-schedule-20220922170041",
+		"acm-resources-schedule-20220922170041",
+	} {
+		veleroRestore := createRestore("restore-"+backupName, ns).
+			backupName(backupName).
+			phase(veleroapi.RestorePhaseCompleted).object
+		veleroRestore.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: apiGVStr,
+				Kind:       "Restore",
+				Name:       "restore",
+				UID:        restore.UID,
+				Controller: &[]bool{true}[0],
+			},
+		})
+		objects = append(objects, veleroRestore)
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(objects...).
+		WithStatusSubresource(&v1beta1.Restore{}).
+		WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+		Build()
+	r := &RestoreReconciler{
+		Client:   fakeClient,
+		Scheme:   scheme1,
+		Recorder: record.NewFakeRecorder(10),
+	}
+	_, _ = r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+	})
+
+	got := &v1beta1.Restore{}
+	if err := fakeClient.Get(context.Background(),
+		types.NamespacedName{Name: "restore", Namespace: ns}, got); err != nil {
+		t.Fatalf("Error getting restore: %s", err.Error())
+	}
+	if got.Status.VeleroCredentialsRestoreName != "restore-acm-credentials-schedule-20220922170041" ||
+		got.Status.VeleroResourcesRestoreName != "restore-acm-resources-schedule-20220922170041" {
+		t.Errorf("Reconcile() velero restore names = %s, %s, want the owned velero restores",
+			got.Status.VeleroCredentialsRestoreName, got.Status.VeleroResourcesRestoreName)
+	}
+	if got.Status.Phase != v1beta1.RestorePhaseFinished {
+		t.Errorf("Reconcile() phase = %v, want %v, message %v",
+			got.Status.Phase, v1beta1.RestorePhaseFinished, got.Status.LastMessage)
+	}
+
+	veleroRestores := veleroapi.RestoreList{}
+	if err := fakeClient.List(context.Background(), &veleroRestores, client.InNamespace(ns)); err != nil {
+		t.Fatalf("Error listing velero restores: %s", err.Error())
+	}
+	if len(veleroRestores.Items) != 2 {
+		t.Errorf("Reconcile() should not create new velero restores, got %d", len(veleroRestores.Items))
+	}
+}
+
+func Test_RestoreReconciler_orphanedVeleroRestores(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
 		}
-		if err := k8sClient1.Create(context.Background(), tt.args.managedClsRestore); err != nil {
-			t.Errorf("cannot create resource %v", err.Error())
+	}
+
+	ns := "velero-ns"
+	newVeleroRestore := func(name, restoreName string) *veleroapi.Restore {
+		veleroRestore := createRestore(name, ns).object
+		if restoreName != "" {
+			veleroRestore.Labels = map[string]string{RestoreOwnerLabel: restoreName}
 		}
+		return veleroRestore
+	}
+	acmRestore := createACMRestore("acm-restore", ns).object
 
-		// set user filters on both restores, using the acm restore
-		setUserRestoreFilters(tt.args.acmRestore, tt.args.credsRestore)
-		setUserRestoreFilters(tt.args.acmRestore, tt.args.managedClsRestore)
+	tests := []struct {
+		name                    string
+		cleanupOrphanedRestores bool
+		wantOrphaned            []string
+		wantDeleted             []string
+		wantEvents              int
+	}{
+		{
+			name:         "orphaned velero restores reported",
+			wantOrphaned: []string{"restore-deleted-owner", "restore-orphan-deleted-owner"},
+			wantEvents:   2,
+		},
+		{
+			name:                    "orphaned velero restores deleted",
+			cleanupOrphanedRestores: true,
+			wantDeleted:             []string{"restore-deleted-owner", "restore-orphan-deleted-owner"},
+			wantEvents:              2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the Restore deleted with the default propagation policy leaves its owner reference,
+			// the owner reference is removed when deleted with the orphan propagation policy
+			ownedVeleroRestore := newVeleroRestore("restore-deleted-owner", "deleted-restore")
+			ownedVeleroRestore.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: apiGVStr,
+				Kind:       "Restore",
+				Name:       "deleted-restore",
+				UID:        "deleted-restore-uid",
+				Controller: &[]bool{true}[0],
+			}}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(
+				acmRestore.DeepCopy(),
+				newVeleroRestore("restore-owned", "acm-restore"),
+				ownedVeleroRestore,
+				newVeleroRestore("restore-orphan-deleted-owner", "other-deleted-restore"),
+				newVeleroRestore("restore-no-owner", ""),
+			).Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &RestoreReconciler{
+				Client:                  fakeClient,
+				Scheme:                  scheme1,
+				Recorder:                recorder,
+				CleanupOrphanedRestores: tt.cleanupOrphanedRestores,
+			}
 
-		// add activation label to creds restore
-		addRestoreLabelSelector(tt.args.credsRestore, *activationLabel)
+			r.processOrphanedVeleroRestores(context.Background(), ns)
+			// orphaned velero restores are reported only once
+			r.processOrphanedVeleroRestores(context.Background(), ns)
 
-		if hasOrActivationLabel(*tt.args.managedClsRestore) {
-			t.Errorf("managed cluster restore should not have the activation label on OR path ")
+			veleroRestores := veleroapi.RestoreList{}
+			if err := fakeClient.List(context.Background(), &veleroRestores, client.InNamespace(ns)); err != nil {
+				t.Fatalf("Error listing velero restores: %s", err.Error())
+			}
+			gotOrphaned := []string{}
+			gotRestores := []string{}
+			for i := range veleroRestores.Items {
+				gotRestores = append(gotRestores, veleroRestores.Items[i].Name)
+				if _, ok := veleroRestores.Items[i].GetLabels()[OrphanedRestoreLabel]; ok {
+					gotOrphaned = append(gotOrphaned, veleroRestores.Items[i].Name)
+				}
+			}
+			sort.Strings(gotOrphaned)
+			if !reflect.DeepEqual(gotOrphaned, tt.wantOrphaned) && len(gotOrphaned)+len(tt.wantOrphaned) > 0 {
+				t.Errorf("velero restores labeled as orphaned = %v, want %v", gotOrphaned, tt.wantOrphaned)
+			}
+			for _, name := range tt.wantDeleted {
+				if findValue(gotRestores, name) {
+					t.Errorf("velero restore %s should be deleted", name)
+				}
+			}
+			for _, name := range []string{"restore-owned", "restore-no-owner"} {
+				if !findValue(gotRestores, name) {
+					t.Errorf("velero restore %s should not be deleted", name)
+				}
+			}
+			if len(recorder.Events) != tt.wantEvents {
+				t.Errorf("events = %d, want %d", len(recorder.Events), tt.wantEvents)
+			}
+		})
+	}
+}
+
+func Test_pauseAndResumeGitOpsControllers(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := appsv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	ns := "openshift-gitops"
+	int32Ptr := func(value int32) *int32 { return &value }
+	gitOpsDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "openshift-gitops-applicationset-controller",
+			Namespace: ns,
+			Labels:    map[string]string{gitOpsControllerNameLabel: "openshift-gitops-applicationset-controller"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+	}
+	gitOpsStatefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "openshift-gitops-application-controller",
+			Namespace: ns,
+			Labels:    map[string]string{gitOpsControllerNameLabel: "openshift-gitops-application-controller"},
+		},
+		Spec: appsv1.StatefulSetSpec{Replicas: int32Ptr(1)},
+	}
+	otherDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "openshift-gitops-server",
+			Namespace: ns,
+			Labels:    map[string]string{gitOpsControllerNameLabel: "openshift-gitops-server"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(gitOpsDeployment, gitOpsStatefulSet, otherDeployment).
+		Build()
+
+	checkReplicas := func(step string, obj client.Object, wantReplicas int32, wantAnnotation string) {
+		var replicas *int32
+		switch obj.(type) {
+		case *appsv1.StatefulSet:
+			statefulSet := &appsv1.StatefulSet{}
+			if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(obj), statefulSet); err != nil {
+				t.Fatalf("Error getting %s: %s", obj.GetName(), err.Error())
+			}
+			obj, replicas = statefulSet, statefulSet.Spec.Replicas
+		default:
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(obj), deployment); err != nil {
+				t.Fatalf("Error getting %s: %s", obj.GetName(), err.Error())
+			}
+			obj, replicas = deployment, deployment.Spec.Replicas
 		}
-		if hasActivationLabel(*tt.args.managedClsRestore) {
-			t.Errorf("managed cluster restore should not have the activation label ")
+		if replicas == nil || *replicas != wantReplicas {
+			t.Errorf("%s %s replicas = %v, want %v", step, obj.GetName(), replicas, wantReplicas)
 		}
-		credsOrActLabel := hasOrActivationLabel(*tt.args.credsRestore)
-		if credsOrActLabel != tt.wantActivationOrSelector {
-			t.Errorf("creds restore OR activation label should be %v, got %v", tt.wantActivationOrSelector, credsOrActLabel)
+		if got := obj.GetAnnotations()[GitOpsPausedReplicasAnnotation]; got != wantAnnotation {
+			t.Errorf("%s %s annotation = %q, want %q", step, obj.GetName(), got, wantAnnotation)
 		}
+	}
 
-		credsActLabel := hasActivationLabel(*tt.args.credsRestore)
-		if credsActLabel != tt.wantActivationSelector {
-			t.Errorf("creds restore activation label should be %v, got %v", tt.wantActivationSelector, credsActLabel)
+	// the GitOps controllers are scaled down, the other deployments are not changed
+	for i := 0; i < 2; i++ {
+		// pausing again keeps the saved replicas
+		if err := pauseGitOpsControllers(context.Background(), fakeClient); err != nil {
+			t.Fatalf("pauseGitOpsControllers() error = %v", err)
 		}
+	}
+	checkReplicas("pauseGitOpsControllers()", gitOpsDeployment, 0, "2")
+	checkReplicas("pauseGitOpsControllers()", gitOpsStatefulSet, 0, "1")
+	checkReplicas("pauseGitOpsControllers()", otherDeployment, 1, "")
 
-		if err := k8sClient1.Delete(context.Background(), tt.args.acmRestore); err != nil {
-			t.Errorf("cannot delete resource %v", err.Error())
+	// the GitOps controllers are scaled back to the saved replicas
+	if err := resumeGitOpsControllers(context.Background(), fakeClient); err != nil {
+		t.Fatalf("resumeGitOpsControllers() error = %v", err)
+	}
+	checkReplicas("resumeGitOpsControllers()", gitOpsDeployment, 2, "")
+	checkReplicas("resumeGitOpsControllers()", gitOpsStatefulSet, 1, "")
+	checkReplicas("resumeGitOpsControllers()", otherDeployment, 1, "")
+}
+
+func Test_isValidPauseGitOpsOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		restore *v1beta1.Restore
+		want    string
+	}{
+		{
+			name:    "GitOps controllers not paused",
+			restore: createACMRestore("restore", "ns").syncRestoreWithNewBackups(true).object,
+			want:    "",
+		},
+		{
+			name:    "GitOps controllers paused",
+			restore: createACMRestore("restore", "ns").pauseGitOpsDuringRestore(true).object,
+			want:    "",
+		},
+		{
+			name: "GitOps controllers paused with a sync restore",
+			restore: createACMRestore("restore", "ns").pauseGitOpsDuringRestore(true).
+				syncRestoreWithNewBackups(true).object,
+			want: "PauseGitOpsDuringRestore cannot be used together with the SyncRestoreWithNewBackups option",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidPauseGitOpsOption(tt.restore); got != tt.want {
+				t.Errorf("isValidPauseGitOpsOption() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_RestoreReconciler_resumeGitOpsControllers(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		v1beta1.AddToScheme,
+		veleroapi.AddToScheme,
+		appsv1.AddToScheme,
+	} {
+		if err := addToScheme(scheme1); err != nil {
+			t.Fatalf("Error adding api to scheme: %s", err.Error())
 		}
+	}
 
-		if err := k8sClient1.Delete(context.Background(), tt.args.credsRestore); err != nil {
-			t.Errorf("cannot delete resource %v", err.Error())
+	ns := "velero-ns"
+	int32Ptr := func(value int32) *int32 { return &value }
+	newGitOpsDeployment := func(replicas int32, pausedReplicas string) *appsv1.Deployment {
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "openshift-gitops-applicationset-controller",
+				Namespace: "openshift-gitops",
+				Labels:    map[string]string{gitOpsControllerNameLabel: "openshift-gitops-applicationset-controller"},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
 		}
-		if err := k8sClient1.Delete(context.Background(), tt.args.managedClsRestore); err != nil {
-			t.Errorf("cannot delete resource %v", err.Error())
+		if pausedReplicas != "" {
+			deployment.Annotations = map[string]string{GitOpsPausedReplicasAnnotation: pausedReplicas}
 		}
+		return deployment
 	}
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
+
+	tests := []struct {
+		name         string
+		restore      *v1beta1.Restore
+		objects      []client.Object
+		deployment   *appsv1.Deployment
+		wantPhase    v1beta1.RestorePhase
+		wantReplicas int32
+	}{
+		{
+			name: "nothing to restore",
+			restore: createACMRestore("restore", ns).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				pauseGitOpsDuringRestore(true).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(skipRestoreStr).object,
+			deployment:   newGitOpsDeployment(2, ""),
+			wantPhase:    v1beta1.RestorePhaseFinished,
+			wantReplicas: 2,
+		},
+		{
+			name: "point-in-time recovery with an incomplete backup",
+			restore: createACMRestore("restore", ns).
+				cleanupBeforeRestore(v1beta1.CleanupTypeAll).
+				pointInTimeRecovery(true).
+				pauseGitOpsDuringRestore(true).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName("acm-resources-schedule-20220922170041").object,
+			objects: []client.Object{
+				createBackup("acm-resources-schedule-20220922170041", ns).
+					phase(veleroapi.BackupPhasePartiallyFailed).object,
+			},
+			deployment:   newGitOpsDeployment(2, ""),
+			wantPhase:    v1beta1.RestorePhaseFinishedWithErrors,
+			wantReplicas: 2,
+		},
+		{
+			name: "finished restore with the GitOps controllers still paused",
+			restore: createACMRestore("restore", ns).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				pauseGitOpsDuringRestore(true).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(latestBackupStr).
+				phase(v1beta1.RestorePhaseFinished).object,
+			deployment:   newGitOpsDeployment(0, "2"),
+			wantPhase:    v1beta1.RestorePhaseFinished,
+			wantReplicas: 2,
+		},
+		{
+			name: "finished restore without the PauseGitOpsDuringRestore option",
+			restore: createACMRestore("restore", ns).
+				cleanupBeforeRestore(v1beta1.CleanupTypeNone).
+				veleroManagedClustersBackupName(skipRestoreStr).
+				veleroCredentialsBackupName(skipRestoreStr).
+				veleroResourcesBackupName(latestBackupStr).
+				phase(v1beta1.RestorePhaseFinished).object,
+			deployment:   newGitOpsDeployment(0, "2"),
+			wantPhase:    v1beta1.RestorePhaseFinished,
+			wantReplicas: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append([]client.Object{
+				tt.restore,
+				tt.deployment,
+				createStorageLocation("default", ns).setOwner().
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+			}, tt.objects...)
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1beta1.Restore{}).
+				WithIndex(&veleroapi.Restore{}, restoreOwnerKey, indexRestoreOwner).
+				Build()
+			r := &RestoreReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme1,
+				Recorder: record.NewFakeRecorder(10),
+			}
+			_, _ = r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "restore", Namespace: ns},
+			})
+
+			got := &v1beta1.Restore{}
+			if err := fakeClient.Get(context.Background(),
+				types.NamespacedName{Name: "restore", Namespace: ns}, got); err != nil {
+				t.Fatalf("Error getting restore: %s", err.Error())
+			}
+			if got.Status.Phase != tt.wantPhase {
+				t.Errorf("Reconcile() phase = %v, want %v, message %v",
+					got.Status.Phase, tt.wantPhase, got.Status.LastMessage)
+			}
+			deployment := &appsv1.Deployment{}
+			if err := fakeClient.Get(context.Background(),
+				client.ObjectKeyFromObject(tt.deployment), deployment); err != nil {
+				t.Fatalf("Error getting deployment: %s", err.Error())
+			}
+			if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != tt.wantReplicas {
+				t.Errorf("Reconcile() GitOps controller replicas = %v, want %v",
+					deployment.Spec.Replicas, tt.wantReplicas)
+			}
+		})
 	}
 }
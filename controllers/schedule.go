@@ -20,17 +20,29 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/robfig/cron/v3"
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -47,8 +59,15 @@ const (
 	// BackupCollisionPhaseMsg when another cluster is creating backups at the same storage location
 	BackupCollisionPhaseMsg string = "Backup %s, from cluster with id [%s] is using the same storage location." +
 		" This is a backup collision with current cluster [%s] backup." +
-		" Review and resolve the collision then create a new BackupSchedule resource to " +
-		" resume backups from this cluster."
+		" Review and resolve the collision; backups from this cluster resume automatically" +
+		" once the other cluster stops creating backups at this storage location."
+	// StorageUsageWarningMsg when the storage location usage reached the configured threshold
+	StorageUsageWarningMsg string = "Backup storage location %s is using %s out of %s (%d%%)," +
+		" which reached the %d%% warning threshold."
+	// UnownedSchedulesPhaseMsg when velero schedules exist but are not owned by the BackupSchedule
+	UnownedSchedulesPhaseMsg string = "Velero schedules %v exist in namespace %s but are not owned by this" +
+		" BackupSchedule. The schedules are not recreated to avoid duplicate backups." +
+		" Verify the owner reference of these schedules or delete them to have them recreated."
 	// Collision when another hub had run the restore managed cluster operation while this cluster schedule is active
 	BackupCollisionRestoreMsg string = "Hub with id [%s] had run a restore managed cluster operation, see [%s]." +
 		" Current hub is no longer the active cluster so the BackupSchedule is set to backup collision." +
@@ -56,6 +75,27 @@ const (
 		" from this hub, or from the [%s] hub."
 )
 
+const (
+	// VeleroSchedulesFinalizer is set on a BackupSchedule creating velero schedules in another namespace,
+	// these schedules are not garbage collected so they are deleted before the BackupSchedule is removed
+	VeleroSchedulesFinalizer = "cluster.open-cluster-management.io/velero-schedules-cleanup"
+	// BackupStorageCapacityAnnotation is the annotation used to expose the capacity
+	// of a velero.io.BackupStorageLocation, as a quantity, for example 500Gi
+	BackupStorageCapacityAnnotation = "cluster.open-cluster-management.io/storage-capacity"
+	// BackupStorageUsedAnnotation is the annotation used to expose the used storage
+	// of a velero.io.BackupStorageLocation, as a quantity, for example 420Gi
+	BackupStorageUsedAnnotation = "cluster.open-cluster-management.io/storage-used"
+	// default storage usage percentage used to set the StorageUsageWarning condition
+	defaultStorageUsageWarningThreshold = 80
+	// default number of schedule intervals with no backup before the backups are overdue
+	defaultBackupOverdueFactor = 3
+	// default number of consecutive failed backups before the backups are failing
+	defaultBackupFailureThreshold = 3
+)
+
+// backoff used to retry the delete of a velero schedule before moving to the next schedule
+var veleroScheduleDeleteBackoff = retry.DefaultRetry
+
 func updateScheduleStatus(
 	ctx context.Context,
 	veleroSchedule *veleroapi.Schedule,
@@ -69,12 +109,22 @@ func updateScheduleStatus(
 		"namespace", veleroSchedule.Namespace,
 	)
 
-	for key, value := range veleroBackupNames {
-		if veleroSchedule.Name == value {
-			// set veleroSchedule in backupSchedule status
-			setVeleroScheduleInStatus(key, veleroSchedule, backupSchedule)
+	scheduleName := veleroSchedule.Name
+	if veleroSchedule.Namespace != getVeleroNamespace(backupSchedule) {
+		// velero schedule from another target namespace, only listed by name
+		scheduleName = veleroSchedule.Namespace + "/" + veleroSchedule.Name
+	} else {
+		for key, value := range veleroBackupNames {
+			if veleroSchedule.Name == value {
+				// set veleroSchedule in backupSchedule status
+				setVeleroScheduleInStatus(key, veleroSchedule, backupSchedule)
+			}
 		}
 	}
+
+	backupSchedule.Status.VeleroScheduleNames = appendUnique(backupSchedule.Status.VeleroScheduleNames,
+		scheduleName)
+	sort.Strings(backupSchedule.Status.VeleroScheduleNames)
 }
 
 func setVeleroScheduleInStatus(
@@ -101,6 +151,8 @@ func setSchedulePhase(
 		return backupSchedule.Status.Phase
 	}
 
+	defer setScheduleConditions(backupSchedule)
+
 	if schedules == nil || len(schedules.Items) <= 0 {
 		backupSchedule.Status.Phase = v1beta1.SchedulePhaseNew
 		backupSchedule.Status.LastMessage = NewPhaseMsg
@@ -133,6 +185,306 @@ func setSchedulePhase(
 	return backupSchedule.Status.Phase
 }
 
+// set a BackupSchedule status condition, using the resource generation as the observed generation
+func setScheduleCondition(
+	backupSchedule *v1beta1.BackupSchedule,
+	conditionType string,
+	status metav1.ConditionStatus,
+	reason string,
+	msg string,
+) {
+	meta.SetStatusCondition(&backupSchedule.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            msg,
+		ObservedGeneration: backupSchedule.Generation,
+	})
+}
+
+// set the SchedulesReconciled and BackupCollisionDetected conditions
+// based on the current BackupSchedule phase
+func setScheduleConditions(
+	backupSchedule *v1beta1.BackupSchedule,
+) {
+	phase := backupSchedule.Status.Phase
+	msg := backupSchedule.Status.LastMessage
+
+	if phase == v1beta1.SchedulePhaseBackupCollision {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupCollisionDetected,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonBackupCollision, msg)
+	} else {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupCollisionDetected,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonNoBackupCollision, "")
+	}
+
+	switch phase {
+	case v1beta1.SchedulePhaseEnabled:
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesReconciled,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonSchedulesEnabled, msg)
+	case v1beta1.SchedulePhaseNew, v1beta1.SchedulePhaseUnknown:
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesReconciled,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonSchedulesNotReady, msg)
+	case v1beta1.SchedulePhasePaused:
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesReconciled,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonSchedulesPaused, msg)
+	case v1beta1.SchedulePhaseBackupCollision:
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesReconciled,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonBackupCollision, msg)
+	default:
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesReconciled,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonSchedulesFailed, msg)
+	}
+}
+
+// set the BackupsOverdue condition when an enabled velero schedule did not create a backup
+// for longer than BackupOverdueFactor times the interval between two scheduled backups;
+// the creation time of the velero schedule is used if it never created a backup
+func setBackupsOverdueCondition(
+	schedules *veleroapi.ScheduleList,
+	backupSchedule *v1beta1.BackupSchedule,
+	currentTime time.Time,
+) {
+	factor := backupSchedule.Spec.BackupOverdueFactor
+	if factor <= 0 {
+		factor = defaultBackupOverdueFactor
+	}
+
+	overdueSchedules := []string{}
+	for i := range schedules.Items {
+		veleroSchedule := &schedules.Items[i]
+		if veleroSchedule.Status.Phase != veleroapi.SchedulePhaseEnabled || veleroSchedule.Spec.Paused {
+			continue
+		}
+		cronSchedule, err := cron.ParseStandard(veleroSchedule.Spec.Schedule)
+		if err != nil {
+			continue
+		}
+		nextRunTime := cronSchedule.Next(currentTime)
+		interval := cronSchedule.Next(nextRunTime).Sub(nextRunTime)
+
+		lastBackupTime := veleroSchedule.CreationTimestamp.Time
+		if veleroSchedule.Status.LastBackup != nil {
+			lastBackupTime = veleroSchedule.Status.LastBackup.Time
+		}
+		if currentTime.Sub(lastBackupTime) > interval*time.Duration(factor) {
+			lastBackup := "never"
+			if veleroSchedule.Status.LastBackup != nil {
+				lastBackup = lastBackupTime.UTC().Format(time.RFC3339)
+			}
+			overdueSchedules = append(overdueSchedules,
+				fmt.Sprintf("%s (last backup %s)", veleroSchedule.Name, lastBackup))
+		}
+	}
+
+	if len(overdueSchedules) > 0 {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupsOverdue,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonBackupsOverdue,
+			fmt.Sprintf("Velero schedules enabled but with no recent backups: %s",
+				strings.Join(overdueSchedules, ", ")))
+	} else {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupsOverdue,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonBackupsOnTime, "")
+	}
+}
+
+// set the BackupsFailing condition when the last BackupFailureThreshold backups created
+// by a velero schedule on this hub failed or partially failed; backups still running are ignored
+func setBackupsFailingCondition(
+	schedules *veleroapi.ScheduleList,
+	backups []veleroapi.Backup,
+	backupSchedule *v1beta1.BackupSchedule,
+) {
+	threshold := backupSchedule.Spec.BackupFailureThreshold
+	if threshold <= 0 {
+		threshold = defaultBackupFailureThreshold
+	}
+
+	failingSchedules := []string{}
+	for i := range schedules.Items {
+		veleroSchedule := &schedules.Items[i]
+		scheduleBackups := filterBackups(backups, func(bkp veleroapi.Backup) bool {
+			return bkp.Namespace == veleroSchedule.Namespace &&
+				bkp.Labels[BackupVeleroLabel] == veleroSchedule.Name &&
+				bkp.Labels[BackupScheduleClusterLabel] == veleroSchedule.Labels[BackupScheduleClusterLabel] &&
+				isVeleroBackupFinished(&bkp)
+		})
+		if len(scheduleBackups) < threshold {
+			continue
+		}
+		// latest backups first
+		sort.Slice(scheduleBackups, func(i, j int) bool {
+			return getBackupStartTime(&scheduleBackups[j]).Before(getBackupStartTime(&scheduleBackups[i]))
+		})
+
+		errorCount := 0
+		failing := true
+		for j := 0; j < threshold; j++ {
+			if scheduleBackups[j].Status.Phase == veleroapi.BackupPhaseCompleted {
+				failing = false
+				break
+			}
+			errorCount += scheduleBackups[j].Status.Errors
+		}
+		if failing {
+			failingSchedules = append(failingSchedules,
+				fmt.Sprintf("%s (last %d backups failed with %d errors)",
+					veleroSchedule.Labels[BackupScheduleTypeLabel], threshold, errorCount))
+		}
+	}
+
+	if len(failingSchedules) > 0 {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupsFailing,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonBackupsFailing,
+			fmt.Sprintf("Backups failing for the resource types: %s",
+				strings.Join(failingSchedules, ", ")))
+	} else {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupsFailing,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonBackupsSucceeding, "")
+	}
+}
+
+// set the BackupWarnings status and the BackupsWithWarnings condition with the warnings
+// reported by the latest finished backup created by each velero schedule on this hub
+func setBackupWarnings(
+	schedules *veleroapi.ScheduleList,
+	backups []veleroapi.Backup,
+	backupSchedule *v1beta1.BackupSchedule,
+) {
+	var backupWarnings map[string]int
+	warningSchedules := []string{}
+	for i := range schedules.Items {
+		veleroSchedule := &schedules.Items[i]
+		scheduleBackups := filterBackups(backups, func(bkp veleroapi.Backup) bool {
+			return bkp.Namespace == veleroSchedule.Namespace &&
+				bkp.Labels[BackupVeleroLabel] == veleroSchedule.Name &&
+				bkp.Labels[BackupScheduleClusterLabel] == veleroSchedule.Labels[BackupScheduleClusterLabel] &&
+				isVeleroBackupFinished(&bkp)
+		})
+		if len(scheduleBackups) == 0 {
+			continue
+		}
+		// latest backup first
+		sort.Slice(scheduleBackups, func(i, j int) bool {
+			return getBackupStartTime(&scheduleBackups[j]).Before(getBackupStartTime(&scheduleBackups[i]))
+		})
+		if warnings := scheduleBackups[0].Status.Warnings; warnings > 0 {
+			backupType := veleroSchedule.Labels[BackupScheduleTypeLabel]
+			if backupWarnings == nil {
+				backupWarnings = map[string]int{}
+			}
+			backupWarnings[backupType] = warnings
+			warningSchedules = append(warningSchedules,
+				fmt.Sprintf("%s (backup %s with %d warnings)", backupType, scheduleBackups[0].Name, warnings))
+		}
+	}
+	backupSchedule.Status.BackupWarnings = backupWarnings
+
+	if len(warningSchedules) > 0 {
+		sort.Strings(warningSchedules)
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupsWithWarnings,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonBackupWarnings,
+			fmt.Sprintf("Latest backups completed with warnings for the resource types: %s",
+				strings.Join(warningSchedules, ", ")))
+	} else {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionBackupsWithWarnings,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonNoBackupWarnings, "")
+	}
+}
+
+// returns true if the velero backup completed, failed or partially failed
+func isVeleroBackupFinished(backup *veleroapi.Backup) bool {
+	switch backup.Status.Phase {
+	case veleroapi.BackupPhaseCompleted,
+		veleroapi.BackupPhaseFailed,
+		veleroapi.BackupPhasePartiallyFailed,
+		veleroapi.BackupPhaseFailedValidation:
+		return true
+	}
+	return false
+}
+
+// returns the backup start time, or the backup creation time if the backup did not start
+func getBackupStartTime(backup *veleroapi.Backup) time.Time {
+	if backup.Status.StartTimestamp != nil {
+		return backup.Status.StartTimestamp.Time
+	}
+	return backup.CreationTimestamp.Time
+}
+
+// set the storage capacity and usage on the BackupSchedule status, if the
+// available storage location from the preferred namespace exposes this info
+// and set the StorageUsageWarning condition when the usage reaches the threshold
+func updateStorageUsageStatus(
+	ctx context.Context,
+	veleroStorageLocations []veleroapi.BackupStorageLocation,
+	preferredNs string,
+	backupSchedule *v1beta1.BackupSchedule,
+) {
+	scheduleLogger := log.FromContext(ctx)
+
+	backupSchedule.Status.StorageCapacity = ""
+	backupSchedule.Status.StorageUsed = ""
+
+	var storageLocation *veleroapi.BackupStorageLocation
+	for i := range veleroStorageLocations {
+		if veleroStorageLocations[i].Namespace == preferredNs &&
+			veleroStorageLocations[i].Status.Phase == veleroapi.BackupStorageLocationPhaseAvailable {
+			storageLocation = &veleroStorageLocations[i]
+			break
+		}
+	}
+
+	capacityStr := ""
+	usedStr := ""
+	if storageLocation != nil {
+		capacityStr = storageLocation.GetAnnotations()[BackupStorageCapacityAnnotation]
+		usedStr = storageLocation.GetAnnotations()[BackupStorageUsedAnnotation]
+	}
+	if capacityStr == "" || usedStr == "" {
+		// storage location doesn't expose capacity info
+		meta.RemoveStatusCondition(&backupSchedule.Status.Conditions, v1beta1.ScheduleConditionStorageUsageWarning)
+		return
+	}
+
+	capacity, capacityErr := resource.ParseQuantity(capacityStr)
+	used, usedErr := resource.ParseQuantity(usedStr)
+	if capacityErr != nil || usedErr != nil {
+		scheduleLogger.Info("Invalid storage capacity annotations",
+			"name", storageLocation.Name,
+			"capacity", capacityStr,
+			"used", usedStr)
+		meta.RemoveStatusCondition(&backupSchedule.Status.Conditions, v1beta1.ScheduleConditionStorageUsageWarning)
+		return
+	}
+
+	backupSchedule.Status.StorageCapacity = capacity.String()
+	backupSchedule.Status.StorageUsed = used.String()
+
+	if capacity.IsZero() {
+		return
+	}
+
+	threshold := backupSchedule.Spec.StorageUsageWarningThreshold
+	if threshold == 0 {
+		threshold = defaultStorageUsageWarningThreshold
+	}
+	usage := int(used.AsApproximateFloat64() * 100 / capacity.AsApproximateFloat64())
+
+	if usage >= threshold {
+		msg := fmt.Sprintf(StorageUsageWarningMsg, storageLocation.Name,
+			used.String(), capacity.String(), usage, threshold)
+		scheduleLogger.Info(msg)
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionStorageUsageWarning,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonStorageUsageHigh, msg)
+		return
+	}
+	setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionStorageUsageWarning,
+		metav1.ConditionFalse, v1beta1.ScheduleReasonStorageUsageOK, "")
+}
+
+// returns true if the velero schedules spec was updated based on the BackupSchedule spec
+// and sets the SchedulesUpdated condition describing the changed fields
 func isScheduleSpecUpdated(
 	schedules *veleroapi.ScheduleList,
 	backupSchedule *v1beta1.BackupSchedule,
@@ -144,25 +496,86 @@ func isScheduleSpecUpdated(
 		return updated
 	}
 
+	scheduleChanges := []string{}
 	for i := range schedules.Items {
 		veleroSchedule := &schedules.Items[i]
+		changes := []string{}
 
 		// validation backup TTL should be ignored here
 		// since that one is using the schedule's cron job interval
 		if veleroSchedule.Name != veleroScheduleNames[ValidationSchedule] &&
 			veleroSchedule.Spec.Template.TTL.Duration != backupSchedule.Spec.VeleroTTL.Duration {
+			changes = append(changes, fmt.Sprintf("ttl %s -> %s",
+				veleroSchedule.Spec.Template.TTL.Duration, backupSchedule.Spec.VeleroTTL.Duration))
 			veleroSchedule.Spec.Template.TTL = backupSchedule.Spec.VeleroTTL
-			updated = true
 		}
-		if veleroSchedule.Spec.Schedule != backupSchedule.Spec.VeleroSchedule {
-			veleroSchedule.Spec.Schedule = backupSchedule.Spec.VeleroSchedule
+		if veleroSchedule.Spec.Paused != backupSchedule.Spec.Paused {
+			// velero schedule paused when the BackupSchedule was paused
+			changes = append(changes, fmt.Sprintf("paused %t -> %t",
+				veleroSchedule.Spec.Paused, backupSchedule.Spec.Paused))
+			veleroSchedule.Spec.Paused = backupSchedule.Spec.Paused
+		}
+		scheduleCron := getScheduleCron(backupSchedule,
+			ResourceType(veleroSchedule.GetLabels()[BackupScheduleTypeLabel]))
+		if veleroSchedule.Spec.Schedule != scheduleCron {
+			changes = append(changes, fmt.Sprintf("cron %s -> %s",
+				veleroSchedule.Spec.Schedule, scheduleCron))
+			veleroSchedule.Spec.Schedule = scheduleCron
 			if veleroSchedule.Name == veleroScheduleNames[ValidationSchedule] {
 				veleroSchedule.Spec.Template.TTL = getValidationBackupTTL(backupSchedule.Spec.VeleroSchedule)
 			}
+		}
+		if updateScheduleGenerationLabel(veleroSchedule, backupSchedule) {
+			changes = append(changes, "template generation label")
+		}
+		if updateVolumeSnapshotLocations(veleroSchedule, backupSchedule) {
+			changes = append(changes, "template volume snapshot locations")
+		}
+		if updateIncrementalBackup(veleroSchedule, backupSchedule) {
+			changes = append(changes, "template incremental backup")
+		}
+		if veleroSchedule.Name == veleroScheduleNames[Resources] {
+			templateUpdated := updateExcludedAddonNamespaces(veleroSchedule, backupSchedule.Spec.ExcludedAddonNamespaces)
+			templateUpdated = updateIncludedNamespaces(veleroSchedule,
+				backupSchedule.Spec.IncludedNamespaces) || templateUpdated
+			templateUpdated = updateBackupNamespaceExclusion(veleroSchedule, backupSchedule) || templateUpdated
+			if templateUpdated {
+				changes = append(changes, "template namespaces")
+			}
+			if updateResourcesBackupHooks(veleroSchedule, backupSchedule) {
+				changes = append(changes, "template hooks")
+			}
+		}
+		if veleroSchedule.Name == veleroScheduleNames[ManagedClusters] &&
+			updateExcludeLocalCluster(&veleroSchedule.Spec.Template, backupSchedule) {
+			changes = append(changes, "template local cluster exclusion")
+		}
+		if veleroSchedule.Name == veleroScheduleNames[Credentials] &&
+			updateCredentialsOrLabelSelectors(veleroSchedule, backupSchedule.Spec.CredentialsOrLabelSelectors) {
+			changes = append(changes, "template label selectors")
+		}
+		if veleroSchedule.Name == configMapsScheduleName &&
+			updateConfigMapsLabelSelector(veleroSchedule, backupSchedule.Spec.IncludeConfigMapSelector) {
+			changes = append(changes, "template label selector")
+		}
+
+		if len(changes) > 0 {
 			updated = true
+			scheduleChanges = append(scheduleChanges,
+				fmt.Sprintf("%s (%s)", veleroSchedule.Name, strings.Join(changes, ", ")))
 		}
 	}
 
+	if updated {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesUpdated,
+			metav1.ConditionTrue, v1beta1.ScheduleReasonSpecChanged,
+			fmt.Sprintf("Velero schedules updated with the %s spec changes: %s",
+				backupSchedule.Name, strings.Join(scheduleChanges, "; ")))
+	} else {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionSchedulesUpdated,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonSchedulesUpToDate, "")
+	}
+
 	return updated
 }
 
@@ -233,6 +646,16 @@ func parseCronSchedule(
 ) []string {
 	var validationErrors []string
 
+	defer func() {
+		if len(validationErrors) > 0 {
+			setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionCronValid,
+				metav1.ConditionFalse, v1beta1.ScheduleReasonCronInvalid, strings.Join(validationErrors, ","))
+		} else {
+			setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionCronValid,
+				metav1.ConditionTrue, v1beta1.ScheduleReasonCronValid, "")
+		}
+	}()
+
 	// cron.Parse panics if schedule is empty
 	if len(backupSchedule.Spec.VeleroSchedule) == 0 {
 		validationErrors = append(
@@ -320,6 +743,360 @@ func scheduleOwnsLatestStorageBackups(
 	return true, nil, nil
 }
 
+// returns true if the backup collision reported by the BackupSchedule is resolved,
+// meaning the latest backups at the storage location are created by this hub again
+// or the other hub stopped creating backups, and no restore operation was run by another hub
+// after the BackupSchedule was created
+func isBackupCollisionResolved(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+	currentTime time.Time,
+) (bool, error) {
+	// the velero schedules are deleted on collision,
+	// so use the hub id and the BackupSchedule creation time for the checks
+	clusterID, _ := getHubIdentification(ctx, c)
+	veleroSchedule := &veleroapi.Schedule{}
+	veleroSchedule.Namespace = getVeleroNamespace(backupSchedule)
+	veleroSchedule.CreationTimestamp = backupSchedule.CreationTimestamp
+	veleroSchedule.SetLabels(map[string]string{BackupScheduleClusterLabel: clusterID})
+
+	isThisTheOwner, _, err := scheduleOwnsLatestStorageBackups(ctx, c, veleroSchedule)
+	if err != nil {
+		return false, err
+	}
+	if !isThisTheOwner {
+		// this hub creates no backups while in collision, so the latest backups are owned
+		// by this hub again only once all the other hub backups expired; check if the other hub stopped
+		stopped, err := isCollidingHubStopped(ctx, c, backupSchedule, clusterID, currentTime)
+		if err != nil || !stopped {
+			return false, err
+		}
+	}
+
+	restoreCollision, _ := isRestoreHubAfterSchedule(ctx, c, veleroSchedule)
+	return !restoreCollision, nil
+}
+
+// returns true if the hub colliding with this BackupSchedule stopped creating backups:
+// no resources backup from another hub started after the collision was detected,
+// while the resources schedule of this BackupSchedule would have run at least twice since then
+func isCollidingHubStopped(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+	clusterID string,
+	currentTime time.Time,
+) (bool, error) {
+	condition := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+		v1beta1.ScheduleConditionBackupCollisionDetected)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return false, nil
+	}
+	collisionTime := condition.LastTransitionTime.Time
+
+	// the hubs sharing a storage location are expected to use the same backup schedule
+	cronSchedule, err := cron.ParseStandard(getScheduleCron(backupSchedule, Resources))
+	if err != nil {
+		return false, nil
+	}
+	if !currentTime.After(cronSchedule.Next(cronSchedule.Next(collisionTime))) {
+		// the other hub may not have run its schedule yet
+		return false, nil
+	}
+
+	backups := veleroapi.BackupList{}
+	if err := c.List(ctx, &backups,
+		client.MatchingLabels{BackupVeleroLabel: veleroScheduleNames[Resources]}); err != nil {
+		return false, err
+	}
+	for i := range backups.Items {
+		backup := backups.Items[i]
+		if backup.Status.Phase == veleroapi.BackupPhaseDeleting ||
+			backup.Labels[BackupScheduleClusterLabel] == clusterID {
+			continue
+		}
+		if backup.Status.StartTimestamp != nil && backup.Status.StartTimestamp.Time.After(collisionTime) {
+			// the other hub is still creating backups
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// returns the latest completed backup created by the velero schedule on this hub,
+// or nil if there is no completed backup
+func getLatestCompletedBackup(
+	ctx context.Context,
+	c client.Client,
+	veleroSchedule *veleroapi.Schedule,
+) (*veleroapi.Backup, error) {
+	backups := veleroapi.BackupList{}
+	if err := c.List(ctx, &backups,
+		client.InNamespace(veleroSchedule.Namespace),
+		client.MatchingLabels{BackupVeleroLabel: veleroSchedule.Name}); err != nil {
+		return nil, err
+	}
+
+	var latestBackup *veleroapi.Backup
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Status.Phase != veleroapi.BackupPhaseCompleted ||
+			backup.Status.CompletionTimestamp == nil ||
+			backup.Labels[BackupScheduleClusterLabel] != veleroSchedule.Labels[BackupScheduleClusterLabel] {
+			continue
+		}
+		if latestBackup == nil ||
+			backup.Status.CompletionTimestamp.After(latestBackup.Status.CompletionTimestamp.Time) {
+			latestBackup = backup
+		}
+	}
+
+	return latestBackup, nil
+}
+
+// returns the resource types with no velero schedule in the schedules list
+func getMissingVeleroSchedules(
+	schedules *veleroapi.ScheduleList,
+) []ResourceType {
+	missingSchedules := []ResourceType{}
+
+	for resourceType, scheduleName := range veleroScheduleNames {
+		found := false
+		if schedules != nil {
+			for i := range schedules.Items {
+				if schedules.Items[i].Name == scheduleName {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			missingSchedules = append(missingSchedules, resourceType)
+		}
+	}
+	sort.Sort(SortResourceType(missingSchedules))
+
+	return missingSchedules
+}
+
+// returns true if the configmaps schedule must be created or deleted; this schedule exists
+// only if the BackupSchedule IncludeConfigMapSelector option is set
+func isConfigMapsScheduleChanged(
+	schedules *veleroapi.ScheduleList,
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	found := false
+	if schedules != nil {
+		for i := range schedules.Items {
+			if schedules.Items[i].Name == configMapsScheduleName {
+				found = true
+				break
+			}
+		}
+	}
+	return found != (backupSchedule.Spec.IncludeConfigMapSelector != nil)
+}
+
+// returns the names of the velero schedules created for a BackupSchedule which exist
+// in the namespace but are not part of the owned schedules list; this happens if the
+// schedules owner reference was removed or the owner index is not properly set
+func getUnownedVeleroSchedules(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	ownedSchedules *veleroapi.ScheduleList,
+) ([]string, error) {
+	veleroScheduleList := veleroapi.ScheduleList{}
+	if err := c.List(ctx, &veleroScheduleList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	scheduleNames := []string{configMapsScheduleName}
+	for _, scheduleName := range veleroScheduleNames {
+		scheduleNames = append(scheduleNames, scheduleName)
+	}
+
+	unownedSchedules := []string{}
+	for i := range veleroScheduleList.Items {
+		scheduleName := veleroScheduleList.Items[i].Name
+		if !findValue(scheduleNames, scheduleName) {
+			continue
+		}
+		owned := false
+		if ownedSchedules != nil {
+			for j := range ownedSchedules.Items {
+				if ownedSchedules.Items[j].Name == scheduleName &&
+					ownedSchedules.Items[j].Namespace == veleroScheduleList.Items[i].Namespace {
+					owned = true
+					break
+				}
+			}
+		}
+		if !owned {
+			unownedSchedules = append(unownedSchedules, scheduleName)
+		}
+	}
+	sort.Strings(unownedSchedules)
+
+	return unownedSchedules, nil
+}
+
+// set the UnownedSchedulesDetected condition; if unowned velero schedules are found
+// the BackupSchedule phase is set to Unknown
+func setUnownedSchedulesStatus(
+	backupSchedule *v1beta1.BackupSchedule,
+	unownedSchedules []string,
+) {
+	if len(unownedSchedules) == 0 {
+		setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionUnownedSchedulesDetected,
+			metav1.ConditionFalse, v1beta1.ScheduleReasonNoUnownedSchedules, "")
+		return
+	}
+
+	msg := fmt.Sprintf(UnownedSchedulesPhaseMsg, unownedSchedules, backupSchedule.Namespace)
+	backupSchedule.Status.Phase = v1beta1.SchedulePhaseUnknown
+	backupSchedule.Status.LastMessage = msg
+	setScheduleConditions(backupSchedule)
+	setScheduleCondition(backupSchedule, v1beta1.ScheduleConditionUnownedSchedulesDetected,
+		metav1.ConditionTrue, v1beta1.ScheduleReasonUnownedSchedules, msg)
+}
+
+// returns the namespace where the velero schedules are created for this BackupSchedule;
+// with the TargetNamespaces option, this is the first target namespace
+func getVeleroNamespace(
+	backupSchedule *v1beta1.BackupSchedule,
+) string {
+	if len(backupSchedule.Spec.TargetNamespaces) > 0 {
+		return backupSchedule.Spec.TargetNamespaces[0]
+	}
+	if backupSchedule.Spec.VeleroNamespace != "" {
+		return backupSchedule.Spec.VeleroNamespace
+	}
+	return backupSchedule.Namespace
+}
+
+// returns all namespaces where the velero schedules are created for this BackupSchedule,
+// the TargetNamespaces if set, otherwise the namespace returned by getVeleroNamespace
+func getVeleroNamespaces(
+	backupSchedule *v1beta1.BackupSchedule,
+) []string {
+	namespaces := []string{}
+	for _, namespace := range backupSchedule.Spec.TargetNamespaces {
+		namespaces = appendUnique(namespaces, namespace)
+	}
+	if len(namespaces) == 0 {
+		namespaces = append(namespaces, getVeleroNamespace(backupSchedule))
+	}
+	return namespaces
+}
+
+// list the velero schedules created by this BackupSchedule in all velero namespaces; the schedules
+// created in the BackupSchedule namespace are owned by the BackupSchedule, the ones created in another
+// namespace can't have an owner reference and are found using the backup schedule name label
+func listVeleroSchedules(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+	veleroScheduleList *veleroapi.ScheduleList,
+) error {
+	items := []veleroapi.Schedule{}
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		namespaceList := veleroapi.ScheduleList{}
+		listOptions := []client.ListOption{client.InNamespace(veleroNamespace)}
+		if veleroNamespace == backupSchedule.Namespace {
+			listOptions = append(listOptions, client.MatchingFields{scheduleOwnerKey: backupSchedule.Name})
+		} else {
+			listOptions = append(listOptions, client.MatchingLabels{BackupScheduleNameLabel: backupSchedule.Name})
+		}
+		if err := c.List(ctx, &namespaceList, listOptions...); err != nil {
+			return err
+		}
+		items = append(items, namespaceList.Items...)
+	}
+	veleroScheduleList.Items = items
+	return nil
+}
+
+// returns the velero schedules from the namespace
+func filterSchedulesByNamespace(
+	schedules *veleroapi.ScheduleList,
+	namespace string,
+) *veleroapi.ScheduleList {
+	filtered := &veleroapi.ScheduleList{}
+	for i := range schedules.Items {
+		if schedules.Items[i].Namespace == namespace {
+			filtered.Items = append(filtered.Items, schedules.Items[i])
+		}
+	}
+	return filtered
+}
+
+// list the velero backups created by velero schedules in all velero namespaces of this BackupSchedule
+func listVeleroBackups(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+	veleroBackupList *veleroapi.BackupList,
+) error {
+	items := []veleroapi.Backup{}
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		namespaceList := veleroapi.BackupList{}
+		if err := c.List(ctx, &namespaceList, client.InNamespace(veleroNamespace),
+			client.HasLabels{BackupVeleroLabel}); err != nil {
+			return err
+		}
+		items = append(items, namespaceList.Items...)
+	}
+	veleroBackupList.Items = items
+	return nil
+}
+
+// returns true if velero schedules are created in a namespace other than the BackupSchedule namespace
+func hasVeleroSchedulesInOtherNamespace(
+	backupSchedule *v1beta1.BackupSchedule,
+) bool {
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		if veleroNamespace != backupSchedule.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// velero schedules created in another namespace are not garbage collected when the BackupSchedule
+// is deleted; use a finalizer to delete them before the BackupSchedule is removed
+// returns true if the BackupSchedule is being deleted
+func processVeleroSchedulesFinalizer(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+) (bool, error) {
+	if backupSchedule.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(backupSchedule, VeleroSchedulesFinalizer) {
+			return true, nil
+		}
+		veleroScheduleList := veleroapi.ScheduleList{}
+		if err := listVeleroSchedules(ctx, c, backupSchedule, &veleroScheduleList); err != nil {
+			return true, err
+		}
+		// keep the finalizer until all velero schedules are deleted
+		for _, err := range deleteEachVeleroSchedule(ctx, c, &veleroScheduleList) {
+			if !k8serr.IsNotFound(err) {
+				return true, err
+			}
+		}
+		controllerutil.RemoveFinalizer(backupSchedule, VeleroSchedulesFinalizer)
+		return true, c.Update(ctx, backupSchedule)
+	}
+
+	if hasVeleroSchedulesInOtherNamespace(backupSchedule) &&
+		controllerutil.AddFinalizer(backupSchedule, VeleroSchedulesFinalizer) {
+		return false, c.Update(ctx, backupSchedule)
+	}
+	return false, nil
+}
+
 // delete all velero schedules owned by this BackupSchedule
 func deleteVeleroSchedules(
 	ctx context.Context,
@@ -327,15 +1104,52 @@ func deleteVeleroSchedules(
 	backupSchedule *v1beta1.BackupSchedule,
 	schedules *veleroapi.ScheduleList,
 ) error {
-	scheduleLogger := log.FromContext(ctx)
-
 	if schedules == nil || len(schedules.Items) <= 0 {
 		return nil
 	}
 
+	deleteErrors := deleteEachVeleroSchedule(ctx, c, schedules)
+	veleroSchedulesDeleted.WithLabelValues(backupSchedule.Namespace, backupSchedule.Name).
+		Add(float64(len(schedules.Items) - len(deleteErrors)))
+	if len(deleteErrors) == len(schedules.Items) {
+		// none of the schedules could be deleted
+		return kerrors.NewAggregate(deleteErrors)
+	}
+	if len(deleteErrors) > 0 {
+		// the remaining schedules are deleted on the next reconcile
+		return nil
+	}
+
+	backupSchedule.Status.Phase = v1beta1.SchedulePhaseNew
+	backupSchedule.Status.LastMessage = NewPhaseMsg
+	backupSchedule.Status.VeleroScheduleCredentials = nil
+	backupSchedule.Status.VeleroScheduleManagedClusters = nil
+	backupSchedule.Status.VeleroScheduleResources = nil
+	backupSchedule.Status.VeleroScheduleNames = nil
+
+	return nil
+}
+
+// attempts to delete all velero schedules, retrying each delete using the veleroScheduleDeleteBackoff
+// unless the schedule is not found; returns the errors for the schedules which could not be deleted
+func deleteEachVeleroSchedule(
+	ctx context.Context,
+	c client.Client,
+	schedules *veleroapi.ScheduleList,
+) []error {
+	scheduleLogger := log.FromContext(ctx)
+
+	deleteErrors := []error{}
+	if schedules == nil {
+		return deleteErrors
+	}
 	for i := range schedules.Items {
 		veleroSchedule := &schedules.Items[i]
-		err := c.Delete(ctx, veleroSchedule)
+		err := retry.OnError(veleroScheduleDeleteBackoff, func(err error) bool {
+			return !k8serr.IsNotFound(err)
+		}, func() error {
+			return c.Delete(ctx, veleroSchedule)
+		})
 		if err != nil {
 			scheduleLogger.Error(
 				err,
@@ -343,7 +1157,8 @@ func deleteVeleroSchedules(
 				"name", veleroSchedule.Name,
 				"namespace", veleroSchedule.Namespace,
 			)
-			return err
+			deleteErrors = append(deleteErrors, err)
+			continue
 		}
 		scheduleLogger.Info(
 			"Deleted Velero schedule",
@@ -351,14 +1166,7 @@ func deleteVeleroSchedules(
 			"namespace", veleroSchedule.Namespace,
 		)
 	}
-
-	backupSchedule.Status.Phase = v1beta1.SchedulePhaseNew
-	backupSchedule.Status.LastMessage = NewPhaseMsg
-	backupSchedule.Status.VeleroScheduleCredentials = nil
-	backupSchedule.Status.VeleroScheduleManagedClusters = nil
-	backupSchedule.Status.VeleroScheduleResources = nil
-
-	return nil
+	return deleteErrors
 }
 
 // check if there is a restore running on this cluster
@@ -429,6 +1237,10 @@ func createInitialBackupForSchedule(
 			labels[k] = v
 		}
 	}
+	// velero uses the schedule template labels instead of the schedule labels, if defined
+	for k, v := range schedule.Spec.Template.Metadata.Labels {
+		labels[k] = v
+	}
 	labels[BackupVeleroLabel] = schedule.Name
 	veleroBackup.SetLabels(labels)
 	// set spec from schedule spec
@@ -509,6 +1321,7 @@ func createFailedValidationResponse(
 
 	backupSchedule.Status.Phase = v1beta1.SchedulePhaseFailedValidation
 	backupSchedule.Status.LastMessage = msg
+	setScheduleConditions(backupSchedule)
 
 	if requeue {
 		// retry after failureInterval
@@ -540,7 +1353,9 @@ func isVeleroSchedulesUpdateRequired(
 ) (ctrl.Result, bool, error) {
 	scheduleLogger := log.FromContext(ctx)
 
-	// update velero schedules if cron schedule or ttl is changed on the backupSchedule
+	// update velero schedules in place if cron schedule or ttl is changed on the backupSchedule;
+	// velero applies the template changes, such as the ttl, to the next backups, so the schedules
+	// are not recreated and keep their last backup time
 	if isScheduleSpecUpdated(&veleroScheduleList, backupSchedule) {
 		scheduleLogger.Info(
 			fmt.Sprintf("Updating Velero schedules spec based on %s spec ", backupSchedule.Name),
@@ -552,7 +1367,26 @@ func isVeleroSchedulesUpdateRequired(
 				return ctrl.Result{}, true, err
 			}
 		}
-		return ctrl.Result{RequeueAfter: collisionControlInterval}, true, nil
+		// report the changed fields with the SchedulesUpdated condition
+		return ctrl.Result{RequeueAfter: collisionControlInterval}, true, errors.Wrap(
+			c.Status().Update(ctx, backupSchedule),
+			"could not update status",
+		)
+	}
+
+	// update the managed clusters backup namespaces if the ManagedClusterSets or their clusters changed
+	for i := range veleroScheduleList.Items {
+		veleroSchedule := &veleroScheduleList.Items[i]
+		if veleroSchedule.Name == veleroScheduleNames[ManagedClusters] &&
+			setManagedClusterSetsNamespaces(ctx, c, &veleroSchedule.Spec.Template, backupSchedule) {
+			scheduleLogger.Info(
+				fmt.Sprintf("Updating managed cluster set namespaces on Velero schedule %s ", veleroSchedule.Name),
+			)
+			if err := c.Update(ctx, veleroSchedule, &client.UpdateOptions{}); err != nil {
+				return ctrl.Result{}, true, err
+			}
+			return ctrl.Result{RequeueAfter: collisionControlInterval}, true, nil
+		}
 	}
 
 	// update backup resources on velero schedules if any changes in hub resources
@@ -618,3 +1452,164 @@ func isRestoreHubAfterSchedule(
 
 	return false, ""
 }
+
+// returns the access checks required to create the velero schedules
+// and to list the resources being backed up
+func getSchedulePermissionChecks(veleroNamespace string) []authorizationv1.ResourceAttributes {
+	return []authorizationv1.ResourceAttributes{
+		{Verb: "create", Group: veleroapi.SchemeGroupVersion.Group, Resource: "schedules", Namespace: veleroNamespace},
+		{Verb: "list", Group: veleroapi.SchemeGroupVersion.Group, Resource: "backups", Namespace: veleroNamespace},
+		{Verb: "list", Group: "cluster.open-cluster-management.io", Resource: "managedclusters"},
+		{Verb: "list", Group: "", Resource: "secrets"},
+	}
+}
+
+// returns an error naming the first missing permission
+// required to create the velero schedules, or nil if all access checks are allowed
+func validateSchedulePermissions(
+	ctx context.Context,
+	c client.Client,
+	backupSchedule *v1beta1.BackupSchedule,
+) error {
+	attributesList := []authorizationv1.ResourceAttributes{}
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		attributesList = append(attributesList, getSchedulePermissionChecks(veleroNamespace)...)
+	}
+	for _, attributes := range attributesList {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: attributes.DeepCopy(),
+			},
+		}
+		if err := c.Create(ctx, review); err != nil {
+			return errors.Wrap(err, "failed to check permissions")
+		}
+		if review.Status.Allowed {
+			continue
+		}
+
+		resource := attributes.Resource
+		if attributes.Group != "" {
+			resource = resource + "." + attributes.Group
+		}
+		msg := fmt.Sprintf("missing permission to %s %s", attributes.Verb, resource)
+		if attributes.Namespace != "" {
+			msg = msg + " in namespace " + attributes.Namespace
+		}
+		if review.Status.Reason != "" {
+			msg = msg + ": " + review.Status.Reason
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// ExportSchedules returns the velero schedules created by the BackupSchedule resources
+// in the namespace, serialized as a YAML ScheduleList.
+// The status and the server set metadata are removed, so the result can be passed to
+// ImportSchedules to recreate the schedules on this or another hub.
+func ExportSchedules(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+) ([]byte, error) {
+	veleroSchedules := veleroapi.ScheduleList{}
+	if err := c.List(ctx, &veleroSchedules, client.InNamespace(namespace),
+		client.HasLabels{BackupScheduleNameLabel}); err != nil {
+		return nil, errors.Wrap(err, "failed to list velero schedules")
+	}
+
+	exported := veleroapi.ScheduleList{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: veleroapi.SchemeGroupVersion.String(),
+			Kind:       "ScheduleList",
+		},
+	}
+	for i := range veleroSchedules.Items {
+		veleroSchedule := veleroSchedules.Items[i].DeepCopy()
+
+		// the hub id is set again on import, for the hub running the schedules
+		labels := veleroSchedule.GetLabels()
+		delete(labels, BackupScheduleClusterLabel)
+
+		exported.Items = append(exported.Items, veleroapi.Schedule{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: veleroapi.SchemeGroupVersion.String(),
+				Kind:       "Schedule",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        veleroSchedule.Name,
+				Labels:      labels,
+				Annotations: veleroSchedule.GetAnnotations(),
+			},
+			Spec: veleroSchedule.Spec,
+		})
+	}
+	sort.Slice(exported.Items, func(i, j int) bool {
+		return exported.Items[i].Name < exported.Items[j].Name
+	})
+
+	return yaml.Marshal(&exported)
+}
+
+// ImportSchedules creates in the namespace the velero schedules from a manifest
+// returned by ExportSchedules.
+// The schedules are labeled with this hub id and are owned by the BackupSchedule
+// named by their cluster.open-cluster-management.io/backup-schedule-name label,
+// if this BackupSchedule exists in the namespace.
+func ImportSchedules(
+	ctx context.Context,
+	c client.Client,
+	namespace string,
+	manifest []byte,
+) error {
+	veleroSchedules := veleroapi.ScheduleList{}
+	if err := yaml.UnmarshalStrict(manifest, &veleroSchedules); err != nil {
+		return errors.Wrap(err, "failed to parse velero schedules")
+	}
+
+	clusterID, _ := getHubIdentification(ctx, c)
+	for i := range veleroSchedules.Items {
+		veleroSchedule := veleroSchedules.Items[i].DeepCopy()
+		veleroSchedule.Namespace = namespace
+		veleroSchedule.ResourceVersion = ""
+		veleroSchedule.OwnerReferences = nil
+
+		labels := veleroSchedule.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[BackupScheduleClusterLabel] = clusterID
+		veleroSchedule.SetLabels(labels)
+
+		if err := setImportedScheduleOwner(ctx, c, veleroSchedule); err != nil {
+			return errors.Wrapf(err, "failed to import velero schedule %s", veleroSchedule.Name)
+		}
+
+		if err := c.Create(ctx, veleroSchedule); err != nil {
+			return errors.Wrapf(err, "failed to import velero schedule %s", veleroSchedule.Name)
+		}
+	}
+	return nil
+}
+
+// sets the BackupSchedule named by the backup-schedule-name label as the controller
+// of the imported velero schedule, if this BackupSchedule exists in the schedule namespace
+func setImportedScheduleOwner(
+	ctx context.Context,
+	c client.Client,
+	veleroSchedule *veleroapi.Schedule,
+) error {
+	backupScheduleName := veleroSchedule.GetLabels()[BackupScheduleNameLabel]
+	if backupScheduleName == "" {
+		return nil
+	}
+	backupSchedule := &v1beta1.BackupSchedule{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Name:      backupScheduleName,
+		Namespace: veleroSchedule.Namespace,
+	}, backupSchedule); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return ctrl.SetControllerReference(backupSchedule, veleroSchedule, c.Scheme())
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	v1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -34,7 +35,9 @@ import (
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -62,6 +65,8 @@ const (
 	// these are user resources, except secrets, labeled with cluster.open-cluster-management.io/backup
 	// secrets labeled with cluster.open-cluster-management.io/backup are already backed up under credentialsCluster
 	ResourcesGeneric ResourceType = "resourcesGeneric"
+	// ConfigMaps resource type for the application configmaps selected by the IncludeConfigMapSelector option
+	ConfigMaps ResourceType = "configMaps"
 
 	msa_kind  = "ManagedServiceAccount"
 	msa_group = "authentication.open-cluster-management.io"
@@ -80,6 +85,10 @@ const (
 )
 const updateStatusFailedMsg = "Could not update status"
 
+// errInvalidVeleroSchedule is returned when the velero schedules are not created
+// because the BackupSchedule VeleroSchedule is empty or not a valid cron expression
+var errInvalidVeleroSchedule = errors.New("invalid VeleroSchedule")
+
 const (
 	failureInterval          = time.Second * 60
 	collisionControlInterval = time.Minute * 5
@@ -92,6 +101,7 @@ type BackupScheduleReconciler struct {
 	DiscoveryClient discovery.DiscoveryInterface
 	DynamicClient   dynamic.Interface
 	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
 }
 
 //nolint:lll
@@ -105,6 +115,9 @@ type BackupScheduleReconciler struct {
 //+kubebuilder:rbac:groups=velero.io,resources=backups,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=velero.io,resources=backupstoragelocations,verbs=get;list;watch
 //+kubebuilder:rbac:groups=velero.io,resources=deletebackuprequests,verbs=create;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;update
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -115,7 +128,11 @@ type BackupScheduleReconciler struct {
 func (r *BackupScheduleReconciler) Reconcile(
 	ctx context.Context,
 	req ctrl.Request,
-) (ctrl.Result, error) {
+) (result ctrl.Result, err error) {
+	defer func(start time.Time) {
+		recordReconcileMetrics(scheduleControllerName, start, err)
+	}(time.Now())
+
 	scheduleLogger := log.FromContext(ctx)
 
 	// velero doesn't delete expired backups if they are in FailedValidation
@@ -126,6 +143,9 @@ func (r *BackupScheduleReconciler) Reconcile(
 	)
 
 	backupSchedule := &v1beta1.BackupSchedule{}
+	defer r.updateLastReconcileTime(ctx, backupSchedule)
+	defer recordBackupSchedulePhase(req.NamespacedName, backupSchedule)
+
 	if result, validConfiguration, err := r.isValidateConfiguration(ctx, mapper,
 		req,
 		backupSchedule); !validConfiguration {
@@ -138,6 +158,7 @@ func (r *BackupScheduleReconciler) Reconcile(
 	if len(errs) > 0 {
 		backupSchedule.Status.Phase = v1beta1.SchedulePhaseFailedValidation
 		backupSchedule.Status.LastMessage = strings.Join(errs, ",")
+		setScheduleConditions(backupSchedule)
 
 		return ctrl.Result{}, errors.Wrap(
 			r.Client.Status().Update(ctx, backupSchedule),
@@ -147,15 +168,32 @@ func (r *BackupScheduleReconciler) Reconcile(
 
 	// retrieve the velero schedules (if any)
 	veleroScheduleList := veleroapi.ScheduleList{}
-	if err := r.List(
-		ctx,
-		&veleroScheduleList,
-		client.InNamespace(req.Namespace),
-		client.MatchingFields{scheduleOwnerKey: req.Name},
-	); err != nil {
+	if err := listVeleroSchedules(ctx, r.Client, backupSchedule, &veleroScheduleList); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// if no owned velero schedules are found, verify there are no schedules with the same name
+	// which are not owned by this BackupSchedule, before creating them
+	unownedSchedules := []string{}
+	if len(veleroScheduleList.Items) == 0 {
+		for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+			namespaceUnowned, err := getUnownedVeleroSchedules(ctx, r.Client, veleroNamespace, &veleroScheduleList)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			unownedSchedules = append(unownedSchedules, namespaceUnowned...)
+		}
+	}
+	setUnownedSchedulesStatus(backupSchedule, unownedSchedules)
+	if len(unownedSchedules) > 0 {
+		scheduleLogger.Info("Velero schedules not owned by this BackupSchedule",
+			"schedules", unownedSchedules)
+		return ctrl.Result{RequeueAfter: collisionControlInterval}, errors.Wrap(
+			r.Client.Status().Update(ctx, backupSchedule),
+			updateStatusFailedMsg,
+		)
+	}
+
 	if backupSchedule.Spec.Paused {
 		// backup schedule is paused
 		msg := "BackupSchedule is paused."
@@ -185,6 +223,7 @@ func (r *BackupScheduleReconciler) Reconcile(
 				veleroScheduleList.Items[0].GetLabels()[BackupScheduleClusterLabel],
 			)
 			scheduleLogger.Info(collisionMsg)
+			r.reportBackupCollision(backupSchedule, lastBackup.GetLabels()[BackupScheduleClusterLabel], collisionMsg)
 		} else {
 			// check if an existing hub restore was created
 			// after this backup schedule and report a collision
@@ -202,10 +241,41 @@ func (r *BackupScheduleReconciler) Reconcile(
 		return updateBackupSchedulePhaseWhenPaused(ctx, r.Client, veleroScheduleList,
 			backupSchedule, v1beta1.SchedulePhaseBackupCollision, collisionMsg)
 	}
+
+	// if any velero schedule is deleted manually, recreate them all to have the same backup due time
+	// the schedules are also recreated when the configmaps schedule is added or removed
+	missingSchedules := []ResourceType{}
+	configMapsScheduleChanged := false
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		namespaceSchedules := filterSchedulesByNamespace(&veleroScheduleList, veleroNamespace)
+		missingSchedules = append(missingSchedules, getMissingVeleroSchedules(namespaceSchedules)...)
+		configMapsScheduleChanged = isConfigMapsScheduleChanged(namespaceSchedules, backupSchedule) ||
+			configMapsScheduleChanged
+	}
+	if len(veleroScheduleList.Items) > 0 && (len(missingSchedules) > 0 || configMapsScheduleChanged) {
+		scheduleLogger.Info("Velero schedules not found or no longer required, recreate all schedules",
+			"missing", missingSchedules, "configMapsScheduleChanged", configMapsScheduleChanged)
+		if err := deleteVeleroSchedules(ctx, r.Client, backupSchedule, &veleroScheduleList); err != nil {
+			return ctrl.Result{}, err
+		}
+		veleroScheduleList.Items = nil
+	}
+
 	// no velero schedules, so create them
 	if len(veleroScheduleList.Items) == 0 {
 		clusterID, _ := getHubIdentification(ctx, r.Client)
 		err := r.initVeleroSchedules(ctx, mapper, backupSchedule, clusterID)
+		if errors.Is(err, errInvalidVeleroSchedule) {
+			// no velero schedule was created, wait for the user to fix the schedule
+			backupSchedule.Status.Phase = v1beta1.SchedulePhaseFailedValidation
+			backupSchedule.Status.LastMessage = err.Error()
+			setScheduleConditions(backupSchedule)
+
+			return ctrl.Result{}, errors.Wrap(
+				r.Client.Status().Update(ctx, backupSchedule),
+				updateStatusFailedMsg,
+			)
+		}
 		if err != nil {
 			msg := fmt.Errorf(FailedPhaseMsg+": %v", err)
 			scheduleLogger.Error(err, err.Error())
@@ -215,6 +285,7 @@ func (r *BackupScheduleReconciler) Reconcile(
 			backupSchedule.Status.LastMessage = NewPhaseMsg
 			backupSchedule.Status.Phase = v1beta1.SchedulePhaseNew
 		}
+		setScheduleConditions(backupSchedule)
 		statusUpdateErr := r.Client.Status().Update(ctx, backupSchedule)
 		if err == nil { // Don't mask previous error
 			err = statusUpdateErr
@@ -222,30 +293,37 @@ func (r *BackupScheduleReconciler) Reconcile(
 		return ctrl.Result{RequeueAfter: collisionControlInterval}, err
 	}
 
-	// if any velero schedule is deleted manually, recreate them all to have the same backup due time
-	if len(veleroScheduleList.Items) < len(veleroScheduleNames) {
-		if err := deleteVeleroSchedules(ctx, r.Client, backupSchedule, &veleroScheduleList); err != nil {
-			return ctrl.Result{}, err
-		}
-		return ctrl.Result{RequeueAfter: collisionControlInterval}, errors.Wrap(
-			r.Client.Status().Update(ctx, backupSchedule),
-			updateStatusFailedMsg,
-		)
-	}
-
 	// check for any updates that are required for velero schedules based on backupSchedule and hub resources
 	if result, updated, err := isVeleroSchedulesUpdateRequired(ctx, r.Client,
-		getResourcesToBackup(ctx, r.DiscoveryClient), veleroScheduleList, backupSchedule); updated {
+		getScheduleResourcesToBackup(ctx, r.DiscoveryClient, backupSchedule), veleroScheduleList,
+		backupSchedule); updated {
 		return result, err
 	}
 
+	// delete backups older than the retention duration, if set and cleanup is not disabled
+	cleanupBackups(ctx, r.Client, backupSchedule,
+		veleroScheduleList.Items[0].GetLabels()[BackupScheduleClusterLabel])
+
+	if backupSchedule.Spec.EmitBackupCompletedEvents {
+		r.emitBackupCompletedEvents(ctx, backupSchedule, &veleroScheduleList)
+	}
+
 	// velero schedules already exist, update schedule status with latest velero schedules
+	backupSchedule.Status.VeleroScheduleNames = nil
 	for i := range veleroScheduleList.Items {
 		updateScheduleStatus(ctx, &veleroScheduleList.Items[i], backupSchedule)
 	}
 	setSchedulePhase(&veleroScheduleList, backupSchedule)
+	setBackupsOverdueCondition(&veleroScheduleList, backupSchedule, time.Now())
+	veleroBackupList := veleroapi.BackupList{}
+	if err := listVeleroBackups(ctx, r.Client, backupSchedule, &veleroBackupList); err != nil {
+		scheduleLogger.Error(err, "Error listing velero backups")
+	} else {
+		setBackupsFailingCondition(&veleroScheduleList, veleroBackupList.Items, backupSchedule)
+		setBackupWarnings(&veleroScheduleList, veleroBackupList.Items, backupSchedule)
+	}
 
-	err := r.Client.Status().Update(ctx, backupSchedule)
+	err = r.Client.Status().Update(ctx, backupSchedule)
 	return ctrl.Result{RequeueAfter: collisionControlInterval}, errors.Wrap(
 		err,
 		fmt.Sprintf(
@@ -270,9 +348,26 @@ func (r *BackupScheduleReconciler) isValidateConfiguration(
 		return ctrl.Result{}, validConfiguration, client.IgnoreNotFound(err)
 	}
 
+	if deleted, err := processVeleroSchedulesFinalizer(ctx, r.Client, backupSchedule); deleted || err != nil {
+		return ctrl.Result{}, validConfiguration, err
+	}
+
 	if backupSchedule.Status.Phase == v1beta1.SchedulePhaseBackupCollision {
-		scheduleLogger.Info("ignore resource in SchedulePhaseBackupCollision state")
-		return ctrl.Result{}, validConfiguration, nil
+		// check periodically if the collision was resolved
+		resolved, err := isBackupCollisionResolved(ctx, r.Client, backupSchedule, time.Now())
+		if err != nil {
+			return ctrl.Result{}, validConfiguration, err
+		}
+		if !resolved {
+			scheduleLogger.Info("ignore resource in SchedulePhaseBackupCollision state")
+			return ctrl.Result{RequeueAfter: collisionControlInterval}, validConfiguration, nil
+		}
+		// the velero schedules were deleted when the collision was found,
+		// so they are created again
+		scheduleLogger.Info("backup collision resolved, resume backups")
+		backupSchedule.Status.Phase = v1beta1.SchedulePhaseNew
+		backupSchedule.Status.LastMessage = NewPhaseMsg
+		setScheduleConditions(backupSchedule)
 	}
 
 	// don't create schedule if an active restore exists
@@ -299,10 +394,13 @@ func (r *BackupScheduleReconciler) isValidateConfiguration(
 
 	// look for available VeleroStorageLocation
 	// and keep track of the velero oadp namespace
-	isValidStorageLocation := isValidStorageLocationDefined(
-		veleroStorageLocations.Items,
-		req.Namespace,
-	)
+	isValidStorageLocation := true
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		if !isValidStorageLocationDefined(veleroStorageLocations.Items, veleroNamespace) {
+			isValidStorageLocation = false
+			break
+		}
+	}
 
 	// if no valid storage location found wait for valid value
 	if !isValidStorageLocation {
@@ -312,6 +410,38 @@ func (r *BackupScheduleReconciler) isValidateConfiguration(
 			msg, true)
 	}
 
+	// don't create schedules if the included namespaces are also excluded
+	if len(backupSchedule.Spec.IncludedNamespaces) > 0 {
+		localClusterName, err := getLocalClusterName(ctx, r.Client)
+		if err != nil || localClusterName == "" {
+			localClusterName = localClusterLabel
+		}
+		if msg := validateIncludedNamespaces(backupSchedule, localClusterName); msg != "" {
+			return createFailedValidationResponse(ctx, r.Client, backupSchedule,
+				msg, false)
+		}
+	}
+
+	if len(backupSchedule.Spec.TargetNamespaces) > 0 && backupSchedule.Spec.VeleroNamespace != "" {
+		msg := "TargetNamespaces can't be used with the VeleroNamespace option."
+		return createFailedValidationResponse(ctx, r.Client, backupSchedule,
+			msg, false)
+	}
+
+	if msg := validateCredentialsOrLabelSelectors(backupSchedule); msg != "" {
+		return createFailedValidationResponse(ctx, r.Client, backupSchedule,
+			msg, false)
+	}
+
+	if backupSchedule.Spec.MaxBackupRetentionDuration.Duration < 0 {
+		msg := "MaxBackupRetentionDuration must be a positive duration."
+		return createFailedValidationResponse(ctx, r.Client, backupSchedule,
+			msg, false)
+	}
+
+	// report storage capacity and usage, if exposed by the storage location
+	updateStorageUsageStatus(ctx, veleroStorageLocations.Items, getVeleroNamespace(backupSchedule), backupSchedule)
+
 	// check MSA status for backup schedules
 	return verifyMSAOption(ctx, r.Client, mapper, backupSchedule)
 }
@@ -327,7 +457,19 @@ func (r *BackupScheduleReconciler) initVeleroSchedules(
 ) error {
 	scheduleLogger := log.FromContext(ctx)
 
-	resourcesToBackup := getResourcesToBackup(ctx, r.DiscoveryClient)
+	// never create velero schedules with an empty or invalid cron schedule
+	if errs := parseCronSchedule(ctx, backupSchedule); len(errs) > 0 {
+		return errors.Wrap(errInvalidVeleroSchedule, strings.Join(errs, ","))
+	}
+
+	// check the operator is allowed to create the velero schedules before creating them
+	if backupSchedule.Spec.ValidatePermissions {
+		if err := validateSchedulePermissions(ctx, r.Client, backupSchedule); err != nil {
+			return err
+		}
+	}
+
+	resourcesToBackup := getScheduleResourcesToBackup(ctx, r.DiscoveryClient, backupSchedule)
 
 	// sort schedule names to create first the credentials schedules, then clusters, last resources
 	scheduleKeys := make([]ResourceType, 0, len(veleroScheduleNames))
@@ -341,6 +483,10 @@ func (r *BackupScheduleReconciler) initVeleroSchedules(
 		// swap resources and resourcesGeneric, so resources is the last backup to be created
 		swapF(2, 3)
 	}
+	if backupSchedule.Spec.IncludeConfigMapSelector != nil {
+		// create the configmaps schedule after the credentials schedule
+		scheduleKeys = append(scheduleKeys[:1], append([]ResourceType{ConfigMaps}, scheduleKeys[1:]...)...)
+	}
 
 	// add any missing labels and create any resources required by the backup and restore process
 	err := r.prepareForBackup(ctx, mapper, backupSchedule)
@@ -358,93 +504,114 @@ func (r *BackupScheduleReconciler) initVeleroSchedules(
 		localClusterName = localClusterLabel
 	}
 
-	// loop through schedule names to create a Velero schedule per type
-	for _, scheduleKey := range scheduleKeys {
-		veleroScheduleIdentity := types.NamespacedName{
-			Namespace: backupSchedule.Namespace,
-			Name:      veleroScheduleNames[scheduleKey],
-		}
+	// loop through the velero namespaces and schedule names to create a Velero schedule per type
+	for _, veleroNamespace := range getVeleroNamespaces(backupSchedule) {
+		for _, scheduleKey := range scheduleKeys {
+			veleroScheduleIdentity := types.NamespacedName{
+				Namespace: veleroNamespace,
+				Name:      veleroScheduleNames[scheduleKey],
+			}
+			if scheduleKey == ConfigMaps {
+				veleroScheduleIdentity.Name = configMapsScheduleName
+			}
 
-		veleroSchedule := &veleroapi.Schedule{}
-		veleroSchedule.Name = veleroScheduleIdentity.Name
-		veleroSchedule.Namespace = veleroScheduleIdentity.Namespace
+			veleroSchedule := &veleroapi.Schedule{}
+			veleroSchedule.Name = veleroScheduleIdentity.Name
+			veleroSchedule.Namespace = veleroScheduleIdentity.Namespace
 
-		// set backup schedule name as label annotation
-		labels := veleroSchedule.GetLabels()
-		if labels == nil {
-			labels = make(map[string]string)
-		}
-		labels[BackupScheduleNameLabel] = backupSchedule.Name
-		labels[BackupScheduleTypeLabel] = string(scheduleKey)
-		// set cluster uid
-		labels[BackupScheduleClusterLabel] = clusterID
-
-		veleroSchedule.SetLabels(labels)
-
-		// create backup based on resource type
-		veleroBackupTemplate := &veleroapi.BackupSpec{}
-		if scheduleKey == ManagedClusters || scheduleKey == Resources {
-			veleroBackupTemplate.ExcludedNamespaces = appendUnique(
-				veleroBackupTemplate.ExcludedNamespaces,
-				localClusterName,
-			)
-		}
-
-		switch scheduleKey {
-		case ManagedClusters:
-			setManagedClustersBackupInfo(veleroBackupTemplate, resourcesToBackup)
-		case Credentials:
-			setCredsBackupInfo(veleroBackupTemplate)
-		case Resources:
-			setResourcesBackupInfo(ctx, veleroBackupTemplate, resourcesToBackup,
-				backupSchedule.Namespace, r.Client)
-		case ResourcesGeneric:
-			setGenericResourcesBackupInfo(veleroBackupTemplate, resourcesToBackup)
-		case ValidationSchedule:
-			veleroBackupTemplate = setValidationBackupInfo(
-				veleroBackupTemplate,
-				backupSchedule,
-			)
-		}
+			// set backup schedule name as label annotation
+			labels := veleroSchedule.GetLabels()
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[BackupScheduleNameLabel] = backupSchedule.Name
+			labels[BackupScheduleTypeLabel] = string(scheduleKey)
+			// set cluster uid
+			labels[BackupScheduleClusterLabel] = clusterID
+
+			veleroSchedule.SetLabels(labels)
+
+			// create backup based on resource type
+			veleroBackupTemplate := &veleroapi.BackupSpec{}
+			if scheduleKey == ManagedClusters || scheduleKey == Resources {
+				veleroBackupTemplate.ExcludedNamespaces = appendUnique(
+					veleroBackupTemplate.ExcludedNamespaces,
+					localClusterName,
+				)
+			}
 
-		if len(backupSchedule.Spec.VolumeSnapshotLocations) > 0 {
-			veleroBackupTemplate.VolumeSnapshotLocations = backupSchedule.Spec.VolumeSnapshotLocations
-		}
-		if backupSchedule.Spec.UseOwnerReferencesInBackup {
-			veleroSchedule.Spec.UseOwnerReferencesInBackup = &backupSchedule.Spec.UseOwnerReferencesInBackup
-		}
-		if backupSchedule.Spec.SkipImmediately {
-			veleroSchedule.Spec.SkipImmediately = &backupSchedule.Spec.SkipImmediately
-		}
-		veleroSchedule.Spec.Template = *veleroBackupTemplate
-		veleroSchedule.Spec.Schedule = backupSchedule.Spec.VeleroSchedule
-		if backupSchedule.Spec.VeleroTTL.Duration != 0 && scheduleKey != ValidationSchedule {
-			// TTL for a validation backup is already set using the cron job interval
-			veleroSchedule.Spec.Template.TTL = backupSchedule.Spec.VeleroTTL
-		}
-		// this is always successful since veleroSchedule is defined now
-		if err := ctrl.SetControllerReference(backupSchedule, veleroSchedule, r.Scheme); err == nil {
-			err := r.Create(ctx, veleroSchedule, &client.CreateOptions{})
-			if err != nil {
-				scheduleLogger.Error(
-					err,
-					"Error in creating velero.io.Schedule",
-					"name", veleroScheduleIdentity.Name,
-					"namespace", veleroScheduleIdentity.Namespace,
+			switch scheduleKey {
+			case ManagedClusters:
+				setManagedClustersBackupInfo(ctx, veleroBackupTemplate, resourcesToBackup, backupSchedule, r.Client)
+			case Credentials:
+				setCredsBackupInfo(veleroBackupTemplate, backupSchedule.Spec.CredentialsOrLabelSelectors)
+			case Resources:
+				setResourcesBackupInfo(ctx, veleroBackupTemplate, resourcesToBackup,
+					getResourcesBackupExcludedNamespace(backupSchedule), r.Client)
+				veleroBackupTemplate.Hooks = getResourcesBackupHooks(backupSchedule)
+			case ResourcesGeneric:
+				setGenericResourcesBackupInfo(veleroBackupTemplate, resourcesToBackup)
+			case ConfigMaps:
+				setConfigMapsBackupInfo(veleroBackupTemplate, backupSchedule.Spec.IncludeConfigMapSelector)
+			case ValidationSchedule:
+				veleroBackupTemplate = setValidationBackupInfo(
+					veleroBackupTemplate,
+					backupSchedule,
 				)
-				return err
 			}
-			scheduleLogger.Info(
-				"Velero schedule created",
-				"name", veleroSchedule.Name,
-				"namespace", veleroSchedule.Namespace,
-			)
 
-			// set veleroSchedule in backupSchedule status
-			setVeleroScheduleInStatus(scheduleKey, veleroSchedule, backupSchedule)
-			// if initial backup needs to be created, process it here
-			createInitialBackupForSchedule(ctx, r.Client, r.Scheme,
-				veleroSchedule, backupSchedule, currentTime)
+			veleroBackupTemplate.VolumeSnapshotLocations = getVolumeSnapshotLocations(backupSchedule, scheduleKey)
+			if backupSchedule.Spec.UseOwnerReferencesInBackup {
+				veleroSchedule.Spec.UseOwnerReferencesInBackup = &backupSchedule.Spec.UseOwnerReferencesInBackup
+			}
+			if backupSchedule.Spec.SkipImmediately {
+				veleroSchedule.Spec.SkipImmediately = &backupSchedule.Spec.SkipImmediately
+			}
+			veleroSchedule.Spec.Template = *veleroBackupTemplate
+			updateScheduleGenerationLabel(veleroSchedule, backupSchedule)
+			updateIncrementalBackup(veleroSchedule, backupSchedule)
+			if scheduleKey == Resources {
+				updateExcludedAddonNamespaces(veleroSchedule, backupSchedule.Spec.ExcludedAddonNamespaces)
+				updateIncludedNamespaces(veleroSchedule, backupSchedule.Spec.IncludedNamespaces)
+			}
+			veleroSchedule.Spec.Schedule = getScheduleCron(backupSchedule, scheduleKey)
+			if backupSchedule.Spec.VeleroTTL.Duration != 0 && scheduleKey != ValidationSchedule {
+				// TTL for a validation backup is already set using the cron job interval
+				veleroSchedule.Spec.Template.TTL = backupSchedule.Spec.VeleroTTL
+			}
+			// owner references can't be set on schedules created in another namespace,
+			// these schedules are found using the backup schedule name label
+			var ownerErr error
+			if veleroSchedule.Namespace == backupSchedule.Namespace {
+				// this is always successful since veleroSchedule is defined now
+				ownerErr = ctrl.SetControllerReference(backupSchedule, veleroSchedule, r.Scheme)
+			}
+			if ownerErr == nil {
+				err := r.Create(ctx, veleroSchedule, &client.CreateOptions{})
+				if err != nil {
+					scheduleLogger.Error(
+						err,
+						"Error in creating velero.io.Schedule",
+						"name", veleroScheduleIdentity.Name,
+						"namespace", veleroScheduleIdentity.Namespace,
+					)
+					return err
+				}
+				scheduleLogger.Info(
+					"Velero schedule created",
+					"name", veleroSchedule.Name,
+					"namespace", veleroSchedule.Namespace,
+				)
+				veleroSchedulesCreated.WithLabelValues(backupSchedule.Namespace, backupSchedule.Name).Inc()
+
+				// set veleroSchedule in backupSchedule status
+				if veleroNamespace == getVeleroNamespace(backupSchedule) {
+					setVeleroScheduleInStatus(scheduleKey, veleroSchedule, backupSchedule)
+				}
+				// if initial backup needs to be created, process it here
+				createInitialBackupForSchedule(ctx, r.Client, r.Scheme,
+					veleroSchedule, backupSchedule, currentTime)
+			}
 		}
 	}
 	return nil
@@ -456,26 +623,127 @@ func (r *BackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		context.Background(),
 		&veleroapi.Schedule{},
 		scheduleOwnerKey,
-		func(rawObj client.Object) []string {
-			schedule := rawObj.(*veleroapi.Schedule)
-			owner := metav1.GetControllerOf(schedule)
-			if owner == nil || owner.APIVersion != apiGVString || owner.Kind != "BackupSchedule" {
-				return nil
-			}
-
-			return []string{owner.Name}
-		}); err != nil {
+		indexScheduleOwner); err != nil {
 		return err
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&v1beta1.BackupSchedule{}).
-		Owns(&veleroapi.Schedule{}).
-		WithEventFilter(predicate.Funcs{
+		For(&v1beta1.BackupSchedule{}, builder.WithPredicates(predicate.Funcs{
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				// Ignore updates to CR status in which case metadata.Generation does not change
 				return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
 			},
-		}).
+		})).
+		Owns(&veleroapi.Schedule{}, builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: isVeleroScheduleUpdated,
+		})).
 		Complete(r)
 }
+
+// returns true if the velero schedule spec, phase or validation errors changed,
+// so the owning BackupSchedule phase is updated without waiting for the next requeue;
+// other velero schedule status changes, such as the last backup time, are ignored
+func isVeleroScheduleUpdated(e event.UpdateEvent) bool {
+	if e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() {
+		return true
+	}
+	oldSchedule, okOld := e.ObjectOld.(*veleroapi.Schedule)
+	newSchedule, okNew := e.ObjectNew.(*veleroapi.Schedule)
+	if !okOld || !okNew {
+		return false
+	}
+	return oldSchedule.Status.Phase != newSchedule.Status.Phase ||
+		!reflect.DeepEqual(oldSchedule.Status.ValidationErrors, newSchedule.Status.ValidationErrors)
+}
+
+// returns the name of the BackupSchedule owning the velero schedule
+func indexScheduleOwner(rawObj client.Object) []string {
+	schedule := rawObj.(*veleroapi.Schedule)
+	owner := metav1.GetControllerOf(schedule)
+	if owner == nil || owner.APIVersion != apiGVString || owner.Kind != "BackupSchedule" {
+		return nil
+	}
+
+	return []string{owner.Name}
+}
+
+// records the time of this reconcile under the BackupSchedule status,
+// so monitors can detect a BackupSchedule no longer reconciled
+func (r *BackupScheduleReconciler) updateLastReconcileTime(
+	ctx context.Context,
+	backupSchedule *v1beta1.BackupSchedule,
+) {
+	if backupSchedule.Name == "" || !backupSchedule.DeletionTimestamp.IsZero() {
+		// not found or being deleted
+		return
+	}
+
+	patch := client.MergeFrom(backupSchedule.DeepCopy())
+	now := metav1.Now()
+	backupSchedule.Status.LastReconcileTime = &now
+	if err := r.Client.Status().Patch(ctx, backupSchedule, patch); client.IgnoreNotFound(err) != nil {
+		log.FromContext(ctx).Error(err, "failed to update the BackupSchedule last reconcile time")
+	}
+}
+
+// emits a BackupCompleted event for each velero schedule with a backup completed since
+// the last reconcile and records the latest observed backup under the BackupSchedule status
+func (r *BackupScheduleReconciler) emitBackupCompletedEvents(
+	ctx context.Context,
+	backupSchedule *v1beta1.BackupSchedule,
+	veleroScheduleList *veleroapi.ScheduleList,
+) {
+	scheduleLogger := log.FromContext(ctx)
+
+	if backupSchedule.Status.LastObservedBackup == nil {
+		backupSchedule.Status.LastObservedBackup = map[string]string{}
+	}
+
+	for resourceType, scheduleName := range veleroScheduleNames {
+		if resourceType == ValidationSchedule {
+			continue
+		}
+		for i := range veleroScheduleList.Items {
+			veleroSchedule := &veleroScheduleList.Items[i]
+			if veleroSchedule.Name != scheduleName {
+				continue
+			}
+
+			backup, err := getLatestCompletedBackup(ctx, r.Client, veleroSchedule)
+			if err != nil {
+				scheduleLogger.Error(err, "Error listing velero backups", "schedule", scheduleName)
+				break
+			}
+			lastObserved, observed := backupSchedule.Status.LastObservedBackup[string(resourceType)]
+			if backup == nil || backup.Name == lastObserved {
+				break
+			}
+			backupSchedule.Status.LastObservedBackup[string(resourceType)] = backup.Name
+
+			// first time backups are observed, ignore the ones completed before the BackupSchedule was created
+			if !observed && backup.Status.CompletionTimestamp.Before(&backupSchedule.CreationTimestamp) {
+				break
+			}
+
+			itemsBackedUp := 0
+			if backup.Status.Progress != nil {
+				itemsBackedUp = backup.Status.Progress.ItemsBackedUp
+			}
+			r.Recorder.Event(backupSchedule, corev1.EventTypeNormal, "BackupCompleted",
+				fmt.Sprintf("Backup %s completed with %d items", backup.Name, itemsBackedUp))
+			break
+		}
+	}
+}
+
+// emits a BackupCollision warning event on the BackupSchedule, naming the hub storing backups
+// at the same storage location, and increments the acm_backup_collisions_total metric
+func (r *BackupScheduleReconciler) reportBackupCollision(
+	backupSchedule *v1beta1.BackupSchedule,
+	clusterID string,
+	msg string,
+) {
+	backupCollisions.WithLabelValues(backupSchedule.Namespace, backupSchedule.Name).Inc()
+	r.Recorder.Event(backupSchedule, corev1.EventTypeWarning, "BackupCollision",
+		fmt.Sprintf("Backup collision with cluster %s: %s", clusterID, msg))
+}
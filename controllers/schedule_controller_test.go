@@ -464,6 +464,7 @@ var _ = Describe("BackupSchedule controller", func() {
 				useManagedServiceAccount(true).
 				managedServiceAccountTTL(metav1.Duration{Duration: time.Hour * 90}).
 				setVolumeSnapshotLocation([]string{"dpa-1"}).
+				managedClustersVolumeSnapshotLocations([]string{"dpa-2"}).
 				useOwnerReferencesInBackup(true).
 				skipImmediately(true).
 				object
@@ -651,10 +652,18 @@ var _ = Describe("BackupSchedule controller", func() {
 				err := k8sClient.List(ctx, &veleroSchedulesList, &client.ListOptions{})
 				return err == nil
 			}, timeout, interval).Should(BeTrue())
-			// check the volumeSnapshotLocation property
-			Expect(
-				veleroSchedulesList.Items[1].Spec.Template.VolumeSnapshotLocations,
-			).Should(Equal([]string{"dpa-1"}))
+			// check the volumeSnapshotLocation and managedClustersVolumeSnapshotLocations properties
+			for i := range veleroSchedulesList.Items {
+				if veleroSchedulesList.Items[i].Name == veleroScheduleNames[ManagedClusters] {
+					Expect(
+						veleroSchedulesList.Items[i].Spec.Template.VolumeSnapshotLocations,
+					).Should(Equal([]string{"dpa-2"}))
+				} else {
+					Expect(
+						veleroSchedulesList.Items[i].Spec.Template.VolumeSnapshotLocations,
+					).Should(Equal([]string{"dpa-1"}))
+				}
+			}
 			// check the UseOwnerReferencesInBackup property
 			Expect(
 				*veleroSchedulesList.Items[1].Spec.UseOwnerReferencesInBackup,
@@ -680,6 +689,7 @@ var _ = Describe("BackupSchedule controller", func() {
 				}
 			}
 
+			deletedScheduleName := veleroSchedulesList.Items[1].Name
 			Expect(k8sClient.Delete(ctx, &veleroSchedulesList.Items[1])).To(Succeed()) // Manually delete a schedule
 			// count velero schedules, should be still len(veleroScheduleNames)
 			Eventually(func() int {
@@ -688,6 +698,17 @@ var _ = Describe("BackupSchedule controller", func() {
 				}
 				return 0
 			}, time.Second*65, interval).Should(BeNumerically("==", len(veleroScheduleNames)))
+			// the deleted schedule is recreated
+			Eventually(func() []ResourceType {
+				if err := k8sClient.List(ctx, &veleroSchedulesList, &client.ListOptions{}); err == nil {
+					return getMissingVeleroSchedules(&veleroSchedulesList)
+				}
+				return nil
+			}, timeout, interval).Should(BeEmpty())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      deletedScheduleName,
+				Namespace: veleroNamespaceName,
+			}, &veleroapi.Schedule{})).To(Succeed())
 
 			// check that the velero schedules have now 150h for ttl
 			Eventually(func() metav1.Duration {
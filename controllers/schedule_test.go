@@ -20,32 +20,47 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	ocinfrav1 "github.com/openshift/api/config/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	backupv1beta1 "github.com/stolostron/cluster-backup-operator/api/v1beta1"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	discoveryclient "k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
+	discoveryfake "k8s.io/client-go/discovery/fake"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	chnv1 "open-cluster-management.io/multicloud-operators-channel/pkg/apis/apps/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func initVeleroScheduleList(
@@ -81,10 +96,44 @@ func initVeleroSchedulesWithSpecs(
 		veleroSchedule := &veleroScheduleList.Items[i]
 		veleroSchedule.Spec.Schedule = cronSpec
 		veleroSchedule.Spec.Template.TTL = ttl
+		if veleroSchedule.Name == veleroScheduleNames[Credentials] {
+			veleroSchedule.Spec.Template.OrLabelSelectors = getCredentialsOrLabelSelectors(nil)
+		}
+		if veleroSchedule.Name == veleroScheduleNames[Resources] {
+			// the resources schedule excludes the namespace of the BackupSchedule, ns
+			veleroSchedule.Spec.Template.ExcludedNamespaces = []string{"ns"}
+		}
 	}
 	return veleroScheduleList
 }
 
+// returns the velero schedules with specs, and the configmaps schedule backing up
+// the ConfigMaps matching the given selector
+func initVeleroSchedulesWithConfigMaps(
+	cronSpec string,
+	ttl metav1.Duration,
+	configMapSelector *metav1.LabelSelector,
+) *veleroapi.ScheduleList {
+	veleroScheduleList := initVeleroSchedulesWithSpecs(cronSpec, ttl)
+	configMapsSchedule := veleroapi.Schedule{}
+	configMapsSchedule.Name = configMapsScheduleName
+	configMapsSchedule.Labels = map[string]string{BackupScheduleTypeLabel: string(ConfigMaps)}
+	configMapsSchedule.Spec.Schedule = cronSpec
+	configMapsSchedule.Spec.Template.TTL = ttl
+	setConfigMapsBackupInfo(&configMapsSchedule.Spec.Template, configMapSelector)
+	veleroScheduleList.Items = append(veleroScheduleList.Items, configMapsSchedule)
+	return veleroScheduleList
+}
+
+// returns the OrLabelSelectors set on the credentials schedule
+func getCredentialsOrLabelSelectors(
+	credentialsOrLabelSelectors []*metav1.LabelSelector,
+) []*metav1.LabelSelector {
+	veleroBackupTemplate := &veleroapi.BackupSpec{}
+	setCredsBackupInfo(veleroBackupTemplate, credentialsOrLabelSelectors)
+	return veleroBackupTemplate.OrLabelSelectors
+}
+
 func initVeleroScheduleTypes() *veleroapi.ScheduleList {
 	return &veleroapi.ScheduleList{
 		TypeMeta: metav1.TypeMeta{
@@ -147,9 +196,10 @@ func Test_parseCronSchedule(t *testing.T) {
 		backupSchedule *v1beta1.BackupSchedule
 	}
 	tests := []struct {
-		name string
-		args args
-		want []string
+		name          string
+		args          args
+		want          []string
+		wantCronValid metav1.ConditionStatus
 	}{
 		{
 			name: "Empty cron",
@@ -157,7 +207,8 @@ func Test_parseCronSchedule(t *testing.T) {
 				ctx:            context.TODO(),
 				backupSchedule: createBackupSchedule("acm", "ns").schedule("").object,
 			},
-			want: []string{"Schedule must be a non-empty valid Cron expression"},
+			want:          []string{"Schedule must be a non-empty valid Cron expression"},
+			wantCronValid: metav1.ConditionFalse,
 		},
 		{
 			name: "Wrong cron",
@@ -165,7 +216,17 @@ func Test_parseCronSchedule(t *testing.T) {
 				ctx:            context.TODO(),
 				backupSchedule: createBackupSchedule("acm", "ns").schedule("WRONG").object,
 			},
-			want: []string{"invalid schedule: expected exactly 5 fields, found 1: [WRONG]"},
+			want:          []string{"invalid schedule: expected exactly 5 fields, found 1: [WRONG]"},
+			wantCronValid: metav1.ConditionFalse,
+		},
+		{
+			name: "Valid cron",
+			args: args{
+				ctx:            context.TODO(),
+				backupSchedule: createBackupSchedule("acm", "ns").schedule("0 8 * * *").object,
+			},
+			want:          nil,
+			wantCronValid: metav1.ConditionTrue,
 		},
 	}
 	for _, tt := range tests {
@@ -176,19 +237,56 @@ func Test_parseCronSchedule(t *testing.T) {
 			) {
 				t.Errorf("parseCronSchedule() = %v, want %v", got, tt.want)
 			}
+			cond := meta.FindStatusCondition(tt.args.backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionCronValid)
+			if cond == nil || cond.Status != tt.wantCronValid {
+				t.Errorf("parseCronSchedule() CronValid condition = %v, want %v", cond, tt.wantCronValid)
+			}
 		})
 	}
 }
 
+func Test_updateScheduleStatus(t *testing.T) {
+	backupSchedule := createBackupSchedule("name", "ns").object
+	veleroSchedules := []veleroapi.Schedule{
+		*createSchedule(veleroScheduleNames[Resources], "ns").object,
+		*createSchedule(veleroScheduleNames[Credentials], "ns").object,
+		*createSchedule(veleroScheduleNames[ManagedClusters], "ns").object,
+		*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").object,
+		*createSchedule(veleroScheduleNames[Credentials], "ns").object,
+	}
+	for i := range veleroSchedules {
+		updateScheduleStatus(context.Background(), &veleroSchedules[i], backupSchedule)
+	}
+
+	wantNames := []string{
+		veleroScheduleNames[Credentials],
+		veleroScheduleNames[ManagedClusters],
+		veleroScheduleNames[ResourcesGeneric],
+		veleroScheduleNames[Resources],
+	}
+	sort.Strings(wantNames)
+	if !reflect.DeepEqual(backupSchedule.Status.VeleroScheduleNames, wantNames) {
+		t.Errorf("updateScheduleStatus() VeleroScheduleNames = %v, want %v",
+			backupSchedule.Status.VeleroScheduleNames, wantNames)
+	}
+	if backupSchedule.Status.VeleroScheduleResources == nil ||
+		backupSchedule.Status.VeleroScheduleResources.Name != veleroScheduleNames[Resources] {
+		t.Errorf("updateScheduleStatus() VeleroScheduleResources = %v", backupSchedule.Status.VeleroScheduleResources)
+	}
+}
+
 func Test_setSchedulePhase(t *testing.T) {
 	type args struct {
 		schedules      *veleroapi.ScheduleList
 		backupSchedule *v1beta1.BackupSchedule
 	}
 	tests := []struct {
-		name string
-		args args
-		want v1beta1.SchedulePhase
+		name           string
+		args           args
+		want           v1beta1.SchedulePhase
+		wantReconciled metav1.ConditionStatus
+		wantReason     string
 	}{
 		{
 			name: "nil schedule",
@@ -196,7 +294,9 @@ func Test_setSchedulePhase(t *testing.T) {
 				schedules:      nil,
 				backupSchedule: createBackupSchedule("name", "ns").schedule("no matter").object,
 			},
-			want: v1beta1.SchedulePhaseNew,
+			want:           v1beta1.SchedulePhaseNew,
+			wantReconciled: metav1.ConditionFalse,
+			wantReason:     v1beta1.ScheduleReasonSchedulesNotReady,
 		},
 		{
 			name: "schedule in collision",
@@ -217,7 +317,9 @@ func Test_setSchedulePhase(t *testing.T) {
 					metav1.Duration{Duration: time.Second * 5}),
 				backupSchedule: createBackupSchedule("name", "ns").schedule("0 8 * * *").object,
 			},
-			want: v1beta1.SchedulePhaseNew,
+			want:           v1beta1.SchedulePhaseNew,
+			wantReconciled: metav1.ConditionFalse,
+			wantReason:     v1beta1.ScheduleReasonSchedulesNotReady,
 		},
 		{
 			name: "failed validation",
@@ -229,7 +331,9 @@ func Test_setSchedulePhase(t *testing.T) {
 				),
 				backupSchedule: createBackupSchedule("name", "ns").schedule("0 8 * * *").object,
 			},
-			want: v1beta1.SchedulePhaseFailedValidation,
+			want:           v1beta1.SchedulePhaseFailedValidation,
+			wantReconciled: metav1.ConditionFalse,
+			wantReason:     v1beta1.ScheduleReasonSchedulesFailed,
 		},
 		{
 			name: "enabled",
@@ -238,7 +342,9 @@ func Test_setSchedulePhase(t *testing.T) {
 					metav1.Duration{Duration: time.Second * 5}),
 				backupSchedule: createBackupSchedule("name", "ns").schedule("0 8 * * *").object,
 			},
-			want: v1beta1.SchedulePhaseEnabled,
+			want:           v1beta1.SchedulePhaseEnabled,
+			wantReconciled: metav1.ConditionTrue,
+			wantReason:     v1beta1.ScheduleReasonSchedulesEnabled,
 		},
 	}
 	for _, tt := range tests {
@@ -246,488 +352,2588 @@ func Test_setSchedulePhase(t *testing.T) {
 			if got := setSchedulePhase(tt.args.schedules, tt.args.backupSchedule); got != tt.want {
 				t.Errorf("setSchedulePhase() = %v, want %v", got, tt.want)
 			}
+			cond := meta.FindStatusCondition(tt.args.backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionSchedulesReconciled)
+			if tt.wantReconciled == "" {
+				// phase is not changed, conditions are not set
+				if cond != nil {
+					t.Errorf("setSchedulePhase() SchedulesReconciled condition should not be set, got %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantReconciled || cond.Reason != tt.wantReason {
+				t.Errorf("setSchedulePhase() SchedulesReconciled condition = %v, want %v with reason %v",
+					cond, tt.wantReconciled, tt.wantReason)
+			}
+			if !meta.IsStatusConditionFalse(tt.args.backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionBackupCollisionDetected) {
+				t.Errorf("setSchedulePhase() BackupCollisionDetected condition should be false")
+			}
 		})
 	}
 }
 
-func Test_getSchedulesWithUpdatedResources(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
+func Test_setScheduleConditions(t *testing.T) {
+	tests := []struct {
+		name           string
+		phase          v1beta1.SchedulePhase
+		wantReconciled metav1.ConditionStatus
+		wantReason     string
+		wantCollision  metav1.ConditionStatus
+	}{
+		{
+			name:           "backup collision",
+			phase:          v1beta1.SchedulePhaseBackupCollision,
+			wantReconciled: metav1.ConditionFalse,
+			wantReason:     v1beta1.ScheduleReasonBackupCollision,
+			wantCollision:  metav1.ConditionTrue,
+		},
+		{
+			name:           "paused",
+			phase:          v1beta1.SchedulePhasePaused,
+			wantReconciled: metav1.ConditionFalse,
+			wantReason:     v1beta1.ScheduleReasonSchedulesPaused,
+			wantCollision:  metav1.ConditionFalse,
+		},
+		{
+			name:           "failed",
+			phase:          v1beta1.SchedulePhaseFailed,
+			wantReconciled: metav1.ConditionFalse,
+			wantReason:     v1beta1.ScheduleReasonSchedulesFailed,
+			wantCollision:  metav1.ConditionFalse,
+		},
+		{
+			name:           "enabled",
+			phase:          v1beta1.SchedulePhaseEnabled,
+			wantReconciled: metav1.ConditionTrue,
+			wantReason:     v1beta1.ScheduleReasonSchedulesEnabled,
+			wantCollision:  metav1.ConditionFalse,
 		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").phase(tt.phase).object
+			setScheduleConditions(backupSchedule)
+
+			reconciled := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionSchedulesReconciled)
+			if reconciled == nil || reconciled.Status != tt.wantReconciled || reconciled.Reason != tt.wantReason {
+				t.Errorf("setScheduleConditions() SchedulesReconciled condition = %v, want %v with reason %v",
+					reconciled, tt.wantReconciled, tt.wantReason)
+			}
+			collision := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionBackupCollisionDetected)
+			if collision == nil || collision.Status != tt.wantCollision {
+				t.Errorf("setScheduleConditions() BackupCollisionDetected condition = %v, want %v",
+					collision, tt.wantCollision)
+			}
+		})
 	}
+}
 
-	type args struct {
-		resourcesToBackup []string
-		schedules         *veleroapi.ScheduleList
+func Test_setBackupsOverdueCondition(t *testing.T) {
+	currentTime := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	hoursAgo := func(hours int) metav1.Time {
+		return metav1.NewTime(currentTime.Add(-time.Hour * time.Duration(hours)))
 	}
 
-	resourcesToBackup := []string{
-		"channel.apps.open-cluster-management.io",
-		"iampolicy.policy.open-cluster-management.io",
-		"agentclusterinstall.extensions.hive.openshift.io",
-		"application.app.k8s.io",
-		"agentserviceconfig.agent-install.openshift.io",
-		"observatorium.core.observatorium.io",
+	tests := []struct {
+		name        string
+		schedules   []veleroapi.Schedule
+		factor      int
+		wantStatus  metav1.ConditionStatus
+		wantMessage string
+	}{
+		{
+			name: "recent backups",
+			schedules: []veleroapi.Schedule{
+				*createSchedule(veleroScheduleNames[Resources], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).
+					creationTimestamp(hoursAgo(48)).lastBackup(hoursAgo(1)).object,
+			},
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name: "enabled schedule with stale backups",
+			schedules: []veleroapi.Schedule{
+				*createSchedule(veleroScheduleNames[Credentials], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).
+					creationTimestamp(hoursAgo(48)).lastBackup(hoursAgo(1)).object,
+				*createSchedule(veleroScheduleNames[Resources], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).
+					creationTimestamp(hoursAgo(48)).lastBackup(hoursAgo(4)).object,
+			},
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: veleroScheduleNames[Resources] + " (last backup 2024-05-10T08:30:00Z)",
+		},
+		{
+			name: "stale backups within the configured factor",
+			schedules: []veleroapi.Schedule{
+				*createSchedule(veleroScheduleNames[Resources], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).
+					creationTimestamp(hoursAgo(48)).lastBackup(hoursAgo(4)).object,
+			},
+			factor:     5,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name: "enabled schedule never created a backup",
+			schedules: []veleroapi.Schedule{
+				*createSchedule(veleroScheduleNames[Resources], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).
+					creationTimestamp(hoursAgo(48)).object,
+			},
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: veleroScheduleNames[Resources] + " (last backup never)",
+		},
+		{
+			name: "new schedule with no backup yet",
+			schedules: []veleroapi.Schedule{
+				*createSchedule(veleroScheduleNames[Resources], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).
+					creationTimestamp(hoursAgo(1)).object,
+			},
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name: "paused and failed schedules are ignored",
+			schedules: []veleroapi.Schedule{
+				*createSchedule(veleroScheduleNames[Resources], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseEnabled).paused(true).
+					creationTimestamp(hoursAgo(48)).lastBackup(hoursAgo(24)).object,
+				*createSchedule(veleroScheduleNames[Credentials], "ns").
+					schedule("0 * * * *").phase(veleroapi.SchedulePhaseFailedValidation).
+					creationTimestamp(hoursAgo(48)).object,
+			},
+			wantStatus: metav1.ConditionFalse,
+		},
 	}
-	schedules := initVeleroScheduleTypes()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").schedule("0 * * * *").object
+			backupSchedule.Spec.BackupOverdueFactor = tt.factor
 
-	veleroSchedulesToUpdate := make([]veleroapi.Schedule, 0)
+			setBackupsOverdueCondition(&veleroapi.ScheduleList{Items: tt.schedules}, backupSchedule, currentTime)
 
-	for i := range schedules.Items {
-		veleroSchedule := &schedules.Items[i]
-		veleroBackupTemplate := &veleroapi.BackupSpec{}
+			condition := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionBackupsOverdue)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Fatalf("setBackupsOverdueCondition() %s condition = %v, want %v",
+					v1beta1.ScheduleConditionBackupsOverdue, condition, tt.wantStatus)
+			}
+			if !strings.Contains(condition.Message, tt.wantMessage) {
+				t.Errorf("setBackupsOverdueCondition() condition message %q should contain %q",
+					condition.Message, tt.wantMessage)
+			}
+			if tt.wantStatus == metav1.ConditionTrue &&
+				strings.Contains(condition.Message, veleroScheduleNames[Credentials]) {
+				t.Errorf("setBackupsOverdueCondition() condition message %q should not contain %s",
+					condition.Message, veleroScheduleNames[Credentials])
+			}
+		})
+	}
+}
 
-		switch veleroSchedule.Name {
-		case veleroScheduleNames[Resources]:
-			setResourcesBackupInfo(context.Background(), veleroBackupTemplate, resourcesToBackup,
-				"open-cluster-management-backup", k8sClient1)
-			veleroSchedulesToUpdate = append(
-				veleroSchedulesToUpdate,
-				*veleroSchedule,
-			)
-		case veleroScheduleNames[ResourcesGeneric]:
-			setGenericResourcesBackupInfo(veleroBackupTemplate, resourcesToBackup)
-			veleroSchedulesToUpdate = append(
-				veleroSchedulesToUpdate,
-				*veleroSchedule,
-			)
-		case veleroScheduleNames[ManagedClusters]:
-			setManagedClustersBackupInfo(veleroBackupTemplate, resourcesToBackup)
-			veleroSchedulesToUpdate = append(
-				veleroSchedulesToUpdate,
-				*veleroSchedule,
-			)
+func Test_setBackupsFailingCondition(t *testing.T) {
+	currentTime := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	scheduleLabels := func(resourceType ResourceType) map[string]string {
+		return map[string]string{
+			BackupScheduleTypeLabel:    string(resourceType),
+			BackupScheduleClusterLabel: "hub-1",
 		}
-
-		veleroSchedule.Spec.Template = *veleroBackupTemplate
 	}
-
-	var newResourcesToBackup []string
-	newResourcesToBackup = append(newResourcesToBackup, resourcesToBackup...)
-	newResourcesToBackup = append(
-		newResourcesToBackup,
-		"baremetalasset.inventory.open-cluster-management.io",
-	)
-	newResourcesToBackup = append(newResourcesToBackup, "x.hive.openshift.io")
-	newResourcesToBackup = append(newResourcesToBackup, "y.hive.openshift.io")
+	schedules := &veleroapi.ScheduleList{
+		Items: []veleroapi.Schedule{
+			*createSchedule(veleroScheduleNames[Credentials], "ns").
+				scheduleLabels(scheduleLabels(Credentials)).object,
+			*createSchedule(veleroScheduleNames[Resources], "ns").
+				scheduleLabels(scheduleLabels(Resources)).object,
+		},
+	}
+	// returns a backup created by the velero schedule for the resource type, started hours ago
+	backup := func(resourceType ResourceType, hours int, phase veleroapi.BackupPhase,
+		errorCount int) veleroapi.Backup {
+		return *createBackup(fmt.Sprintf("%s-%d", veleroScheduleNames[resourceType], hours), "ns").
+			labels(map[string]string{
+				BackupVeleroLabel:          veleroScheduleNames[resourceType],
+				BackupScheduleClusterLabel: "hub-1",
+			}).
+			startTimestamp(metav1.NewTime(currentTime.Add(-time.Hour * time.Duration(hours)))).
+			phase(phase).errors(errorCount).object
+	}
 
 	tests := []struct {
-		name string
-		args args
-		want []veleroapi.Schedule
+		name        string
+		backups     []veleroapi.Backup
+		threshold   int
+		wantStatus  metav1.ConditionStatus
+		wantMessage string
 	}{
 		{
-			name: "nil arguments",
-			args: args{
-				resourcesToBackup: nil,
-				schedules:         nil,
+			name: "backups completed",
+			backups: []veleroapi.Backup{
+				backup(Resources, 1, veleroapi.BackupPhaseCompleted, 0),
+				backup(Resources, 2, veleroapi.BackupPhaseCompleted, 0),
+				backup(Resources, 3, veleroapi.BackupPhaseCompleted, 0),
 			},
-			want: []veleroapi.Schedule{},
+			wantStatus: metav1.ConditionFalse,
 		},
 		{
-			name: "unchanged hub resources",
-			args: args{
-				resourcesToBackup: resourcesToBackup,
-				schedules:         schedules,
+			name: "last backups failing",
+			backups: []veleroapi.Backup{
+				backup(Credentials, 1, veleroapi.BackupPhaseCompleted, 0),
+				backup(Resources, 1, veleroapi.BackupPhasePartiallyFailed, 4),
+				backup(Resources, 2, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 3, veleroapi.BackupPhasePartiallyFailed, 2),
+				backup(Resources, 4, veleroapi.BackupPhaseCompleted, 0),
 			},
-			want: []veleroapi.Schedule{},
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: string(Resources) + " (last 3 backups failed with 7 errors)",
 		},
 		{
-			name: "changed hub resources",
-			args: args{
-				resourcesToBackup: newResourcesToBackup,
-				schedules:         schedules,
+			name: "latest backup completed after failures",
+			backups: []veleroapi.Backup{
+				backup(Resources, 1, veleroapi.BackupPhaseCompleted, 0),
+				backup(Resources, 2, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 3, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 4, veleroapi.BackupPhaseFailed, 1),
 			},
-			want: veleroSchedulesToUpdate,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name: "running backup ignored",
+			backups: []veleroapi.Backup{
+				backup(Resources, 1, veleroapi.BackupPhaseInProgress, 0),
+				backup(Resources, 2, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 3, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 4, veleroapi.BackupPhaseFailed, 1),
+			},
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: string(Resources) + " (last 3 backups failed with 3 errors)",
+		},
+		{
+			name: "failures below the configured threshold",
+			backups: []veleroapi.Backup{
+				backup(Resources, 1, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 2, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 3, veleroapi.BackupPhaseFailed, 1),
+				backup(Resources, 4, veleroapi.BackupPhaseCompleted, 0),
+			},
+			threshold:  4,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name: "failed backups from another hub ignored",
+			backups: func() []veleroapi.Backup {
+				backups := []veleroapi.Backup{
+					backup(Resources, 1, veleroapi.BackupPhaseFailed, 1),
+					backup(Resources, 2, veleroapi.BackupPhaseFailed, 1),
+					backup(Resources, 3, veleroapi.BackupPhaseFailed, 1),
+				}
+				for i := range backups {
+					backups[i].Labels[BackupScheduleClusterLabel] = "hub-2"
+				}
+				return backups
+			}(),
+			wantStatus: metav1.ConditionFalse,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := getSchedulesWithUpdatedResources(tt.args.resourcesToBackup, tt.args.schedules)
-			if len(got) != len(tt.want) {
-				t.Errorf("getSchedulesWithUpdatedResources() = %v, want %v", got, tt.want)
+			backupSchedule := createBackupSchedule("name", "ns").object
+			backupSchedule.Spec.BackupFailureThreshold = tt.threshold
+
+			setBackupsFailingCondition(schedules, tt.backups, backupSchedule)
+
+			condition := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionBackupsFailing)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Fatalf("setBackupsFailingCondition() %s condition = %v, want %v",
+					v1beta1.ScheduleConditionBackupsFailing, condition, tt.wantStatus)
+			}
+			if !strings.Contains(condition.Message, tt.wantMessage) {
+				t.Errorf("setBackupsFailingCondition() condition message %q should contain %q",
+					condition.Message, tt.wantMessage)
+			}
+			if tt.wantStatus == metav1.ConditionTrue &&
+				strings.Contains(condition.Message, string(Credentials)) {
+				t.Errorf("setBackupsFailingCondition() condition message %q should not contain %s",
+					condition.Message, Credentials)
 			}
 		})
 	}
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
-	}
 }
 
-func Test_isScheduleSpecUpdated(t *testing.T) {
-	type args struct {
-		schedules      *veleroapi.ScheduleList
-		backupSchedule *v1beta1.BackupSchedule
+func Test_setBackupWarnings(t *testing.T) {
+	currentTime := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	scheduleLabels := func(resourceType ResourceType) map[string]string {
+		return map[string]string{
+			BackupScheduleTypeLabel:    string(resourceType),
+			BackupScheduleClusterLabel: "hub-1",
+		}
+	}
+	schedules := &veleroapi.ScheduleList{
+		Items: []veleroapi.Schedule{
+			*createSchedule(veleroScheduleNames[Credentials], "ns").
+				scheduleLabels(scheduleLabels(Credentials)).object,
+			*createSchedule(veleroScheduleNames[Resources], "ns").
+				scheduleLabels(scheduleLabels(Resources)).object,
+		},
 	}
+	// returns a backup created by the velero schedule for the resource type, started hours ago
+	backup := func(resourceType ResourceType, hours int, phase veleroapi.BackupPhase,
+		warningCount int) veleroapi.Backup {
+		return *createBackup(fmt.Sprintf("%s-%d", veleroScheduleNames[resourceType], hours), "ns").
+			labels(map[string]string{
+				BackupVeleroLabel:          veleroScheduleNames[resourceType],
+				BackupScheduleClusterLabel: "hub-1",
+			}).
+			startTimestamp(metav1.NewTime(currentTime.Add(-time.Hour * time.Duration(hours)))).
+			phase(phase).warnings(warningCount).object
+	}
+
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name         string
+		backups      []veleroapi.Backup
+		wantWarnings map[string]int
+		wantStatus   metav1.ConditionStatus
+		wantMessage  string
 	}{
 		{
-			name: "no schedules",
-			args: args{
-				schedules: nil,
+			name:       "no backups",
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name: "backups without warnings",
+			backups: []veleroapi.Backup{
+				backup(Credentials, 1, veleroapi.BackupPhaseCompleted, 0),
+				backup(Resources, 1, veleroapi.BackupPhaseCompleted, 0),
 			},
-			want: false,
+			wantStatus: metav1.ConditionFalse,
 		},
 		{
-			name: "same schedule and ttl",
-			args: args{
-				schedules: initVeleroSchedulesWithSpecs(
-					"0 6 * * *",
-					metav1.Duration{Duration: time.Hour * 1},
-				),
-				backupSchedule: createBackupSchedule(
-					"name",
-					"ns",
-				).schedule("0 6 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
-					object,
+			name: "latest backups with warnings",
+			backups: []veleroapi.Backup{
+				backup(Credentials, 1, veleroapi.BackupPhaseCompleted, 2),
+				backup(Resources, 1, veleroapi.BackupPhasePartiallyFailed, 5),
+				backup(Resources, 2, veleroapi.BackupPhaseCompleted, 1),
 			},
-			want: false,
+			wantWarnings: map[string]int{string(Credentials): 2, string(Resources): 5},
+			wantStatus:   metav1.ConditionTrue,
+			wantMessage: string(Credentials) + " (backup " + veleroScheduleNames[Credentials] +
+				"-1 with 2 warnings), " + string(Resources) + " (backup " + veleroScheduleNames[Resources] +
+				"-1 with 5 warnings)",
 		},
 		{
-			name: "schedule updated",
-			args: args{
-				schedules: initVeleroSchedulesWithSpecs(
-					"0 6 * * *",
-					metav1.Duration{Duration: time.Hour * 1},
-				),
-				backupSchedule: createBackupSchedule(
-					"name",
-					"ns",
-				).schedule("0 8 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
-					object,
+			name: "warnings from an older backup ignored",
+			backups: []veleroapi.Backup{
+				backup(Resources, 1, veleroapi.BackupPhaseCompleted, 0),
+				backup(Resources, 2, veleroapi.BackupPhaseCompleted, 3),
 			},
-			want: true,
+			wantStatus: metav1.ConditionFalse,
 		},
 		{
-			name: "ttl updated",
-			args: args{
-				schedules: initVeleroSchedulesWithSpecs(
-					"0 6 * * *",
-					metav1.Duration{Duration: time.Hour * 1},
-				),
-				backupSchedule: createBackupSchedule(
-					"name",
-					"ns",
-				).schedule("0 6 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Hour * 2}).
-					object,
+			name: "running backup ignored",
+			backups: []veleroapi.Backup{
+				backup(Resources, 1, veleroapi.BackupPhaseInProgress, 0),
+				backup(Resources, 2, veleroapi.BackupPhaseCompleted, 3),
 			},
-			want: true,
+			wantWarnings: map[string]int{string(Resources): 3},
+			wantStatus:   metav1.ConditionTrue,
+			wantMessage:  string(Resources) + " (backup " + veleroScheduleNames[Resources] + "-2 with 3 warnings)",
+		},
+		{
+			name: "backups from another hub ignored",
+			backups: func() []veleroapi.Backup {
+				backups := []veleroapi.Backup{
+					backup(Resources, 1, veleroapi.BackupPhaseCompleted, 3),
+				}
+				backups[0].Labels[BackupScheduleClusterLabel] = "hub-2"
+				return backups
+			}(),
+			wantStatus: metav1.ConditionFalse,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isScheduleSpecUpdated(tt.args.schedules, tt.args.backupSchedule); got != tt.want {
-				t.Errorf("isScheduleSpecUpdated() = %v, want %v", got, tt.want)
+			backupSchedule := createBackupSchedule("name", "ns").object
+			// warnings from a previous reconcile are replaced
+			backupSchedule.Status.BackupWarnings = map[string]int{"old": 1}
+
+			setBackupWarnings(schedules, tt.backups, backupSchedule)
+
+			if !reflect.DeepEqual(backupSchedule.Status.BackupWarnings, tt.wantWarnings) {
+				t.Errorf("setBackupWarnings() BackupWarnings = %v, want %v",
+					backupSchedule.Status.BackupWarnings, tt.wantWarnings)
+			}
+			condition := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionBackupsWithWarnings)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Fatalf("setBackupWarnings() %s condition = %v, want %v",
+					v1beta1.ScheduleConditionBackupsWithWarnings, condition, tt.wantStatus)
+			}
+			if !strings.Contains(condition.Message, tt.wantMessage) {
+				t.Errorf("setBackupWarnings() condition message %q should contain %q",
+					condition.Message, tt.wantMessage)
 			}
 		})
 	}
 }
 
-func Test_deleteVeleroSchedules(t *testing.T) {
-	veleroNamespaceName := "backup-ns"
-	veleroNamespace := *createNamespace(veleroNamespaceName)
-
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
+func Test_updateStorageUsageStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		storageLocations []veleroapi.BackupStorageLocation
+		threshold        int
+		wantCapacity     string
+		wantUsed         string
+		wantWarning      metav1.ConditionStatus
+	}{
+		{
+			name: "storage location without capacity annotations",
+			storageLocations: []veleroapi.BackupStorageLocation{
+				*createStorageLocation("default", "ns").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).object,
+			},
+		},
+		{
+			name: "storage location with invalid capacity annotations",
+			storageLocations: []veleroapi.BackupStorageLocation{
+				*createStorageLocation("default", "ns").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).
+					annotations(map[string]string{
+						BackupStorageCapacityAnnotation: "abc",
+						BackupStorageUsedAnnotation:     "10Gi",
+					}).object,
+			},
+		},
+		{
+			name: "storage location from another namespace",
+			storageLocations: []veleroapi.BackupStorageLocation{
+				*createStorageLocation("default", "ns1").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).
+					annotations(map[string]string{
+						BackupStorageCapacityAnnotation: "100Gi",
+						BackupStorageUsedAnnotation:     "90Gi",
+					}).object,
+			},
+		},
+		{
+			name: "usage under the default threshold",
+			storageLocations: []veleroapi.BackupStorageLocation{
+				*createStorageLocation("default", "ns").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).
+					annotations(map[string]string{
+						BackupStorageCapacityAnnotation: "100Gi",
+						BackupStorageUsedAnnotation:     "50Gi",
+					}).object,
+			},
+			wantCapacity: "100Gi",
+			wantUsed:     "50Gi",
+			wantWarning:  metav1.ConditionFalse,
+		},
+		{
+			name: "usage over the default threshold",
+			storageLocations: []veleroapi.BackupStorageLocation{
+				*createStorageLocation("default", "ns").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).
+					annotations(map[string]string{
+						BackupStorageCapacityAnnotation: "100Gi",
+						BackupStorageUsedAnnotation:     "85Gi",
+					}).object,
+			},
+			wantCapacity: "100Gi",
+			wantUsed:     "85Gi",
+			wantWarning:  metav1.ConditionTrue,
+		},
+		{
+			name: "usage under a custom threshold",
+			storageLocations: []veleroapi.BackupStorageLocation{
+				*createStorageLocation("default", "ns").
+					phase(veleroapi.BackupStorageLocationPhaseAvailable).
+					annotations(map[string]string{
+						BackupStorageCapacityAnnotation: "1Ti",
+						BackupStorageUsedAnnotation:     "900Gi",
+					}).object,
+			},
+			threshold:    95,
+			wantCapacity: "1Ti",
+			wantUsed:     "900Gi",
+			wantWarning:  metav1.ConditionFalse,
 		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
-	unstructuredScheme := runtime.NewScheme()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").object
+			backupSchedule.Spec.StorageUsageWarningThreshold = tt.threshold
 
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: unstructuredScheme})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+			updateStorageUsageStatus(context.Background(), tt.storageLocations, "ns", backupSchedule)
+
+			if backupSchedule.Status.StorageCapacity != tt.wantCapacity ||
+				backupSchedule.Status.StorageUsed != tt.wantUsed {
+				t.Errorf("updateStorageUsageStatus() capacity = %v, used = %v, want capacity = %v, used = %v",
+					backupSchedule.Status.StorageCapacity, backupSchedule.Status.StorageUsed,
+					tt.wantCapacity, tt.wantUsed)
+			}
+			cond := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionStorageUsageWarning)
+			if tt.wantWarning == "" {
+				if cond != nil {
+					t.Errorf("updateStorageUsageStatus() StorageUsageWarning should not be set, got %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Status != tt.wantWarning {
+				t.Errorf("updateStorageUsageStatus() StorageUsageWarning = %v, want %v", cond, tt.wantWarning)
+			}
+		})
 	}
-	err = veleroapi.AddToScheme(unstructuredScheme)
-	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
+}
+
+func Test_getMissingVeleroSchedules(t *testing.T) {
+	allSchedules := initVeleroScheduleTypes()
+
+	oneDeleted := initVeleroScheduleTypes()
+	oneDeleted.Items = append(oneDeleted.Items[:1], oneDeleted.Items[2:]...)
+
+	duplicates := initVeleroScheduleTypes()
+	duplicates.Items = append(duplicates.Items[:4], duplicates.Items[0])
+
+	tests := []struct {
+		name      string
+		schedules *veleroapi.ScheduleList
+		want      []ResourceType
+	}{
+		{
+			name:      "nil schedules, all are missing",
+			schedules: nil,
+			want: []ResourceType{Credentials, ManagedClusters, Resources,
+				ResourcesGeneric, ValidationSchedule},
+		},
+		{
+			name:      "all schedules found",
+			schedules: allSchedules,
+			want:      []ResourceType{},
+		},
+		{
+			name:      "one schedule deleted",
+			schedules: oneDeleted,
+			want:      []ResourceType{Resources},
+		},
+		{
+			name:      "same number of schedules, but one schedule missing",
+			schedules: duplicates,
+			want:      []ResourceType{ValidationSchedule},
+		},
 	}
-	err = corev1.AddToScheme(unstructuredScheme)
-	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getMissingVeleroSchedules(tt.schedules); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getMissingVeleroSchedules() = %v, want %v", got, tt.want)
+			}
+		})
 	}
+}
 
-	if err := k8sClient1.Create(context.Background(), &veleroNamespace); err != nil {
-		t.Fatalf("cannot create ns %s ", err.Error())
+func Test_isConfigMapsScheduleChanged(t *testing.T) {
+	configMapSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app-config": "true"},
+	}
+	tests := []struct {
+		name      string
+		schedules *veleroapi.ScheduleList
+		selector  *metav1.LabelSelector
+		want      bool
+	}{
+		{
+			name:      "no selector, no configmaps schedule",
+			schedules: initVeleroScheduleTypes(),
+			want:      false,
+		},
+		{
+			name:      "selector set, configmaps schedule found",
+			schedules: initVeleroSchedulesWithConfigMaps("0 6 * * *", metav1.Duration{}, configMapSelector),
+			selector:  configMapSelector,
+			want:      false,
+		},
+		{
+			name:      "selector set, configmaps schedule must be created",
+			schedules: initVeleroScheduleTypes(),
+			selector:  configMapSelector,
+			want:      true,
+		},
+		{
+			name:      "selector removed, configmaps schedule must be deleted",
+			schedules: initVeleroSchedulesWithConfigMaps("0 6 * * *", metav1.Duration{}, configMapSelector),
+			want:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("name", "ns").includeConfigMapSelector(tt.selector).object
+			if got := isConfigMapsScheduleChanged(tt.schedules, backupSchedule); got != tt.want {
+				t.Errorf("isConfigMapsScheduleChanged() = %v, want %v", got, tt.want)
+			}
+		})
 	}
+}
 
-	rhacmBackupSchedule := *createBackupSchedule("backup-sch-to-error-restore", veleroNamespaceName).
-		schedule("0 8 * * *").
-		veleroTTL(metav1.Duration{Duration: time.Hour * 72}).
-		object
+func Test_getUnownedVeleroSchedules(t *testing.T) {
+	ns := "backup-ns"
+	backupSchedule := createBackupSchedule("acm-schedule", ns).object
 
-	veleroSchedules := initVeleroScheduleList(veleroNamespace.Name, veleroapi.SchedulePhaseNew, "0 8 * * *",
-		metav1.Duration{Duration: time.Second * 5})
-	for i := range veleroSchedules.Items {
-		veleroSchedule := &veleroSchedules.Items[i]
-		veleroSchedule.Namespace = veleroNamespaceName
-		if err := k8sClient1.Create(context.Background(), veleroSchedule); err != nil {
-			t.Errorf("cannot create veleroSchedule %s ", err.Error())
-		}
+	ownerRefs := []metav1.OwnerReference{
+		{
+			APIVersion: apiGVString,
+			Kind:       "BackupSchedule",
+			Name:       backupSchedule.Name,
+			Controller: &[]bool{true}[0],
+		},
+	}
+	ownedSchedule := func(name string) *veleroapi.Schedule {
+		schedule := createSchedule(name, ns).object
+		schedule.OwnerReferences = ownerRefs
+		return schedule
 	}
 
-	type argsDelete struct {
-		ctx            context.Context
-		c              client.Client
-		backupSchedule *v1beta1.BackupSchedule
-		schedules      *veleroapi.ScheduleList
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	testsForDelete := []struct {
-		name string
-		args argsDelete
-		want bool
+	tests := []struct {
+		name          string
+		schedules     []client.Object
+		want          []string
+		wantPhase     v1beta1.SchedulePhase
+		wantCondition metav1.ConditionStatus
 	}{
 		{
-			name: "velero schedules is nil",
-			args: argsDelete{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				schedules:      nil,
-			},
-			want: false,
+			name:          "no velero schedules",
+			schedules:     []client.Object{},
+			want:          []string{},
+			wantCondition: metav1.ConditionFalse,
 		},
 		{
-			name: "no velero schedules Items",
-			args: argsDelete{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				schedules:      &veleroapi.ScheduleList{},
+			name: "all velero schedules are owned",
+			schedules: []client.Object{
+				ownedSchedule(veleroScheduleNames[Credentials]),
+				ownedSchedule(veleroScheduleNames[ManagedClusters]),
+				ownedSchedule(veleroScheduleNames[Resources]),
 			},
-			want: false,
+			want:          []string{},
+			wantCondition: metav1.ConditionFalse,
 		},
 		{
-			name: "failed to delete the schedule, returns error",
-			args: argsDelete{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				schedules: &veleroapi.ScheduleList{
-					Items: []veleroapi.Schedule{
-						{
-							TypeMeta: metav1.TypeMeta{
-								APIVersion: "velero/v1",
-								Kind:       "Schedule",
-							},
-							ObjectMeta: metav1.ObjectMeta{
-								Name:      "schedule-invalid-not-found",
-								Namespace: veleroNamespaceName,
-							},
-						},
-					},
-				},
+			name: "owner reference removed from velero schedules",
+			schedules: []client.Object{
+				createSchedule(veleroScheduleNames[Resources], ns).object,
+				createSchedule(veleroScheduleNames[Credentials], ns).object,
+				createSchedule("some-other-schedule", ns).object,
+				createSchedule(veleroScheduleNames[ManagedClusters], "other-ns").object,
 			},
-			want: true,
+			want: []string{
+				veleroScheduleNames[Credentials],
+				veleroScheduleNames[Resources],
+			},
+			wantPhase:     v1beta1.SchedulePhaseUnknown,
+			wantCondition: metav1.ConditionTrue,
 		},
 		{
-			name: "successfully delete the schedule, returns no error",
-			args: argsDelete{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				schedules:      veleroSchedules,
+			name: "owner reference removed from one velero schedule",
+			schedules: []client.Object{
+				ownedSchedule(veleroScheduleNames[Credentials]),
+				createSchedule(veleroScheduleNames[ManagedClusters], ns).object,
+				ownedSchedule(veleroScheduleNames[Resources]),
 			},
-			want: false,
+			want: []string{
+				veleroScheduleNames[ManagedClusters],
+			},
+			wantPhase:     v1beta1.SchedulePhaseUnknown,
+			wantCondition: metav1.ConditionTrue,
 		},
 	}
-	for _, tt := range testsForDelete {
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := deleteVeleroSchedules(tt.args.ctx, tt.args.c,
-				tt.args.backupSchedule, tt.args.schedules); (got != nil) != tt.want {
-				t.Errorf(
-					"deleteVeleroSchedules() = %v, want len of string is empty %v",
-					got,
-					tt.want,
-				)
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(tt.schedules...).
+				WithIndex(&veleroapi.Schedule{}, scheduleOwnerKey, indexScheduleOwner).
+				Build()
+
+			ownedSchedules := veleroapi.ScheduleList{}
+			if err := fakeClient.List(context.Background(), &ownedSchedules,
+				client.InNamespace(ns),
+				client.MatchingFields{scheduleOwnerKey: backupSchedule.Name}); err != nil {
+				t.Fatalf("Error listing schedules: %s", err.Error())
+			}
+
+			got, err := getUnownedVeleroSchedules(context.Background(), fakeClient, ns, &ownedSchedules)
+			if err != nil {
+				t.Errorf("getUnownedVeleroSchedules() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getUnownedVeleroSchedules() = %v, want %v", got, tt.want)
+			}
+
+			bs := backupSchedule.DeepCopy()
+			setUnownedSchedulesStatus(bs, got)
+			if bs.Status.Phase != tt.wantPhase {
+				t.Errorf("setUnownedSchedulesStatus() phase = %v, want %v", bs.Status.Phase, tt.wantPhase)
+			}
+			if !meta.IsStatusConditionPresentAndEqual(bs.Status.Conditions,
+				v1beta1.ScheduleConditionUnownedSchedulesDetected, tt.wantCondition) {
+				t.Errorf("setUnownedSchedulesStatus() condition %s is not %v",
+					v1beta1.ScheduleConditionUnownedSchedulesDetected, tt.wantCondition)
 			}
 		})
 	}
+}
 
-	veleroSchedulesUpdate := initVeleroScheduleList(veleroNamespaceName, veleroapi.SchedulePhaseNew, "0 8 * * *",
-		metav1.Duration{Duration: time.Second * 5})
+func Test_listVeleroSchedules(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
 
-	type argsUpdate struct {
-		ctx               context.Context
-		c                 client.Client
-		backupSchedule    *v1beta1.BackupSchedule
-		schedules         *veleroapi.ScheduleList
-		resourcesToBackup []string
+	ownerRefs := []metav1.OwnerReference{
+		{
+			APIVersion: apiGVString,
+			Kind:       "BackupSchedule",
+			Name:       "acm-schedule",
+			Controller: &[]bool{true}[0],
+		},
 	}
+	ownedSchedule := createSchedule(veleroScheduleNames[Credentials], "acm-ns").object
+	ownedSchedule.OwnerReferences = ownerRefs
+	labeledSchedule := createSchedule(veleroScheduleNames[Credentials], "velero-ns").
+		scheduleLabels(map[string]string{BackupScheduleNameLabel: "acm-schedule"}).object
+	otherSchedule := createSchedule(veleroScheduleNames[Resources], "velero-ns").
+		scheduleLabels(map[string]string{BackupScheduleNameLabel: "other-schedule"}).object
 
-	testsForSchedulesUpdateRequired := []struct {
-		name string
-		args argsUpdate
-		want bool
-		err  string
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(ownedSchedule, labeledSchedule, otherSchedule).
+		WithIndex(&veleroapi.Schedule{}, scheduleOwnerKey, indexScheduleOwner).
+		Build()
+
+	tests := []struct {
+		name           string
+		backupSchedule *v1beta1.BackupSchedule
+		wantNamespace  string
 	}{
 		{
-			name: "velero schedules is empty",
-			args: argsUpdate{
-				ctx:               context.Background(),
-				c:                 k8sClient1,
-				backupSchedule:    &rhacmBackupSchedule,
-				schedules:         &veleroapi.ScheduleList{},
-				resourcesToBackup: []string{},
-			},
-			want: false,
-			err:  "",
+			name:           "velero namespace not set, list owned schedules",
+			backupSchedule: createBackupSchedule("acm-schedule", "acm-ns").object,
+			wantNamespace:  "acm-ns",
 		},
 		{
-			name: "velero schedules is not empty, but schedules cannot be updated, not found",
-			args: argsUpdate{
-				ctx:               context.Background(),
-				c:                 k8sClient1,
-				backupSchedule:    &rhacmBackupSchedule,
-				schedules:         veleroSchedulesUpdate,
-				resourcesToBackup: []string{},
-			},
-			want: true,
-			err:  `schedules.velero.io "acm-credentials-schedule" not found`,
+			name: "velero namespace same as the BackupSchedule namespace",
+			backupSchedule: createBackupSchedule("acm-schedule", "acm-ns").
+				veleroNamespace("acm-ns").object,
+			wantNamespace: "acm-ns",
 		},
 		{
-			name: "velero schedules is not empty, schedules are updated",
-			args: argsUpdate{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
-					schedule("0 6 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Hour * 72}).
-					object,
-				schedules:         veleroSchedulesUpdate,
-				resourcesToBackup: []string{},
+			name: "separate velero namespace, list schedules by label",
+			backupSchedule: createBackupSchedule("acm-schedule", "acm-ns").
+				veleroNamespace("velero-ns").object,
+			wantNamespace: "velero-ns",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getVeleroNamespace(tt.backupSchedule); got != tt.wantNamespace {
+				t.Errorf("getVeleroNamespace() = %v, want %v", got, tt.wantNamespace)
+			}
+
+			veleroScheduleList := veleroapi.ScheduleList{}
+			if err := listVeleroSchedules(context.Background(), fakeClient, tt.backupSchedule,
+				&veleroScheduleList); err != nil {
+				t.Fatalf("listVeleroSchedules() error = %v", err)
+			}
+			if len(veleroScheduleList.Items) != 1 ||
+				veleroScheduleList.Items[0].Name != veleroScheduleNames[Credentials] ||
+				veleroScheduleList.Items[0].Namespace != tt.wantNamespace {
+				t.Errorf("listVeleroSchedules() = %v, want the %s schedule from namespace %s",
+					veleroScheduleList.Items, veleroScheduleNames[Credentials], tt.wantNamespace)
+			}
+		})
+	}
+}
+
+func Test_initVeleroSchedules_targetNamespaces(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := backupv1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	backupSchedule := createBackupSchedule("acm-schedule", "acm-ns").schedule("0 */6 * * *").
+		targetNamespaces([]string{"acm-ns", "velero-ns"}).object
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(backupSchedule).
+		WithIndex(&veleroapi.Schedule{}, scheduleOwnerKey, indexScheduleOwner).
+		Build()
+	fakeDiscovery := &discoveryfake.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "hive.openshift.io/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "clusterdeployments", Kind: "ClusterDeployment", Namespaced: true},
+					},
+				},
 			},
-			want: true,
-			err:  "",
+		},
+	}
+	hiveGVR := schema.GroupVersionResource{Group: "hive.openshift.io", Version: "v1", Resource: "clusterdeployments"}
+	r := &BackupScheduleReconciler{
+		Client:          fakeClient,
+		DiscoveryClient: fakeDiscovery,
+		DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{hiveGVR: "ClusterDeploymentList"}),
+		Scheme: scheme1,
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(fakeDiscovery))
+
+	createdCounter := veleroSchedulesCreated.WithLabelValues("acm-ns", "acm-schedule")
+	createdCount := testutil.ToFloat64(createdCounter)
+	if err := r.initVeleroSchedules(context.Background(), mapper, backupSchedule, "cluster1"); err != nil {
+		t.Fatalf("initVeleroSchedules() error = %v", err)
+	}
+	if got := testutil.ToFloat64(createdCounter); got != createdCount+float64(2*len(veleroScheduleNames)) {
+		t.Errorf("acm_backup_velero_schedules_created_total = %v, want %v", got,
+			createdCount+float64(2*len(veleroScheduleNames)))
+	}
+
+	// the velero schedules are created in both target namespaces
+	veleroScheduleList := veleroapi.ScheduleList{}
+	if err := listVeleroSchedules(context.Background(), fakeClient, backupSchedule,
+		&veleroScheduleList); err != nil {
+		t.Fatalf("listVeleroSchedules() error = %v", err)
+	}
+	for _, ns := range []string{"acm-ns", "velero-ns"} {
+		nsSchedules := filterSchedulesByNamespace(&veleroScheduleList, ns)
+		if missing := getMissingVeleroSchedules(nsSchedules); len(missing) > 0 {
+			t.Errorf("velero schedules %v not created in namespace %s", missing, ns)
+		}
+		for i := range nsSchedules.Items {
+			owned := metav1.GetControllerOf(&nsSchedules.Items[i]) != nil
+			if owned != (ns == "acm-ns") {
+				t.Errorf("velero schedule %s/%s owned = %v", ns, nsSchedules.Items[i].Name, owned)
+			}
+		}
+	}
+	if len(veleroScheduleList.Items) != 2*len(veleroScheduleNames) {
+		t.Errorf("listVeleroSchedules() returned %d velero schedules, want %d",
+			len(veleroScheduleList.Items), 2*len(veleroScheduleNames))
+	}
+
+	// the status lists the schedules from the other target namespace with their namespace
+	backupSchedule.Status.VeleroScheduleNames = nil
+	for i := range veleroScheduleList.Items {
+		updateScheduleStatus(context.Background(), &veleroScheduleList.Items[i], backupSchedule)
+	}
+	if !findValue(backupSchedule.Status.VeleroScheduleNames, veleroScheduleNames[Resources]) ||
+		!findValue(backupSchedule.Status.VeleroScheduleNames, "velero-ns/"+veleroScheduleNames[Resources]) {
+		t.Errorf("VeleroScheduleNames = %v, want the schedules from both namespaces",
+			backupSchedule.Status.VeleroScheduleNames)
+	}
+	if backupSchedule.Status.VeleroScheduleResources == nil ||
+		backupSchedule.Status.VeleroScheduleResources.Namespace != "acm-ns" {
+		t.Errorf("VeleroScheduleResources = %v, want the schedule from the first target namespace",
+			backupSchedule.Status.VeleroScheduleResources)
+	}
+
+	// the velero schedules from the other namespace are deleted with the BackupSchedule
+	if _, err := processVeleroSchedulesFinalizer(context.Background(), fakeClient, backupSchedule); err != nil {
+		t.Fatalf("processVeleroSchedulesFinalizer() error = %v", err)
+	}
+	if !findValue(backupSchedule.Finalizers, VeleroSchedulesFinalizer) {
+		t.Errorf("finalizer %s not set", VeleroSchedulesFinalizer)
+	}
+}
+
+// returns true if velero backs up a namespaced resource with the given labels using this backup spec
+func isBackedUpByTemplate(
+	template *veleroapi.BackupSpec,
+	resource string,
+	resourceLabels map[string]string,
+) bool {
+	if len(template.IncludedResources) > 0 && !findValue(template.IncludedResources, resource) {
+		return false
+	}
+	if findValue(template.ExcludedResources, resource) {
+		return false
+	}
+	selectors := template.OrLabelSelectors
+	if template.LabelSelector != nil {
+		selectors = []*metav1.LabelSelector{template.LabelSelector}
+	}
+	if len(selectors) == 0 {
+		return true
+	}
+	for _, labelSelector := range selectors {
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err == nil && selector.Matches(labels.Set(resourceLabels)) {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_initVeleroSchedules_includeConfigMapSelector(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := backupv1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	appConfigLabels := map[string]string{"app-config": "true"}
+	type resource struct {
+		kind   string
+		labels map[string]string
+	}
+	tests := []struct {
+		name         string
+		selector     *metav1.LabelSelector
+		wantBackedUp map[string]resource
+		wantExcluded map[string]resource
+	}{
+		{
+			name: "no configmap selector, no configmaps schedule",
+			wantExcluded: map[string]resource{
+				"labeled configmap": {kind: "configmap", labels: appConfigLabels},
+				"other configmap":   {kind: "configmap", labels: map[string]string{"app": "other"}},
+				"labeled secret":    {kind: "secret", labels: appConfigLabels},
+			},
+		},
+		{
+			name:     "configmap selector, only the labeled configmaps are backed up",
+			selector: &metav1.LabelSelector{MatchLabels: appConfigLabels},
+			wantBackedUp: map[string]resource{
+				"labeled configmap": {kind: "configmap", labels: appConfigLabels},
+			},
+			wantExcluded: map[string]resource{
+				"other configmap": {kind: "configmap", labels: map[string]string{"app": "other"}},
+				"labeled secret":  {kind: "secret", labels: appConfigLabels},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupSchedule := createBackupSchedule("acm-schedule", "acm-ns").schedule("0 */6 * * *").
+				includeConfigMapSelector(tt.selector).object
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(backupSchedule).
+				WithIndex(&veleroapi.Schedule{}, scheduleOwnerKey, indexScheduleOwner).
+				Build()
+			fakeDiscovery := &discoveryfake.FakeDiscovery{
+				Fake: &clienttesting.Fake{
+					Resources: []*metav1.APIResourceList{
+						{
+							GroupVersion: "hive.openshift.io/v1",
+							APIResources: []metav1.APIResource{
+								{Name: "clusterdeployments", Kind: "ClusterDeployment", Namespaced: true},
+							},
+						},
+						{
+							GroupVersion: "apps.open-cluster-management.io/v1",
+							APIResources: []metav1.APIResource{
+								{Name: "channels", SingularName: "channel", Kind: "Channel", Namespaced: true},
+							},
+						},
+					},
+				},
+			}
+			hiveGVR := schema.GroupVersionResource{Group: "hive.openshift.io", Version: "v1",
+				Resource: "clusterdeployments"}
+			r := &BackupScheduleReconciler{
+				Client:          fakeClient,
+				DiscoveryClient: fakeDiscovery,
+				DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{hiveGVR: "ClusterDeploymentList"}),
+				Scheme: scheme1,
+			}
+			mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(fakeDiscovery))
+
+			if err := r.initVeleroSchedules(context.Background(), mapper, backupSchedule, "cluster1"); err != nil {
+				t.Fatalf("initVeleroSchedules() error = %v", err)
+			}
+
+			veleroScheduleList := veleroapi.ScheduleList{}
+			if err := listVeleroSchedules(context.Background(), fakeClient, backupSchedule,
+				&veleroScheduleList); err != nil {
+				t.Fatalf("listVeleroSchedules() error = %v", err)
+			}
+			if isConfigMapsScheduleChanged(&veleroScheduleList, backupSchedule) {
+				t.Errorf("velero schedules %v, configmaps schedule created = %v, want %v",
+					veleroScheduleList.Items, tt.selector == nil, tt.selector != nil)
+			}
+			if missing := getMissingVeleroSchedules(&veleroScheduleList); len(missing) > 0 {
+				t.Errorf("velero schedules %v not created", missing)
+			}
+
+			backedUpBy := func(res resource) []string {
+				schedules := []string{}
+				for i := range veleroScheduleList.Items {
+					if isBackedUpByTemplate(&veleroScheduleList.Items[i].Spec.Template, res.kind, res.labels) {
+						schedules = append(schedules, veleroScheduleList.Items[i].Name)
+					}
+				}
+				return schedules
+			}
+			for name, res := range tt.wantBackedUp {
+				if got := backedUpBy(res); !reflect.DeepEqual(got, []string{configMapsScheduleName}) {
+					t.Errorf("%s backed up by %v, want %s", name, got, configMapsScheduleName)
+				}
+			}
+			for name, res := range tt.wantExcluded {
+				if got := backedUpBy(res); len(got) > 0 {
+					t.Errorf("%s backed up by %v, want not backed up", name, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_processVeleroSchedulesFinalizer(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	newSchedules := func() []client.Object {
+		return []client.Object{
+			createSchedule(veleroScheduleNames[Credentials], "velero-ns").
+				scheduleLabels(map[string]string{BackupScheduleNameLabel: "acm-schedule"}).object,
+			createSchedule(veleroScheduleNames[Resources], "velero-ns").
+				scheduleLabels(map[string]string{BackupScheduleNameLabel: "acm-schedule"}).object,
+			createSchedule(veleroScheduleNames[Resources], "other-ns").
+				scheduleLabels(map[string]string{BackupScheduleNameLabel: "acm-schedule"}).object,
+		}
+	}
+
+	deletionTime := metav1.Now()
+	tests := []struct {
+		name                string
+		backupSchedule      *v1beta1.BackupSchedule
+		wantDeleted         bool
+		wantFinalizer       bool
+		wantVeleroSchedules int
+	}{
+		{
+			name:                "velero namespace not set, no finalizer",
+			backupSchedule:      createBackupSchedule("acm-schedule", "acm-ns").object,
+			wantDeleted:         false,
+			wantFinalizer:       false,
+			wantVeleroSchedules: 3,
+		},
+		{
+			name: "separate velero namespace, finalizer added",
+			backupSchedule: createBackupSchedule("acm-schedule", "acm-ns").
+				veleroNamespace("velero-ns").object,
+			wantDeleted:         false,
+			wantFinalizer:       true,
+			wantVeleroSchedules: 3,
+		},
+		{
+			name: "separate velero namespace, BackupSchedule deleted",
+			backupSchedule: func() *v1beta1.BackupSchedule {
+				bs := createBackupSchedule("acm-schedule", "acm-ns").
+					veleroNamespace("velero-ns").object
+				bs.Finalizers = []string{VeleroSchedulesFinalizer}
+				bs.DeletionTimestamp = &deletionTime
+				return bs
+			}(),
+			wantDeleted:         true,
+			wantFinalizer:       false,
+			wantVeleroSchedules: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(append(newSchedules(), tt.backupSchedule)...).
+				WithIndex(&veleroapi.Schedule{}, scheduleOwnerKey, indexScheduleOwner).
+				Build()
+
+			deleted, err := processVeleroSchedulesFinalizer(context.Background(), fakeClient, tt.backupSchedule)
+			if err != nil {
+				t.Errorf("processVeleroSchedulesFinalizer() error = %v", err)
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("processVeleroSchedulesFinalizer() = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if got := findValue(tt.backupSchedule.Finalizers, VeleroSchedulesFinalizer); got != tt.wantFinalizer {
+				t.Errorf("processVeleroSchedulesFinalizer() finalizer set = %v, want %v", got, tt.wantFinalizer)
+			}
+
+			veleroScheduleList := veleroapi.ScheduleList{}
+			if err := fakeClient.List(context.Background(), &veleroScheduleList); err != nil {
+				t.Fatalf("Error listing schedules: %s", err.Error())
+			}
+			if len(veleroScheduleList.Items) != tt.wantVeleroSchedules {
+				t.Errorf("processVeleroSchedulesFinalizer() velero schedules = %v, want %v",
+					len(veleroScheduleList.Items), tt.wantVeleroSchedules)
+			}
+		})
+	}
+}
+
+func Test_getSchedulesWithUpdatedResources(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	type args struct {
+		resourcesToBackup []string
+		schedules         *veleroapi.ScheduleList
+	}
+
+	resourcesToBackup := []string{
+		"channel.apps.open-cluster-management.io",
+		"iampolicy.policy.open-cluster-management.io",
+		"agentclusterinstall.extensions.hive.openshift.io",
+		"application.app.k8s.io",
+		"agentserviceconfig.agent-install.openshift.io",
+		"observatorium.core.observatorium.io",
+	}
+	schedules := initVeleroScheduleTypes()
+
+	veleroSchedulesToUpdate := make([]veleroapi.Schedule, 0)
+
+	for i := range schedules.Items {
+		veleroSchedule := &schedules.Items[i]
+		veleroBackupTemplate := &veleroapi.BackupSpec{}
+
+		switch veleroSchedule.Name {
+		case veleroScheduleNames[Resources]:
+			setResourcesBackupInfo(context.Background(), veleroBackupTemplate, resourcesToBackup,
+				"open-cluster-management-backup", k8sClient1)
+			veleroSchedulesToUpdate = append(
+				veleroSchedulesToUpdate,
+				*veleroSchedule,
+			)
+		case veleroScheduleNames[ResourcesGeneric]:
+			setGenericResourcesBackupInfo(veleroBackupTemplate, resourcesToBackup)
+			veleroSchedulesToUpdate = append(
+				veleroSchedulesToUpdate,
+				*veleroSchedule,
+			)
+		case veleroScheduleNames[ManagedClusters]:
+			setManagedClustersBackupInfo(context.Background(), veleroBackupTemplate, resourcesToBackup,
+				createBackupSchedule("name", "ns").object, k8sClient1)
+			veleroSchedulesToUpdate = append(
+				veleroSchedulesToUpdate,
+				*veleroSchedule,
+			)
+		}
+
+		veleroSchedule.Spec.Template = *veleroBackupTemplate
+	}
+
+	var newResourcesToBackup []string
+	newResourcesToBackup = append(newResourcesToBackup, resourcesToBackup...)
+	newResourcesToBackup = append(
+		newResourcesToBackup,
+		"baremetalasset.inventory.open-cluster-management.io",
+	)
+	newResourcesToBackup = append(newResourcesToBackup, "x.hive.openshift.io")
+	newResourcesToBackup = append(newResourcesToBackup, "y.hive.openshift.io")
+
+	tests := []struct {
+		name string
+		args args
+		want []veleroapi.Schedule
+	}{
+		{
+			name: "nil arguments",
+			args: args{
+				resourcesToBackup: nil,
+				schedules:         nil,
+			},
+			want: []veleroapi.Schedule{},
+		},
+		{
+			name: "unchanged hub resources",
+			args: args{
+				resourcesToBackup: resourcesToBackup,
+				schedules:         schedules,
+			},
+			want: []veleroapi.Schedule{},
+		},
+		{
+			name: "changed hub resources",
+			args: args{
+				resourcesToBackup: newResourcesToBackup,
+				schedules:         schedules,
+			},
+			want: veleroSchedulesToUpdate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getSchedulesWithUpdatedResources(tt.args.resourcesToBackup, tt.args.schedules)
+			if len(got) != len(tt.want) {
+				t.Errorf("getSchedulesWithUpdatedResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	// clean up
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_isScheduleSpecUpdated(t *testing.T) {
+	type args struct {
+		schedules      *veleroapi.ScheduleList
+		backupSchedule *v1beta1.BackupSchedule
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "no schedules",
+			args: args{
+				schedules: nil,
+			},
+			want: false,
+		},
+		{
+			name: "same schedule and ttl",
+			args: args{
+				schedules: initVeleroSchedulesWithSpecs(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					object,
+			},
+			want: false,
+		},
+		{
+			name: "velero schedules paused, backup schedule no longer paused",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[Credentials], "ns").
+							schedule("0 6 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							paused(true).object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "schedule updated",
+			args: args{
+				schedules: initVeleroSchedulesWithSpecs(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 8 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "ttl updated",
+			args: args{
+				schedules: initVeleroSchedulesWithSpecs(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 2}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "excluded addon namespaces updated",
+			args: args{
+				schedules: initVeleroSchedulesWithSpecs(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					excludedAddonNamespaces([]string{"addon-ns"}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "included namespaces updated",
+			args: args{
+				schedules: initVeleroSchedulesWithSpecs(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					includedNamespaces([]string{"app-ns"}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "credentials OR label selectors updated",
+			args: args{
+				schedules: initVeleroSchedulesWithSpecs(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					credentialsOrLabelSelectors([]*metav1.LabelSelector{
+						{MatchLabels: map[string]string{"backup-creds": "true"}},
+					}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "include configmap selector not changed",
+			args: args{
+				schedules: initVeleroSchedulesWithConfigMaps(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+					&metav1.LabelSelector{
+						MatchLabels: map[string]string{"app-config": "true"},
+					},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					includeConfigMapSelector(&metav1.LabelSelector{
+						MatchLabels: map[string]string{"app-config": "true"},
+					}).
+					object,
+			},
+			want: false,
+		},
+		{
+			name: "include configmap selector updated",
+			args: args{
+				schedules: initVeleroSchedulesWithConfigMaps(
+					"0 6 * * *",
+					metav1.Duration{Duration: time.Hour * 1},
+					&metav1.LabelSelector{
+						MatchLabels: map[string]string{"app-config": "true"},
+					},
+				),
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					includeConfigMapSelector(&metav1.LabelSelector{
+						MatchLabels: map[string]string{"app-config": "prod"},
+					}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "staggered schedules not updated",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[Credentials], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(Credentials)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							orLabelSelectors(getCredentialsOrLabelSelectors(nil)).
+							object,
+						*createSchedule(veleroScheduleNames[ManagedClusters], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ManagedClusters)}).
+							schedule("5 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							object,
+						*createSchedule(veleroScheduleNames[Resources], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(Resources)}).
+							schedule("10 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							excludedNamespaces([]string{"ns"}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					staggerSchedules(true).
+					object,
+			},
+			want: false,
+		},
+		{
+			name: "stagger schedules enabled",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[ManagedClusters], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ManagedClusters)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					staggerSchedules(true).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "managed clusters volume snapshot locations updated",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[ManagedClusters], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ManagedClusters)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							volumeSnapshotLocations([]string{"vsl-1"}).
+							object,
+						*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ResourcesGeneric)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							volumeSnapshotLocations([]string{"vsl-1"}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					setVolumeSnapshotLocation([]string{"vsl-1"}).
+					managedClustersVolumeSnapshotLocations([]string{"vsl-clusters"}).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "volume snapshot locations not changed",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[ManagedClusters], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ManagedClusters)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							volumeSnapshotLocations([]string{"vsl-clusters"}).
+							object,
+						*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ResourcesGeneric)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							volumeSnapshotLocations([]string{"vsl-1"}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					setVolumeSnapshotLocation([]string{"vsl-1"}).
+					managedClustersVolumeSnapshotLocations([]string{"vsl-clusters"}).
+					object,
+			},
+			want: false,
+		},
+		{
+			name: "incremental backup enabled",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[Resources], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(Resources)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							excludedNamespaces([]string{"ns"}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					incrementalBackup(true).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "incremental backup not changed",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[Resources], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(Resources)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							excludedNamespaces([]string{"ns"}).
+							snapshotMoveData(true).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					incrementalBackup(true).
+					object,
+			},
+			want: false,
+		},
+		{
+			name: "local cluster excluded from the managed clusters backup",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[ManagedClusters], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ManagedClusters)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					excludeLocalCluster(true).
+					object,
+			},
+			want: true,
+		},
+		{
+			name: "BackupSchedule generation changed",
+			args: args{
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").
+							scheduleLabels(map[string]string{BackupScheduleTypeLabel: string(ResourcesGeneric)}).
+							schedule("0 */2 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+							templateLabels(map[string]string{BackupScheduleGenerationLabel: "1"}).
+							object,
+					},
+				},
+				backupSchedule: createBackupSchedule(
+					"name",
+					"ns",
+				).schedule("0 */2 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 1}).
+					generation(2).
+					object,
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isScheduleSpecUpdated(tt.args.schedules, tt.args.backupSchedule); got != tt.want {
+				t.Errorf("isScheduleSpecUpdated() = %v, want %v", got, tt.want)
+			}
+			if tt.args.schedules == nil {
+				return
+			}
+			// addon namespaces are excluded and namespaces are included only for the resources schedule
+			for _, schedule := range tt.args.schedules.Items {
+				isResourcesSchedule := schedule.Name == veleroScheduleNames[Resources]
+				for _, ns := range tt.args.backupSchedule.Spec.IncludedNamespaces {
+					included := findValue(schedule.Spec.Template.IncludedNamespaces, ns)
+					if included != isResourcesSchedule {
+						t.Errorf("isScheduleSpecUpdated() namespace %s included=%v for schedule %s",
+							ns, included, schedule.Name)
+					}
+				}
+				for _, ns := range tt.args.backupSchedule.Spec.ExcludedAddonNamespaces {
+					excluded := findValue(schedule.Spec.Template.ExcludedNamespaces, ns)
+					if excluded != isResourcesSchedule {
+						t.Errorf("isScheduleSpecUpdated() namespace %s excluded=%v for schedule %s",
+							ns, excluded, schedule.Name)
+					}
+				}
+				// user OR label selectors are merged with the default credentials selectors
+				if schedule.Name == veleroScheduleNames[Credentials] &&
+					!reflect.DeepEqual(schedule.Spec.Template.OrLabelSelectors,
+						getCredentialsOrLabelSelectors(tt.args.backupSchedule.Spec.CredentialsOrLabelSelectors)) {
+					t.Errorf("isScheduleSpecUpdated() OrLabelSelectors = %v for schedule %s",
+						schedule.Spec.Template.OrLabelSelectors, schedule.Name)
+				}
+				// the configmaps schedule uses the include configmap selector
+				if schedule.Name == configMapsScheduleName &&
+					!reflect.DeepEqual(schedule.Spec.Template.LabelSelector,
+						tt.args.backupSchedule.Spec.IncludeConfigMapSelector) {
+					t.Errorf("isScheduleSpecUpdated() LabelSelector = %v for schedule %s",
+						schedule.Spec.Template.LabelSelector, schedule.Name)
+				}
+			}
+		})
+	}
+}
+
+func Test_deleteVeleroSchedules(t *testing.T) {
+	veleroNamespaceName := "backup-ns"
+	veleroNamespace := *createNamespace(veleroNamespaceName)
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	unstructuredScheme := runtime.NewScheme()
+
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: unstructuredScheme})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+	err = veleroapi.AddToScheme(unstructuredScheme)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	err = corev1.AddToScheme(unstructuredScheme)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	if err := k8sClient1.Create(context.Background(), &veleroNamespace); err != nil {
+		t.Fatalf("cannot create ns %s ", err.Error())
+	}
+
+	rhacmBackupSchedule := *createBackupSchedule("backup-sch-to-error-restore", veleroNamespaceName).
+		schedule("0 8 * * *").
+		veleroTTL(metav1.Duration{Duration: time.Hour * 72}).
+		object
+
+	veleroSchedules := initVeleroScheduleList(veleroNamespace.Name, veleroapi.SchedulePhaseNew, "0 8 * * *",
+		metav1.Duration{Duration: time.Second * 5})
+	for i := range veleroSchedules.Items {
+		veleroSchedule := &veleroSchedules.Items[i]
+		veleroSchedule.Namespace = veleroNamespaceName
+		if err := k8sClient1.Create(context.Background(), veleroSchedule); err != nil {
+			t.Errorf("cannot create veleroSchedule %s ", err.Error())
+		}
+	}
+
+	type argsDelete struct {
+		ctx            context.Context
+		c              client.Client
+		backupSchedule *v1beta1.BackupSchedule
+		schedules      *veleroapi.ScheduleList
+	}
+
+	testsForDelete := []struct {
+		name string
+		args argsDelete
+		want bool
+	}{
+		{
+			name: "velero schedules is nil",
+			args: argsDelete{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				schedules:      nil,
+			},
+			want: false,
+		},
+		{
+			name: "no velero schedules Items",
+			args: argsDelete{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				schedules:      &veleroapi.ScheduleList{},
+			},
+			want: false,
+		},
+		{
+			name: "failed to delete the schedule, returns error",
+			args: argsDelete{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				schedules: &veleroapi.ScheduleList{
+					Items: []veleroapi.Schedule{
+						{
+							TypeMeta: metav1.TypeMeta{
+								APIVersion: "velero/v1",
+								Kind:       "Schedule",
+							},
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "schedule-invalid-not-found",
+								Namespace: veleroNamespaceName,
+							},
+						},
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "successfully delete the schedule, returns no error",
+			args: argsDelete{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				schedules:      veleroSchedules,
+			},
+			want: false,
+		},
+	}
+	for _, tt := range testsForDelete {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deleteVeleroSchedules(tt.args.ctx, tt.args.c,
+				tt.args.backupSchedule, tt.args.schedules); (got != nil) != tt.want {
+				t.Errorf(
+					"deleteVeleroSchedules() = %v, want len of string is empty %v",
+					got,
+					tt.want,
+				)
+			}
+		})
+	}
+
+	veleroSchedulesUpdate := initVeleroScheduleList(veleroNamespaceName, veleroapi.SchedulePhaseNew, "0 8 * * *",
+		metav1.Duration{Duration: time.Second * 5})
+
+	type argsUpdate struct {
+		ctx               context.Context
+		c                 client.Client
+		backupSchedule    *v1beta1.BackupSchedule
+		schedules         *veleroapi.ScheduleList
+		resourcesToBackup []string
+	}
+
+	testsForSchedulesUpdateRequired := []struct {
+		name string
+		args argsUpdate
+		want bool
+		err  string
+	}{
+		{
+			name: "velero schedules is empty",
+			args: argsUpdate{
+				ctx:               context.Background(),
+				c:                 k8sClient1,
+				backupSchedule:    &rhacmBackupSchedule,
+				schedules:         &veleroapi.ScheduleList{},
+				resourcesToBackup: []string{},
+			},
+			want: false,
+			err:  "",
+		},
+		{
+			name: "velero schedules is not empty, but schedules cannot be updated, not found",
+			args: argsUpdate{
+				ctx:               context.Background(),
+				c:                 k8sClient1,
+				backupSchedule:    &rhacmBackupSchedule,
+				schedules:         veleroSchedulesUpdate,
+				resourcesToBackup: []string{},
+			},
+			want: true,
+			err:  `schedules.velero.io "acm-credentials-schedule" not found`,
+		},
+		{
+			name: "velero schedules is not empty, schedules are updated",
+			args: argsUpdate{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
+					schedule("0 6 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Hour * 72}).
+					object,
+				schedules:         veleroSchedulesUpdate,
+				resourcesToBackup: []string{},
+			},
+			want: true,
+			err:  "",
+		},
+		{
+			name: "velero schedules is not empty, schedules are updated and NO CRDs found",
+			args: argsUpdate{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
+					schedule("0 8 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Second * 5}).
+					object,
+				schedules:         veleroSchedulesUpdate,
+				resourcesToBackup: []string{},
+			},
+			want: true,
+			err:  "",
+		},
+		{
+			name: "velero schedules is not empty, schedules are NOT updated but new CRDs found",
+			args: argsUpdate{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
+					schedule("0 8 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Second * 5}).
+					object,
+				schedules:         veleroSchedulesUpdate,
+				resourcesToBackup: []string{"policy123.open-cluster-management.io"},
+			},
+			want: true,
+			err:  "",
+		},
+		{
+			name: "velero schedules is not empty, schedules are NOT updated but new CRDs found error on update",
+			args: argsUpdate{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
+					schedule("0 8 * * *").
+					veleroTTL(metav1.Duration{Duration: time.Second * 5}).
+					object,
+				schedules:         veleroSchedulesUpdate,
+				resourcesToBackup: []string{"policy456.open-cluster-management.io"},
+			},
+			want: true,
+			err: `Operation cannot be fulfilled on schedules.velero.io "acm-resources-schedule": ` +
+				`the object has been modified; please apply your changes to the latest version and try again`,
+		},
+	}
+	for idx, tt := range testsForSchedulesUpdateRequired {
+
+		if idx == 2 {
+			// create schedules, they don't have the same ttl as the backupSchedule
+			for i := range veleroSchedulesUpdate.Items {
+				veleroSchedule := &veleroSchedulesUpdate.Items[i]
+				veleroSchedule.Namespace = veleroNamespaceName
+				if err := k8sClient1.Create(context.Background(), veleroSchedule); err != nil {
+					t.Errorf("cannot create veleroSchedule %s ", err.Error())
+				}
+			}
+		}
+
+		if idx == len(testsForSchedulesUpdateRequired)-1 {
+			// update one schedule now so the code fails when it tries to update it with the new CRD
+			veleroSchedulesUpdate.Items[0].Spec.Template.IncludedResources = append(
+				veleroSchedulesUpdate.Items[0].Spec.Template.IncludedResources, "new-res")
+			// don't throw error if the update fails
+			_ = k8sClient1.Update(context.Background(), &veleroSchedulesUpdate.Items[0])
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := isVeleroSchedulesUpdateRequired(tt.args.ctx, tt.args.c, tt.args.resourcesToBackup,
+				*tt.args.schedules, tt.args.backupSchedule)
+			if got != tt.want {
+				t.Errorf(
+					"isVeleroSchedulesUpdateRequired() = %v, want  %v",
+					got,
+					tt.want,
+				)
+			}
+			if err == nil && tt.err != "" {
+				t.Errorf(
+					"isVeleroSchedulesUpdateRequired() = error is nil, want %v",
+					got,
+				)
+			}
+			if err != nil && tt.err == "" {
+				t.Errorf(
+					"isVeleroSchedulesUpdateRequired() error is %v, want no error",
+					err.Error(),
+				)
+			}
+		})
+
+	}
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_isRestoreRunning(t *testing.T) {
+	veleroNamespaceName := "backup-ns"
+	veleroNamespace := *createNamespace(veleroNamespaceName)
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme2 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme2)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	err = corev1.AddToScheme(scheme2)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme2})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	rhacmBackupSchedule := *createBackupSchedule("backup-sch-to-error-restore", veleroNamespaceName).
+		object
+
+	rhacmBackupScheduleInvalidNS := *createBackupSchedule("backup-sch-to-error-restore", "invalid-ns").
+		object
+
+	latestRestore := "latest"
+	rhacmRestore := *createACMRestore("restore-name", veleroNamespaceName).
+		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
+		veleroManagedClustersBackupName(latestRestore).
+		veleroCredentialsBackupName(latestRestore).
+		veleroResourcesBackupName(latestRestore).object
+
+	type args struct {
+		ctx            context.Context
+		c              client.Client
+		backupSchedule *v1beta1.BackupSchedule
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "velero schema not found",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+			},
+			want: "",
+		},
+		{
+			name: "velero has no restores",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+			},
+			want: "",
+		},
+		{
+			name: "velero restore has one restore and not completed",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+			},
+			want: rhacmRestore.Name,
+		},
+		{
+			name: "velero restore has one restore and not completed invalid",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupScheduleInvalidNS,
+			},
+			want: "",
+		},
+	}
+	for index, tt := range tests {
+
+		if index == 1 {
+			err := k8sClient1.Create(context.Background(), &veleroNamespace)
+			if err != nil {
+				t.Errorf("Error creating ns: %s", err.Error())
+			}
+		}
+		if index == 2 {
+			err := backupv1beta1.AddToScheme(scheme2)
+			if err != nil {
+				t.Errorf("Error adding api to scheme: %s", err.Error())
+			}
+			err = k8sClient1.Create(tt.args.ctx, &rhacmRestore)
+			if err != nil {
+				t.Errorf("Error creating ns: %s", err.Error())
+			}
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRestoreRunning(tt.args.ctx, tt.args.c,
+				tt.args.backupSchedule); got != tt.want {
+				t.Errorf("isRestoreRunning() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_createInitialBackupForSchedule(t *testing.T) {
+	timeStr := "20220912191647"
+	veleroNamespaceName := "backup-ns"
+	rhacmBackupSchedule := *createBackupSchedule("backup-sch", veleroNamespaceName).
+		object
+
+	rhacmBackupScheduleNoRun := *createBackupSchedule("backup-sch", veleroNamespaceName).
+		noBackupOnStart(true).
+		object
+
+	schNoLabels := *createSchedule("acm-credentials-cluster-schedule", veleroNamespaceName).
+		scheduleLabels(map[string]string{BackupScheduleNameLabel: "aa"}).
+		object
+
+	veleroNamespace := *createNamespace(veleroNamespaceName)
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+	err = corev1.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	type args struct {
+		ctx            context.Context
+		c              client.Client
+		backupSchedule *v1beta1.BackupSchedule
+		veleroSchedule *veleroapi.Schedule
+	}
+	tests := []struct {
+		name              string
+		args              args
+		want              bool
+		want_veleroBackup string
+	}{
+		{
+			name: "backup schedule should not call backup on init schedule",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient,
+				backupSchedule: &rhacmBackupScheduleNoRun,
+				veleroSchedule: &schNoLabels,
+			},
+			want_veleroBackup: "", // no backup
+		},
+		{
+			name: "backup schedule should call backup on init schedule - error, no ns",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				veleroSchedule: &schNoLabels,
+			},
+			want_veleroBackup: "",
+		},
+		{
+			name: "backup schedule should call backup on init schedule",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				veleroSchedule: &schNoLabels,
+			},
+			want_veleroBackup: schNoLabels.Name + "-" + timeStr,
+		},
+		{
+			name: "backup schedule should call backup on init schedule, but error since schedule already created",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				backupSchedule: &rhacmBackupSchedule,
+				veleroSchedule: &schNoLabels,
+			},
+			want_veleroBackup: "",
+		},
+	}
+	for index, tt := range tests {
+
+		if index == 2 {
+			err := k8sClient1.Create(context.Background(), &veleroNamespace)
+			if err != nil {
+				t.Errorf("Error creating ns: %s", err.Error())
+			}
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if got_backup := createInitialBackupForSchedule(tt.args.ctx, tt.args.c, scheme1, tt.args.veleroSchedule,
+				tt.args.backupSchedule, timeStr); got_backup != tt.want_veleroBackup {
+				t.Errorf("createInitialBackupForSchedule() backupName is %v, want %v",
+					got_backup, tt.want_veleroBackup)
+			}
+		})
+	}
+
+	// clean up
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_createFailedValidationResponse(t *testing.T) {
+	veleroNamespaceName := "backup-ns-v"
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	k8sClient2, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	rhacmBackupSchedule := *createBackupSchedule("backup-sch-v", veleroNamespaceName).
+		object
+
+	type args struct {
+		ctx            context.Context
+		c              client.Client
+		backupSchedule *v1beta1.BackupSchedule
+		msg            string
+		requeue        bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Duration
+	}{
+		{
+			name: "reque failure interval",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient2,
+				backupSchedule: &rhacmBackupSchedule,
+				requeue:        true,
+				msg:            "some error",
+			},
+			want: failureInterval,
+		},
+		{
+			name: "do not reque",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient2,
+				backupSchedule: &rhacmBackupSchedule,
+				requeue:        false,
+				msg:            "some error",
+			},
+			want: time.Second * 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r, _, _ := createFailedValidationResponse(tt.args.ctx, tt.args.c,
+				tt.args.backupSchedule, tt.args.msg,
+				tt.args.requeue); r.RequeueAfter != tt.want {
+				t.Errorf("createFailedValidationResponse() = %v, want %v", r.RequeueAfter, tt.want)
+			}
+		})
+	}
+	// clean up
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_verifyMSAOptione(t *testing.T) {
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	appsInfo := metav1.APIResourceList{
+		GroupVersion: "apps.open-cluster-management.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "channels", Namespaced: true, Kind: "Channel"},
+			{Name: "subscriptions", Namespaced: true, Kind: "Subscription"},
+		},
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var list interface{}
+		switch req.URL.Path {
+		case "/apis/apps.open-cluster-management.io/v1beta1":
+			list = &appsInfo
+		case "/api":
+			list = &metav1.APIVersions{
+				Versions: []string{
+					"v1",
+					"v1beta1",
+				},
+			}
+		case "/apis":
+			list = &metav1.APIGroupList{
+				Groups: []metav1.APIGroup{
+					{
+						Name: "config.openshift.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: "config.openshift.io/v1",
+								Version:      "v1",
+							},
+						},
+					},
+					{
+						Name: "apps.open-cluster-management.io",
+						Versions: []metav1.GroupVersionForDiscovery{
+							{
+								GroupVersion: "apps.open-cluster-management.io/v1beta1",
+								Version:      "v1beta1",
+							},
+							{GroupVersion: "apps.open-cluster-management.io/v1", Version: "v1"},
+						},
+					},
+				},
+			}
+		default:
+			// t.Logf("unexpected request: %s", req.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		output, err := json.Marshal(list)
+		if err != nil {
+			// t.Errorf("unexpected encoding error: %v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(output)
+		if err != nil {
+			return
+		}
+	}))
+
+	res_local_ns := &unstructured.Unstructured{}
+	res_local_ns.SetUnstructuredContent(map[string]interface{}{
+		"apiVersion": "apps.open-cluster-management.io/v1",
+		"kind":       "Channel",
+		"metadata": map[string]interface{}{
+			"name":      "channel-new",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"type":     "Git",
+			"pathname": "https://github.com/test/app-samples",
+		},
+	})
+
+	unstructuredScheme := runtime.NewScheme()
+	err = chnv1.AddToScheme(unstructuredScheme)
+	if err != nil {
+		t.Errorf("Error adding api to scheme: %s", err.Error())
+	}
+
+	dynClient := dynamicfake.NewSimpleDynamicClient(unstructuredScheme, res_local_ns)
+
+	targetGVK := schema.GroupVersionKind{
+		Group:   "apps.open-cluster-management.io",
+		Version: "v1",
+		Kind:    "Channel",
+	}
+	targetGVR := targetGVK.GroupVersion().WithResource("channel")
+
+	resInterface := dynClient.Resource(targetGVR)
+
+	sch := *createBackupSchedule("name", "ns").
+		schedule("0 */6 * * *").object
+
+	sch_msa := *createBackupSchedule("name", "ns").
+		useManagedServiceAccount(true).
+		schedule("0 */6 * * *").object
+
+	// create resources which should be found
+	_, err = resInterface.Namespace("default").Create(context.Background(), res_local_ns, v1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Error creating resource: %s", err.Error())
+	}
+
+	fakeDiscovery := discoveryclient.NewDiscoveryClientForConfigOrDie(
+		&restclient.Config{Host: server.URL},
+	)
+	m := restmapper.NewDeferredDiscoveryRESTMapper(
+		memory.NewMemCacheClient(fakeDiscovery),
+	)
+	type args struct {
+		ctx            context.Context
+		c              client.Client
+		backupSchedule *v1beta1.BackupSchedule
+		mapper         *restmapper.DeferredDiscoveryRESTMapper
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "no CRD found for MSA, but MSA not enabled",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				mapper:         m,
+				backupSchedule: &sch,
+			},
+			want: true,
+		},
+		{
+			name: "no CRD found for MSA, MSA enabled",
+			args: args{
+				ctx:            context.Background(),
+				c:              k8sClient1,
+				mapper:         m,
+				backupSchedule: &sch_msa,
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got, _ := verifyMSAOption(tt.args.ctx,
+				tt.args.c,
+				tt.args.mapper,
+				tt.args.backupSchedule,
+			); got != tt.want {
+				t.Errorf("verifyMSAOption() = %v, want %v", got,
+					tt.want)
+			}
+		})
+	}
+
+	if err := testEnv.Stop(); err != nil {
+		t.Fatalf("Error stopping testenv: %s", err.Error())
+	}
+}
+
+func Test_scheduleOwnsLatestStorageBackups(t *testing.T) {
+	veleroNamespaceName := "default"
+
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "config", "crd", "bases"),
+			filepath.Join("..", "hack", "crds"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Error starting testEnv: %s", err.Error())
+	}
+	scheme1 := runtime.NewScheme()
+	err = veleroapi.AddToScheme(scheme1)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
+	if err != nil {
+		t.Fatalf("Error starting client: %s", err.Error())
+	}
+
+	velero_schedule := *createSchedule(veleroScheduleNames[Resources], veleroNamespaceName).
+		scheduleLabels(map[string]string{BackupScheduleClusterLabel: "cls"}).
+		object
+
+	aFewSecondsAgo := metav1.NewTime(time.Now().Add(-2 * time.Second))
+	anHourAgo := metav1.NewTime(time.Now().Add(-1 * time.Hour))
+
+	type args struct {
+		ctx      context.Context
+		c        client.Client
+		schedule *veleroapi.Schedule
+	}
+	tests := []struct {
+		name      string
+		args      args
+		want      bool
+		resources []*veleroapi.Backup
+	}{
+		{
+			name: "no crd",
+			args: args{
+				ctx:      context.Background(),
+				c:        k8sClient1,
+				schedule: &velero_schedule,
+			},
+			want:      true,
+			resources: []*veleroapi.Backup{},
 		},
 		{
-			name: "velero schedules is not empty, schedules are updated and NO CRDs found",
-			args: argsUpdate{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
-					schedule("0 8 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Second * 5}).
-					object,
-				schedules:         veleroSchedulesUpdate,
-				resourcesToBackup: []string{},
+			name: "no backups",
+			args: args{
+				ctx:      context.Background(),
+				c:        k8sClient1,
+				schedule: &velero_schedule,
 			},
-			want: true,
-			err:  "",
+			want:      true,
+			resources: []*veleroapi.Backup{},
 		},
 		{
-			name: "velero schedules is not empty, schedules are NOT updated but new CRDs found",
-			args: argsUpdate{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
-					schedule("0 8 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Second * 5}).
+			name: "has backups, different cluster version",
+			args: args{
+				ctx:      context.Background(),
+				c:        k8sClient1,
+				schedule: &velero_schedule,
+			},
+			want: false,
+			resources: []*veleroapi.Backup{
+				createBackup(veleroScheduleNames[Resources]+"-1", veleroNamespaceName).
+					startTimestamp(anHourAgo).errors(0).
+					labels(map[string]string{
+						BackupScheduleClusterLabel: "abcd",
+						BackupVeleroLabel:          veleroScheduleNames[Resources],
+					}).
 					object,
-				schedules:         veleroSchedulesUpdate,
-				resourcesToBackup: []string{"policy123.open-cluster-management.io"},
 			},
-			want: true,
-			err:  "",
 		},
 		{
-			name: "velero schedules is not empty, schedules are NOT updated but new CRDs found error on update",
-			args: argsUpdate{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				backupSchedule: createBackupSchedule("acm", veleroNamespaceName).
-					schedule("0 8 * * *").
-					veleroTTL(metav1.Duration{Duration: time.Second * 5}).
-					object,
-				schedules:         veleroSchedulesUpdate,
-				resourcesToBackup: []string{"policy456.open-cluster-management.io"},
+			name: "has backups, same cluster version",
+			args: args{
+				ctx:      context.Background(),
+				c:        k8sClient1,
+				schedule: &velero_schedule,
 			},
 			want: true,
-			err: `Operation cannot be fulfilled on schedules.velero.io "acm-resources-schedule": ` +
-				`the object has been modified; please apply your changes to the latest version and try again`,
+			resources: []*veleroapi.Backup{
+				createBackup(veleroScheduleNames[Resources]+"-2", veleroNamespaceName).
+					startTimestamp(aFewSecondsAgo).errors(0).
+					labels(map[string]string{
+						BackupScheduleClusterLabel: "cls",
+						BackupVeleroLabel:          veleroScheduleNames[Resources],
+					}).
+					object,
+			},
 		},
 	}
-	for idx, tt := range testsForSchedulesUpdateRequired {
-
-		if idx == 2 {
-			// create schedules, they don't have the same ttl as the backupSchedule
-			for i := range veleroSchedulesUpdate.Items {
-				veleroSchedule := &veleroSchedulesUpdate.Items[i]
-				veleroSchedule.Namespace = veleroNamespaceName
-				if err := k8sClient1.Create(context.Background(), veleroSchedule); err != nil {
-					t.Errorf("cannot create veleroSchedule %s ", err.Error())
-				}
+	for _, tt := range tests {
+		for i := range tt.resources {
+			if err := k8sClient1.Create(tt.args.ctx, tt.resources[i]); err != nil {
+				t.Errorf("failed to create %s", err.Error())
 			}
 		}
 
-		if idx == len(testsForSchedulesUpdateRequired)-1 {
-			// update one schedule now so the code fails when it tries to update it with the new CRD
-			veleroSchedulesUpdate.Items[0].Spec.Template.IncludedResources = append(
-				veleroSchedulesUpdate.Items[0].Spec.Template.IncludedResources, "new-res")
-			// don't throw error if the update fails
-			_ = k8sClient1.Update(context.Background(), &veleroSchedulesUpdate.Items[0])
-		}
-
 		t.Run(tt.name, func(t *testing.T) {
-			_, got, err := isVeleroSchedulesUpdateRequired(tt.args.ctx, tt.args.c, tt.args.resourcesToBackup,
-				*tt.args.schedules, tt.args.backupSchedule)
-			if got != tt.want {
-				t.Errorf(
-					"isVeleroSchedulesUpdateRequired() = %v, want  %v",
-					got,
-					tt.want,
-				)
-			}
-			if err == nil && tt.err != "" {
-				t.Errorf(
-					"isVeleroSchedulesUpdateRequired() = error is nil, want %v",
-					got,
-				)
-			}
-			if err != nil && tt.err == "" {
-				t.Errorf(
-					"isVeleroSchedulesUpdateRequired() error is %v, want no error",
-					err.Error(),
-				)
+			if got, _, err := scheduleOwnsLatestStorageBackups(tt.args.ctx, tt.args.c,
+				tt.args.schedule); got != tt.want || err != nil {
+				t.Errorf("scheduleOwnsLatestStorageBackups() = got %v, want %v, err %v", got, tt.want, err)
 			}
 		})
 
 	}
-
+	// clean up
 	if err := testEnv.Stop(); err != nil {
 		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
 }
 
-func Test_isRestoreRunning(t *testing.T) {
-	veleroNamespaceName := "backup-ns"
-	veleroNamespace := *createNamespace(veleroNamespaceName)
-
+func Test_isRestoreHubAfterSchedule(t *testing.T) {
 	testEnv := &envtest.Environment{
 		CRDDirectoryPaths: []string{
 			filepath.Join("..", "config", "crd", "bases"),
@@ -740,104 +2946,193 @@ func Test_isRestoreRunning(t *testing.T) {
 		t.Fatalf("Error starting testEnv: %s", err.Error())
 	}
 	scheme2 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme2)
+	err = corev1.AddToScheme(scheme2)
 	if err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = corev1.AddToScheme(scheme2)
+	err = backupv1beta1.AddToScheme(scheme2)
 	if err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
+	err = ocinfrav1.AddToScheme(scheme2)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	err = veleroapi.AddToScheme(scheme2)
+	if err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
 	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme2})
 	if err != nil {
 		t.Fatalf("Error starting client: %s", err.Error())
 	}
 
-	rhacmBackupSchedule := *createBackupSchedule("backup-sch-to-error-restore", veleroNamespaceName).
-		object
-
-	rhacmBackupScheduleInvalidNS := *createBackupSchedule("backup-sch-to-error-restore", "invalid-ns").
-		object
+	veleroNamespaceName := "backup-ns"
+	veleroNamespace := *createNamespace(veleroNamespaceName)
+	err = k8sClient1.Create(context.Background(), &veleroNamespace)
+	if err != nil {
+		t.Fatalf("Error creating ns: %s", err.Error())
+	}
 
-	latestRestore := "latest"
-	rhacmRestore := *createACMRestore("restore-name", veleroNamespaceName).
-		cleanupBeforeRestore(v1beta1.CleanupTypeRestored).
-		veleroManagedClustersBackupName(latestRestore).
-		veleroCredentialsBackupName(latestRestore).
-		veleroResourcesBackupName(latestRestore).object
+	crWithVersion := createClusterVersion("version", "cluster1", nil)
+	if err := k8sClient1.Create(context.Background(), crWithVersion); err != nil {
+		t.Fatalf("cannot create cluster version  %s ", err.Error())
+	}
 
 	type args struct {
-		ctx            context.Context
-		c              client.Client
-		backupSchedule *v1beta1.BackupSchedule
+		ctx                         context.Context
+		c                           client.Client
+		veleroSchedule              *veleroapi.Schedule
+		acmClusterActivationBackups []*veleroapi.Backup
+		createScheduleFirst         bool
+		sleepTime                   int
 	}
 	tests := []struct {
 		name string
 		args args
-		want string
+		want bool
 	}{
 		{
-			name: "velero schema not found",
+			name: "no collision, list backup fails",
 			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
+				ctx: context.Background(),
+				c:   k8sClient1,
+				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
+					object,
+				acmClusterActivationBackups: []*veleroapi.Backup{},
+				createScheduleFirst:         true,
+				sleepTime:                   2,
 			},
-			want: "",
+			want: false,
 		},
 		{
-			name: "velero has no restores",
+			name: "no collision, no backup restore found",
 			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
+				ctx: context.Background(),
+				c:   k8sClient1,
+				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
+					object,
+				acmClusterActivationBackups: []*veleroapi.Backup{},
+				createScheduleFirst:         true,
+				sleepTime:                   2,
 			},
-			want: "",
+			want: false,
 		},
 		{
-			name: "velero restore has one restore and not completed",
+			name: "collision, backup schedule created first, before restore",
 			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
+				ctx: context.Background(),
+				c:   k8sClient1,
+				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
+					object,
+				acmClusterActivationBackups: []*veleroapi.Backup{createBackup("acm-restore-clusters-2", veleroNamespaceName).
+					labels(map[string]string{
+						BackupScheduleClusterLabel: "cluster1",
+						RestoreClusterLabel:        "cluster2",
+					}).
+					phase(veleroapi.BackupPhaseCompleted).
+					object},
+				createScheduleFirst: true,
+				sleepTime:           2,
 			},
-			want: rhacmRestore.Name,
+			want: true,
 		},
 		{
-			name: "velero restore has one restore and not completed invalid",
+			name: "no collision, backup schedule created first, before restore,  but on the same hub",
 			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupScheduleInvalidNS,
+				ctx: context.Background(),
+				c:   k8sClient1,
+				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
+					object,
+				acmClusterActivationBackups: []*veleroapi.Backup{createBackup("acm-restore-clusters-1", veleroNamespaceName).
+					labels(map[string]string{
+						BackupScheduleClusterLabel: "cluster1",
+						RestoreClusterLabel:        "cluster1",
+					}).
+					phase(veleroapi.BackupPhaseCompleted).
+					object},
+				createScheduleFirst: true,
+				sleepTime:           2,
 			},
-			want: "",
+			want: false,
+		},
+		{
+			name: "no collision, backup schedule created after restore",
+			args: args{
+				ctx: context.Background(),
+				c:   k8sClient1,
+				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
+					object,
+				acmClusterActivationBackups: []*veleroapi.Backup{createBackup("acm-restore-clusters-2", veleroNamespaceName).
+					labels(map[string]string{
+						BackupScheduleClusterLabel: "cluster1",
+						RestoreClusterLabel:        "cluster2",
+					}).
+					phase(veleroapi.BackupPhaseCompleted).
+					object},
+				createScheduleFirst: false,
+				sleepTime:           2,
+			},
+			want: false,
 		},
 	}
-	for index, tt := range tests {
 
-		if index == 1 {
-			err := k8sClient1.Create(context.Background(), &veleroNamespace)
+	for idx, tt := range tests {
+
+		if idx > 0 {
+			err := veleroapi.AddToScheme(scheme2)
 			if err != nil {
-				t.Errorf("Error creating ns: %s", err.Error())
+				t.Errorf("Error adding api to scheme: %s", err.Error())
 			}
 		}
-		if index == 2 {
-			err := backupv1beta1.AddToScheme(scheme2)
+
+		if tt.args.createScheduleFirst {
+			err := k8sClient1.Create(tt.args.ctx, tt.args.veleroSchedule)
 			if err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
+				t.Errorf("Error creating velero schedule: %s", err.Error())
 			}
-			err = k8sClient1.Create(tt.args.ctx, &rhacmRestore)
+			time.Sleep(time.Duration(tt.args.sleepTime) * time.Second)
+			for i := range tt.args.acmClusterActivationBackups {
+				err := k8sClient1.Create(tt.args.ctx, tt.args.acmClusterActivationBackups[i])
+				if err != nil {
+					t.Errorf("Error creating velero backup: %s", err.Error())
+				}
+			}
+
+		} else {
+			for i := range tt.args.acmClusterActivationBackups {
+				err := k8sClient1.Create(tt.args.ctx, tt.args.acmClusterActivationBackups[i])
+				if err != nil {
+					t.Errorf("Error creating velero backup: %s", err.Error())
+				}
+			}
+			time.Sleep(time.Duration(tt.args.sleepTime) * time.Second)
+			err := k8sClient1.Create(tt.args.ctx, tt.args.veleroSchedule)
 			if err != nil {
-				t.Errorf("Error creating ns: %s", err.Error())
+				t.Errorf("Error creating velero schedule: %s", err.Error())
 			}
+
 		}
 
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isRestoreRunning(tt.args.ctx, tt.args.c,
-				tt.args.backupSchedule); got != tt.want {
-				t.Errorf("isRestoreRunning() = %v, want %v", got, tt.want)
+			if got, _ := isRestoreHubAfterSchedule(tt.args.ctx, tt.args.c,
+				tt.args.veleroSchedule); got != tt.want {
+				t.Errorf("isRestoreHubAfterSchedule() = %v, want %v ", got, tt.want)
 			}
 		})
+
+		// clean up after the test is run
+		err := k8sClient1.Delete(tt.args.ctx, tt.args.veleroSchedule)
+		if err != nil {
+			t.Errorf("Error deleting velero schedule: %s", err.Error())
+		}
+		for i := range tt.args.acmClusterActivationBackups {
+			err := k8sClient1.Delete(tt.args.ctx, tt.args.acmClusterActivationBackups[i])
+			if err != nil {
+				t.Errorf("Error deleting velero backup: %s", err.Error())
+			}
+		}
 	}
 
 	if err := testEnv.Stop(); err != nil {
@@ -845,702 +3140,880 @@ func Test_isRestoreRunning(t *testing.T) {
 	}
 }
 
-func Test_createInitialBackupForSchedule(t *testing.T) {
-	timeStr := "20220912191647"
-	veleroNamespaceName := "backup-ns"
-	rhacmBackupSchedule := *createBackupSchedule("backup-sch", veleroNamespaceName).
-		object
-
-	rhacmBackupScheduleNoRun := *createBackupSchedule("backup-sch", veleroNamespaceName).
-		noBackupOnStart(true).
-		object
-
-	schNoLabels := *createSchedule("acm-credentials-cluster-schedule", veleroNamespaceName).
-		scheduleLabels(map[string]string{BackupScheduleNameLabel: "aa"}).
-		object
-
-	veleroNamespace := *createNamespace(veleroNamespaceName)
-
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
+func Test_emitBackupCompletedEvents(t *testing.T) {
 	scheme1 := runtime.NewScheme()
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
-	}
-	err = corev1.AddToScheme(scheme1)
-	if err != nil {
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = veleroapi.AddToScheme(scheme1)
-	if err != nil {
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	type args struct {
-		ctx            context.Context
-		c              client.Client
-		backupSchedule *v1beta1.BackupSchedule
-		veleroSchedule *veleroapi.Schedule
+	ns := "acm-ns"
+	now := time.Now()
+	clusterLabel := map[string]string{BackupScheduleClusterLabel: "cluster1"}
+	backupLabels := func(schedule string, cluster string) map[string]string {
+		return map[string]string{BackupVeleroLabel: schedule, BackupScheduleClusterLabel: cluster}
+	}
+
+	veleroScheduleList := veleroapi.ScheduleList{
+		Items: []veleroapi.Schedule{
+			*createSchedule(veleroScheduleNames[Credentials], ns).scheduleLabels(clusterLabel).object,
+			*createSchedule(veleroScheduleNames[Resources], ns).scheduleLabels(clusterLabel).object,
+		},
+	}
+
+	backupSchedule := createBackupSchedule("acm-schedule", ns).emitBackupCompletedEvents(true).object
+	backupSchedule.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(
+			// completed before the BackupSchedule was created, no event
+			createBackup("acm-credentials-schedule-1", ns).
+				labels(backupLabels(veleroScheduleNames[Credentials], "cluster1")).
+				phase(veleroapi.BackupPhaseCompleted).
+				completionTimestamp(metav1.NewTime(now.Add(-3*time.Hour))).object,
+			// completed after the BackupSchedule was created
+			createBackup("acm-resources-schedule-1", ns).
+				labels(backupLabels(veleroScheduleNames[Resources], "cluster1")).
+				phase(veleroapi.BackupPhaseCompleted).
+				completionTimestamp(metav1.NewTime(now.Add(-1*time.Hour))).object,
+			// in progress, no event
+			createBackup("acm-resources-schedule-2", ns).
+				labels(backupLabels(veleroScheduleNames[Resources], "cluster1")).
+				phase(veleroapi.BackupPhaseInProgress).object,
+			// created by another hub, no event
+			createBackup("acm-resources-schedule-3", ns).
+				labels(backupLabels(veleroScheduleNames[Resources], "cluster2")).
+				phase(veleroapi.BackupPhaseCompleted).
+				completionTimestamp(metav1.NewTime(now)).object,
+		).
+		Build()
+
+	recorder := record.NewFakeRecorder(10)
+	r := &BackupScheduleReconciler{Client: fakeClient, Scheme: scheme1, Recorder: recorder}
+
+	r.emitBackupCompletedEvents(context.Background(), backupSchedule, &veleroScheduleList)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("emitBackupCompletedEvents() emitted %d events, want 1", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "BackupCompleted") ||
+		!strings.Contains(event, "acm-resources-schedule-1") {
+		t.Errorf("emitBackupCompletedEvents() event = %s, want BackupCompleted for acm-resources-schedule-1", event)
+	}
+	wantObserved := map[string]string{
+		string(Credentials): "acm-credentials-schedule-1",
+		string(Resources):   "acm-resources-schedule-1",
+	}
+	if !reflect.DeepEqual(backupSchedule.Status.LastObservedBackup, wantObserved) {
+		t.Errorf("LastObservedBackup = %v, want %v", backupSchedule.Status.LastObservedBackup, wantObserved)
+	}
+
+	// no new backups, no event
+	r.emitBackupCompletedEvents(context.Background(), backupSchedule, &veleroScheduleList)
+	if len(recorder.Events) != 0 {
+		t.Errorf("emitBackupCompletedEvents() emitted %d events, want 0", len(recorder.Events))
+	}
+
+	// a new credentials backup completed
+	if err := fakeClient.Create(context.Background(), createBackup("acm-credentials-schedule-2", ns).
+		labels(backupLabels(veleroScheduleNames[Credentials], "cluster1")).
+		phase(veleroapi.BackupPhaseCompleted).
+		completionTimestamp(metav1.NewTime(now)).object); err != nil {
+		t.Fatalf("Error creating backup: %s", err.Error())
+	}
+	r.emitBackupCompletedEvents(context.Background(), backupSchedule, &veleroScheduleList)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("emitBackupCompletedEvents() emitted %d events, want 1", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "acm-credentials-schedule-2") {
+		t.Errorf("emitBackupCompletedEvents() event = %s, want event for acm-credentials-schedule-2", event)
+	}
+}
+
+func Test_validateSchedulePermissions(t *testing.T) {
+	ns := "acm-ns"
+
+	// returns a client denying the access reviews for the given verb and resource
+	denyingClient := func(verb, resource, reason string, reviewErr error) client.Client {
+		return fake.NewClientBuilder().
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+					if !ok {
+						return c.Create(ctx, obj, opts...)
+					}
+					if reviewErr != nil {
+						return reviewErr
+					}
+					attributes := review.Spec.ResourceAttributes
+					review.Status.Allowed = attributes.Verb != verb || attributes.Resource != resource
+					if !review.Status.Allowed {
+						review.Status.Reason = reason
+					}
+					return nil
+				},
+			}).Build()
 	}
+
 	tests := []struct {
-		name              string
-		args              args
-		want              bool
-		want_veleroBackup string
+		name           string
+		client         client.Client
+		backupSchedule *v1beta1.BackupSchedule
+		wantErr        string
 	}{
 		{
-			name: "backup schedule should not call backup on init schedule",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient,
-				backupSchedule: &rhacmBackupScheduleNoRun,
-				veleroSchedule: &schNoLabels,
-			},
-			want_veleroBackup: "", // no backup
+			name:           "all permissions allowed",
+			client:         denyingClient("", "", "", nil),
+			backupSchedule: createBackupSchedule("acm-schedule", ns).validatePermissions(true).object,
 		},
 		{
-			name: "backup schedule should call backup on init schedule - error, no ns",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				veleroSchedule: &schNoLabels,
-			},
-			want_veleroBackup: "",
+			name:           "create velero schedules denied",
+			client:         denyingClient("create", "schedules", "", nil),
+			backupSchedule: createBackupSchedule("acm-schedule", ns).object,
+			wantErr:        "missing permission to create schedules.velero.io in namespace acm-ns",
 		},
 		{
-			name: "backup schedule should call backup on init schedule",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				veleroSchedule: &schNoLabels,
-			},
-			want_veleroBackup: schNoLabels.Name + "-" + timeStr,
+			name:           "create velero schedules denied in the velero namespace",
+			client:         denyingClient("create", "schedules", "", nil),
+			backupSchedule: createBackupSchedule("acm-schedule", ns).veleroNamespace("velero-ns").object,
+			wantErr:        "missing permission to create schedules.velero.io in namespace velero-ns",
 		},
 		{
-			name: "backup schedule should call backup on init schedule, but error since schedule already created",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				backupSchedule: &rhacmBackupSchedule,
-				veleroSchedule: &schNoLabels,
-			},
-			want_veleroBackup: "",
+			name:           "list secrets denied, with reason",
+			client:         denyingClient("list", "secrets", "no RBAC policy matched", nil),
+			backupSchedule: createBackupSchedule("acm-schedule", ns).object,
+			wantErr:        "missing permission to list secrets: no RBAC policy matched",
+		},
+		{
+			name:           "access review failed",
+			client:         denyingClient("", "", "", errors.New("connection refused")),
+			backupSchedule: createBackupSchedule("acm-schedule", ns).object,
+			wantErr:        "failed to check permissions: connection refused",
 		},
 	}
-	for index, tt := range tests {
-
-		if index == 2 {
-			err := k8sClient1.Create(context.Background(), &veleroNamespace)
-			if err != nil {
-				t.Errorf("Error creating ns: %s", err.Error())
-			}
-		}
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got_backup := createInitialBackupForSchedule(tt.args.ctx, tt.args.c, scheme1, tt.args.veleroSchedule,
-				tt.args.backupSchedule, timeStr); got_backup != tt.want_veleroBackup {
-				t.Errorf("createInitialBackupForSchedule() backupName is %v, want %v",
-					got_backup, tt.want_veleroBackup)
+			err := validateSchedulePermissions(context.Background(), tt.client, tt.backupSchedule)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateSchedulePermissions() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("validateSchedulePermissions() error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
-
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
-	}
 }
 
-func Test_createFailedValidationResponse(t *testing.T) {
-	veleroNamespaceName := "backup-ns-v"
-
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
+func Test_isBackupCollisionResolved(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	k8sClient2, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+	if err := ocinfrav1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	rhacmBackupSchedule := *createBackupSchedule("backup-sch-v", veleroNamespaceName).
-		object
+	ns := "acm-ns"
+	now := time.Now()
+	newBackupSchedule := func(collisionTime time.Time) *v1beta1.BackupSchedule {
+		backupSchedule := createBackupSchedule("acm-schedule", ns).
+			schedule("0 */1 * * *").
+			phase(v1beta1.SchedulePhaseBackupCollision).object
+		backupSchedule.CreationTimestamp = metav1.NewTime(now.Add(-5 * time.Hour))
+		if !collisionTime.IsZero() {
+			backupSchedule.Status.Conditions = []metav1.Condition{{
+				Type:               v1beta1.ScheduleConditionBackupCollisionDetected,
+				Status:             metav1.ConditionTrue,
+				Reason:             v1beta1.ScheduleReasonBackupCollision,
+				LastTransitionTime: metav1.NewTime(collisionTime),
+			}}
+		}
+		return backupSchedule
+	}
 
-	type args struct {
-		ctx            context.Context
-		c              client.Client
-		backupSchedule *v1beta1.BackupSchedule
-		msg            string
-		requeue        bool
+	resourcesBackup := func(name string, cluster string, started time.Time) *veleroapi.Backup {
+		return createBackup(name, ns).
+			startTimestamp(metav1.NewTime(started)).
+			labels(map[string]string{
+				BackupScheduleClusterLabel: cluster,
+				BackupVeleroLabel:          veleroScheduleNames[Resources],
+			}).object
 	}
+	restoreClustersBackup := createBackup("acm-restore-clusters-1", ns).
+		labels(map[string]string{
+			BackupScheduleClusterLabel: "cluster2",
+			RestoreClusterLabel:        "cluster2",
+		}).
+		phase(veleroapi.BackupPhaseCompleted).object
+	restoreClustersBackup.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+
 	tests := []struct {
-		name string
-		args args
-		want time.Duration
+		name          string
+		collisionTime time.Time
+		objects       []client.Object
+		want          bool
 	}{
 		{
-			name: "reque failure interval",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient2,
-				backupSchedule: &rhacmBackupSchedule,
-				requeue:        true,
-				msg:            "some error",
+			name:    "no backups, collision resolved",
+			objects: []client.Object{createClusterVersion("version", "cluster1", nil)},
+			want:    true,
+		},
+		{
+			name: "latest backup created by another hub, collision not resolved",
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-1", "cluster1", now.Add(-2*time.Hour)),
+				resourcesBackup("acm-resources-schedule-2", "cluster2", now.Add(-1*time.Hour)),
 			},
-			want: failureInterval,
+			want: false,
 		},
 		{
-			name: "do not reque",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient2,
-				backupSchedule: &rhacmBackupSchedule,
-				requeue:        false,
-				msg:            "some error",
+			name: "latest backup created by this hub again, collision resolved",
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-1", "cluster2", now.Add(-2*time.Hour)),
+				resourcesBackup("acm-resources-schedule-2", "cluster1", now.Add(-1*time.Hour)),
 			},
-			want: time.Second * 0,
+			want: true,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if r, _, _ := createFailedValidationResponse(tt.args.ctx, tt.args.c,
-				tt.args.backupSchedule, tt.args.msg,
-				tt.args.requeue); r.RequeueAfter != tt.want {
-				t.Errorf("createFailedValidationResponse() = %v, want %v", r.RequeueAfter, tt.want)
-			}
-		})
-	}
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
-	}
-}
-
-func Test_verifyMSAOptione(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
+		{
+			name: "latest backup created by this hub, another hub restored after the schedule was created",
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-2", "cluster1", now.Add(-1*time.Hour)),
+				restoreClustersBackup,
+			},
+			want: false,
+		},
+		{
+			name:          "other hub stopped creating backups after the collision, collision resolved",
+			collisionTime: now.Add(-3 * time.Hour),
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-1", "cluster1", now.Add(-5*time.Hour)),
+				resourcesBackup("acm-resources-schedule-2", "cluster2", now.Add(-4*time.Hour)),
+			},
+			want: true,
+		},
+		{
+			name:          "other hub still creating backups after the collision, collision not resolved",
+			collisionTime: now.Add(-3 * time.Hour),
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-1", "cluster1", now.Add(-5*time.Hour)),
+				resourcesBackup("acm-resources-schedule-2", "cluster2", now.Add(-4*time.Hour)),
+				resourcesBackup("acm-resources-schedule-3", "cluster2", now.Add(-30*time.Minute)),
+			},
+			want: false,
+		},
+		{
+			name:          "collision just detected, the other hub schedule did not run yet",
+			collisionTime: now.Add(-10 * time.Minute),
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-1", "cluster1", now.Add(-5*time.Hour)),
+				resourcesBackup("acm-resources-schedule-2", "cluster2", now.Add(-4*time.Hour)),
+			},
+			want: false,
+		},
+		{
+			name:          "other hub stopped creating backups, another hub restored after the schedule was created",
+			collisionTime: now.Add(-3 * time.Hour),
+			objects: []client.Object{
+				createClusterVersion("version", "cluster1", nil),
+				resourcesBackup("acm-resources-schedule-2", "cluster2", now.Add(-4*time.Hour)),
+				restoreClustersBackup,
+			},
+			want: false,
 		},
-		ErrorIfCRDPathMissing: true,
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(tt.objects...).Build()
 
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
+			got, err := isBackupCollisionResolved(context.Background(), fakeClient,
+				newBackupSchedule(tt.collisionTime), now)
+			if err != nil {
+				t.Errorf("isBackupCollisionResolved() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isBackupCollisionResolved() = %v, want %v", got, tt.want)
+			}
+		})
 	}
+}
+
+func Test_initVeleroSchedules_invalidCron(t *testing.T) {
 	scheme1 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme1)
-	if err != nil {
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+	if err := backupv1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	appsInfo := metav1.APIResourceList{
-		GroupVersion: "apps.open-cluster-management.io/v1beta1",
-		APIResources: []metav1.APIResource{
-			{Name: "channels", Namespaced: true, Kind: "Channel"},
-			{Name: "subscriptions", Namespaced: true, Kind: "Subscription"},
+	ns := "velero-ns"
+	tests := []struct {
+		name     string
+		schedule string
+	}{
+		{
+			name:     "empty VeleroSchedule",
+			schedule: "",
+		},
+		{
+			name:     "invalid VeleroSchedule",
+			schedule: "invalid-schedule",
 		},
 	}
-	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		var list interface{}
-		switch req.URL.Path {
-		case "/apis/apps.open-cluster-management.io/v1beta1":
-			list = &appsInfo
-		case "/api":
-			list = &metav1.APIVersions{
-				Versions: []string{
-					"v1",
-					"v1beta1",
-				},
-			}
-		case "/apis":
-			list = &metav1.APIGroupList{
-				Groups: []metav1.APIGroup{
-					{
-						Name: "config.openshift.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{
-								GroupVersion: "config.openshift.io/v1",
-								Version:      "v1",
-							},
-						},
-					},
-					{
-						Name: "apps.open-cluster-management.io",
-						Versions: []metav1.GroupVersionForDiscovery{
-							{
-								GroupVersion: "apps.open-cluster-management.io/v1beta1",
-								Version:      "v1beta1",
-							},
-							{GroupVersion: "apps.open-cluster-management.io/v1", Version: "v1"},
-						},
-					},
-				},
-			}
-		default:
-			// t.Logf("unexpected request: %s", req.URL.Path)
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-		output, err := json.Marshal(list)
-		if err != nil {
-			// t.Errorf("unexpected encoding error: %v", err)
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, err = w.Write(output)
-		if err != nil {
-			return
-		}
-	}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).Build()
+			r := &BackupScheduleReconciler{Client: fakeClient, Scheme: scheme1}
 
-	res_local_ns := &unstructured.Unstructured{}
-	res_local_ns.SetUnstructuredContent(map[string]interface{}{
-		"apiVersion": "apps.open-cluster-management.io/v1",
-		"kind":       "Channel",
-		"metadata": map[string]interface{}{
-			"name":      "channel-new",
-			"namespace": "default",
-		},
-		"spec": map[string]interface{}{
-			"type":     "Git",
-			"pathname": "https://github.com/test/app-samples",
-		},
-	})
+			backupSchedule := createBackupSchedule("acm", ns).schedule(tt.schedule).object
+			err := r.initVeleroSchedules(context.Background(), nil, backupSchedule, "cluster1")
+			if !errors.Is(err, errInvalidVeleroSchedule) {
+				t.Errorf("initVeleroSchedules() error = %v, want %v", err, errInvalidVeleroSchedule)
+			}
 
-	unstructuredScheme := runtime.NewScheme()
-	err = chnv1.AddToScheme(unstructuredScheme)
-	if err != nil {
-		t.Errorf("Error adding api to scheme: %s", err.Error())
+			veleroSchedules := veleroapi.ScheduleList{}
+			if err := fakeClient.List(context.Background(), &veleroSchedules); err != nil {
+				t.Fatalf("Error listing velero schedules: %s", err.Error())
+			}
+			if len(veleroSchedules.Items) != 0 {
+				t.Errorf("initVeleroSchedules() created %d velero schedules, want 0", len(veleroSchedules.Items))
+			}
+		})
 	}
+}
 
-	dynClient := dynamicfake.NewSimpleDynamicClient(unstructuredScheme, res_local_ns)
-
-	targetGVK := schema.GroupVersionKind{
-		Group:   "apps.open-cluster-management.io",
-		Version: "v1",
-		Kind:    "Channel",
+func Test_updateLastReconcileTime(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := backupv1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	targetGVR := targetGVK.GroupVersion().WithResource("channel")
 
-	resInterface := dynClient.Resource(targetGVR)
+	lastReconcile := metav1.NewTime(time.Now().Add(-time.Hour))
+	backupSchedule := createBackupSchedule("acm", "ns").object
+	backupSchedule.Status.LastReconcileTime = &lastReconcile
 
-	sch := *createBackupSchedule("name", "ns").
-		schedule("0 */6 * * *").object
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(backupSchedule).
+		WithStatusSubresource(backupSchedule).
+		Build()
+	r := &BackupScheduleReconciler{Client: fakeClient, Scheme: scheme1}
 
-	sch_msa := *createBackupSchedule("name", "ns").
-		useManagedServiceAccount(true).
-		schedule("0 */6 * * *").object
+	r.updateLastReconcileTime(context.Background(), backupSchedule)
 
-	// create resources which should be found
-	_, err = resInterface.Namespace("default").Create(context.Background(), res_local_ns, v1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("Error creating resource: %s", err.Error())
+	updated := &backupv1beta1.BackupSchedule{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(backupSchedule), updated); err != nil {
+		t.Fatalf("Error getting BackupSchedule: %s", err.Error())
 	}
-
-	fakeDiscovery := discoveryclient.NewDiscoveryClientForConfigOrDie(
-		&restclient.Config{Host: server.URL},
-	)
-	m := restmapper.NewDeferredDiscoveryRESTMapper(
-		memory.NewMemCacheClient(fakeDiscovery),
-	)
-	type args struct {
-		ctx            context.Context
-		c              client.Client
-		backupSchedule *v1beta1.BackupSchedule
-		mapper         *restmapper.DeferredDiscoveryRESTMapper
+	if updated.Status.LastReconcileTime == nil ||
+		!updated.Status.LastReconcileTime.After(lastReconcile.Time) {
+		t.Errorf("updateLastReconcileTime() LastReconcileTime = %v, should be after %v",
+			updated.Status.LastReconcileTime, lastReconcile)
 	}
+
+	// BackupSchedule not found, nothing to update
+	r.updateLastReconcileTime(context.Background(), &backupv1beta1.BackupSchedule{})
+}
+
+func Test_isScheduleSpecUpdated_condition(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name        string
+		schedules   *veleroapi.ScheduleList
+		cron        string
+		ttl         time.Duration
+		wantStatus  metav1.ConditionStatus
+		wantMessage []string
 	}{
 		{
-			name: "no CRD found for MSA, but MSA not enabled",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				mapper:         m,
-				backupSchedule: &sch,
+			name: "spec not changed",
+			schedules: &veleroapi.ScheduleList{
+				Items: []veleroapi.Schedule{
+					*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").
+						schedule("0 6 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).object,
+				},
 			},
-			want: true,
+			cron:       "0 6 * * *",
+			ttl:        time.Hour * 1,
+			wantStatus: metav1.ConditionFalse,
 		},
 		{
-			name: "no CRD found for MSA, MSA enabled",
-			args: args{
-				ctx:            context.Background(),
-				c:              k8sClient1,
-				mapper:         m,
-				backupSchedule: &sch_msa,
+			name: "ttl changed",
+			schedules: &veleroapi.ScheduleList{
+				Items: []veleroapi.Schedule{
+					*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").
+						schedule("0 6 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).object,
+				},
+			},
+			cron:       "0 6 * * *",
+			ttl:        time.Hour * 2,
+			wantStatus: metav1.ConditionTrue,
+			wantMessage: []string{
+				veleroScheduleNames[ResourcesGeneric] + " (ttl 1h0m0s -> 2h0m0s)",
+			},
+		},
+		{
+			name: "cron changed",
+			schedules: &veleroapi.ScheduleList{
+				Items: []veleroapi.Schedule{
+					*createSchedule(veleroScheduleNames[ResourcesGeneric], "ns").
+						schedule("0 6 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).object,
+					*createSchedule(veleroScheduleNames[ManagedClusters], "ns").
+						schedule("0 6 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).object,
+				},
+			},
+			cron:       "0 8 * * *",
+			ttl:        time.Hour * 1,
+			wantStatus: metav1.ConditionTrue,
+			wantMessage: []string{
+				veleroScheduleNames[ResourcesGeneric] + " (cron 0 6 * * * -> 0 8 * * *)",
+				veleroScheduleNames[ManagedClusters] + " (cron 0 6 * * * -> 0 8 * * *)",
 			},
-			want: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if _, got, _ := verifyMSAOption(tt.args.ctx,
-				tt.args.c,
-				tt.args.mapper,
-				tt.args.backupSchedule,
-			); got != tt.want {
-				t.Errorf("verifyMSAOption() = %v, want %v", got,
-					tt.want)
+			backupSchedule := createBackupSchedule("name", "ns").
+				schedule(tt.cron).
+				veleroTTL(metav1.Duration{Duration: tt.ttl}).
+				object
+			isScheduleSpecUpdated(tt.schedules, backupSchedule)
+
+			condition := meta.FindStatusCondition(backupSchedule.Status.Conditions,
+				v1beta1.ScheduleConditionSchedulesUpdated)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Fatalf("isScheduleSpecUpdated() %s condition = %v, want %v",
+					v1beta1.ScheduleConditionSchedulesUpdated, condition, tt.wantStatus)
+			}
+			for _, msg := range tt.wantMessage {
+				if !strings.Contains(condition.Message, msg) {
+					t.Errorf("isScheduleSpecUpdated() condition message %q should contain %q",
+						condition.Message, msg)
+				}
 			}
 		})
 	}
-
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
-	}
 }
 
-func Test_scheduleOwnsLatestStorageBackups(t *testing.T) {
-	veleroNamespaceName := "default"
-
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
+func Test_isVeleroSchedulesUpdateRequired_ttl(t *testing.T) {
 	scheme1 := runtime.NewScheme()
-	err = veleroapi.AddToScheme(scheme1)
-	if err != nil {
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme1})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	velero_schedule := *createSchedule(veleroScheduleNames[Resources], veleroNamespaceName).
-		scheduleLabels(map[string]string{BackupScheduleClusterLabel: "cls"}).
-		object
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		wantUpdated bool
+	}{
+		{
+			name:        "ttl not changed, schedule not updated",
+			ttl:         time.Hour * 1,
+			wantUpdated: false,
+		},
+		{
+			name:        "ttl changed, schedule updated in place",
+			ttl:         time.Hour * 2,
+			wantUpdated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroSchedule := createSchedule(veleroScheduleNames[Credentials], "ns").
+				schedule("0 6 * * *").ttl(metav1.Duration{Duration: time.Hour * 1}).
+				orLabelSelectors(getCredentialsOrLabelSelectors(nil)).object
+			veleroSchedule.UID = "schedule-uid"
+			backupSchedule := createBackupSchedule("acm-schedule", "ns").
+				schedule("0 6 * * *").
+				veleroTTL(metav1.Duration{Duration: tt.ttl}).
+				object
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(veleroSchedule, backupSchedule).
+				WithStatusSubresource(&v1beta1.BackupSchedule{}).
+				Build()
+
+			veleroScheduleList := veleroapi.ScheduleList{}
+			if err := fakeClient.List(context.Background(), &veleroScheduleList); err != nil {
+				t.Fatalf("Error listing schedules: %s", err.Error())
+			}
+			resourceVersion := veleroScheduleList.Items[0].ResourceVersion
 
-	aFewSecondsAgo := metav1.NewTime(time.Now().Add(-2 * time.Second))
-	anHourAgo := metav1.NewTime(time.Now().Add(-1 * time.Hour))
+			_, updated, err := isVeleroSchedulesUpdateRequired(context.Background(), fakeClient,
+				nil, veleroScheduleList, backupSchedule)
+			if err != nil {
+				t.Errorf("isVeleroSchedulesUpdateRequired() error = %v", err)
+			}
+			if updated != tt.wantUpdated {
+				t.Errorf("isVeleroSchedulesUpdateRequired() = %v, want %v", updated, tt.wantUpdated)
+			}
 
-	type args struct {
-		ctx      context.Context
-		c        client.Client
-		schedule *veleroapi.Schedule
+			// the schedule is not recreated, it keeps its uid and has the new ttl
+			got := veleroapi.Schedule{}
+			if err := fakeClient.Get(context.Background(),
+				client.ObjectKeyFromObject(veleroSchedule), &got); err != nil {
+				t.Fatalf("Error getting schedule: %s", err.Error())
+			}
+			if got.UID != veleroSchedule.UID {
+				t.Errorf("isVeleroSchedulesUpdateRequired() schedule uid = %v, want %v",
+					got.UID, veleroSchedule.UID)
+			}
+			if got.Spec.Template.TTL.Duration != tt.ttl {
+				t.Errorf("isVeleroSchedulesUpdateRequired() schedule ttl = %v, want %v",
+					got.Spec.Template.TTL.Duration, tt.ttl)
+			}
+			if (got.ResourceVersion != resourceVersion) != tt.wantUpdated {
+				t.Errorf("isVeleroSchedulesUpdateRequired() schedule resourceVersion %s -> %s, want updated %v",
+					resourceVersion, got.ResourceVersion, tt.wantUpdated)
+			}
+		})
 	}
+}
+
+func Test_isVeleroScheduleUpdated(t *testing.T) {
 	tests := []struct {
 		name      string
-		args      args
+		oldObj    *veleroapi.Schedule
+		newObj    *veleroapi.Schedule
 		want      bool
-		resources []*veleroapi.Backup
+		wantPhase v1beta1.SchedulePhase
 	}{
 		{
-			name: "no crd",
-			args: args{
-				ctx:      context.Background(),
-				c:        k8sClient1,
-				schedule: &velero_schedule,
-			},
+			name:   "last backup time changed",
+			oldObj: createSchedule("acm-resources-schedule", "ns").phase(veleroapi.SchedulePhaseEnabled).object,
+			newObj: createSchedule("acm-resources-schedule", "ns").
+				phase(veleroapi.SchedulePhaseEnabled).lastBackup(metav1.Now()).object,
+			want:      false,
+			wantPhase: v1beta1.SchedulePhaseEnabled,
+		},
+		{
+			name:      "velero schedule failed validation after creation",
+			oldObj:    createSchedule("acm-resources-schedule", "ns").phase(veleroapi.SchedulePhaseNew).object,
+			newObj:    createSchedule("acm-resources-schedule", "ns").phase(veleroapi.SchedulePhaseFailedValidation).object,
 			want:      true,
-			resources: []*veleroapi.Backup{},
+			wantPhase: v1beta1.SchedulePhaseFailedValidation,
+		},
+		{
+			name:   "validation errors changed",
+			oldObj: createSchedule("acm-resources-schedule", "ns").phase(veleroapi.SchedulePhaseFailedValidation).object,
+			newObj: func() *veleroapi.Schedule {
+				schedule := createSchedule("acm-resources-schedule", "ns").
+					phase(veleroapi.SchedulePhaseFailedValidation).object
+				schedule.Status.ValidationErrors = []string{"invalid schedule"}
+				return schedule
+			}(),
+			want:      true,
+			wantPhase: v1beta1.SchedulePhaseFailedValidation,
 		},
 		{
-			name: "no backups",
-			args: args{
-				ctx:      context.Background(),
-				c:        k8sClient1,
-				schedule: &velero_schedule,
-			},
-			want:      true,
-			resources: []*veleroapi.Backup{},
+			name:   "velero schedule spec changed",
+			oldObj: createSchedule("acm-resources-schedule", "ns").phase(veleroapi.SchedulePhaseEnabled).object,
+			newObj: func() *veleroapi.Schedule {
+				schedule := createSchedule("acm-resources-schedule", "ns").
+					phase(veleroapi.SchedulePhaseEnabled).object
+				schedule.Generation = 2
+				return schedule
+			}(),
+			want:      true,
+			wantPhase: v1beta1.SchedulePhaseEnabled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isVeleroScheduleUpdated(event.UpdateEvent{
+				ObjectOld: tt.oldObj,
+				ObjectNew: tt.newObj,
+			}); got != tt.want {
+				t.Errorf("isVeleroScheduleUpdated() = %v, want %v", got, tt.want)
+			}
+
+			// the reconcile triggered by the update recomputes the BackupSchedule phase
+			backupSchedule := createBackupSchedule("acm-schedule", "ns").
+				phase(v1beta1.SchedulePhaseEnabled).object
+			if got := setSchedulePhase(&veleroapi.ScheduleList{
+				Items: []veleroapi.Schedule{*tt.newObj},
+			}, backupSchedule); got != tt.wantPhase {
+				t.Errorf("setSchedulePhase() = %v, want %v", got, tt.wantPhase)
+			}
+		})
+	}
+}
+
+func Test_deleteVeleroSchedules_partialFailure(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	defaultBackoff := veleroScheduleDeleteBackoff
+	veleroScheduleDeleteBackoff.Duration = time.Millisecond
+	defer func() { veleroScheduleDeleteBackoff = defaultBackoff }()
+
+	ns := "velero-ns"
+	scheduleNames := []string{"acm-credentials-schedule", "acm-managed-clusters-schedule", "acm-resources-schedule"}
+
+	tests := []struct {
+		name          string
+		failing       map[string]bool
+		wantErr       bool
+		wantRemaining []string
+		wantReset     bool
+	}{
+		{
+			name:          "all schedules deleted",
+			failing:       map[string]bool{},
+			wantErr:       false,
+			wantRemaining: []string{},
+			wantReset:     true,
 		},
 		{
-			name: "has backups, different cluster version",
-			args: args{
-				ctx:      context.Background(),
-				c:        k8sClient1,
-				schedule: &velero_schedule,
-			},
-			want: false,
-			resources: []*veleroapi.Backup{
-				createBackup(veleroScheduleNames[Resources]+"-1", veleroNamespaceName).
-					startTimestamp(anHourAgo).errors(0).
-					labels(map[string]string{
-						BackupScheduleClusterLabel: "abcd",
-						BackupVeleroLabel:          veleroScheduleNames[Resources],
-					}).
-					object,
-			},
+			name:          "one delete fails, the others are deleted",
+			failing:       map[string]bool{"acm-managed-clusters-schedule": true},
+			wantErr:       false,
+			wantRemaining: []string{"acm-managed-clusters-schedule"},
+			wantReset:     false,
 		},
 		{
-			name: "has backups, same cluster version",
-			args: args{
-				ctx:      context.Background(),
-				c:        k8sClient1,
-				schedule: &velero_schedule,
-			},
-			want: true,
-			resources: []*veleroapi.Backup{
-				createBackup(veleroScheduleNames[Resources]+"-2", veleroNamespaceName).
-					startTimestamp(aFewSecondsAgo).errors(0).
-					labels(map[string]string{
-						BackupScheduleClusterLabel: "cls",
-						BackupVeleroLabel:          veleroScheduleNames[Resources],
-					}).
-					object,
+			name: "all deletes fail",
+			failing: map[string]bool{
+				"acm-credentials-schedule":      true,
+				"acm-managed-clusters-schedule": true,
+				"acm-resources-schedule":        true,
 			},
+			wantErr:       true,
+			wantRemaining: scheduleNames,
+			wantReset:     false,
 		},
 	}
 	for _, tt := range tests {
-		for i := range tt.resources {
-			if err := k8sClient1.Create(tt.args.ctx, tt.resources[i]); err != nil {
-				t.Errorf("failed to create %s", err.Error())
+		t.Run(tt.name, func(t *testing.T) {
+			schedules := &veleroapi.ScheduleList{}
+			objects := []client.Object{}
+			for _, name := range scheduleNames {
+				schedule := createSchedule(name, ns).object
+				schedules.Items = append(schedules.Items, *schedule)
+				objects = append(objects, schedule)
 			}
-		}
+			deleteCalls := map[string]int{}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(objects...).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Delete: func(ctx context.Context, c client.WithWatch, obj client.Object,
+						opts ...client.DeleteOption,
+					) error {
+						deleteCalls[obj.GetName()]++
+						if tt.failing[obj.GetName()] {
+							return errors.New("delete failed")
+						}
+						return c.Delete(ctx, obj, opts...)
+					},
+				}).
+				Build()
 
-		t.Run(tt.name, func(t *testing.T) {
-			if got, _, err := scheduleOwnsLatestStorageBackups(tt.args.ctx, tt.args.c,
-				tt.args.schedule); got != tt.want || err != nil {
-				t.Errorf("scheduleOwnsLatestStorageBackups() = got %v, want %v, err %v", got, tt.want, err)
+			backupSchedule := createBackupSchedule("acm-schedule", ns).
+				phase(v1beta1.SchedulePhaseEnabled).object
+			backupSchedule.Status.VeleroScheduleNames = scheduleNames
+
+			deletedCounter := veleroSchedulesDeleted.WithLabelValues(ns, "acm-schedule")
+			deletedCount := testutil.ToFloat64(deletedCounter)
+
+			err := deleteVeleroSchedules(context.Background(), fakeClient, backupSchedule, schedules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("deleteVeleroSchedules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			wantDeleted := deletedCount + float64(len(scheduleNames)-len(tt.failing))
+			if got := testutil.ToFloat64(deletedCounter); got != wantDeleted {
+				t.Errorf("acm_backup_velero_schedules_deleted_total = %v, want %v", got, wantDeleted)
 			}
-		})
 
-	}
-	// clean up
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
+			remaining := &veleroapi.ScheduleList{}
+			if err := fakeClient.List(context.Background(), remaining, client.InNamespace(ns)); err != nil {
+				t.Fatalf("Error listing schedules: %s", err.Error())
+			}
+			remainingNames := []string{}
+			for i := range remaining.Items {
+				remainingNames = append(remainingNames, remaining.Items[i].Name)
+			}
+			sort.Strings(remainingNames)
+			if !reflect.DeepEqual(remainingNames, tt.wantRemaining) {
+				t.Errorf("deleteVeleroSchedules() remaining schedules = %v, want %v", remainingNames, tt.wantRemaining)
+			}
+
+			for name := range tt.failing {
+				if deleteCalls[name] != veleroScheduleDeleteBackoff.Steps {
+					t.Errorf("deleteVeleroSchedules() delete attempts for %s = %d, want %d",
+						name, deleteCalls[name], veleroScheduleDeleteBackoff.Steps)
+				}
+			}
+
+			reset := backupSchedule.Status.Phase == v1beta1.SchedulePhaseNew &&
+				backupSchedule.Status.VeleroScheduleNames == nil
+			if reset != tt.wantReset {
+				t.Errorf("deleteVeleroSchedules() status reset = %v, want %v", reset, tt.wantReset)
+			}
+		})
 	}
 }
 
-func Test_isRestoreHubAfterSchedule(t *testing.T) {
-	testEnv := &envtest.Environment{
-		CRDDirectoryPaths: []string{
-			filepath.Join("..", "config", "crd", "bases"),
-			filepath.Join("..", "hack", "crds"),
-		},
-		ErrorIfCRDPathMissing: true,
-	}
-	cfg, err := testEnv.Start()
-	if err != nil {
-		t.Fatalf("Error starting testEnv: %s", err.Error())
-	}
-	scheme2 := runtime.NewScheme()
-	err = corev1.AddToScheme(scheme2)
-	if err != nil {
-		t.Fatalf("Error adding api to scheme: %s", err.Error())
-	}
-	err = backupv1beta1.AddToScheme(scheme2)
-	if err != nil {
+func Test_ExportImportSchedules(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = ocinfrav1.AddToScheme(scheme2)
-	if err != nil {
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
-	err = veleroapi.AddToScheme(scheme2)
-	if err != nil {
+	if err := ocinfrav1.AddToScheme(scheme1); err != nil {
 		t.Fatalf("Error adding api to scheme: %s", err.Error())
 	}
 
-	k8sClient1, err := client.New(cfg, client.Options{Scheme: scheme2})
-	if err != nil {
-		t.Fatalf("Error starting client: %s", err.Error())
+	scheduleLabels := func(clusterID string) map[string]string {
+		return map[string]string{
+			BackupScheduleNameLabel:    "acm-schedule",
+			BackupScheduleClusterLabel: clusterID,
+			BackupScheduleTypeLabel:    string(Resources),
+		}
 	}
-
-	veleroNamespaceName := "backup-ns"
-	veleroNamespace := *createNamespace(veleroNamespaceName)
-	err = k8sClient1.Create(context.Background(), &veleroNamespace)
+	sourceObjects := []client.Object{
+		createClusterVersion("version", "source-hub", nil),
+		createSchedule(veleroScheduleNames[Resources], "source-ns").
+			scheduleLabels(scheduleLabels("source-hub")).
+			schedule("0 6 * * *").
+			ttl(metav1.Duration{Duration: time.Hour * 72}).
+			templateLabels(map[string]string{"app": "acm"}).
+			excludedNamespaces([]string{"local-cluster"}).
+			phase(veleroapi.SchedulePhaseEnabled).
+			lastBackup(metav1.NewTime(time.Now())).
+			object,
+		createSchedule(veleroScheduleNames[Credentials], "source-ns").
+			scheduleLabels(scheduleLabels("source-hub")).
+			schedule("0 6 * * *").
+			paused(true).
+			object,
+		// not created by a BackupSchedule, not exported
+		createSchedule("user-schedule", "source-ns").
+			schedule("0 8 * * *").
+			object,
+		// created in another namespace, not exported
+		createSchedule(veleroScheduleNames[ManagedClusters], "other-ns").
+			scheduleLabels(scheduleLabels("source-hub")).
+			schedule("0 6 * * *").
+			object,
+	}
+	sourceClient := fake.NewClientBuilder().
+		WithScheme(scheme1).
+		WithObjects(sourceObjects...).
+		Build()
+
+	manifest, err := ExportSchedules(context.Background(), sourceClient, "source-ns")
 	if err != nil {
-		t.Fatalf("Error creating ns: %s", err.Error())
+		t.Fatalf("ExportSchedules() error = %v", err)
 	}
-
-	crWithVersion := createClusterVersion("version", "cluster1", nil)
-	if err := k8sClient1.Create(context.Background(), crWithVersion); err != nil {
-		t.Fatalf("cannot create cluster version  %s ", err.Error())
+	for _, notExpected := range []string{"user-schedule", "source-hub", "resourceVersion", "phase"} {
+		if strings.Contains(string(manifest), notExpected) {
+			t.Errorf("ExportSchedules() manifest contains %s\n%s", notExpected, manifest)
+		}
 	}
 
-	type args struct {
-		ctx                         context.Context
-		c                           client.Client
-		veleroSchedule              *veleroapi.Schedule
-		acmClusterActivationBackups []*veleroapi.Backup
-		createScheduleFirst         bool
-		sleepTime                   int
-	}
 	tests := []struct {
-		name string
-		args args
-		want bool
+		name          string
+		objects       []client.Object
+		wantClusterID string
+		wantOwner     bool
 	}{
 		{
-			name: "no collision, list backup fails",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
-					object,
-				acmClusterActivationBackups: []*veleroapi.Backup{},
-				createScheduleFirst:         true,
-				sleepTime:                   2,
-			},
-			want: false,
-		},
-		{
-			name: "no collision, no backup restore found",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
-					object,
-				acmClusterActivationBackups: []*veleroapi.Backup{},
-				createScheduleFirst:         true,
-				sleepTime:                   2,
-			},
-			want: false,
-		},
-		{
-			name: "collision, backup schedule created first, before restore",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
-					object,
-				acmClusterActivationBackups: []*veleroapi.Backup{createBackup("acm-restore-clusters-2", veleroNamespaceName).
-					labels(map[string]string{
-						BackupScheduleClusterLabel: "cluster1",
-						RestoreClusterLabel:        "cluster2",
-					}).
-					phase(veleroapi.BackupPhaseCompleted).
-					object},
-				createScheduleFirst: true,
-				sleepTime:           2,
-			},
-			want: true,
-		},
-		{
-			name: "no collision, backup schedule created first, before restore,  but on the same hub",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
-					object,
-				acmClusterActivationBackups: []*veleroapi.Backup{createBackup("acm-restore-clusters-1", veleroNamespaceName).
-					labels(map[string]string{
-						BackupScheduleClusterLabel: "cluster1",
-						RestoreClusterLabel:        "cluster1",
-					}).
-					phase(veleroapi.BackupPhaseCompleted).
-					object},
-				createScheduleFirst: true,
-				sleepTime:           2,
+			name: "import on another hub with the BackupSchedule",
+			objects: []client.Object{
+				createClusterVersion("version", "target-hub", nil),
+				createBackupSchedule("acm-schedule", "target-ns").object,
 			},
-			want: false,
+			wantClusterID: "target-hub",
+			wantOwner:     true,
 		},
 		{
-			name: "no collision, backup schedule created after restore",
-			args: args{
-				ctx: context.Background(),
-				c:   k8sClient1,
-				veleroSchedule: createSchedule("acm-backup-schedule", veleroNamespaceName).
-					object,
-				acmClusterActivationBackups: []*veleroapi.Backup{createBackup("acm-restore-clusters-2", veleroNamespaceName).
-					labels(map[string]string{
-						BackupScheduleClusterLabel: "cluster1",
-						RestoreClusterLabel:        "cluster2",
-					}).
-					phase(veleroapi.BackupPhaseCompleted).
-					object},
-				createScheduleFirst: false,
-				sleepTime:           2,
-			},
-			want: false,
+			name:          "import with no BackupSchedule and no cluster version",
+			objects:       []client.Object{},
+			wantClusterID: "unknown",
+			wantOwner:     false,
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithObjects(tt.objects...).
+				Build()
 
-	for idx, tt := range tests {
-
-		if idx > 0 {
-			err := veleroapi.AddToScheme(scheme2)
-			if err != nil {
-				t.Errorf("Error adding api to scheme: %s", err.Error())
+			if err := ImportSchedules(context.Background(), targetClient, "target-ns", manifest); err != nil {
+				t.Fatalf("ImportSchedules() error = %v", err)
 			}
-		}
 
-		if tt.args.createScheduleFirst {
-			err := k8sClient1.Create(tt.args.ctx, tt.args.veleroSchedule)
-			if err != nil {
-				t.Errorf("Error creating velero schedule: %s", err.Error())
+			imported := veleroapi.ScheduleList{}
+			if err := targetClient.List(context.Background(), &imported,
+				client.InNamespace("target-ns")); err != nil {
+				t.Fatalf("Error listing schedules: %s", err.Error())
 			}
-			time.Sleep(time.Duration(tt.args.sleepTime) * time.Second)
-			for i := range tt.args.acmClusterActivationBackups {
-				err := k8sClient1.Create(tt.args.ctx, tt.args.acmClusterActivationBackups[i])
-				if err != nil {
-					t.Errorf("Error creating velero backup: %s", err.Error())
-				}
+			if len(imported.Items) != 2 {
+				t.Fatalf("ImportSchedules() created %d schedules, want 2", len(imported.Items))
 			}
-
-		} else {
-			for i := range tt.args.acmClusterActivationBackups {
-				err := k8sClient1.Create(tt.args.ctx, tt.args.acmClusterActivationBackups[i])
-				if err != nil {
-					t.Errorf("Error creating velero backup: %s", err.Error())
+			for i := range imported.Items {
+				got := imported.Items[i]
+				source := veleroapi.Schedule{}
+				if err := sourceClient.Get(context.Background(), types.NamespacedName{
+					Name: got.Name, Namespace: "source-ns"}, &source); err != nil {
+					t.Fatalf("Error getting source schedule %s: %s", got.Name, err.Error())
+				}
+				if !reflect.DeepEqual(got.Spec, source.Spec) {
+					t.Errorf("schedule %s spec = %v, want %v", got.Name, got.Spec, source.Spec)
+				}
+				if !reflect.DeepEqual(got.Labels, scheduleLabels(tt.wantClusterID)) {
+					t.Errorf("schedule %s labels = %v, want %v", got.Name, got.Labels,
+						scheduleLabels(tt.wantClusterID))
+				}
+				if got.Status.Phase != "" {
+					t.Errorf("schedule %s status phase = %s, want empty", got.Name, got.Status.Phase)
+				}
+				hasOwner := len(got.OwnerReferences) == 1 &&
+					got.OwnerReferences[0].Kind == "BackupSchedule" &&
+					got.OwnerReferences[0].Name == "acm-schedule"
+				if hasOwner != tt.wantOwner {
+					t.Errorf("schedule %s owner references = %v, want owner %v",
+						got.Name, got.OwnerReferences, tt.wantOwner)
 				}
 			}
-			time.Sleep(time.Duration(tt.args.sleepTime) * time.Second)
-			err := k8sClient1.Create(tt.args.ctx, tt.args.veleroSchedule)
+
+			// exporting the imported schedules returns the same manifest
+			reexported, err := ExportSchedules(context.Background(), targetClient, "target-ns")
 			if err != nil {
-				t.Errorf("Error creating velero schedule: %s", err.Error())
+				t.Fatalf("ExportSchedules() error = %v", err)
 			}
-
-		}
-
-		t.Run(tt.name, func(t *testing.T) {
-			if got, _ := isRestoreHubAfterSchedule(tt.args.ctx, tt.args.c,
-				tt.args.veleroSchedule); got != tt.want {
-				t.Errorf("isRestoreHubAfterSchedule() = %v, want %v ", got, tt.want)
+			if string(reexported) != string(manifest) {
+				t.Errorf("ExportSchedules() after import = \n%s\nwant\n%s", reexported, manifest)
 			}
-		})
 
-		// clean up after the test is run
-		err := k8sClient1.Delete(tt.args.ctx, tt.args.veleroSchedule)
-		if err != nil {
-			t.Errorf("Error deleting velero schedule: %s", err.Error())
-		}
-		for i := range tt.args.acmClusterActivationBackups {
-			err := k8sClient1.Delete(tt.args.ctx, tt.args.acmClusterActivationBackups[i])
-			if err != nil {
-				t.Errorf("Error deleting velero backup: %s", err.Error())
+			// the schedules already exist
+			if err := ImportSchedules(context.Background(), targetClient, "target-ns", manifest); err == nil {
+				t.Errorf("ImportSchedules() expected error for existing schedules")
 			}
-		}
+		})
 	}
 
-	if err := testEnv.Stop(); err != nil {
-		t.Fatalf("Error stopping testenv: %s", err.Error())
+	if err := ImportSchedules(context.Background(), sourceClient, "target-ns",
+		[]byte("items: [{unknown: value}]")); err == nil {
+		t.Errorf("ImportSchedules() expected error for an invalid manifest")
 	}
 }
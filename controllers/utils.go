@@ -18,7 +18,10 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -35,6 +38,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/discovery"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -45,8 +49,35 @@ import (
 const (
 	localClusterLabel  = "local-cluster"
 	managedClusterKind = "ManagedCluster"
+	namespaceKind      = "Namespace"
+
+	// OCMManagedClusterNamespaceLabelKey is the label set by OCM on the managed cluster namespaces
+	OCMManagedClusterNamespaceLabelKey = "cluster.open-cluster-management.io/managedCluster"
+
+	// env variable set to the namespace where the operator is running
+	operatorNamespaceEnv = "POD_NAMESPACE"
+
+	// max length of the generated velero restore name
+	maxKsRestoreNameLength = 252
+	// length of the hash suffix appended to truncated velero restore names
+	restoreNameHashLength = 8
 )
 
+// file storing the namespace of the pod service account
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// returns the namespace where the operator is running, read from the POD_NAMESPACE
+// env variable or from the service account namespace file; "" if not found
+func getOperatorNamespace() string {
+	if ns := os.Getenv(operatorNamespaceEnv); ns != "" {
+		return ns
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
+
 func findSuffix(slice []string, val string) (int, bool) {
 	for i, item := range slice {
 		if strings.HasSuffix(val, item) {
@@ -115,13 +146,35 @@ func sortCompare(a, b []string) bool {
 }
 
 // returns a valid name for the velero restore kubernetes resource
-// by trimming the concatenated cluster restore and backup names
+// by concatenating the cluster restore and backup names;
+// if the name is too long, it is truncated and a short hash of the full name
+// is appended, so the result is unique and always the same for the same input
 func getValidKsRestoreName(clusterRestoreName string, backupName string) string {
 	// max name for ns or resources is 253 chars
 	fullName := clusterRestoreName + "-" + backupName
 
-	if len(fullName) > 252 {
-		return fullName[:252]
+	if len(fullName) > maxKsRestoreNameLength {
+		hash := sha256.Sum256([]byte(fullName))
+		suffix := hex.EncodeToString(hash[:])[:restoreNameHashLength]
+		prefix := strings.TrimRight(
+			fullName[:maxKsRestoreNameLength-restoreNameHashLength-1], "-.")
+		return prefix + "-" + suffix
+	}
+	return fullName
+}
+
+// returns the name of the sandbox namespace used to restore the resources
+// from the given namespace, <prefix>-<namespace>;
+// if the name is too long, it is truncated and a short hash of the full name is appended
+func getSandboxNamespaceName(prefix string, namespace string) string {
+	fullName := prefix + "-" + namespace
+
+	if len(fullName) > validation.DNS1123LabelMaxLength {
+		hash := sha256.Sum256([]byte(fullName))
+		suffix := hex.EncodeToString(hash[:])[:restoreNameHashLength]
+		truncated := strings.TrimRight(
+			fullName[:validation.DNS1123LabelMaxLength-restoreNameHashLength-1], "-")
+		return truncated + "-" + suffix
 	}
 	return fullName
 }
@@ -170,6 +223,21 @@ func isValidStorageLocationDefined(
 	return isValidStorageLocation
 }
 
+// returns true if the storage location with the given name exists in the namespace and is available;
+// the storage location doesn't need to be owned by the velero or OADP operator
+func isStorageLocationAvailable(
+	veleroStorageLocations []veleroapi.BackupStorageLocation,
+	namespace string,
+	name string,
+) bool {
+	for i := range veleroStorageLocations {
+		if veleroStorageLocations[i].Namespace == namespace && veleroStorageLocations[i].Name == name {
+			return veleroStorageLocations[i].Status.Phase == veleroapi.BackupStorageLocationPhaseAvailable
+		}
+	}
+	return false
+}
+
 // having a resourceKind.resourceGroup string, return (resourceKind, resourceGroup)
 func getResourceDetails(resourceName string) (string, string) {
 	indexOfName := strings.Index(resourceName, ".")
@@ -471,8 +539,29 @@ func addRestoreLabelSelector(
 	}
 }
 
-// update BackupSchedule status and remove velero schedules
-// when the BackupSchedule is paused or in BackupCollision
+// pause the velero schedule by setting the schedule Spec.Paused property
+// returns false if the schedule was not paused because the installed velero Schedule CRD
+// doesn't support the paused property, which is then pruned by the API server
+func pauseVeleroSchedule(
+	ctx context.Context,
+	c client.Client,
+	veleroSchedule *veleroapi.Schedule,
+) (bool, error) {
+	if veleroSchedule.Spec.Paused {
+		return true, nil
+	}
+
+	veleroSchedule.Spec.Paused = true
+	if err := c.Update(ctx, veleroSchedule); err != nil {
+		return false, err
+	}
+	return veleroSchedule.Spec.Paused, nil
+}
+
+// update BackupSchedule status and pause or remove velero schedules
+// when the BackupSchedule is paused or in BackupCollision;
+// velero schedules are paused using the schedule paused property when the BackupSchedule is paused,
+// and deleted if the paused property is not supported or the BackupSchedule is in BackupCollision
 func updateBackupSchedulePhaseWhenPaused(
 	ctx context.Context,
 	c client.Client,
@@ -490,6 +579,23 @@ func updateBackupSchedulePhaseWhenPaused(
 
 	// delete schedules, so we don't generate new backups
 	for i := range veleroScheduleList.Items {
+		if phase == v1beta1.SchedulePhasePaused {
+			paused, err := pauseVeleroSchedule(ctx, c, &veleroScheduleList.Items[i])
+			if err != nil {
+				// ignore not found errors
+				if kerrors.IsNotFound(err) {
+					continue
+				}
+				scheduleLogger.Error(err, "Failed to pause schedule "+veleroScheduleList.Items[i].Name)
+				return ctrl.Result{}, err
+			}
+			if paused {
+				scheduleLogger.Info("Schedule paused successfully " + veleroScheduleList.Items[i].Name)
+				continue
+			}
+			// the paused property is not supported by the velero Schedule CRD, delete the schedule
+		}
+
 		scheduleLogger.Info(
 			"Attempt to delete schedule " + veleroScheduleList.Items[i].Name,
 		)
@@ -513,10 +619,12 @@ func updateBackupSchedulePhaseWhenPaused(
 	// update status
 	backupSchedule.Status.Phase = phase
 	backupSchedule.Status.LastMessage = msg
+	setScheduleConditions(backupSchedule)
 
 	backupSchedule.Status.VeleroScheduleCredentials = nil
 	backupSchedule.Status.VeleroScheduleManagedClusters = nil
 	backupSchedule.Status.VeleroScheduleResources = nil
+	backupSchedule.Status.VeleroScheduleNames = nil
 
 	err := c.Status().Update(ctx, backupSchedule)
 
@@ -532,6 +640,44 @@ func hasLocalClusterLabel(obj client.Object) bool {
 	return obj.GetLabels()[localClusterLabel] == "true"
 }
 
+// returns the managed cluster namespaces, identified by the OCMManagedClusterNamespaceLabelKey label
+// or by matching a ManagedCluster name, and the managed cluster namespaces missing the label
+func getManagedClusterNamespaces(
+	ctx context.Context,
+	c client.Client,
+) ([]string, []string, error) {
+	managedClusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, managedClusters); err != nil {
+		return nil, nil, err
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaces); err != nil {
+		return nil, nil, err
+	}
+
+	clusterNames := make(map[string]bool, len(managedClusters.Items))
+	for i := range managedClusters.Items {
+		clusterNames[managedClusters.Items[i].Name] = true
+	}
+
+	clusterNamespaces := []string{}
+	unlabeledNamespaces := []string{}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		_, labeled := ns.GetLabels()[OCMManagedClusterNamespaceLabelKey]
+		if labeled || clusterNames[ns.Name] {
+			clusterNamespaces = append(clusterNamespaces, ns.Name)
+		}
+		if !labeled && clusterNames[ns.Name] {
+			unlabeledNamespaces = append(unlabeledNamespaces, ns.Name)
+		}
+	}
+	sort.Strings(clusterNamespaces)
+	sort.Strings(unlabeledNamespaces)
+
+	return clusterNamespaces, unlabeledNamespaces, nil
+}
+
 func isResourceLocalCluster(resource *unstructured.Unstructured) bool {
 	return resource.GetKind() == managedClusterKind && hasLocalClusterLabel(resource)
 }
@@ -5,7 +5,10 @@ package controllers
 
 import (
 	"context"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,9 +20,12 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 )
 
@@ -1143,6 +1149,7 @@ func Test_updateBackupSchedulePhaseWhenPaused(t *testing.T) {
 		args                    args
 		wantBackupSchedule      v1beta1.BackupSchedule
 		wantDeletedSchedules    []veleroapi.Schedule
+		wantPausedSchedules     []veleroapi.Schedule
 		wantScheduleStatusNil   bool
 		wantReturn              ctrl.Result
 		wantBackupSchedulePhase v1beta1.SchedulePhase
@@ -1242,6 +1249,8 @@ func Test_updateBackupSchedulePhaseWhenPaused(t *testing.T) {
 				object,
 			wantDeletedSchedules: []veleroapi.Schedule{
 				creds,
+			},
+			wantPausedSchedules: []veleroapi.Schedule{
 				cls,
 				res,
 			},
@@ -1366,6 +1375,17 @@ func Test_updateBackupSchedulePhaseWhenPaused(t *testing.T) {
 				}
 			}
 
+			// check schedules are paused
+			for i := range tt.wantPausedSchedules {
+				schedule := veleroapi.Schedule{}
+				if err := k8sClient1.Get(tt.args.ctx, types.NamespacedName{
+					Name:      tt.wantPausedSchedules[i].Name,
+					Namespace: tt.wantPausedSchedules[i].Namespace,
+				}, &schedule); err != nil || !schedule.Spec.Paused {
+					t.Errorf(" schedule should be found and paused = %v", tt.wantPausedSchedules[i].Name)
+				}
+			}
+
 			// first test (i=0) will not create velero schedules, it intentionally doesn't add the api to the
 			// client scheme - so no cleanup required
 			if i > 0 {
@@ -1389,3 +1409,289 @@ func Test_updateBackupSchedulePhaseWhenPaused(t *testing.T) {
 		t.Fatalf("Error stopping testenv: %s", err.Error())
 	}
 }
+
+func Test_updateBackupSchedulePhaseWhenPaused_pausedProperty(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := veleroapi.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := v1beta1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	// simulates a velero Schedule CRD with no paused property, which is pruned by the API server
+	pruneScheduleUpdate := interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if schedule, ok := obj.(*veleroapi.Schedule); ok {
+				schedule.Spec.Paused = false
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}
+
+	tests := []struct {
+		name         string
+		funcs        interceptor.Funcs
+		phase        v1beta1.SchedulePhase
+		wantPaused   bool
+		wantSchedule bool
+	}{
+		{
+			name:         "paused property supported, schedules are paused",
+			phase:        v1beta1.SchedulePhasePaused,
+			wantPaused:   true,
+			wantSchedule: true,
+		},
+		{
+			name:         "paused property not supported, schedules are deleted",
+			funcs:        pruneScheduleUpdate,
+			phase:        v1beta1.SchedulePhasePaused,
+			wantSchedule: false,
+		},
+		{
+			name:         "backup collision, schedules are deleted",
+			phase:        v1beta1.SchedulePhaseBackupCollision,
+			wantSchedule: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			veleroScheduleList := veleroapi.ScheduleList{
+				Items: []veleroapi.Schedule{
+					*createSchedule(veleroScheduleNames[Credentials], "default").object,
+					*createSchedule(veleroScheduleNames[Resources], "default").object,
+				},
+			}
+			backupSchedule := createBackupSchedule("acm-schedule", "default").
+				paused(tt.phase == v1beta1.SchedulePhasePaused).object
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme1).
+				WithStatusSubresource(&v1beta1.BackupSchedule{}).
+				WithObjects(backupSchedule, &veleroScheduleList.Items[0], &veleroScheduleList.Items[1]).
+				WithInterceptorFuncs(tt.funcs).
+				Build()
+
+			if _, err := updateBackupSchedulePhaseWhenPaused(context.Background(), fakeClient,
+				veleroScheduleList, backupSchedule, tt.phase, "msg"); err != nil {
+				t.Fatalf("updateBackupSchedulePhaseWhenPaused() error = %v", err)
+			}
+			if backupSchedule.Status.Phase != tt.phase {
+				t.Errorf("backup schedule should have phase= %v got=%v", tt.phase, backupSchedule.Status.Phase)
+			}
+
+			for i := range veleroScheduleList.Items {
+				schedule := veleroapi.Schedule{}
+				err := fakeClient.Get(context.Background(),
+					client.ObjectKeyFromObject(&veleroScheduleList.Items[i]), &schedule)
+				if (err == nil) != tt.wantSchedule {
+					t.Errorf("schedule %s found = %v, want %v", schedule.Name, err == nil, tt.wantSchedule)
+				}
+				if err == nil && schedule.Spec.Paused != tt.wantPaused {
+					t.Errorf("schedule %s paused = %v, want %v", schedule.Name, schedule.Spec.Paused, tt.wantPaused)
+				}
+			}
+		})
+	}
+}
+
+func Test_getOperatorNamespace(t *testing.T) {
+	nsFile := filepath.Join(t.TempDir(), "namespace")
+	if err := os.WriteFile(nsFile, []byte("sa-ns\n"), 0o600); err != nil {
+		t.Fatalf("Error writing namespace file: %s", err.Error())
+	}
+	defaultNsFile := serviceAccountNamespaceFile
+	defer func() { serviceAccountNamespaceFile = defaultNsFile }()
+
+	tests := []struct {
+		name   string
+		envNs  string
+		nsFile string
+		want   string
+	}{
+		{
+			name:   "namespace from env",
+			envNs:  "env-ns",
+			nsFile: nsFile,
+			want:   "env-ns",
+		},
+		{
+			name:   "namespace from service account",
+			envNs:  "",
+			nsFile: nsFile,
+			want:   "sa-ns",
+		},
+		{
+			name:   "namespace not found",
+			envNs:  "",
+			nsFile: filepath.Join(t.TempDir(), "missing"),
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(operatorNamespaceEnv, tt.envNs)
+			serviceAccountNamespaceFile = tt.nsFile
+			if got := getOperatorNamespace(); got != tt.want {
+				t.Errorf("getOperatorNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getManagedClusterNamespaces(t *testing.T) {
+	scheme1 := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+	if err := clusterv1.AddToScheme(scheme1); err != nil {
+		t.Fatalf("Error adding api to scheme: %s", err.Error())
+	}
+
+	labeledNamespace := func(name string) *corev1.Namespace {
+		ns := createNamespace(name)
+		ns.Labels = map[string]string{OCMManagedClusterNamespaceLabelKey: name}
+		return ns
+	}
+
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		wantNs        []string
+		wantUnlabeled []string
+	}{
+		{
+			name:          "no managed clusters",
+			objects:       []client.Object{createNamespace("default")},
+			wantNs:        []string{},
+			wantUnlabeled: []string{},
+		},
+		{
+			name: "labeled and unlabeled managed cluster namespaces",
+			objects: []client.Object{
+				createNamespace("default"),
+				labeledNamespace("managed1"),
+				createNamespace("managed2"),
+				// labeled, managed cluster not restored yet
+				labeledNamespace("managed3"),
+				createManagedCluster("managed1", false).object,
+				createManagedCluster("managed2", false).object,
+				// no namespace for this cluster
+				createManagedCluster("managed4", false).object,
+			},
+			wantNs:        []string{"managed1", "managed2", "managed3"},
+			wantUnlabeled: []string{"managed2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme1).WithObjects(tt.objects...).Build()
+
+			gotNs, gotUnlabeled, err := getManagedClusterNamespaces(context.Background(), fakeClient)
+			if err != nil {
+				t.Fatalf("getManagedClusterNamespaces() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotNs, tt.wantNs) {
+				t.Errorf("getManagedClusterNamespaces() namespaces = %v, want %v", gotNs, tt.wantNs)
+			}
+			if !reflect.DeepEqual(gotUnlabeled, tt.wantUnlabeled) {
+				t.Errorf("getManagedClusterNamespaces() unlabeled = %v, want %v", gotUnlabeled, tt.wantUnlabeled)
+			}
+		})
+	}
+}
+
+func Test_getValidKsRestoreName(t *testing.T) {
+	longRestoreName := strings.Repeat("restore-acm", 20)
+	longBackupName := "acm-resources-schedule-" + strings.Repeat("x", 60) + "-20210910181336"
+	otherLongBackupName := "acm-resources-schedule-" + strings.Repeat("x", 60) + "-20210910181337"
+
+	tests := []struct {
+		name        string
+		restoreName string
+		backupName  string
+		want        string
+	}{
+		{
+			name:        "short name is not changed",
+			restoreName: "restore-acm",
+			backupName:  "acm-resources-schedule-20210910181336",
+			want:        "restore-acm-acm-resources-schedule-20210910181336",
+		},
+		{
+			name:        "long restore and backup names",
+			restoreName: longRestoreName,
+			backupName:  longBackupName,
+		},
+		{
+			name:        "long restore name, truncated name ending with a dash",
+			restoreName: strings.Repeat("a", 242) + "-" + strings.Repeat("b", 20),
+			backupName:  "acm-resources-schedule-20210910181336",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getValidKsRestoreName(tt.restoreName, tt.backupName)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("getValidKsRestoreName() = %v, want %v", got, tt.want)
+			}
+			if len(got) > maxKsRestoreNameLength {
+				t.Errorf("getValidKsRestoreName() length = %v, want at most %v", len(got), maxKsRestoreNameLength)
+			}
+			if errs := validation.IsDNS1123Subdomain(got); len(errs) > 0 {
+				t.Errorf("getValidKsRestoreName() = %v is not a valid name: %v", got, errs)
+			}
+			if again := getValidKsRestoreName(tt.restoreName, tt.backupName); again != got {
+				t.Errorf("getValidKsRestoreName() is not deterministic, got %v and %v", got, again)
+			}
+		})
+	}
+
+	// truncated names for different backups must not collide
+	name1 := getValidKsRestoreName(longRestoreName, longBackupName)
+	name2 := getValidKsRestoreName(longRestoreName, otherLongBackupName)
+	if name1 == name2 {
+		t.Errorf("getValidKsRestoreName() returned the same name %v for different backups", name1)
+	}
+}
+
+func Test_getSandboxNamespaceName(t *testing.T) {
+	longNamespace := "app-" + strings.Repeat("n", 60) + "-1"
+	otherLongNamespace := "app-" + strings.Repeat("n", 60) + "-2"
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      string
+	}{
+		{
+			name:      "short name is not changed",
+			namespace: "app-ns",
+			want:      "sandbox-app-ns",
+		},
+		{
+			name:      "long namespace name",
+			namespace: longNamespace,
+		},
+		{
+			name:      "other long namespace name",
+			namespace: otherLongNamespace,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getSandboxNamespaceName("sandbox", tt.namespace)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("getSandboxNamespaceName() = %v, want %v", got, tt.want)
+			}
+			if errs := validation.IsDNS1123Label(got); len(errs) > 0 {
+				t.Errorf("getSandboxNamespaceName() = %v is not a valid name: %v", got, errs)
+			}
+			if again := getSandboxNamespaceName("sandbox", tt.namespace); again != got {
+				t.Errorf("getSandboxNamespaceName() = %v, then %v for the same namespace", got, again)
+			}
+		})
+	}
+	if getSandboxNamespaceName("sandbox", longNamespace) == getSandboxNamespaceName("sandbox", otherLongNamespace) {
+		t.Errorf("getSandboxNamespaceName() should return different names for different namespaces")
+	}
+}
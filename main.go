@@ -20,6 +20,7 @@ import (
 	"context"
 	"flag"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -36,6 +37,7 @@ import (
 	certsv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -86,6 +88,10 @@ func main() {
 	var leaseDuration time.Duration
 	var renewDeadline time.Duration
 	var retryPeriod time.Duration
+	var scheduleNamesConfigMap string
+	var defaultBackupSchedule string
+	var cleanupOrphanedRestores bool
+	var labelNewHiveSecrets bool
 
 	flag.StringVar(
 		&metricsAddr,
@@ -116,6 +122,21 @@ func main() {
 		"The duration the clients should wait between attempting acquisition and renewal "+
 		"of a leadership. This is only applicable if leader election is enabled.")
 
+	flag.StringVar(&scheduleNamesConfigMap, "schedule-names-configmap", "",
+		"The name of a ConfigMap in the operator namespace overriding the velero schedule name "+
+			"used for each resource type, and the resources backed up by the managed clusters schedule. "+
+			"If not set, the default schedule names and resources are used.")
+	flag.StringVar(&defaultBackupSchedule, "default-backup-schedule", "",
+		"The namespace/name of a BackupSchedule used as template to create a BackupSchedule "+
+			"in each namespace where a velero BackupStorageLocation is created and no BackupSchedule exists. "+
+			"If not set, no BackupSchedule is created.")
+	flag.BoolVar(&cleanupOrphanedRestores, "cleanup-orphaned-restores", false,
+		"Delete the velero restores whose owning Restore no longer exists. "+
+			"If not set, these velero restores are only reported.")
+	flag.BoolVar(&labelNewHiveSecrets, "label-new-hive-secrets", false,
+		"Set the backup label on the cluster pool secrets as soon as they are created. "+
+			"If not set, these secrets are labeled when the BackupSchedule is reconciled.")
+
 	opts := zap.Options{
 		Development: true,
 		TimeEncoder: zapcore.ISO8601TimeEncoder,
@@ -178,6 +199,11 @@ func main() {
 		break
 	}
 
+	if err := controllers.LoadVeleroScheduleConfig(context.Background(), setupClient,
+		scheduleNamesConfigMap); err != nil {
+		setupLog.Error(err, "using the default velero schedule names and backed up resources")
+	}
+
 	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
 	if err != nil {
 		setupLog.Error(err, "unable to set up discovery client")
@@ -196,6 +222,7 @@ func main() {
 		DiscoveryClient: dc,
 		DynamicClient:   dyn,
 		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("BackupSchedule controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create Schedule controller")
 		os.Exit(1)
@@ -212,10 +239,38 @@ func main() {
 		DynamicClient:   dyn,
 		Scheme:          mgr.GetScheme(),
 		Recorder:        mgr.GetEventRecorderFor("Restore controller"),
+
+		CleanupOrphanedRestores: cleanupOrphanedRestores,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create Restore controller")
 		os.Exit(1)
 	}
+	if defaultBackupSchedule != "" {
+		templateNamespace, templateName, found := strings.Cut(defaultBackupSchedule, "/")
+		if !found || templateNamespace == "" || templateName == "" {
+			setupLog.Error(nil, "invalid default-backup-schedule, expecting namespace/name",
+				"default-backup-schedule", defaultBackupSchedule)
+			os.Exit(1)
+		}
+		if err = (&controllers.DefaultBackupScheduleReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("Default BackupSchedule controller"),
+			Template: types.NamespacedName{Namespace: templateNamespace, Name: templateName},
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create default BackupSchedule controller")
+			os.Exit(1)
+		}
+	}
+	if labelNewHiveSecrets {
+		if err = (&controllers.HiveSecretReconciler{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create hive secret controller")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {